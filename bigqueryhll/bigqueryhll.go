@@ -0,0 +1,128 @@
+// Package bigqueryhll mirrors the semantics of BigQuery's HLL_COUNT functions. Sketches
+// are exchanged as bytes, in the same format BigQuery returns from HLL_COUNT.INIT and
+// HLL_COUNT.MERGE_PARTIAL, so they can be freely combined with sketches produced by
+// BigQuery. Like in BigQuery, nil sketches represent NULL and are ignored.
+//
+// The BigQuery Go client maps BYTES columns to []byte struct fields, both when
+// inserting and when reading rows, and infers a schema of type BYTES for them. Sketches
+// can be exchanged through such fields using the functions of this package, or
+// HLL.Marshal and HLL.Unmarshal:
+//
+//	type Row struct {
+//		Day      civil.Date
+//		Visitors []byte // HLL_COUNT sketch, REQUIRED BYTES
+//	}
+//
+// The bigqueryhll/bq module provides bigquery.ValueSaver and bigquery.ValueLoader
+// implementations and schema helpers, which exchange *hllplus.HLL values directly. It is
+// a separate module, so that this one does not depend on cloud.google.com/go/bigquery.
+package bigqueryhll
+
+import (
+	"fmt"
+
+	"github.com/gowthamkommineni/zetasketch/hllplus"
+)
+
+// DefaultPrecision is the default precision of HLL_COUNT.INIT.
+const DefaultPrecision = 15
+
+// Value is the set of input types supported by HLL_COUNT.INIT: INT64, STRING and BYTES.
+type Value interface {
+	~int64 | ~string | ~[]byte
+}
+
+// Init mirrors HLL_COUNT.INIT(input [, precision]). It aggregates values into a sketch
+// with the given precision, which must be between 10 and 24. A precision of 0 selects
+// the DefaultPrecision. Init returns nil when there are no values.
+func Init[T Value](values []T, precision uint8) ([]byte, error) {
+	if precision == 0 {
+		precision = DefaultPrecision
+	}
+
+	s, err := newSketch(precision)
+	if err != nil {
+		return nil, err
+	}
+	if len(values) == 0 {
+		return nil, nil
+	}
+
+	for _, v := range values {
+		hllplus.AddValue(s, v)
+	}
+	return s.Marshal()
+}
+
+// MergePartial mirrors HLL_COUNT.MERGE_PARTIAL(sketch). It merges sketches into a single
+// sketch. Sketches of different precisions are downgraded to the lowest precision, while
+// sketches of different input types cannot be merged. MergePartial returns nil when there
+// are no sketches.
+func MergePartial(sketches ...[]byte) ([]byte, error) {
+	s, err := merge(sketches)
+	if err != nil || s == nil {
+		return nil, err
+	}
+	return s.Marshal()
+}
+
+// Merge mirrors HLL_COUNT.MERGE(sketch). It returns the cardinality of the union of
+// sketches, or 0 when there are no sketches.
+func Merge(sketches ...[]byte) (int64, error) {
+	s, err := merge(sketches)
+	if err != nil || s == nil {
+		return 0, err
+	}
+	return s.Estimate(), nil
+}
+
+// Extract mirrors HLL_COUNT.EXTRACT(sketch). It returns the cardinality of a sketch, or
+// 0 for a nil sketch.
+func Extract(sketch []byte) (int64, error) {
+	if sketch == nil {
+		return 0, nil
+	}
+
+	s, err := unmarshal(sketch)
+	if err != nil {
+		return 0, err
+	}
+	return s.Estimate(), nil
+}
+
+func newSketch(precision uint8) (*hllplus.HLL, error) {
+	sparsePrecision := precision + 5
+	if sparsePrecision > hllplus.MaxSparsePrecision {
+		sparsePrecision = hllplus.MaxSparsePrecision
+	}
+	return hllplus.New(precision, sparsePrecision)
+}
+
+func merge(sketches [][]byte) (*hllplus.HLL, error) {
+	var acc *hllplus.HLL
+	for _, sketch := range sketches {
+		if sketch == nil {
+			continue
+		}
+
+		s, err := unmarshal(sketch)
+		if err != nil {
+			return nil, err
+		}
+
+		if acc == nil {
+			acc = s
+		} else if err := acc.Merge(s); err != nil {
+			return nil, err
+		}
+	}
+	return acc, nil
+}
+
+func unmarshal(sketch []byte) (*hllplus.HLL, error) {
+	s := new(hllplus.HLL)
+	if err := s.Unmarshal(sketch); err != nil {
+		return nil, fmt.Errorf("invalid sketch: %w", err)
+	}
+	return s, nil
+}
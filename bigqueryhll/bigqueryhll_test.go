@@ -0,0 +1,86 @@
+package bigqueryhll_test
+
+import (
+	"encoding/base64"
+	"testing"
+
+	"github.com/gowthamkommineni/zetasketch/bigqueryhll"
+	"github.com/gowthamkommineni/zetasketch/hllplus"
+
+	. "github.com/bsm/ginkgo"
+	. "github.com/bsm/gomega"
+)
+
+var _ = Describe("HLL_COUNT", func() {
+	decode := func(s string) []byte {
+		data, err := base64.StdEncoding.DecodeString(s)
+		Expect(err).NotTo(HaveOccurred())
+		return data
+	}
+
+	// golden sketches in the format of BigQuery's TO_BASE64(HLL_COUNT.INIT(x, precision))
+	var (
+		ints15    = decode("CHAQAxgCIASCBxIQAxgPIBQyCqz8IOqmDev+oQE=") // INT64 1, 2, 3
+		strings15 = decode("CHAQBBgCIAuCBxEQAxgPIBQyCavcB8eXCtmjIg==") // STRING foo, bar, baz, foo
+		strings12 = decode("CHAQAhgCIAuCBwwQAhgMIBEyBMsV+mU=")         // STRING bar, qux with precision 12
+	)
+
+	It("should init", func() {
+		Expect(bigqueryhll.Init([]int64{1, 2, 3}, 0)).To(Equal(ints15))
+		Expect(bigqueryhll.Init([]string{"foo", "bar", "baz", "foo"}, 15)).To(Equal(strings15))
+		Expect(bigqueryhll.Init([]string{"bar", "qux"}, 12)).To(Equal(strings12))
+
+		// STRING and BYTES hash alike
+		strs, err := bigqueryhll.Init([]string{"foo", "bar", "baz"}, 15)
+		Expect(err).NotTo(HaveOccurred())
+		Expect(bigqueryhll.Init([][]byte{[]byte("foo"), []byte("bar"), []byte("baz")}, 15)).To(Equal(strs))
+
+		Expect(bigqueryhll.Init([]int64{}, 0)).To(BeNil())
+
+		_, err = bigqueryhll.Init([]int64{1}, 9)
+		Expect(err).To(MatchError("invalid normal precision 9"))
+	})
+
+	It("should merge partial", func() {
+		merged, err := bigqueryhll.MergePartial(strings15, nil, strings12)
+		Expect(err).NotTo(HaveOccurred())
+		Expect(base64.StdEncoding.EncodeToString(merged)).To(Equal("CHAQBhgCIAuCBxIQBBgMIBEyCssV+mX5ogG7pAQ="))
+
+		// downgraded to the lowest precision
+		s := new(hllplus.HLL)
+		Expect(s.Unmarshal(merged)).To(Succeed())
+		Expect(s.Precision()).To(Equal(uint8(12)))
+		Expect(s.NumValues()).To(Equal(int64(6)))
+
+		Expect(bigqueryhll.MergePartial()).To(BeNil())
+		Expect(bigqueryhll.MergePartial(nil, nil)).To(BeNil())
+		Expect(bigqueryhll.MergePartial(ints15)).To(Equal(ints15))
+	})
+
+	It("should merge", func() {
+		Expect(bigqueryhll.Merge(strings15, nil, strings12)).To(Equal(int64(4)))
+		Expect(bigqueryhll.Merge(ints15)).To(Equal(int64(3)))
+		Expect(bigqueryhll.Merge()).To(Equal(int64(0)))
+		Expect(bigqueryhll.Merge(nil)).To(Equal(int64(0)))
+
+		_, err := bigqueryhll.Merge(ints15, strings15)
+		Expect(err).To(MatchError("cannot merge sketches with different value types INT64 and BYTES_OR_UTF8_STRING"))
+
+		_, err = bigqueryhll.Merge([]byte("bad"))
+		Expect(err).To(HaveOccurred())
+	})
+
+	It("should extract", func() {
+		Expect(bigqueryhll.Extract(ints15)).To(Equal(int64(3)))
+		Expect(bigqueryhll.Extract(strings15)).To(Equal(int64(3)))
+		Expect(bigqueryhll.Extract(nil)).To(Equal(int64(0)))
+
+		_, err := bigqueryhll.Extract([]byte("bad"))
+		Expect(err).To(HaveOccurred())
+	})
+})
+
+func TestSuite(t *testing.T) {
+	RegisterFailHandler(Fail)
+	RunSpecs(t, "zetasketch/bigqueryhll")
+}
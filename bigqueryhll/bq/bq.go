@@ -0,0 +1,132 @@
+// Package bq integrates sketches with the cloud.google.com/go/bigquery client. It is a
+// separate module, so that the zetasketch module does not depend on the client.
+//
+// Row implements bigquery.ValueSaver and bigquery.ValueLoader, it stores sketches in
+// BYTES columns in the same format as HLL_COUNT.INIT, so they can be combined with
+// sketches produced by BigQuery:
+//
+//	schema := bigquery.Schema{
+//		{Name: "day", Type: bigquery.DateFieldType, Required: true},
+//		bq.SketchField("visitors", true),
+//	}
+//
+//	row := &bq.Row{
+//		Sketches: map[string]*hllplus.HLL{"visitors": visitors},
+//		Values:   map[string]bigquery.Value{"day": civil.DateOf(time.Now())},
+//	}
+//	err := table.Inserter().Put(ctx, row)
+package bq
+
+import (
+	"fmt"
+
+	"cloud.google.com/go/bigquery"
+	"github.com/gowthamkommineni/zetasketch/hllplus"
+)
+
+var (
+	_ bigquery.ValueSaver  = (*Row)(nil)
+	_ bigquery.ValueLoader = (*Row)(nil)
+)
+
+// sketchDescription marks the columns created by SketchField.
+const sketchDescription = "HLL_COUNT sketch"
+
+// SketchField returns the schema of a BYTES column holding sketches.
+func SketchField(name string, required bool) *bigquery.FieldSchema {
+	return &bigquery.FieldSchema{
+		Name:        name,
+		Type:        bigquery.BytesFieldType,
+		Required:    required,
+		Description: sketchDescription,
+	}
+}
+
+// IsSketchField reports whether a column can hold sketches, i.e. whether it is a
+// non-repeated BYTES column.
+func IsSketchField(f *bigquery.FieldSchema) bool {
+	return f.Type == bigquery.BytesFieldType && !f.Repeated
+}
+
+// Row is a table row with sketch columns. Nil sketches represent NULL.
+type Row struct {
+	// Sketches holds the sketch columns by name.
+	Sketches map[string]*hllplus.HLL
+	// Values holds all other columns by name.
+	Values map[string]bigquery.Value
+	// InsertID is used by BigQuery for best-effort de-duplication on Save, see
+	// bigquery.ValueSaver.
+	InsertID string
+	// SketchColumns restricts the columns which are loaded into Sketches. If empty, all
+	// columns accepted by IsSketchField are loaded as sketches.
+	SketchColumns []string
+}
+
+// Save implements bigquery.ValueSaver.
+func (r *Row) Save() (map[string]bigquery.Value, string, error) {
+	row := make(map[string]bigquery.Value, len(r.Sketches)+len(r.Values))
+	for name, v := range r.Values {
+		row[name] = v
+	}
+	for name, s := range r.Sketches {
+		if _, ok := row[name]; ok {
+			return nil, "", fmt.Errorf("column %q is both a sketch and a value", name)
+		}
+		if s == nil {
+			row[name] = nil
+			continue
+		}
+
+		data, err := s.Marshal()
+		if err != nil {
+			return nil, "", fmt.Errorf("column %q: %w", name, err)
+		}
+		row[name] = data
+	}
+	return row, r.InsertID, nil
+}
+
+// Load implements bigquery.ValueLoader. Existing Sketches and Values are replaced.
+func (r *Row) Load(values []bigquery.Value, schema bigquery.Schema) error {
+	if len(values) != len(schema) {
+		return fmt.Errorf("got %d values for %d columns", len(values), len(schema))
+	}
+
+	r.Sketches = make(map[string]*hllplus.HLL)
+	r.Values = make(map[string]bigquery.Value)
+	for i, f := range schema {
+		if !r.isSketchColumn(f) {
+			r.Values[f.Name] = values[i]
+			continue
+		}
+
+		switch v := values[i].(type) {
+		case nil:
+			r.Sketches[f.Name] = nil
+		case []byte:
+			s := new(hllplus.HLL)
+			if err := s.Unmarshal(v); err != nil {
+				return fmt.Errorf("column %q: invalid sketch: %w", f.Name, err)
+			}
+			r.Sketches[f.Name] = s
+		default:
+			return fmt.Errorf("column %q: unexpected value type %T", f.Name, v)
+		}
+	}
+	return nil
+}
+
+func (r *Row) isSketchColumn(f *bigquery.FieldSchema) bool {
+	if !IsSketchField(f) {
+		return false
+	}
+	if len(r.SketchColumns) == 0 {
+		return true
+	}
+	for _, name := range r.SketchColumns {
+		if name == f.Name {
+			return true
+		}
+	}
+	return false
+}
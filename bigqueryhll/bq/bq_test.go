@@ -0,0 +1,104 @@
+package bq_test
+
+import (
+	"testing"
+
+	"cloud.google.com/go/bigquery"
+	"github.com/gowthamkommineni/zetasketch/bigqueryhll"
+	"github.com/gowthamkommineni/zetasketch/bigqueryhll/bq"
+	"github.com/gowthamkommineni/zetasketch/hllplus"
+
+	. "github.com/bsm/ginkgo"
+	. "github.com/bsm/gomega"
+)
+
+var _ = Describe("Row", func() {
+	var sketch []byte
+	var schema bigquery.Schema
+
+	BeforeEach(func() {
+		var err error
+		sketch, err = bigqueryhll.Init([]string{"foo", "bar", "baz"}, 0)
+		Expect(err).NotTo(HaveOccurred())
+
+		schema = bigquery.Schema{
+			{Name: "day", Type: bigquery.StringFieldType, Required: true},
+			{Name: "tags", Type: bigquery.BytesFieldType, Repeated: true},
+			bq.SketchField("visitors", true),
+			bq.SketchField("buyers", false),
+		}
+	})
+
+	It("should build schemas", func() {
+		Expect(bq.SketchField("visitors", true)).To(Equal(&bigquery.FieldSchema{
+			Name:        "visitors",
+			Type:        bigquery.BytesFieldType,
+			Required:    true,
+			Description: "HLL_COUNT sketch",
+		}))
+		Expect(bq.IsSketchField(schema[0])).To(BeFalse())
+		Expect(bq.IsSketchField(schema[1])).To(BeFalse())
+		Expect(bq.IsSketchField(schema[2])).To(BeTrue())
+		Expect(bq.IsSketchField(&bigquery.FieldSchema{Name: "raw", Type: bigquery.BytesFieldType})).To(BeTrue())
+	})
+
+	It("should save", func() {
+		s := new(hllplus.HLL)
+		Expect(s.Unmarshal(sketch)).To(Succeed())
+
+		row := &bq.Row{
+			Sketches: map[string]*hllplus.HLL{"visitors": s, "buyers": nil},
+			Values:   map[string]bigquery.Value{"day": "2022-01-01"},
+			InsertID: "x",
+		}
+		values, insertID, err := row.Save()
+		Expect(err).NotTo(HaveOccurred())
+		Expect(insertID).To(Equal("x"))
+		Expect(values).To(Equal(map[string]bigquery.Value{
+			"day":      "2022-01-01",
+			"visitors": sketch,
+			"buyers":   nil,
+		}))
+
+		row.Values["visitors"] = 1
+		_, _, err = row.Save()
+		Expect(err).To(MatchError(`column "visitors" is both a sketch and a value`))
+	})
+
+	It("should load", func() {
+		var row bq.Row
+		Expect(row.Load([]bigquery.Value{"2022-01-01", []bigquery.Value{[]byte("a")}, sketch, nil}, schema)).To(Succeed())
+		Expect(row.Values).To(Equal(map[string]bigquery.Value{
+			"day":  "2022-01-01",
+			"tags": []bigquery.Value{[]byte("a")},
+		}))
+		Expect(row.Sketches).To(HaveLen(2))
+		Expect(row.Sketches).To(HaveKeyWithValue("buyers", BeNil()))
+		Expect(row.Sketches["visitors"].Estimate()).To(Equal(int64(3)))
+
+		// saved rows load back
+		values, _, err := row.Save()
+		Expect(err).NotTo(HaveOccurred())
+		Expect(values["visitors"]).To(Equal(sketch))
+	})
+
+	It("should restrict sketch columns", func() {
+		row := bq.Row{SketchColumns: []string{"visitors"}}
+		Expect(row.Load([]bigquery.Value{"2022-01-01", nil, sketch, []byte("raw")}, schema)).To(Succeed())
+		Expect(row.Sketches).To(HaveLen(1))
+		Expect(row.Sketches).To(HaveKey("visitors"))
+		Expect(row.Values).To(HaveKeyWithValue("buyers", []byte("raw")))
+	})
+
+	It("should reject invalid rows", func() {
+		var row bq.Row
+		Expect(row.Load([]bigquery.Value{"2022-01-01"}, schema)).To(MatchError("got 1 values for 4 columns"))
+		Expect(row.Load([]bigquery.Value{"2022-01-01", nil, []byte("x"), nil}, schema)).To(MatchError(HavePrefix(`column "visitors": invalid sketch: `)))
+		Expect(row.Load([]bigquery.Value{"2022-01-01", nil, "x", nil}, schema)).To(MatchError(`column "visitors": unexpected value type string`))
+	})
+})
+
+func TestSuite(t *testing.T) {
+	RegisterFailHandler(Fail)
+	RunSpecs(t, "zetasketch/bigqueryhll/bq")
+}
@@ -0,0 +1,57 @@
+package bigqueryhll
+
+import (
+	"fmt"
+
+	"google.golang.org/protobuf/encoding/protowire"
+	"google.golang.org/protobuf/proto"
+	"google.golang.org/protobuf/reflect/protodesc"
+	"google.golang.org/protobuf/types/descriptorpb"
+)
+
+// The BigQuery Storage Write API accepts rows as serialized proto messages, along with a
+// self-contained descriptor of the message. Sketches are stored in BYTES columns, which
+// map to bytes fields holding the sketch in the same format as HLL_COUNT.INIT. The
+// helpers below build descriptors and rows for such tables without generated code; the
+// descriptor is passed as ProtoSchema.ProtoDescriptor and the rows as
+// ProtoRows.SerializedRows of an AppendRowsRequest.
+
+// SketchField returns the descriptor of a bytes field for a sketch column. Names are
+// matched to columns case-insensitively, numbers must be unique within the row.
+func SketchField(name string, number int32) *descriptorpb.FieldDescriptorProto {
+	return &descriptorpb.FieldDescriptorProto{
+		Name:   proto.String(name),
+		Number: proto.Int32(number),
+		Label:  descriptorpb.FieldDescriptorProto_LABEL_OPTIONAL.Enum(),
+		Type:   descriptorpb.FieldDescriptorProto_TYPE_BYTES.Enum(),
+	}
+}
+
+// RowDescriptor returns the descriptor of a row message with the given fields, e.g. a
+// SketchField and fields for the other columns of the table. The descriptor is
+// validated, so invalid names and duplicate numbers are reported before streaming.
+func RowDescriptor(name string, fields ...*descriptorpb.FieldDescriptorProto) (*descriptorpb.DescriptorProto, error) {
+	msg := &descriptorpb.DescriptorProto{
+		Name:  proto.String(name),
+		Field: fields,
+	}
+	file := &descriptorpb.FileDescriptorProto{
+		Name:        proto.String(name + ".proto"),
+		Syntax:      proto.String("proto2"),
+		MessageType: []*descriptorpb.DescriptorProto{msg},
+	}
+	if _, err := protodesc.NewFile(file, nil); err != nil {
+		return nil, fmt.Errorf("invalid row descriptor: %w", err)
+	}
+	return msg, nil
+}
+
+// AppendSketch appends a sketch as field number to a serialized row. Nil sketches
+// represent NULL and are omitted.
+func AppendSketch(row []byte, number int32, sketch []byte) []byte {
+	if sketch == nil {
+		return row
+	}
+	row = protowire.AppendTag(row, protowire.Number(number), protowire.BytesType)
+	return protowire.AppendBytes(row, sketch)
+}
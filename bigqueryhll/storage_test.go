@@ -0,0 +1,57 @@
+package bigqueryhll_test
+
+import (
+	"github.com/gowthamkommineni/zetasketch/bigqueryhll"
+	"google.golang.org/protobuf/encoding/protowire"
+	"google.golang.org/protobuf/proto"
+	"google.golang.org/protobuf/reflect/protodesc"
+	"google.golang.org/protobuf/types/descriptorpb"
+	"google.golang.org/protobuf/types/dynamicpb"
+
+	. "github.com/bsm/ginkgo"
+	. "github.com/bsm/gomega"
+)
+
+var _ = Describe("Storage Write API", func() {
+	It("should build row descriptors", func() {
+		desc, err := bigqueryhll.RowDescriptor("VisitorsRow",
+			&descriptorpb.FieldDescriptorProto{
+				Name:   proto.String("day"),
+				Number: proto.Int32(1),
+				Label:  descriptorpb.FieldDescriptorProto_LABEL_OPTIONAL.Enum(),
+				Type:   descriptorpb.FieldDescriptorProto_TYPE_INT32.Enum(),
+			},
+			bigqueryhll.SketchField("visitors", 2),
+		)
+		Expect(err).NotTo(HaveOccurred())
+		Expect(desc.GetName()).To(Equal("VisitorsRow"))
+		Expect(desc.GetField()).To(HaveLen(2))
+
+		_, err = bigqueryhll.RowDescriptor("Row", bigqueryhll.SketchField("a", 1), bigqueryhll.SketchField("b", 1))
+		Expect(err).To(MatchError(HavePrefix("invalid row descriptor: ")))
+	})
+
+	It("should encode rows", func() {
+		desc, _ := bigqueryhll.RowDescriptor("Row", bigqueryhll.SketchField("visitors", 1), bigqueryhll.SketchField("buyers", 2))
+		sketch, _ := bigqueryhll.Init([]string{"foo", "bar"}, 0)
+
+		row := bigqueryhll.AppendSketch(nil, 1, sketch)
+		row = bigqueryhll.AppendSketch(row, 2, nil)
+		Expect(row[0]).To(Equal(byte(protowire.EncodeTag(1, protowire.BytesType))))
+
+		// decode like BigQuery, using the descriptor only
+		file, err := protodesc.NewFile(&descriptorpb.FileDescriptorProto{
+			Name:        proto.String("row.proto"),
+			Syntax:      proto.String("proto2"),
+			MessageType: []*descriptorpb.DescriptorProto{desc},
+		}, nil)
+		Expect(err).NotTo(HaveOccurred())
+
+		md := file.Messages().Get(0)
+		msg := dynamicpb.NewMessage(md)
+		Expect(proto.Unmarshal(row, msg)).To(Succeed())
+		Expect(msg.Get(md.Fields().ByName("visitors")).Bytes()).To(Equal(sketch))
+		Expect(msg.Has(md.Fields().ByName("buyers"))).To(BeFalse())
+		Expect(bigqueryhll.Extract(msg.Get(md.Fields().ByName("visitors")).Bytes())).To(Equal(int64(2)))
+	})
+})
@@ -0,0 +1,279 @@
+// Package bloom implements Bloom filters for approximate membership tests. Values are
+// hashed with the same Fingerprint2011 hash as hllplus sketches, so filters and sketches
+// can be fed from the same hashes, see the fingerprint package.
+//
+// Both the standard variant, where all k hash functions address the whole bit array,
+// and the partitioned variant, where each hash function addresses its own slice of m/k
+// bits, are supported.
+package bloom
+
+import (
+	"fmt"
+	"math"
+	"math/bits"
+
+	"github.com/gowthamkommineni/zetasketch/internal/hash"
+)
+
+// Parameter limits.
+const (
+	// MaxK is the maximum number of hash functions.
+	MaxK = 64
+	// MaxM is the maximum number of bits.
+	MaxM = 1 << 40
+)
+
+// Option configures optional filter behaviour.
+type Option func(*Filter) error
+
+// WithPartitions selects the partitioned variant, which splits the bit array into k
+// partitions, one per hash function.
+func WithPartitions() Option {
+	return func(f *Filter) error {
+		f.partitioned = true
+		return nil
+	}
+}
+
+// Filter is a Bloom filter with m bits and k hash functions.
+type Filter struct {
+	m           uint64 // number of bits
+	k           uint8
+	partitioned bool
+	words       []uint64
+}
+
+// New inits a filter with m bits and k hash functions. For the partitioned variant,
+// m is rounded up to a multiple of k.
+func New(m uint64, k uint8, opts ...Option) (*Filter, error) {
+	if k < 1 || k > MaxK {
+		return nil, fmt.Errorf("invalid number of hash functions %d", k)
+	}
+	if m < 1 || m > MaxM {
+		return nil, fmt.Errorf("invalid number of bits %d", m)
+	}
+
+	f := &Filter{m: m, k: k}
+	for _, opt := range opts {
+		if err := opt(f); err != nil {
+			return nil, err
+		}
+	}
+	if f.partitioned {
+		f.m = (m + uint64(k) - 1) / uint64(k) * uint64(k)
+	}
+	f.words = make([]uint64, (f.m+63)/64)
+	return f, nil
+}
+
+// NewForCapacity inits a filter sized for n values with a false positive rate of at most
+// fpRate, which must be between 0 and 1.
+func NewForCapacity(n uint64, fpRate float64, opts ...Option) (*Filter, error) {
+	if !(fpRate > 0 && fpRate < 1) {
+		return nil, fmt.Errorf("invalid false positive rate %v", fpRate)
+	}
+	if n < 1 {
+		n = 1
+	}
+
+	m := math.Ceil(-float64(n) * math.Log(fpRate) / (math.Ln2 * math.Ln2))
+	k := math.Round(m / float64(n) * math.Ln2)
+	if k < 1 {
+		k = 1
+	} else if k > MaxK {
+		k = MaxK
+	}
+	return New(uint64(m), uint8(k), opts...)
+}
+
+// M returns the number of bits.
+func (f *Filter) M() uint64 {
+	return f.m
+}
+
+// K returns the number of hash functions.
+func (f *Filter) K() uint8 {
+	return f.k
+}
+
+// IsPartitioned returns true for the partitioned variant.
+func (f *Filter) IsPartitioned() bool {
+	return f.partitioned
+}
+
+// Add adds the uniform hash value to the filter.
+func (f *Filter) Add(hash uint64) {
+	f.each(hash, func(i uint64) bool {
+		f.words[i/64] |= 1 << (i % 64)
+		return true
+	})
+}
+
+// AddString hashes and adds a string value.
+func (f *Filter) AddString(v string) {
+	f.Add(hash.String(v))
+}
+
+// AddBytes hashes and adds a byte value.
+func (f *Filter) AddBytes(v []byte) {
+	f.Add(hash.Bytes(v))
+}
+
+// AddInt64 hashes and adds a signed number.
+func (f *Filter) AddInt64(v int64) {
+	f.Add(hash.Uint64(uint64(v)))
+}
+
+// AddUint64 hashes and adds an unsigned number.
+func (f *Filter) AddUint64(v uint64) {
+	f.Add(hash.Uint64(v))
+}
+
+// Contains returns true if the hash value may have been added, or false if it
+// definitely has not.
+func (f *Filter) Contains(hash uint64) bool {
+	return f.each(hash, func(i uint64) bool {
+		return f.words[i/64]&(1<<(i%64)) != 0
+	})
+}
+
+// ContainsString tests a string value, see Contains.
+func (f *Filter) ContainsString(v string) bool {
+	return f.Contains(hash.String(v))
+}
+
+// ContainsBytes tests a byte value, see Contains.
+func (f *Filter) ContainsBytes(v []byte) bool {
+	return f.Contains(hash.Bytes(v))
+}
+
+// ContainsInt64 tests a signed number, see Contains.
+func (f *Filter) ContainsInt64(v int64) bool {
+	return f.Contains(hash.Uint64(uint64(v)))
+}
+
+// ContainsUint64 tests an unsigned number, see Contains.
+func (f *Filter) ContainsUint64(v uint64) bool {
+	return f.Contains(hash.Uint64(v))
+}
+
+// Union merges other into the filter. Both filters must have the same
+// configuration.
+func (f *Filter) Union(other *Filter) error {
+	if f.m != other.m || f.k != other.k || f.partitioned != other.partitioned {
+		return fmt.Errorf("cannot merge filters with different configurations")
+	}
+	for i, w := range other.words {
+		f.words[i] |= w
+	}
+	return nil
+}
+
+// Count returns the number of bits set.
+func (f *Filter) Count() uint64 {
+	var n int
+	for _, w := range f.words {
+		n += bits.OnesCount64(w)
+	}
+	return uint64(n)
+}
+
+// Estimate estimates the number of distinct values added from the number of bits
+// set, as described in "Fast Processing of Massive Data Sets Using Bloom Filters"
+// (Swamidass, Baldi, 2007). It returns math.MaxInt64 if the filter is saturated.
+func (f *Filter) Estimate() int64 {
+	if !f.partitioned {
+		return estimateBits(f.Count(), f.m, f.k)
+	}
+
+	// each partition behaves like a filter with a single hash function
+	size := f.m / uint64(f.k)
+	var sum int64
+	for p := uint64(0); p < uint64(f.k); p++ {
+		n := estimateBits(f.countRange(p*size, (p+1)*size), size, 1)
+		if n == math.MaxInt64 {
+			return n
+		}
+		sum += n
+	}
+	return int64(math.Round(float64(sum) / float64(f.k)))
+}
+
+// FalsePositiveRate returns the current probability that Contains reports a value
+// which has not been added.
+func (f *Filter) FalsePositiveRate() float64 {
+	if !f.partitioned {
+		return math.Pow(float64(f.Count())/float64(f.m), float64(f.k))
+	}
+
+	size := f.m / uint64(f.k)
+	rate := 1.0
+	for p := uint64(0); p < uint64(f.k); p++ {
+		rate *= float64(f.countRange(p*size, (p+1)*size)) / float64(size)
+	}
+	return rate
+}
+
+// Reset clears all bits.
+func (f *Filter) Reset() {
+	for i := range f.words {
+		f.words[i] = 0
+	}
+}
+
+// each calls fn with each bit index of hash, until fn returns false. It returns
+// false if fn did.
+func (f *Filter) each(hash uint64, fn func(uint64) bool) bool {
+	// double hashing, see "Less Hashing, Same Performance: Building a Better Bloom
+	// Filter" (Kirsch, Mitzenmacher, 2006)
+	h1, h2 := hash, mix64(hash)|1
+	if !f.partitioned {
+		for i := uint64(0); i < uint64(f.k); i++ {
+			if !fn((h1 + i*h2) % f.m) {
+				return false
+			}
+		}
+		return true
+	}
+
+	size := f.m / uint64(f.k)
+	for i := uint64(0); i < uint64(f.k); i++ {
+		if !fn(i*size + (h1+i*h2)%size) {
+			return false
+		}
+	}
+	return true
+}
+
+// countRange returns the number of bits set in [lo, hi).
+func (f *Filter) countRange(lo, hi uint64) uint64 {
+	var n uint64
+	for i := lo; i < hi; {
+		w := f.words[i/64] >> (i % 64)
+		width := 64 - i%64
+		if rem := hi - i; rem < width {
+			w &= 1<<rem - 1
+			width = rem
+		}
+		n += uint64(bits.OnesCount64(w))
+		i += width
+	}
+	return n
+}
+
+func estimateBits(x, m uint64, k uint8) int64 {
+	if x >= m {
+		return math.MaxInt64
+	}
+	return int64(math.Round(-float64(m) / float64(k) * math.Log1p(-float64(x)/float64(m))))
+}
+
+// mix64 is the finalizer of MurmurHash3, used to derive a second hash.
+func mix64(h uint64) uint64 {
+	h ^= h >> 33
+	h *= 0xff51afd7ed558ccd
+	h ^= h >> 33
+	h *= 0xc4ceb9fe1a85ec53
+	h ^= h >> 33
+	return h
+}
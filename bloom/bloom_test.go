@@ -0,0 +1,138 @@
+package bloom_test
+
+import (
+	"fmt"
+	"math"
+	"testing"
+
+	"github.com/gowthamkommineni/zetasketch/bloom"
+	"github.com/gowthamkommineni/zetasketch/fingerprint"
+
+	. "github.com/bsm/ginkgo"
+	. "github.com/bsm/gomega"
+)
+
+var _ = Describe("Filter", func() {
+	newFilter := func(n uint64, opts ...bloom.Option) *bloom.Filter {
+		f, err := bloom.NewForCapacity(n, 0.01, opts...)
+		Expect(err).NotTo(HaveOccurred())
+		return f
+	}
+
+	It("should validate parameters", func() {
+		_, err := bloom.New(1024, 0)
+		Expect(err).To(MatchError("invalid number of hash functions 0"))
+		_, err = bloom.New(0, 3)
+		Expect(err).To(MatchError("invalid number of bits 0"))
+		_, err = bloom.NewForCapacity(1000, 1)
+		Expect(err).To(MatchError("invalid false positive rate 1"))
+	})
+
+	It("should size for capacity", func() {
+		f := newFilter(1000)
+		Expect(f.M()).To(Equal(uint64(9586)))
+		Expect(f.K()).To(Equal(uint8(7)))
+		Expect(f.IsPartitioned()).To(BeFalse())
+
+		f = newFilter(1000, bloom.WithPartitions())
+		Expect(f.M()).To(Equal(uint64(9590)))
+		Expect(f.IsPartitioned()).To(BeTrue())
+	})
+
+	for _, partitioned := range []bool{false, true} {
+		partitioned := partitioned
+		var opts []bloom.Option
+		if partitioned {
+			opts = append(opts, bloom.WithPartitions())
+		}
+
+		Context(fmt.Sprintf("partitioned=%v", partitioned), func() {
+			It("should test membership", func() {
+				f := newFilter(10_000, opts...)
+				for i := 0; i < 10_000; i++ {
+					f.AddInt64(int64(i))
+				}
+				f.AddString("foo")
+				f.AddBytes([]byte("bar"))
+				f.AddUint64(math.MaxUint64)
+
+				for i := 0; i < 10_000; i++ {
+					Expect(f.ContainsInt64(int64(i))).To(BeTrue())
+				}
+				Expect(f.ContainsString("foo")).To(BeTrue())
+				Expect(f.ContainsBytes([]byte("foo"))).To(BeTrue())
+				Expect(f.ContainsString("bar")).To(BeTrue())
+				Expect(f.ContainsUint64(math.MaxUint64)).To(BeTrue())
+				Expect(f.Contains(fingerprint.String("foo"))).To(BeTrue())
+
+				var fp int
+				for i := 10_000; i < 110_000; i++ {
+					if f.ContainsInt64(int64(i)) {
+						fp++
+					}
+				}
+				Expect(float64(fp) / 100_000).To(BeNumerically("~", 0.01, 0.003))
+				Expect(f.FalsePositiveRate()).To(BeNumerically("~", 0.01, 0.003))
+
+				f.Reset()
+				Expect(f.Count()).To(BeZero())
+				Expect(f.ContainsString("foo")).To(BeFalse())
+			})
+
+			It("should estimate cardinality", func() {
+				f := newFilter(10_000, opts...)
+				Expect(f.Estimate()).To(BeZero())
+
+				for i := 0; i < 5_000; i++ {
+					f.AddInt64(int64(i))
+					f.AddInt64(int64(i))
+				}
+				Expect(f.Estimate()).To(BeNumerically("~", 5_000, 100))
+			})
+
+			It("should union", func() {
+				a, b := newFilter(10_000, opts...), newFilter(10_000, opts...)
+				for i := 0; i < 5_000; i++ {
+					a.AddInt64(int64(i))
+					b.AddInt64(int64(i + 2_500))
+				}
+
+				Expect(a.Union(b)).To(Succeed())
+				Expect(a.ContainsInt64(0)).To(BeTrue())
+				Expect(a.ContainsInt64(7_499)).To(BeTrue())
+				Expect(a.Estimate()).To(BeNumerically("~", 7_500, 150))
+
+				Expect(a.Union(newFilter(20_000, opts...))).To(MatchError("cannot merge filters with different configurations"))
+			})
+		})
+	}
+
+	It("should saturate", func() {
+		f, err := bloom.New(64, 1)
+		Expect(err).NotTo(HaveOccurred())
+		for i := 0; i < 10_000; i++ {
+			f.AddInt64(int64(i))
+		}
+		Expect(f.Count()).To(Equal(uint64(64)))
+		Expect(f.Estimate()).To(Equal(int64(math.MaxInt64)))
+		Expect(f.FalsePositiveRate()).To(Equal(1.0))
+	})
+})
+
+func TestSuite(t *testing.T) {
+	RegisterFailHandler(Fail)
+	RunSpecs(t, "zetasketch/bloom")
+}
+
+func BenchmarkFilter_Add(b *testing.B) {
+	f, err := bloom.NewForCapacity(1_000_000, 0.01)
+	if err != nil {
+		b.Fatal(err)
+	}
+
+	b.ReportAllocs()
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		f.Add(uint64(i) * 0x9e3779b97f4a7c15)
+	}
+}
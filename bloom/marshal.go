@@ -0,0 +1,78 @@
+package bloom
+
+import (
+	"encoding/binary"
+	"fmt"
+)
+
+// encodingVersion is the version of the binary format.
+const encodingVersion = 1
+
+const (
+	headerSize      = 12
+	flagPartitioned = 1 << 0
+)
+
+// Marshal serializes the filter into a stable binary format: a version byte, a flags
+// byte, k, a reserved byte, m as a little-endian uint64, followed by the bits as
+// little-endian 64-bit words.
+func (f *Filter) Marshal() ([]byte, error) {
+	var flags byte
+	if f.partitioned {
+		flags |= flagPartitioned
+	}
+
+	data := make([]byte, headerSize+len(f.words)*8)
+	data[0], data[1], data[2] = encodingVersion, flags, f.k
+	binary.LittleEndian.PutUint64(data[4:], f.m)
+	for i, w := range f.words {
+		binary.LittleEndian.PutUint64(data[headerSize+i*8:], w)
+	}
+	return data, nil
+}
+
+// Unmarshal restores the filter from data serialized by Marshal, replacing its current
+// state, including its configuration.
+func (f *Filter) Unmarshal(data []byte) error {
+	if len(data) < headerSize {
+		return fmt.Errorf("invalid bloom filter: too short")
+	}
+	if data[0] != encodingVersion {
+		return fmt.Errorf("invalid bloom filter: unsupported version %d", data[0])
+	}
+
+	flags, k, m := data[1], data[2], binary.LittleEndian.Uint64(data[4:])
+	if flags&^flagPartitioned != 0 {
+		return fmt.Errorf("invalid bloom filter: unsupported flags %d", flags)
+	}
+	if k < 1 || k > MaxK || m < 1 || m > MaxM || (flags&flagPartitioned != 0 && m%uint64(k) != 0) {
+		return fmt.Errorf("invalid bloom filter: %d bits with %d hash functions", m, k)
+	}
+
+	data = data[headerSize:]
+	numWords := (m + 63) / 64
+	if uint64(len(data)) != numWords*8 {
+		return fmt.Errorf("invalid bloom filter: %d bytes of bits, expected %d", len(data), numWords*8)
+	}
+
+	words := make([]uint64, numWords)
+	for i := range words {
+		words[i] = binary.LittleEndian.Uint64(data[i*8:])
+	}
+	if m%64 != 0 && words[len(words)-1]>>(m%64) != 0 {
+		return fmt.Errorf("invalid bloom filter: bits set beyond %d", m)
+	}
+
+	*f = Filter{m: m, k: k, partitioned: flags&flagPartitioned != 0, words: words}
+	return nil
+}
+
+// MarshalBinary implements encoding.BinaryMarshaler using the same format as Marshal.
+func (f *Filter) MarshalBinary() ([]byte, error) {
+	return f.Marshal()
+}
+
+// UnmarshalBinary implements encoding.BinaryUnmarshaler using the same format as Unmarshal.
+func (f *Filter) UnmarshalBinary(data []byte) error {
+	return f.Unmarshal(data)
+}
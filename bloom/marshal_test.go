@@ -0,0 +1,62 @@
+package bloom_test
+
+import (
+	"github.com/gowthamkommineni/zetasketch/bloom"
+
+	. "github.com/bsm/ginkgo"
+	. "github.com/bsm/gomega"
+)
+
+var _ = Describe("Filter (marshal)", func() {
+	It("should marshal", func() {
+		f, err := bloom.New(70, 2, bloom.WithPartitions())
+		Expect(err).NotTo(HaveOccurred())
+		f.Add(0)
+
+		data, err := f.Marshal()
+		Expect(err).NotTo(HaveOccurred())
+		Expect(data).To(Equal([]byte{
+			1, 1, 2, 0, // version, flags: partitioned, k, reserved
+			70, 0, 0, 0, 0, 0, 0, 0, // m
+			1, 0, 0, 0, 16, 0, 0, 0, // bits 0 and 36 (partition 2 starts at 35)
+			0, 0, 0, 0, 0, 0, 0, 0,
+		}))
+		Expect(f.Count()).To(Equal(uint64(2)))
+
+		res := new(bloom.Filter)
+		Expect(res.Unmarshal(data)).To(Succeed())
+		Expect(res.M()).To(Equal(uint64(70)))
+		Expect(res.K()).To(Equal(uint8(2)))
+		Expect(res.IsPartitioned()).To(BeTrue())
+		Expect(res.Contains(0)).To(BeTrue())
+		Expect(res.Marshal()).To(Equal(data))
+	})
+
+	It("should round trip", func() {
+		f, err := bloom.NewForCapacity(1000, 0.01)
+		Expect(err).NotTo(HaveOccurred())
+		for i := 0; i < 1000; i++ {
+			f.AddInt64(int64(i))
+		}
+
+		data, err := f.MarshalBinary()
+		Expect(err).NotTo(HaveOccurred())
+		Expect(data).To(HaveLen(12 + 150*8))
+
+		res := new(bloom.Filter)
+		Expect(res.UnmarshalBinary(data)).To(Succeed())
+		Expect(res.Count()).To(Equal(f.Count()))
+		Expect(res.ContainsInt64(999)).To(BeTrue())
+		Expect(res.Union(f)).To(Succeed())
+	})
+
+	It("should reject invalid data", func() {
+		f := new(bloom.Filter)
+		Expect(f.Unmarshal(nil)).To(MatchError("invalid bloom filter: too short"))
+		Expect(f.Unmarshal([]byte{2, 0, 1, 0, 64, 0, 0, 0, 0, 0, 0, 0})).To(MatchError("invalid bloom filter: unsupported version 2"))
+		Expect(f.Unmarshal([]byte{1, 2, 1, 0, 64, 0, 0, 0, 0, 0, 0, 0})).To(MatchError("invalid bloom filter: unsupported flags 2"))
+		Expect(f.Unmarshal([]byte{1, 1, 3, 0, 64, 0, 0, 0, 0, 0, 0, 0})).To(MatchError("invalid bloom filter: 64 bits with 3 hash functions"))
+		Expect(f.Unmarshal([]byte{1, 0, 1, 0, 64, 0, 0, 0, 0, 0, 0, 0})).To(MatchError("invalid bloom filter: 0 bytes of bits, expected 8"))
+		Expect(f.Unmarshal([]byte{1, 0, 1, 0, 4, 0, 0, 0, 0, 0, 0, 0, 16, 0, 0, 0, 0, 0, 0, 0})).To(MatchError("invalid bloom filter: bits set beyond 4"))
+	})
+})
@@ -0,0 +1,106 @@
+package main
+
+import (
+	"encoding/base64"
+	"fmt"
+	"io"
+	"sort"
+	"strings"
+
+	"github.com/gowthamkommineni/zetasketch/convert"
+	"github.com/gowthamkommineni/zetasketch/hllplus"
+)
+
+// format converts between a serialized representation and sketches. Either direction
+// is nil if the conversion is not supported or not lossless.
+type format struct {
+	decode func(data []byte, opts ...hllplus.Option) (*hllplus.HLL, error)
+	encode func(s *hllplus.HLL) ([]byte, error)
+}
+
+var formats = map[string]format{
+	"zetasketch": {
+		decode: func(data []byte, opts ...hllplus.Option) (*hllplus.HLL, error) {
+			s, err := hllplus.New(hllplus.MinPrecision, hllplus.MinPrecision, opts...)
+			if err != nil {
+				return nil, err
+			}
+			if err := s.Unmarshal(data); err != nil {
+				return nil, err
+			}
+			return s, nil
+		},
+		encode: func(s *hllplus.HLL) ([]byte, error) { return s.Marshal() },
+	},
+	"redis":        {decode: convert.FromRedis, encode: convert.ToRedis},
+	"presto":       {decode: convert.FromPresto, encode: convert.ToPresto},
+	"datasketches": {decode: convert.FromDataSketches},
+}
+
+func runConvert(args []string, stdin io.Reader, stdout, stderr io.Writer) error {
+	fs := newFlagSet("convert", stderr)
+	from := fs.String("from", "zetasketch", "input format, one of "+formatNames(func(f format) bool { return f.decode != nil }))
+	to := fs.String("to", "zetasketch", "output format, one of "+formatNames(func(f format) bool { return f.encode != nil }))
+	hasher := fs.String("hasher", hllplus.Fingerprint2011.ID(), "hash function the values of zetasketch input were hashed with, e.g. redis-murmur64a for sketches converted from Redis, since it is not serialized")
+	isBase64 := fs.Bool("base64", false, "decode base64-encoded input and encode output")
+	output := fs.String("o", "", "write the result to this file instead of stdout")
+	if err := fs.Parse(args); err != nil {
+		return err
+	}
+	if fs.NArg() > 1 {
+		fs.Usage()
+		return fmt.Errorf("convert: too many arguments")
+	}
+
+	src, ok := formats[*from]
+	if !ok || src.decode == nil {
+		return fmt.Errorf("cannot convert from %q", *from)
+	}
+	dst, ok := formats[*to]
+	if !ok || dst.encode == nil {
+		return fmt.Errorf("cannot convert to %q", *to)
+	}
+
+	var opts []hllplus.Option
+	if *from == "zetasketch" {
+		h, ok := hashers[*hasher]
+		if !ok {
+			return fmt.Errorf("invalid hasher %q", *hasher)
+		}
+		opts = append(opts, hllplus.WithHasher(h))
+	}
+
+	data, err := readInput(fs.Arg(0), stdin)
+	if err != nil {
+		return err
+	}
+	if *isBase64 {
+		if data, err = decodeBase64(fs.Arg(0), data); err != nil {
+			return err
+		}
+	}
+
+	s, err := src.decode(data, opts...)
+	if err != nil {
+		return fmt.Errorf("%s: %w", inputName(fs.Arg(0)), err)
+	}
+	if data, err = dst.encode(s); err != nil {
+		return err
+	}
+	if *isBase64 {
+		data = append([]byte(base64.StdEncoding.EncodeToString(data)), '\n')
+	}
+	return writeOutput(*output, stdout, data)
+}
+
+// formatNames returns the sorted names of formats which match fn.
+func formatNames(fn func(format) bool) string {
+	var names []string
+	for name, f := range formats {
+		if fn(f) {
+			names = append(names, name)
+		}
+	}
+	sort.Strings(names)
+	return strings.Join(names, ", ")
+}
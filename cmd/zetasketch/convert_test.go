@@ -0,0 +1,95 @@
+package main
+
+import (
+	"encoding/base64"
+	"fmt"
+	"strings"
+
+	"github.com/gowthamkommineni/zetasketch/hllplus"
+
+	. "github.com/bsm/ginkgo"
+	. "github.com/bsm/gomega"
+)
+
+var _ = Describe("convert", func() {
+	var lines string
+
+	BeforeEach(func() {
+		var b strings.Builder
+		for i := 0; i < 5_000; i++ {
+			fmt.Fprintf(&b, "value-%d\n", i)
+		}
+		lines = b.String()
+	})
+
+	It("should convert to and from Redis", func() {
+		sketch, err := execute(lines, "init", "-hasher", "redis-murmur64a", "-precision", "14", "-sparse-precision", "19")
+		Expect(err).NotTo(HaveOccurred())
+		est := estimateOf([]byte(sketch))
+		Expect(est).To(BeNumerically("~", 5_000, 100))
+
+		// the hasher is not serialized
+		_, err = execute(sketch, "convert", "-to", "redis")
+		Expect(err).To(MatchError(`cannot convert sketch with hasher "fingerprint2011" to Redis`))
+
+		redis, err := execute(sketch, "convert", "-to", "redis", "-hasher", "redis-murmur64a")
+		Expect(err).NotTo(HaveOccurred())
+		Expect(redis).To(HavePrefix("HYLL"))
+
+		out, err := execute(redis, "convert", "-from", "redis")
+		Expect(err).NotTo(HaveOccurred())
+		Expect(estimateOf([]byte(out))).To(BeNumerically("~", est, 100))
+
+		out, err = execute(redis, "convert", "-from", "redis", "-to", "redis")
+		Expect(err).NotTo(HaveOccurred())
+		Expect(out).To(Equal(redis))
+
+		_, err = execute(redis, "convert", "-from", "redis", "-to", "presto")
+		Expect(err).To(MatchError(`cannot convert sketch with hasher "redis-murmur64a" to Presto`))
+	})
+
+	It("should convert to and from Presto as base64", func() {
+		sketch, err := execute(lines, "init", "-hasher", "airlift-murmur3-128", "-precision", "12", "-sparse-precision", "17", "-base64")
+		Expect(err).NotTo(HaveOccurred())
+
+		presto, err := execute(sketch, "convert", "-to", "presto", "-hasher", "airlift-murmur3-128", "-base64")
+		Expect(err).NotTo(HaveOccurred())
+		Expect(presto).To(HaveSuffix("\n"))
+
+		out, err := execute(presto, "convert", "-from", "presto", "-base64")
+		Expect(err).NotTo(HaveOccurred())
+
+		data, err := base64.StdEncoding.DecodeString(strings.TrimSpace(out))
+		Expect(err).NotTo(HaveOccurred())
+		s := new(hllplus.HLL)
+		Expect(s.Unmarshal(data)).To(Succeed())
+		Expect(s.Precision()).To(Equal(uint8(12)))
+		Expect(s.Estimate()).To(BeNumerically("~", 5_000, 150))
+	})
+
+	It("should convert from DataSketches", func() {
+		empty := string([]byte{2, 1, 7, 12, 3, 12, 0, 0})
+		out, err := execute(empty, "convert", "-from", "datasketches")
+		Expect(err).NotTo(HaveOccurred())
+
+		s := new(hllplus.HLL)
+		Expect(s.Unmarshal([]byte(out))).To(Succeed())
+		Expect(s.Precision()).To(Equal(uint8(12)))
+		Expect(s.Estimate()).To(BeZero())
+	})
+
+	It("should reject invalid conversions", func() {
+		_, err := execute("", "convert", "-to", "datasketches")
+		Expect(err).To(MatchError(`cannot convert to "datasketches"`))
+		_, err = execute("", "convert", "-from", "hive")
+		Expect(err).To(MatchError(`cannot convert from "hive"`))
+		_, err = execute("", "convert", "-hasher", "md5")
+		Expect(err).To(MatchError(`invalid hasher "md5"`))
+		_, err = execute("garbage", "convert", "-from", "redis")
+		Expect(err).To(MatchError("stdin: invalid Redis HyperLogLog: bad header"))
+		_, err = execute(string([]byte{2, 1, 7, 12, 62, 0, 0, 0}), "convert", "-from", "datasketches")
+		Expect(err).To(MatchError("stdin: invalid DataSketches HLL: invalid lgArr 62"))
+		_, err = execute("", "convert", "a", "b")
+		Expect(err).To(MatchError("convert: too many arguments"))
+	})
+})
@@ -0,0 +1,144 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"runtime"
+)
+
+// downgrade describes the result of downgrading a file.
+type downgrade struct {
+	path                           string
+	precision, sparsePrecision     uint8
+	toPrecision, toSparsePrecision uint8
+}
+
+func runDowngrade(args []string, stdin io.Reader, stdout, stderr io.Writer) error {
+	flags := newFlagSet("downgrade", stderr)
+	isBase64 := flags.Bool("base64", false, "decode base64-encoded input and encode output")
+	precision := flags.Uint("precision", 0, "target precision")
+	sparsePrecision := flags.Uint("sparse-precision", 0, "target sparse precision, the current one is kept if not given")
+	pattern := flags.String("pattern", "*", "only process files in directories whose names match this pattern")
+	parallelism := flags.Int("parallelism", runtime.GOMAXPROCS(0), "number of files processed concurrently")
+	output := flags.String("o", "", "write sketches to this directory, preserving their relative paths, instead of rewriting them in place")
+	if err := flags.Parse(args); err != nil {
+		return err
+	}
+	if flags.NArg() == 0 {
+		flags.Usage()
+		return fmt.Errorf("downgrade: no files given")
+	}
+	if *precision == 0 && *sparsePrecision == 0 {
+		return fmt.Errorf("downgrade: a target precision is required")
+	}
+	if *precision > 255 || *sparsePrecision > 255 {
+		return fmt.Errorf("invalid precision %d/%d", *precision, *sparsePrecision)
+	}
+	if _, err := filepath.Match(*pattern, ""); err != nil {
+		return fmt.Errorf("invalid pattern %q: %w", *pattern, err)
+	}
+
+	type file struct{ path, dest string }
+	var files []file
+	for _, root := range flags.Args() {
+		err := filepath.WalkDir(root, func(path string, d fs.DirEntry, err error) error {
+			if err != nil {
+				return err
+			}
+			if d.IsDir() {
+				return nil
+			}
+			if path != root {
+				if ok, _ := filepath.Match(*pattern, d.Name()); !ok {
+					return nil
+				}
+			}
+
+			dest := path
+			if *output != "" {
+				rel, err := filepath.Rel(root, path)
+				if err != nil {
+					return err
+				}
+				if rel == "." {
+					rel = d.Name()
+				}
+				dest = filepath.Join(*output, rel)
+			}
+			files = append(files, file{path: path, dest: dest})
+			return nil
+		})
+		if err != nil {
+			return err
+		}
+	}
+
+	results := make([]downgrade, len(files))
+	err := parallelize(context.Background(), len(files), *parallelism, func(i int) error {
+		f := files[i]
+		s, err := readSketch(f.path, nil, *isBase64)
+		if err != nil {
+			return err
+		}
+
+		res := downgrade{path: f.path, precision: s.Precision(), sparsePrecision: s.SparsePrecision()}
+		p, sp := uint8(*precision), uint8(*sparsePrecision)
+		if p == 0 {
+			p = s.Precision()
+		}
+		if sp == 0 {
+			sp = s.SparsePrecision()
+		}
+		if err := s.Downgrade(p, sp); err != nil {
+			return fmt.Errorf("%s: %w", f.path, err)
+		}
+		res.toPrecision, res.toSparsePrecision = s.Precision(), s.SparsePrecision()
+		results[i] = res
+
+		unchanged := res.toPrecision == res.precision && res.toSparsePrecision == res.sparsePrecision
+		if unchanged && f.dest == f.path {
+			return nil
+		}
+		if err := os.MkdirAll(filepath.Dir(f.dest), 0o755); err != nil {
+			return err
+		}
+		return replaceFile(f.dest, func(w io.Writer) error {
+			return writeSketch("", w, s, *isBase64)
+		})
+	})
+	if err != nil {
+		return err
+	}
+
+	for _, res := range results {
+		fmt.Fprintf(stdout, "%s: %d/%d -> %d/%d\n", res.path, res.precision, res.sparsePrecision, res.toPrecision, res.toSparsePrecision)
+	}
+	return nil
+}
+
+// replaceFile atomically replaces the file at path with the output of fn, by writing
+// to a temporary file in the same directory and renaming it.
+func replaceFile(path string, fn func(io.Writer) error) error {
+	tmp, err := os.CreateTemp(filepath.Dir(path), "."+filepath.Base(path)+".*")
+	if err != nil {
+		return err
+	}
+	defer os.Remove(tmp.Name())
+
+	if err := fn(tmp); err != nil {
+		_ = tmp.Close()
+		return err
+	}
+	if err := tmp.Chmod(0o644); err != nil {
+		_ = tmp.Close()
+		return err
+	}
+	if err := tmp.Close(); err != nil {
+		return err
+	}
+	return os.Rename(tmp.Name(), path)
+}
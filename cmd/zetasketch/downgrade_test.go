@@ -0,0 +1,91 @@
+package main
+
+import (
+	"os"
+	"path/filepath"
+
+	"github.com/gowthamkommineni/zetasketch/hllplus"
+
+	. "github.com/bsm/ginkgo"
+	. "github.com/bsm/gomega"
+)
+
+var _ = Describe("downgrade", func() {
+	var dir string
+
+	write := func(name string, data []byte) string {
+		path := filepath.Join(dir, name)
+		Expect(os.MkdirAll(filepath.Dir(path), 0o755)).To(Succeed())
+		Expect(os.WriteFile(path, data, 0o644)).To(Succeed())
+		return path
+	}
+
+	read := func(path string) *hllplus.HLL {
+		data, err := os.ReadFile(path)
+		Expect(err).NotTo(HaveOccurred())
+		s := new(hllplus.HLL)
+		Expect(s.Unmarshal(data)).To(Succeed())
+		return s
+	}
+
+	BeforeEach(func() {
+		var err error
+		dir, err = os.MkdirTemp("", "zetasketch")
+		Expect(err).NotTo(HaveOccurred())
+
+		write("2024/01.pb", sketchOf(1_000, 15, 20))
+		write("2024/02.pb", sketchOf(20_000, 15, 20))
+		write("2024/03/01.pb", sketchOf(300, 12, 17))
+		write("2024/README", []byte("not a sketch"))
+	})
+
+	AfterEach(func() {
+		Expect(os.RemoveAll(dir)).To(Succeed())
+	})
+
+	It("should rewrite directories in place", func() {
+		out, err := execute("", "downgrade", "-precision", "13", "-sparse-precision", "18", "-pattern", "*.pb", filepath.Join(dir, "2024"))
+		Expect(err).NotTo(HaveOccurred())
+		Expect(out).To(Equal(filepath.Join(dir, "2024/01.pb") + ": 15/20 -> 13/18\n" +
+			filepath.Join(dir, "2024/02.pb") + ": 15/20 -> 13/18\n" +
+			filepath.Join(dir, "2024/03/01.pb") + ": 12/17 -> 12/17\n"))
+
+		s := read(filepath.Join(dir, "2024/01.pb"))
+		Expect(s.Precision()).To(Equal(uint8(13)))
+		Expect(s.SparsePrecision()).To(Equal(uint8(18)))
+		Expect(s.Estimate()).To(BeNumerically("~", 1_000, 10))
+		Expect(read(filepath.Join(dir, "2024/02.pb")).Estimate()).To(BeNumerically("~", 20_000, 1_000))
+		Expect(read(filepath.Join(dir, "2024/03/01.pb")).Precision()).To(Equal(uint8(12)))
+
+		// no temporary files are left behind
+		entries, err := os.ReadDir(filepath.Join(dir, "2024"))
+		Expect(err).NotTo(HaveOccurred())
+		Expect(entries).To(HaveLen(4))
+	})
+
+	It("should write to an output directory", func() {
+		output := filepath.Join(dir, "archive")
+		_, err := execute("", "downgrade", "-precision", "12", "-o", output, "-pattern", "*.pb", filepath.Join(dir, "2024"), filepath.Join(dir, "2024/01.pb"))
+		Expect(err).NotTo(HaveOccurred())
+
+		Expect(read(filepath.Join(output, "01.pb")).Precision()).To(Equal(uint8(12)))
+		Expect(read(filepath.Join(output, "02.pb")).SparsePrecision()).To(Equal(uint8(20)))
+		Expect(read(filepath.Join(output, "03/01.pb")).Precision()).To(Equal(uint8(12)))
+		Expect(read(filepath.Join(dir, "2024/01.pb")).Precision()).To(Equal(uint8(15)))
+	})
+
+	It("should reject invalid input", func() {
+		_, err := execute("", "downgrade", "-precision", "12", filepath.Join(dir, "2024"))
+		Expect(err).To(MatchError(HavePrefix(filepath.Join(dir, "2024/README") + ": invalid sketch: ")))
+		_, err = execute("", "downgrade", "-precision", "12")
+		Expect(err).To(MatchError("downgrade: no files given"))
+		_, err = execute("", "downgrade", dir)
+		Expect(err).To(MatchError("downgrade: a target precision is required"))
+		_, err = execute("", "downgrade", "-precision", "12", "-pattern", "[", dir)
+		Expect(err).To(MatchError(`invalid pattern "[": syntax error in pattern`))
+		_, err = execute("", "downgrade", "-precision", "12", filepath.Join(dir, "missing"))
+		Expect(err).To(MatchError(ContainSubstring("no such file or directory")))
+		_, err = execute("", "downgrade", "-precision", "9", "-pattern", "*.pb", dir)
+		Expect(err).To(MatchError(ContainSubstring("invalid normal precision 9")))
+	})
+})
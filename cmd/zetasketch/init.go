@@ -0,0 +1,102 @@
+package main
+
+import (
+	"bufio"
+	"fmt"
+	"io"
+	"strconv"
+	"strings"
+
+	"github.com/gowthamkommineni/zetasketch/convert"
+	"github.com/gowthamkommineni/zetasketch/hllplus"
+)
+
+// maxLineSize limits the size of values read by init.
+const maxLineSize = 64 << 20
+
+// hashers are the hash functions which can be selected by ID.
+var hashers = map[string]hllplus.Hasher{
+	hllplus.Fingerprint2011.ID(): hllplus.Fingerprint2011,
+	hllplus.XXHash64.ID():        hllplus.XXHash64,
+	hllplus.Murmur3.ID():         hllplus.Murmur3,
+	convert.RedisHasher.ID():     convert.RedisHasher,
+	convert.PrestoHasher.ID():    convert.PrestoHasher,
+}
+
+func runInit(args []string, stdin io.Reader, stdout, stderr io.Writer) error {
+	fs := newFlagSet("init", stderr)
+	isBase64 := fs.Bool("base64", false, "encode output as base64")
+	precision := fs.Uint("precision", 15, "normal precision")
+	sparsePrecision := fs.Uint("sparse-precision", 20, "sparse precision")
+	hasher := fs.String("hasher", hllplus.Fingerprint2011.ID(), "hash function, one of fingerprint2011 (BigQuery-compatible), xxhash64, murmur3-128, redis-murmur64a or airlift-murmur3-128; it is not serialized, further values must be added using the same one")
+	seed := fs.Uint64("seed", 0, "hash seed")
+	valueType := fs.String("type", "string", "type of values, one of string, int64, uint64 or float64")
+	output := fs.String("o", "", "write the sketch to this file instead of stdout")
+	if err := fs.Parse(args); err != nil {
+		return err
+	}
+	if fs.NArg() != 0 {
+		fs.Usage()
+		return fmt.Errorf("init: too many arguments")
+	}
+
+	h, ok := hashers[*hasher]
+	if !ok {
+		return fmt.Errorf("invalid hasher %q", *hasher)
+	}
+	if *precision > 255 || *sparsePrecision > 255 {
+		return fmt.Errorf("invalid precision %d/%d", *precision, *sparsePrecision)
+	}
+	s, err := hllplus.New(uint8(*precision), uint8(*sparsePrecision), hllplus.WithHasher(h), hllplus.WithSeed(*seed))
+	if err != nil {
+		return err
+	}
+
+	var add func(line string) error
+	switch *valueType {
+	case "string":
+		add = func(line string) error { s.AddString(line); return nil }
+	case "int64":
+		add = func(line string) error {
+			v, err := strconv.ParseInt(strings.TrimSpace(line), 10, 64)
+			if err == nil {
+				s.AddInt64(v)
+			}
+			return err
+		}
+	case "uint64":
+		add = func(line string) error {
+			v, err := strconv.ParseUint(strings.TrimSpace(line), 10, 64)
+			if err == nil {
+				s.AddUint64(v)
+			}
+			return err
+		}
+	case "float64":
+		add = func(line string) error {
+			v, err := strconv.ParseFloat(strings.TrimSpace(line), 64)
+			if err == nil {
+				s.AddFloat64(v)
+			}
+			return err
+		}
+	default:
+		return fmt.Errorf("invalid type %q", *valueType)
+	}
+
+	scanner := bufio.NewScanner(stdin)
+	scanner.Buffer(make([]byte, 0, 64*1024), maxLineSize)
+	for n := 1; scanner.Scan(); n++ {
+		line := strings.TrimSuffix(scanner.Text(), "\r")
+		if line == "" {
+			continue
+		}
+		if err := add(line); err != nil {
+			return fmt.Errorf("line %d: %w", n, err)
+		}
+	}
+	if err := scanner.Err(); err != nil {
+		return err
+	}
+	return writeSketch(*output, stdout, s, *isBase64)
+}
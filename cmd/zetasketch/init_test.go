@@ -0,0 +1,73 @@
+package main
+
+import (
+	"encoding/base64"
+	"fmt"
+	"strings"
+
+	"github.com/gowthamkommineni/zetasketch/hllplus"
+
+	. "github.com/bsm/ginkgo"
+	. "github.com/bsm/gomega"
+)
+
+var _ = Describe("init", func() {
+	It("should build sketches from lines", func() {
+		out, err := execute("alice\nbob\r\n\nalice\ncarol", "init")
+		Expect(err).NotTo(HaveOccurred())
+
+		s := new(hllplus.HLL)
+		Expect(s.Unmarshal([]byte(out))).To(Succeed())
+		Expect(s.Precision()).To(Equal(uint8(15)))
+		Expect(s.SparsePrecision()).To(Equal(uint8(20)))
+		Expect(s.ValueType()).To(Equal(hllplus.ValueTypeBytes))
+		Expect(s.NumValues()).To(Equal(int64(4)))
+		Expect(s.Estimate()).To(Equal(int64(3)))
+
+		// compatible with sketches built by the library
+		expected, _ := hllplus.New(15, 20)
+		for _, v := range []string{"alice", "bob", "alice", "carol"} {
+			expected.AddString(v)
+		}
+		Expect(expected.Marshal()).To(Equal([]byte(out)))
+	})
+
+	It("should support options", func() {
+		var lines strings.Builder
+		for i := 0; i < 1_000; i++ {
+			fmt.Fprintf(&lines, "%d\n", i%500)
+		}
+
+		out, err := execute(lines.String(), "init", "-type", "int64", "-precision", "12", "-sparse-precision", "17", "-hasher", "xxhash64", "-seed", "7", "-base64")
+		Expect(err).NotTo(HaveOccurred())
+		data, err := base64.StdEncoding.DecodeString(strings.TrimSpace(out))
+		Expect(err).NotTo(HaveOccurred())
+
+		s, _ := hllplus.New(12, 17, hllplus.WithHasher(hllplus.XXHash64), hllplus.WithSeed(7))
+		Expect(s.Unmarshal(data)).To(Succeed())
+		Expect(s.Precision()).To(Equal(uint8(12)))
+		Expect(s.ValueType()).To(Equal(hllplus.ValueTypeInt64))
+		Expect(s.Estimate()).To(BeNumerically("~", 500, 5))
+
+		expected, _ := hllplus.New(12, 17, hllplus.WithHasher(hllplus.XXHash64), hllplus.WithSeed(7))
+		for i := 0; i < 1_000; i++ {
+			expected.AddInt64(int64(i % 500))
+		}
+		Expect(s.Estimate()).To(Equal(expected.Estimate()))
+	})
+
+	It("should reject invalid input", func() {
+		_, err := execute("1\n2\nx\n", "init", "-type", "int64")
+		Expect(err).To(MatchError(`line 3: strconv.ParseInt: parsing "x": invalid syntax`))
+		_, err = execute("", "init", "-type", "time")
+		Expect(err).To(MatchError(`invalid type "time"`))
+		_, err = execute("", "init", "-hasher", "md5")
+		Expect(err).To(MatchError(`invalid hasher "md5"`))
+		_, err = execute("", "init", "-precision", "30")
+		Expect(err).To(MatchError("invalid normal precision 30"))
+		_, err = execute("", "init", "-precision", "270")
+		Expect(err).To(MatchError("invalid precision 270/20"))
+		_, err = execute("", "init", "values.txt")
+		Expect(err).To(MatchError("init: too many arguments"))
+	})
+})
@@ -0,0 +1,110 @@
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"text/tabwriter"
+
+	"github.com/gowthamkommineni/zetasketch/hllplus"
+)
+
+// inspection describes a sketch.
+type inspection struct {
+	Precision       uint8   `json:"precision"`
+	SparsePrecision uint8   `json:"sparse_precision"`
+	Representation  string  `json:"representation"`
+	Hasher          string  `json:"hasher"`
+	ValueType       string  `json:"value_type"`
+	NumValues       int64   `json:"num_values"`
+	SerializedSize  int     `json:"serialized_size"`
+	Registers       int     `json:"registers"`
+	NonZero         int     `json:"non_zero_registers"`
+	MaxRegister     uint8   `json:"max_register"`
+	MeanRegister    float64 `json:"mean_register"`
+	LinearCounting  bool    `json:"linear_counting"`
+	Estimate        int64   `json:"estimate"`
+}
+
+func runInspect(args []string, stdin io.Reader, stdout, stderr io.Writer) error {
+	fs := newFlagSet("inspect", stderr)
+	isBase64 := fs.Bool("base64", false, "decode base64-encoded input")
+	asJSON := fs.Bool("json", false, "print as JSON")
+	if err := fs.Parse(args); err != nil {
+		return err
+	}
+	if fs.NArg() > 1 {
+		fs.Usage()
+		return fmt.Errorf("inspect: too many arguments")
+	}
+
+	s, err := readSketch(fs.Arg(0), stdin, *isBase64)
+	if err != nil {
+		return err
+	}
+
+	info, err := inspect(s)
+	if err != nil {
+		return err
+	}
+	if *asJSON {
+		enc := json.NewEncoder(stdout)
+		enc.SetIndent("", "  ")
+		return enc.Encode(info)
+	}
+
+	w := tabwriter.NewWriter(stdout, 0, 0, 2, ' ', 0)
+	fmt.Fprintf(w, "precision:\t%d\n", info.Precision)
+	fmt.Fprintf(w, "sparse precision:\t%d\n", info.SparsePrecision)
+	fmt.Fprintf(w, "representation:\t%s\n", info.Representation)
+	fmt.Fprintf(w, "hasher:\t%s\n", info.Hasher)
+	fmt.Fprintf(w, "value type:\t%s\n", info.ValueType)
+	fmt.Fprintf(w, "values:\t%d\n", info.NumValues)
+	fmt.Fprintf(w, "serialized size:\t%d bytes\n", info.SerializedSize)
+	fmt.Fprintf(w, "registers:\t%d\n", info.Registers)
+	fmt.Fprintf(w, "non-zero registers:\t%d (%.2f%%)\n", info.NonZero, 100*float64(info.NonZero)/float64(info.Registers))
+	fmt.Fprintf(w, "max register:\t%d\n", info.MaxRegister)
+	fmt.Fprintf(w, "mean register:\t%.4f\n", info.MeanRegister)
+	fmt.Fprintf(w, "linear counting:\t%t\n", info.LinearCounting)
+	fmt.Fprintf(w, "estimate:\t%d\n", info.Estimate)
+	return w.Flush()
+}
+
+func inspect(s *hllplus.HLL) (*inspection, error) {
+	data, err := s.Marshal()
+	if err != nil {
+		return nil, err
+	}
+
+	info := &inspection{
+		Precision:       s.Precision(),
+		SparsePrecision: s.SparsePrecision(),
+		Representation:  "dense",
+		Hasher:          s.Hasher().ID(),
+		ValueType:       s.ValueType().String(),
+		NumValues:       s.NumValues(),
+		SerializedSize:  len(data),
+	}
+	if len(s.Proto().Data) == 0 {
+		info.Representation = "sparse"
+	}
+
+	registers := s.Registers()
+	var sum int
+	for _, r := range registers {
+		if r != 0 {
+			info.NonZero++
+		}
+		if r > info.MaxRegister {
+			info.MaxRegister = r
+		}
+		sum += int(r)
+	}
+	info.Registers = len(registers)
+	info.MeanRegister = float64(sum) / float64(len(registers))
+
+	details := s.EstimateDetails()
+	info.LinearCounting = details.LinearCounting
+	info.Estimate = details.Estimate
+	return info, nil
+}
@@ -0,0 +1,72 @@
+package main
+
+import (
+	"encoding/base64"
+	"encoding/json"
+	"os"
+	"path/filepath"
+
+	. "github.com/bsm/ginkgo"
+	. "github.com/bsm/gomega"
+)
+
+var _ = Describe("inspect", func() {
+	var dir string
+
+	BeforeEach(func() {
+		var err error
+		dir, err = os.MkdirTemp("", "zetasketch")
+		Expect(err).NotTo(HaveOccurred())
+	})
+
+	AfterEach(func() {
+		Expect(os.RemoveAll(dir)).To(Succeed())
+	})
+
+	It("should inspect sparse sketches", func() {
+		out, err := execute(string(sketchOf(100, 14, 19)), "inspect")
+		Expect(err).NotTo(HaveOccurred())
+		Expect(out).To(ContainSubstring("precision:           14\n"))
+		Expect(out).To(ContainSubstring("sparse precision:    19\n"))
+		Expect(out).To(ContainSubstring("representation:      sparse\n"))
+		Expect(out).To(ContainSubstring("hasher:              fingerprint2011\n"))
+		Expect(out).To(ContainSubstring("value type:          BYTES_OR_UTF8_STRING\n"))
+		Expect(out).To(ContainSubstring("values:              100\n"))
+		Expect(out).To(ContainSubstring("registers:           16384\n"))
+		Expect(out).To(ContainSubstring("estimate:            100\n"))
+	})
+
+	It("should inspect files as JSON", func() {
+		path := filepath.Join(dir, "sketch.pb")
+		Expect(os.WriteFile(path, sketchOf(20_000, 10, 15), 0o644)).To(Succeed())
+
+		out, err := execute("", "inspect", "-json", path)
+		Expect(err).NotTo(HaveOccurred())
+
+		var info inspection
+		Expect(json.Unmarshal([]byte(out), &info)).To(Succeed())
+		Expect(info.Precision).To(Equal(uint8(10)))
+		Expect(info.Representation).To(Equal("dense"))
+		Expect(info.Registers).To(Equal(1024))
+		Expect(info.NonZero).To(Equal(1024))
+		Expect(info.MaxRegister).To(BeNumerically(">", 10))
+		Expect(info.MeanRegister).To(BeNumerically("~", 5.5, 0.5))
+		Expect(info.LinearCounting).To(BeFalse())
+		Expect(info.Estimate).To(BeNumerically("~", 20_000, 1_000))
+	})
+
+	It("should inspect base64 input", func() {
+		out, err := execute(base64.StdEncoding.EncodeToString(sketchOf(3, 14, 19))+"\n", "inspect", "-base64", "-")
+		Expect(err).NotTo(HaveOccurred())
+		Expect(out).To(ContainSubstring("estimate:            3\n"))
+	})
+
+	It("should reject invalid input", func() {
+		_, err := execute("not base64", "inspect", "-base64")
+		Expect(err).To(MatchError(HavePrefix("stdin: invalid base64: ")))
+		_, err = execute("", "inspect", "a", "b")
+		Expect(err).To(MatchError("inspect: too many arguments"))
+		_, err = execute("", "inspect", "missing.pb")
+		Expect(err).To(MatchError(ContainSubstring("no such file or directory")))
+	})
+})
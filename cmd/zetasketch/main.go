@@ -0,0 +1,220 @@
+// Command zetasketch inspects and manipulates serialized HyperLogLog++ sketches, in the
+// format used by BigQuery's HLL_COUNT functions and the zetasketch Java library.
+//
+// Usage:
+//
+//	zetasketch <command> [flags] [args]
+//
+// Commands:
+//
+//	inspect    print the precision, representation, register stats and estimate of a sketch
+//	merge      merge sketch files, given as glob patterns or listed on stdin, into one
+//	init       build a sketch from newline-delimited values on stdin
+//	convert    convert sketches between the formats of other systems
+//	downgrade  rewrite sketch files and directories to lower precisions
+//
+// Sketches are read from files, or from stdin if no file or "-" is given, and written to
+// stdout unless an output file is given. Use the -base64 flag for base64-encoded input
+// and output, e.g. as returned by BigQuery's TO_BASE64.
+package main
+
+import (
+	"bytes"
+	"context"
+	"encoding/base64"
+	"flag"
+	"fmt"
+	"io"
+	"os"
+	"runtime"
+	"sort"
+	"sync"
+
+	"github.com/gowthamkommineni/zetasketch/hllplus"
+)
+
+type command struct {
+	usage string
+	run   func(args []string, stdin io.Reader, stdout, stderr io.Writer) error
+}
+
+// commands are registered in init, since they refer to commands for their usage.
+var commands map[string]command
+
+func init() {
+	commands = map[string]command{
+		"convert":   {usage: "[-from format] [-to format] [-hasher name] [-base64] [-o file] [file]", run: runConvert},
+		"downgrade": {usage: "[-precision p] [-sparse-precision sp] [-pattern glob] [-parallelism n] [-base64] [-o dir] path ...", run: runDowngrade},
+		"init":      {usage: "[-base64] [-precision p] [-sparse-precision sp] [-hasher name] [-seed n] [-type t] [-o file] < values", run: runInit},
+		"inspect":   {usage: "[-base64] [-json] [file]", run: runInspect},
+		"merge":     {usage: "[-base64] [-precision p] [-sparse-precision sp] [-parallelism n] [-o file] [pattern ...]", run: runMerge},
+	}
+}
+
+func main() {
+	if err := run(os.Args[1:], os.Stdin, os.Stdout, os.Stderr); err != nil {
+		if err != flag.ErrHelp {
+			fmt.Fprintln(os.Stderr, "zetasketch:", err)
+		}
+		os.Exit(2)
+	}
+}
+
+// run executes the command given by args.
+func run(args []string, stdin io.Reader, stdout, stderr io.Writer) error {
+	if len(args) == 0 || args[0] == "-h" || args[0] == "-help" || args[0] == "help" {
+		printUsage(stderr)
+		return flag.ErrHelp
+	}
+
+	cmd, ok := commands[args[0]]
+	if !ok {
+		printUsage(stderr)
+		return fmt.Errorf("unknown command %q", args[0])
+	}
+	return cmd.run(args[1:], stdin, stdout, stderr)
+}
+
+func printUsage(w io.Writer) {
+	names := make([]string, 0, len(commands))
+	for name := range commands {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+
+	fmt.Fprintln(w, "Usage: zetasketch <command> [flags] [args]")
+	fmt.Fprintln(w)
+	fmt.Fprintln(w, "Commands:")
+	for _, name := range names {
+		fmt.Fprintf(w, "  %s %s\n", name, commands[name].usage)
+	}
+}
+
+// newFlagSet inits a flag set for a command, which reports errors instead of exiting.
+func newFlagSet(name string, stderr io.Writer) *flag.FlagSet {
+	fs := flag.NewFlagSet("zetasketch "+name, flag.ContinueOnError)
+	fs.SetOutput(stderr)
+	fs.Usage = func() {
+		fmt.Fprintf(fs.Output(), "Usage: zetasketch %s %s\n", name, commands[name].usage)
+		fs.PrintDefaults()
+	}
+	return fs
+}
+
+// parallelize calls fn for 0 <= i < n using up to parallelism goroutines. If
+// parallelism is <= 0, runtime.GOMAXPROCS(0) is used. The first error stops all workers
+// and is returned.
+func parallelize(ctx context.Context, n, parallelism int, fn func(i int) error) error {
+	if parallelism <= 0 {
+		parallelism = runtime.GOMAXPROCS(0)
+	}
+
+	ctx, cancel := context.WithCancel(ctx)
+	defer cancel()
+
+	indices := make(chan int)
+	go func() {
+		defer close(indices)
+
+		for i := 0; i < n; i++ {
+			select {
+			case indices <- i:
+			case <-ctx.Done():
+				return
+			}
+		}
+	}()
+
+	var once sync.Once
+	var firstErr error
+	var wg sync.WaitGroup
+	for w := 0; w < parallelism; w++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+
+			for i := range indices {
+				if err := fn(i); err != nil {
+					once.Do(func() { firstErr = err })
+					cancel()
+					return
+				}
+			}
+		}()
+	}
+	wg.Wait()
+
+	if firstErr != nil {
+		return firstErr
+	}
+	return ctx.Err()
+}
+
+// readInput reads a file, or stdin if path is empty or "-".
+func readInput(path string, stdin io.Reader) ([]byte, error) {
+	if path == "" || path == "-" {
+		return io.ReadAll(stdin)
+	}
+	return os.ReadFile(path)
+}
+
+// readSketch reads and parses a serialized sketch, see readInput.
+func readSketch(path string, stdin io.Reader, isBase64 bool) (*hllplus.HLL, error) {
+	data, err := readInput(path, stdin)
+	if err != nil {
+		return nil, err
+	}
+	return parseSketch(path, data, isBase64)
+}
+
+func parseSketch(path string, data []byte, isBase64 bool) (*hllplus.HLL, error) {
+	if isBase64 {
+		var err error
+		if data, err = decodeBase64(path, data); err != nil {
+			return nil, err
+		}
+	}
+
+	s := new(hllplus.HLL)
+	if err := s.Unmarshal(data); err != nil {
+		return nil, fmt.Errorf("%s: invalid sketch: %w", inputName(path), err)
+	}
+	return s, nil
+}
+
+func decodeBase64(path string, data []byte) ([]byte, error) {
+	decoded := make([]byte, base64.StdEncoding.DecodedLen(len(data)))
+	n, err := base64.StdEncoding.Decode(decoded, bytes.TrimSpace(data))
+	if err != nil {
+		return nil, fmt.Errorf("%s: invalid base64: %w", inputName(path), err)
+	}
+	return decoded[:n], nil
+}
+
+// writeSketch serializes a sketch to a file, or stdout if path is empty or "-".
+func writeSketch(path string, stdout io.Writer, s *hllplus.HLL, isBase64 bool) error {
+	data, err := s.Marshal()
+	if err != nil {
+		return err
+	}
+	if isBase64 {
+		data = append([]byte(base64.StdEncoding.EncodeToString(data)), '\n')
+	}
+	return writeOutput(path, stdout, data)
+}
+
+// writeOutput writes data to a file, or stdout if path is empty or "-".
+func writeOutput(path string, stdout io.Writer, data []byte) error {
+	if path == "" || path == "-" {
+		_, err := stdout.Write(data)
+		return err
+	}
+	return os.WriteFile(path, data, 0o644)
+}
+
+func inputName(path string) string {
+	if path == "" || path == "-" {
+		return "stdin"
+	}
+	return path
+}
@@ -0,0 +1,57 @@
+package main
+
+import (
+	"bytes"
+	"fmt"
+	"strings"
+	"testing"
+
+	"github.com/gowthamkommineni/zetasketch/hllplus"
+
+	. "github.com/bsm/ginkgo"
+	. "github.com/bsm/gomega"
+)
+
+var _ = Describe("run", func() {
+	It("should print usage", func() {
+		var stderr bytes.Buffer
+		Expect(run(nil, nil, nil, &stderr)).To(MatchError("flag: help requested"))
+		Expect(stderr.String()).To(ContainSubstring("Usage: zetasketch <command> [flags] [args]"))
+		Expect(stderr.String()).To(ContainSubstring("  inspect [-base64] [-json] [file]\n"))
+
+		stderr.Reset()
+		Expect(run([]string{"unknown"}, nil, nil, &stderr)).To(MatchError(`unknown command "unknown"`))
+		Expect(stderr.String()).To(ContainSubstring("Commands:"))
+	})
+})
+
+// execute runs a command with stdin and returns its output.
+func execute(stdin string, args ...string) (string, error) {
+	var stdout, stderr bytes.Buffer
+	err := run(args, strings.NewReader(stdin), &stdout, &stderr)
+	return stdout.String(), err
+}
+
+// sketchOf builds a serialized sketch of n string values.
+func sketchOf(n int, precision, sparsePrecision uint8) []byte {
+	s, err := hllplus.New(precision, sparsePrecision)
+	Expect(err).NotTo(HaveOccurred())
+	for i := 0; i < n; i++ {
+		s.AddString(fmt.Sprintf("value-%d", i))
+	}
+	data, err := s.Marshal()
+	Expect(err).NotTo(HaveOccurred())
+	return data
+}
+
+// estimateOf parses a serialized sketch and returns its estimate.
+func estimateOf(data []byte) int64 {
+	s := new(hllplus.HLL)
+	Expect(s.Unmarshal(data)).To(Succeed())
+	return s.Estimate()
+}
+
+func TestSuite(t *testing.T) {
+	RegisterFailHandler(Fail)
+	RunSpecs(t, "zetasketch/cmd/zetasketch")
+}
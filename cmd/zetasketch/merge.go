@@ -0,0 +1,103 @@
+package main
+
+import (
+	"bufio"
+	"context"
+	"fmt"
+	"io"
+	"path/filepath"
+	"runtime"
+	"strings"
+
+	"github.com/gowthamkommineni/zetasketch/hllplus"
+)
+
+func runMerge(args []string, stdin io.Reader, stdout, stderr io.Writer) error {
+	fs := newFlagSet("merge", stderr)
+	isBase64 := fs.Bool("base64", false, "decode base64-encoded input and encode output")
+	precision := fs.Uint("precision", 0, "downgrade the result to this precision")
+	sparsePrecision := fs.Uint("sparse-precision", 0, "downgrade the result to this sparse precision")
+	parallelism := fs.Int("parallelism", runtime.GOMAXPROCS(0), "number of files read and merged concurrently")
+	output := fs.String("o", "", "write the result to this file instead of stdout")
+	if err := fs.Parse(args); err != nil {
+		return err
+	}
+
+	if *precision > 255 || *sparsePrecision > 255 {
+		return fmt.Errorf("invalid precision %d/%d", *precision, *sparsePrecision)
+	}
+
+	paths, err := listFiles(fs.Args(), stdin)
+	if err != nil {
+		return err
+	}
+	if len(paths) == 0 {
+		return fmt.Errorf("merge: no files given")
+	}
+
+	ctx := context.Background()
+	sketches, err := readSketches(ctx, paths, *isBase64, *parallelism)
+	if err != nil {
+		return err
+	}
+	s, err := hllplus.MergeAll(ctx, sketches, *parallelism)
+	if err != nil {
+		return err
+	}
+
+	if *precision != 0 || *sparsePrecision != 0 {
+		p, sp := uint8(*precision), uint8(*sparsePrecision)
+		if p == 0 {
+			p = s.Precision()
+		}
+		if sp == 0 {
+			sp = s.SparsePrecision()
+		}
+		if err := s.Downgrade(p, sp); err != nil {
+			return err
+		}
+	}
+	return writeSketch(*output, stdout, s, *isBase64)
+}
+
+// listFiles expands glob patterns, or reads a list of files from stdin, one per line,
+// if no patterns are given.
+func listFiles(patterns []string, stdin io.Reader) ([]string, error) {
+	if len(patterns) == 0 {
+		var paths []string
+		scanner := bufio.NewScanner(stdin)
+		for scanner.Scan() {
+			if path := strings.TrimSpace(scanner.Text()); path != "" {
+				paths = append(paths, path)
+			}
+		}
+		return paths, scanner.Err()
+	}
+
+	var paths []string
+	for _, pattern := range patterns {
+		matches, err := filepath.Glob(pattern)
+		if err != nil {
+			return nil, fmt.Errorf("invalid pattern %q: %w", pattern, err)
+		}
+		if len(matches) == 0 {
+			return nil, fmt.Errorf("no files match %q", pattern)
+		}
+		paths = append(paths, matches...)
+	}
+	return paths, nil
+}
+
+// readSketches reads and parses files using up to parallelism goroutines.
+func readSketches(ctx context.Context, paths []string, isBase64 bool, parallelism int) ([]*hllplus.HLL, error) {
+	sketches := make([]*hllplus.HLL, len(paths))
+	err := parallelize(ctx, len(paths), parallelism, func(i int) error {
+		s, err := readSketch(paths[i], nil, isBase64)
+		sketches[i] = s
+		return err
+	})
+	if err != nil {
+		return nil, err
+	}
+	return sketches, nil
+}
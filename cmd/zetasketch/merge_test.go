@@ -0,0 +1,104 @@
+package main
+
+import (
+	"encoding/base64"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"github.com/gowthamkommineni/zetasketch/hllplus"
+
+	. "github.com/bsm/ginkgo"
+	. "github.com/bsm/gomega"
+)
+
+var _ = Describe("merge", func() {
+	var dir string
+
+	BeforeEach(func() {
+		var err error
+		dir, err = os.MkdirTemp("", "zetasketch")
+		Expect(err).NotTo(HaveOccurred())
+
+		// day-N.pb contains values N*100 ... N*100+199, so days overlap by half
+		for day := 0; day < 4; day++ {
+			s, _ := hllplus.New(14, 19)
+			for i := day * 100; i < day*100+200; i++ {
+				s.AddString(fmt.Sprintf("value-%d", i))
+			}
+			data, err := s.Marshal()
+			Expect(err).NotTo(HaveOccurred())
+			Expect(os.WriteFile(filepath.Join(dir, fmt.Sprintf("day-%d.pb", day)), data, 0o644)).To(Succeed())
+		}
+	})
+
+	AfterEach(func() {
+		Expect(os.RemoveAll(dir)).To(Succeed())
+	})
+
+	It("should merge globs", func() {
+		out, err := execute("", "merge", "-parallelism", "2", filepath.Join(dir, "day-*.pb"))
+		Expect(err).NotTo(HaveOccurred())
+		Expect(estimateOf([]byte(out))).To(Equal(int64(500)))
+
+		out, err = execute("", "merge", filepath.Join(dir, "day-0.pb"), filepath.Join(dir, "day-3.pb"))
+		Expect(err).NotTo(HaveOccurred())
+		Expect(estimateOf([]byte(out))).To(Equal(int64(400)))
+	})
+
+	It("should merge files listed on stdin", func() {
+		list := filepath.Join(dir, "day-1.pb") + "\n\n" + filepath.Join(dir, "day-2.pb") + "\n"
+		output := filepath.Join(dir, "merged.pb")
+		out, err := execute(list, "merge", "-o", output)
+		Expect(err).NotTo(HaveOccurred())
+		Expect(out).To(BeEmpty())
+
+		data, err := os.ReadFile(output)
+		Expect(err).NotTo(HaveOccurred())
+		Expect(estimateOf(data)).To(Equal(int64(300)))
+	})
+
+	It("should downgrade the result", func() {
+		out, err := execute("", "merge", "-precision", "12", filepath.Join(dir, "day-*.pb"))
+		Expect(err).NotTo(HaveOccurred())
+		s := new(hllplus.HLL)
+		Expect(s.Unmarshal([]byte(out))).To(Succeed())
+		Expect(s.Precision()).To(Equal(uint8(12)))
+		Expect(s.SparsePrecision()).To(Equal(uint8(19)))
+
+		out, err = execute("", "merge", "-precision", "12", "-sparse-precision", "17", filepath.Join(dir, "day-*.pb"))
+		Expect(err).NotTo(HaveOccurred())
+		Expect(s.Unmarshal([]byte(out))).To(Succeed())
+		Expect(s.SparsePrecision()).To(Equal(uint8(17)))
+		Expect(s.Estimate()).To(BeNumerically("~", 500, 5))
+	})
+
+	It("should read and write base64", func() {
+		for _, name := range []string{"a", "b"} {
+			data, err := os.ReadFile(filepath.Join(dir, "day-0.pb"))
+			Expect(err).NotTo(HaveOccurred())
+			Expect(os.WriteFile(filepath.Join(dir, name+".b64"), []byte(base64.StdEncoding.EncodeToString(data)), 0o644)).To(Succeed())
+		}
+
+		out, err := execute("", "merge", "-base64", filepath.Join(dir, "*.b64"))
+		Expect(err).NotTo(HaveOccurred())
+		Expect(out).To(HaveSuffix("\n"))
+		data, err := base64.StdEncoding.DecodeString(strings.TrimSpace(out))
+		Expect(err).NotTo(HaveOccurred())
+		Expect(estimateOf(data)).To(Equal(int64(200)))
+	})
+
+	It("should reject invalid input", func() {
+		_, err := execute("", "merge")
+		Expect(err).To(MatchError("merge: no files given"))
+		_, err = execute("", "merge", filepath.Join(dir, "*.missing"))
+		Expect(err).To(MatchError(fmt.Sprintf("no files match %q", filepath.Join(dir, "*.missing"))))
+		_, err = execute("", "merge", "[")
+		Expect(err).To(MatchError(`invalid pattern "[": syntax error in pattern`))
+
+		Expect(os.WriteFile(filepath.Join(dir, "day-9.pb"), []byte("garbage"), 0o644)).To(Succeed())
+		_, err = execute("", "merge", filepath.Join(dir, "day-*.pb"))
+		Expect(err).To(MatchError(HavePrefix(filepath.Join(dir, "day-9.pb") + ": invalid sketch: ")))
+	})
+})
@@ -0,0 +1,149 @@
+package convert
+
+import (
+	"encoding/binary"
+	"fmt"
+	"math/bits"
+
+	"github.com/gowthamkommineni/zetasketch/hllplus"
+)
+
+// Algebird HyperLogLog constants, see
+// https://github.com/twitter/algebird/blob/develop/algebird-core/src/main/scala/com/twitter/algebird/HyperLogLog.scala.
+const (
+	algebirdDense  = 1
+	algebirdSparse = 3
+	algebirdSeed   = 12345678
+)
+
+// AlgebirdHasher returns a hasher which hashes values like Algebird's HyperLogLogMonoid
+// with the given number of bits does for byte arrays. Algebird uses the lowest bits of a
+// 128-bit MurmurHash3 as the register index and the number of trailing zeros of the
+// remaining bits as the register value; hashes are re-arranged, so that hllplus sketches
+// with precision bits derive the same registers.
+func AlgebirdHasher(bits uint8) hllplus.Hasher {
+	return algebirdHasher{bits: bits}
+}
+
+type algebirdHasher struct{ bits uint8 }
+
+func (h algebirdHasher) ID() string { return fmt.Sprintf("algebird-murmur3-bits%d", h.bits) }
+func (h algebirdHasher) Hash64(p []byte) uint64 {
+	// Algebird reads the big-endian hash as a little-endian bit set
+	h1, _ := cassandraMurmur3(p, algebirdSeed)
+	return trailingZerosHash64(bits.ReverseBytes64(h1), h.bits)
+}
+
+// FromAlgebird converts a HyperLogLog serialized by Algebird's HyperLogLog.toBytes into a
+// sketch with a precision of the HLL's bits. Dense and sparse HLLs are supported. The
+// sketch uses the AlgebirdHasher for bits, additional options can be passed.
+func FromAlgebird(data []byte, opts ...hllplus.Option) (*hllplus.HLL, error) {
+	if len(data) < 2 {
+		return nil, fmt.Errorf("invalid Algebird HLL: too short")
+	}
+
+	precision := data[1]
+	if precision < hllplus.MinPrecision || precision > hllplus.MaxPrecision {
+		return nil, fmt.Errorf("cannot convert Algebird HLL with %d bits: must be between %d and %d", precision, hllplus.MinPrecision, hllplus.MaxPrecision)
+	}
+
+	registers := make([]byte, 1<<precision)
+	body := data[2:]
+
+	switch data[0] {
+	case algebirdDense:
+		if len(body) != len(registers) {
+			return nil, fmt.Errorf("invalid Algebird HLL: %d registers, expected %d", len(body), len(registers))
+		}
+		copy(registers, body)
+	case algebirdSparse:
+		indexLen := int(precision+7) / 8
+		if len(body)%(indexLen+1) != 0 {
+			return nil, fmt.Errorf("invalid Algebird HLL: sparse data of %d bytes", len(body))
+		}
+		for ; len(body) != 0; body = body[indexLen+1:] {
+			var index int
+			for _, b := range body[1 : indexLen+1] {
+				index = index<<8 | int(b)
+			}
+			if index >= len(registers) {
+				return nil, fmt.Errorf("invalid Algebird HLL: register %d out of range", index)
+			}
+			if v := body[0]; v > registers[index] {
+				registers[index] = v
+			}
+		}
+	default:
+		return nil, fmt.Errorf("invalid Algebird HLL: unsupported type %d", data[0])
+	}
+
+	// Algebird counts zeros across 128 hash bits, hllplus across the remaining 64.
+	maxRhoW := 64 - precision + 1
+	for i, v := range registers {
+		if v > maxRhoW {
+			registers[i] = maxRhoW
+		}
+	}
+
+	sparsePrecision := precision + 5
+	if sparsePrecision > hllplus.MaxSparsePrecision {
+		sparsePrecision = hllplus.MaxSparsePrecision
+	}
+
+	opts = append([]hllplus.Option{hllplus.WithHasher(AlgebirdHasher(precision))}, opts...)
+	return hllplus.NewFromRegisters(precision, sparsePrecision, registers, opts...)
+}
+
+// cassandraMurmur3 implements the 128-bit MurmurHash3 variant of Apache Cassandra, which
+// Algebird uses. It differs from the reference implementation by sign-extending the
+// trailing bytes.
+func cassandraMurmur3(p []byte, seed uint64) (uint64, uint64) {
+	const c1, c2 = 0x87c37b91114253d5, 0x4cf5ad432745937f
+
+	h1, h2 := seed, seed
+	n := uint64(len(p))
+	for ; len(p) >= 16; p = p[16:] {
+		k1 := binary.LittleEndian.Uint64(p)
+		k2 := binary.LittleEndian.Uint64(p[8:])
+
+		h1 ^= bits.RotateLeft64(k1*c1, 31) * c2
+		h1 = (bits.RotateLeft64(h1, 27)+h2)*5 + 0x52dce729
+		h2 ^= bits.RotateLeft64(k2*c2, 33) * c1
+		h2 = (bits.RotateLeft64(h2, 31)+h1)*5 + 0x38495ab5
+	}
+
+	var k1, k2 uint64
+	for i := len(p) - 1; i >= 0; i-- {
+		v := uint64(int64(int8(p[i])))
+		if i >= 8 {
+			k2 ^= v << (8 * (i - 8))
+		} else {
+			k1 ^= v << (8 * i)
+		}
+	}
+	if len(p) > 8 {
+		h2 ^= bits.RotateLeft64(k2*c2, 33) * c1
+	}
+	if len(p) > 0 {
+		h1 ^= bits.RotateLeft64(k1*c1, 31) * c2
+	}
+
+	h1 ^= n
+	h2 ^= n
+	h1 += h2
+	h2 += h1
+	h1 = fmix64(h1)
+	h2 = fmix64(h2)
+	h1 += h2
+	h2 += h1
+	return h1, h2
+}
+
+func fmix64(k uint64) uint64 {
+	k ^= k >> 33
+	k *= 0xff51afd7ed558ccd
+	k ^= k >> 33
+	k *= 0xc4ceb9fe1a85ec53
+	k ^= k >> 33
+	return k
+}
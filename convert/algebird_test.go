@@ -0,0 +1,110 @@
+package convert_test
+
+import (
+	"bytes"
+	"encoding/binary"
+	"fmt"
+
+	"github.com/gowthamkommineni/zetasketch/convert"
+	"github.com/gowthamkommineni/zetasketch/hllplus"
+	"github.com/spaolacci/murmur3"
+
+	. "github.com/bsm/ginkgo"
+	. "github.com/bsm/gomega"
+)
+
+var _ = Describe("Algebird", func() {
+	// algebirdJRhoW emulates Algebird's HyperLogLog.jRhoW on the hashed bytes.
+	algebirdJRhoW := func(v []byte, bits int) (int, byte) {
+		l0, l1 := convert.CassandraMurmur3(v, 12345678)
+		hashed := make([]byte, 16)
+		binary.BigEndian.PutUint64(hashed, l0)
+		binary.BigEndian.PutUint64(hashed[8:], l1)
+
+		bit := func(i int) int { return int(hashed[i/8]>>(i%8)) & 1 }
+
+		j := 0
+		for i := 0; i < bits; i++ {
+			j |= bit(i) << i
+		}
+		for i := bits; i < 128; i++ {
+			if bit(i) != 0 {
+				return j, byte(i - bits + 1)
+			}
+		}
+		return j, byte(128 - bits + 1)
+	}
+
+	It("should hash like Cassandra", func() {
+		for n := 0; n < 40; n++ {
+			p := bytes.Repeat([]byte("x"), n)
+			h1, h2 := murmur3.Sum128WithSeed(p, 12345678)
+			c1, c2 := convert.CassandraMurmur3(p, 12345678)
+			Expect([]uint64{c1, c2}).To(Equal([]uint64{h1, h2}), "for length %d", n)
+		}
+
+		// trailing bytes are sign-extended
+		h1, _ := murmur3.Sum128WithSeed([]byte("\xff"), 12345678)
+		c1, _ := convert.CassandraMurmur3([]byte("\xff"), 12345678)
+		Expect(c1).NotTo(Equal(h1))
+	})
+
+	It("should import dense HLLs", func() {
+		registers := make([]byte, 1024)
+		for i := 0; i < 5000; i++ {
+			j, rhoW := algebirdJRhoW([]byte(fmt.Sprintf("v%d", i)), 10)
+			if rhoW > registers[j] {
+				registers[j] = rhoW
+			}
+		}
+
+		s, err := convert.FromAlgebird(append([]byte{1, 10}, registers...))
+		Expect(err).NotTo(HaveOccurred())
+		Expect(s.Precision()).To(Equal(uint8(10)))
+		Expect(s.SparsePrecision()).To(Equal(uint8(15)))
+		Expect(s.Hasher().ID()).To(Equal("algebird-murmur3-bits10"))
+		Expect(s.Registers()).To(Equal(registers))
+		Expect(s.Estimate()).To(BeNumerically("~", 5000, 250))
+
+		// hllplus derives the same registers using the AlgebirdHasher
+		t, err := hllplus.New(10, 15, hllplus.WithHasher(convert.AlgebirdHasher(10)))
+		Expect(err).NotTo(HaveOccurred())
+		for i := 0; i < 5000; i++ {
+			t.AddBytes([]byte(fmt.Sprintf("v%d", i)))
+		}
+		Expect(t.Registers()).To(Equal(registers))
+		Expect(t.Merge(s)).To(Succeed())
+	})
+
+	It("should import sparse HLLs", func() {
+		// rhoW, j (big-endian)
+		s, err := convert.FromAlgebird([]byte{3, 12, 2, 0x00, 0x05, 7, 0x0f, 0xff})
+		Expect(err).NotTo(HaveOccurred())
+		Expect(s.Precision()).To(Equal(uint8(12)))
+
+		registers := s.Registers()
+		Expect(registers[5]).To(Equal(byte(2)))
+		Expect(registers[4095]).To(Equal(byte(7)))
+		Expect(s.Estimate()).To(Equal(int64(2)))
+	})
+
+	It("should reject invalid input", func() {
+		_, err := convert.FromAlgebird([]byte{1})
+		Expect(err).To(MatchError("invalid Algebird HLL: too short"))
+
+		_, err = convert.FromAlgebird([]byte{1, 8})
+		Expect(err).To(MatchError("cannot convert Algebird HLL with 8 bits: must be between 10 and 24"))
+
+		_, err = convert.FromAlgebird([]byte{2, 12})
+		Expect(err).To(MatchError("invalid Algebird HLL: unsupported type 2"))
+
+		_, err = convert.FromAlgebird([]byte{1, 12, 0})
+		Expect(err).To(MatchError("invalid Algebird HLL: 1 registers, expected 4096"))
+
+		_, err = convert.FromAlgebird([]byte{3, 12, 1, 0})
+		Expect(err).To(MatchError("invalid Algebird HLL: sparse data of 2 bytes"))
+
+		_, err = convert.FromAlgebird([]byte{3, 12, 1, 0x10, 0x00})
+		Expect(err).To(MatchError("invalid Algebird HLL: register 4096 out of range"))
+	})
+})
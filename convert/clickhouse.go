@@ -0,0 +1,240 @@
+package convert
+
+import (
+	"encoding/binary"
+	"fmt"
+	"math/bits"
+
+	"github.com/go-faster/city"
+	"github.com/gowthamkommineni/zetasketch/hllplus"
+)
+
+// ClickHouse aggregate state constants, see
+// https://github.com/ClickHouse/ClickHouse/blob/master/src/Common/CombinedCardinalityEstimator.h and
+// https://github.com/ClickHouse/ClickHouse/blob/master/src/Common/HyperLogLogWithSmallSetOptimization.h.
+const (
+	chContainerSmall  = 1
+	chContainerMedium = 2
+	chContainerLarge  = 3
+
+	chUniqHLL12Precision = 12
+
+	// ClickHouse hashes values to 32 bits and stores 5-bit registers.
+	chHashBits     = 32
+	chRegisterBits = 5
+)
+
+// ClickHouseUniqCombinedHasher returns a hasher which hashes strings like ClickHouse's
+// uniqCombined(precision) aggregate function. Numeric values are hashed differently by
+// ClickHouse and cannot be mixed.
+func ClickHouseUniqCombinedHasher(precision uint8) hllplus.Hasher {
+	return clickHouseHasher{id: fmt.Sprintf("clickhouse-uniqcombined-p%d", precision), precision: precision}
+}
+
+// ClickHouseUniqHLL12Hasher hashes strings like ClickHouse's uniqHLL12 aggregate function.
+// Numeric values are hashed differently by ClickHouse and cannot be mixed.
+var ClickHouseUniqHLL12Hasher hllplus.Hasher = clickHouseHasher{id: "clickhouse-uniqhll12", precision: chUniqHLL12Precision, intHash: true}
+
+type clickHouseHasher struct {
+	id        string
+	precision uint8
+	intHash   bool
+}
+
+func (h clickHouseHasher) ID() string { return h.id }
+func (h clickHouseHasher) Hash64(p []byte) uint64 {
+	key := city.CH64(p)
+	if h.intHash {
+		return clickHouseHash64(intHash32(key), h.precision)
+	}
+	return clickHouseHash64(uint32(key), h.precision)
+}
+
+// clickHouseHash64 re-arranges a 32-bit ClickHouse hash, so that hllplus derives the same
+// registers as ClickHouse. ClickHouse uses the lowest precision bits as the register index
+// and the number of trailing zeros of the remaining bits as the register value.
+func clickHouseHash64(h uint32, precision uint8) uint64 {
+	index := uint64(h) & (1<<precision - 1)
+	rest := bits.Reverse64(uint64(h)) & (1<<(64-precision) - 1)
+
+	// cap the value at the number of remaining hash bits, like ClickHouse
+	return index<<(64-precision) | rest | 1<<(chHashBits-1)
+}
+
+// intHash32 implements ClickHouse's IntHash32.
+func intHash32(key uint64) uint32 {
+	key = ^key + key<<18
+	key ^= bits.RotateLeft64(key, -31)
+	key *= 21
+	key ^= bits.RotateLeft64(key, -11)
+	key += key << 6
+	key ^= bits.RotateLeft64(key, -22)
+	return uint32(key)
+}
+
+// FromClickHouseUniqCombined converts the state of ClickHouse's uniqCombined(precision)
+// aggregate function, as returned by uniqCombinedState, into a sketch. The sketch uses
+// the ClickHouseUniqCombinedHasher, additional options can be passed. ClickHouse's
+// default precision is 17.
+func FromClickHouseUniqCombined(data []byte, precision uint8, opts ...hllplus.Option) (*hllplus.HLL, error) {
+	if precision < 12 || precision > 20 {
+		return nil, fmt.Errorf("invalid uniqCombined precision %d", precision)
+	}
+	if len(data) == 0 {
+		return nil, fmt.Errorf("invalid uniqCombined state: no data")
+	}
+
+	registers := make([]byte, 1<<precision)
+	switch data[0] {
+	case chContainerSmall, chContainerMedium:
+		keys, err := decodeClickHouseKeys(data[1:], 4)
+		if err != nil {
+			return nil, err
+		}
+		for _, key := range keys {
+			updateClickHouseRegister(registers, uint32(key), precision)
+		}
+	case chContainerLarge:
+		if err := decodeClickHouseRegisters(registers, data[1:]); err != nil {
+			return nil, err
+		}
+	default:
+		return nil, fmt.Errorf("invalid uniqCombined state: unknown container type %d", data[0])
+	}
+
+	return newClickHouse(precision, registers, ClickHouseUniqCombinedHasher(precision), opts)
+}
+
+// FromClickHouseUniqHLL12 converts the state of ClickHouse's uniqHLL12 aggregate function,
+// as returned by uniqHLL12State, into a sketch with precision 12. Only states of String and
+// 64-bit integer columns are supported. The sketch uses the ClickHouseUniqHLL12Hasher,
+// additional options can be passed.
+func FromClickHouseUniqHLL12(data []byte, opts ...hllplus.Option) (*hllplus.HLL, error) {
+	if len(data) == 0 {
+		return nil, fmt.Errorf("invalid uniqHLL12 state: no data")
+	}
+
+	registers := make([]byte, 1<<chUniqHLL12Precision)
+	switch data[0] {
+	case 0: // small set
+		keys, err := decodeClickHouseKeys(data[1:], 8)
+		if err != nil {
+			return nil, err
+		}
+		for _, key := range keys {
+			updateClickHouseRegister(registers, intHash32(key), chUniqHLL12Precision)
+		}
+	case 1:
+		if err := decodeClickHouseRegisters(registers, data[1:]); err != nil {
+			return nil, err
+		}
+	default:
+		return nil, fmt.Errorf("invalid uniqHLL12 state: unknown container type %d", data[0])
+	}
+
+	return newClickHouse(chUniqHLL12Precision, registers, ClickHouseUniqHLL12Hasher, opts)
+}
+
+// ToClickHouseUniqCombined converts a sketch into the state of ClickHouse's
+// uniqCombined(precision) aggregate function, which can be restored via
+// uniqCombinedMerge. The sketch must use the ClickHouseUniqCombinedHasher.
+func ToClickHouseUniqCombined(s *hllplus.HLL) ([]byte, error) {
+	if err := checkClickHouseHasher(s, ClickHouseUniqCombinedHasher(s.Precision())); err != nil {
+		return nil, err
+	}
+	return append([]byte{chContainerLarge}, encodeClickHouseRegisters(s.Registers())...), nil
+}
+
+// ToClickHouseUniqHLL12 converts a sketch into the state of ClickHouse's uniqHLL12
+// aggregate function, which can be restored via uniqHLL12Merge. The sketch must use the
+// ClickHouseUniqHLL12Hasher.
+func ToClickHouseUniqHLL12(s *hllplus.HLL) ([]byte, error) {
+	if err := checkClickHouseHasher(s, ClickHouseUniqHLL12Hasher); err != nil {
+		return nil, err
+	}
+	return append([]byte{1}, encodeClickHouseRegisters(s.Registers())...), nil
+}
+
+func checkClickHouseHasher(s *hllplus.HLL, h hllplus.Hasher) error {
+	if id := s.Hasher().ID(); id != h.ID() {
+		return fmt.Errorf("cannot convert sketch with hasher %q to ClickHouse: expected %q", id, h.ID())
+	}
+	return nil
+}
+
+func newClickHouse(precision uint8, registers []byte, h hllplus.Hasher, opts []hllplus.Option) (*hllplus.HLL, error) {
+	sparsePrecision := precision + 5
+	if sparsePrecision > hllplus.MaxSparsePrecision {
+		sparsePrecision = hllplus.MaxSparsePrecision
+	}
+
+	opts = append([]hllplus.Option{hllplus.WithHasher(h)}, opts...)
+	return hllplus.NewFromRegisters(precision, sparsePrecision, registers, opts...)
+}
+
+func updateClickHouseRegister(registers []byte, h uint32, precision uint8) {
+	index := h & (1<<precision - 1)
+
+	rank := chHashBits - precision + 1
+	if rest := h >> precision; rest != 0 {
+		rank = byte(bits.TrailingZeros32(rest) + 1)
+	}
+	if rank > registers[index] {
+		registers[index] = rank
+	}
+}
+
+// decodeClickHouseKeys decodes a varint-prefixed list of little-endian keys of the given
+// width, as written by ClickHouse's small and medium sets.
+func decodeClickHouseKeys(data []byte, width int) ([]uint64, error) {
+	n, sz := binary.Uvarint(data)
+	if sz <= 0 {
+		return nil, fmt.Errorf("invalid ClickHouse state: bad set size")
+	}
+	if data = data[sz:]; uint64(len(data)) != n*uint64(width) {
+		return nil, fmt.Errorf("invalid ClickHouse state: set of %d keys does not match %d bytes", n, len(data))
+	}
+
+	keys := make([]uint64, 0, n)
+	for ; len(data) != 0; data = data[width:] {
+		if width == 4 {
+			keys = append(keys, uint64(binary.LittleEndian.Uint32(data)))
+		} else {
+			keys = append(keys, binary.LittleEndian.Uint64(data))
+		}
+	}
+	return keys, nil
+}
+
+// decodeClickHouseRegisters unpacks 5-bit registers, stored LSB first.
+func decodeClickHouseRegisters(registers, data []byte) error {
+	if expected := (len(registers)*chRegisterBits + 7) / 8; len(data) != expected {
+		return fmt.Errorf("invalid ClickHouse state: %d register bytes, expected %d", len(data), expected)
+	}
+
+	for i := range registers {
+		pos := i * chRegisterBits / 8
+		shift := uint(i * chRegisterBits & 7)
+
+		v := uint(data[pos]) >> shift
+		if pos+1 < len(data) {
+			v |= uint(data[pos+1]) << (8 - shift)
+		}
+		registers[i] = byte(v & (1<<chRegisterBits - 1))
+	}
+	return nil
+}
+
+func encodeClickHouseRegisters(registers []byte) []byte {
+	data := make([]byte, (len(registers)*chRegisterBits+7)/8)
+	for i, v := range registers {
+		pos := i * chRegisterBits / 8
+		shift := uint(i * chRegisterBits & 7)
+
+		data[pos] |= v << shift
+		if pos+1 < len(data) {
+			data[pos+1] |= v >> (8 - shift)
+		}
+	}
+	return data
+}
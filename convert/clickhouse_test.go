@@ -0,0 +1,140 @@
+package convert_test
+
+import (
+	"encoding/binary"
+	"fmt"
+	"math/bits"
+
+	"github.com/go-faster/city"
+	"github.com/gowthamkommineni/zetasketch/convert"
+	"github.com/gowthamkommineni/zetasketch/hllplus"
+
+	. "github.com/bsm/ginkgo"
+	. "github.com/bsm/gomega"
+)
+
+var _ = Describe("ClickHouse", func() {
+	// chInsert emulates the HyperLogLogCounter of ClickHouse.
+	chInsert := func(registers []byte, h uint32, precision uint8) {
+		index := h & (1<<precision - 1)
+		rank := 32 - precision + 1
+		if rest := h >> precision; rest != 0 {
+			rank = uint8(bits.TrailingZeros32(rest)) + 1
+		}
+		if rank > registers[index] {
+			registers[index] = rank
+		}
+	}
+
+	chLarge := func(typ byte, registers []byte) []byte {
+		data := make([]byte, 1+len(registers)*5/8)
+		data[0] = typ
+		for i, v := range registers {
+			pos, shift := 1+i*5/8, uint(i*5&7)
+			data[pos] |= v << shift
+			if pos+1 < len(data) {
+				data[pos+1] |= v >> (8 - shift)
+			}
+		}
+		return data
+	}
+
+	It("should import uniqCombined states", func() {
+		// small set
+		small := []byte{1, 3}
+		for i := 0; i < 3; i++ {
+			small = append(small, 0, 0, 0, 0)
+			binary.LittleEndian.PutUint32(small[len(small)-4:], uint32(city.CH64([]byte(fmt.Sprintf("v%d", i)))))
+		}
+
+		s, err := convert.FromClickHouseUniqCombined(small, 17)
+		Expect(err).NotTo(HaveOccurred())
+		Expect(s.Precision()).To(Equal(uint8(17)))
+		Expect(s.SparsePrecision()).To(Equal(uint8(22)))
+		Expect(s.Hasher().ID()).To(Equal("clickhouse-uniqcombined-p17"))
+		Expect(s.Estimate()).To(Equal(int64(3)))
+
+		// large
+		registers := make([]byte, 1<<14)
+		for i := 0; i < 50000; i++ {
+			chInsert(registers, uint32(city.CH64([]byte(fmt.Sprintf("v%d", i)))), 14)
+		}
+
+		s, err = convert.FromClickHouseUniqCombined(chLarge(3, registers), 14)
+		Expect(err).NotTo(HaveOccurred())
+		Expect(s.Registers()).To(Equal(registers))
+		Expect(s.Estimate()).To(BeNumerically("~", 50000, 1000))
+
+		// hllplus derives the same registers using the ClickHouseUniqCombinedHasher
+		t, err := hllplus.New(14, 19, hllplus.WithHasher(convert.ClickHouseUniqCombinedHasher(14)))
+		Expect(err).NotTo(HaveOccurred())
+		for i := 0; i < 50000; i++ {
+			t.AddString(fmt.Sprintf("v%d", i))
+		}
+		Expect(t.Registers()).To(Equal(registers))
+		Expect(t.Merge(s)).To(Succeed())
+
+		data, err := convert.ToClickHouseUniqCombined(t)
+		Expect(err).NotTo(HaveOccurred())
+		Expect(data).To(Equal(chLarge(3, registers)))
+	})
+
+	It("should import uniqHLL12 states", func() {
+		keys := []uint64{city.CH64([]byte("foo")), city.CH64([]byte("bar"))}
+		small := []byte{0, 2}
+		for _, key := range keys {
+			small = append(small, 0, 0, 0, 0, 0, 0, 0, 0)
+			binary.LittleEndian.PutUint64(small[len(small)-8:], key)
+		}
+
+		s, err := convert.FromClickHouseUniqHLL12(small)
+		Expect(err).NotTo(HaveOccurred())
+		Expect(s.Precision()).To(Equal(uint8(12)))
+		Expect(s.Hasher()).To(Equal(convert.ClickHouseUniqHLL12Hasher))
+		Expect(s.Estimate()).To(Equal(int64(2)))
+
+		t, err := hllplus.New(12, 17, hllplus.WithHasher(convert.ClickHouseUniqHLL12Hasher))
+		Expect(err).NotTo(HaveOccurred())
+		t.AddString("foo")
+		t.AddString("bar")
+		Expect(t.Registers()).To(Equal(s.Registers()))
+
+		for i := 0; i < 20000; i++ {
+			t.AddString(fmt.Sprintf("v%d", i))
+		}
+		data, err := convert.ToClickHouseUniqHLL12(t)
+		Expect(err).NotTo(HaveOccurred())
+		Expect(data).To(HaveLen(1 + 4096*5/8))
+		Expect(data[0]).To(Equal(byte(1)))
+
+		s, err = convert.FromClickHouseUniqHLL12(data)
+		Expect(err).NotTo(HaveOccurred())
+		Expect(s.Registers()).To(Equal(t.Registers()))
+		Expect(s.Estimate()).To(BeNumerically("~", 20000, 1000))
+	})
+
+	It("should reject invalid input", func() {
+		_, err := convert.FromClickHouseUniqCombined([]byte{1, 0}, 8)
+		Expect(err).To(MatchError("invalid uniqCombined precision 8"))
+
+		_, err = convert.FromClickHouseUniqCombined(nil, 17)
+		Expect(err).To(MatchError("invalid uniqCombined state: no data"))
+
+		_, err = convert.FromClickHouseUniqCombined([]byte{4}, 17)
+		Expect(err).To(MatchError("invalid uniqCombined state: unknown container type 4"))
+
+		_, err = convert.FromClickHouseUniqCombined([]byte{2, 2, 1, 2, 3, 4}, 17)
+		Expect(err).To(MatchError("invalid ClickHouse state: set of 2 keys does not match 4 bytes"))
+
+		_, err = convert.FromClickHouseUniqCombined([]byte{3, 1, 2, 3}, 17)
+		Expect(err).To(MatchError("invalid ClickHouse state: 3 register bytes, expected 81920"))
+
+		_, err = convert.FromClickHouseUniqHLL12([]byte{2})
+		Expect(err).To(MatchError("invalid uniqHLL12 state: unknown container type 2"))
+
+		s, err := hllplus.New(14, 19)
+		Expect(err).NotTo(HaveOccurred())
+		_, err = convert.ToClickHouseUniqCombined(s)
+		Expect(err).To(MatchError(`cannot convert sketch with hasher "fingerprint2011" to ClickHouse: expected "clickhouse-uniqcombined-p14"`))
+	})
+})
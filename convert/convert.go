@@ -0,0 +1,48 @@
+// Package convert implements conversions between hllplus sketches and the
+// HyperLogLog representations of other systems.
+//
+// HyperLogLog registers can only be combined if they were derived from the same hash
+// function. Each format therefore comes with a Hasher which maps values to hashes
+// exactly like the originating system does. Imported sketches use that hasher, so
+// further values can be added consistently, while merges with sketches using a
+// different hasher, such as the BigQuery-compatible default, are refused.
+package convert
+
+import (
+	"encoding/binary"
+	"math/bits"
+)
+
+// murmur64A implements MurmurHash64A by Austin Appleby.
+func murmur64A(p []byte, seed uint64) uint64 {
+	const m = 0xc6a4a7935bd1e995
+	const r = 47
+
+	h := seed ^ uint64(len(p))*m
+	for ; len(p) >= 8; p = p[8:] {
+		k := binary.LittleEndian.Uint64(p)
+		k *= m
+		k ^= k >> r
+		k *= m
+		h ^= k
+		h *= m
+	}
+	if len(p) > 0 {
+		for i := len(p) - 1; i >= 0; i-- {
+			h ^= uint64(p[i]) << (8 * i)
+		}
+		h *= m
+	}
+	h ^= h >> r
+	h *= m
+	h ^= h >> r
+	return h
+}
+
+// trailingZerosHash64 re-arranges a hash for systems which use the lowest precision bits
+// as the register index and the number of trailing zeros of the remaining bits as the
+// register value, so that hllplus derives the same registers.
+func trailingZerosHash64(h uint64, precision uint8) uint64 {
+	index := h & (1<<precision - 1)
+	return index<<(64-precision) | bits.Reverse64(h)&(1<<(64-precision)-1)
+}
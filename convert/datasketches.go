@@ -0,0 +1,201 @@
+package convert
+
+import (
+	"encoding/binary"
+	"fmt"
+
+	"github.com/gowthamkommineni/zetasketch/hllplus"
+	"github.com/spaolacci/murmur3"
+)
+
+// Apache DataSketches HLL constants, see
+// https://github.com/apache/datasketches-java/blob/master/src/main/java/org/apache/datasketches/hll/PreambleUtil.java.
+const (
+	dsSerVer   = 1
+	dsFamilyID = 7
+	dsSeed     = 9001
+	dsMinLgK   = 4
+	dsMaxLgK   = 21
+
+	dsFlagEmpty   = 4
+	dsFlagCompact = 8
+
+	dsModeList = 0
+	dsModeSet  = 1
+	dsModeHLL  = 2
+
+	dsTypeHLL4 = 0
+	dsTypeHLL6 = 1
+	dsTypeHLL8 = 2
+
+	dsListPreInts = 2
+	dsSetPreInts  = 3
+	dsHLLPreInts  = 10
+
+	dsKeyBits  = 26
+	dsKeyMask  = 1<<dsKeyBits - 1
+	dsAuxToken = 15
+)
+
+// DataSketchesHasher returns a hasher which hashes values like the Apache DataSketches HLL
+// sketch with the given lgK. DataSketches derives the register index from one half of a
+// 128-bit MurmurHash3 and the register value from the other half; hashes are re-arranged,
+// so that hllplus sketches with precision lgK derive the same registers.
+func DataSketchesHasher(lgK uint8) hllplus.Hasher {
+	return dataSketchesHasher{lgK: lgK}
+}
+
+type dataSketchesHasher struct{ lgK uint8 }
+
+func (h dataSketchesHasher) ID() string { return fmt.Sprintf("datasketches-murmur3-lgk%d", h.lgK) }
+func (h dataSketchesHasher) Hash64(p []byte) uint64 {
+	h1, h2 := murmur3.Sum128WithSeed(p, dsSeed)
+	return (h1&(1<<h.lgK-1))<<(64-h.lgK) | h2>>h.lgK
+}
+
+// FromDataSketches converts a serialized Apache DataSketches HLL sketch (HLL_4, HLL_6 or
+// HLL_8, in any mode) into a sketch with precision lgK. The sketch uses the
+// DataSketchesHasher for lgK, additional options can be passed. Only images with a lgK
+// of at least 10 can be converted.
+func FromDataSketches(data []byte, opts ...hllplus.Option) (*hllplus.HLL, error) {
+	if len(data) < 8 {
+		return nil, fmt.Errorf("invalid DataSketches HLL: too short")
+	}
+	if data[1] != dsSerVer {
+		return nil, fmt.Errorf("invalid DataSketches HLL: unsupported serialization version %d", data[1])
+	}
+	if data[2] != dsFamilyID {
+		return nil, fmt.Errorf("invalid DataSketches HLL: unexpected family %d", data[2])
+	}
+
+	lgK := data[3]
+	if lgK < dsMinLgK || lgK > dsMaxLgK {
+		return nil, fmt.Errorf("invalid DataSketches HLL: invalid lgK %d", lgK)
+	}
+	if lgK < hllplus.MinPrecision {
+		return nil, fmt.Errorf("cannot convert DataSketches HLL with lgK %d: must be >= %d", lgK, hllplus.MinPrecision)
+	}
+
+	registers := make([]byte, 1<<lgK)
+	if data[5]&dsFlagEmpty == 0 {
+		if err := decodeDataSketches(registers, data); err != nil {
+			return nil, err
+		}
+	}
+
+	// DataSketches caps values at 63, hllplus at the number of remaining hash bits.
+	maxRhoW := 64 - lgK + 1
+	for i, v := range registers {
+		if v > maxRhoW {
+			registers[i] = maxRhoW
+		}
+	}
+
+	sparsePrecision := lgK + 5
+	if sparsePrecision > hllplus.MaxSparsePrecision {
+		sparsePrecision = hllplus.MaxSparsePrecision
+	}
+
+	opts = append([]hllplus.Option{hllplus.WithHasher(DataSketchesHasher(lgK))}, opts...)
+	return hllplus.NewFromRegisters(lgK, sparsePrecision, registers, opts...)
+}
+
+func decodeDataSketches(registers, data []byte) error {
+	preInts := int(data[0])
+	compact := data[5]&dsFlagCompact != 0
+	lgArr := data[4]
+	if lgK := data[3]; lgArr > lgK+1 || lgArr > dsMaxLgK {
+		return fmt.Errorf("invalid DataSketches HLL: invalid lgArr %d", lgArr)
+	}
+
+	switch mode := data[7] & 3; mode {
+	case dsModeList:
+		if preInts != dsListPreInts {
+			return fmt.Errorf("invalid DataSketches HLL: unexpected preamble size %d", preInts)
+		}
+		n := 1 << lgArr
+		if compact {
+			n = int(data[6])
+		}
+		return decodeDataSketchesCoupons(registers, data[dsListPreInts*4:], n)
+	case dsModeSet:
+		if preInts != dsSetPreInts || len(data) < dsSetPreInts*4 {
+			return fmt.Errorf("invalid DataSketches HLL: unexpected preamble size %d", preInts)
+		}
+		n := 1 << lgArr
+		if compact {
+			n = int(binary.LittleEndian.Uint32(data[8:]))
+		}
+		return decodeDataSketchesCoupons(registers, data[dsSetPreInts*4:], n)
+	case dsModeHLL:
+		if preInts != dsHLLPreInts || len(data) < dsHLLPreInts*4 {
+			return fmt.Errorf("invalid DataSketches HLL: unexpected preamble size %d", preInts)
+		}
+	default:
+		return fmt.Errorf("invalid DataSketches HLL: unknown mode %d", mode)
+	}
+
+	k := len(registers)
+	body := data[dsHLLPreInts*4:]
+
+	switch typ := data[7] >> 2 & 3; typ {
+	case dsTypeHLL8:
+		if len(body) < k {
+			return fmt.Errorf("invalid DataSketches HLL: truncated HLL_8 array")
+		}
+		copy(registers, body)
+	case dsTypeHLL6:
+		if len(body) < k*3/4+1 {
+			return fmt.Errorf("invalid DataSketches HLL: truncated HLL_6 array")
+		}
+		for i := range registers {
+			pos := i * 6 / 8
+			v := binary.LittleEndian.Uint16(body[pos:]) >> uint(i*6&7)
+			registers[i] = byte(v & 63)
+		}
+	case dsTypeHLL4:
+		if len(body) < k/2 {
+			return fmt.Errorf("invalid DataSketches HLL: truncated HLL_4 array")
+		}
+
+		curMin := data[6]
+		for i := range registers {
+			v := body[i/2]
+			if i&1 != 0 {
+				v >>= 4
+			}
+			if v &= 15; v != dsAuxToken {
+				registers[i] = curMin + v
+			}
+		}
+
+		n := 1 << lgArr
+		if compact {
+			n = int(binary.LittleEndian.Uint32(data[36:]))
+		}
+		return decodeDataSketchesCoupons(registers, body[k/2:], n)
+	default:
+		return fmt.Errorf("invalid DataSketches HLL: unknown type %d", typ)
+	}
+	return nil
+}
+
+// decodeDataSketchesCoupons applies n coupons, each holding a 26-bit slot address and a
+// 6-bit value. Empty coupons are zero.
+func decodeDataSketchesCoupons(registers, data []byte, n int) error {
+	if n > len(data)/4 {
+		return fmt.Errorf("invalid DataSketches HLL: truncated coupon array")
+	}
+
+	mask := uint32(len(registers) - 1)
+	for i := 0; i < n; i++ {
+		coupon := binary.LittleEndian.Uint32(data[i*4:])
+		if coupon == 0 {
+			continue
+		}
+		if pos, v := coupon&dsKeyMask&mask, byte(coupon>>dsKeyBits); v > registers[pos] {
+			registers[pos] = v
+		}
+	}
+	return nil
+}
@@ -0,0 +1,201 @@
+package convert_test
+
+import (
+	"encoding/binary"
+	"fmt"
+	"math/bits"
+
+	"github.com/gowthamkommineni/zetasketch/convert"
+	"github.com/gowthamkommineni/zetasketch/hllplus"
+	"github.com/spaolacci/murmur3"
+
+	. "github.com/bsm/ginkgo"
+	. "github.com/bsm/gomega"
+)
+
+var _ = Describe("DataSketches", func() {
+	// dsCoupon emulates the coupon derivation of the DataSketches HLL sketch.
+	dsCoupon := func(v string) uint32 {
+		h1, h2 := murmur3.Sum128WithSeed([]byte(v), 9001)
+		lz := bits.LeadingZeros64(h2)
+		if lz > 62 {
+			lz = 62
+		}
+		return uint32(lz+1)<<26 | uint32(h1&(1<<26-1))
+	}
+
+	dsRegisters := func(lgK uint8, n int) []byte {
+		registers := make([]byte, 1<<lgK)
+		for i := 0; i < n; i++ {
+			c := dsCoupon(fmt.Sprintf("v%d", i))
+			if pos, v := c&(1<<lgK-1), byte(c>>26); v > registers[pos] {
+				registers[pos] = v
+			}
+		}
+		return registers
+	}
+
+	dsPreamble := func(preInts, lgK, lgArr, flags, b6, mode byte) []byte {
+		return []byte{preInts, 1, 7, lgK, lgArr, flags, b6, mode}
+	}
+
+	dsHLL := func(lgK, lgArr, curMin, typ byte, auxCount int, body []byte) []byte {
+		data := dsPreamble(10, lgK, lgArr, 8, curMin, typ<<2|2)
+		data = append(data, make([]byte, 32)...)
+		binary.LittleEndian.PutUint32(data[36:], uint32(auxCount))
+		return append(data, body...)
+	}
+
+	It("should import empty sketches", func() {
+		s, err := convert.FromDataSketches(dsPreamble(2, 12, 3, 4|8, 0, 0))
+		Expect(err).NotTo(HaveOccurred())
+		Expect(s.Precision()).To(Equal(uint8(12)))
+		Expect(s.SparsePrecision()).To(Equal(uint8(17)))
+		Expect(s.Hasher().ID()).To(Equal("datasketches-murmur3-lgk12"))
+		Expect(s.Estimate()).To(Equal(int64(0)))
+	})
+
+	It("should import list and set modes", func() {
+		list := dsPreamble(2, 12, 3, 8, 3, 0)
+		set := append(dsPreamble(3, 12, 5, 0, 0, 1), 3, 0, 0, 0)
+		set = append(set, make([]byte, 32*4)...)
+		for i := 0; i < 3; i++ {
+			c := dsCoupon(fmt.Sprintf("v%d", i))
+			list = append(list, 0, 0, 0, 0)
+			binary.LittleEndian.PutUint32(list[len(list)-4:], c)
+			binary.LittleEndian.PutUint32(set[12+int(c%32)*4:], c)
+		}
+		expected := dsRegisters(12, 3)
+
+		s, err := convert.FromDataSketches(list)
+		Expect(err).NotTo(HaveOccurred())
+		Expect(s.Registers()).To(Equal(expected))
+		Expect(s.Estimate()).To(Equal(int64(3)))
+
+		s, err = convert.FromDataSketches(set)
+		Expect(err).NotTo(HaveOccurred())
+		Expect(s.Registers()).To(Equal(expected))
+	})
+
+	It("should import HLL_8", func() {
+		registers := dsRegisters(12, 20000)
+
+		s, err := convert.FromDataSketches(dsHLL(12, 0, 0, 2, 0, registers))
+		Expect(err).NotTo(HaveOccurred())
+		Expect(s.Registers()).To(Equal(registers))
+		Expect(s.Estimate()).To(BeNumerically("~", 20000, 400))
+
+		// hllplus derives the same registers using the DataSketchesHasher
+		t, err := hllplus.New(12, 17, hllplus.WithHasher(convert.DataSketchesHasher(12)))
+		Expect(err).NotTo(HaveOccurred())
+		for i := 0; i < 20000; i++ {
+			t.AddString(fmt.Sprintf("v%d", i))
+		}
+		Expect(t.Registers()).To(Equal(registers))
+		Expect(t.Merge(s)).To(Succeed())
+	})
+
+	It("should import HLL_6", func() {
+		registers := dsRegisters(11, 10000)
+		body := make([]byte, 2048*3/4+1)
+		for i, v := range registers {
+			pos, shift := i*6/8, uint(i*6&7)
+			w := binary.LittleEndian.Uint16(body[pos:]) | uint16(v)<<shift
+			binary.LittleEndian.PutUint16(body[pos:], w)
+		}
+
+		s, err := convert.FromDataSketches(dsHLL(11, 0, 0, 1, 0, body))
+		Expect(err).NotTo(HaveOccurred())
+		Expect(s.Registers()).To(Equal(registers))
+	})
+
+	It("should import HLL_4", func() {
+		registers := dsRegisters(10, 30000)
+		curMin := registers[0]
+		for _, v := range registers {
+			if v < curMin {
+				curMin = v
+			}
+		}
+		Expect(curMin).To(BeNumerically(">", 0))
+		registers[7] = curMin + 20 // exceeds the 4-bit range
+
+		body := make([]byte, 512)
+		var aux []byte
+		for i, v := range registers {
+			nibble := v - curMin
+			if nibble >= 15 {
+				nibble = 15
+				aux = append(aux, 0, 0, 0, 0)
+				binary.LittleEndian.PutUint32(aux[len(aux)-4:], uint32(v)<<26|uint32(i))
+			}
+			body[i/2] |= nibble << (uint(i&1) * 4)
+		}
+		Expect(aux).NotTo(BeEmpty())
+
+		s, err := convert.FromDataSketches(dsHLL(10, 0, curMin, 0, len(aux)/4, append(body, aux...)))
+		Expect(err).NotTo(HaveOccurred())
+		Expect(s.Registers()).To(Equal(registers))
+	})
+
+	It("should reject invalid input", func() {
+		_, err := convert.FromDataSketches([]byte{2, 1, 7})
+		Expect(err).To(MatchError("invalid DataSketches HLL: too short"))
+
+		_, err = convert.FromDataSketches([]byte{2, 2, 7, 12, 3, 12, 0, 0})
+		Expect(err).To(MatchError("invalid DataSketches HLL: unsupported serialization version 2"))
+
+		_, err = convert.FromDataSketches([]byte{2, 1, 3, 12, 3, 12, 0, 0})
+		Expect(err).To(MatchError("invalid DataSketches HLL: unexpected family 3"))
+
+		_, err = convert.FromDataSketches(dsPreamble(2, 8, 3, 12, 0, 0))
+		Expect(err).To(MatchError("cannot convert DataSketches HLL with lgK 8: must be >= 10"))
+
+		_, err = convert.FromDataSketches(dsPreamble(2, 12, 3, 8, 2, 0))
+		Expect(err).To(MatchError("invalid DataSketches HLL: truncated coupon array"))
+
+		_, err = convert.FromDataSketches(dsHLL(12, 0, 0, 2, 0, nil))
+		Expect(err).To(MatchError("invalid DataSketches HLL: truncated HLL_8 array"))
+	})
+
+	It("should reject truncated input", func() {
+		_, err := convert.FromDataSketches(dsHLL(11, 0, 0, 1, 0, make([]byte, 100)))
+		Expect(err).To(MatchError("invalid DataSketches HLL: truncated HLL_6 array"))
+
+		_, err = convert.FromDataSketches(dsHLL(10, 0, 0, 0, 0, make([]byte, 100)))
+		Expect(err).To(MatchError("invalid DataSketches HLL: truncated HLL_4 array"))
+
+		// aux coupons
+		_, err = convert.FromDataSketches(dsHLL(10, 3, 0, 0, 2, make([]byte, 512+4)))
+		Expect(err).To(MatchError("invalid DataSketches HLL: truncated coupon array"))
+
+		// compact set with more coupons than data
+		set := append(dsPreamble(3, 12, 5, 8, 0, 1), 0xff, 0xff, 0xff, 0xff)
+		_, err = convert.FromDataSketches(set)
+		Expect(err).To(MatchError("invalid DataSketches HLL: truncated coupon array"))
+
+		_, err = convert.FromDataSketches(dsPreamble(3, 12, 5, 0, 0, 1))
+		Expect(err).To(MatchError("invalid DataSketches HLL: unexpected preamble size 3"))
+	})
+
+	It("should reject corrupt headers", func() {
+		for _, lgArr := range []byte{14, 22, 62, 63, 255} {
+			_, err := convert.FromDataSketches([]byte{2, 1, 7, 12, lgArr, 0, 0, 0})
+			Expect(err).To(MatchError(fmt.Sprintf("invalid DataSketches HLL: invalid lgArr %d", lgArr)))
+			_, err = convert.FromDataSketches(dsHLL(12, lgArr, 0, 0, 0, make([]byte, 2048)))
+			Expect(err).To(MatchError(fmt.Sprintf("invalid DataSketches HLL: invalid lgArr %d", lgArr)))
+		}
+
+		_, err := convert.FromDataSketches([]byte{2, 1, 7, 22, 3, 0, 0, 0})
+		Expect(err).To(MatchError("invalid DataSketches HLL: invalid lgK 22"))
+
+		_, err = convert.FromDataSketches([]byte{2, 1, 7, 12, 3, 0, 0, 3})
+		Expect(err).To(MatchError("invalid DataSketches HLL: unknown mode 3"))
+
+		_, err = convert.FromDataSketches(dsHLL(12, 0, 0, 3, 0, make([]byte, 4096)))
+		Expect(err).To(MatchError("invalid DataSketches HLL: unknown type 3"))
+
+		_, err = convert.FromDataSketches(dsPreamble(4, 12, 3, 0, 0, 0))
+		Expect(err).To(MatchError("invalid DataSketches HLL: unexpected preamble size 4"))
+	})
+})
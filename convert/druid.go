@@ -0,0 +1,39 @@
+package convert
+
+import (
+	"encoding/base64"
+	"encoding/binary"
+	"strings"
+	"unicode/utf16"
+
+	"github.com/gowthamkommineni/zetasketch/hllplus"
+)
+
+// FromDruid converts a value of a Druid HLLSketch complex column, as stored in segments by
+// the druid-datasketches extension, into a sketch. HLLSketch values are Apache
+// DataSketches HLL images, see FromDataSketches.
+func FromDruid(data []byte, opts ...hllplus.Option) (*hllplus.HLL, error) {
+	return FromDataSketches(data, opts...)
+}
+
+// FromDruidBase64 converts a base64-encoded HLLSketch value, as returned in Druid's JSON
+// query results, into a sketch. Surrounding whitespace is ignored.
+func FromDruidBase64(str string, opts ...hllplus.Option) (*hllplus.HLL, error) {
+	data, err := base64.StdEncoding.DecodeString(strings.TrimSpace(str))
+	if err != nil {
+		return nil, err
+	}
+	return FromDruid(data, opts...)
+}
+
+// DruidString returns the bytes which Druid's HLLSketchBuild aggregator hashes for a string
+// with the default UTF16LE string encoding. Add them via AddBytes to a sketch using the
+// DataSketchesHasher to match Druid; numeric values can be added directly.
+func DruidString(s string) []byte {
+	units := utf16.Encode([]rune(s))
+	data := make([]byte, 2*len(units))
+	for i, u := range units {
+		binary.LittleEndian.PutUint16(data[2*i:], u)
+	}
+	return data
+}
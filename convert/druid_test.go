@@ -0,0 +1,55 @@
+package convert_test
+
+import (
+	"encoding/base64"
+	"fmt"
+	"math/bits"
+
+	"github.com/gowthamkommineni/zetasketch/convert"
+	"github.com/gowthamkommineni/zetasketch/hllplus"
+	"github.com/spaolacci/murmur3"
+
+	. "github.com/bsm/ginkgo"
+	. "github.com/bsm/gomega"
+)
+
+var _ = Describe("Druid", func() {
+	It("should encode strings like Druid", func() {
+		Expect(convert.DruidString("")).To(BeEmpty())
+		Expect(convert.DruidString("ab")).To(Equal([]byte{'a', 0, 'b', 0}))
+		Expect(convert.DruidString("ü😀")).To(Equal([]byte{0xfc, 0x00, 0x3d, 0xd8, 0x00, 0xde}))
+	})
+
+	It("should import HLLSketch values", func() {
+		// emulate HLLSketchBuild with lgK=11 and HLL_8
+		registers := make([]byte, 2048)
+		for i := 0; i < 10000; i++ {
+			h1, h2 := murmur3.Sum128WithSeed(convert.DruidString(fmt.Sprintf("v%d", i)), 9001)
+			if pos, v := h1&2047, byte(bits.LeadingZeros64(h2)+1); v > registers[pos] {
+				registers[pos] = v
+			}
+		}
+		data := append([]byte{10, 1, 7, 11, 0, 8, 0, 2<<2 | 2}, make([]byte, 32)...)
+		data = append(data, registers...)
+
+		s, err := convert.FromDruid(data)
+		Expect(err).NotTo(HaveOccurred())
+		Expect(s.Registers()).To(Equal(registers))
+		Expect(s.Estimate()).To(BeNumerically("~", 10000, 300))
+
+		s, err = convert.FromDruidBase64(" " + base64.StdEncoding.EncodeToString(data) + "\n")
+		Expect(err).NotTo(HaveOccurred())
+		Expect(s.Registers()).To(Equal(registers))
+
+		// hllplus derives the same registers using the DataSketchesHasher
+		t, err := hllplus.New(11, 16, hllplus.WithHasher(convert.DataSketchesHasher(11)))
+		Expect(err).NotTo(HaveOccurred())
+		for i := 0; i < 10000; i++ {
+			t.AddBytes(convert.DruidString(fmt.Sprintf("v%d", i)))
+		}
+		Expect(t.Registers()).To(Equal(registers))
+
+		_, err = convert.FromDruidBase64("not base64")
+		Expect(err).To(HaveOccurred())
+	})
+})
@@ -0,0 +1,9 @@
+package convert
+
+func Murmur64A(p []byte, seed uint64) uint64 {
+	return murmur64A(p, seed)
+}
+
+func CassandraMurmur3(p []byte, seed uint64) (uint64, uint64) {
+	return cassandraMurmur3(p, seed)
+}
@@ -0,0 +1,165 @@
+package convert
+
+import (
+	"encoding/binary"
+	"fmt"
+	"math/bits"
+
+	"github.com/gowthamkommineni/zetasketch/hllplus"
+	"github.com/spaolacci/murmur3"
+)
+
+// postgresql-hll storage constants, see
+// https://github.com/citusdata/postgresql-hll/blob/master/STORAGE.markdown.
+const (
+	pgVersion = 1
+
+	pgTypeUndefined = 0
+	pgTypeEmpty     = 1
+	pgTypeExplicit  = 2
+	pgTypeSparse    = 3
+	pgTypeFull      = 4
+
+	pgHeaderSize    = 3
+	pgRegisterWidth = 5
+	pgCutoffDefault = 0x7f // sparse enabled, automatic explicit threshold
+)
+
+// PostgresHasher returns a hasher which hashes values like the hll_hash_* functions of the
+// postgresql-hll extension with the default seed, for sketches with the given log2m.
+// postgresql-hll uses the lowest log2m bits of a 64-bit MurmurHash3 as the register
+// index and the number of trailing zeros of the remaining bits as the register value;
+// hashes are re-arranged, so that hllplus sketches with precision log2m derive the same
+// registers.
+func PostgresHasher(log2m uint8) hllplus.Hasher {
+	return postgresHasher{log2m: log2m}
+}
+
+type postgresHasher struct{ log2m uint8 }
+
+func (h postgresHasher) ID() string { return fmt.Sprintf("postgresql-hll-murmur3-log2m%d", h.log2m) }
+func (h postgresHasher) Hash64(p []byte) uint64 {
+	h1, _ := murmur3.Sum128(p)
+	return trailingZerosHash64(h1, h.log2m)
+}
+
+// FromPostgres converts a value of a postgresql-hll hll column, as returned by
+// hll_send or by selecting the column as bytea, into a sketch with precision log2m. All
+// storage types, EMPTY, EXPLICIT, SPARSE and FULL, are supported. The sketch uses the
+// PostgresHasher for log2m, additional options can be passed.
+func FromPostgres(data []byte, opts ...hllplus.Option) (*hllplus.HLL, error) {
+	if len(data) < pgHeaderSize {
+		return nil, fmt.Errorf("invalid postgresql-hll: too short")
+	}
+	if version := data[0] >> 4; version != pgVersion {
+		return nil, fmt.Errorf("invalid postgresql-hll: unsupported version %d", version)
+	}
+
+	width := int(data[1]>>5) + 1
+	log2m := data[1] & 0x1f
+	if log2m < hllplus.MinPrecision || log2m > hllplus.MaxPrecision {
+		return nil, fmt.Errorf("cannot convert postgresql-hll with log2m %d: must be between %d and %d", log2m, hllplus.MinPrecision, hllplus.MaxPrecision)
+	}
+
+	registers := make([]byte, 1<<log2m)
+	body := data[pgHeaderSize:]
+
+	switch typ := data[0] & 0xf; typ {
+	case pgTypeEmpty:
+	case pgTypeExplicit:
+		if len(body)%8 != 0 {
+			return nil, fmt.Errorf("invalid postgresql-hll: explicit data of %d bytes", len(body))
+		}
+		for ; len(body) != 0; body = body[8:] {
+			updatePostgresRegister(registers, binary.BigEndian.Uint64(body), log2m, width)
+		}
+	case pgTypeSparse:
+		entryBits := int(log2m) + width
+		for i, n := 0, len(body)*8/entryBits; i < n; i++ {
+			entry := readBitsMSB(body, i*entryBits, entryBits)
+			index, v := entry>>width, byte(entry&(1<<width-1))
+			if v > registers[index] {
+				registers[index] = v
+			}
+		}
+	case pgTypeFull:
+		if expected := (len(registers)*width + 7) / 8; len(body) != expected {
+			return nil, fmt.Errorf("invalid postgresql-hll: %d register bytes, expected %d", len(body), expected)
+		}
+		for i := range registers {
+			registers[i] = byte(readBitsMSB(body, i*width, width))
+		}
+	default:
+		return nil, fmt.Errorf("invalid postgresql-hll: unsupported type %d", typ)
+	}
+
+	sparsePrecision := log2m + 5
+	if sparsePrecision > hllplus.MaxSparsePrecision {
+		sparsePrecision = hllplus.MaxSparsePrecision
+	}
+
+	opts = append([]hllplus.Option{hllplus.WithHasher(PostgresHasher(log2m))}, opts...)
+	return hllplus.NewFromRegisters(log2m, sparsePrecision, registers, opts...)
+}
+
+// ToPostgres converts a sketch into the FULL postgresql-hll storage type with a register
+// width of 5, which can be restored via hll_recv or by casting bytea to hll. The sketch
+// must use the PostgresHasher.
+func ToPostgres(s *hllplus.HLL) ([]byte, error) {
+	if id, expected := s.Hasher().ID(), PostgresHasher(s.Precision()).ID(); id != expected {
+		return nil, fmt.Errorf("cannot convert sketch with hasher %q to postgresql-hll: expected %q", id, expected)
+	}
+
+	registers := s.Registers()
+	data := make([]byte, pgHeaderSize+(len(registers)*pgRegisterWidth+7)/8)
+	data[0] = pgVersion<<4 | pgTypeFull
+	data[1] = (pgRegisterWidth-1)<<5 | s.Precision()
+	data[2] = pgCutoffDefault
+
+	body := data[pgHeaderSize:]
+	for i, v := range registers {
+		// postgresql-hll caps values at the register width
+		if v > 1<<pgRegisterWidth-1 {
+			v = 1<<pgRegisterWidth - 1
+		}
+		writeBitsMSB(body, i*pgRegisterWidth, pgRegisterWidth, uint64(v))
+	}
+	return data, nil
+}
+
+// updatePostgresRegister adds a raw hash to the registers, like postgresql-hll does for
+// EXPLICIT values.
+func updatePostgresRegister(registers []byte, h uint64, log2m uint8, width int) {
+	rest := h >> log2m
+	if rest == 0 {
+		return
+	}
+
+	v := byte(bits.TrailingZeros64(rest) + 1)
+	if limit := byte(1<<width - 1); v > limit {
+		v = limit
+	}
+	if index := h & (1<<log2m - 1); v > registers[index] {
+		registers[index] = v
+	}
+}
+
+// readBitsMSB reads n bits at the given bit offset from a big-endian bit stream.
+func readBitsMSB(data []byte, offset, n int) uint64 {
+	var v uint64
+	for i := offset; i < offset+n; i++ {
+		v = v<<1 | uint64(data[i/8]>>(7-i%8)&1)
+	}
+	return v
+}
+
+// writeBitsMSB writes the lowest n bits of v at the given bit offset into a big-endian
+// bit stream.
+func writeBitsMSB(data []byte, offset, n int, v uint64) {
+	for i := 0; i < n; i++ {
+		if v>>(n-1-i)&1 != 0 {
+			pos := offset + i
+			data[pos/8] |= 1 << (7 - pos%8)
+		}
+	}
+}
@@ -0,0 +1,134 @@
+package convert_test
+
+import (
+	"encoding/binary"
+	"fmt"
+	"math/bits"
+
+	"github.com/gowthamkommineni/zetasketch/convert"
+	"github.com/gowthamkommineni/zetasketch/hllplus"
+	"github.com/spaolacci/murmur3"
+
+	. "github.com/bsm/ginkgo"
+	. "github.com/bsm/gomega"
+)
+
+var _ = Describe("Postgres", func() {
+	pgHash := func(v string) uint64 {
+		h, _ := murmur3.Sum128([]byte(v))
+		return h
+	}
+
+	// pgAdd emulates postgresql-hll's register update.
+	pgAdd := func(registers []byte, h uint64, log2m uint8) {
+		if rest := h >> log2m; rest != 0 {
+			v := byte(bits.TrailingZeros64(rest) + 1)
+			if index := h & (1<<log2m - 1); v > registers[index] {
+				registers[index] = v
+			}
+		}
+	}
+
+	// pgPack packs values of the given width into a big-endian bit stream.
+	pgPack := func(width int, values ...uint64) []byte {
+		data := make([]byte, (len(values)*width+7)/8)
+		for i, v := range values {
+			for j := 0; j < width; j++ {
+				if v>>(width-1-j)&1 != 0 {
+					pos := i*width + j
+					data[pos/8] |= 1 << (7 - pos%8)
+				}
+			}
+		}
+		return data
+	}
+
+	It("should import empty sketches", func() {
+		s, err := convert.FromPostgres([]byte{0x11, 0x8b, 0x7f})
+		Expect(err).NotTo(HaveOccurred())
+		Expect(s.Precision()).To(Equal(uint8(11)))
+		Expect(s.SparsePrecision()).To(Equal(uint8(16)))
+		Expect(s.Hasher().ID()).To(Equal("postgresql-hll-murmur3-log2m11"))
+		Expect(s.Estimate()).To(Equal(int64(0)))
+	})
+
+	It("should import explicit sketches", func() {
+		data := []byte{0x12, 0x8b, 0x7f}
+		registers := make([]byte, 2048)
+		for _, v := range []string{"foo", "bar", "baz"} {
+			data = append(data, 0, 0, 0, 0, 0, 0, 0, 0)
+			binary.BigEndian.PutUint64(data[len(data)-8:], pgHash(v))
+			pgAdd(registers, pgHash(v), 11)
+		}
+
+		s, err := convert.FromPostgres(data)
+		Expect(err).NotTo(HaveOccurred())
+		Expect(s.Registers()).To(Equal(registers))
+		Expect(s.Estimate()).To(Equal(int64(3)))
+	})
+
+	It("should import sparse sketches", func() {
+		// registers 3 = 2, 700 = 5, 2047 = 31
+		body := pgPack(11+5, 3<<5|2, 700<<5|5, 2047<<5|31)
+
+		s, err := convert.FromPostgres(append([]byte{0x13, 0x8b, 0x7f}, body...))
+		Expect(err).NotTo(HaveOccurred())
+
+		registers := s.Registers()
+		Expect(registers[3]).To(Equal(byte(2)))
+		Expect(registers[700]).To(Equal(byte(5)))
+		Expect(registers[2047]).To(Equal(byte(31)))
+		Expect(s.Estimate()).To(Equal(int64(3)))
+	})
+
+	It("should import full sketches", func() {
+		registers := make([]byte, 4096)
+		for i := 0; i < 50000; i++ {
+			pgAdd(registers, pgHash(fmt.Sprintf("v%d", i)), 12)
+		}
+		values := make([]uint64, len(registers))
+		for i, v := range registers {
+			values[i] = uint64(v)
+		}
+
+		s, err := convert.FromPostgres(append([]byte{0x14, 0x8c, 0x7f}, pgPack(5, values...)...))
+		Expect(err).NotTo(HaveOccurred())
+		Expect(s.Registers()).To(Equal(registers))
+		Expect(s.Estimate()).To(BeNumerically("~", 50000, 1500))
+
+		// hllplus derives the same registers using the PostgresHasher
+		t, err := hllplus.New(12, 17, hllplus.WithHasher(convert.PostgresHasher(12)))
+		Expect(err).NotTo(HaveOccurred())
+		for i := 0; i < 50000; i++ {
+			t.AddString(fmt.Sprintf("v%d", i))
+		}
+		Expect(t.Registers()).To(Equal(registers))
+		Expect(t.Merge(s)).To(Succeed())
+
+		data, err := convert.ToPostgres(t)
+		Expect(err).NotTo(HaveOccurred())
+		Expect(data).To(Equal(append([]byte{0x14, 0x8c, 0x7f}, pgPack(5, values...)...)))
+	})
+
+	It("should reject invalid input", func() {
+		_, err := convert.FromPostgres([]byte{0x11, 0x8b})
+		Expect(err).To(MatchError("invalid postgresql-hll: too short"))
+
+		_, err = convert.FromPostgres([]byte{0x21, 0x8b, 0x7f})
+		Expect(err).To(MatchError("invalid postgresql-hll: unsupported version 2"))
+
+		_, err = convert.FromPostgres([]byte{0x11, 0x84, 0x7f})
+		Expect(err).To(MatchError("cannot convert postgresql-hll with log2m 4: must be between 10 and 24"))
+
+		_, err = convert.FromPostgres([]byte{0x10, 0x8b, 0x7f})
+		Expect(err).To(MatchError("invalid postgresql-hll: unsupported type 0"))
+
+		_, err = convert.FromPostgres([]byte{0x14, 0x8b, 0x7f, 0x00})
+		Expect(err).To(MatchError("invalid postgresql-hll: 1 register bytes, expected 1280"))
+
+		s, err := hllplus.New(11, 16, hllplus.WithHasher(convert.PostgresHasher(12)))
+		Expect(err).NotTo(HaveOccurred())
+		_, err = convert.ToPostgres(s)
+		Expect(err).To(MatchError(`cannot convert sketch with hasher "postgresql-hll-murmur3-log2m12" to postgresql-hll: expected "postgresql-hll-murmur3-log2m11"`))
+	})
+})
@@ -0,0 +1,179 @@
+package convert
+
+import (
+	"encoding/binary"
+	"fmt"
+	"math/bits"
+
+	"github.com/gowthamkommineni/zetasketch/hllplus"
+	"github.com/spaolacci/murmur3"
+)
+
+// Airlift HyperLogLog constants, as used by Presto, Trino and Athena, see
+// https://github.com/airlift/airlift/tree/master/stats/src/main/java/io/airlift/stats/cardinality.
+const (
+	prestoSparseV2 = 2
+	prestoDenseV2  = 3
+
+	prestoMaxPrecision = 16
+	prestoPrefixBits   = 26
+	prestoValueBits    = 6
+	prestoMaxDelta     = 15
+)
+
+// PrestoHasher hashes values like the approx_set function of Presto, Trino and Athena.
+// Airlift derives registers exactly like hllplus, so hashes need no re-arrangement.
+var PrestoHasher hllplus.Hasher = prestoHasher{}
+
+type prestoHasher struct{}
+
+func (prestoHasher) ID() string { return "airlift-murmur3-128" }
+func (prestoHasher) Hash64(p []byte) uint64 {
+	h1, _ := murmur3.Sum128(p)
+	return h1
+}
+
+// FromPresto converts a serialized Airlift HyperLogLog, as returned by CAST(approx_set(x) AS
+// VARBINARY) in Presto, Trino and Athena, into a sketch. Both, the SPARSE_V2 and DENSE_V2
+// formats are supported. The sketch uses the PrestoHasher, additional options can be passed.
+func FromPresto(data []byte, opts ...hllplus.Option) (*hllplus.HLL, error) {
+	if len(data) < 2 {
+		return nil, fmt.Errorf("invalid Presto HyperLogLog: too short")
+	}
+
+	precision := data[1]
+	if precision < hllplus.MinPrecision || precision > prestoMaxPrecision {
+		return nil, fmt.Errorf("cannot convert Presto HyperLogLog with %d index bits: must be between %d and %d", precision, hllplus.MinPrecision, prestoMaxPrecision)
+	}
+
+	registers := make([]byte, 1<<precision)
+	switch data[0] {
+	case prestoSparseV2:
+		if err := decodePrestoSparse(registers, precision, data[2:]); err != nil {
+			return nil, err
+		}
+	case prestoDenseV2:
+		if err := decodePrestoDense(registers, data[2:]); err != nil {
+			return nil, err
+		}
+	default:
+		return nil, fmt.Errorf("invalid Presto HyperLogLog: unsupported format %d", data[0])
+	}
+
+	opts = append([]hllplus.Option{hllplus.WithHasher(PrestoHasher)}, opts...)
+	return hllplus.NewFromRegisters(precision, precision+5, registers, opts...)
+}
+
+// ToPresto converts a sketch into the DENSE_V2 Airlift HyperLogLog format, which can be
+// restored via CAST(x AS HyperLogLog) in Presto, Trino and Athena. The sketch must use the
+// PrestoHasher. Precisions above 16 are downgraded.
+func ToPresto(s *hllplus.HLL) ([]byte, error) {
+	if id := s.Hasher().ID(); id != PrestoHasher.ID() {
+		return nil, fmt.Errorf("cannot convert sketch with hasher %q to Presto", id)
+	}
+
+	if s.Precision() > prestoMaxPrecision {
+		s = s.Clone()
+		if err := s.Downgrade(prestoMaxPrecision, s.SparsePrecision()); err != nil {
+			return nil, err
+		}
+	}
+
+	registers := s.Registers()
+	baseline := registers[0]
+	for _, v := range registers {
+		if v < baseline {
+			baseline = v
+		}
+	}
+
+	data := make([]byte, 3+len(registers)/2+2)
+	data[0] = prestoDenseV2
+	data[1] = s.Precision()
+	data[2] = baseline
+
+	var overflowBuckets []uint16
+	var overflowValues []byte
+	deltas := data[3 : 3+len(registers)/2]
+	for i, v := range registers {
+		delta := v - baseline
+		if delta > prestoMaxDelta {
+			overflowBuckets = append(overflowBuckets, uint16(i))
+			overflowValues = append(overflowValues, delta-prestoMaxDelta)
+			delta = prestoMaxDelta
+		}
+		deltas[i/2] |= delta << prestoDeltaShift(i)
+	}
+
+	binary.LittleEndian.PutUint16(data[len(data)-2:], uint16(len(overflowBuckets)))
+	for _, bucket := range overflowBuckets {
+		data = append(data, 0, 0)
+		binary.LittleEndian.PutUint16(data[len(data)-2:], bucket)
+	}
+	return append(data, overflowValues...), nil
+}
+
+// prestoDeltaShift returns the position of a bucket's delta within its byte. Even buckets
+// are stored in the high nibble.
+func prestoDeltaShift(bucket int) uint {
+	return uint(^bucket&1) << 2
+}
+
+// decodePrestoSparse decodes SPARSE_V2 entries, each holding a 26-bit hash prefix and
+// the number of leading zeros of the remaining hash bits.
+func decodePrestoSparse(registers []byte, precision uint8, data []byte) error {
+	if len(data) < 2 {
+		return fmt.Errorf("invalid Presto HyperLogLog: truncated sparse header")
+	}
+
+	n := int(binary.LittleEndian.Uint16(data))
+	if data = data[2:]; len(data) != n*4 {
+		return fmt.Errorf("invalid Presto HyperLogLog: %d sparse entries do not match %d bytes", n, len(data))
+	}
+
+	restBits := prestoPrefixBits - int(precision)
+	for i := 0; i < n; i++ {
+		entry := binary.LittleEndian.Uint32(data[i*4:])
+		prefix := entry >> prestoValueBits
+
+		index := prefix >> restBits
+		rest := prefix & (1<<restBits - 1)
+
+		zeros := restBits + int(entry&(1<<prestoValueBits-1))
+		if rest != 0 {
+			zeros = restBits - bits.Len32(rest)
+		}
+		if v := byte(zeros + 1); v > registers[index] {
+			registers[index] = v
+		}
+	}
+	return nil
+}
+
+// decodePrestoDense decodes DENSE_V2 registers, stored as a baseline, 4-bit deltas and
+// overflows.
+func decodePrestoDense(registers []byte, data []byte) error {
+	size := 1 + len(registers)/2 + 2
+	if len(data) < size {
+		return fmt.Errorf("invalid Presto HyperLogLog: truncated dense data")
+	}
+
+	baseline := data[0]
+	deltas := data[1 : 1+len(registers)/2]
+	for i := range registers {
+		registers[i] = baseline + deltas[i/2]>>prestoDeltaShift(i)&0xf
+	}
+
+	n := int(binary.LittleEndian.Uint16(data[size-2:]))
+	if data = data[size:]; len(data) != n*3 {
+		return fmt.Errorf("invalid Presto HyperLogLog: %d overflows do not match %d bytes", n, len(data))
+	}
+	for i := 0; i < n; i++ {
+		bucket := int(binary.LittleEndian.Uint16(data[i*2:]))
+		if bucket >= len(registers) {
+			return fmt.Errorf("invalid Presto HyperLogLog: overflow bucket %d out of range", bucket)
+		}
+		registers[bucket] += data[n*2+i]
+	}
+	return nil
+}
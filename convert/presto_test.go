@@ -0,0 +1,97 @@
+package convert_test
+
+import (
+	"encoding/binary"
+	"fmt"
+	"math/bits"
+
+	"github.com/gowthamkommineni/zetasketch/convert"
+	"github.com/gowthamkommineni/zetasketch/hllplus"
+	"github.com/spaolacci/murmur3"
+
+	. "github.com/bsm/ginkgo"
+	. "github.com/bsm/gomega"
+)
+
+var _ = Describe("Presto", func() {
+	It("should import sparse sketches", func() {
+		data := []byte{2, 11, 0, 0}
+		for i := 0; i < 300; i++ {
+			// emulate Airlift's SparseHll
+			h, _ := murmur3.Sum128([]byte(fmt.Sprintf("v%d", i)))
+			entry := uint32(h>>38)<<6 | uint32(bits.LeadingZeros64(h<<26|1<<25))
+			data = append(data, 0, 0, 0, 0)
+			binary.LittleEndian.PutUint32(data[len(data)-4:], entry)
+		}
+		binary.LittleEndian.PutUint16(data[2:], 300)
+
+		s, err := convert.FromPresto(data)
+		Expect(err).NotTo(HaveOccurred())
+		Expect(s.Precision()).To(Equal(uint8(11)))
+		Expect(s.SparsePrecision()).To(Equal(uint8(16)))
+		Expect(s.Hasher()).To(Equal(convert.PrestoHasher))
+		Expect(s.Estimate()).To(BeNumerically("~", 300, 10))
+
+		// hllplus derives the same registers using the PrestoHasher
+		t, err := hllplus.New(11, 16, hllplus.WithHasher(convert.PrestoHasher))
+		Expect(err).NotTo(HaveOccurred())
+		for i := 0; i < 300; i++ {
+			t.AddString(fmt.Sprintf("v%d", i))
+		}
+		Expect(t.Registers()).To(Equal(s.Registers()))
+		Expect(t.Merge(s)).To(Succeed())
+	})
+
+	It("should import dense sketches", func() {
+		data := append([]byte{3, 10, 2}, make([]byte, 512)...)
+		data[3] = 0x31                     // buckets 0 and 1
+		data = append(data, 1, 0, 1, 0, 7) // bucket 1 overflows by 7
+
+		s, err := convert.FromPresto(data)
+		Expect(err).NotTo(HaveOccurred())
+		Expect(s.Registers()[:3]).To(Equal([]byte{5, 2 + 1 + 7, 2}))
+	})
+
+	It("should export sketches", func() {
+		s, err := hllplus.New(17, 22, hllplus.WithHasher(convert.PrestoHasher))
+		Expect(err).NotTo(HaveOccurred())
+		for i := 0; i < 100000; i++ {
+			s.AddString(fmt.Sprintf("v%d", i))
+		}
+
+		data, err := convert.ToPresto(s)
+		Expect(err).NotTo(HaveOccurred())
+		Expect(data[:3]).To(Equal([]byte{3, 16, 0}))
+		Expect(len(data)).To(BeNumerically(">", 3+32768+2))
+
+		t, err := convert.FromPresto(data)
+		Expect(err).NotTo(HaveOccurred())
+		Expect(t.Precision()).To(Equal(uint8(16)))
+		Expect(t.Estimate()).To(BeNumerically("~", 100000, 1000))
+
+		Expect(s.Downgrade(16, 21)).To(Succeed())
+		Expect(t.Registers()).To(Equal(s.Registers()))
+	})
+
+	It("should reject invalid input", func() {
+		_, err := convert.FromPresto([]byte{3})
+		Expect(err).To(MatchError("invalid Presto HyperLogLog: too short"))
+
+		_, err = convert.FromPresto([]byte{3, 17})
+		Expect(err).To(MatchError("cannot convert Presto HyperLogLog with 17 index bits: must be between 10 and 16"))
+
+		_, err = convert.FromPresto([]byte{1, 12})
+		Expect(err).To(MatchError("invalid Presto HyperLogLog: unsupported format 1"))
+
+		_, err = convert.FromPresto([]byte{2, 12, 1, 0})
+		Expect(err).To(MatchError("invalid Presto HyperLogLog: 1 sparse entries do not match 0 bytes"))
+
+		_, err = convert.FromPresto([]byte{3, 12, 0})
+		Expect(err).To(MatchError("invalid Presto HyperLogLog: truncated dense data"))
+
+		s, err := hllplus.New(14, 19)
+		Expect(err).NotTo(HaveOccurred())
+		_, err = convert.ToPresto(s)
+		Expect(err).To(MatchError(`cannot convert sketch with hasher "fingerprint2011" to Presto`))
+	})
+})
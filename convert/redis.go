@@ -0,0 +1,153 @@
+package convert
+
+import (
+	"bytes"
+	"fmt"
+	"math/bits"
+
+	"github.com/gowthamkommineni/zetasketch/hllplus"
+)
+
+// Redis HyperLogLog constants, see https://github.com/redis/redis/blob/unstable/src/hyperloglog.c.
+const (
+	redisPrecision  = 14
+	redisSparse     = redisPrecision + 5
+	redisRegisters  = 1 << redisPrecision
+	redisHeaderSize = 16
+	redisDenseSize  = redisHeaderSize + (redisRegisters*6+7)/8
+	redisSeed       = 0xadc83b19
+
+	redisEncodingDense  = 0
+	redisEncodingSparse = 1
+)
+
+var redisMagic = []byte("HYLL")
+
+// RedisHasher hashes values like Redis' PFADD command. Hashes are re-arranged, so that
+// the register index and value derived by hllplus match the ones derived by Redis.
+var RedisHasher hllplus.Hasher = redisHasher{}
+
+type redisHasher struct{}
+
+func (redisHasher) ID() string { return "redis-murmur64a" }
+func (redisHasher) Hash64(p []byte) uint64 {
+	h := murmur64A(p, redisSeed)
+
+	// Redis uses the lowest 14 bits as the register index and the number of trailing
+	// zeros of the remaining 50 bits as the register value. hllplus uses the highest
+	// bits as the index and the number of leading zeros as the value.
+	index := h & (redisRegisters - 1)
+	return index<<(64-redisPrecision) | bits.Reverse64(h>>redisPrecision)>>redisPrecision
+}
+
+// FromRedis converts the value of a Redis HyperLogLog key, as returned by GET, into a sketch
+// with precision 14 and sparse precision 19. The sketch uses the RedisHasher, additional
+// options can be passed.
+func FromRedis(data []byte, opts ...hllplus.Option) (*hllplus.HLL, error) {
+	if len(data) < redisHeaderSize || !bytes.Equal(data[:4], redisMagic) {
+		return nil, fmt.Errorf("invalid Redis HyperLogLog: bad header")
+	}
+
+	registers := make([]byte, redisRegisters)
+	switch data[4] {
+	case redisEncodingDense:
+		if len(data) != redisDenseSize {
+			return nil, fmt.Errorf("invalid Redis HyperLogLog: dense size %d, expected %d", len(data), redisDenseSize)
+		}
+		decodeRedisDense(registers, data[redisHeaderSize:])
+	case redisEncodingSparse:
+		if err := decodeRedisSparse(registers, data[redisHeaderSize:]); err != nil {
+			return nil, err
+		}
+	default:
+		return nil, fmt.Errorf("invalid Redis HyperLogLog: unknown encoding %d", data[4])
+	}
+
+	opts = append([]hllplus.Option{hllplus.WithHasher(RedisHasher)}, opts...)
+	return hllplus.NewFromRegisters(redisPrecision, redisSparse, registers, opts...)
+}
+
+// ToRedis converts a sketch into the dense Redis HyperLogLog representation, which can be
+// restored into a Redis key via SET. The sketch must use the RedisHasher and a precision of
+// at least 14, higher precisions are downgraded.
+func ToRedis(s *hllplus.HLL) ([]byte, error) {
+	if id := s.Hasher().ID(); id != RedisHasher.ID() {
+		return nil, fmt.Errorf("cannot convert sketch with hasher %q to Redis", id)
+	}
+	if s.Precision() < redisPrecision {
+		return nil, fmt.Errorf("cannot convert sketch with precision %d to Redis: must be >= %d", s.Precision(), redisPrecision)
+	}
+
+	if s.Precision() > redisPrecision {
+		s = s.Clone()
+		if err := s.Downgrade(redisPrecision, s.SparsePrecision()); err != nil {
+			return nil, err
+		}
+	}
+
+	data := make([]byte, redisDenseSize)
+	copy(data, redisMagic)
+	data[4] = redisEncodingDense
+	data[15] = 1 << 7 // invalidate the cached cardinality
+
+	encodeRedisDense(data[redisHeaderSize:], s.Registers())
+	return data, nil
+}
+
+func decodeRedisDense(registers, data []byte) {
+	for i := range registers {
+		pos := i * 6 / 8
+		shift := uint(i * 6 & 7)
+
+		v := uint(data[pos]) >> shift
+		if pos+1 < len(data) {
+			v |= uint(data[pos+1]) << (8 - shift)
+		}
+		registers[i] = byte(v & 63)
+	}
+}
+
+func encodeRedisDense(data, registers []byte) {
+	for i, v := range registers {
+		pos := i * 6 / 8
+		shift := uint(i * 6 & 7)
+
+		data[pos] |= v << shift
+		if pos+1 < len(data) {
+			data[pos+1] |= v >> (8 - shift)
+		}
+	}
+}
+
+func decodeRedisSparse(registers, data []byte) error {
+	idx := 0
+	for i := 0; i < len(data); i++ {
+		var runLen, value int
+
+		switch op := data[i]; {
+		case op&0xc0 == 0x00: // ZERO: 00xxxxxx
+			runLen = int(op&0x3f) + 1
+		case op&0xc0 == 0x40: // XZERO: 01xxxxxx yyyyyyyy
+			if i++; i == len(data) {
+				return fmt.Errorf("invalid Redis HyperLogLog: truncated sparse data")
+			}
+			runLen = int(op&0x3f)<<8 | int(data[i]) + 1
+		default: // VAL: 1vvvvvxx
+			value = int(op>>2&0x1f) + 1
+			runLen = int(op&0x3) + 1
+		}
+
+		if idx+runLen > len(registers) {
+			return fmt.Errorf("invalid Redis HyperLogLog: sparse data exceeds %d registers", len(registers))
+		}
+		for ; runLen > 0; runLen-- {
+			registers[idx] = byte(value)
+			idx++
+		}
+	}
+
+	if idx != len(registers) {
+		return fmt.Errorf("invalid Redis HyperLogLog: sparse data covers %d of %d registers", idx, len(registers))
+	}
+	return nil
+}
@@ -0,0 +1,146 @@
+package convert_test
+
+import (
+	"encoding/binary"
+	"fmt"
+	"math/bits"
+
+	"github.com/gowthamkommineni/zetasketch/convert"
+	"github.com/gowthamkommineni/zetasketch/hllplus"
+
+	. "github.com/bsm/ginkgo"
+	. "github.com/bsm/gomega"
+)
+
+var _ = Describe("Redis", func() {
+	// redisAdd emulates Redis' PFADD on a dense register array.
+	redisAdd := func(registers []byte, v string) {
+		h := convert.Murmur64A([]byte(v), 0xadc83b19)
+		index := h & 16383
+		count := byte(bits.TrailingZeros64(h>>14|1<<50) + 1)
+		if count > registers[index] {
+			registers[index] = count
+		}
+	}
+
+	redisDense := func(registers []byte) []byte {
+		data := make([]byte, 16+12288)
+		copy(data, "HYLL")
+		for i, v := range registers {
+			pos, shift := i*6/8, uint(i*6&7)
+			data[16+pos] |= v << shift
+			if 16+pos+1 < len(data) {
+				data[16+pos+1] |= v >> (8 - shift)
+			}
+		}
+		return data
+	}
+
+	It("should verify murmur64a", func() {
+		key := make([]byte, 256)
+		hashes := make([]byte, 256*8)
+		for i := 0; i < 256; i++ {
+			key[i] = byte(i)
+			binary.LittleEndian.PutUint64(hashes[i*8:], convert.Murmur64A(key[:i], uint64(256-i)))
+		}
+		Expect(uint32(convert.Murmur64A(hashes, 0))).To(Equal(uint32(0x1f0d3804)))
+	})
+
+	It("should import empty sketches", func() {
+		empty := []byte("HYLL\x01\x00\x00\x00\x00\x00\x00\x00\x00\x00\x00\x00\x7f\xff")
+
+		s, err := convert.FromRedis(empty)
+		Expect(err).NotTo(HaveOccurred())
+		Expect(s.Precision()).To(Equal(uint8(14)))
+		Expect(s.SparsePrecision()).To(Equal(uint8(19)))
+		Expect(s.Hasher()).To(Equal(convert.RedisHasher))
+		Expect(s.Estimate()).To(Equal(int64(0)))
+	})
+
+	It("should import dense sketches", func() {
+		registers := make([]byte, 16384)
+		for i := 0; i < 100000; i++ {
+			redisAdd(registers, fmt.Sprintf("v%d", i))
+		}
+
+		s, err := convert.FromRedis(redisDense(registers))
+		Expect(err).NotTo(HaveOccurred())
+		Expect(s.Registers()).To(Equal(registers))
+		Expect(s.Estimate()).To(BeNumerically("~", 100000, 1000))
+
+		// hllplus derives the same registers using the RedisHasher
+		t, err := hllplus.New(14, 19, hllplus.WithHasher(convert.RedisHasher))
+		Expect(err).NotTo(HaveOccurred())
+		for i := 0; i < 100000; i++ {
+			t.AddString(fmt.Sprintf("v%d", i))
+		}
+		Expect(t.Registers()).To(Equal(registers))
+		Expect(t.Merge(s)).To(Succeed())
+	})
+
+	It("should import sparse sketches", func() {
+		data := []byte("HYLL\x01\x00\x00\x00\x00\x00\x00\x00\x00\x00\x00\x00")
+		data = append(data,
+			0x40, 0x63, // XZERO: 100 registers
+			0x89,       // VAL: 3, 2 registers
+			0x02,       // ZERO: 3 registers
+			0xfc,       // VAL: 32, 1 register
+			0x7f, 0x95, // XZERO: 16278 registers
+		)
+
+		s, err := convert.FromRedis(data)
+		Expect(err).NotTo(HaveOccurred())
+
+		registers := s.Registers()
+		Expect(registers[100:106]).To(Equal([]byte{3, 3, 0, 0, 0, 32}))
+		Expect(registers[:100]).To(Equal(make([]byte, 100)))
+		Expect(registers[106:]).To(Equal(make([]byte, 16278)))
+		Expect(s.Estimate()).To(Equal(int64(3)))
+	})
+
+	It("should export sketches", func() {
+		registers := make([]byte, 16384)
+		s, err := hllplus.New(15, 20, hllplus.WithHasher(convert.RedisHasher))
+		Expect(err).NotTo(HaveOccurred())
+		for i := 0; i < 50000; i++ {
+			redisAdd(registers, fmt.Sprintf("v%d", i))
+			s.AddString(fmt.Sprintf("v%d", i))
+		}
+
+		data, err := convert.ToRedis(s)
+		Expect(err).NotTo(HaveOccurred())
+		Expect(s.Precision()).To(Equal(uint8(15)))
+
+		expected := redisDense(registers)
+		expected[15] = 1 << 7
+		Expect(data).To(Equal(expected))
+
+		t, err := convert.FromRedis(data)
+		Expect(err).NotTo(HaveOccurred())
+		Expect(t.Registers()).To(Equal(registers))
+	})
+
+	It("should reject invalid input", func() {
+		_, err := convert.FromRedis([]byte("HYLX\x01\x00\x00\x00\x00\x00\x00\x00\x00\x00\x00\x00\x7f\xff"))
+		Expect(err).To(MatchError("invalid Redis HyperLogLog: bad header"))
+
+		_, err = convert.FromRedis([]byte("HYLL\x00\x00\x00\x00\x00\x00\x00\x00\x00\x00\x00\x00\x00"))
+		Expect(err).To(MatchError("invalid Redis HyperLogLog: dense size 17, expected 12304"))
+
+		_, err = convert.FromRedis([]byte("HYLL\x01\x00\x00\x00\x00\x00\x00\x00\x00\x00\x00\x00\x7f"))
+		Expect(err).To(MatchError("invalid Redis HyperLogLog: truncated sparse data"))
+
+		_, err = convert.FromRedis([]byte("HYLL\x01\x00\x00\x00\x00\x00\x00\x00\x00\x00\x00\x00\x7f\xfe"))
+		Expect(err).To(MatchError("invalid Redis HyperLogLog: sparse data covers 16383 of 16384 registers"))
+
+		s, err := hllplus.New(14, 19)
+		Expect(err).NotTo(HaveOccurred())
+		_, err = convert.ToRedis(s)
+		Expect(err).To(MatchError(`cannot convert sketch with hasher "fingerprint2011" to Redis`))
+
+		s, err = hllplus.New(12, 17, hllplus.WithHasher(convert.RedisHasher))
+		Expect(err).NotTo(HaveOccurred())
+		_, err = convert.ToRedis(s)
+		Expect(err).To(MatchError("cannot convert sketch with precision 12 to Redis: must be >= 14"))
+	})
+})
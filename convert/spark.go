@@ -0,0 +1,84 @@
+package convert
+
+import (
+	"fmt"
+
+	"github.com/cespare/xxhash/v2"
+	"github.com/gowthamkommineni/zetasketch/hllplus"
+)
+
+// Spark SQL HyperLogLogPlusPlus constants, see
+// https://github.com/apache/spark/blob/master/sql/catalyst/src/main/scala/org/apache/spark/sql/catalyst/util/HyperLogLogPlusPlusHelper.scala.
+const (
+	sparkSeed             = 42
+	sparkRegisterBits     = 6
+	sparkRegistersPerWord = 64 / sparkRegisterBits
+)
+
+// SparkHasher hashes values like Spark SQL's approx_count_distinct, which uses a 64-bit
+// xxHash with seed 42. Spark derives registers exactly like hllplus, so hashes need no
+// re-arrangement. Note that Spark hashes IntegerType values as 4 bytes.
+var SparkHasher hllplus.Hasher = sparkHasher{}
+
+type sparkHasher struct{}
+
+func (sparkHasher) ID() string { return "spark-xxhash64-42" }
+func (sparkHasher) Hash64(p []byte) uint64 {
+	var d xxhash.Digest
+	d.ResetWithSeed(sparkSeed)
+	_, _ = d.Write(p)
+	return d.Sum64()
+}
+
+// FromSpark converts the aggregation buffer of Spark SQL's approx_count_distinct, given as
+// its LongType words, into a sketch. The precision is derived from the number of words.
+// Spark's default relativeSD of 0.05 results in a precision of 9, which cannot be
+// converted; use a relativeSD of at most 0.04. The sketch uses the SparkHasher,
+// additional options can be passed.
+func FromSpark(words []int64, opts ...hllplus.Option) (*hllplus.HLL, error) {
+	precision := uint8(0)
+	for p := uint8(4); p <= hllplus.MaxPrecision; p++ {
+		if sparkNumWords(p) == len(words) {
+			precision = p
+			break
+		}
+	}
+	if precision == 0 {
+		return nil, fmt.Errorf("invalid Spark HyperLogLog++ buffer: unexpected number of words %d", len(words))
+	}
+	if precision < hllplus.MinPrecision {
+		return nil, fmt.Errorf("cannot convert Spark HyperLogLog++ buffer with precision %d: must be >= %d", precision, hllplus.MinPrecision)
+	}
+
+	registers := make([]byte, 1<<precision)
+	for i := range registers {
+		word := uint64(words[i/sparkRegistersPerWord])
+		registers[i] = byte(word >> (i % sparkRegistersPerWord * sparkRegisterBits) & (1<<sparkRegisterBits - 1))
+	}
+
+	sparsePrecision := precision + 5
+	if sparsePrecision > hllplus.MaxSparsePrecision {
+		sparsePrecision = hllplus.MaxSparsePrecision
+	}
+
+	opts = append([]hllplus.Option{hllplus.WithHasher(SparkHasher)}, opts...)
+	return hllplus.NewFromRegisters(precision, sparsePrecision, registers, opts...)
+}
+
+// ToSpark converts a sketch into the aggregation buffer words of Spark SQL's
+// approx_count_distinct. The sketch must use the SparkHasher.
+func ToSpark(s *hllplus.HLL) ([]int64, error) {
+	if id := s.Hasher().ID(); id != SparkHasher.ID() {
+		return nil, fmt.Errorf("cannot convert sketch with hasher %q to Spark", id)
+	}
+
+	words := make([]int64, sparkNumWords(s.Precision()))
+	for i, v := range s.Registers() {
+		words[i/sparkRegistersPerWord] |= int64(v) << (i % sparkRegistersPerWord * sparkRegisterBits)
+	}
+	return words, nil
+}
+
+func sparkNumWords(precision uint8) int {
+	return 1<<precision/sparkRegistersPerWord + 1
+}
@@ -0,0 +1,87 @@
+package convert_test
+
+import (
+	"encoding/binary"
+	"fmt"
+	"math/bits"
+
+	"github.com/gowthamkommineni/zetasketch/convert"
+	"github.com/gowthamkommineni/zetasketch/hllplus"
+
+	. "github.com/bsm/ginkgo"
+	. "github.com/bsm/gomega"
+)
+
+var _ = Describe("Spark", func() {
+	// sparkUpdate emulates Spark's HyperLogLogPlusPlusHelper.update.
+	sparkUpdate := func(words []int64, v string, p uint) {
+		x := convert.SparkHasher.Hash64([]byte(v))
+		idx := x >> (64 - p)
+		pW := int64(bits.LeadingZeros64(x<<p|1<<(p-1)) + 1)
+
+		word, shift := idx/10, idx%10*6
+		if pW > words[word]>>shift&63 {
+			words[word] = words[word]&^(63<<shift) | pW<<shift
+		}
+	}
+
+	It("should hash like Spark", func() {
+		// emulate Spark's XXH64.hashLong
+		hashLong := func(input int64) uint64 {
+			const p1, p2, p3, p4, p5 = 0x9E3779B185EBCA87, 0xC2B2AE3D27D4EB4F, 0x165667B19E3779F9, 0x85EBCA77C2B2AE63, 0x27D4EB2F165667C5
+
+			h := uint64(42 + p5 + 8)
+			h ^= bits.RotateLeft64(uint64(input)*p2, 31) * p1
+			h = bits.RotateLeft64(h, 27)*p1 + p4
+			h ^= h >> 33
+			h *= p2
+			h ^= h >> 29
+			h *= p3
+			h ^= h >> 32
+			return h
+		}
+
+		for _, v := range []int64{0, 1, -1, 1 << 40} {
+			b := make([]byte, 8)
+			binary.LittleEndian.PutUint64(b, uint64(v))
+			Expect(convert.SparkHasher.Hash64(b)).To(Equal(hashLong(v)))
+		}
+	})
+
+	It("should convert buffers", func() {
+		words := make([]int64, 1<<14/10+1)
+		for i := 0; i < 50000; i++ {
+			sparkUpdate(words, fmt.Sprintf("v%d", i), 14)
+		}
+
+		s, err := convert.FromSpark(words)
+		Expect(err).NotTo(HaveOccurred())
+		Expect(s.Precision()).To(Equal(uint8(14)))
+		Expect(s.SparsePrecision()).To(Equal(uint8(19)))
+		Expect(s.Hasher()).To(Equal(convert.SparkHasher))
+		Expect(s.Estimate()).To(BeNumerically("~", 50000, 1000))
+
+		// hllplus derives the same registers using the SparkHasher
+		t, err := hllplus.New(14, 19, hllplus.WithHasher(convert.SparkHasher))
+		Expect(err).NotTo(HaveOccurred())
+		for i := 0; i < 50000; i++ {
+			t.AddString(fmt.Sprintf("v%d", i))
+		}
+		Expect(t.Registers()).To(Equal(s.Registers()))
+		Expect(t.Merge(s)).To(Succeed())
+		Expect(convert.ToSpark(t)).To(Equal(words))
+	})
+
+	It("should reject invalid input", func() {
+		_, err := convert.FromSpark(make([]int64, 5))
+		Expect(err).To(MatchError("invalid Spark HyperLogLog++ buffer: unexpected number of words 5"))
+
+		_, err = convert.FromSpark(make([]int64, 52))
+		Expect(err).To(MatchError("cannot convert Spark HyperLogLog++ buffer with precision 9: must be >= 10"))
+
+		s, err := hllplus.New(14, 19)
+		Expect(err).NotTo(HaveOccurred())
+		_, err = convert.ToSpark(s)
+		Expect(err).To(MatchError(`cannot convert sketch with hasher "fingerprint2011" to Spark`))
+	})
+})
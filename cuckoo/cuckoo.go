@@ -0,0 +1,307 @@
+// Package cuckoo implements cuckoo filters, as described in "Cuckoo Filter: Practically
+// Better Than Bloom" (Fan, Andersen, Kaminsky, Mitzenmacher, 2014). Unlike Bloom
+// filters, cuckoo filters support deleting values.
+//
+// Values are hashed with an hllplus.Hasher, Fingerprint2011 by default, so filters can
+// be fed from the same hashes as hllplus sketches.
+package cuckoo
+
+import (
+	"encoding/binary"
+	"fmt"
+
+	"github.com/gowthamkommineni/zetasketch/hllplus"
+)
+
+const (
+	// bucketSize is the number of fingerprints per bucket.
+	bucketSize = 4
+	// maxKicks is the maximum number of relocations when inserting.
+	maxKicks = 500
+	// maxBuckets is the maximum number of buckets.
+	maxBuckets = 1 << 32
+	// defaultRandomState seeds the choice of entries to evict.
+	defaultRandomState = 0x9e3779b97f4a7c15
+)
+
+var defaultHasher = hllplus.Fingerprint2011
+
+// Option configures optional filter behaviour.
+type Option func(*Filter) error
+
+// WithHasher sets the hasher used for adding values, such as strings or numbers.
+// Filters can only be restored with the same hasher.
+func WithHasher(h hllplus.Hasher) Option {
+	return func(f *Filter) error {
+		if h == nil {
+			return fmt.Errorf("invalid hasher")
+		}
+		f.hasher = h
+		return nil
+	}
+}
+
+// Filter is a cuckoo filter with 16-bit fingerprints and buckets of 4 entries. The
+// false positive rate is below 0.02% at full load.
+type Filter struct {
+	hasher  hllplus.Hasher
+	buckets [][bucketSize]uint16 // 0 marks empty entries
+	count   uint64
+	victim  victim
+	rnd     uint64 // state for choosing entries to evict
+}
+
+// victim holds a fingerprint which could not be placed after too many relocations.
+type victim struct {
+	used   bool
+	index  uint64
+	fprint uint16
+}
+
+// New inits a filter with room for at least capacity values. The number of buckets is
+// rounded up to a power of two; filters typically fill up to about 95% of their size.
+func New(capacity uint64, opts ...Option) (*Filter, error) {
+	numBuckets := uint64(1)
+	for numBuckets*bucketSize < capacity {
+		numBuckets <<= 1
+	}
+	if numBuckets > maxBuckets {
+		return nil, fmt.Errorf("invalid capacity %d", capacity)
+	}
+
+	f := &Filter{
+		hasher:  defaultHasher,
+		buckets: make([][bucketSize]uint16, numBuckets),
+		rnd:     defaultRandomState,
+	}
+	for _, opt := range opts {
+		if err := opt(f); err != nil {
+			return nil, err
+		}
+	}
+	return f, nil
+}
+
+// Hasher returns the hasher used by the filter.
+func (f *Filter) Hasher() hllplus.Hasher {
+	if f.hasher == nil {
+		return defaultHasher
+	}
+	return f.hasher
+}
+
+// Count returns the number of values in the filter.
+func (f *Filter) Count() uint64 {
+	return f.count
+}
+
+// Size returns the number of fingerprints the filter can hold.
+func (f *Filter) Size() uint64 {
+	return uint64(len(f.buckets)) * bucketSize
+}
+
+// LoadFactor returns the fraction of occupied entries.
+func (f *Filter) LoadFactor() float64 {
+	return float64(f.count) / float64(f.Size())
+}
+
+// Add adds the uniform hash value to the filter. It returns an error if the filter is
+// full. Adding the same value multiple times requires deleting it as many times.
+func (f *Filter) Add(hash uint64) error {
+	if f.victim.used {
+		return fmt.Errorf("filter is full")
+	}
+
+	i1, fp := f.indexAndFingerprint(hash)
+	if f.insert(i1, fp) || f.insert(f.altIndex(i1, fp), fp) {
+		f.count++
+		return nil
+	}
+
+	// relocate existing entries, starting at a random bucket
+	i := i1
+	if f.random()&1 == 1 {
+		i = f.altIndex(i1, fp)
+	}
+	for n := 0; n < maxKicks; n++ {
+		j := f.random() % bucketSize
+		fp, f.buckets[i][j] = f.buckets[i][j], fp
+		i = f.altIndex(i, fp)
+		if f.insert(i, fp) {
+			f.count++
+			return nil
+		}
+	}
+
+	// the value was added, but the last evicted one has no place
+	f.victim = victim{used: true, index: i, fprint: fp}
+	f.count++
+	return nil
+}
+
+// AddString hashes and adds a string value, see Add.
+func (f *Filter) AddString(v string) error {
+	return f.Add(f.hasher.Hash64([]byte(v)))
+}
+
+// AddBytes hashes and adds a byte value, see Add.
+func (f *Filter) AddBytes(v []byte) error {
+	return f.Add(f.hasher.Hash64(v))
+}
+
+// AddInt64 hashes and adds a signed number, see Add.
+func (f *Filter) AddInt64(v int64) error {
+	return f.Add(f.hashUint64(uint64(v)))
+}
+
+// AddUint64 hashes and adds an unsigned number, see Add.
+func (f *Filter) AddUint64(v uint64) error {
+	return f.Add(f.hashUint64(v))
+}
+
+// Contains returns true if the hash value may have been added, or false if it
+// definitely has not.
+func (f *Filter) Contains(hash uint64) bool {
+	i1, fp := f.indexAndFingerprint(hash)
+	i2 := f.altIndex(i1, fp)
+	if f.victim.used && f.victim.fprint == fp && (f.victim.index == i1 || f.victim.index == i2) {
+		return true
+	}
+	return f.find(i1, fp) >= 0 || f.find(i2, fp) >= 0
+}
+
+// ContainsString tests a string value, see Contains.
+func (f *Filter) ContainsString(v string) bool {
+	return f.Contains(f.hasher.Hash64([]byte(v)))
+}
+
+// ContainsBytes tests a byte value, see Contains.
+func (f *Filter) ContainsBytes(v []byte) bool {
+	return f.Contains(f.hasher.Hash64(v))
+}
+
+// ContainsInt64 tests a signed number, see Contains.
+func (f *Filter) ContainsInt64(v int64) bool {
+	return f.Contains(f.hashUint64(uint64(v)))
+}
+
+// ContainsUint64 tests an unsigned number, see Contains.
+func (f *Filter) ContainsUint64(v uint64) bool {
+	return f.Contains(f.hashUint64(v))
+}
+
+// Delete removes the hash value from the filter and returns true if it was found.
+// Only values which have been added must be deleted, deleting others may remove values
+// with the same fingerprint.
+func (f *Filter) Delete(hash uint64) bool {
+	i1, fp := f.indexAndFingerprint(hash)
+	i2 := f.altIndex(i1, fp)
+
+	switch {
+	case f.victim.used && f.victim.fprint == fp && (f.victim.index == i1 || f.victim.index == i2):
+		f.victim = victim{}
+	case f.remove(i1, fp) || f.remove(i2, fp):
+		// the victim may fit into the freed entry
+		if v := f.victim; v.used {
+			f.victim = victim{}
+			f.place(v.index, v.fprint)
+		}
+	default:
+		return false
+	}
+	f.count--
+	return true
+}
+
+// DeleteString deletes a string value, see Delete.
+func (f *Filter) DeleteString(v string) bool {
+	return f.Delete(f.hasher.Hash64([]byte(v)))
+}
+
+// DeleteBytes deletes a byte value, see Delete.
+func (f *Filter) DeleteBytes(v []byte) bool {
+	return f.Delete(f.hasher.Hash64(v))
+}
+
+// DeleteInt64 deletes a signed number, see Delete.
+func (f *Filter) DeleteInt64(v int64) bool {
+	return f.Delete(f.hashUint64(uint64(v)))
+}
+
+// DeleteUint64 deletes an unsigned number, see Delete.
+func (f *Filter) DeleteUint64(v uint64) bool {
+	return f.Delete(f.hashUint64(v))
+}
+
+// Reset removes all values.
+func (f *Filter) Reset() {
+	for i := range f.buckets {
+		f.buckets[i] = [bucketSize]uint16{}
+	}
+	f.count = 0
+	f.victim = victim{}
+}
+
+// place puts a fingerprint into bucket i or its alternate, or keeps it as the victim.
+func (f *Filter) place(i uint64, fp uint16) {
+	if !f.insert(i, fp) && !f.insert(f.altIndex(i, fp), fp) {
+		f.victim = victim{used: true, index: i, fprint: fp}
+	}
+}
+
+func (f *Filter) indexAndFingerprint(hash uint64) (uint64, uint16) {
+	fp := uint16(hash >> 48)
+	if fp == 0 {
+		fp = 1
+	}
+	return hash & uint64(len(f.buckets)-1), fp
+}
+
+// altIndex returns the alternate bucket of a fingerprint in bucket i. It is symmetric:
+// altIndex(altIndex(i, fp), fp) == i.
+func (f *Filter) altIndex(i uint64, fp uint16) uint64 {
+	return (i ^ uint64(fp)*0x5bd1e995) & uint64(len(f.buckets)-1)
+}
+
+func (f *Filter) insert(i uint64, fp uint16) bool {
+	b := &f.buckets[i]
+	for j := range b {
+		if b[j] == 0 {
+			b[j] = fp
+			return true
+		}
+	}
+	return false
+}
+
+func (f *Filter) find(i uint64, fp uint16) int {
+	for j, v := range f.buckets[i] {
+		if v == fp {
+			return j
+		}
+	}
+	return -1
+}
+
+func (f *Filter) remove(i uint64, fp uint16) bool {
+	j := f.find(i, fp)
+	if j < 0 {
+		return false
+	}
+	f.buckets[i][j] = 0
+	return true
+}
+
+// random returns the next value of a xorshift64 generator.
+func (f *Filter) random() uint64 {
+	f.rnd ^= f.rnd << 13
+	f.rnd ^= f.rnd >> 7
+	f.rnd ^= f.rnd << 17
+	return f.rnd
+}
+
+func (f *Filter) hashUint64(v uint64) uint64 {
+	var buf [8]byte
+	binary.LittleEndian.PutUint64(buf[:], v)
+	return f.hasher.Hash64(buf[:])
+}
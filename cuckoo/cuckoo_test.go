@@ -0,0 +1,146 @@
+package cuckoo_test
+
+import (
+	"testing"
+
+	"github.com/gowthamkommineni/zetasketch/cuckoo"
+	"github.com/gowthamkommineni/zetasketch/fingerprint"
+	"github.com/gowthamkommineni/zetasketch/hllplus"
+
+	. "github.com/bsm/ginkgo"
+	. "github.com/bsm/gomega"
+)
+
+var _ = Describe("Filter", func() {
+	var subject *cuckoo.Filter
+
+	BeforeEach(func() {
+		var err error
+		subject, err = cuckoo.New(10_000)
+		Expect(err).NotTo(HaveOccurred())
+	})
+
+	It("should init", func() {
+		Expect(subject.Size()).To(Equal(uint64(16_384)))
+		Expect(subject.Count()).To(BeZero())
+		Expect(subject.Hasher()).To(Equal(hllplus.Fingerprint2011))
+
+		_, err := cuckoo.New(1<<34 + 1)
+		Expect(err).To(MatchError("invalid capacity 17179869185"))
+		_, err = cuckoo.New(1, cuckoo.WithHasher(nil))
+		Expect(err).To(MatchError("invalid hasher"))
+	})
+
+	It("should add, test and delete", func() {
+		for i := 0; i < 10_000; i++ {
+			Expect(subject.AddInt64(int64(i))).To(Succeed())
+		}
+		Expect(subject.AddString("foo")).To(Succeed())
+		Expect(subject.AddBytes([]byte("bar"))).To(Succeed())
+		Expect(subject.AddUint64(1 << 63)).To(Succeed())
+		Expect(subject.Count()).To(Equal(uint64(10_003)))
+		Expect(subject.LoadFactor()).To(BeNumerically("~", 0.61, 0.01))
+
+		for i := 0; i < 10_000; i++ {
+			Expect(subject.ContainsInt64(int64(i))).To(BeTrue())
+		}
+		Expect(subject.ContainsString("foo")).To(BeTrue())
+		Expect(subject.ContainsBytes([]byte("foo"))).To(BeTrue())
+		Expect(subject.Contains(fingerprint.String("bar"))).To(BeTrue())
+		Expect(subject.ContainsUint64(1 << 63)).To(BeTrue())
+
+		var fp int
+		for i := 10_000; i < 110_000; i++ {
+			if subject.ContainsInt64(int64(i)) {
+				fp++
+			}
+		}
+		Expect(fp).To(BeNumerically("<", 100))
+
+		for i := 0; i < 5_000; i++ {
+			Expect(subject.DeleteInt64(int64(i))).To(BeTrue())
+		}
+		Expect(subject.DeleteString("foo")).To(BeTrue())
+		Expect(subject.DeleteBytes([]byte("bar"))).To(BeTrue())
+		Expect(subject.DeleteUint64(1 << 63)).To(BeTrue())
+		Expect(subject.DeleteString("foo")).To(BeFalse())
+		Expect(subject.Count()).To(Equal(uint64(5_000)))
+
+		var found int
+		for i := 0; i < 5_000; i++ {
+			if subject.ContainsInt64(int64(i)) {
+				found++
+			}
+		}
+		Expect(found).To(BeNumerically("<", 5))
+		for i := 5_000; i < 10_000; i++ {
+			Expect(subject.ContainsInt64(int64(i))).To(BeTrue())
+		}
+
+		subject.Reset()
+		Expect(subject.Count()).To(BeZero())
+		Expect(subject.ContainsInt64(9_999)).To(BeFalse())
+	})
+
+	It("should count duplicates", func() {
+		Expect(subject.AddString("foo")).To(Succeed())
+		Expect(subject.AddString("foo")).To(Succeed())
+		Expect(subject.DeleteString("foo")).To(BeTrue())
+		Expect(subject.ContainsString("foo")).To(BeTrue())
+		Expect(subject.DeleteString("foo")).To(BeTrue())
+		Expect(subject.ContainsString("foo")).To(BeFalse())
+	})
+
+	It("should fill up", func() {
+		f, err := cuckoo.New(64)
+		Expect(err).NotTo(HaveOccurred())
+
+		var n int
+		for ; n < 1_000; n++ {
+			if f.AddInt64(int64(n)) != nil {
+				break
+			}
+		}
+		Expect(f.AddInt64(-1)).To(MatchError("filter is full"))
+		Expect(f.LoadFactor()).To(BeNumerically(">", 0.9))
+		Expect(f.Count()).To(Equal(uint64(n)))
+
+		// all values are retained, including the last displaced one
+		for i := 0; i < n; i++ {
+			Expect(f.ContainsInt64(int64(i))).To(BeTrue(), "value %d", i)
+		}
+
+		// deleting makes room again
+		for i := 0; i < n; i++ {
+			Expect(f.DeleteInt64(int64(i))).To(BeTrue(), "value %d", i)
+		}
+		Expect(f.Count()).To(BeZero())
+		Expect(f.AddInt64(-1)).To(Succeed())
+	})
+
+	It("should use custom hashers", func() {
+		f, err := cuckoo.New(100, cuckoo.WithHasher(hllplus.XXHash64))
+		Expect(err).NotTo(HaveOccurred())
+		Expect(f.Hasher()).To(Equal(hllplus.XXHash64))
+		Expect(f.AddString("foo")).To(Succeed())
+		Expect(f.ContainsString("foo")).To(BeTrue())
+	})
+})
+
+func TestSuite(t *testing.T) {
+	RegisterFailHandler(Fail)
+	RunSpecs(t, "zetasketch/cuckoo")
+}
+
+func BenchmarkFilter_Add(b *testing.B) {
+	f, err := cuckoo.New(uint64(b.N))
+	if err != nil {
+		b.Fatal(err)
+	}
+
+	b.ReportAllocs()
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		_ = f.Add(uint64(i) * 0x9e3779b97f4a7c15)
+	}
+}
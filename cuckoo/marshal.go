@@ -0,0 +1,136 @@
+package cuckoo
+
+import (
+	"encoding/binary"
+	"fmt"
+)
+
+// encodingVersion is the version of the binary format.
+const encodingVersion = 1
+
+// headerSize is the size of the fields following the hasher ID.
+const headerSize = 23
+
+// Marshal serializes the filter into a stable binary format: a version byte, the
+// length and ID of the hasher, the number of buckets as a little-endian uint32 (0 for
+// 2^32), the number of values as a little-endian uint64 and the victim entry (a flag, its
+// fingerprint and bucket), followed by the fingerprints of all buckets as little-endian
+// uint16 values.
+func (f *Filter) Marshal() ([]byte, error) {
+	id := f.Hasher().ID()
+	if len(id) > 255 {
+		return nil, fmt.Errorf("cannot marshal filter with hasher %q: ID too long", id)
+	}
+
+	data := make([]byte, 0, 2+len(id)+headerSize+len(f.buckets)*bucketSize*2)
+	data = append(data, encodingVersion, byte(len(id)))
+	data = append(data, id...)
+	data = appendUint32(data, uint32(len(f.buckets)))
+	data = appendUint64(data, f.count)
+
+	var used byte
+	if f.victim.used {
+		used = 1
+	}
+	data = append(data, used)
+	data = append(data, byte(f.victim.fprint), byte(f.victim.fprint>>8))
+	data = appendUint64(data, f.victim.index)
+
+	for _, b := range f.buckets {
+		for _, fp := range b {
+			data = append(data, byte(fp), byte(fp>>8))
+		}
+	}
+	return data, nil
+}
+
+// Unmarshal restores the filter from data serialized by Marshal, replacing its current
+// state. The filter must use the same hasher as the serialized one.
+func (f *Filter) Unmarshal(data []byte) error {
+	if len(data) < 2 {
+		return fmt.Errorf("invalid cuckoo filter: too short")
+	}
+	if data[0] != encodingVersion {
+		return fmt.Errorf("invalid cuckoo filter: unsupported version %d", data[0])
+	}
+
+	idLen := int(data[1])
+	data = data[2:]
+	if len(data) < idLen+headerSize {
+		return fmt.Errorf("invalid cuckoo filter: too short")
+	}
+
+	hasher := f.Hasher()
+	if id := string(data[:idLen]); id != hasher.ID() {
+		return fmt.Errorf("cannot restore filter with hasher %q into %q", id, hasher.ID())
+	}
+	data = data[idLen:]
+
+	numBuckets := uint64(binary.LittleEndian.Uint32(data))
+	if numBuckets == 0 {
+		numBuckets = maxBuckets
+	}
+	count := binary.LittleEndian.Uint64(data[4:])
+	v := victim{
+		used:   data[12] == 1,
+		fprint: binary.LittleEndian.Uint16(data[13:]),
+		index:  binary.LittleEndian.Uint64(data[15:]),
+	}
+	data = data[headerSize:]
+
+	if numBuckets&(numBuckets-1) != 0 {
+		return fmt.Errorf("invalid cuckoo filter: %d buckets", numBuckets)
+	}
+	if uint64(len(data)) != numBuckets*bucketSize*2 {
+		return fmt.Errorf("invalid cuckoo filter: %d bytes of buckets, expected %d", len(data), numBuckets*bucketSize*2)
+	}
+	if v.used && (v.index >= numBuckets || v.fprint == 0) {
+		return fmt.Errorf("invalid cuckoo filter: invalid victim")
+	}
+
+	buckets := make([][bucketSize]uint16, numBuckets)
+	var n uint64
+	for i := range buckets {
+		for j := range buckets[i] {
+			fp := binary.LittleEndian.Uint16(data)
+			data = data[2:]
+			if fp != 0 {
+				n++
+			}
+			buckets[i][j] = fp
+		}
+	}
+	if v.used {
+		n++
+	}
+	if n != count {
+		return fmt.Errorf("invalid cuckoo filter: %d fingerprints, expected %d", n, count)
+	}
+
+	f.hasher = hasher
+	f.buckets = buckets
+	f.count = count
+	f.victim = v
+	if f.rnd == 0 {
+		f.rnd = defaultRandomState
+	}
+	return nil
+}
+
+// MarshalBinary implements encoding.BinaryMarshaler using the same format as Marshal.
+func (f *Filter) MarshalBinary() ([]byte, error) {
+	return f.Marshal()
+}
+
+// UnmarshalBinary implements encoding.BinaryUnmarshaler using the same format as Unmarshal.
+func (f *Filter) UnmarshalBinary(data []byte) error {
+	return f.Unmarshal(data)
+}
+
+func appendUint32(data []byte, v uint32) []byte {
+	return append(data, byte(v), byte(v>>8), byte(v>>16), byte(v>>24))
+}
+
+func appendUint64(data []byte, v uint64) []byte {
+	return appendUint32(appendUint32(data, uint32(v)), uint32(v>>32))
+}
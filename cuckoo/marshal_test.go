@@ -0,0 +1,80 @@
+package cuckoo_test
+
+import (
+	"github.com/gowthamkommineni/zetasketch/cuckoo"
+	"github.com/gowthamkommineni/zetasketch/hllplus"
+
+	. "github.com/bsm/ginkgo"
+	. "github.com/bsm/gomega"
+)
+
+var _ = Describe("Filter (marshal)", func() {
+	It("should marshal", func() {
+		f, err := cuckoo.New(4)
+		Expect(err).NotTo(HaveOccurred())
+		Expect(f.Add(0x1234_0000_0000_0000)).To(Succeed())
+
+		data, err := f.Marshal()
+		Expect(err).NotTo(HaveOccurred())
+		Expect(data).To(Equal([]byte{
+			1, 15, // version, hasher ID length
+			'f', 'i', 'n', 'g', 'e', 'r', 'p', 'r', 'i', 'n', 't', '2', '0', '1', '1',
+			1, 0, 0, 0, // buckets
+			1, 0, 0, 0, 0, 0, 0, 0, // count
+			0, 0, 0, 0, 0, 0, 0, 0, 0, 0, 0, // victim
+			0x34, 0x12, 0, 0, 0, 0, 0, 0, // fingerprints
+		}))
+
+		res := new(cuckoo.Filter)
+		Expect(res.Unmarshal(data)).To(Succeed())
+		Expect(res.Count()).To(Equal(uint64(1)))
+		Expect(res.Contains(0x1234_0000_0000_0000)).To(BeTrue())
+		Expect(res.Marshal()).To(Equal(data))
+	})
+
+	It("should round trip full filters", func() {
+		f, err := cuckoo.New(64)
+		Expect(err).NotTo(HaveOccurred())
+		var n int
+		for ; f.AddInt64(int64(n)) == nil; n++ {
+		}
+
+		data, err := f.MarshalBinary()
+		Expect(err).NotTo(HaveOccurred())
+
+		res, err := cuckoo.New(1)
+		Expect(err).NotTo(HaveOccurred())
+		Expect(res.UnmarshalBinary(data)).To(Succeed())
+		Expect(res.Count()).To(Equal(f.Count()))
+		Expect(res.Size()).To(Equal(f.Size()))
+		for i := 0; i < n; i++ {
+			Expect(res.ContainsInt64(int64(i))).To(BeTrue())
+		}
+		Expect(res.AddInt64(-1)).To(MatchError("filter is full"))
+	})
+
+	It("should reject invalid data", func() {
+		f, err := cuckoo.New(4)
+		Expect(err).NotTo(HaveOccurred())
+		data, err := f.Marshal()
+		Expect(err).NotTo(HaveOccurred())
+
+		res := new(cuckoo.Filter)
+		Expect(res.Unmarshal(nil)).To(MatchError("invalid cuckoo filter: too short"))
+		Expect(res.Unmarshal([]byte{2, 0})).To(MatchError("invalid cuckoo filter: unsupported version 2"))
+		Expect(res.Unmarshal(data[:20])).To(MatchError("invalid cuckoo filter: too short"))
+		Expect(res.Unmarshal(data[:len(data)-1])).To(MatchError("invalid cuckoo filter: 7 bytes of buckets, expected 8"))
+
+		bad := append([]byte{}, data...)
+		bad[17] = 3
+		Expect(res.Unmarshal(bad)).To(MatchError("invalid cuckoo filter: 3 buckets"))
+
+		bad = append([]byte{}, data...)
+		bad[21] = 1
+		Expect(res.Unmarshal(bad)).To(MatchError("invalid cuckoo filter: 0 fingerprints, expected 1"))
+
+		xx, err := cuckoo.New(4, cuckoo.WithHasher(hllplus.XXHash64))
+		Expect(err).NotTo(HaveOccurred())
+		Expect(xx.Unmarshal(data)).To(MatchError(`cannot restore filter with hasher "fingerprint2011" into "xxhash64"`))
+	})
+})
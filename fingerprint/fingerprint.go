@@ -0,0 +1,36 @@
+// Package fingerprint implements the Fingerprint2011 64-bit hash, as used by the zetasketch
+// Java library and BigQuery's HLL_COUNT functions. Values hashed with this package can be
+// added to sketches which are merged with sketches produced by BigQuery.
+package fingerprint
+
+import "github.com/gowthamkommineni/zetasketch/internal/hash"
+
+// Bytes computes the fingerprint of a byte slice.
+func Bytes(p []byte) uint64 {
+	return hash.Bytes(p)
+}
+
+// String computes the fingerprint of a string, using its UTF-8 bytes.
+func String(s string) uint64 {
+	return hash.String(s)
+}
+
+// Int32 computes the fingerprint of a signed 32-bit number.
+func Int32(v int32) uint64 {
+	return hash.Uint32(uint32(v))
+}
+
+// Uint32 computes the fingerprint of an unsigned 32-bit number.
+func Uint32(v uint32) uint64 {
+	return hash.Uint32(v)
+}
+
+// Int64 computes the fingerprint of a signed 64-bit number.
+func Int64(v int64) uint64 {
+	return hash.Uint64(uint64(v))
+}
+
+// Uint64 computes the fingerprint of an unsigned 64-bit number.
+func Uint64(v uint64) uint64 {
+	return hash.Uint64(v)
+}
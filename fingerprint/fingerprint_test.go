@@ -0,0 +1,38 @@
+package fingerprint_test
+
+import (
+	"bytes"
+	"testing"
+
+	"github.com/gowthamkommineni/zetasketch/fingerprint"
+
+	. "github.com/bsm/ginkgo"
+	. "github.com/bsm/gomega"
+)
+
+var _ = Describe("Fingerprint", func() {
+	It("should fingerprint bytes", func() {
+		Expect(fingerprint.Bytes(nil)).To(Equal(uint64(0x23ad7c904aa665e3)))
+		Expect(fingerprint.Bytes([]byte("foobar"))).To(Equal(uint64(0x36a1e57a138e4467)))
+		Expect(fingerprint.Bytes(bytes.Repeat([]byte("foobar"), 8))).To(Equal(uint64(0x94386e8403038649)))
+	})
+
+	It("should fingerprint strings", func() {
+		Expect(fingerprint.String("foo")).To(Equal(uint64(0xd0bcbfe261b36504)))
+		Expect(fingerprint.String("Z\u00fcrich")).To(Equal(uint64(0x27efc00f7d2ce548)))
+		Expect(fingerprint.String("Zu\u0308rich")).To(Equal(uint64(0x7dfa3067e55c7e8a)))
+	})
+
+	It("should fingerprint numbers", func() {
+		Expect(fingerprint.Uint32(1)).To(Equal(uint64(0xba4e724d9d787a26)))
+		Expect(fingerprint.Int32(1)).To(Equal(uint64(0xba4e724d9d787a26)))
+		Expect(fingerprint.Uint64(1)).To(Equal(uint64(0xb91968b83211c978)))
+		Expect(fingerprint.Int64(1)).To(Equal(uint64(0xb91968b83211c978)))
+		Expect(fingerprint.Int64(-1)).To(Equal(fingerprint.Uint64(1<<64 - 1)))
+	})
+})
+
+func TestSuite(t *testing.T) {
+	RegisterFailHandler(Fail)
+	RunSpecs(t, "zetasketch/fingerprint")
+}
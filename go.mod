@@ -1,10 +1,13 @@
 module github.com/gowthamkommineni/zetasketch
 
-go 1.14
+go 1.18
 
 require (
 	github.com/bsm/ginkgo v1.16.4
 	github.com/bsm/gomega v1.16.0
+	github.com/cespare/xxhash/v2 v2.3.0
+	github.com/go-faster/city v1.0.1
+	github.com/spaolacci/murmur3 v1.1.0
 	google.golang.org/protobuf v1.27.1
 )
 
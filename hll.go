@@ -4,9 +4,6 @@ import (
 	"fmt"
 
 	"github.com/gowthamkommineni/zetasketch/hllplus"
-	"github.com/gowthamkommineni/zetasketch/internal/zetasketch"
-	pb "github.com/gowthamkommineni/zetasketch/internal/zetasketch"
-	"google.golang.org/protobuf/proto"
 )
 
 // HLL implements a HLL++ aggregator for estimating cardinalities of multisets.
@@ -20,7 +17,6 @@ import (
 // Note that this aggregator is not designed to be thread safe.
 type HLL struct {
 	h *hllplus.HLL
-	n int64
 }
 
 // NewHLL inits a new HLL++ aggregator.
@@ -34,13 +30,12 @@ func NewHLL(cfg *HLLConfig) *HLL {
 
 // Add adds value v to the aggregator.
 func (h *HLL) Add(v Value) {
-	h.n++
 	h.h.Add(v.Sum64())
 }
 
 // NumValues returns the number of values seen.
 func (h *HLL) NumValues() int64 {
-	return h.n
+	return h.h.NumValues()
 }
 
 // Merge merges aggregator other into h.
@@ -50,9 +45,7 @@ func (h *HLL) Merge(other Aggregator) error {
 		return fmt.Errorf("cannot merge %T into %T", other, h)
 	}
 
-	h.h.Merge(h2.h)
-	h.n += h2.n
-	return nil
+	return h.h.Merge(h2.h)
 }
 
 // Result returns an estimate of the unique of values.
@@ -62,57 +55,17 @@ func (h *HLL) Result() int64 {
 
 // MarshalBinary serializes aggregator to bytes.
 func (h *HLL) MarshalBinary() ([]byte, error) {
-	return proto.Marshal(h.proto())
+	return h.h.Marshal()
 }
 
 // UnmarshalBinary deserializes aggregator from bytes.
 func (h *HLL) UnmarshalBinary(data []byte) error {
-	msg := new(pb.AggregatorStateProto)
-	if err := proto.Unmarshal(data, msg); err != nil {
-		return err
-	}
-	return h.fromProto(msg)
-}
-
-func (h *HLL) proto() *pb.AggregatorStateProto {
-	var (
-		encodingVersion int32 = 2
-		aggType               = pb.AggregatorType_HYPERLOGLOG_PLUS_UNIQUE
-		numValues             = int64(h.n)
-	)
-	msg := &pb.AggregatorStateProto{
-		Type:            &aggType,
-		EncodingVersion: &encodingVersion,
-		NumValues:       &numValues,
-	}
-	proto.SetExtension(msg, zetasketch.E_HyperloglogplusUniqueState, h.h.Proto())
-	return msg
-}
-
-func (h *HLL) fromProto(msg *pb.AggregatorStateProto) error {
-	if msg.GetType() != pb.AggregatorType_HYPERLOGLOG_PLUS_UNIQUE {
-		return fmt.Errorf("incompatible binary message: unexpected type %s", msg.GetType().String())
-	}
-	if msg.GetEncodingVersion() != 2 {
-		return fmt.Errorf("incompatible binary message: unsupported encoding version %#v", msg.GetEncodingVersion())
-	}
-	if msg.NumValues == nil {
-		return fmt.Errorf("incompatible binary message: no num values")
-	}
-
-	ext := proto.GetExtension(msg, zetasketch.E_HyperloglogplusUniqueState)
-	hState, ok := ext.(*pb.HyperLogLogPlusUniqueStateProto)
-	if !ok {
-		return fmt.Errorf("incompatible binary message: invalid HyperLogLog++ state")
-	}
-
-	hll, err := hllplus.NewFromProto(hState)
-	if err != nil {
+	hll := new(hllplus.HLL)
+	if err := hll.Unmarshal(data); err != nil {
 		return err
 	}
 
 	h.h = hll
-	h.n = msg.GetNumValues()
 	return nil
 }
 
@@ -38,7 +38,7 @@ var _ = Describe("HLL", func() {
 
 		Expect(subject.Merge(other)).To(Succeed())
 		Expect(subject.NumValues()).To(BeNumerically("==", 1_900))
-		Expect(subject.Result()).To(BeNumerically("==", 1_207))
+		Expect(subject.Result()).To(BeNumerically("==", 1_201))
 
 		// `other` is not modified:
 		Expect(other.NumValues()).To(BeNumerically("==", 400))
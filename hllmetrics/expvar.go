@@ -0,0 +1,42 @@
+package hllmetrics
+
+import (
+	"expvar"
+	"strconv"
+)
+
+// Sketch is implemented by sketches which report their memory usage, such as
+// hllplus.SafeHLL, hllplus.AtomicHLL and hllplus.ShardedHLL.
+type Sketch interface {
+	Estimator
+	MemoryUsage() int
+}
+
+// Var publishes the state of a sketch as an expvar.Var, e.g.:
+//
+//	expvar.Publish("unique_visitors", hllmetrics.NewVar(visitors))
+//
+// which is served under /debug/vars as:
+//
+//	"unique_visitors": {"estimate": 1234, "memory_usage": 4136}
+type Var struct {
+	s Sketch
+}
+
+var _ expvar.Var = (*Var)(nil)
+
+// NewVar inits a new var for s.
+func NewVar(s Sketch) *Var {
+	return &Var{s: s}
+}
+
+// String implements expvar.Var and returns the state as JSON.
+func (v *Var) String() string {
+	b := make([]byte, 0, 64)
+	b = append(b, `{"estimate": `...)
+	b = strconv.AppendInt(b, v.s.Estimate(), 10)
+	b = append(b, `, "memory_usage": `...)
+	b = strconv.AppendInt(b, int64(v.s.MemoryUsage()), 10)
+	b = append(b, '}')
+	return string(b)
+}
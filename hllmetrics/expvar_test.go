@@ -0,0 +1,46 @@
+package hllmetrics_test
+
+import (
+	"encoding/json"
+	"expvar"
+	"fmt"
+
+	"github.com/gowthamkommineni/zetasketch/hllmetrics"
+	"github.com/gowthamkommineni/zetasketch/hllplus"
+
+	. "github.com/bsm/ginkgo"
+	. "github.com/bsm/gomega"
+)
+
+var _ = Describe("Var", func() {
+	var sketch *hllplus.SafeHLL
+	var subject *hllmetrics.Var
+
+	BeforeEach(func() {
+		var err error
+		sketch, err = hllplus.NewSafe(14, 19)
+		Expect(err).NotTo(HaveOccurred())
+		subject = hllmetrics.NewVar(sketch)
+	})
+
+	It("should publish the current state as JSON", func() {
+		var state struct {
+			Estimate    int64 `json:"estimate"`
+			MemoryUsage int   `json:"memory_usage"`
+		}
+		Expect(json.Unmarshal([]byte(subject.String()), &state)).To(Succeed())
+		Expect(state.Estimate).To(Equal(int64(0)))
+
+		for i := 0; i < 100; i++ {
+			sketch.AddString(fmt.Sprintf("user-%d", i))
+		}
+		Expect(json.Unmarshal([]byte(subject.String()), &state)).To(Succeed())
+		Expect(state.Estimate).To(Equal(int64(100)))
+		Expect(state.MemoryUsage).To(Equal(sketch.MemoryUsage()))
+	})
+
+	It("should be publishable", func() {
+		expvar.Publish("hllmetrics_test_var", subject)
+		Expect(expvar.Get("hllmetrics_test_var")).To(Equal(subject))
+	})
+})
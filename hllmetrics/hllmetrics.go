@@ -0,0 +1,222 @@
+// Package hllmetrics exposes the estimates of sketches as gauges, in the Prometheus text
+// exposition format, so unique counts show up in existing monitoring without polling
+// code. Prometheus scrapes the Registry directly, as it implements http.Handler.
+//
+// The package has no dependencies beyond this module. The hllmetrics/hllprom module
+// provides a prometheus.Collector for registries, for services which already use the
+// Prometheus client library. InstrumentedHLL records sketch behavior with instruments
+// which mirror, but do not import, the OpenTelemetry metrics API; the hllmetrics/hllotel
+// module backs them with OpenTelemetry instruments and reports estimates with
+// observable gauges.
+package hllmetrics
+
+import (
+	"bufio"
+	"fmt"
+	"io"
+	"net/http"
+	"regexp"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+
+	"github.com/gowthamkommineni/zetasketch/hllplus"
+)
+
+var (
+	metricNameRE = regexp.MustCompile(`^[a-zA-Z_:][a-zA-Z0-9_:]*$`)
+	labelNameRE  = regexp.MustCompile(`^[a-zA-Z_][a-zA-Z0-9_]*$`)
+)
+
+// Estimator is implemented by sketches which can be estimated concurrently, such as
+// hllplus.SafeHLL, hllplus.AtomicHLL and hllplus.ShardedHLL. A plain hllplus.HLL must
+// only be registered if it is not modified concurrently.
+type Estimator interface {
+	Estimate() int64
+}
+
+// Sample is the value of a gauge.
+type Sample struct {
+	Name       string
+	LabelName  string // empty for sketches without keys
+	LabelValue string
+	Value      int64
+}
+
+// Description describes a registered metric.
+type Description struct {
+	Name      string
+	Help      string
+	LabelName string // empty for sketches without keys
+}
+
+// Registry maintains named sketches and is safe for concurrent use.
+type Registry struct {
+	mu      sync.Mutex
+	metrics map[string]*metric
+}
+
+type metric struct {
+	help string
+
+	sketch Estimator
+
+	grouped *hllplus.GroupedHLL
+	label   string
+	maxKeys int
+}
+
+// NewRegistry inits a new registry.
+func NewRegistry() *Registry {
+	return &Registry{metrics: make(map[string]*metric)}
+}
+
+// Register registers a sketch under a metric name.
+func (r *Registry) Register(name, help string, s Estimator) error {
+	return r.register(name, &metric{help: help, sketch: s})
+}
+
+// RegisterGrouped registers the sketches of a GroupedHLL under a metric name, with the
+// key as the value of the given label. To limit the cardinality of the metric, only the
+// maxKeys keys with the largest estimates are exposed.
+func (r *Registry) RegisterGrouped(name, help, label string, g *hllplus.GroupedHLL, maxKeys int) error {
+	if !labelNameRE.MatchString(label) || strings.HasPrefix(label, "__") {
+		return fmt.Errorf("invalid label name %q", label)
+	}
+	if maxKeys <= 0 {
+		return fmt.Errorf("invalid number of keys %d", maxKeys)
+	}
+	return r.register(name, &metric{help: help, grouped: g, label: label, maxKeys: maxKeys})
+}
+
+// Unregister removes the metric name.
+func (r *Registry) Unregister(name string) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	delete(r.metrics, name)
+}
+
+func (r *Registry) register(name string, m *metric) error {
+	if !metricNameRE.MatchString(name) {
+		return fmt.Errorf("invalid metric name %q", name)
+	}
+
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	if _, ok := r.metrics[name]; ok {
+		return fmt.Errorf("duplicate metric name %q", name)
+	}
+	r.metrics[name] = m
+	return nil
+}
+
+// Descriptions returns the descriptions of all registered metrics, sorted by name.
+func (r *Registry) Descriptions() []Description {
+	r.mu.Lock()
+	descs := make([]Description, 0, len(r.metrics))
+	for name, m := range r.metrics {
+		descs = append(descs, Description{Name: name, Help: m.help, LabelName: m.label})
+	}
+	r.mu.Unlock()
+
+	sort.Slice(descs, func(i, j int) bool { return descs[i].Name < descs[j].Name })
+	return descs
+}
+
+// Gather estimates all sketches. Samples are sorted by name, samples of grouped
+// sketches by decreasing value.
+func (r *Registry) Gather() []Sample {
+	r.mu.Lock()
+	names := make([]string, 0, len(r.metrics))
+	metrics := make(map[string]*metric, len(r.metrics))
+	for name, m := range r.metrics {
+		names = append(names, name)
+		metrics[name] = m
+	}
+	r.mu.Unlock()
+
+	sort.Strings(names)
+	var samples []Sample
+	for _, name := range names {
+		m := metrics[name]
+		if m.grouped == nil {
+			samples = append(samples, Sample{Name: name, Value: m.sketch.Estimate()})
+			continue
+		}
+
+		estimates := m.grouped.Estimates()
+		group := make([]Sample, 0, len(estimates))
+		for key, est := range estimates {
+			group = append(group, Sample{Name: name, LabelName: m.label, LabelValue: key, Value: est})
+		}
+		sort.Slice(group, func(i, j int) bool {
+			if group[i].Value != group[j].Value {
+				return group[i].Value > group[j].Value
+			}
+			return group[i].LabelValue < group[j].LabelValue
+		})
+		if len(group) > m.maxKeys {
+			group = group[:m.maxKeys]
+		}
+		samples = append(samples, group...)
+	}
+	return samples
+}
+
+// WriteTo writes all gauges in the Prometheus text exposition format.
+func (r *Registry) WriteTo(w io.Writer) (int64, error) {
+	samples := r.Gather()
+
+	r.mu.Lock()
+	help := make(map[string]string, len(r.metrics))
+	for name, m := range r.metrics {
+		help[name] = m.help
+	}
+	r.mu.Unlock()
+
+	cw := &countingWriter{w: w}
+	bw := bufio.NewWriter(cw)
+	for i, s := range samples {
+		if i == 0 || samples[i-1].Name != s.Name {
+			if h := help[s.Name]; h != "" {
+				fmt.Fprintf(bw, "# HELP %s %s\n", s.Name, helpEscaper.Replace(h))
+			}
+			fmt.Fprintf(bw, "# TYPE %s gauge\n", s.Name)
+		}
+
+		bw.WriteString(s.Name)
+		if s.LabelName != "" {
+			fmt.Fprintf(bw, "{%s=\"%s\"}", s.LabelName, labelEscaper.Replace(s.LabelValue))
+		}
+		bw.WriteByte(' ')
+		bw.WriteString(strconv.FormatInt(s.Value, 10))
+		bw.WriteByte('\n')
+	}
+	err := bw.Flush()
+	return cw.n, err
+}
+
+// ServeHTTP implements http.Handler.
+func (r *Registry) ServeHTTP(w http.ResponseWriter, _ *http.Request) {
+	w.Header().Set("Content-Type", "text/plain; version=0.0.4; charset=utf-8")
+	_, _ = r.WriteTo(w)
+}
+
+var (
+	helpEscaper  = strings.NewReplacer(`\`, `\\`, "\n", `\n`)
+	labelEscaper = strings.NewReplacer(`\`, `\\`, "\n", `\n`, `"`, `\"`)
+)
+
+type countingWriter struct {
+	w io.Writer
+	n int64
+}
+
+func (w *countingWriter) Write(p []byte) (int, error) {
+	n, err := w.w.Write(p)
+	w.n += int64(n)
+	return n, err
+}
@@ -0,0 +1,104 @@
+package hllmetrics_test
+
+import (
+	"fmt"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/gowthamkommineni/zetasketch/hllmetrics"
+	"github.com/gowthamkommineni/zetasketch/hllplus"
+
+	. "github.com/bsm/ginkgo"
+	. "github.com/bsm/gomega"
+)
+
+var _ = Describe("Registry", func() {
+	var subject *hllmetrics.Registry
+	var visitors *hllplus.SafeHLL
+	var pages *hllplus.GroupedHLL
+
+	BeforeEach(func() {
+		var err error
+		visitors, err = hllplus.NewSafe(14, 19)
+		Expect(err).NotTo(HaveOccurred())
+		pages, err = hllplus.NewGrouped(4, 14, 19)
+		Expect(err).NotTo(HaveOccurred())
+
+		for i := 0; i < 3; i++ {
+			visitors.AddString(fmt.Sprintf("user-%d", i))
+		}
+		for i := 0; i < 5; i++ {
+			pages.AddString("/home", fmt.Sprintf("user-%d", i))
+		}
+		for i := 0; i < 3; i++ {
+			pages.AddString("/about", fmt.Sprintf("user-%d", i))
+		}
+		pages.AddString(`/say "hi"`, "user-0")
+
+		subject = hllmetrics.NewRegistry()
+		Expect(subject.Register("unique_visitors", "Unique visitors.", visitors)).To(Succeed())
+		Expect(subject.RegisterGrouped("unique_page_visitors", "Unique visitors\nby page.", "page", pages, 2)).To(Succeed())
+	})
+
+	It("should validate", func() {
+		Expect(subject.Register("unique_visitors", "", visitors)).To(MatchError(`duplicate metric name "unique_visitors"`))
+		Expect(subject.Register("unique-visitors", "", visitors)).To(MatchError(`invalid metric name "unique-visitors"`))
+		Expect(subject.RegisterGrouped("by_page", "", "__page", pages, 2)).To(MatchError(`invalid label name "__page"`))
+		Expect(subject.RegisterGrouped("by_page", "", "page", pages, 0)).To(MatchError(`invalid number of keys 0`))
+	})
+
+	It("should gather", func() {
+		Expect(subject.Gather()).To(Equal([]hllmetrics.Sample{
+			{Name: "unique_page_visitors", LabelName: "page", LabelValue: "/home", Value: 5},
+			{Name: "unique_page_visitors", LabelName: "page", LabelValue: "/about", Value: 3},
+			{Name: "unique_visitors", Value: 3},
+		}))
+
+		subject.Unregister("unique_page_visitors")
+		Expect(subject.Gather()).To(Equal([]hllmetrics.Sample{
+			{Name: "unique_visitors", Value: 3},
+		}))
+	})
+
+	It("should describe", func() {
+		Expect(subject.Descriptions()).To(Equal([]hllmetrics.Description{
+			{Name: "unique_page_visitors", Help: "Unique visitors\nby page.", LabelName: "page"},
+			{Name: "unique_visitors", Help: "Unique visitors."},
+		}))
+	})
+
+	It("should write the text format", func() {
+		Expect(subject.RegisterGrouped("all_page_visitors", "", "page", pages, 10)).To(Succeed())
+
+		var b strings.Builder
+		n, err := subject.WriteTo(&b)
+		Expect(err).NotTo(HaveOccurred())
+		Expect(n).To(Equal(int64(b.Len())))
+		Expect(b.String()).To(Equal(`# TYPE all_page_visitors gauge
+all_page_visitors{page="/home"} 5
+all_page_visitors{page="/about"} 3
+all_page_visitors{page="/say \"hi\""} 1
+# HELP unique_page_visitors Unique visitors\nby page.
+# TYPE unique_page_visitors gauge
+unique_page_visitors{page="/home"} 5
+unique_page_visitors{page="/about"} 3
+# HELP unique_visitors Unique visitors.
+# TYPE unique_visitors gauge
+unique_visitors 3
+`))
+	})
+
+	It("should serve HTTP", func() {
+		w := httptest.NewRecorder()
+		subject.ServeHTTP(w, httptest.NewRequest("GET", "/metrics", nil))
+		Expect(w.Code).To(Equal(200))
+		Expect(w.Header().Get("Content-Type")).To(Equal("text/plain; version=0.0.4; charset=utf-8"))
+		Expect(w.Body.String()).To(ContainSubstring("\nunique_visitors 3\n"))
+	})
+})
+
+func TestSuite(t *testing.T) {
+	RegisterFailHandler(Fail)
+	RunSpecs(t, "zetasketch/hllmetrics")
+}
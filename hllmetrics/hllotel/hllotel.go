@@ -0,0 +1,116 @@
+// Package hllotel exposes sketches through the OpenTelemetry metrics API. Estimates are
+// reported by observable gauges, which are computed on collection, and the instruments
+// of a hllmetrics.InstrumentedHLL are backed by OpenTelemetry instruments. It is a
+// separate module, so that the zetasketch module does not depend on OpenTelemetry.
+//
+//	meter := otel.Meter("visitors")
+//	reg, err := hllotel.RegisterGauge(meter, "unique_visitors", "Unique visitors.", visitors)
+//	...
+//	defer reg.Unregister()
+package hllotel
+
+import (
+	"context"
+
+	"github.com/gowthamkommineni/zetasketch/hllmetrics"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/metric"
+)
+
+// RegisterGauge registers an observable gauge, which reports the estimate of s with the
+// given attributes whenever the meter collects. Unregister the returned registration to
+// stop observing s.
+func RegisterGauge(meter metric.Meter, name, description string, s hllmetrics.Estimator, attrs ...attribute.KeyValue) (metric.Registration, error) {
+	gauge, err := meter.Int64ObservableGauge(name, metric.WithDescription(description))
+	if err != nil {
+		return nil, err
+	}
+
+	opt := metric.WithAttributes(attrs...)
+	return meter.RegisterCallback(func(_ context.Context, o metric.Observer) error {
+		o.ObserveInt64(gauge, s.Estimate(), opt)
+		return nil
+	}, gauge)
+}
+
+// RegisterRegistry registers an observable gauge for each metric of r, which reports the
+// samples of r.Gather whenever the meter collects. Grouped sketches are reported with
+// their key as the value of the label attribute. Metrics registered with r afterwards
+// are not reported.
+func RegisterRegistry(meter metric.Meter, r *hllmetrics.Registry) (metric.Registration, error) {
+	descs := r.Descriptions()
+	gauges := make(map[string]metric.Int64ObservableGauge, len(descs))
+	instruments := make([]metric.Observable, 0, len(descs))
+	for _, d := range descs {
+		gauge, err := meter.Int64ObservableGauge(d.Name, metric.WithDescription(d.Help))
+		if err != nil {
+			return nil, err
+		}
+		gauges[d.Name] = gauge
+		instruments = append(instruments, gauge)
+	}
+
+	return meter.RegisterCallback(func(_ context.Context, o metric.Observer) error {
+		for _, s := range r.Gather() {
+			gauge, ok := gauges[s.Name]
+			if !ok {
+				continue
+			}
+
+			if s.LabelName == "" {
+				o.ObserveInt64(gauge, s.Value)
+			} else {
+				o.ObserveInt64(gauge, s.Value, metric.WithAttributes(attribute.String(s.LabelName, s.LabelValue)))
+			}
+		}
+		return nil
+	}, instruments...)
+}
+
+// NewInstruments creates the instruments of a hllmetrics.InstrumentedHLL with meter.
+// Instrument names are prefixed with prefix, e.g. "visitors." for "visitors.adds".
+func NewInstruments(meter metric.Meter, prefix string) (hllmetrics.Instruments, error) {
+	adds, err := meter.Int64Counter(prefix+"adds", metric.WithDescription("Number of added values."))
+	if err != nil {
+		return hllmetrics.Instruments{}, err
+	}
+	merges, err := meter.Int64Counter(prefix+"merges", metric.WithDescription("Number of merged sketches."))
+	if err != nil {
+		return hllmetrics.Instruments{}, err
+	}
+	normalizations, err := meter.Int64Counter(prefix+"normalizations", metric.WithDescription("Number of conversions to the normal representation."))
+	if err != nil {
+		return hllmetrics.Instruments{}, err
+	}
+	estimate, err := meter.Int64Gauge(prefix+"estimate", metric.WithDescription("Last computed estimate."))
+	if err != nil {
+		return hllmetrics.Instruments{}, err
+	}
+
+	return hllmetrics.Instruments{
+		Adds:           counter{adds},
+		Merges:         counter{merges},
+		Normalizations: counter{normalizations},
+		Estimate:       gauge{estimate},
+	}, nil
+}
+
+type counter struct{ c metric.Int64Counter }
+
+func (c counter) Add(ctx context.Context, incr int64, attrs ...hllmetrics.Attribute) {
+	c.c.Add(ctx, incr, withAttributes(attrs))
+}
+
+type gauge struct{ g metric.Int64Gauge }
+
+func (g gauge) Record(ctx context.Context, value int64, attrs ...hllmetrics.Attribute) {
+	g.g.Record(ctx, value, withAttributes(attrs))
+}
+
+func withAttributes(attrs []hllmetrics.Attribute) metric.MeasurementOption {
+	kvs := make([]attribute.KeyValue, 0, len(attrs))
+	for _, a := range attrs {
+		kvs = append(kvs, attribute.String(a.Key, a.Value))
+	}
+	return metric.WithAttributes(kvs...)
+}
@@ -0,0 +1,128 @@
+package hllotel_test
+
+import (
+	"context"
+	"fmt"
+	"testing"
+
+	"github.com/gowthamkommineni/zetasketch/hllmetrics"
+	"github.com/gowthamkommineni/zetasketch/hllmetrics/hllotel"
+	"github.com/gowthamkommineni/zetasketch/hllplus"
+	"go.opentelemetry.io/otel/attribute"
+	sdkmetric "go.opentelemetry.io/otel/sdk/metric"
+	"go.opentelemetry.io/otel/sdk/metric/metricdata"
+
+	. "github.com/bsm/ginkgo"
+	. "github.com/bsm/gomega"
+)
+
+var _ = Describe("hllotel", func() {
+	var reader *sdkmetric.ManualReader
+	var provider *sdkmetric.MeterProvider
+	var visitors *hllplus.SafeHLL
+	ctx := context.Background()
+
+	// collect returns the data points of all instruments by name.
+	collect := func() map[string][]metricdata.DataPoint[int64] {
+		var rm metricdata.ResourceMetrics
+		Expect(reader.Collect(ctx, &rm)).To(Succeed())
+
+		points := make(map[string][]metricdata.DataPoint[int64])
+		for _, sm := range rm.ScopeMetrics {
+			for _, m := range sm.Metrics {
+				switch data := m.Data.(type) {
+				case metricdata.Gauge[int64]:
+					points[m.Name] = data.DataPoints
+				case metricdata.Sum[int64]:
+					points[m.Name] = data.DataPoints
+				}
+			}
+		}
+		return points
+	}
+
+	BeforeEach(func() {
+		reader = sdkmetric.NewManualReader()
+		provider = sdkmetric.NewMeterProvider(sdkmetric.WithReader(reader))
+
+		var err error
+		visitors, err = hllplus.NewSafe(14, 19)
+		Expect(err).NotTo(HaveOccurred())
+		for i := 0; i < 3; i++ {
+			visitors.AddString(fmt.Sprintf("user-%d", i))
+		}
+	})
+
+	AfterEach(func() {
+		Expect(provider.Shutdown(ctx)).To(Succeed())
+	})
+
+	It("should register gauges", func() {
+		reg, err := hllotel.RegisterGauge(provider.Meter("test"), "unique_visitors", "Unique visitors.", visitors, attribute.String("site", "a"))
+		Expect(err).NotTo(HaveOccurred())
+
+		points := collect()["unique_visitors"]
+		Expect(points).To(HaveLen(1))
+		Expect(points[0].Value).To(Equal(int64(3)))
+		site, _ := points[0].Attributes.Value("site")
+		Expect(site.AsString()).To(Equal("a"))
+
+		// estimates are observed on collection
+		visitors.AddString("user-3")
+		Expect(collect()["unique_visitors"][0].Value).To(Equal(int64(4)))
+
+		Expect(reg.Unregister()).To(Succeed())
+		Expect(collect()).NotTo(HaveKey("unique_visitors"))
+	})
+
+	It("should register registries", func() {
+		pages, err := hllplus.NewGrouped(4, 14, 19)
+		Expect(err).NotTo(HaveOccurred())
+		for i := 0; i < 5; i++ {
+			pages.AddString("/home", fmt.Sprintf("user-%d", i))
+		}
+		pages.AddString("/about", "user-0")
+		pages.AddString("/contact", "user-0")
+
+		r := hllmetrics.NewRegistry()
+		Expect(r.Register("unique_visitors", "Unique visitors.", visitors)).To(Succeed())
+		Expect(r.RegisterGrouped("unique_page_visitors", "Unique visitors by page.", "page", pages, 2)).To(Succeed())
+
+		_, err = hllotel.RegisterRegistry(provider.Meter("test"), r)
+		Expect(err).NotTo(HaveOccurred())
+
+		points := collect()
+		Expect(points["unique_visitors"]).To(HaveLen(1))
+		Expect(points["unique_visitors"][0].Value).To(Equal(int64(3)))
+
+		byPage := make(map[string]int64)
+		for _, p := range points["unique_page_visitors"] {
+			page, _ := p.Attributes.Value("page")
+			byPage[page.AsString()] = p.Value
+		}
+		Expect(byPage).To(Equal(map[string]int64{"/home": 5, "/about": 1}))
+	})
+
+	It("should create instruments", func() {
+		inst, err := hllotel.NewInstruments(provider.Meter("test"), "visitors.")
+		Expect(err).NotTo(HaveOccurred())
+
+		s, err := hllmetrics.NewInstrumented(inst, []hllmetrics.Attribute{{Key: "site", Value: "a"}}, 14, 19)
+		Expect(err).NotTo(HaveOccurred())
+		s.AddStrings(ctx, []string{"a", "b", "c"})
+		Expect(s.Merge(ctx, visitors.Snapshot())).To(Succeed())
+		Expect(s.Estimate(ctx)).To(Equal(int64(6)))
+
+		points := collect()
+		Expect(points["visitors.adds"][0].Value).To(Equal(int64(3)))
+		Expect(points["visitors.merges"][0].Value).To(Equal(int64(1)))
+		Expect(points["visitors.estimate"][0].Value).To(Equal(int64(6)))
+		site, _ := points["visitors.adds"][0].Attributes.Value("site")
+		Expect(site.AsString()).To(Equal("a"))
+	})
+})
+
+func TestSuite(t *testing.T) {
+	RegisterFailHandler(Fail)
+	RunSpecs(t, "zetasketch/hllmetrics/hllotel")
+}
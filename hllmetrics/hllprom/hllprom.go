@@ -0,0 +1,73 @@
+// Package hllprom exposes the gauges of a hllmetrics.Registry through the Prometheus
+// client library. It is a separate module, so that the zetasketch module does not depend
+// on github.com/prometheus/client_golang.
+//
+//	r := hllmetrics.NewRegistry()
+//	err := r.Register("unique_visitors", "Unique visitors.", visitors)
+//	...
+//	prometheus.MustRegister(hllprom.NewCollector(r))
+package hllprom
+
+import (
+	"github.com/gowthamkommineni/zetasketch/hllmetrics"
+	"github.com/prometheus/client_golang/prometheus"
+)
+
+// Collector implements prometheus.Collector for the gauges of a registry.
+//
+// Describe sends the descriptors of the metrics registered at that time, which the
+// Prometheus registry checks for conflicts on registration. Metrics registered with the
+// hllmetrics.Registry later are collected too, but pedantic registries reject them as
+// undescribed; register all metrics before the collector in that case.
+type Collector struct {
+	r *hllmetrics.Registry
+}
+
+var _ prometheus.Collector = (*Collector)(nil)
+
+// NewCollector inits a new collector for r.
+func NewCollector(r *hllmetrics.Registry) *Collector {
+	return &Collector{r: r}
+}
+
+// Describe implements prometheus.Collector.
+func (c *Collector) Describe(ch chan<- *prometheus.Desc) {
+	for _, d := range c.r.Descriptions() {
+		ch <- newDesc(d)
+	}
+}
+
+// Collect implements prometheus.Collector.
+func (c *Collector) Collect(ch chan<- prometheus.Metric) {
+	descs := make(map[string]*prometheus.Desc)
+	for _, d := range c.r.Descriptions() {
+		descs[d.Name] = newDesc(d)
+	}
+
+	for _, s := range c.r.Gather() {
+		desc, ok := descs[s.Name]
+		if !ok {
+			// registered after the descriptions were taken
+			desc = newDesc(hllmetrics.Description{Name: s.Name, LabelName: s.LabelName})
+			descs[s.Name] = desc
+		}
+
+		var labelValues []string
+		if s.LabelName != "" {
+			labelValues = []string{s.LabelValue}
+		}
+		m, err := prometheus.NewConstMetric(desc, prometheus.GaugeValue, float64(s.Value), labelValues...)
+		if err != nil {
+			m = prometheus.NewInvalidMetric(desc, err)
+		}
+		ch <- m
+	}
+}
+
+func newDesc(d hllmetrics.Description) *prometheus.Desc {
+	var labels []string
+	if d.LabelName != "" {
+		labels = []string{d.LabelName}
+	}
+	return prometheus.NewDesc(d.Name, d.Help, labels, nil)
+}
@@ -0,0 +1,94 @@
+package hllprom_test
+
+import (
+	"fmt"
+	"strings"
+	"testing"
+
+	"github.com/gowthamkommineni/zetasketch/hllmetrics"
+	"github.com/gowthamkommineni/zetasketch/hllmetrics/hllprom"
+	"github.com/gowthamkommineni/zetasketch/hllplus"
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/testutil"
+
+	. "github.com/bsm/ginkgo"
+	. "github.com/bsm/gomega"
+)
+
+var _ = Describe("Collector", func() {
+	var subject *hllprom.Collector
+	var registry *hllmetrics.Registry
+	var visitors *hllplus.SafeHLL
+
+	BeforeEach(func() {
+		var err error
+		visitors, err = hllplus.NewSafe(14, 19)
+		Expect(err).NotTo(HaveOccurred())
+		pages, err := hllplus.NewGrouped(4, 14, 19)
+		Expect(err).NotTo(HaveOccurred())
+
+		for i := 0; i < 3; i++ {
+			visitors.AddString(fmt.Sprintf("user-%d", i))
+		}
+		for i := 0; i < 5; i++ {
+			pages.AddString("/home", fmt.Sprintf("user-%d", i))
+		}
+		for i := 0; i < 3; i++ {
+			pages.AddString("/about", fmt.Sprintf("user-%d", i))
+		}
+		pages.AddString("/contact", "user-0")
+
+		registry = hllmetrics.NewRegistry()
+		Expect(registry.Register("unique_visitors", "Unique visitors.", visitors)).To(Succeed())
+		Expect(registry.RegisterGrouped("unique_page_visitors", "Unique visitors by page.", "page", pages, 2)).To(Succeed())
+		subject = hllprom.NewCollector(registry)
+	})
+
+	It("should describe", func() {
+		ch := make(chan *prometheus.Desc, 10)
+		subject.Describe(ch)
+		close(ch)
+
+		var descs []string
+		for d := range ch {
+			descs = append(descs, d.String())
+		}
+		Expect(descs).To(Equal([]string{
+			`Desc{fqName: "unique_page_visitors", help: "Unique visitors by page.", constLabels: {}, variableLabels: {page}}`,
+			`Desc{fqName: "unique_visitors", help: "Unique visitors.", constLabels: {}, variableLabels: {}}`,
+		}))
+	})
+
+	It("should collect", func() {
+		Expect(testutil.CollectAndCompare(subject, strings.NewReader(`
+# HELP unique_page_visitors Unique visitors by page.
+# TYPE unique_page_visitors gauge
+unique_page_visitors{page="/about"} 3
+unique_page_visitors{page="/home"} 5
+# HELP unique_visitors Unique visitors.
+# TYPE unique_visitors gauge
+unique_visitors 3
+`))).To(Succeed())
+
+		// metrics registered later are collected too
+		Expect(registry.Register("late_visitors", "", visitors)).To(Succeed())
+		Expect(testutil.CollectAndCount(subject)).To(Equal(4))
+	})
+
+	It("should register with prometheus", func() {
+		reg := prometheus.NewPedanticRegistry()
+		Expect(reg.Register(subject)).To(Succeed())
+		Expect(reg.Register(hllprom.NewCollector(registry))).To(HaveOccurred())
+
+		families, err := reg.Gather()
+		Expect(err).NotTo(HaveOccurred())
+		Expect(families).To(HaveLen(2))
+		Expect(families[1].GetName()).To(Equal("unique_visitors"))
+		Expect(families[1].GetMetric()[0].GetGauge().GetValue()).To(Equal(3.0))
+	})
+})
+
+func TestSuite(t *testing.T) {
+	RegisterFailHandler(Fail)
+	RunSpecs(t, "zetasketch/hllmetrics/hllprom")
+}
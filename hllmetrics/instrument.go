@@ -0,0 +1,190 @@
+package hllmetrics
+
+import (
+	"context"
+	"sync"
+
+	"github.com/gowthamkommineni/zetasketch/hllplus"
+)
+
+// Attribute is a key/value pair attached to recorded measurements.
+type Attribute struct {
+	Key, Value string
+}
+
+// Int64Counter records increments, e.g. an OpenTelemetry Int64Counter.
+type Int64Counter interface {
+	Add(ctx context.Context, incr int64, attrs ...Attribute)
+}
+
+// Int64Recorder records current values, e.g. an OpenTelemetry Int64Gauge or
+// Int64Histogram.
+type Int64Recorder interface {
+	Record(ctx context.Context, value int64, attrs ...Attribute)
+}
+
+// Instruments are the instruments sketch behavior is recorded with. Nil instruments are
+// skipped. This package does not depend on OpenTelemetry; the hllmetrics/hllotel module
+// creates Instruments backed by OpenTelemetry instruments, see hllotel.NewInstruments.
+//
+// Measurements are recorded with the context passed to the sketch, so they can be
+// correlated with the active trace.
+type Instruments struct {
+	// Adds counts added values.
+	Adds Int64Counter
+	// Merges counts merged sketches.
+	Merges Int64Counter
+	// Normalizations counts conversions from the sparse to the normal representation.
+	Normalizations Int64Counter
+	// Estimate records estimates as they are computed.
+	Estimate Int64Recorder
+}
+
+// InstrumentedHLL wraps a sketch for concurrent use, like hllplus.SafeHLL, and records
+// its behavior with the given instruments.
+type InstrumentedHLL struct {
+	inst  Instruments
+	attrs []Attribute
+
+	mu         sync.Mutex
+	s          *hllplus.HLL
+	normalized int64
+}
+
+// NewInstrumented inits a new, instrumented sketch. See hllplus.New for details. All
+// measurements are recorded with attrs, e.g. to identify the sketch.
+func NewInstrumented(inst Instruments, attrs []Attribute, precision, sparsePrecision uint8, opts ...hllplus.Option) (*InstrumentedHLL, error) {
+	s := &InstrumentedHLL{inst: inst, attrs: attrs}
+	opts = append(opts[:len(opts):len(opts)], hllplus.WithNormalizeHook(func() { s.normalized++ }))
+
+	hll, err := hllplus.New(precision, sparsePrecision, opts...)
+	if err != nil {
+		return nil, err
+	}
+	s.s = hll
+	return s, nil
+}
+
+// AddString hashes and adds a string value, see hllplus.HLL.AddString.
+func (s *InstrumentedHLL) AddString(ctx context.Context, v string) {
+	s.mu.Lock()
+	s.s.AddString(v)
+	normalized := s.takeNormalized()
+	s.mu.Unlock()
+	s.recordAdds(ctx, 1, normalized)
+}
+
+// AddStrings hashes and adds string values, see hllplus.HLL.AddStrings.
+func (s *InstrumentedHLL) AddStrings(ctx context.Context, vs []string) {
+	s.mu.Lock()
+	s.s.AddStrings(vs)
+	normalized := s.takeNormalized()
+	s.mu.Unlock()
+	s.recordAdds(ctx, int64(len(vs)), normalized)
+}
+
+// AddBytes hashes and adds a byte value, see hllplus.HLL.AddBytes.
+func (s *InstrumentedHLL) AddBytes(ctx context.Context, v []byte) {
+	s.mu.Lock()
+	s.s.AddBytes(v)
+	normalized := s.takeNormalized()
+	s.mu.Unlock()
+	s.recordAdds(ctx, 1, normalized)
+}
+
+// AddInt64 hashes and adds a signed number, see hllplus.HLL.AddInt64.
+func (s *InstrumentedHLL) AddInt64(ctx context.Context, v int64) {
+	s.mu.Lock()
+	s.s.AddInt64(v)
+	normalized := s.takeNormalized()
+	s.mu.Unlock()
+	s.recordAdds(ctx, 1, normalized)
+}
+
+// AddUint64 hashes and adds an unsigned number, see hllplus.HLL.AddUint64.
+func (s *InstrumentedHLL) AddUint64(ctx context.Context, v uint64) {
+	s.mu.Lock()
+	s.s.AddUint64(v)
+	normalized := s.takeNormalized()
+	s.mu.Unlock()
+	s.recordAdds(ctx, 1, normalized)
+}
+
+// AddFloat64 hashes and adds a float, see hllplus.HLL.AddFloat64.
+func (s *InstrumentedHLL) AddFloat64(ctx context.Context, v float64) {
+	s.mu.Lock()
+	s.s.AddFloat64(v)
+	normalized := s.takeNormalized()
+	s.mu.Unlock()
+	s.recordAdds(ctx, 1, normalized)
+}
+
+// Merge merges other into the sketch. Failed merges are not counted.
+func (s *InstrumentedHLL) Merge(ctx context.Context, other *hllplus.HLL) error {
+	s.mu.Lock()
+	err := s.s.Merge(other)
+	normalized := s.takeNormalized()
+	s.mu.Unlock()
+
+	s.recordNormalized(ctx, normalized)
+	if err != nil {
+		return err
+	}
+	if s.inst.Merges != nil {
+		s.inst.Merges.Add(ctx, 1, s.attrs...)
+	}
+	return nil
+}
+
+// Estimate computes and records the cardinality estimate, see hllplus.HLL.Estimate.
+func (s *InstrumentedHLL) Estimate(ctx context.Context) int64 {
+	s.mu.Lock()
+	est := s.s.Estimate()
+	normalized := s.takeNormalized()
+	s.mu.Unlock()
+
+	s.recordNormalized(ctx, normalized)
+	if s.inst.Estimate != nil {
+		s.inst.Estimate.Record(ctx, est, s.attrs...)
+	}
+	return est
+}
+
+// MemoryUsage returns the number of bytes held by the sketch, see hllplus.HLL.MemoryUsage.
+func (s *InstrumentedHLL) MemoryUsage() int {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	return s.s.MemoryUsage()
+}
+
+// Snapshot returns a copy of the current state, which is not instrumented.
+func (s *InstrumentedHLL) Snapshot() *hllplus.HLL {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	return s.s.Snapshot()
+}
+
+func (s *InstrumentedHLL) recordAdds(ctx context.Context, n, normalized int64) {
+	if s.inst.Adds != nil {
+		s.inst.Adds.Add(ctx, n, s.attrs...)
+	}
+	s.recordNormalized(ctx, normalized)
+}
+
+func (s *InstrumentedHLL) recordNormalized(ctx context.Context, n int64) {
+	if n != 0 && s.inst.Normalizations != nil {
+		s.inst.Normalizations.Add(ctx, n, s.attrs...)
+	}
+}
+
+// takeNormalized returns and resets the number of normalizations since the last call.
+// Sparse sketches normalize while flushing buffered values, so normalizations are
+// recorded with the operation which flushed, e.g. Estimate. It must be called with the
+// lock held.
+func (s *InstrumentedHLL) takeNormalized() int64 {
+	n := s.normalized
+	s.normalized = 0
+	return n
+}
@@ -0,0 +1,112 @@
+package hllmetrics_test
+
+import (
+	"context"
+	"fmt"
+	"sync"
+
+	"github.com/gowthamkommineni/zetasketch/hllmetrics"
+	"github.com/gowthamkommineni/zetasketch/hllplus"
+
+	. "github.com/bsm/ginkgo"
+	. "github.com/bsm/gomega"
+)
+
+type ctxKey struct{}
+
+type mockInstrument struct {
+	mu     sync.Mutex
+	total  int64
+	last   int64
+	attrs  []hllmetrics.Attribute
+	traces []interface{}
+}
+
+func (m *mockInstrument) Add(ctx context.Context, incr int64, attrs ...hllmetrics.Attribute) {
+	m.Record(ctx, incr, attrs...)
+}
+
+func (m *mockInstrument) Record(ctx context.Context, value int64, attrs ...hllmetrics.Attribute) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	m.total += value
+	m.last = value
+	m.attrs = attrs
+	m.traces = append(m.traces, ctx.Value(ctxKey{}))
+}
+
+var _ = Describe("InstrumentedHLL", func() {
+	var subject *hllmetrics.InstrumentedHLL
+	var adds, merges, normalizations, estimate *mockInstrument
+	var ctx context.Context
+	attrs := []hllmetrics.Attribute{{Key: "sketch", Value: "visitors"}}
+
+	BeforeEach(func() {
+		adds, merges, normalizations, estimate = new(mockInstrument), new(mockInstrument), new(mockInstrument), new(mockInstrument)
+		ctx = context.WithValue(context.Background(), ctxKey{}, "trace-1")
+
+		var err error
+		subject, err = hllmetrics.NewInstrumented(hllmetrics.Instruments{
+			Adds:           adds,
+			Merges:         merges,
+			Normalizations: normalizations,
+			Estimate:       estimate,
+		}, attrs, 10, 12)
+		Expect(err).NotTo(HaveOccurred())
+	})
+
+	It("should validate", func() {
+		_, err := hllmetrics.NewInstrumented(hllmetrics.Instruments{}, nil, 30, 30)
+		Expect(err).To(MatchError("invalid normal precision 30"))
+	})
+
+	It("should record adds and estimates", func() {
+		subject.AddString(ctx, "a")
+		subject.AddStrings(ctx, []string{"b", "c"})
+		Expect(adds.total).To(Equal(int64(3)))
+		Expect(adds.attrs).To(Equal(attrs))
+		Expect(adds.traces).To(ConsistOf("trace-1", "trace-1"))
+
+		Expect(subject.Estimate(ctx)).To(Equal(int64(3)))
+		Expect(estimate.last).To(Equal(int64(3)))
+		Expect(estimate.attrs).To(Equal(attrs))
+		Expect(normalizations.total).To(BeZero())
+	})
+
+	It("should record normalizations", func() {
+		for i := 0; i < 10_000; i++ {
+			subject.AddInt64(ctx, int64(i))
+		}
+		subject.Estimate(ctx)
+		Expect(adds.total).To(Equal(int64(10_000)))
+		Expect(normalizations.total).To(Equal(int64(1)))
+		Expect(normalizations.attrs).To(Equal(attrs))
+		Expect(normalizations.traces).To(ConsistOf("trace-1"))
+	})
+
+	It("should record merges", func() {
+		other, _ := hllplus.New(10, 12)
+		for i := 0; i < 10_000; i++ {
+			other.AddString(fmt.Sprint(i))
+		}
+		Expect(subject.Merge(ctx, other)).To(Succeed())
+		Expect(merges.total).To(Equal(int64(1)))
+		Expect(normalizations.total).To(Equal(int64(1)))
+
+		incompatible, _ := hllplus.New(10, 12)
+		incompatible.AddInt64(1)
+		Expect(subject.Merge(ctx, incompatible)).To(MatchError("cannot merge sketches with different value types BYTES_OR_UTF8_STRING and INT64"))
+		Expect(merges.total).To(Equal(int64(1)))
+	})
+
+	It("should skip nil instruments", func() {
+		plain, err := hllmetrics.NewInstrumented(hllmetrics.Instruments{}, nil, 10, 12)
+		Expect(err).NotTo(HaveOccurred())
+		for i := 0; i < 10_000; i++ {
+			plain.AddUint64(ctx, uint64(i))
+		}
+		Expect(plain.Estimate(ctx)).To(BeNumerically("~", 10_000, 500))
+		Expect(plain.MemoryUsage()).To(BeNumerically(">", 0))
+	})
+})
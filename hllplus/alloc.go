@@ -0,0 +1,86 @@
+package hllplus
+
+import "fmt"
+
+// Allocator provides the memory of sketches, e.g. from an arena or an off-heap region
+// managed by the application.
+type Allocator interface {
+	// Alloc returns a slice of n zeroed bytes.
+	Alloc(n int) []byte
+	// Free releases a slice returned by Alloc, with the same length. The sketch never
+	// accesses it afterwards.
+	Free(b []byte)
+}
+
+// WithAllocator allocates normal registers and sparse data from alloc, which must be
+// safe for concurrent use if the sketch or its clones are used concurrently. Memory is
+// freed as soon as the sketch stops using it, e.g. when it is downgraded or normalized,
+// and deterministically by HLL.Release. Clones and snapshots inherit the allocator, but
+// normal registers shared between a sketch and its snapshot are never freed.
+//
+// Sketches restored from serialized data copy sparse data into memory from alloc. The
+// buffer of values which are not yet merged into sparse data is small, bounded by the
+// precision and always allocated by the runtime.
+func WithAllocator(alloc Allocator) Option {
+	return func(s *HLL) error {
+		if alloc == nil {
+			return fmt.Errorf("invalid allocator")
+		}
+		s.alloc = alloc
+		if s.sparse != nil {
+			s.sparse.setAllocator(alloc)
+		}
+		return nil
+	}
+}
+
+// Release returns the registers and sparse data of the sketch for reuse. Memory is only
+// recycled if the sketch was created WithRegisterPool or WithAllocator. The sketch must
+// not be used after it has been released.
+func (s *HLL) Release() {
+	if s.sparse != nil {
+		s.sparse.Release()
+		s.sparse = nil
+	}
+	s.replaceNormal(nil, false)
+}
+
+// allocNormal allocates normal registers for the given precision.
+func (s *HLL) allocNormal(precision uint8) []byte {
+	n := 1 << precision
+	if s.packed {
+		n = packedSize(precision)
+	}
+
+	if s.alloc != nil {
+		return s.alloc.Alloc(n)
+	}
+	return make([]byte, n)
+}
+
+// replaceNormal replaces the normal registers, recycling the current ones.
+func (s *HLL) replaceNormal(normal []byte, pooled bool) {
+	if !s.shared {
+		s.releaseNormal(s.normal, s.pooled)
+	}
+	s.normal, s.pooled, s.shared = normal, pooled, false
+}
+
+// replaceSparse replaces the sparse state, releasing the current one. Restored states
+// are moved into memory from the allocator.
+func (s *HLL) replaceSparse(sparse *sparseState) {
+	if s.sparse != nil {
+		s.sparse.Release()
+	}
+	if sparse != nil && s.alloc != nil {
+		sparse.setAllocator(s.alloc)
+	}
+	s.sparse = sparse
+}
+
+// releaseNormal frees normal registers, if they were obtained from the allocator.
+func (s *HLL) releaseNormal(normal []byte, pooled bool) {
+	if pooled && len(normal) != 0 {
+		s.alloc.Free(normal)
+	}
+}
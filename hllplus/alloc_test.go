@@ -0,0 +1,123 @@
+package hllplus_test
+
+import (
+	"math/rand"
+
+	"github.com/gowthamkommineni/zetasketch/hllplus"
+
+	. "github.com/bsm/ginkgo"
+	. "github.com/bsm/gomega"
+)
+
+var _ = Describe("Allocator", func() {
+	var alloc *trackingAllocator
+	var rnd *rand.Rand
+
+	BeforeEach(func() {
+		alloc = &trackingAllocator{live: make(map[*byte]int)}
+		rnd = rand.New(rand.NewSource(13))
+	})
+
+	It("should allocate sparse and normal representations", func() {
+		subject, err := hllplus.New(12, 17, hllplus.WithAllocator(alloc))
+		Expect(err).NotTo(HaveOccurred())
+		expected, _ := hllplus.New(12, 17)
+
+		for i := 0; i < 5_000; i++ {
+			h := rnd.Uint64()
+			subject.Add(h)
+			expected.Add(h)
+			if i == 500 {
+				Expect(subject.IsSparse()).To(BeTrue())
+				Expect(alloc.Live()).To(BeNumerically(">", 0))
+				Expect(subject.Proto()).To(Equal(expected.Proto()))
+			}
+		}
+		Expect(subject.IsSparse()).To(BeFalse())
+		Expect(alloc.Live()).To(BeNumerically(">=", 1<<12))
+		Expect(subject.Proto()).To(Equal(expected.Proto()))
+		Expect(subject.Estimate()).To(Equal(expected.Estimate()))
+
+		subject.Release()
+		Expect(alloc.Live()).To(Equal(0))
+	})
+
+	It("should free on downgrade", func() {
+		subject, _ := hllplus.New(14, 19, hllplus.WithAllocator(alloc))
+		for i := 0; i < 500; i++ {
+			subject.Add(rnd.Uint64())
+		}
+		expected := subject.Clone()
+		Expect(subject.Downgrade(12, 17)).To(Succeed())
+		Expect(expected.Downgrade(12, 17)).To(Succeed())
+		Expect(subject.Proto()).To(Equal(expected.Proto()))
+
+		expected.Release()
+		subject.Release()
+		Expect(alloc.Live()).To(Equal(0))
+	})
+
+	It("should allocate clones and restored sketches", func() {
+		subject, _ := hllplus.New(12, 17, hllplus.WithAllocator(alloc))
+		for i := 0; i < 500; i++ {
+			subject.Add(rnd.Uint64())
+		}
+		data, err := subject.Marshal()
+		Expect(err).NotTo(HaveOccurred())
+
+		clone := subject.Clone()
+		subject.Release()
+		Expect(alloc.Live()).To(BeNumerically(">", 0))
+
+		restored, err := hllplus.NewFromProto(clone.Proto(), hllplus.WithAllocator(alloc))
+		Expect(err).NotTo(HaveOccurred())
+		Expect(restored.Estimate()).To(Equal(clone.Estimate()))
+
+		unmarshaled, _ := hllplus.New(12, 17, hllplus.WithAllocator(alloc))
+		Expect(unmarshaled.Unmarshal(data)).To(Succeed())
+		Expect(unmarshaled.Estimate()).To(Equal(clone.Estimate()))
+
+		clone.Release()
+		restored.Release()
+		unmarshaled.Release()
+		Expect(alloc.Live()).To(Equal(0))
+	})
+
+	It("should reject nil allocators", func() {
+		_, err := hllplus.New(12, 17, hllplus.WithAllocator(nil))
+		Expect(err).To(MatchError("invalid allocator"))
+	})
+})
+
+// trackingAllocator tracks live allocations and poisons freed memory.
+type trackingAllocator struct {
+	live map[*byte]int
+}
+
+func (a *trackingAllocator) Alloc(n int) []byte {
+	b := make([]byte, n)
+	if n != 0 {
+		a.live[&b[0]] = n
+	}
+	return b
+}
+
+func (a *trackingAllocator) Free(b []byte) {
+	Expect(b).NotTo(BeEmpty())
+	n, ok := a.live[&b[0]]
+	Expect(ok).To(BeTrue(), "freed memory which is not live")
+	Expect(len(b)).To(Equal(n))
+	delete(a.live, &b[0])
+	for i := range b {
+		b[i] = 0xff
+	}
+}
+
+// Live returns the number of bytes allocated but not freed.
+func (a *trackingAllocator) Live() int {
+	n := 0
+	for _, size := range a.live {
+		n += size
+	}
+	return n
+}
@@ -0,0 +1,162 @@
+package hllplus
+
+import (
+	"fmt"
+	"sync/atomic"
+)
+
+// AtomicHLL is a lock-free, concurrency-safe sketch in normal representation. Registers
+// are packed into 32-bit words and updated via compare-and-swap, so concurrent writers
+// never block each other. It always allocates 2^precision bytes, so it is best suited for
+// sketches with high cardinalities.
+//
+// Reads take a snapshot of the registers while writers may continue. Since registers
+// only ever increase, a snapshot reflects all values added before the read started and
+// possibly some added concurrently.
+type AtomicHLL struct {
+	tmpl      *HLL // empty sketch, carries the configuration
+	words     []uint32
+	numValues int64
+	valueType int32
+}
+
+// NewAtomic inits a new lock-free sketch. See New for details. WithExemplars is not
+// supported, since sampling values would require a lock.
+func NewAtomic(precision, sparsePrecision uint8, opts ...Option) (*AtomicHLL, error) {
+	tmpl, err := New(precision, sparsePrecision, opts...)
+	if err != nil {
+		return nil, err
+	}
+	if tmpl.exemplars != nil {
+		return nil, fmt.Errorf("exemplars are not supported by AtomicHLL")
+	}
+
+	return &AtomicHLL{
+		tmpl:      tmpl,
+		words:     make([]uint32, (1<<precision+3)/4),
+		valueType: int32(tmpl.valueType),
+	}, nil
+}
+
+// Precision returns the normal precision.
+func (s *AtomicHLL) Precision() uint8 {
+	return s.tmpl.precision
+}
+
+// NumValues returns the number of values seen.
+func (s *AtomicHLL) NumValues() int64 {
+	return atomic.LoadInt64(&s.numValues)
+}
+
+// MemoryUsage returns the number of bytes held by the registers.
+func (s *AtomicHLL) MemoryUsage() int {
+	return cap(s.words) * 4
+}
+
+// Add adds the uniform hash value to the sketch.
+func (s *AtomicHLL) Add(hash uint64) {
+	atomic.AddInt64(&s.numValues, 1)
+
+	pos, rhoW := computePosRhoW(hash, s.tmpl.precision)
+	s.update(pos, rhoW)
+}
+
+// AddString hashes and adds a string value, see HLL.AddString.
+func (s *AtomicHLL) AddString(v string) {
+	s.setValueType(ValueTypeBytes)
+	s.Add(s.tmpl.hashString(v))
+}
+
+// AddBytes hashes and adds a byte value, see HLL.AddBytes.
+func (s *AtomicHLL) AddBytes(v []byte) {
+	s.setValueType(ValueTypeBytes)
+	s.Add(s.tmpl.hashBytes(v))
+}
+
+// AddInt64 hashes and adds a signed number, see HLL.AddInt64.
+func (s *AtomicHLL) AddInt64(v int64) {
+	s.setValueType(ValueTypeInt64)
+	s.Add(s.tmpl.hashUint64(uint64(v)))
+}
+
+// AddUint64 hashes and adds an unsigned number, see HLL.AddUint64.
+func (s *AtomicHLL) AddUint64(v uint64) {
+	s.setValueType(ValueTypeUint64)
+	s.Add(s.tmpl.hashUint64(v))
+}
+
+// AddFloat64 hashes and adds a floating point number, see HLL.AddFloat64.
+func (s *AtomicHLL) AddFloat64(v float64) {
+	s.setValueType(ValueTypeDouble)
+	s.Add(s.tmpl.hashUint64(float64Bits(v)))
+}
+
+// Merge merges other into the sketch. Other must not have a lower precision.
+func (s *AtomicHLL) Merge(other *HLL) error {
+	if err := s.tmpl.checkHasher(other); err != nil {
+		return err
+	}
+	known := ValueType(atomic.LoadInt32(&s.valueType))
+	if known != other.valueType && known != ValueTypeUnknown && other.valueType != ValueTypeUnknown {
+		return fmt.Errorf("cannot merge sketches with different value types %s and %s", known, other.valueType)
+	}
+	if other.precision < s.tmpl.precision {
+		return fmt.Errorf("cannot merge sketch with lower precision %d into %d", other.precision, s.tmpl.precision)
+	}
+
+	if other.valueType != ValueTypeUnknown {
+		s.setValueType(other.valueType)
+	}
+	atomic.AddInt64(&s.numValues, other.numValues)
+
+	if other.sparse == nil && len(other.normal) == 0 {
+		return nil
+	}
+	other.downgradeEach(s.tmpl.precision, s.update)
+	return nil
+}
+
+// Snapshot copies the current registers into a new sketch.
+func (s *AtomicHLL) Snapshot() *HLL {
+	res := s.tmpl.Clone()
+	res.sparse = nil
+	normal := make([]byte, 1<<res.precision)
+	for i := range normal {
+		normal[i] = byte(atomic.LoadUint32(&s.words[i/4]) >> (i % 4 * 8))
+	}
+	res.setUnpackedNormal(normal)
+	res.numValues = s.NumValues()
+	res.valueType = ValueType(atomic.LoadInt32(&s.valueType))
+	return res
+}
+
+// Estimate computes the cardinality estimate of a snapshot.
+func (s *AtomicHLL) Estimate() int64 {
+	return s.Snapshot().Estimate()
+}
+
+// Marshal serializes a snapshot, see HLL.Marshal.
+func (s *AtomicHLL) Marshal() ([]byte, error) {
+	return s.Snapshot().Marshal()
+}
+
+// update raises the register at pos to rhoW, unless it is already larger.
+func (s *AtomicHLL) update(pos uint32, rhoW uint8) {
+	addr := &s.words[pos/4]
+	shift := pos % 4 * 8
+	for {
+		old := atomic.LoadUint32(addr)
+		if uint8(old>>shift) >= rhoW {
+			return
+		}
+		if atomic.CompareAndSwapUint32(addr, old, old&^(0xff<<shift)|uint32(rhoW)<<shift) {
+			return
+		}
+	}
+}
+
+func (s *AtomicHLL) setValueType(t ValueType) {
+	if atomic.LoadInt32(&s.valueType) == int32(ValueTypeUnknown) {
+		atomic.CompareAndSwapInt32(&s.valueType, int32(ValueTypeUnknown), int32(t))
+	}
+}
@@ -0,0 +1,93 @@
+package hllplus_test
+
+import (
+	"math/rand"
+	"sync"
+	"testing"
+
+	"github.com/gowthamkommineni/zetasketch/hllplus"
+
+	. "github.com/bsm/ginkgo"
+	. "github.com/bsm/gomega"
+)
+
+var _ = Describe("AtomicHLL", func() {
+	It("should match a single sketch", func() {
+		subject, err := hllplus.NewAtomic(14, 19)
+		Expect(err).NotTo(HaveOccurred())
+		Expect(subject.Precision()).To(Equal(uint8(14)))
+		Expect(subject.MemoryUsage()).To(Equal(16_384))
+
+		expected, _ := hllplus.New(14, 19)
+		hashes := make([]uint64, 200_000)
+		rnd := rand.New(rand.NewSource(9))
+		for i := range hashes {
+			hashes[i] = rnd.Uint64()
+			expected.Add(hashes[i])
+		}
+
+		var wg sync.WaitGroup
+		for w := 0; w < 8; w++ {
+			wg.Add(1)
+			go func(w int) {
+				defer wg.Done()
+				for i := w; i < len(hashes); i += 8 {
+					subject.Add(hashes[i])
+					if i%10_000 == 0 {
+						_ = subject.Estimate()
+					}
+				}
+			}(w)
+		}
+		wg.Wait()
+
+		Expect(subject.NumValues()).To(Equal(int64(200_000)))
+		Expect(subject.Estimate()).To(Equal(expected.Estimate()))
+		Expect(subject.Snapshot().Registers()).To(Equal(expected.Registers()))
+	})
+
+	It("should track value types and merge", func() {
+		subject, _ := hllplus.NewAtomic(12, 17)
+		subject.AddString("foo")
+		subject.AddString("bar")
+		Expect(subject.Snapshot().ValueType()).To(Equal(hllplus.ValueTypeBytes))
+		Expect(subject.Estimate()).To(Equal(int64(2)))
+
+		sparse, _ := hllplus.New(14, 19)
+		sparse.AddString("baz")
+		Expect(subject.Merge(sparse)).To(Succeed())
+		Expect(subject.Estimate()).To(Equal(int64(3)))
+		Expect(subject.NumValues()).To(Equal(int64(3)))
+
+		normal, _ := hllplus.NewNormal(12)
+		normal.AddString("qux")
+		Expect(subject.Merge(normal)).To(Succeed())
+		Expect(subject.Estimate()).To(Equal(int64(4)))
+
+		lower, _ := hllplus.New(11, 16)
+		Expect(subject.Merge(lower)).To(MatchError("cannot merge sketch with lower precision 11 into 12"))
+
+		ints, _ := hllplus.New(12, 17)
+		ints.AddInt64(1)
+		Expect(subject.Merge(ints)).To(MatchError("cannot merge sketches with different value types BYTES_OR_UTF8_STRING and INT64"))
+
+		seeded, _ := hllplus.New(12, 17, hllplus.WithSeed(1))
+		Expect(subject.Merge(seeded)).To(MatchError("cannot merge sketches with different seeds 0 and 1"))
+
+		data, err := subject.Marshal()
+		Expect(err).NotTo(HaveOccurred())
+		restored := new(hllplus.HLL)
+		Expect(restored.Unmarshal(data)).To(Succeed())
+		Expect(restored.Estimate()).To(Equal(int64(4)))
+	})
+})
+
+func BenchmarkAtomicHLL_Add(b *testing.B) {
+	subject, _ := hllplus.NewAtomic(15, 20)
+	b.RunParallel(func(pb *testing.PB) {
+		rnd := rand.New(rand.NewSource(rand.Int63()))
+		for pb.Next() {
+			subject.Add(rnd.Uint64())
+		}
+	})
+}
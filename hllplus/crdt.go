@@ -0,0 +1,128 @@
+package hllplus
+
+import (
+	"fmt"
+
+	"github.com/gowthamkommineni/zetasketch/internal/hash"
+	"google.golang.org/protobuf/encoding/protowire"
+)
+
+// CRDT wraps a sketch as a state-based conflict-free replicated data type. Its state is
+// the set of normal registers, which forms a join-semilattice: Join keeps the larger
+// value of each register, so it is commutative, associative and idempotent, and replicas
+// which have seen the same updates converge to the same state, regardless of the order
+// in which they exchange and join their states, or how often.
+//
+// To guarantee convergence, the API enforces what HLL.Merge does not:
+//   - sketches are always in normal representation, since sparse sketches estimate
+//     differently from normal ones with the same registers;
+//   - only replicas of the same precision can be joined, since Merge would downgrade;
+//   - the number of values is not part of the state, since summing it is not idempotent;
+//   - estimates are computed from the registers, so the martingale estimator, which
+//     depends on the order of updates, is rejected.
+type CRDT struct {
+	s *HLL
+}
+
+// NewCRDT inits a new replica. See New for the precision and options.
+func NewCRDT(precision uint8, opts ...Option) (*CRDT, error) {
+	sparsePrecision := precision + 5
+	if sparsePrecision > MaxSparsePrecision {
+		sparsePrecision = MaxSparsePrecision
+	}
+
+	s, err := New(precision, sparsePrecision, opts...)
+	if err != nil {
+		return nil, err
+	}
+	return NewCRDTFrom(s)
+}
+
+// NewCRDTFrom inits a replica from a copy of s, e.g. a state received from another
+// replica and restored by HLL.Unmarshal.
+func NewCRDTFrom(s *HLL) (*CRDT, error) {
+	if s.martingale != nil {
+		return nil, fmt.Errorf("cannot replicate sketches with the martingale estimator")
+	}
+
+	s = s.Clone()
+	s.normalize()
+	s.ensureNormal()
+	return &CRDT{s: s}, nil
+}
+
+// Precision returns the normal precision.
+func (c *CRDT) Precision() uint8 {
+	return c.s.precision
+}
+
+// Add adds the uniform hash value to the replica.
+func (c *CRDT) Add(hash uint64) {
+	c.s.Add(hash)
+}
+
+// AddString hashes and adds a string value, see HLL.AddString.
+func (c *CRDT) AddString(v string) {
+	c.s.AddString(v)
+}
+
+// AddBytes hashes and adds a byte value, see HLL.AddBytes.
+func (c *CRDT) AddBytes(v []byte) {
+	c.s.AddBytes(v)
+}
+
+// AddInt64 hashes and adds a signed number, see HLL.AddInt64.
+func (c *CRDT) AddInt64(v int64) {
+	c.s.AddInt64(v)
+}
+
+// AddUint64 hashes and adds an unsigned number, see HLL.AddUint64.
+func (c *CRDT) AddUint64(v uint64) {
+	c.s.AddUint64(v)
+}
+
+// AddFloat64 hashes and adds a floating point number, see HLL.AddFloat64.
+func (c *CRDT) AddFloat64(v float64) {
+	c.s.AddFloat64(v)
+}
+
+// Join merges the state of other into the replica. It returns an error if the replicas
+// have different precisions, hashers, seeds or value types.
+func (c *CRDT) Join(other *CRDT) error {
+	if c.s.precision != other.s.precision {
+		return fmt.Errorf("cannot join replicas with different precisions %d and %d", c.s.precision, other.s.precision)
+	}
+
+	numValues := c.s.numValues
+	if err := c.s.Merge(other.s); err != nil {
+		return err
+	}
+	c.s.numValues = numValues
+	return nil
+}
+
+// Estimate computes the cardinality estimate from the registers. Replicas with equal
+// states return equal estimates.
+func (c *CRDT) Estimate() int64 {
+	return c.s.estimateNormal(c.s.estimator).Estimate
+}
+
+// Digest returns a hash of the state, for anti-entropy protocols: replicas with equal
+// digests have converged with overwhelming probability, so states only need to be
+// exchanged when digests differ. It covers the registers, the precision, the hasher,
+// the seed and the value type.
+func (c *CRDT) Digest() uint64 {
+	id := c.s.Hasher().ID()
+	buf := make([]byte, 0, 32+len(id)+1<<c.s.precision)
+	buf = append(buf, c.s.precision)
+	buf = protowire.AppendVarint(buf, uint64(c.s.valueType))
+	buf = protowire.AppendVarint(buf, c.s.seed)
+	buf = protowire.AppendString(buf, id)
+	buf = append(buf, c.s.Registers()...)
+	return hash.Bytes(buf)
+}
+
+// State returns a copy of the sketch, e.g. to serialize and ship it to other replicas.
+func (c *CRDT) State() *HLL {
+	return c.s.Clone()
+}
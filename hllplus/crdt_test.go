@@ -0,0 +1,123 @@
+package hllplus_test
+
+import (
+	"math/rand"
+
+	"github.com/gowthamkommineni/zetasketch/hllplus"
+
+	. "github.com/bsm/ginkgo"
+	. "github.com/bsm/gomega"
+)
+
+var _ = Describe("CRDT", func() {
+	var replicas []*hllplus.CRDT
+	var rnd *rand.Rand
+
+	BeforeEach(func() {
+		rnd = rand.New(rand.NewSource(11))
+
+		// each replica sees its own values, some of which overlap
+		replicas = make([]*hllplus.CRDT, 6)
+		for i := range replicas {
+			replicas[i], _ = hllplus.NewCRDT(12)
+			for j := 0; j < 1_000; j++ {
+				replicas[i].AddInt64(rnd.Int63n(20_000))
+			}
+		}
+	})
+
+	It("should validate", func() {
+		_, err := hllplus.NewCRDT(30)
+		Expect(err).To(MatchError("invalid normal precision 30"))
+
+		_, err = hllplus.NewCRDT(12, hllplus.WithMartingale())
+		Expect(err).To(MatchError("cannot replicate sketches with the martingale estimator"))
+
+		Expect(replicas[0].Precision()).To(Equal(uint8(12)))
+	})
+
+	It("should be commutative, associative and idempotent", func() {
+		a, b, c := replicas[0], replicas[1], replicas[2]
+		join := func(x, y *hllplus.CRDT) *hllplus.CRDT {
+			res, _ := hllplus.NewCRDTFrom(x.State())
+			Expect(res.Join(y)).To(Succeed())
+			return res
+		}
+
+		Expect(join(a, b).Digest()).To(Equal(join(b, a).Digest()))
+		Expect(join(join(a, b), c).Digest()).To(Equal(join(a, join(b, c)).Digest()))
+		Expect(join(a, a).Digest()).To(Equal(a.Digest()))
+		Expect(join(join(a, b), b).Digest()).To(Equal(join(a, b).Digest()))
+		Expect(a.Digest()).NotTo(Equal(b.Digest()))
+	})
+
+	It("should converge under arbitrary merge orders", func() {
+		expected, _ := hllplus.NewCRDT(12)
+		for _, r := range replicas {
+			Expect(expected.Join(r)).To(Succeed())
+		}
+
+		for round := 0; round < 5; round++ {
+			// every round starts from copies of the original replicas and gossips
+			// random pairs until all of them have converged
+			nodes := make([]*hllplus.CRDT, len(replicas))
+			for i, r := range replicas {
+				nodes[i], _ = hllplus.NewCRDTFrom(r.State())
+			}
+
+			for converged := false; !converged; {
+				i, j := rnd.Intn(len(nodes)), rnd.Intn(len(nodes))
+				Expect(nodes[i].Join(nodes[j])).To(Succeed())
+
+				converged = true
+				for _, n := range nodes {
+					converged = converged && n.Digest() == expected.Digest()
+				}
+			}
+
+			for _, n := range nodes {
+				Expect(n.Estimate()).To(Equal(expected.Estimate()))
+			}
+		}
+		Expect(expected.Estimate()).To(BeNumerically("~", 5_300, 250))
+	})
+
+	It("should converge with sparse states", func() {
+		sparse, _ := hllplus.New(12, 17)
+		sparse.AddInt64(1)
+		sparse.AddInt64(2)
+		Expect(sparse.IsSparse()).To(BeTrue())
+
+		a, err := hllplus.NewCRDTFrom(sparse)
+		Expect(err).NotTo(HaveOccurred())
+		Expect(a.State().IsSparse()).To(BeFalse())
+		Expect(sparse.IsSparse()).To(BeTrue())
+
+		b, _ := hllplus.NewCRDT(12)
+		b.AddInt64(2)
+		b.AddInt64(1)
+		Expect(a.Digest()).To(Equal(b.Digest()))
+		Expect(a.Estimate()).To(Equal(int64(2)))
+	})
+
+	It("should not count values twice", func() {
+		a, b := replicas[0], replicas[1]
+		n := a.State().NumValues()
+		Expect(a.Join(b)).To(Succeed())
+		Expect(a.Join(b)).To(Succeed())
+		Expect(a.State().NumValues()).To(Equal(n))
+	})
+
+	It("should reject incompatible replicas", func() {
+		low, _ := hllplus.NewCRDT(10)
+		Expect(replicas[0].Join(low)).To(MatchError("cannot join replicas with different precisions 12 and 10"))
+
+		xx, _ := hllplus.NewCRDT(12, hllplus.WithHasher(hllplus.XXHash64))
+		Expect(replicas[0].Join(xx)).To(MatchError(`cannot merge sketches with different hashers "fingerprint2011" and "xxhash64"`))
+		Expect(replicas[0].Digest()).NotTo(Equal(xx.Digest()))
+
+		str, _ := hllplus.NewCRDT(12)
+		str.AddString("x")
+		Expect(replicas[0].Join(str)).To(MatchError("cannot merge sketches with different value types INT64 and BYTES_OR_UTF8_STRING"))
+	})
+})
@@ -686,8 +686,8 @@ func alpha(precision uint8) float64 {
  * bias has not been computed precisely for the given estimate, the bias is computed from the
  * weighted mean of its neighbors.
  */
-func estimateBias(estimate float64, precision uint8) float64 {
-	biases := closestBiases(estimate, precision)
+func estimateBias(estimate float64, table *biasTable) float64 {
+	biases := closestBiases(estimate, table)
 	if len(biases) == 0 {
 		return 0
 	}
@@ -719,18 +719,40 @@ func (p weightedBiases) Len() int           { return len(p) }
 func (p weightedBiases) Less(i, j int) bool { return p[i].Distance < p[j].Distance }
 func (p weightedBiases) Swap(i, j int)      { p[i], p[j] = p[j], p[i] }
 
+// biasTable is a lookup table of empirically determined bias corrections (biases)
+// for given raw estimates (means).
+type biasTable struct {
+	means  []float64
+	biases []float64
+}
+
+/**
+ * Returns the built-in bias correction table for the given precision or nil, if the precision is
+ * out of defined bounds.
+ */
+func builtinBiasTable(precision uint8) *biasTable {
+	if precision < minDataPrecision || maxDataPrecision < precision {
+		return nil
+	}
+
+	return &biasTable{
+		means:  meanData[precision-minDataPrecision],
+		biases: biasData[precision-minDataPrecision],
+	}
+}
+
 /**
  * Returns a list of the knnNumNeighbors closest biases and their distance to the estimate, sorted by increasing
  * distance.
  */
-func closestBiases(estimate float64, precision uint8) weightedBiases {
-	// Return no bias correction when precision is out of defined bounds.
-	if precision < minDataPrecision || maxDataPrecision < precision {
+func closestBiases(estimate float64, table *biasTable) weightedBiases {
+	// Return no bias correction when there is no table.
+	if table == nil {
 		return nil
 	}
 
-	biases := biasData[precision-minDataPrecision]
-	means := meanData[precision-minDataPrecision]
+	biases := table.biases
+	means := table.means
 
 	// Return no bias correction when estimate is out of bounds.
 	if estimate < means[0] || means[len(means)-1] < estimate {
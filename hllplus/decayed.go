@@ -0,0 +1,129 @@
+package hllplus
+
+import (
+	"fmt"
+	"math"
+	"time"
+)
+
+// decayHorizon is the number of half-lives after which values are dropped from a
+// DecayedHLL. Their weight is below 2^-16 by then.
+const decayHorizon = 16
+
+// maxDecayBuckets limits the number of buckets of a DecayedHLL.
+const maxDecayBuckets = 1 << 12
+
+// DecayedHLL estimates a time-decayed number of distinct values, e.g. recently active
+// devices. Each value counts with weight 2^(-age/halfLife), where age is the time since
+// it was last seen, so older contributions fade out smoothly instead of dropping out of
+// a hard window like with WindowHLL.
+//
+// Values are kept in buckets of a fixed granularity, which covers 16 half-lives; ages
+// are measured in whole buckets. The estimate is derived from the growth of the
+// distinct count as older buckets are merged in, each step weighted by its age.
+//
+// DecayedHLL is not safe for concurrent use.
+type DecayedHLL struct {
+	w        *WindowHLL
+	halfLife float64 // in buckets
+}
+
+// NewDecayed inits a new decayed sketch. The granularity must be positive and the
+// half-life must not be shorter than the granularity. See New for all other parameters.
+func NewDecayed(halfLife, granularity time.Duration, precision, sparsePrecision uint8, opts ...Option) (*DecayedHLL, error) {
+	if granularity <= 0 || halfLife < granularity {
+		return nil, fmt.Errorf("invalid half-life %s: must not be shorter than the granularity %s", halfLife, granularity)
+	}
+
+	n := (decayHorizon*halfLife + granularity - 1) / granularity
+	if n > maxDecayBuckets {
+		return nil, fmt.Errorf("invalid granularity %s: too fine for half-life %s", granularity, halfLife)
+	}
+
+	w, err := NewWindow(n*granularity, granularity, precision, sparsePrecision, opts...)
+	if err != nil {
+		return nil, err
+	}
+	return &DecayedHLL{w: w, halfLife: float64(halfLife) / float64(granularity)}, nil
+}
+
+// HalfLife returns the time after which the weight of a value is halved.
+func (d *DecayedHLL) HalfLife() time.Duration {
+	return time.Duration(d.halfLife * float64(d.w.granularity))
+}
+
+// At returns the sketch of the bucket which contains t, see WindowHLL.At.
+func (d *DecayedHLL) At(t time.Time) *HLL {
+	return d.w.At(t)
+}
+
+// Add adds the uniform hash value to the current bucket.
+func (d *DecayedHLL) Add(hash uint64) {
+	d.w.Add(hash)
+}
+
+// AddString hashes and adds a string value to the current bucket, see HLL.AddString.
+func (d *DecayedHLL) AddString(v string) {
+	d.w.AddString(v)
+}
+
+// AddBytes hashes and adds a byte value to the current bucket, see HLL.AddBytes.
+func (d *DecayedHLL) AddBytes(v []byte) {
+	d.w.AddBytes(v)
+}
+
+// AddInt64 hashes and adds a signed number to the current bucket, see HLL.AddInt64.
+func (d *DecayedHLL) AddInt64(v int64) {
+	d.w.AddInt64(v)
+}
+
+// AddUint64 hashes and adds an unsigned number to the current bucket, see
+// HLL.AddUint64.
+func (d *DecayedHLL) AddUint64(v uint64) {
+	d.w.AddUint64(v)
+}
+
+// AddFloat64 hashes and adds a floating point number to the current bucket, see
+// HLL.AddFloat64.
+func (d *DecayedHLL) AddFloat64(v float64) {
+	d.w.AddFloat64(v)
+}
+
+// Estimate returns the decayed number of distinct values now.
+func (d *DecayedHLL) Estimate() float64 {
+	return d.EstimateAt(time.Now())
+}
+
+// EstimateAt returns the decayed number of distinct values at the bucket containing t.
+// Values in later buckets are ignored.
+func (d *DecayedHLL) EstimateAt(t time.Time) float64 {
+	num := d.w.bucketNum(t)
+	acc := d.w.tmpl.Clone()
+
+	var sum float64
+	var prev int64
+	for age := int64(0); age < int64(len(d.w.buckets)); age++ {
+		b := d.w.buckets[d.w.slot(num-age)]
+		if b.s == nil || b.num != num-age {
+			continue
+		}
+		_ = acc.Merge(b.s) // buckets are always compatible
+
+		// values which were last seen age buckets ago
+		if n := acc.Estimate(); n > prev {
+			sum += float64(n-prev) * math.Exp2(-float64(age)/d.halfLife)
+			prev = n
+		}
+	}
+	return sum
+}
+
+// MemoryUsage returns the number of bytes held by all buckets, see HLL.MemoryUsage.
+func (d *DecayedHLL) MemoryUsage() int {
+	return d.w.MemoryUsage()
+}
+
+// Reset removes all values.
+func (d *DecayedHLL) Reset() {
+	d.w.Reset()
+}
@@ -0,0 +1,67 @@
+package hllplus_test
+
+import (
+	"time"
+
+	"github.com/gowthamkommineni/zetasketch/hllplus"
+
+	. "github.com/bsm/ginkgo"
+	. "github.com/bsm/gomega"
+)
+
+var _ = Describe("DecayedHLL", func() {
+	var subject *hllplus.DecayedHLL
+	t0 := time.Date(2024, 5, 1, 12, 0, 0, 0, time.UTC)
+
+	BeforeEach(func() {
+		var err error
+		subject, err = hllplus.NewDecayed(time.Hour, 15*time.Minute, 14, 19)
+		Expect(err).NotTo(HaveOccurred())
+	})
+
+	It("should validate", func() {
+		_, err := hllplus.NewDecayed(time.Minute, time.Hour, 14, 19)
+		Expect(err).To(MatchError("invalid half-life 1m0s: must not be shorter than the granularity 1h0m0s"))
+		_, err = hllplus.NewDecayed(time.Hour, time.Millisecond, 14, 19)
+		Expect(err).To(MatchError("invalid granularity 1ms: too fine for half-life 1h0m0s"))
+		_, err = hllplus.NewDecayed(time.Hour, time.Minute, 30, 19)
+		Expect(err).To(MatchError("invalid normal precision 30"))
+
+		Expect(subject.HalfLife()).To(Equal(time.Hour))
+	})
+
+	It("should decay contributions by age", func() {
+		for i := 0; i < 4_000; i++ {
+			// 1,000 distinct values each, seen 0, 1, 2 and 3 hours before t0
+			age := time.Duration(i/1_000) * time.Hour
+			subject.At(t0.Add(-age)).AddInt64(int64(i))
+		}
+
+		Expect(subject.EstimateAt(t0)).To(BeNumerically("~", 1_000+500+250+125, 40))
+		Expect(subject.EstimateAt(t0.Add(time.Hour))).To(BeNumerically("~", (1_000+500+250+125)/2, 20))
+		Expect(subject.EstimateAt(t0.Add(-time.Hour))).To(BeNumerically("~", 1_000+500+250, 40))
+		Expect(subject.EstimateAt(t0.Add(20 * time.Hour))).To(BeZero())
+	})
+
+	It("should weigh values by their latest occurrence", func() {
+		for i := 0; i < 1_000; i++ {
+			subject.At(t0.Add(-2 * time.Hour)).AddInt64(int64(i))
+		}
+		Expect(subject.EstimateAt(t0)).To(BeNumerically("~", 250, 10))
+
+		for i := 0; i < 1_000; i++ {
+			subject.At(t0).AddInt64(int64(i))
+		}
+		Expect(subject.EstimateAt(t0)).To(BeNumerically("~", 1_000, 30))
+	})
+
+	It("should add values now", func() {
+		subject.AddString("a")
+		subject.AddString("b")
+		Expect(subject.Estimate()).To(Equal(2.0))
+		Expect(subject.MemoryUsage()).To(BeNumerically(">", 0))
+
+		subject.Reset()
+		Expect(subject.Estimate()).To(BeZero())
+	})
+})
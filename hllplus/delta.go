@@ -0,0 +1,190 @@
+package hllplus
+
+import (
+	"fmt"
+
+	"google.golang.org/protobuf/encoding/protowire"
+)
+
+// deltaVersion is the version of the delta format.
+const deltaVersion = 1
+
+// Diff computes the registers of s which are larger than in prev, an earlier state of
+// the same sketch, e.g. the state last shipped to a replica. Applying the delta to prev
+// by ApplyDelta yields the registers of s. Since applying keeps the larger value of each
+// register, deltas can be applied in any order and more than once. A nil prev produces
+// a delta of all registers.
+//
+// The delta is a compact binary format: a version byte, the precision, varints of the
+// value type and seed, the hasher ID, a zig-zag varint of the difference in the number
+// of values and the number of registers, then the gap to the previous position as a
+// varint and the value byte of each register.
+func (s *HLL) Diff(prev *HLL) ([]byte, error) {
+	var numValues int64
+	var old func(uint32) uint8
+	if prev == nil {
+		old = func(uint32) uint8 { return 0 }
+		numValues = s.numValues
+	} else {
+		if err := s.checkMergeable(prev); err != nil {
+			return nil, err
+		}
+		if prev.precision != s.precision {
+			return nil, fmt.Errorf("cannot diff sketches with different precisions %d and %d", s.precision, prev.precision)
+		}
+		old = prev.registerFunc()
+		numValues = s.numValues - prev.numValues
+	}
+
+	id := s.Hasher().ID()
+	data := make([]byte, 0, 32+len(id))
+	data = append(data, deltaVersion, s.precision)
+	data = protowire.AppendVarint(data, uint64(s.valueType))
+	data = protowire.AppendVarint(data, s.seed)
+	data = protowire.AppendString(data, id)
+	data = protowire.AppendVarint(data, protowire.EncodeZigZag(numValues))
+
+	// registers are collected first, their number precedes them
+	cur := s.registerFunc()
+	var body []byte
+	var count, last uint32
+	for pos := uint32(0); pos < 1<<s.precision; pos++ {
+		if rhoW := cur(pos); rhoW > old(pos) {
+			body = protowire.AppendVarint(body, uint64(pos-last))
+			body = append(body, rhoW)
+			count, last = count+1, pos
+		}
+	}
+
+	data = protowire.AppendVarint(data, uint64(count))
+	return append(data, body...), nil
+}
+
+// ApplyDelta raises the registers of the sketch to the values of a delta computed by
+// Diff. The sketch is converted to normal representation. Deltas of sketches with a
+// higher precision are downgraded, like with Merge; deltas of sketches with a lower
+// precision or a different hasher, seed or value type cannot be applied.
+func (s *HLL) ApplyDelta(delta []byte) error {
+	if len(delta) < 2 {
+		return fmt.Errorf("invalid delta: too short")
+	}
+	if delta[0] != deltaVersion {
+		return fmt.Errorf("invalid delta: unsupported version %d", delta[0])
+	}
+	precision := delta[1]
+	if precision < MinPrecision || precision > MaxPrecision {
+		return fmt.Errorf("invalid delta: precision %d", precision)
+	}
+	if precision < s.precision {
+		return fmt.Errorf("cannot apply delta with lower precision %d to %d", precision, s.precision)
+	}
+	delta = delta[2:]
+
+	r := deltaReader{b: delta}
+	valueType := ValueType(r.varint())
+	seed := r.varint()
+	id := r.string()
+	numValues := protowire.DecodeZigZag(r.varint())
+	count := r.varint()
+	if r.err != nil {
+		return fmt.Errorf("invalid delta: %w", r.err)
+	}
+	delta = r.b
+
+	if own := s.Hasher().ID(); id != own {
+		return fmt.Errorf("cannot apply delta with hasher %q to sketch with %q", id, own)
+	}
+	if seed != s.seed {
+		return fmt.Errorf("cannot apply delta with seed %d to sketch with %d", seed, s.seed)
+	}
+	if s.valueType != valueType && s.valueType != ValueTypeUnknown && valueType != ValueTypeUnknown {
+		return fmt.Errorf("cannot merge sketches with different value types %s and %s", s.valueType, valueType)
+	}
+
+	// each register takes at least 2 bytes
+	if count > uint64(len(delta)/2) {
+		return fmt.Errorf("invalid delta: %d registers in %d bytes", count, len(delta))
+	}
+
+	// validate before modifying the sketch
+	maxRhoW := 64 - precision + 1
+	var pos uint64
+	for i, rest := uint64(0), delta; i < count; i++ {
+		gap, n := protowire.ConsumeVarint(rest)
+		if n < 0 || n >= len(rest) {
+			return fmt.Errorf("invalid delta: truncated register %d", i)
+		}
+		if gap >= 1<<precision || pos+gap >= 1<<precision || rest[n] > maxRhoW {
+			return fmt.Errorf("invalid delta: register %d out of range", i)
+		}
+		pos += gap
+		rest = rest[n+1:]
+	}
+
+	s.setValueType(valueType)
+	s.numValues += numValues
+	s.cached = false
+	s.resetMartingale()
+	s.resetTally()
+
+	s.normalize()
+	s.ensureNormal()
+	s.ownNormal()
+
+	pos = 0
+	for i := uint64(0); i < count; i++ {
+		gap, n := protowire.ConsumeVarint(delta)
+		pos += gap
+		rhoW := normalDowngrade(int(pos), delta[n], precision, s.precision)
+		delta = delta[n+1:]
+
+		pos2 := uint32(pos >> (precision - s.precision))
+		if loadRegister(s.normal, s.packed, pos2) < rhoW {
+			storeRegister(s.normal, s.packed, pos2, rhoW)
+		}
+	}
+	return nil
+}
+
+// registerFunc returns a function which reads the registers at the normal precision.
+// Sparse sketches are converted, but not modified.
+func (s *HLL) registerFunc() func(pos uint32) uint8 {
+	if s.sparse != nil || len(s.normal) == 0 {
+		registers := s.Registers()
+		return func(pos uint32) uint8 { return registers[pos] }
+	}
+	return func(pos uint32) uint8 { return loadRegister(s.normal, s.packed, pos) }
+}
+
+// deltaReader consumes the fields of a delta. After the first error, all reads return
+// zero values.
+type deltaReader struct {
+	b   []byte
+	err error
+}
+
+func (r *deltaReader) varint() uint64 {
+	if r.err != nil {
+		return 0
+	}
+	v, n := protowire.ConsumeVarint(r.b)
+	if n < 0 {
+		r.err = protowire.ParseError(n)
+		return 0
+	}
+	r.b = r.b[n:]
+	return v
+}
+
+func (r *deltaReader) string() string {
+	if r.err != nil {
+		return ""
+	}
+	v, n := protowire.ConsumeString(r.b)
+	if n < 0 {
+		r.err = protowire.ParseError(n)
+		return ""
+	}
+	r.b = r.b[n:]
+	return v
+}
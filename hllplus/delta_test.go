@@ -0,0 +1,124 @@
+package hllplus_test
+
+import (
+	"math/rand"
+
+	"github.com/gowthamkommineni/zetasketch/hllplus"
+
+	. "github.com/bsm/ginkgo"
+	. "github.com/bsm/gomega"
+)
+
+var _ = Describe("Delta", func() {
+	var primary, replica *hllplus.HLL
+	var rnd *rand.Rand
+
+	BeforeEach(func() {
+		rnd = rand.New(rand.NewSource(3))
+		primary, _ = hllplus.NewNormal(14)
+		replica, _ = hllplus.NewNormal(14)
+		for i := 0; i < 50_000; i++ {
+			primary.AddUint64(rnd.Uint64())
+		}
+	})
+
+	It("should sync replicas", func() {
+		full, err := primary.Diff(nil)
+		Expect(err).NotTo(HaveOccurred())
+		Expect(replica.ApplyDelta(full)).To(Succeed())
+		Expect(replica.Registers()).To(Equal(primary.Registers()))
+		Expect(replica.NumValues()).To(Equal(primary.NumValues()))
+		Expect(replica.ValueType()).To(Equal(hllplus.ValueTypeUint64))
+
+		prev := primary.Clone()
+		for i := 0; i < 1_000; i++ {
+			primary.AddUint64(rnd.Uint64())
+		}
+
+		delta, err := primary.Diff(prev)
+		Expect(err).NotTo(HaveOccurred())
+		Expect(len(delta)).To(BeNumerically("<", len(full)/10))
+
+		Expect(replica.ApplyDelta(delta)).To(Succeed())
+		Expect(replica.Registers()).To(Equal(primary.Registers()))
+		Expect(replica.Estimate()).To(Equal(primary.Estimate()))
+		Expect(replica.NumValues()).To(Equal(int64(51_000)))
+
+		// applying again keeps the registers
+		Expect(replica.ApplyDelta(delta)).To(Succeed())
+		Expect(replica.Registers()).To(Equal(primary.Registers()))
+
+		// nothing changed
+		delta, err = primary.Diff(primary.Clone())
+		Expect(err).NotTo(HaveOccurred())
+		Expect(delta).To(HaveLen(22))
+	})
+
+	It("should diff sparse sketches", func() {
+		sparse, _ := hllplus.New(12, 17)
+		for i := 0; i < 100; i++ {
+			sparse.AddString(string(rune('a' + i)))
+		}
+		Expect(sparse.IsSparse()).To(BeTrue())
+
+		delta, err := sparse.Diff(nil)
+		Expect(err).NotTo(HaveOccurred())
+
+		target, _ := hllplus.New(12, 17)
+		Expect(target.ApplyDelta(delta)).To(Succeed())
+		Expect(target.IsSparse()).To(BeFalse())
+		Expect(target.Registers()).To(Equal(sparse.Registers()))
+		Expect(target.Estimate()).To(BeNumerically("~", 100, 2))
+	})
+
+	It("should downgrade deltas", func() {
+		low, _ := hllplus.NewNormal(12)
+		delta, err := primary.Diff(nil)
+		Expect(err).NotTo(HaveOccurred())
+		Expect(low.ApplyDelta(delta)).To(Succeed())
+
+		merged, _ := hllplus.NewNormal(12)
+		Expect(merged.Merge(primary)).To(Succeed())
+		Expect(low.Registers()).To(Equal(merged.Registers()))
+
+		delta, _ = low.Diff(nil)
+		Expect(primary.ApplyDelta(delta)).To(MatchError("cannot apply delta with lower precision 12 to 14"))
+	})
+
+	It("should reject incompatible sketches", func() {
+		low, _ := hllplus.NewNormal(12)
+		_, err := primary.Diff(low)
+		Expect(err).To(MatchError("cannot diff sketches with different precisions 14 and 12"))
+
+		xx, _ := hllplus.NewNormal(14, hllplus.WithHasher(hllplus.XXHash64))
+		_, err = primary.Diff(xx)
+		Expect(err).To(MatchError(`cannot merge sketches with different hashers "fingerprint2011" and "xxhash64"`))
+
+		delta, _ := primary.Diff(nil)
+		Expect(xx.ApplyDelta(delta)).To(MatchError(`cannot apply delta with hasher "fingerprint2011" to sketch with "xxhash64"`))
+
+		seeded, _ := hllplus.NewNormal(14, hllplus.WithSeed(7))
+		Expect(seeded.ApplyDelta(delta)).To(MatchError("cannot apply delta with seed 0 to sketch with 7"))
+
+		str, _ := hllplus.NewNormal(14)
+		str.AddString("x")
+		Expect(str.ApplyDelta(delta)).To(MatchError("cannot merge sketches with different value types BYTES_OR_UTF8_STRING and UINT64"))
+	})
+
+	It("should reject invalid deltas", func() {
+		delta, _ := primary.Diff(nil)
+
+		Expect(replica.ApplyDelta(nil)).To(MatchError("invalid delta: too short"))
+		Expect(replica.ApplyDelta([]byte{2, 14})).To(MatchError("invalid delta: unsupported version 2"))
+		Expect(replica.ApplyDelta([]byte{1, 40})).To(MatchError("invalid delta: precision 40"))
+		Expect(replica.ApplyDelta(delta[:5])).To(MatchError(HavePrefix("invalid delta: ")))
+		Expect(replica.ApplyDelta(delta[:len(delta)-1])).To(MatchError(HavePrefix("invalid delta: ")))
+
+		corrupt := append([]byte(nil), delta...)
+		corrupt[len(corrupt)-1] = 60
+		Expect(replica.ApplyDelta(corrupt)).To(MatchError(HavePrefix("invalid delta: register")))
+
+		// the sketch is not modified by invalid deltas
+		Expect(replica.Estimate()).To(BeZero())
+	})
+})
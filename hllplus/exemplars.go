@@ -0,0 +1,83 @@
+package hllplus
+
+import "sort"
+
+// exemplars keeps the raw values with the smallest hashes, a so-called bottom-k sample.
+// Since hashes are uniform, this is a uniform sample of the distinct values added to
+// the sketch. Unlike a classic reservoir, it ignores duplicates and merges exactly: the
+// sample of the union is the bottom-k of both samples, regardless of merge order.
+type exemplars struct {
+	max    int
+	hashes []uint64 // sorted
+	values []interface{}
+}
+
+func (e *exemplars) Clone() *exemplars {
+	if e == nil {
+		return nil
+	}
+
+	return &exemplars{
+		max:    e.max,
+		hashes: append([]uint64(nil), e.hashes...),
+		values: append([]interface{}(nil), e.values...),
+	}
+}
+
+// accepts reports whether a value with the given hash would be sampled. It is cheap,
+// so callers only need to copy and box values which are accepted.
+func (e *exemplars) accepts(hash uint64) bool {
+	if e == nil {
+		return false
+	}
+	if len(e.hashes) == e.max && hash >= e.hashes[len(e.hashes)-1] {
+		return false
+	}
+	i := e.search(hash)
+	return i == len(e.hashes) || e.hashes[i] != hash
+}
+
+// insert adds an accepted value, evicting the one with the largest hash if full.
+func (e *exemplars) insert(hash uint64, v interface{}) {
+	i := e.search(hash)
+	if len(e.hashes) == e.max {
+		e.hashes = e.hashes[:len(e.hashes)-1]
+		e.values = e.values[:len(e.values)-1]
+	}
+
+	e.hashes = append(e.hashes, 0)
+	e.values = append(e.values, nil)
+	copy(e.hashes[i+1:], e.hashes[i:])
+	copy(e.values[i+1:], e.values[i:])
+	e.hashes[i], e.values[i] = hash, v
+}
+
+// merge adds the values of other.
+func (e *exemplars) merge(other *exemplars) {
+	if e == nil || other == nil {
+		return
+	}
+	for i, hash := range other.hashes {
+		if !e.accepts(hash) {
+			if len(e.hashes) == e.max && hash >= e.hashes[len(e.hashes)-1] {
+				return // all following hashes are larger
+			}
+			continue
+		}
+		e.insert(hash, other.values[i])
+	}
+}
+
+func (e *exemplars) search(hash uint64) int {
+	return sort.Search(len(e.hashes), func(i int) bool { return e.hashes[i] >= hash })
+}
+
+// Exemplars returns a sample of the distinct values added to the sketch, if enabled by
+// WithExemplars. Values have the type they were added with: string, []byte, int64,
+// uint64 or float64. The sample is ordered by hash, i.e. in random order.
+func (s *HLL) Exemplars() []interface{} {
+	if s.exemplars == nil {
+		return nil
+	}
+	return append([]interface{}(nil), s.exemplars.values...)
+}
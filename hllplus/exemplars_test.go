@@ -0,0 +1,142 @@
+package hllplus_test
+
+import (
+	"fmt"
+	"time"
+
+	"github.com/gowthamkommineni/zetasketch/hllplus"
+
+	. "github.com/bsm/ginkgo"
+	. "github.com/bsm/gomega"
+)
+
+var _ = Describe("Exemplars", func() {
+	var subject *hllplus.HLL
+
+	BeforeEach(func() {
+		subject, _ = hllplus.New(12, 17, hllplus.WithExemplars(10))
+	})
+
+	It("should validate", func() {
+		_, err := hllplus.New(12, 17, hllplus.WithExemplars(0))
+		Expect(err).To(MatchError("invalid number of exemplars 0"))
+
+		plain, _ := hllplus.New(12, 17)
+		plain.AddString("a")
+		Expect(plain.Exemplars()).To(BeNil())
+	})
+
+	It("should sample distinct values", func() {
+		Expect(subject.Exemplars()).To(BeEmpty())
+
+		subject.AddString("a")
+		subject.AddString("a")
+		subject.AddStrings([]string{"b", "a"})
+		Expect(subject.Exemplars()).To(ConsistOf("a", "b"))
+
+		for i := 0; i < 10_000; i++ {
+			subject.AddString(fmt.Sprintf("v%d", i%1_000))
+		}
+		Expect(subject.Exemplars()).To(HaveLen(10))
+
+		// the sample only depends on the set of values
+		other, _ := hllplus.New(12, 17, hllplus.WithExemplars(10))
+		for i := 999; i >= 0; i-- {
+			other.AddString(fmt.Sprintf("v%d", i))
+		}
+		other.AddStrings([]string{"a", "b"})
+		Expect(other.Exemplars()).To(Equal(subject.Exemplars()))
+	})
+
+	It("should keep typed values", func() {
+		bytes, _ := hllplus.New(12, 17, hllplus.WithExemplars(2))
+		buf := []byte("x")
+		bytes.AddBytes(buf)
+		buf[0] = 'y'
+		Expect(bytes.Exemplars()).To(Equal([]interface{}{[]byte("x")}))
+
+		ints, _ := hllplus.New(12, 17, hllplus.WithExemplars(2))
+		ints.AddInt64(-7)
+		Expect(ints.Exemplars()).To(Equal([]interface{}{int64(-7)}))
+
+		uints, _ := hllplus.New(12, 17, hllplus.WithExemplars(2))
+		uints.AddUint64(7)
+		Expect(uints.Exemplars()).To(Equal([]interface{}{uint64(7)}))
+
+		floats, _ := hllplus.New(12, 17, hllplus.WithExemplars(2))
+		floats.AddFloat64(1.5)
+		floats.Add(1234)
+		Expect(floats.Exemplars()).To(Equal([]interface{}{1.5}))
+	})
+
+	It("should carry exemplars through merges and clones", func() {
+		a, _ := hllplus.New(12, 17, hllplus.WithExemplars(20))
+		b, _ := hllplus.NewNormal(14, hllplus.WithExemplars(20))
+		all, _ := hllplus.New(12, 17, hllplus.WithExemplars(20))
+		for i := 0; i < 2_000; i++ {
+			if i%3 == 0 {
+				a.AddInt64(int64(i))
+			} else {
+				b.AddInt64(int64(i))
+			}
+			all.AddInt64(int64(i))
+		}
+
+		clone := a.Clone()
+		Expect(clone.Exemplars()).To(Equal(a.Exemplars()))
+		clone.AddInt64(-1)
+		Expect(a.Exemplars()).NotTo(ContainElement(int64(-1)))
+
+		Expect(a.Merge(b)).To(Succeed())
+		Expect(a.Exemplars()).To(Equal(all.Exemplars()))
+
+		// merging again does not change the sample
+		Expect(a.Merge(b)).To(Succeed())
+		Expect(a.Exemplars()).To(Equal(all.Exemplars()))
+		Expect(a.Snapshot().Exemplars()).To(Equal(all.Exemplars()))
+	})
+
+	It("should sample values added to wrappers", func() {
+		expected, _ := hllplus.New(12, 17, hllplus.WithExemplars(20))
+		sharded, _ := hllplus.NewSharded(4, 12, 17, hllplus.WithExemplars(20))
+		grouped, _ := hllplus.NewGrouped(4, 12, 17, hllplus.WithExemplars(20))
+		hybrid, _ := hllplus.NewHybrid(100, 12, 17, hllplus.WithExemplars(20))
+		for i := 0; i < 1_000; i++ {
+			v := fmt.Sprintf("v%d", i)
+			expected.AddString(v)
+			sharded.AddString(v)
+			grouped.AddString("k", v)
+			hybrid.AddString(v)
+		}
+		Expect(expected.Exemplars()).To(HaveLen(20))
+		Expect(sharded.Snapshot().Exemplars()).To(Equal(expected.Exemplars()))
+		Expect(grouped.Get("k").Exemplars()).To(Equal(expected.Exemplars()))
+		Expect(hybrid.Sketch().Exemplars()).To(Equal(expected.Exemplars()))
+
+		// typed values are kept with their type
+		ints, _ := hllplus.NewGrouped(1, 12, 17, hllplus.WithExemplars(2))
+		ints.AddInt64("k", -7)
+		buf := []byte("x")
+		ints.AddBytes("b", buf)
+		buf[0] = 'y'
+		Expect(ints.Get("k").Exemplars()).To(Equal([]interface{}{int64(-7)}))
+		Expect(ints.Get("b").Exemplars()).To(Equal([]interface{}{[]byte("x")}))
+
+		// exact hybrid sketches merge their samples
+		a, _ := hllplus.NewHybrid(100, 12, 17, hllplus.WithExemplars(20))
+		b, _ := hllplus.NewHybrid(100, 12, 17, hllplus.WithExemplars(20))
+		a.AddString("a")
+		b.AddString("b")
+		Expect(a.Merge(b)).To(Succeed())
+		Expect(a.Sketch().Exemplars()).To(ConsistOf("a", "b"))
+	})
+
+	It("should be rejected by sketches without a sample", func() {
+		_, err := hllplus.NewAtomic(12, 17, hllplus.WithExemplars(10))
+		Expect(err).To(MatchError("exemplars are not supported by AtomicHLL"))
+		_, err = hllplus.NewTTL(time.Minute, time.Second, 12, 17, hllplus.WithExemplars(10))
+		Expect(err).To(MatchError("exemplars are not supported by TTLHLL"))
+		_, err = hllplus.NewLinearCounting(1024, hllplus.WithExemplars(10))
+		Expect(err).To(MatchError("exemplars are not supported by LinearCounting"))
+	})
+})
@@ -2,16 +2,16 @@ package hllplus
 
 // EstimateBias test export.
 func EstimateBias(e float64, p uint8) float64 {
-	return estimateBias(e, p)
+	return estimateBias(e, builtinBiasTable(p))
 }
 
-func NewNormal(precision uint8) (*HLL, error) {
+func NewNormal(precision uint8, opts ...Option) (*HLL, error) {
 	pp := precision + 5
 	if pp > MaxSparsePrecision {
 		pp = MaxSparsePrecision
 	}
 
-	s, err := New(precision, pp)
+	s, err := New(precision, pp, opts...)
 	if err != nil {
 		return nil, err
 	}
@@ -22,3 +22,23 @@ func NewNormal(precision uint8) (*HLL, error) {
 func (s *HLL) IsSparse() bool {
 	return s.sparse != nil
 }
+
+// RegisterStats test export.
+func RegisterStats(registers []byte) (int, float64) {
+	return registerStats(registers)
+}
+
+// RegisterStatsGeneric test export.
+func RegisterStatsGeneric(registers []byte) (int, float64) {
+	return registerStatsGeneric(registers)
+}
+
+// NormalSize returns the number of bytes used by normal registers.
+func (s *HLL) NormalSize() int {
+	return len(s.normal)
+}
+
+// NumBuffered returns the number of buffered sparse values.
+func (s *HLL) NumBuffered() int {
+	return len(s.sparse.buffer)
+}
@@ -0,0 +1,45 @@
+package hllplus
+
+import "reflect"
+
+// Value is the set of value types supported by AddValue.
+type Value interface {
+	~int | ~int8 | ~int16 | ~int32 | ~int64 |
+		~uint | ~uint8 | ~uint16 | ~uint32 | ~uint64 | ~uintptr |
+		~float32 | ~float64 | ~string | ~[]byte
+}
+
+// AddValue hashes and adds a value to the sketch. Signed integers are added
+// via AddInt64, unsigned integers via AddUint64, floats via AddFloat64,
+// strings via AddString and byte slices via AddBytes.
+func AddValue[T Value](s *HLL, v T) {
+	switch x := any(v).(type) {
+	case string:
+		s.AddString(x)
+	case []byte:
+		s.AddBytes(x)
+	case int:
+		s.AddInt64(int64(x))
+	case int64:
+		s.AddInt64(x)
+	case uint64:
+		s.AddUint64(x)
+	default:
+		addReflect(s, reflect.ValueOf(v))
+	}
+}
+
+func addReflect(s *HLL, v reflect.Value) {
+	switch v.Kind() {
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
+		s.AddInt64(v.Int())
+	case reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64, reflect.Uintptr:
+		s.AddUint64(v.Uint())
+	case reflect.Float32, reflect.Float64:
+		s.AddFloat64(v.Float())
+	case reflect.String:
+		s.AddString(v.String())
+	case reflect.Slice:
+		s.AddBytes(v.Bytes())
+	}
+}
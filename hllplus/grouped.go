@@ -0,0 +1,267 @@
+package hllplus
+
+import (
+	"math"
+	"runtime"
+	"sort"
+	"sync"
+
+	"github.com/gowthamkommineni/zetasketch/internal/hash"
+	pb "github.com/gowthamkommineni/zetasketch/internal/zetasketch"
+)
+
+// GroupedHLL maintains one sketch per key, e.g. distinct users per page, and is safe
+// for concurrent use. Keys are distributed across independently locked shards, so
+// writers of different keys rarely contend. Sketches are created on first use with the
+// configuration the GroupedHLL was created with.
+type GroupedHLL struct {
+	tmpl   *HLL // empty sketch, carries the configuration
+	shards []groupShard
+	mask   uint64
+}
+
+type groupShard struct {
+	mu       sync.Mutex
+	sketches map[string]*HLL
+	_        [48]byte // avoid false sharing
+}
+
+// NewGrouped inits a new grouped sketch with n shards, rounded up to the next power of
+// two. If n is <= 0, runtime.GOMAXPROCS(0) is used. See New for all other parameters.
+func NewGrouped(n int, precision, sparsePrecision uint8, opts ...Option) (*GroupedHLL, error) {
+	tmpl, err := New(precision, sparsePrecision, opts...)
+	if err != nil {
+		return nil, err
+	}
+
+	if n <= 0 {
+		n = runtime.GOMAXPROCS(0)
+	}
+	n = 1 << uint(math.Ceil(math.Log2(float64(n))))
+
+	g := &GroupedHLL{
+		tmpl:   tmpl,
+		shards: make([]groupShard, n),
+		mask:   uint64(n - 1),
+	}
+	for i := range g.shards {
+		g.shards[i].sketches = make(map[string]*HLL)
+	}
+	return g, nil
+}
+
+// Add adds the uniform hash value to the sketch of key.
+func (g *GroupedHLL) Add(key string, hash uint64) {
+	sh := g.shard(key)
+	sh.mu.Lock()
+	defer sh.mu.Unlock()
+
+	sh.get(g.tmpl, key).Add(hash)
+}
+
+// AddString hashes and adds a string value to the sketch of key, see HLL.AddString.
+func (g *GroupedHLL) AddString(key, v string) {
+	addGrouped(g, key, ValueTypeBytes, g.tmpl.hashString(v), v)
+}
+
+// AddBytes hashes and adds a byte value to the sketch of key, see HLL.AddBytes.
+func (g *GroupedHLL) AddBytes(key string, v []byte) {
+	addGrouped(g, key, ValueTypeBytes, g.tmpl.hashBytes(v), v)
+}
+
+// AddInt64 hashes and adds a signed number to the sketch of key, see HLL.AddInt64.
+func (g *GroupedHLL) AddInt64(key string, v int64) {
+	addGrouped(g, key, ValueTypeInt64, g.tmpl.hashUint64(uint64(v)), v)
+}
+
+// AddUint64 hashes and adds an unsigned number to the sketch of key, see HLL.AddUint64.
+func (g *GroupedHLL) AddUint64(key string, v uint64) {
+	addGrouped(g, key, ValueTypeUint64, g.tmpl.hashUint64(v), v)
+}
+
+// AddFloat64 hashes and adds a floating point number to the sketch of key, see
+// HLL.AddFloat64.
+func (g *GroupedHLL) AddFloat64(key string, v float64) {
+	addGrouped(g, key, ValueTypeDouble, g.tmpl.hashUint64(float64Bits(v)), v)
+}
+
+// Merge merges other into the sketch of key.
+func (g *GroupedHLL) Merge(key string, other *HLL) error {
+	sh := g.shard(key)
+	sh.mu.Lock()
+	defer sh.mu.Unlock()
+
+	if s, ok := sh.sketches[key]; ok {
+		return s.Merge(other)
+	}
+
+	s := g.tmpl.Clone()
+	if err := s.Merge(other); err != nil {
+		return err
+	}
+	sh.sketches[key] = s
+	return nil
+}
+
+// Update calls fn with the sketch of key, creating it if necessary, while holding the
+// lock of its shard, so that fn can add several values at once. It reports whether fn
+// changed any register of the sketch, e.g. to implement PFADD of Redis. fn must not
+// retain the sketch or take snapshots of it.
+func (g *GroupedHLL) Update(key string, fn func(s *HLL)) bool {
+	sh := g.shard(key)
+	sh.mu.Lock()
+	defer sh.mu.Unlock()
+
+	s := sh.get(g.tmpl, key)
+	changed := s.watch()
+	fn(s)
+	return changed()
+}
+
+// Get returns a copy of the sketch of key, or nil if there is none.
+func (g *GroupedHLL) Get(key string) *HLL {
+	sh := g.shard(key)
+	sh.mu.Lock()
+	defer sh.mu.Unlock()
+
+	if s, ok := sh.sketches[key]; ok {
+		return s.Clone()
+	}
+	return nil
+}
+
+// Estimate returns the cardinality estimate of key, or 0 if there is no sketch for key.
+func (g *GroupedHLL) Estimate(key string) int64 {
+	sh := g.shard(key)
+	sh.mu.Lock()
+	defer sh.mu.Unlock()
+
+	if s, ok := sh.sketches[key]; ok {
+		return s.Estimate()
+	}
+	return 0
+}
+
+// Estimates returns the cardinality estimates of all keys.
+func (g *GroupedHLL) Estimates() map[string]int64 {
+	res := make(map[string]int64, g.Len())
+	g.each(func(key string, s *HLL) {
+		res[key] = s.Estimate()
+	})
+	return res
+}
+
+// Delete removes the sketch of key.
+func (g *GroupedHLL) Delete(key string) {
+	sh := g.shard(key)
+	sh.mu.Lock()
+	defer sh.mu.Unlock()
+
+	delete(sh.sketches, key)
+}
+
+// Reset removes all sketches.
+func (g *GroupedHLL) Reset() {
+	for i := range g.shards {
+		sh := &g.shards[i]
+		sh.mu.Lock()
+		sh.sketches = make(map[string]*HLL)
+		sh.mu.Unlock()
+	}
+}
+
+// Len returns the number of keys.
+func (g *GroupedHLL) Len() int {
+	var n int
+	for i := range g.shards {
+		sh := &g.shards[i]
+		sh.mu.Lock()
+		n += len(sh.sketches)
+		sh.mu.Unlock()
+	}
+	return n
+}
+
+// Keys returns all keys in sorted order.
+func (g *GroupedHLL) Keys() []string {
+	keys := make([]string, 0, g.Len())
+	g.each(func(key string, _ *HLL) {
+		keys = append(keys, key)
+	})
+	sort.Strings(keys)
+	return keys
+}
+
+// MemoryUsage returns the number of bytes held by the keys and sketches, see
+// HLL.MemoryUsage. Like there, the fixed size of structs and map overhead are not
+// included.
+func (g *GroupedHLL) MemoryUsage() int {
+	var n int
+	g.each(func(key string, s *HLL) {
+		n += len(key) + s.MemoryUsage()
+	})
+	return n
+}
+
+// Snapshot returns copies of all sketches by key, which can be used without locking.
+func (g *GroupedHLL) Snapshot() map[string]*HLL {
+	res := make(map[string]*HLL, g.Len())
+	g.each(func(key string, s *HLL) {
+		res[key] = s.Snapshot()
+	})
+	return res
+}
+
+// Protos builds the BigQuery-compatible protobuf messages of all sketches by key, see
+// HLL.Proto.
+func (g *GroupedHLL) Protos() map[string]*pb.HyperLogLogPlusUniqueStateProto {
+	res := make(map[string]*pb.HyperLogLogPlusUniqueStateProto, g.Len())
+	g.each(func(key string, s *HLL) {
+		res[key] = s.Proto()
+	})
+	return res
+}
+
+// addGrouped adds the hash of v to the sketch of key, which samples v, see
+// WithExemplars.
+func addGrouped[T exemplar](g *GroupedHLL, key string, t ValueType, hash uint64, v T) {
+	sh := g.shard(key)
+	sh.mu.Lock()
+	defer sh.mu.Unlock()
+
+	s := sh.get(g.tmpl, key)
+	s.setValueType(t)
+	sample(s, hash, v)
+	s.Add(hash)
+}
+
+// each calls fn for all sketches, one shard at a time, while holding the shard's lock.
+func (g *GroupedHLL) each(fn func(key string, s *HLL)) {
+	for i := range g.shards {
+		g.shards[i].each(fn)
+	}
+}
+
+// each calls fn for all sketches of the shard while holding its lock.
+func (sh *groupShard) each(fn func(key string, s *HLL)) {
+	sh.mu.Lock()
+	defer sh.mu.Unlock()
+
+	for key, s := range sh.sketches {
+		fn(key, s)
+	}
+}
+
+func (g *GroupedHLL) shard(key string) *groupShard {
+	return &g.shards[hash.String(key)&g.mask]
+}
+
+// get returns the sketch of key, creating it if necessary. The shard must be locked.
+func (sh *groupShard) get(tmpl *HLL, key string) *HLL {
+	s, ok := sh.sketches[key]
+	if !ok {
+		s = tmpl.Clone()
+		sh.sketches[key] = s
+	}
+	return s
+}
@@ -0,0 +1,159 @@
+package hllplus_test
+
+import (
+	"fmt"
+	"sync"
+	"testing"
+
+	"github.com/gowthamkommineni/zetasketch/hllplus"
+	"google.golang.org/protobuf/proto"
+
+	. "github.com/bsm/ginkgo"
+	. "github.com/bsm/gomega"
+)
+
+var _ = Describe("GroupedHLL", func() {
+	var subject *hllplus.GroupedHLL
+
+	BeforeEach(func() {
+		var err error
+		subject, err = hllplus.NewGrouped(4, 12, 17)
+		Expect(err).NotTo(HaveOccurred())
+	})
+
+	It("should validate", func() {
+		_, err := hllplus.NewGrouped(4, 30, 17)
+		Expect(err).To(MatchError("invalid normal precision 30"))
+	})
+
+	It("should maintain sketches by key", func() {
+		var wg sync.WaitGroup
+		for w := 0; w < 8; w++ {
+			wg.Add(1)
+			go func(w int) {
+				defer wg.Done()
+				for i := w; i < 40_000; i += 8 {
+					subject.AddInt64("page0", int64(i))
+					if i%2 == 0 {
+						subject.AddInt64("page1", int64(i))
+					}
+					if i%4 == 0 {
+						subject.AddInt64("page2", int64(i))
+					}
+				}
+			}(w)
+		}
+		wg.Wait()
+
+		Expect(subject.Len()).To(Equal(3))
+		Expect(subject.Keys()).To(Equal([]string{"page0", "page1", "page2"}))
+		Expect(subject.Estimate("page0")).To(BeNumerically("~", 40_000, 2_000))
+		Expect(subject.Estimate("page1")).To(BeNumerically("~", 20_000, 1_000))
+		Expect(subject.Estimate("page2")).To(BeNumerically("~", 10_000, 500))
+		Expect(subject.Estimate("missing")).To(BeZero())
+		Expect(subject.Estimates()).To(HaveLen(3))
+		Expect(subject.Estimates()).To(HaveKeyWithValue("page1", subject.Estimate("page1")))
+
+		s := subject.Get("page1")
+		Expect(s.Estimate()).To(Equal(subject.Estimate("page1")))
+		Expect(s.ValueType()).To(Equal(hllplus.ValueTypeInt64))
+		Expect(subject.Get("missing")).To(BeNil())
+
+		subject.Delete("page1")
+		Expect(subject.Keys()).To(Equal([]string{"page0", "page2"}))
+
+		subject.Reset()
+		Expect(subject.Len()).To(BeZero())
+	})
+
+	It("should account memory", func() {
+		Expect(subject.MemoryUsage()).To(BeZero())
+
+		subject.AddString("a", "x")
+		n := subject.MemoryUsage()
+		Expect(n).To(BeNumerically(">", 1))
+
+		for i := 0; i < 10_000; i++ {
+			subject.AddString("b", fmt.Sprint(i))
+		}
+		Expect(subject.MemoryUsage()).To(Equal(n + 1 + subject.Get("b").MemoryUsage()))
+	})
+
+	It("should merge", func() {
+		other, _ := hllplus.New(12, 17)
+		other.AddString("x")
+		other.AddString("y")
+
+		Expect(subject.Merge("a", other)).To(Succeed())
+		Expect(subject.Merge("a", other)).To(Succeed())
+		Expect(subject.Estimate("a")).To(Equal(int64(2)))
+
+		xx, _ := hllplus.New(12, 17, hllplus.WithHasher(hllplus.XXHash64))
+		Expect(subject.Merge("b", xx)).To(MatchError(`cannot merge sketches with different hashers "fingerprint2011" and "xxhash64"`))
+		Expect(subject.Keys()).To(Equal([]string{"a"}))
+
+		subject.AddInt64("c", 1)
+		Expect(subject.Merge("c", other)).To(MatchError("cannot merge sketches with different value types INT64 and BYTES_OR_UTF8_STRING"))
+	})
+
+	It("should report whether updates change registers", func() {
+		Expect(subject.Update("a", func(s *hllplus.HLL) { s.AddStrings([]string{"x", "y"}) })).To(BeTrue())
+		Expect(subject.Update("a", func(s *hllplus.HLL) { s.AddString("x") })).To(BeFalse())
+		Expect(subject.Update("a", func(*hllplus.HLL) {})).To(BeFalse())
+		Expect(subject.Estimate("a")).To(Equal(int64(2)))
+
+		// sparse and normal, with and without snapshots
+		Expect(subject.Update("b", func(*hllplus.HLL) {})).To(BeFalse())
+		var snaps []*hllplus.HLL
+		for i := 0; i < 20_000; i++ {
+			before := subject.Get("b").Proto()
+			changed := subject.Update("b", func(s *hllplus.HLL) {
+				s.AddInt64(int64(i % 5_000))
+			})
+			Expect(changed).To(Equal(!proto.Equal(before, subject.Get("b").Proto())), "after %d values", i+1)
+
+			if i%1_000 == 0 {
+				snaps = append(snaps, subject.Snapshot()["b"])
+			}
+		}
+		Expect(subject.Get("b").IsSparse()).To(BeFalse())
+		Expect(snaps[0].Estimate()).To(Equal(int64(1)))
+		Expect(snaps[1].Estimate()).To(BeNumerically("~", 1_001, 10))
+	})
+
+	It("should export snapshots and protos", func() {
+		subject.AddString("a", "x")
+		subject.AddString("b", "x")
+		subject.AddString("b", "y")
+
+		snap := subject.Snapshot()
+		Expect(snap).To(HaveLen(2))
+		Expect(snap["b"].Estimate()).To(Equal(int64(2)))
+
+		subject.AddString("b", "z")
+		Expect(snap["b"].Estimate()).To(Equal(int64(2)))
+
+		protos := subject.Protos()
+		Expect(protos).To(HaveLen(2))
+
+		restored, err := hllplus.NewFromProto(protos["b"])
+		Expect(err).NotTo(HaveOccurred())
+		Expect(restored.Estimate()).To(Equal(int64(3)))
+	})
+})
+
+func BenchmarkGroupedHLL_Add(b *testing.B) {
+	subject, _ := hllplus.NewGrouped(0, 14, 19)
+	keys := make([]string, 1_000)
+	for i := range keys {
+		keys[i] = fmt.Sprint("key", i)
+	}
+
+	b.RunParallel(func(pb *testing.PB) {
+		var i int
+		for pb.Next() {
+			subject.AddInt64(keys[i%len(keys)], int64(i))
+			i++
+		}
+	})
+}
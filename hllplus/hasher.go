@@ -0,0 +1,109 @@
+package hllplus
+
+import (
+	"encoding/binary"
+
+	"github.com/cespare/xxhash/v2"
+	"github.com/gowthamkommineni/zetasketch/internal/hash"
+	"github.com/spaolacci/murmur3"
+)
+
+// Hasher computes 64-bit hashes of values.
+type Hasher interface {
+	// ID uniquely identifies the hash function. Sketches can only be
+	// merged with sketches which were built using the same ID.
+	ID() string
+	// Hash64 returns the 64-bit hash of p.
+	Hash64(p []byte) uint64
+}
+
+// Fingerprint2011 is the default hasher, compatible with the zetasketch Java
+// library and BigQuery's HLL_COUNT functions.
+var Fingerprint2011 Hasher = fingerprint2011{}
+
+type fingerprint2011 struct{}
+
+func (fingerprint2011) ID() string             { return "fingerprint2011" }
+func (fingerprint2011) Hash64(p []byte) uint64 { return hash.Bytes(p) }
+
+// XXHash64 is a fast, non-cryptographic hasher based on the 64-bit xxHash algorithm.
+// Sketches built using it are not compatible with BigQuery.
+var XXHash64 Hasher = xxHash64{}
+
+type xxHash64 struct{}
+
+func (xxHash64) ID() string             { return "xxhash64" }
+func (xxHash64) Hash64(p []byte) uint64 { return xxhash.Sum64(p) }
+
+// Murmur3 is a hasher based on the 128-bit MurmurHash3 algorithm, folded into
+// 64 bits. Sketches built using it are not compatible with BigQuery.
+var Murmur3 Hasher = murmur3x128{}
+
+type murmur3x128 struct{}
+
+func (murmur3x128) ID() string { return "murmur3-128" }
+func (murmur3x128) Hash64(p []byte) uint64 {
+	h1, h2 := murmur3.Sum128(p)
+	return h1 ^ h2
+}
+
+// Hasher returns the hasher used by the sketch.
+func (s *HLL) Hasher() Hasher {
+	if s.hasher == nil {
+		return Fingerprint2011
+	}
+	return s.hasher
+}
+
+// Seed returns the hash seed of the sketch.
+func (s *HLL) Seed() uint64 {
+	return s.seed
+}
+
+func (s *HLL) hashBytes(p []byte) uint64 {
+	if h, ok := s.hashBuiltin(p); ok {
+		return h
+	}
+	return s.seeded(s.hasher.Hash64(p))
+}
+
+func (s *HLL) hashString(v string) uint64 {
+	if h, ok := s.hashBuiltin([]byte(v)); ok {
+		return h
+	}
+	return s.hashBytes([]byte(v))
+}
+
+func (s *HLL) hashUint64(v uint64) uint64 {
+	var buf [8]byte
+	binary.LittleEndian.PutUint64(buf[:], v)
+	if h, ok := s.hashBuiltin(buf[:]); ok {
+		return h
+	}
+	// custom hashers may retain p, so buf must not be passed on
+	return s.hashBytes(append([]byte(nil), buf[:]...))
+}
+
+// hashBuiltin hashes p with one of the built-in hashers. Calling them directly instead
+// of through the Hasher interface keeps p from escaping, so hashing values on the stack
+// does not allocate. It returns false for all other hashers, including Murmur3, whose
+// implementation retains its input.
+func (s *HLL) hashBuiltin(p []byte) (uint64, bool) {
+	var h uint64
+	switch s.hasher.(type) {
+	case nil, fingerprint2011:
+		h = hash.Bytes(p)
+	case xxHash64:
+		h = xxhash.Sum64(p)
+	default:
+		return 0, false
+	}
+	return s.seeded(h), true
+}
+
+func (s *HLL) seeded(h uint64) uint64 {
+	if s.seed != 0 {
+		h = hash.Seeded(h, s.seed)
+	}
+	return h
+}
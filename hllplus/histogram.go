@@ -0,0 +1,191 @@
+package hllplus
+
+import (
+	"context"
+	"fmt"
+	"math"
+	"runtime"
+	"sort"
+	"sync"
+)
+
+// histogramBatchSize is the number of sketches a Histogram worker claims at once.
+const histogramBatchSize = 256
+
+// HistogramBucket counts the sketches with an estimate in (previous UpperBound, UpperBound].
+type HistogramBucket struct {
+	UpperBound int64
+	Count      int
+}
+
+// EstimateHistogram summarizes the distribution of the estimates of many sketches, e.g.
+// of the distinct users per customer for capacity planning.
+type EstimateHistogram struct {
+	// Buckets contains the counts per bucket. The last bucket has an upper bound of
+	// math.MaxInt64 and counts all estimates above the largest requested bound.
+	Buckets []HistogramBucket
+	// Count is the number of sketches.
+	Count int
+	// Sum is the sum of all estimates.
+	Sum int64
+
+	sorted []int64
+}
+
+// Min returns the smallest estimate, or 0 if there are no sketches.
+func (h *EstimateHistogram) Min() int64 {
+	if len(h.sorted) == 0 {
+		return 0
+	}
+	return h.sorted[0]
+}
+
+// Max returns the largest estimate, or 0 if there are no sketches.
+func (h *EstimateHistogram) Max() int64 {
+	if len(h.sorted) == 0 {
+		return 0
+	}
+	return h.sorted[len(h.sorted)-1]
+}
+
+// Mean returns the mean estimate, or 0 if there are no sketches.
+func (h *EstimateHistogram) Mean() float64 {
+	if h.Count == 0 {
+		return 0
+	}
+	return float64(h.Sum) / float64(h.Count)
+}
+
+// Percentile returns the p-th percentile of the estimates, using the nearest-rank method.
+// p is clamped to [0, 100]. It returns 0 if there are no sketches.
+func (h *EstimateHistogram) Percentile(p float64) int64 {
+	n := len(h.sorted)
+	if n == 0 {
+		return 0
+	}
+
+	rank := int(math.Ceil(p / 100 * float64(n)))
+	if rank < 1 {
+		rank = 1
+	} else if rank > n {
+		rank = n
+	}
+	return h.sorted[rank-1]
+}
+
+// Histogram estimates each sketch once and summarizes the estimates, using up to
+// parallelism goroutines. If parallelism is <= 0, runtime.GOMAXPROCS(0) is used.
+//
+// Bounds are the inclusive upper bounds of the buckets and must be strictly increasing;
+// nil bounds default to powers of ten from 1 to 10^12. Nil sketches are ignored. Sketches
+// must not be modified concurrently and must not appear more than once, since estimating
+// caches the result in the sketch. Cancellation of ctx stops all workers and is returned.
+func Histogram(ctx context.Context, sketches []*HLL, bounds []int64, parallelism int) (*EstimateHistogram, error) {
+	if bounds == nil {
+		bounds = make([]int64, 13)
+		for i, b := 0, int64(1); i < len(bounds); i, b = i+1, b*10 {
+			bounds[i] = b
+		}
+	}
+	for i := 1; i < len(bounds); i++ {
+		if bounds[i] <= bounds[i-1] {
+			return nil, fmt.Errorf("invalid histogram bounds: %d is not greater than %d", bounds[i], bounds[i-1])
+		}
+	}
+
+	estimates, err := estimateAll(ctx, sketches, parallelism)
+	if err != nil {
+		return nil, err
+	}
+	sort.Slice(estimates, func(i, j int) bool { return estimates[i] < estimates[j] })
+
+	h := &EstimateHistogram{
+		Buckets: make([]HistogramBucket, len(bounds), len(bounds)+1),
+		Count:   len(estimates),
+		sorted:  estimates,
+	}
+	for i, b := range bounds {
+		h.Buckets[i].UpperBound = b
+	}
+	if len(bounds) == 0 || bounds[len(bounds)-1] != math.MaxInt64 {
+		h.Buckets = append(h.Buckets, HistogramBucket{UpperBound: math.MaxInt64})
+	}
+
+	i := 0
+	for _, e := range estimates {
+		h.Sum += e
+		for e > h.Buckets[i].UpperBound {
+			i++
+		}
+		h.Buckets[i].Count++
+	}
+	return h, nil
+}
+
+// estimateAll estimates all non-nil sketches in parallel.
+func estimateAll(ctx context.Context, sketches []*HLL, parallelism int) ([]int64, error) {
+	if parallelism <= 0 {
+		parallelism = runtime.GOMAXPROCS(0)
+	}
+	if n := (len(sketches) + histogramBatchSize - 1) / histogramBatchSize; parallelism > n {
+		parallelism = n
+	}
+
+	ctx, cancel := context.WithCancel(ctx)
+	defer cancel()
+
+	offsets := make(chan int)
+	go func() {
+		defer close(offsets)
+
+		for i := 0; i < len(sketches); i += histogramBatchSize {
+			select {
+			case offsets <- i:
+			case <-ctx.Done():
+				return
+			}
+		}
+	}()
+
+	// each worker writes to its own batches, nil sketches are marked with -1
+	estimates := make([]int64, len(sketches))
+	var wg sync.WaitGroup
+	for w := 0; w < parallelism; w++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+
+			for i := range offsets {
+				if ctx.Err() != nil {
+					return
+				}
+
+				j := i + histogramBatchSize
+				if j > len(sketches) {
+					j = len(sketches)
+				}
+				for k, s := range sketches[i:j] {
+					if s == nil {
+						estimates[i+k] = -1
+					} else {
+						estimates[i+k] = s.Estimate()
+					}
+				}
+			}
+		}()
+	}
+	wg.Wait()
+
+	if err := ctx.Err(); err != nil {
+		return nil, err
+	}
+
+	n := 0
+	for _, e := range estimates {
+		if e >= 0 {
+			estimates[n] = e
+			n++
+		}
+	}
+	return estimates[:n], nil
+}
@@ -0,0 +1,105 @@
+package hllplus_test
+
+import (
+	"context"
+	"math"
+	"testing"
+
+	"github.com/gowthamkommineni/zetasketch/hllplus"
+
+	. "github.com/bsm/ginkgo"
+	. "github.com/bsm/gomega"
+)
+
+var _ = Describe("Histogram", func() {
+	var sketches []*hllplus.HLL
+
+	BeforeEach(func() {
+		// sketch i contains i*10 distinct values
+		sketches = make([]*hllplus.HLL, 1_000)
+		for i := range sketches {
+			sketches[i], _ = hllplus.New(12, 17)
+			for v := 0; v < i*10; v++ {
+				sketches[i].AddInt64(int64(v))
+			}
+		}
+	})
+
+	It("should summarize estimates", func() {
+		for _, parallelism := range []int{0, 1, 3} {
+			h, err := hllplus.Histogram(context.Background(), sketches, []int64{0, 100, 1_000, 5_000}, parallelism)
+			Expect(err).NotTo(HaveOccurred())
+			Expect(h.Count).To(Equal(1_000))
+			Expect(h.Min()).To(BeZero())
+			Expect(h.Max()).To(BeNumerically("~", 9_990, 150))
+			Expect(h.Mean()).To(BeNumerically("~", 4_995, 50))
+			Expect(h.Percentile(50)).To(BeNumerically("~", 4_990, 100))
+			Expect(h.Percentile(99)).To(BeNumerically("~", 9_890, 150))
+			Expect(h.Percentile(0)).To(Equal(h.Min()))
+			Expect(h.Percentile(100)).To(Equal(h.Max()))
+
+			Expect(h.Buckets).To(HaveLen(5))
+			Expect(h.Buckets[0]).To(Equal(hllplus.HistogramBucket{UpperBound: 0, Count: 1}))
+			Expect(h.Buckets[1]).To(Equal(hllplus.HistogramBucket{UpperBound: 100, Count: 10}))
+			Expect(h.Buckets[2].Count).To(BeNumerically("~", 90, 2))
+			Expect(h.Buckets[3].Count).To(BeNumerically("~", 400, 20))
+			Expect(h.Buckets[4].UpperBound).To(Equal(int64(math.MaxInt64)))
+			Expect(h.Buckets[1].Count + h.Buckets[2].Count + h.Buckets[3].Count + h.Buckets[4].Count).To(Equal(999))
+		}
+	})
+
+	It("should default bounds", func() {
+		h, err := hllplus.Histogram(context.Background(), sketches, nil, 0)
+		Expect(err).NotTo(HaveOccurred())
+		Expect(h.Buckets).To(HaveLen(14))
+		Expect(h.Buckets[0]).To(Equal(hllplus.HistogramBucket{UpperBound: 1, Count: 1}))
+		Expect(h.Buckets[1]).To(Equal(hllplus.HistogramBucket{UpperBound: 10, Count: 1}))
+		Expect(h.Buckets[2]).To(Equal(hllplus.HistogramBucket{UpperBound: 100, Count: 9}))
+		Expect(h.Buckets[12].UpperBound).To(Equal(int64(1e12)))
+	})
+
+	It("should handle edge cases", func() {
+		h, err := hllplus.Histogram(context.Background(), nil, []int64{10}, 0)
+		Expect(err).NotTo(HaveOccurred())
+		Expect(h.Count).To(BeZero())
+		Expect(h.Percentile(50)).To(BeZero())
+		Expect(h.Mean()).To(BeZero())
+		Expect(h.Buckets).To(Equal([]hllplus.HistogramBucket{{UpperBound: 10}, {UpperBound: math.MaxInt64}}))
+
+		h, err = hllplus.Histogram(context.Background(), []*hllplus.HLL{nil, sketches[5], nil}, []int64{math.MaxInt64}, 0)
+		Expect(err).NotTo(HaveOccurred())
+		Expect(h.Count).To(Equal(1))
+		Expect(h.Sum).To(Equal(int64(50)))
+		Expect(h.Buckets).To(Equal([]hllplus.HistogramBucket{{UpperBound: math.MaxInt64, Count: 1}}))
+	})
+
+	It("should validate bounds", func() {
+		_, err := hllplus.Histogram(context.Background(), sketches, []int64{10, 10}, 0)
+		Expect(err).To(MatchError("invalid histogram bounds: 10 is not greater than 10"))
+	})
+
+	It("should respect cancellation", func() {
+		ctx, cancel := context.WithCancel(context.Background())
+		cancel()
+
+		_, err := hllplus.Histogram(ctx, sketches, nil, 4)
+		Expect(err).To(MatchError(context.Canceled))
+	})
+})
+
+func BenchmarkHistogram(b *testing.B) {
+	sketches := make([]*hllplus.HLL, 10_000)
+	for i := range sketches {
+		sketches[i], _ = hllplus.New(12, 17)
+		for v := 0; v < i%1_000; v++ {
+			sketches[i].AddInt64(int64(v))
+		}
+	}
+
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		if _, err := hllplus.Histogram(context.Background(), sketches, nil, 0); err != nil {
+			b.Fatal(err)
+		}
+	}
+}
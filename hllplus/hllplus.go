@@ -1,6 +1,8 @@
 package hllplus
 
 import (
+	"bytes"
+	"context"
 	"fmt"
 	"math"
 
@@ -14,36 +16,95 @@ const (
 	MaxSparsePrecision = 25
 )
 
+// ctxCheckInterval is the number of registers processed between checks for cancellation
+// by context-aware operations.
+const ctxCheckInterval = 1 << 16
+
+// Estimator identifies the algorithm used to estimate the cardinality of normal sketches.
+// Sparse sketches are always estimated using linear counting over the sparse buckets.
+type Estimator uint8
+
+// Supported estimators.
+const (
+	// EstimatorHLLPlusPlus uses linear counting for small cardinalities and corrects
+	// the bias of the raw HLL estimate as defined in the HLL++ paper (https://goo.gl/pc916Z).
+	// This is the default.
+	EstimatorHLLPlusPlus Estimator = iota
+	// EstimatorLogLogBeta uses the LogLog-Beta formula (https://arxiv.org/abs/1612.02284),
+	// which requires neither bias tables nor a switch to linear counting.
+	EstimatorLogLogBeta
+)
+
 // HLL is a HyperLogLog++ sketch implementation.
 type HLL struct {
 	normal []byte
 	sparse *sparseState
+	shared bool // normal is shared with a snapshot and must be copied before writing
+	pooled bool // normal was obtained from alloc
+	packed bool // normal registers are packed, see WithPackedRegisters
+	alloc  Allocator
 
 	precision       uint8
 	sparsePrecision uint8
+	numValues       int64
+
+	hasher     Hasher
+	seed       uint64
+	valueType  ValueType
+	estimator  Estimator
+	martingale *martingale
+	tally      *tally
+	exemplars  *exemplars
+	thresholds *thresholdState
+	normalized func()
+	biasTables map[uint8]*biasTable
+
+	// the last computed estimate, valid until the sketch is modified.
+	cache  EstimateDetails
+	cached bool
+
+	// the largest estimate reported in monotonic mode, see WithMonotonicEstimate.
+	monotonic bool
+	reported  int64
 }
 
 // New inits a new sketch.
 // The normal precision must be between 10 and 24.
 // The sparse precision must be between 0 and 25.
-// This function only returns an error when an invalid precision is provided.
-func New(precision, sparsePrecision uint8) (*HLL, error) {
+// This function only returns an error when an invalid precision or option is provided.
+func New(precision, sparsePrecision uint8, opts ...Option) (*HLL, error) {
 	if err := validate(precision, sparsePrecision); err != nil {
 		return nil, err
 	}
 
-	return &HLL{
+	h := &HLL{
 		precision:       precision,
 		sparsePrecision: sparsePrecision,
-		sparse:          newSparseState(precision, sparsePrecision, nil),
-	}, nil
+		sparse:          newSparseState(precision, sparsePrecision, nil, nil),
+	}
+	if err := h.apply(opts); err != nil {
+		return nil, err
+	}
+	return h, nil
 }
 
 // NewFromProto inits/restores a sketch from proto message.
-func NewFromProto(msg *pb.HyperLogLogPlusUniqueStateProto) (*HLL, error) {
+func NewFromProto(msg *pb.HyperLogLogPlusUniqueStateProto, opts ...Option) (*HLL, error) {
+	h, err := newFromProto(msg, false)
+	if err != nil {
+		return nil, err
+	}
+
+	if err := h.apply(opts); err != nil {
+		return nil, err
+	}
+	return h, nil
+}
+
+func newFromProto(msg *pb.HyperLogLogPlusUniqueStateProto, noCopy bool) (*HLL, error) {
 	precision := uint8(msg.GetPrecisionOrNumBuckets())
 	sparsePrecision := uint8(msg.GetSparsePrecisionOrNumBuckets())
-	if err := validate(precision, sparsePrecision); err != nil {
+	if err := validateProto(msg, precision, sparsePrecision); err != nil {
 		return nil, err
 	}
 
@@ -52,15 +113,33 @@ func NewFromProto(msg *pb.HyperLogLogPlusUniqueStateProto) (*HLL, error) {
 		sparsePrecision: sparsePrecision,
 	}
 
-	if len(msg.SparseData) > 0 {
-		h.sparse = newSparseState(precision, sparsePrecision, msg.SparseData)
-	} else {
+	// sketches without normal data are sparse, including empty ones
+	if len(msg.Data) != 0 {
 		h.normal = msg.Data
+	} else if noCopy {
+		h.sparse = newSparseState(precision, sparsePrecision, nil, nil)
+		h.sparse.data.SetDataNoCopy(msg.SparseData)
+	} else {
+		h.sparse = newSparseState(precision, sparsePrecision, msg.SparseData, nil)
 	}
-
 	return h, nil
 }
 
+// validateProto validates the precisions and the state of msg, so that malformed states
+// are rejected on restore rather than corrupting the sketch once it is modified.
+func validateProto(msg *pb.HyperLogLogPlusUniqueStateProto, precision, sparsePrecision uint8) error {
+	if err := validate(precision, sparsePrecision); err != nil {
+		return err
+	}
+	if len(msg.Data) != 0 {
+		if len(msg.Data) != 1<<precision {
+			return fmt.Errorf("invalid normal data: %d registers, expected %d", len(msg.Data), 1<<precision)
+		}
+		return nil
+	}
+	return validateSparseData(precision, sparsePrecision, msg.SparseData)
+}
+
 // Precision returns the normal precision.
 func (s *HLL) Precision() uint8 {
 	return s.precision
@@ -71,54 +150,152 @@ func (s *HLL) SparsePrecision() uint8 {
 	return s.sparsePrecision
 }
 
+// NumValues returns the number of values added to the sketch, including values
+// of merged sketches.
+func (s *HLL) NumValues() int64 {
+	return s.numValues
+}
+
+// MemoryUsage returns the number of bytes currently held by the normal registers, the
+// encoded sparse data and the buffers of the sketch, e.g. to enforce memory budgets.
+// Registers shared with a snapshot are accounted to both sketches. The fixed size of
+// the sketch struct itself is not included.
+func (s *HLL) MemoryUsage() int {
+	n := cap(s.normal)
+	if s.sparse != nil {
+		n += s.sparse.MemoryUsage()
+	}
+	return n
+}
+
 // Add adds the uniform hash value to the representation.
 func (s *HLL) Add(hash uint64) {
+	s.numValues++
 	if s.sparse != nil {
+		s.cached = false
 		if s.sparse.Add(hash); s.sparse.OverMax() {
 			s.normalize()
 		}
+		if s.thresholds != nil {
+			s.checkThresholds()
+		}
 		return
 	}
 
 	s.ensureNormal()
 	pos, rho := computePosRhoW(hash, s.precision)
-	if rho > s.normal[pos] {
-		s.normal[pos] = rho
+	if old := loadRegister(s.normal, s.packed, pos); rho > old {
+		if s.martingale != nil {
+			s.updateMartingale(old, rho)
+		}
+		if s.tally != nil {
+			s.updateTally(old, rho)
+		}
+		s.ownNormal()
+		storeRegister(s.normal, s.packed, pos, rho)
+		s.cached = false
+		if s.thresholds != nil {
+			s.checkThresholds()
+		}
 	}
 }
 
+// AddHashes adds multiple uniform hash values to the representation. It is
+// equivalent to calling Add for each value, but faster.
+func (s *HLL) AddHashes(hashes []uint64) {
+	if len(hashes) == 0 {
+		return
+	}
+	s.numValues += int64(len(hashes))
+	s.cached = false
+	if s.thresholds != nil {
+		defer s.checkThresholds()
+	}
+
+	for ; s.sparse != nil && len(hashes) != 0; hashes = hashes[1:] {
+		if s.sparse.Add(hashes[0]); s.sparse.OverMax() {
+			s.normalize()
+		}
+	}
+	if len(hashes) == 0 {
+		return
+	}
+
+	s.ensureNormal()
+	for _, hash := range hashes {
+		pos, rho := computePosRhoW(hash, s.precision)
+		if old := loadRegister(s.normal, s.packed, pos); rho > old {
+			if s.martingale != nil {
+				s.updateMartingale(old, rho)
+			}
+			if s.tally != nil {
+				s.updateTally(old, rho)
+			}
+			s.ownNormal()
+			storeRegister(s.normal, s.packed, pos, rho)
+		}
+	}
+}
+
+// Add128 adds a uniform 128-bit hash value to the representation. The value is
+// folded into 64 bits by XOR-ing both halves (hi ^ lo), which preserves
+// uniformity and matches the folding used by the Murmur3 hasher.
+func (s *HLL) Add128(hi, lo uint64) {
+	s.Add(hi ^ lo)
+}
+
 // Merge merges other into s.
-func (s *HLL) Merge(other *HLL) {
+// It returns an error if the sketches were built using different hashers, seeds or value types.
+func (s *HLL) Merge(other *HLL) error {
+	if err := s.checkMergeable(other); err != nil {
+		return err
+	}
+	s.setValueType(other.valueType)
+	s.numValues += other.numValues
+	s.exemplars.merge(other.exemplars)
+	if s.monotonic && other.reported > s.reported {
+		s.reported = other.reported
+	}
+	if s.thresholds != nil {
+		defer s.checkThresholds()
+	}
+
 	// Skip if there is nothing to merge.
 	if len(other.normal) == 0 && other.sparse == nil {
-		return
+		return nil
 	}
+	s.cached = false
+	s.resetMartingale()
+	s.resetTally()
 
-	// FIXME: allow sparse merge
-	if s.sparse != nil {
+	// Normalize receiver if other is normal.
+	if s.sparse != nil && other.sparse == nil {
 		s.normalize()
 	}
-	if other.sparse != nil {
-		other = other.Clone()
-		other.normalize()
+
+	// If other precision is lower, downgrade.
+	_ = s.Downgrade(other.precision, other.sparsePrecision)
+
+	// Merge sparse into sparse, only normalize when the combined size exceeds the threshold.
+	if s.sparse != nil {
+		if s.sparse.Merge(other.sparse); s.sparse.OverMax() {
+			s.normalize()
+		}
+		return nil
 	}
 
-	// Make sure receiver is allocated.
+	// Make sure receiver is allocated and writable.
 	s.ensureNormal()
+	s.ownNormal()
 
-	// If other precision is higher.
-	if s.precision < other.precision {
+	// If other precision is higher, other is sparse or either is packed.
+	if s.precision < other.precision || other.sparse != nil || s.packed || other.packed {
 		other.downgradeEach(s.precision, func(pos uint32, rhoW uint8) {
-			if s.normal[pos] < rhoW {
-				s.normal[pos] = rhoW
+			if loadRegister(s.normal, s.packed, pos) < rhoW {
+				storeRegister(s.normal, s.packed, pos, rhoW)
 			}
 		})
-		return
-	}
-
-	// If other precision is lower, downgrade.
-	if s.precision > other.precision {
-		_ = s.Downgrade(other.precision, other.sparsePrecision)
+		return nil
 	}
 
 	// Use largest rhoW.
@@ -127,6 +304,29 @@ func (s *HLL) Merge(other *HLL) {
 			s.normal[i] = rho
 		}
 	}
+	return nil
+}
+
+// checkMergeable ensures that other can be merged into s.
+func (s *HLL) checkMergeable(other *HLL) error {
+	if err := s.checkHasher(other); err != nil {
+		return err
+	}
+	if s.valueType != other.valueType && s.valueType != ValueTypeUnknown && other.valueType != ValueTypeUnknown {
+		return fmt.Errorf("cannot merge sketches with different value types %s and %s", s.valueType, other.valueType)
+	}
+	return nil
+}
+
+// checkHasher ensures that both sketches hash values alike.
+func (s *HLL) checkHasher(other *HLL) error {
+	if id, otherID := s.Hasher().ID(), other.Hasher().ID(); id != otherID {
+		return fmt.Errorf("cannot merge sketches with different hashers %q and %q", id, otherID)
+	}
+	if s.seed != other.seed {
+		return fmt.Errorf("cannot merge sketches with different seeds %d and %d", s.seed, other.seed)
+	}
+	return nil
 }
 
 // Clone creates a copy of the sketch.
@@ -134,88 +334,416 @@ func (s *HLL) Clone() *HLL {
 	clone := &HLL{
 		precision:       s.precision,
 		sparsePrecision: s.sparsePrecision,
+		numValues:       s.numValues,
 		sparse:          s.sparse.Clone(),
+		hasher:          s.hasher,
+		seed:            s.seed,
+		valueType:       s.valueType,
+		estimator:       s.estimator,
+		biasTables:      s.biasTables,
+		cache:           s.cache,
+		cached:          s.cached,
+		martingale:      s.martingale.Clone(),
+		tally:           s.tally.Clone(),
+		exemplars:       s.exemplars.Clone(),
+		alloc:           s.alloc,
+		packed:          s.packed,
+		monotonic:       s.monotonic,
+		reported:        s.reported,
 	}
 	if len(s.normal) != 0 {
-		clone.normal = make([]byte, len(s.normal))
+		clone.normal = clone.allocNormal(s.precision)
+		clone.pooled = s.alloc != nil
 		copy(clone.normal, s.normal)
 	}
 	return clone
 }
 
+// Snapshot returns a copy of the sketch, which can be estimated and serialized while the
+// original keeps being modified. Unlike Clone, it is cheap: normal registers are shared
+// and only copied by whichever sketch is modified first. Sparse sketches are small and
+// always copied.
+//
+// Snapshot must not be called concurrently with modifications of the sketch, but the
+// returned snapshot may be used by another goroutine while the original is modified.
+func (s *HLL) Snapshot() *HLL {
+	snap := &HLL{
+		precision:       s.precision,
+		sparsePrecision: s.sparsePrecision,
+		numValues:       s.numValues,
+		sparse:          s.sparse.Clone(),
+		hasher:          s.hasher,
+		seed:            s.seed,
+		valueType:       s.valueType,
+		estimator:       s.estimator,
+		biasTables:      s.biasTables,
+		cache:           s.cache,
+		cached:          s.cached,
+		martingale:      s.martingale.Clone(),
+		tally:           s.tally.Clone(),
+		exemplars:       s.exemplars.Clone(),
+		alloc:           s.alloc,
+		packed:          s.packed,
+		monotonic:       s.monotonic,
+		reported:        s.reported,
+	}
+	if len(s.normal) != 0 {
+		snap.normal = s.normal
+		snap.shared, s.shared = true, true
+	}
+	return snap
+}
+
+// watch returns a function which reports whether the registers of s changed since watch
+// was called. Like Snapshot, it marks the current normal registers as shared, so they are
+// copied rather than modified in place, and likewise keeps sparse data from being
+// recycled. The returned function restores both, so it must be called exactly once and s
+// must not be snapshotted in between. Conversions to another precision or representation
+// count as changes.
+func (s *HLL) watch() func() bool {
+	precision, sparsePrecision := s.precision, s.sparsePrecision
+
+	if s.sparse != nil {
+		s.sparse.Flush()
+		sparse, data := s.sparse, s.sparse.data
+		shared := data.shared
+		data.shared = true
+
+		return func() bool {
+			current := s.sparse == sparse && s.precision == precision && s.sparsePrecision == sparsePrecision
+			if current {
+				sparse.Flush()
+				if sparse.data == data {
+					data.shared = shared
+					return false
+				}
+			}
+			changed := !current || !bytes.Equal(sparse.data.Bytes(), data.Bytes())
+
+			// data was replaced, release it unless it is external
+			if data.shared = shared; !shared {
+				data.Release()
+			}
+			return changed
+		}
+	}
+
+	normal, pooled, shared := s.normal, s.pooled, s.shared
+	s.shared = len(normal) != 0
+
+	return func() bool {
+		switch {
+		case len(normal) == 0:
+			return s.sparse != nil || len(s.normal) != 0
+		case s.sparse == nil && len(s.normal) != 0 && &s.normal[0] == &normal[0]:
+			s.shared = shared
+			return false
+		}
+
+		// normal was replaced, release it unless it is shared with a snapshot
+		changed := s.sparse != nil || s.precision != precision || !bytes.Equal(s.normal, normal)
+		if !shared {
+			s.releaseNormal(normal, pooled)
+		}
+		return changed
+	}
+}
+
 // Estimate computes the cardinality estimate according to the algorithm in Figure 6 of the HLL++ paper
-// (https://goo.gl/pc916Z).
+// (https://goo.gl/pc916Z). The result is cached until the sketch is modified.
 func (s *HLL) Estimate() int64 {
+	return s.estimate().Estimate
+}
+
+// EstimateReadOnly is like Estimate, but never modifies the sketch: buffered sparse
+// values are merged on the fly and the result is not cached. Unlike Estimate, it may be
+// called concurrently, e.g. under a read lock, as long as the sketch is not modified.
+func (s *HLL) EstimateReadOnly() int64 {
+	return s.estimateReadOnly().Estimate
+}
+
+// estimateReadOnly is like estimate, but never modifies the sketch, see EstimateReadOnly.
+func (s *HLL) estimateReadOnly() EstimateDetails {
+	var d EstimateDetails
+	switch {
+	case s.cached:
+		return s.cache
+	case s.sparse != nil:
+		n := s.sparse.Count()
+		d = EstimateDetails{Estimate: s.sparse.linearCount(n), NumZeros: 1<<s.sparsePrecision - n, LinearCounting: true}
+	case s.martingale != nil && s.martingale.seeded && len(s.normal) != 0:
+		d = EstimateDetails{Estimate: int64(s.martingale.estimate + 0.5)}
+	default:
+		d = s.estimateNormal(s.estimator)
+	}
+	d.Estimate = s.monotonicEstimate(d.Estimate)
+	return d
+}
+
+// monotonicEstimate raises est to the largest estimate reported so far in monotonic
+// mode. It does not record est, this is done when the estimate is cached.
+func (s *HLL) monotonicEstimate(est int64) int64 {
+	if s.monotonic && est < s.reported {
+		return s.reported
+	}
+	return est
+}
+
+// EstimateWith computes the cardinality estimate using a specific estimator, bypassing
+// the one the sketch was configured with. This is useful for cross-validation.
+func (s *HLL) EstimateWith(e Estimator) int64 {
 	if s.sparse != nil {
-		s.sparse.Flush()
-		return s.sparse.Estimate()
+		return s.estimateSparse().Estimate
 	}
+	return s.estimateNormal(e).Estimate
+}
 
+// EstimateWithBounds returns the cardinality estimate together with lower and upper bounds
+// which are stdDevs standard errors away from it. The standard error depends on the
+// estimator in use: linear counting (for sparse sketches and small cardinalities)
+// or the bias-corrected HLL++ estimate with a relative error of 1.04 / sqrt(2^precision).
+func (s *HLL) EstimateWithBounds(stdDevs int) (est, lower, upper int64) {
+	d := s.estimate()
+	delta := int64(math.Ceil(float64(stdDevs) * s.stdError(d.Estimate, d.LinearCounting)))
+	if lower = d.Estimate - delta; lower < 0 {
+		lower = 0
+	}
+	return d.Estimate, lower, d.Estimate + delta
+}
+
+// EstimateDetails contains diagnostic details of a cardinality estimate.
+type EstimateDetails struct {
+	// Estimate is the final cardinality estimate.
+	Estimate int64
+	// Raw is the raw harmonic mean estimate, designated by E in the HLL++ paper.
+	// It is 0 for sparse sketches.
+	Raw float64
+	// NumZeros is the number of zero registers or, for sparse sketches,
+	// the number of empty sparse buckets.
+	NumZeros int
+	// LinearCounting reports whether linear counting was used.
+	LinearCounting bool
+	// Bias is the bias correction subtracted from the raw estimate. For the
+	// LogLog-Beta estimator, it is the effective difference between Raw and Estimate.
+	Bias float64
+}
+
+// EstimateDetails computes the cardinality estimate and returns details about the
+// computation, for diagnostics and validation against other implementations. The
+// martingale estimator is bypassed, details are always computed from the registers.
+func (s *HLL) EstimateDetails() EstimateDetails {
+	if s.sparse != nil {
+		return s.estimateSparse()
+	}
+	return s.estimateNormal(s.estimator)
+}
+
+func (s *HLL) estimate() EstimateDetails {
+	if !s.cached {
+		s.cache = s.estimateUncached()
+		s.cached = true
+
+		if s.monotonic {
+			if s.cache.Estimate < s.reported {
+				s.cache.Estimate = s.reported
+			}
+			s.reported = s.cache.Estimate
+		}
+	}
+	return s.cache
+}
+
+func (s *HLL) estimateUncached() EstimateDetails {
+	if s.sparse != nil {
+		return s.estimateSparse()
+	}
+
+	if s.tally != nil && !s.tally.seeded && len(s.normal) != 0 {
+		s.seedTally()
+	}
+	if s.martingale != nil && len(s.normal) != 0 {
+		if !s.martingale.seeded {
+			s.seedMartingale()
+		}
+		return EstimateDetails{Estimate: int64(s.martingale.estimate + 0.5)}
+	}
+	return s.estimateNormal(s.estimator)
+}
+
+// estimateSparse computes the estimate of the sparse representation.
+func (s *HLL) estimateSparse() EstimateDetails {
+	s.sparse.Flush()
+	return EstimateDetails{
+		Estimate:       s.sparse.Estimate(),
+		NumZeros:       1<<s.sparsePrecision - s.sparse.data.Count(),
+		LinearCounting: true,
+	}
+}
+
+// estimateNormal computes the estimate of the normal representation.
+func (s *HLL) estimateNormal(e Estimator) EstimateDetails {
 	if len(s.normal) == 0 {
-		return 0
+		return EstimateDetails{NumZeros: 1 << s.precision, LinearCounting: true}
 	}
 
 	// Compute the summation component of the harmonic mean for the HLL++ algorithm while also
 	// keeping track of the number of zeros in case we need to apply LinearCounting instead.
-	numZeros := 0
-	sum := 0.0
+	numZeros, sum := s.normalStats()
+	return s.estimateStats(e, numZeros, sum)
+}
 
-	for _, c := range s.normal {
-		if c == 0 {
-			numZeros++
-		}
+// estimateStats computes the estimate from the number of zero registers and the sum of
+// 2^-rhoW over all registers at the normal precision.
+func (s *HLL) estimateStats(e Estimator, numZeros int, sum float64) EstimateDetails {
+	m := float64(uint64(1) << s.precision)
 
-		// Compute sum += math.pow(2, -v) without actually performing a floating point exponent
-		// computation (which is expensive). v can be at most 64 - precision + 1 and the minimum
-		// precision is larger than 2 (see MINIMUM_PRECISION), so this left shift can not overflow.
-		x := 1 << c
-		sum += 1.0 / float64(x)
+	// The "raw" estimate, designated by E in the HLL++ paper (https://goo.gl/pc916Z).
+	d := EstimateDetails{
+		Raw:      alpha(s.precision) * m * m / sum,
+		NumZeros: numZeros,
+	}
+
+	if e == EstimatorLogLogBeta {
+		d.Estimate = s.estimateLogLogBeta(numZeros, sum)
+		d.Bias = d.Raw - float64(d.Estimate)
+		return d
 	}
 
 	// Return the LinearCount for small cardinalities where, as explained in the HLL++ paper
 	// (https://goo.gl/pc916Z), the results with LinearCount tend to be more accurate than with HLL.
-	x := 1 << s.precision
-	m := float64(x)
 	if numZeros != 0 {
 		n := int64(m*math.Log(m/float64(numZeros)) + 0.5)
 		if n <= linearCountingThreshold(s.precision) {
-			return n
+			d.Estimate = n
+			d.LinearCounting = true
+			return d
 		}
 	}
 
-	// The "raw" estimate, designated by E in the HLL++ paper (https://goo.gl/pc916Z).
-	raw := alpha(s.precision) * m * m / sum
-
 	// Perform bias correction on small estimates. HyperLogLogPlusPlusData only contains bias
 	// estimates for small cardinalities and returns 0 for anything else, so the "E < 5m" guard from
 	// the HLL++ paper (https://goo.gl/pc916Z) is superfluous here.
-	return int64(raw - estimateBias(raw, s.precision) + 0.5)
+	d.Bias = estimateBias(d.Raw, s.biasTable())
+	d.Estimate = int64(d.Raw - d.Bias + 0.5)
+	return d
+}
+
+// biasTable returns the bias correction table for the current precision.
+func (s *HLL) biasTable() *biasTable {
+	if table, ok := s.biasTables[s.precision]; ok {
+		return table
+	}
+	return builtinBiasTable(s.precision)
+}
+
+// stdError returns the theoretical absolute standard error of estimate n.
+func (s *HLL) stdError(n int64, linearCounting bool) float64 {
+	if !linearCounting {
+		return relativeError(s.precision) * float64(n)
+	}
+
+	// Standard error of linear counting, as derived in "A Linear-Time Probabilistic
+	// Counting Algorithm for Database Applications" (Whang et al.), over the number
+	// of buckets used by the respective representation.
+	precision := s.precision
+	if s.sparse != nil {
+		precision = s.sparsePrecision
+	}
+	m := float64(uint64(1) << precision)
+	t := float64(n) / m
+	return math.Sqrt(m * (math.Exp(t) - t - 1))
+}
+
+// relativeError returns the theoretical relative standard error of HLL++ for the given precision.
+func relativeError(precision uint8) float64 {
+	return 1.04 / math.Sqrt(float64(uint64(1)<<precision))
 }
 
 // Downgrade tries to reduce the precision of the sketch.
 // Attempts to increase precision will be ignored.
+// Sparse sketches are normalized if the downgraded data exceeds the sparse threshold.
 func (s *HLL) Downgrade(precision, sparsePrecision uint8) error {
+	return s.DowngradeContext(context.Background(), precision, sparsePrecision)
+}
+
+// DowngradeContext is like Downgrade, but periodically checks ctx while downgrading
+// normal registers. On cancellation, the sketch is left unchanged and ctx.Err() is
+// returned.
+func (s *HLL) DowngradeContext(ctx context.Context, precision, sparsePrecision uint8) error {
 	if err := validate(precision, sparsePrecision); err != nil {
 		return err
 	}
 
-	// TODO: downgrade sparse as well (and don't forget a switch between normal and sparse)
+	if precision > s.precision {
+		precision = s.precision
+	}
+	if sparsePrecision > s.sparsePrecision {
+		sparsePrecision = s.sparsePrecision
+	}
 
-	if s.precision > precision {
-		if len(s.normal) != 0 {
-			normal := make([]byte, 1<<precision)
-			s.downgradeEach(precision, func(pos uint32, rhoW uint8) {
-				if normal[pos] < rhoW {
-					normal[pos] = rhoW
-				}
-			})
-			s.normal = normal
+	if s.sparse != nil {
+		if s.precision != precision || s.sparsePrecision != sparsePrecision {
+			converted := s.sparse.Convert(precision, sparsePrecision)
+			s.sparse.Release()
+			s.sparse = converted
 		}
-		s.precision = precision
+	} else if s.precision != precision && len(s.normal) != 0 {
+		normal := s.allocNormal(precision)
+		if err := s.downgradeEachContext(ctx, precision, func(pos uint32, rhoW uint8) {
+			if loadRegister(normal, s.packed, pos) < rhoW {
+				storeRegister(normal, s.packed, pos, rhoW)
+			}
+		}); err != nil {
+			s.releaseNormal(normal, s.alloc != nil)
+			return err
+		}
+		s.replaceNormal(normal, s.alloc != nil)
+	}
+	s.cached = false
+	s.resetMartingale()
+	s.resetTally()
+
+	s.precision = precision
+	s.sparsePrecision = sparsePrecision
+
+	// Switch to normal representation if downgraded data exceeds the threshold.
+	if s.sparse != nil && s.sparse.OverMax() {
+		s.normalize()
+	}
+	return nil
+}
+
+// Upgrade increases the normal precision of a sparse sketch, up to its sparse precision.
+// Sparse sketches retain hash positions at sparse precision, so this is lossless for
+// the sparse estimate. The only exception are rhoW values of hashes with an all-zero
+// suffix in the now normal part of the index, for which the lower bound is assumed.
+// Attempts to decrease precision will be ignored. Normal sketches cannot be upgraded.
+func (s *HLL) Upgrade(precision uint8) error {
+	if err := validate(precision, s.sparsePrecision); err != nil {
+		return err
+	}
+	if precision <= s.precision {
+		return nil
+	}
+	if s.sparse == nil && len(s.normal) != 0 {
+		return fmt.Errorf("cannot upgrade normal representation")
 	}
 
-	if s.sparsePrecision > sparsePrecision {
-		s.sparsePrecision = sparsePrecision
+	if s.sparse != nil {
+		converted := s.sparse.Convert(precision, s.sparsePrecision)
+		s.sparse.Release()
+		s.sparse = converted
+	}
+	s.precision = precision
+	s.cached = false
+	return nil
+}
+
+func (s *HLL) apply(opts []Option) error {
+	for _, opt := range opts {
+		if err := opt(s); err != nil {
+			return err
+		}
 	}
 	return nil
 }
@@ -227,25 +755,66 @@ func (s *HLL) normalize() {
 
 	s.ensureNormal()
 	s.sparse.Iterate(func(pos uint32, rhoW uint8) {
-		if rhoW > s.normal[pos] {
-			s.normal[pos] = rhoW
+		if rhoW > loadRegister(s.normal, s.packed, pos) {
+			storeRegister(s.normal, s.packed, pos, rhoW)
 		}
 	})
+	s.sparse.Release()
 	s.sparse = nil
+
+	if s.normalized != nil {
+		s.normalized()
+	}
 }
 
 func (s *HLL) ensureNormal() {
 	if len(s.normal) == 0 {
-		s.normal = make([]byte, 1<<s.precision)
+		s.replaceNormal(s.allocNormal(s.precision), s.alloc != nil)
+	}
+}
+
+// ownNormal copies normal registers which are shared with a snapshot.
+func (s *HLL) ownNormal() {
+	if s.shared {
+		normal := s.allocNormal(s.precision)
+		copy(normal, s.normal)
+		s.replaceNormal(normal, s.alloc != nil)
 	}
 }
 
 func (s *HLL) downgradeEach(targetPrecision uint8, iter func(uint32, uint8)) {
-	for pos, rho := range s.normal {
+	_ = s.downgradeEachContext(context.Background(), targetPrecision, iter)
+}
+
+// downgradeEachContext is like downgradeEach, but checks ctx every ctxCheckInterval
+// normal registers. Sparse data is small and never interrupted.
+func (s *HLL) downgradeEachContext(ctx context.Context, targetPrecision uint8, iter func(uint32, uint8)) error {
+	if s.sparse != nil {
+		s.sparse.Iterate(func(pos uint32, rhoW uint8) {
+			pos2 := pos >> (s.precision - targetPrecision)
+			rho2 := normalDowngrade(int(pos), rhoW, s.precision, targetPrecision)
+			iter(pos2, rho2)
+		})
+		return nil
+	}
+
+	var n int
+	if len(s.normal) != 0 {
+		n = 1 << s.precision
+	}
+	for pos := 0; pos < n; pos++ {
+		if pos%ctxCheckInterval == 0 {
+			if err := ctx.Err(); err != nil {
+				return err
+			}
+		}
+
+		rho := loadRegister(s.normal, s.packed, uint32(pos))
 		pos2 := pos >> (s.precision - targetPrecision)
 		rho2 := normalDowngrade(pos, rho, s.precision, targetPrecision)
 		iter(uint32(pos2), rho2)
 	}
+	return nil
 }
 
 func validate(precision, sparsePrecision uint8) error {
@@ -262,6 +831,8 @@ func validate(precision, sparsePrecision uint8) error {
 }
 
 // Proto builds a BigQuery-compatible protobuf message, representing HLL aggregator state.
+// Sparse data is always sorted and contains at most one value per sparse index, so
+// sketches with the same state produce identical messages.
 func (s *HLL) Proto() *pb.HyperLogLogPlusUniqueStateProto {
 	// both precisions must always be marshalled:
 	precision := int32(s.precision)
@@ -277,7 +848,7 @@ func (s *HLL) Proto() *pb.HyperLogLogPlusUniqueStateProto {
 		msg.SparseSize = &size32 // populated to be compatible with zetasketch/BigQuery
 		msg.SparseData = data
 	} else {
-		msg.Data = s.normal
+		msg.Data = s.unpackedNormal()
 	}
 	return msg
 }
@@ -1,7 +1,9 @@
 package hllplus_test
 
 import (
+	"context"
 	"math/rand"
+	"sync"
 	"testing"
 
 	"github.com/gowthamkommineni/zetasketch/hllplus"
@@ -49,7 +51,7 @@ var _ = Describe("HLL", func() {
 			Expect(subject.Estimate()).To(Equal(int64(exp)))
 		},
 		Entry("p=16", 16, 795),
-		Entry("p=17", 17, 798),
+		Entry("p=17", 17, 797),
 		Entry("p=18", 18, 799),
 		Entry("p=19", 19, 799),
 		Entry("p=20", 20, 799),
@@ -84,6 +86,32 @@ var _ = Describe("HLL", func() {
 		Entry("p=24", 24, 200026),
 	)
 
+	DescribeTable("estimate normal LogLog-Beta (200k unique)",
+		func(p int, exp int) {
+			subject, _ = hllplus.NewNormal(uint8(p), hllplus.WithEstimator(hllplus.EstimatorLogLogBeta))
+			for i := 0; i < 200_000; i++ {
+				subject.Add(rnd.Uint64())
+			}
+			Expect(subject.IsSparse()).To(BeFalse())
+			Expect(subject.Estimate()).To(Equal(int64(exp)))
+		},
+		Entry("p=10", 10, 199197),
+		Entry("p=11", 11, 204855),
+		Entry("p=12", 12, 204356),
+		Entry("p=13", 13, 202958),
+		Entry("p=14", 14, 202977),
+		Entry("p=15", 15, 201582),
+		Entry("p=16", 16, 201215),
+		Entry("p=17", 17, 200940),
+		Entry("p=18", 18, 200861),
+		Entry("p=19", 19, 200552),
+		Entry("p=20", 20, 200029),
+		Entry("p=21", 21, 199996),
+		Entry("p=22", 22, 199991),
+		Entry("p=23", 23, 199986),
+		Entry("p=24", 24, 200034),
+	)
+
 	DescribeTable("estimate sparse (200k unique)",
 		func(p int, exp int) {
 			subject, _ = hllplus.New(uint8(p-5), uint8(p))
@@ -93,8 +121,8 @@ var _ = Describe("HLL", func() {
 			Expect(subject.IsSparse()).To(BeTrue())
 			Expect(subject.Estimate()).To(Equal(int64(exp)))
 		},
-		Entry("p=24", 24, 200039),
-		Entry("p=25", 25, 200048),
+		Entry("p=24", 24, 200026),
+		Entry("p=25", 25, 200035),
 	)
 
 	DescribeTable("estimate normal (100k unique + 2x50k)",
@@ -142,9 +170,9 @@ var _ = Describe("HLL", func() {
 			Expect(subject.IsSparse()).To(BeTrue())
 			Expect(subject.Estimate()).To(Equal(int64(exp)))
 		},
-		Entry("p=23", 23, 149969),
-		Entry("p=24", 24, 149998),
-		Entry("p=25", 25, 150012),
+		Entry("p=23", 23, 149946),
+		Entry("p=24", 24, 149988),
+		Entry("p=25", 25, 150004),
 	)
 
 	It("should estimate sparse (repetitive)", func() {
@@ -160,17 +188,325 @@ var _ = Describe("HLL", func() {
 		Expect(subject.IsSparse()).To(BeTrue())
 	})
 
+	It("should add hashes in batches", func() {
+		hashes := make([]uint64, 20_000)
+		for i := range hashes {
+			hashes[i] = rnd.Uint64()
+		}
+
+		subject, _ = hllplus.New(12, 17)
+		expected, _ := hllplus.New(12, 17)
+		subject.AddHashes(hashes[:1_000])
+		for _, h := range hashes[:1_000] {
+			expected.Add(h)
+		}
+		Expect(subject.IsSparse()).To(BeTrue())
+		Expect(subject.Proto()).To(Equal(expected.Proto()))
+
+		subject.AddHashes(hashes[1_000:])
+		for _, h := range hashes[1_000:] {
+			expected.Add(h)
+		}
+		Expect(subject.IsSparse()).To(BeFalse())
+		Expect(subject.Proto()).To(Equal(expected.Proto()))
+		Expect(subject.Estimate()).To(Equal(expected.Estimate()))
+	})
+
+	It("should not allocate when adding", func() {
+		hashes := make([]uint64, 2_000)
+		for i := range hashes {
+			hashes[i] = rnd.Uint64()
+		}
+
+		// sparse, once buffers have grown to their working size
+		subject, _ = hllplus.New(14, 19)
+		subject.AddHashes(hashes)
+		subject.AddHashes(hashes)
+		Expect(subject.IsSparse()).To(BeTrue())
+		Expect(testing.AllocsPerRun(10, func() {
+			for _, h := range hashes {
+				subject.Add(h)
+			}
+		})).To(BeZero())
+		Expect(testing.AllocsPerRun(10, func() { subject.AddHashes(hashes) })).To(BeZero())
+		Expect(subject.IsSparse()).To(BeTrue())
+
+		// normal
+		subject, _ = hllplus.NewNormal(14)
+		Expect(testing.AllocsPerRun(10, func() {
+			for _, h := range hashes {
+				subject.Add(h)
+			}
+		})).To(BeZero())
+
+		// values, using built-in hashers
+		for _, opt := range []hllplus.Option{hllplus.WithHasher(hllplus.Fingerprint2011), hllplus.WithHasher(hllplus.XXHash64)} {
+			subject, _ = hllplus.NewNormal(14, opt)
+			Expect(testing.AllocsPerRun(10, func() { subject.AddInt64(-7) })).To(BeZero())
+			subject, _ = hllplus.NewNormal(14, opt)
+			Expect(testing.AllocsPerRun(10, func() { subject.AddUint64(7) })).To(BeZero())
+			subject, _ = hllplus.NewNormal(14, opt)
+			Expect(testing.AllocsPerRun(10, func() { subject.AddFloat64(0.7) })).To(BeZero())
+			subject, _ = hllplus.NewNormal(14, opt)
+			Expect(testing.AllocsPerRun(10, func() { subject.AddString("value") })).To(BeZero())
+		}
+	})
+
+	It("should report memory usage", func() {
+		subject, _ = hllplus.New(12, 17)
+		Expect(subject.MemoryUsage()).To(BeNumerically(">", 0))
+
+		for i := 0; i < 1_000; i++ {
+			subject.Add(rnd.Uint64())
+		}
+		Expect(subject.IsSparse()).To(BeTrue())
+		sparse := subject.MemoryUsage()
+		Expect(sparse).To(BeNumerically(">", 1_000))
+
+		for i := 0; i < 5_000; i++ {
+			subject.Add(rnd.Uint64())
+		}
+		Expect(subject.IsSparse()).To(BeFalse())
+		Expect(subject.MemoryUsage()).To(Equal(4_096))
+
+		packed, _ := hllplus.NewNormal(12, hllplus.WithPackedRegisters())
+		packed.Add(1)
+		Expect(packed.MemoryUsage()).To(Equal(3_072))
+	})
+
+	It("should estimate with a specific estimator", func() {
+		subject, _ = hllplus.NewNormal(16)
+		for i := 0; i < 800; i++ {
+			subject.Add(rnd.Uint64())
+		}
+		Expect(subject.Estimate()).To(Equal(int64(795)))
+		Expect(subject.EstimateWith(hllplus.EstimatorHLLPlusPlus)).To(Equal(int64(795)))
+		Expect(subject.EstimateWith(hllplus.EstimatorLogLogBeta)).To(Equal(int64(795)))
+
+		for i := 0; i < 30_000; i++ {
+			subject.Add(rnd.Uint64())
+		}
+		Expect(subject.Estimate()).To(Equal(int64(30780)))
+		Expect(subject.EstimateWith(hllplus.EstimatorLogLogBeta)).To(Equal(int64(30804)))
+	})
+
+	It("should reject invalid estimators", func() {
+		_, err := hllplus.New(12, 17, hllplus.WithEstimator(9))
+		Expect(err).To(MatchError("invalid estimator 9"))
+	})
+
+	It("should return estimate details", func() {
+		subject, _ = hllplus.New(12, 17)
+		for i := 0; i < 1_000; i++ {
+			subject.Add(rnd.Uint64())
+		}
+		Expect(subject.EstimateDetails()).To(Equal(hllplus.EstimateDetails{
+			Estimate:       997,
+			NumZeros:       130_079,
+			LinearCounting: true,
+		}))
+
+		for i := 0; i < 2_500; i++ {
+			subject.Add(rnd.Uint64())
+		}
+		d := subject.EstimateDetails()
+		Expect(d.Estimate).To(Equal(int64(3_467)))
+		Expect(d.Raw).To(BeNumerically("~", 4_952.4, 0.1))
+		Expect(d.NumZeros).To(Equal(1_751))
+		Expect(d.LinearCounting).To(BeFalse())
+		Expect(d.Bias).To(BeNumerically("~", 1_485.2, 0.1))
+
+		for i := 0; i < 10_000; i++ {
+			subject.Add(rnd.Uint64())
+		}
+		d = subject.EstimateDetails()
+		Expect(d.Estimate).To(Equal(int64(13_427)))
+		Expect(d.Raw).To(BeNumerically("~", 13_499.8, 0.1))
+		Expect(d.NumZeros).To(Equal(148))
+		Expect(d.LinearCounting).To(BeFalse())
+		Expect(d.Bias).To(BeNumerically("~", 73.0, 0.1))
+	})
+
+	It("should estimate with custom bias data", func() {
+		subject, _ = hllplus.New(12, 17, hllplus.WithBiasData(12,
+			[]float64{4_000, 4_500, 5_000, 5_500, 6_000, 6_500},
+			[]float64{500, 500, 500, 500, 500, 500},
+		))
+		for i := 0; i < 3_500; i++ {
+			subject.Add(rnd.Uint64())
+		}
+
+		d := subject.EstimateDetails()
+		Expect(d.Raw).To(BeNumerically("~", 4_952.4, 0.1))
+		Expect(d.Bias).To(BeNumerically("~", 500, 0.001))
+		Expect(d.Estimate).To(Equal(int64(4_452)))
+
+		// custom bias data is only used for matching precision:
+		Expect(subject.Downgrade(11, 16)).To(Succeed())
+		Expect(subject.EstimateDetails().Bias).To(BeNumerically("~", 307.7, 0.1))
+	})
+
+	It("should reject invalid bias data", func() {
+		_, err := hllplus.New(12, 17, hllplus.WithBiasData(9, nil, nil))
+		Expect(err).To(MatchError("invalid bias data precision 9"))
+
+		_, err = hllplus.New(12, 17, hllplus.WithBiasData(12, []float64{1, 2}, []float64{1}))
+		Expect(err).To(MatchError("invalid bias data: 2 raw estimates but 1 biases"))
+
+		_, err = hllplus.New(12, 17, hllplus.WithBiasData(12, []float64{1, 2}, []float64{1, 2}))
+		Expect(err).To(MatchError("invalid bias data: at least 6 values are required"))
+
+		_, err = hllplus.New(12, 17, hllplus.WithBiasData(12, []float64{1, 2, 3, 5, 4, 6}, []float64{1, 2, 3, 4, 5, 6}))
+		Expect(err).To(MatchError("invalid bias data: raw estimates must be sorted"))
+	})
+
+	It("should estimate read-only", func() {
+		subject, _ = hllplus.New(14, 19)
+		for i := 0; i < 3_000; i++ {
+			subject.Add(rnd.Uint64())
+		}
+		subject.Add(1 << 63) // explicitly encoded rhoW'
+		subject.Add(1<<63 | 1<<44)
+		Expect(subject.NumBuffered()).NotTo(BeZero())
+
+		buffered := subject.NumBuffered()
+		expected := subject.Clone().Estimate()
+		Expect(subject.EstimateReadOnly()).To(Equal(expected))
+		Expect(subject.NumBuffered()).To(Equal(buffered))
+		Expect(subject.Estimate()).To(Equal(expected))
+		Expect(subject.EstimateReadOnly()).To(Equal(expected))
+
+		normal, _ := hllplus.NewNormal(14)
+		for i := 0; i < 100_000; i++ {
+			normal.Add(rnd.Uint64())
+		}
+		Expect(normal.EstimateReadOnly()).To(Equal(normal.Clone().Estimate()))
+
+		martingale, _ := hllplus.NewNormal(14, hllplus.WithMartingale())
+		for i := 0; i < 100_000; i++ {
+			martingale.Add(rnd.Uint64())
+		}
+		Expect(martingale.EstimateReadOnly()).To(Equal(martingale.Clone().Estimate()))
+	})
+
+	It("should estimate read-only concurrently", func() {
+		subject, _ = hllplus.New(14, 19)
+		for i := 0; i < 3_000; i++ {
+			subject.Add(rnd.Uint64())
+		}
+
+		var wg sync.WaitGroup
+		for i := 0; i < 4; i++ {
+			wg.Add(1)
+			go func() {
+				defer GinkgoRecover()
+				defer wg.Done()
+				Expect(subject.EstimateReadOnly()).To(BeNumerically("~", 3_000, 30))
+			}()
+		}
+		wg.Wait()
+	})
+
+	It("should cache estimates until modified", func() {
+		subject, _ = hllplus.NewNormal(14)
+		for i := 0; i < 10_000; i++ {
+			subject.Add(rnd.Uint64())
+		}
+		Expect(subject.Estimate()).To(Equal(int64(10_000)))
+		Expect(subject.Estimate()).To(Equal(int64(10_000)))
+
+		for i := 0; i < 100; i++ {
+			subject.Add(rnd.Uint64())
+		}
+		Expect(subject.Estimate()).To(Equal(int64(10_106)))
+
+		other, _ := hllplus.New(14, 19)
+		for i := 0; i < 100; i++ {
+			other.Add(rnd.Uint64())
+		}
+		subject.Merge(other)
+		Expect(subject.Estimate()).To(Equal(int64(10_211)))
+
+		Expect(subject.Downgrade(12, 17)).To(Succeed())
+		Expect(subject.Estimate()).To(Equal(int64(10_139)))
+	})
+
+	It("should snapshot", func() {
+		subject, _ = hllplus.NewNormal(14)
+		for i := 0; i < 10_000; i++ {
+			subject.Add(rnd.Uint64())
+		}
+
+		snap := subject.Snapshot()
+		regs := snap.Registers()
+		for i := 0; i < 10_000; i++ {
+			subject.Add(rnd.Uint64())
+		}
+		Expect(snap.Registers()).To(Equal(regs))
+		Expect(snap.NumValues()).To(Equal(int64(10_000)))
+		Expect(snap.Estimate()).To(Equal(int64(10_000)))
+		estimate := subject.Estimate()
+		Expect(estimate).To(BeNumerically("~", 20_000, 200))
+
+		// snapshots are writable too, without affecting the original
+		snap2 := subject.Snapshot()
+		Expect(snap2.Merge(snap)).To(Succeed())
+		snap2.AddHashes([]uint64{rnd.Uint64(), rnd.Uint64()})
+		Expect(subject.NumValues()).To(Equal(int64(20_000)))
+		Expect(subject.Estimate()).To(Equal(estimate))
+	})
+
+	It("should snapshot sparse", func() {
+		subject, _ = hllplus.New(14, 19)
+		subject.Add(1 << 63)
+		snap := subject.Snapshot()
+		subject.Add(1 << 62)
+		Expect(snap.Estimate()).To(Equal(int64(1)))
+		Expect(subject.Estimate()).To(Equal(int64(2)))
+	})
+
+	It("should estimate with bounds", func() {
+		subject, _ = hllplus.NewNormal(14)
+		for i := 0; i < 100_000; i++ {
+			subject.Add(rnd.Uint64())
+		}
+
+		est, lower, upper := subject.EstimateWithBounds(2)
+		Expect(est).To(Equal(subject.Estimate()))
+		Expect(lower).To(BeNumerically("~", float64(est)*(1-2*1.04/128), 1))
+		Expect(upper).To(BeNumerically("~", float64(est)*(1+2*1.04/128), 1))
+		Expect(lower).To(BeNumerically("<=", 100_000))
+		Expect(upper).To(BeNumerically(">=", 100_000))
+	})
+
+	It("should estimate with bounds (linear counting)", func() {
+		subject, _ = hllplus.New(12, 17)
+		for i := 0; i < 1_000; i++ {
+			subject.Add(rnd.Uint64())
+		}
+		Expect(subject.IsSparse()).To(BeTrue())
+
+		est, lower, upper := subject.EstimateWithBounds(3)
+		Expect(est).To(Equal(int64(997)))
+		Expect(lower).To(Equal(int64(991)))
+		Expect(upper).To(Equal(int64(1003)))
+
+		subject, _ = hllplus.NewNormal(12)
+		est, lower, upper = subject.EstimateWithBounds(3)
+		Expect([]int64{est, lower, upper}).To(Equal([]int64{0, 0, 0}))
+	})
+
 	It("should normalize", func() {
 		subject, _ = hllplus.New(12, 17)
-		for i := 0; i < 3_084; i++ {
+		for i := 0; i < 3_071; i++ {
 			subject.Add(rnd.Uint64())
 		}
 		Expect(subject.IsSparse()).To(BeTrue())
-		Expect(subject.Estimate()).To(BeNumerically("==", 3_083))
+		Expect(subject.Estimate()).To(BeNumerically("==", 3_071))
 
 		subject.Add(rnd.Uint64())
 		Expect(subject.IsSparse()).To(BeFalse())
-		Expect(subject.Estimate()).To(BeNumerically("==", 3_072))
+		Expect(subject.Estimate()).To(BeNumerically("==", 3_061))
 	})
 
 	It("should downgrade", func() {
@@ -196,6 +532,90 @@ var _ = Describe("HLL", func() {
 		Expect(s2.Estimate()).To(Equal(int64(100680)))
 	})
 
+	It("should downgrade with context", func() {
+		subject, _ = hllplus.NewNormal(14)
+		for i := 0; i < 10_000; i++ {
+			subject.Add(rnd.Uint64())
+		}
+		regs := subject.Registers()
+
+		ctx, cancel := context.WithCancel(context.Background())
+		cancel()
+		Expect(subject.DowngradeContext(ctx, 12, 17)).To(MatchError(context.Canceled))
+		Expect(subject.Precision()).To(Equal(uint8(14)))
+		Expect(subject.Registers()).To(Equal(regs))
+
+		Expect(subject.DowngradeContext(context.Background(), 12, 17)).To(Succeed())
+		Expect(subject.Precision()).To(Equal(uint8(12)))
+	})
+
+	It("should downgrade sparse", func() {
+		s1, _ := hllplus.New(14, 19)
+		s2, _ := hllplus.New(12, 17)
+
+		for i := 0; i < 500; i++ {
+			n := rnd.Uint64()
+			s1.Add(n)
+			s2.Add(n)
+		}
+
+		Expect(s1.Downgrade(12, 17)).To(Succeed())
+		Expect(s1.IsSparse()).To(BeTrue())
+		Expect(s1.Precision()).To(Equal(uint8(12)))
+		Expect(s1.SparsePrecision()).To(Equal(uint8(17)))
+		Expect(s1.Estimate()).To(Equal(s2.Estimate()))
+		Expect(s1.Proto().SparseData).To(Equal(s2.Proto().SparseData))
+	})
+
+	It("should normalize when downgraded sparse exceeds threshold", func() {
+		s1, _ := hllplus.New(14, 19)
+		s2, _ := hllplus.NewNormal(10)
+
+		for i := 0; i < 1_000; i++ {
+			n := rnd.Uint64()
+			s1.Add(n)
+			s2.Add(n)
+		}
+		Expect(s1.IsSparse()).To(BeTrue())
+
+		Expect(s1.Downgrade(10, 15)).To(Succeed())
+		Expect(s1.IsSparse()).To(BeFalse())
+		Expect(s1.Precision()).To(Equal(uint8(10)))
+		Expect(s1.SparsePrecision()).To(Equal(uint8(15)))
+		Expect(s1.Estimate()).To(Equal(s2.Estimate()))
+	})
+
+	It("should upgrade sparse", func() {
+		s1, _ := hllplus.New(10, 20)
+		s2, _ := hllplus.New(15, 20)
+
+		for i := 0; i < 500; i++ {
+			n := rnd.Uint64()
+			s1.Add(n)
+			s2.Add(n)
+		}
+		Expect(s1.Estimate()).To(Equal(int64(500)))
+
+		Expect(s1.Upgrade(15)).To(Succeed())
+		Expect(s1.IsSparse()).To(BeTrue())
+		Expect(s1.Precision()).To(Equal(uint8(15)))
+		Expect(s1.SparsePrecision()).To(Equal(uint8(20)))
+		Expect(s1.Estimate()).To(Equal(int64(500)))
+		Expect(s1.Estimate()).To(Equal(s2.Estimate()))
+
+		// decreasing is ignored:
+		Expect(s1.Upgrade(12)).To(Succeed())
+		Expect(s1.Precision()).To(Equal(uint8(15)))
+
+		// sparse precision is the limit:
+		Expect(s1.Upgrade(21)).To(MatchError("invalid sparse precision 20: must be >= normal precision 21"))
+
+		// normal sketches cannot be upgraded:
+		s3, _ := hllplus.NewNormal(12)
+		s3.Add(rnd.Uint64())
+		Expect(s3.Upgrade(14)).To(MatchError("cannot upgrade normal representation"))
+	})
+
 	Describe("merge", func() {
 		var s1, s2, s3 *hllplus.HLL
 
@@ -249,6 +669,237 @@ var _ = Describe("HLL", func() {
 			Expect(subject.Precision()).To(Equal(s1.Precision()))
 			Expect(subject.SparsePrecision()).To(Equal(s1.SparsePrecision()))
 		})
+
+		It("should merge sparse into sparse", func() {
+			s1, _ = hllplus.New(12, 17)
+			s2, _ = hllplus.New(12, 17)
+			for i := 0; i < 500; i++ {
+				n := rnd.Uint64()
+				s1.Add(n)
+				s2.Add(n)
+			}
+			for i := 0; i < 500; i++ {
+				s1.Add(rnd.Uint64())
+				s2.Add(rnd.Uint64())
+			}
+
+			s1.Merge(s2)
+			Expect(s1.IsSparse()).To(BeTrue())
+			Expect(s1.Estimate()).To(Equal(int64(1494)))
+
+			// `s2` is not modified:
+			Expect(s2.IsSparse()).To(BeTrue())
+			Expect(s2.Estimate()).To(Equal(int64(997)))
+		})
+
+		It("should merge sparse into itself", func() {
+			// enough values to flush several times while merging
+			s1, _ = hllplus.New(12, 17)
+			for i := 0; i < 2_400; i++ {
+				s1.Add(rnd.Uint64())
+			}
+			clone := s1.Clone()
+
+			Expect(s1.Merge(s1)).To(Succeed())
+			Expect(s1.IsSparse()).To(BeTrue())
+			Expect(s1.Estimate()).To(Equal(clone.Estimate()))
+			Expect(s1.Proto().SparseData).To(Equal(clone.Proto().SparseData))
+		})
+
+		It("should merge sparse with different precisions", func() {
+			s1, _ = hllplus.New(12, 17)
+			s2, _ = hllplus.New(11, 15)
+			s3, _ = hllplus.New(11, 15)
+			for i := 0; i < 1_000; i++ {
+				n := rnd.Uint64()
+				s1.Add(n)
+				s3.Add(n)
+			}
+			for i := 0; i < 200; i++ {
+				n := rnd.Uint64()
+				s2.Add(n)
+				s3.Add(n)
+			}
+
+			s1.Merge(s2)
+			Expect(s1.IsSparse()).To(BeTrue())
+			Expect(s1.Precision()).To(Equal(uint8(11)))
+			Expect(s1.SparsePrecision()).To(Equal(uint8(15)))
+			Expect(s1.Estimate()).To(Equal(s3.Estimate()))
+			Expect(s1.Proto().SparseData).To(Equal(s3.Proto().SparseData))
+		})
+
+		It("should normalize when merged sparse exceeds threshold", func() {
+			s1, _ = hllplus.New(12, 17)
+			s2, _ = hllplus.New(12, 17)
+			for i := 0; i < 2_000; i++ {
+				s1.Add(rnd.Uint64())
+				s2.Add(rnd.Uint64())
+			}
+			Expect(s1.IsSparse()).To(BeTrue())
+			Expect(s2.IsSparse()).To(BeTrue())
+
+			s1.Merge(s2)
+			Expect(s1.IsSparse()).To(BeFalse())
+			Expect(s1.Estimate()).To(Equal(int64(3978)))
+		})
+
+		It("should merge sparse into normal", func() {
+			subject, _ = hllplus.New(12, 17)
+			for i := 0; i < 500; i++ {
+				subject.Add(rnd.Uint64())
+			}
+			Expect(subject.IsSparse()).To(BeTrue())
+
+			s3.Merge(subject)
+			Expect(s3.Estimate()).To(Equal(int64(101265)))
+			Expect(subject.IsSparse()).To(BeTrue())
+		})
+	})
+
+	Describe("martingale", func() {
+		It("should estimate", func() {
+			subject, _ = hllplus.New(14, 19, hllplus.WithMartingale())
+			for i := 0; i < 100_000; i++ {
+				subject.Add(rnd.Uint64())
+			}
+			Expect(subject.IsSparse()).To(BeFalse())
+			Expect(subject.Estimate()).To(Equal(int64(99775)))
+
+			// estimate increases by the inverse probability of a register change:
+			subject.Add(rnd.Uint64())
+			Expect(subject.Estimate()).To(Equal(int64(99783)))
+		})
+
+		It("should re-seed after merge", func() {
+			subject, _ = hllplus.New(14, 19, hllplus.WithMartingale())
+			plain, _ := hllplus.NewNormal(14)
+			other, _ := hllplus.NewNormal(14)
+			for i := 0; i < 100_000; i++ {
+				n := rnd.Uint64()
+				subject.Add(n)
+				plain.Add(n)
+				other.Add(rnd.Uint64())
+			}
+
+			subject.Merge(other)
+			plain.Merge(other)
+			Expect(subject.Estimate()).To(Equal(plain.Estimate()))
+		})
+	})
+
+	Describe("incremental estimate", func() {
+		It("should estimate", func() {
+			subject, _ = hllplus.New(14, 19, hllplus.WithIncrementalEstimate())
+			plain, _ := hllplus.New(14, 19)
+			for i := 0; i < 200_000; i++ {
+				n := rnd.Uint64()
+				subject.Add(n)
+				plain.Add(n)
+				if i%997 == 0 {
+					Expect(subject.Estimate()).To(Equal(plain.Estimate()), "after %d values", i+1)
+				}
+			}
+			Expect(subject.IsSparse()).To(BeFalse())
+			Expect(subject.EstimateDetails().Raw).To(BeNumerically("~", plain.EstimateDetails().Raw, 1e-6))
+			Expect(subject.EstimateDetails().NumZeros).To(Equal(plain.EstimateDetails().NumZeros))
+		})
+
+		It("should re-seed after merge and downgrade", func() {
+			subject, _ = hllplus.NewNormal(14, hllplus.WithIncrementalEstimate(), hllplus.WithPackedRegisters())
+			plain, _ := hllplus.NewNormal(14)
+			other, _ := hllplus.NewNormal(14)
+			for i := 0; i < 10_000; i++ {
+				hashes := []uint64{rnd.Uint64(), rnd.Uint64()}
+				subject.AddHashes(hashes)
+				plain.AddHashes(hashes)
+				other.Add(rnd.Uint64())
+			}
+			Expect(subject.Estimate()).To(Equal(plain.Estimate()))
+
+			Expect(subject.Merge(other)).To(Succeed())
+			Expect(plain.Merge(other)).To(Succeed())
+			Expect(subject.Estimate()).To(Equal(plain.Estimate()))
+
+			Expect(subject.Downgrade(12, 17)).To(Succeed())
+			Expect(plain.Downgrade(12, 17)).To(Succeed())
+			Expect(subject.Estimate()).To(Equal(plain.Estimate()))
+
+			clone := subject.Clone()
+			for i := 0; i < 10_000; i++ {
+				n := rnd.Uint64()
+				clone.Add(n)
+				plain.Add(n)
+			}
+			Expect(clone.Estimate()).To(Equal(plain.Estimate()))
+		})
+	})
+
+	It("should call the normalize hook", func() {
+		var calls int
+		_, err := hllplus.New(10, 12, hllplus.WithNormalizeHook(nil))
+		Expect(err).To(MatchError("invalid normalize hook"))
+
+		subject, _ = hllplus.New(10, 12, hllplus.WithNormalizeHook(func() { calls++ }))
+		for i := 0; i < 100; i++ {
+			subject.AddInt64(int64(i))
+		}
+		Expect(subject.IsSparse()).To(BeTrue())
+		Expect(calls).To(Equal(0))
+
+		for i := 100; i < 10_000; i++ {
+			subject.AddInt64(int64(i))
+		}
+		Expect(subject.IsSparse()).To(BeFalse())
+		Expect(calls).To(Equal(1))
+
+		// hooks are not copied
+		clone := subject.Clone()
+		Expect(clone.Merge(subject)).To(Succeed())
+		Expect(calls).To(Equal(1))
+	})
+
+	Describe("monotonic estimate", func() {
+		It("should never decrease", func() {
+			subject, _ = hllplus.New(10, 12, hllplus.WithMonotonicEstimate())
+			plain, _ := hllplus.New(10, 12)
+
+			var max int64
+			var decreased bool
+			for i := 0; i < 10_000; i++ {
+				subject.AddInt64(int64(i))
+				plain.AddInt64(int64(i))
+
+				if est := plain.Estimate(); est < max {
+					decreased = true
+				} else {
+					max = est
+				}
+				Expect(subject.EstimateReadOnly()).To(Equal(max), "after %d values", i+1)
+				Expect(subject.Estimate()).To(Equal(max), "after %d values", i+1)
+			}
+			Expect(decreased).To(BeTrue())
+		})
+
+		It("should keep the reported estimate through downgrades and merges", func() {
+			subject, _ = hllplus.New(10, 12, hllplus.WithMonotonicEstimate())
+			for i := 0; i < 3_000; i++ {
+				subject.AddInt64(int64(i))
+			}
+			est := subject.Estimate()
+			Expect(subject.Clone().Estimate()).To(Equal(est))
+
+			Expect(subject.Downgrade(10, 10)).To(Succeed())
+			Expect(subject.Estimate()).To(BeNumerically(">=", est))
+
+			target, _ := hllplus.New(10, 12, hllplus.WithMonotonicEstimate())
+			Expect(target.Merge(subject)).To(Succeed())
+			Expect(target.Estimate()).To(BeNumerically(">=", est))
+
+			plain, _ := hllplus.New(10, 12)
+			Expect(plain.Merge(subject)).To(Succeed())
+			Expect(plain.Estimate()).To(Equal(plain.EstimateDetails().Estimate))
+		})
 	})
 
 	Describe("proto", func() {
@@ -258,7 +909,7 @@ var _ = Describe("HLL", func() {
 				subject.Add(rnd.Uint64())
 			}
 			Expect(subject.IsSparse()).To(BeFalse())
-			Expect(subject.Estimate()).To(BeNumerically("==", 9_912))
+			Expect(subject.Estimate()).To(BeNumerically("==", 9_914))
 
 			msg := subject.Proto()
 
@@ -279,7 +930,7 @@ var _ = Describe("HLL", func() {
 			Expect(subject.IsSparse()).To(BeFalse())
 			Expect(subject.Precision()).To(BeNumerically("==", 12))
 			Expect(subject.SparsePrecision()).To(BeNumerically("==", 17))
-			Expect(subject.Estimate()).To(BeNumerically("==", 9_912))
+			Expect(subject.Estimate()).To(BeNumerically("==", 9_914))
 		})
 
 		It("should init sparse", func() {
@@ -288,7 +939,7 @@ var _ = Describe("HLL", func() {
 				subject.Add(rnd.Uint64())
 			}
 			Expect(subject.IsSparse()).To(BeTrue())
-			Expect(subject.Estimate()).To(BeNumerically("==", 798))
+			Expect(subject.Estimate()).To(BeNumerically("==", 797))
 
 			msg := subject.Proto()
 
@@ -300,7 +951,7 @@ var _ = Describe("HLL", func() {
 			Expect(msg.GetData()).To(BeEmpty())
 
 			// expect sparse representation:
-			Expect(msg.GetSparseSize()).To(BeNumerically("==", 796)) // hash/rand collisions are fine, that's why it is != 800
+			Expect(msg.GetSparseSize()).To(BeNumerically("==", 795)) // hash/rand collisions are fine, that's why it is != 800
 			Expect(msg.GetSparseData()).NotTo(BeEmpty())
 
 			// init back from proto:
@@ -309,7 +960,7 @@ var _ = Describe("HLL", func() {
 			Expect(subject.IsSparse()).To(BeTrue())
 			Expect(subject.Precision()).To(BeNumerically("==", 12))
 			Expect(subject.SparsePrecision()).To(BeNumerically("==", 17))
-			Expect(subject.Estimate()).To(BeNumerically("==", 798))
+			Expect(subject.Estimate()).To(BeNumerically("==", 797))
 		})
 	})
 })
@@ -320,3 +971,104 @@ func TestSuite(t *testing.T) {
 	RegisterFailHandler(Fail)
 	RunSpecs(t, "zetasketch/hllplus")
 }
+
+func BenchmarkHLL_Estimate(b *testing.B) {
+	rnd := rand.New(rand.NewSource(33))
+	subject, _ := hllplus.NewNormal(18)
+	for i := 0; i < 1_000_000; i++ {
+		subject.Add(rnd.Uint64())
+	}
+
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		subject.Estimate()
+	}
+}
+
+func BenchmarkHLL_AddHashes(b *testing.B) {
+	rnd := rand.New(rand.NewSource(33))
+	hashes := make([]uint64, 1024)
+	for i := range hashes {
+		hashes[i] = rnd.Uint64()
+	}
+
+	b.Run("single", func(b *testing.B) {
+		subject, _ := hllplus.NewNormal(15)
+		for i := 0; i < b.N; i++ {
+			for _, h := range hashes {
+				subject.Add(h)
+			}
+		}
+	})
+
+	b.Run("batch", func(b *testing.B) {
+		subject, _ := hllplus.NewNormal(15)
+		for i := 0; i < b.N; i++ {
+			subject.AddHashes(hashes)
+		}
+	})
+}
+
+func BenchmarkHLL_Add(b *testing.B) {
+	rnd := rand.New(rand.NewSource(33))
+	hashes := make([]uint64, 1024)
+	for i := range hashes {
+		hashes[i] = rnd.Uint64()
+	}
+
+	b.Run("sparse", func(b *testing.B) {
+		subject, _ := hllplus.New(15, 20)
+		subject.AddHashes(hashes)
+
+		b.ReportAllocs()
+		b.ResetTimer()
+		for i := 0; i < b.N; i++ {
+			subject.Add(hashes[i%len(hashes)])
+		}
+	})
+
+	b.Run("normal", func(b *testing.B) {
+		subject, _ := hllplus.NewNormal(15)
+
+		b.ReportAllocs()
+		b.ResetTimer()
+		for i := 0; i < b.N; i++ {
+			subject.Add(hashes[i%len(hashes)])
+		}
+	})
+
+	b.Run("string", func(b *testing.B) {
+		subject, _ := hllplus.NewNormal(15)
+
+		b.ReportAllocs()
+		b.ResetTimer()
+		for i := 0; i < b.N; i++ {
+			subject.AddString("value")
+		}
+	})
+}
+
+func BenchmarkHLL_AddEstimate(b *testing.B) {
+	rnd := rand.New(rand.NewSource(33))
+
+	for _, opt := range []struct {
+		name string
+		opts []hllplus.Option
+	}{
+		{name: "default"},
+		{name: "incremental", opts: []hllplus.Option{hllplus.WithIncrementalEstimate()}},
+	} {
+		b.Run(opt.name, func(b *testing.B) {
+			subject, _ := hllplus.NewNormal(16, opt.opts...)
+			for i := 0; i < 1_000_000; i++ {
+				subject.Add(rnd.Uint64())
+			}
+
+			b.ResetTimer()
+			for i := 0; i < b.N; i++ {
+				subject.Add(rnd.Uint64())
+				subject.Estimate()
+			}
+		})
+	}
+}
@@ -0,0 +1,183 @@
+package hllplus
+
+import "fmt"
+
+// HybridHLL counts distinct values exactly, by keeping a set of their hashes, until
+// their number exceeds a threshold. Only then are the hashes added to a regular sketch,
+// which estimates all further values. Like several SQL engines do internally, this
+// gives exact answers for small sets, at a cost of about 40 bytes per value up to the
+// threshold. Values are hashed like in HLL, so exact counts are subject to 64-bit hash
+// collisions only.
+type HybridHLL struct {
+	s         *HLL
+	exact     map[uint64]struct{} // nil once converted
+	threshold int
+	numValues int64
+}
+
+// NewHybrid inits a new sketch which counts up to threshold distinct values exactly.
+// See New for all other parameters.
+func NewHybrid(threshold int, precision, sparsePrecision uint8, opts ...Option) (*HybridHLL, error) {
+	if threshold <= 0 {
+		return nil, fmt.Errorf("invalid threshold %d", threshold)
+	}
+
+	s, err := New(precision, sparsePrecision, opts...)
+	if err != nil {
+		return nil, err
+	}
+	return &HybridHLL{
+		s:         s,
+		exact:     make(map[uint64]struct{}),
+		threshold: threshold,
+	}, nil
+}
+
+// Threshold returns the maximum number of distinct values counted exactly.
+func (s *HybridHLL) Threshold() int {
+	return s.threshold
+}
+
+// IsExact returns true as long as the number of distinct values has not exceeded the
+// threshold and Estimate is exact.
+func (s *HybridHLL) IsExact() bool {
+	return s.exact != nil
+}
+
+// NumValues returns the number of values added, including values of merged sketches.
+func (s *HybridHLL) NumValues() int64 {
+	if s.exact == nil {
+		return s.s.numValues
+	}
+	return s.numValues
+}
+
+// MemoryUsage returns an approximation of the number of bytes held by the exact set,
+// or by the sketch once converted.
+func (s *HybridHLL) MemoryUsage() int {
+	if s.exact == nil {
+		return s.s.MemoryUsage()
+	}
+	return len(s.exact) * 40
+}
+
+// Add adds the uniform hash value to the sketch.
+func (s *HybridHLL) Add(hash uint64) {
+	if s.exact == nil {
+		s.s.Add(hash)
+		return
+	}
+
+	s.numValues++
+	if s.exact[hash] = struct{}{}; len(s.exact) > s.threshold {
+		s.convert()
+	}
+}
+
+// AddString hashes and adds a string value, see HLL.AddString.
+func (s *HybridHLL) AddString(v string) {
+	s.s.setValueType(ValueTypeBytes)
+	hash := s.s.hashString(v)
+	sample(s.s, hash, v)
+	s.Add(hash)
+}
+
+// AddBytes hashes and adds a byte value, see HLL.AddBytes.
+func (s *HybridHLL) AddBytes(v []byte) {
+	s.s.setValueType(ValueTypeBytes)
+	hash := s.s.hashBytes(v)
+	sample(s.s, hash, v)
+	s.Add(hash)
+}
+
+// AddInt64 hashes and adds a signed number, see HLL.AddInt64.
+func (s *HybridHLL) AddInt64(v int64) {
+	s.s.setValueType(ValueTypeInt64)
+	hash := s.s.hashUint64(uint64(v))
+	sample(s.s, hash, v)
+	s.Add(hash)
+}
+
+// AddUint64 hashes and adds an unsigned number, see HLL.AddUint64.
+func (s *HybridHLL) AddUint64(v uint64) {
+	s.s.setValueType(ValueTypeUint64)
+	hash := s.s.hashUint64(v)
+	sample(s.s, hash, v)
+	s.Add(hash)
+}
+
+// AddFloat64 hashes and adds a floating point number, see HLL.AddFloat64.
+func (s *HybridHLL) AddFloat64(v float64) {
+	s.s.setValueType(ValueTypeDouble)
+	hash := s.s.hashUint64(float64Bits(v))
+	sample(s.s, hash, v)
+	s.Add(hash)
+}
+
+// Merge merges other into the sketch. The result stays exact if both sketches are exact
+// and their union does not exceed the threshold of the receiver. It returns an error if
+// the sketches were built using different hashers, seeds or value types.
+func (s *HybridHLL) Merge(other *HybridHLL) error {
+	if err := s.s.checkMergeable(other.s); err != nil {
+		return err
+	}
+	s.s.setValueType(other.s.valueType)
+	s.s.exemplars.merge(other.s.exemplars)
+
+	if other.exact == nil {
+		s.convert()
+		return s.s.Merge(other.s)
+	}
+
+	if s.exact == nil {
+		for hash := range other.exact {
+			s.s.Add(hash)
+		}
+		s.s.numValues += other.numValues - int64(len(other.exact))
+		return nil
+	}
+
+	for hash := range other.exact {
+		s.exact[hash] = struct{}{}
+	}
+	s.numValues += other.numValues
+	if len(s.exact) > s.threshold {
+		s.convert()
+	}
+	return nil
+}
+
+// Estimate returns the exact number of distinct values, or the cardinality estimate of
+// the sketch once the threshold has been exceeded.
+func (s *HybridHLL) Estimate() int64 {
+	if s.exact == nil {
+		return s.s.Estimate()
+	}
+	return int64(len(s.exact))
+}
+
+// Sketch returns a sketch of all values, e.g. to serialize it. While exact, the hashes
+// are added to a copy of the empty sketch.
+func (s *HybridHLL) Sketch() *HLL {
+	res := s.s.Clone()
+	if s.exact != nil {
+		for hash := range s.exact {
+			res.Add(hash)
+		}
+		res.numValues = s.numValues
+	}
+	return res
+}
+
+// convert adds the exact hashes to the sketch.
+func (s *HybridHLL) convert() {
+	if s.exact == nil {
+		return
+	}
+
+	for hash := range s.exact {
+		s.s.Add(hash)
+	}
+	s.s.numValues = s.numValues
+	s.exact = nil
+}
@@ -0,0 +1,119 @@
+package hllplus_test
+
+import (
+	"testing"
+
+	"github.com/gowthamkommineni/zetasketch/hllplus"
+
+	. "github.com/bsm/ginkgo"
+	. "github.com/bsm/gomega"
+)
+
+var _ = Describe("HybridHLL", func() {
+	var subject *hllplus.HybridHLL
+
+	BeforeEach(func() {
+		subject, _ = hllplus.NewHybrid(1_000, 12, 17)
+	})
+
+	It("should validate", func() {
+		_, err := hllplus.NewHybrid(0, 12, 17)
+		Expect(err).To(MatchError("invalid threshold 0"))
+
+		_, err = hllplus.NewHybrid(10, 30, 0)
+		Expect(err).To(MatchError("invalid normal precision 30"))
+
+		Expect(subject.Threshold()).To(Equal(1_000))
+	})
+
+	It("should count exactly up to the threshold", func() {
+		for i := 0; i < 1_000; i++ {
+			subject.AddInt64(int64(i))
+			subject.AddInt64(int64(i))
+			Expect(subject.Estimate()).To(Equal(int64(i + 1)))
+		}
+		Expect(subject.IsExact()).To(BeTrue())
+		Expect(subject.NumValues()).To(Equal(int64(2_000)))
+		Expect(subject.MemoryUsage()).To(Equal(40_000))
+
+		// the sketch of the exact values estimates them like a regular sketch
+		plain, _ := hllplus.New(12, 17)
+		for i := 0; i < 1_000; i++ {
+			plain.AddInt64(int64(i))
+		}
+		sketch := subject.Sketch()
+		Expect(sketch.Registers()).To(Equal(plain.Registers()))
+		Expect(sketch.NumValues()).To(Equal(int64(2_000)))
+		Expect(sketch.ValueType()).To(Equal(hllplus.ValueTypeInt64))
+
+		subject.AddInt64(1_000)
+		Expect(subject.IsExact()).To(BeFalse())
+		Expect(subject.Estimate()).To(BeNumerically("~", 1_001, 50))
+		Expect(subject.NumValues()).To(Equal(int64(2_001)))
+
+		plain.AddInt64(1_000)
+		Expect(subject.Sketch().Registers()).To(Equal(plain.Registers()))
+		Expect(subject.Estimate()).To(Equal(plain.Estimate()))
+	})
+
+	It("should merge", func() {
+		a, _ := hllplus.NewHybrid(1_000, 12, 17)
+		b, _ := hllplus.NewHybrid(1_000, 12, 17)
+		for i := 0; i < 400; i++ {
+			a.AddString(string(rune(i)))
+			b.AddString(string(rune(i + 200)))
+		}
+
+		Expect(a.Merge(b)).To(Succeed())
+		Expect(a.IsExact()).To(BeTrue())
+		Expect(a.Estimate()).To(Equal(int64(600)))
+		Expect(a.NumValues()).To(Equal(int64(800)))
+
+		// exceeding the threshold converts
+		Expect(a.Merge(b)).To(Succeed())
+		Expect(a.IsExact()).To(BeTrue())
+		for i := 1_000; i < 1_500; i++ {
+			b.AddString(string(rune(i)))
+		}
+		Expect(a.Merge(b)).To(Succeed())
+		Expect(a.IsExact()).To(BeFalse())
+		Expect(a.Estimate()).To(BeNumerically("~", 1_100, 50))
+		Expect(a.NumValues()).To(Equal(int64(2_100)))
+
+		// exact into converted
+		c, _ := hllplus.NewHybrid(1_000, 12, 17)
+		c.AddString("x")
+		Expect(a.Merge(c)).To(Succeed())
+		Expect(a.NumValues()).To(Equal(int64(2_101)))
+
+		// converted into exact
+		Expect(c.Merge(a)).To(Succeed())
+		Expect(c.IsExact()).To(BeFalse())
+		Expect(c.NumValues()).To(Equal(int64(2_102)))
+		Expect(c.Sketch().Registers()).To(Equal(a.Sketch().Registers()))
+	})
+
+	It("should reject incompatible sketches", func() {
+		subject.AddInt64(1)
+
+		str, _ := hllplus.NewHybrid(10, 12, 17)
+		str.AddString("x")
+		Expect(subject.Merge(str)).To(MatchError("cannot merge sketches with different value types INT64 and BYTES_OR_UTF8_STRING"))
+
+		xx, _ := hllplus.NewHybrid(10, 12, 17, hllplus.WithHasher(hllplus.XXHash64))
+		Expect(subject.Merge(xx)).To(MatchError(`cannot merge sketches with different hashers "fingerprint2011" and "xxhash64"`))
+		Expect(subject.Estimate()).To(Equal(int64(1)))
+	})
+})
+
+func BenchmarkHybridHLL_Add(b *testing.B) {
+	s, _ := hllplus.NewHybrid(1_000, 14, 19)
+
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		if i%2_000 == 0 {
+			s, _ = hllplus.NewHybrid(1_000, 14, 19)
+		}
+		s.AddInt64(int64(i))
+	}
+}
@@ -0,0 +1,81 @@
+package hllplus
+
+import pb "github.com/gowthamkommineni/zetasketch/internal/zetasketch"
+
+// LazyHLL is a sketch restored from a proto message, which defers decoding
+// of the state until it is first inspected or modified. This allows
+// pass-through workloads to avoid paying the decoding cost for sketches
+// they never inspect.
+type LazyHLL struct {
+	msg *pb.HyperLogLogPlusUniqueStateProto
+	h   *HLL
+}
+
+// NewLazyFromProto creates a lazily decoded sketch from a proto message.
+// Precisions, the state and options are validated immediately.
+func NewLazyFromProto(msg *pb.HyperLogLogPlusUniqueStateProto, opts ...Option) (*LazyHLL, error) {
+	precision := uint8(msg.GetPrecisionOrNumBuckets())
+	sparsePrecision := uint8(msg.GetSparsePrecisionOrNumBuckets())
+	if err := validateProto(msg, precision, sparsePrecision); err != nil {
+		return nil, err
+	}
+
+	h := &HLL{
+		precision:       precision,
+		sparsePrecision: sparsePrecision,
+	}
+	if err := h.apply(opts); err != nil {
+		return nil, err
+	}
+	return &LazyHLL{msg: msg, h: h}, nil
+}
+
+// Precision returns the normal precision.
+func (l *LazyHLL) Precision() uint8 {
+	return l.h.precision
+}
+
+// SparsePrecision returns the sparse precision.
+func (l *LazyHLL) SparsePrecision() uint8 {
+	return l.h.sparsePrecision
+}
+
+// IsDecoded returns true if the state has been decoded.
+func (l *LazyHLL) IsDecoded() bool {
+	return l.msg == nil
+}
+
+// HLL decodes the state (if necessary) and returns the underlying sketch.
+func (l *LazyHLL) HLL() *HLL {
+	if l.msg != nil {
+		restored, _ := newFromProto(l.msg, false) // already validated
+		l.h.setUnpackedNormal(restored.normal)
+		l.h.replaceSparse(restored.sparse)
+		l.msg = nil
+	}
+	return l.h
+}
+
+// Add adds the uniform hash value to the representation.
+func (l *LazyHLL) Add(hash uint64) {
+	l.HLL().Add(hash)
+}
+
+// Merge merges other into the sketch.
+func (l *LazyHLL) Merge(other *HLL) error {
+	return l.HLL().Merge(other)
+}
+
+// Estimate returns the cardinality estimate.
+func (l *LazyHLL) Estimate() int64 {
+	return l.HLL().Estimate()
+}
+
+// Proto returns the proto message. The original message is
+// returned as long as the state has not been decoded.
+func (l *LazyHLL) Proto() *pb.HyperLogLogPlusUniqueStateProto {
+	if l.msg != nil {
+		return l.msg
+	}
+	return l.h.Proto()
+}
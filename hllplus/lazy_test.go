@@ -0,0 +1,63 @@
+package hllplus_test
+
+import (
+	"math/rand"
+
+	"github.com/gowthamkommineni/zetasketch/hllplus"
+
+	. "github.com/bsm/ginkgo"
+	. "github.com/bsm/gomega"
+)
+
+var _ = Describe("LazyHLL", func() {
+	var source *hllplus.HLL
+	var rnd *rand.Rand
+
+	BeforeEach(func() {
+		rnd = rand.New(rand.NewSource(5))
+		source, _ = hllplus.New(12, 17)
+		for i := 0; i < 500; i++ {
+			source.Add(rnd.Uint64())
+		}
+	})
+
+	It("should pass through without decoding", func() {
+		msg := source.Proto()
+		subject, err := hllplus.NewLazyFromProto(msg)
+		Expect(err).NotTo(HaveOccurred())
+		Expect(subject.Precision()).To(Equal(uint8(12)))
+		Expect(subject.SparsePrecision()).To(Equal(uint8(17)))
+		Expect(subject.Proto()).To(BeIdenticalTo(msg))
+		Expect(subject.IsDecoded()).To(BeFalse())
+	})
+
+	It("should decode on demand", func() {
+		subject, _ := hllplus.NewLazyFromProto(source.Proto(), hllplus.WithSeed(3))
+		Expect(subject.Estimate()).To(Equal(source.Estimate()))
+		Expect(subject.IsDecoded()).To(BeTrue())
+		Expect(subject.HLL().Seed()).To(Equal(uint64(3)))
+
+		subject, _ = hllplus.NewLazyFromProto(source.Proto())
+		subject.Add(7)
+		source.Add(7)
+		Expect(subject.IsDecoded()).To(BeTrue())
+		Expect(subject.Proto()).To(Equal(source.Proto()))
+
+		other, _ := hllplus.New(12, 17)
+		other.Add(8)
+		subject, _ = hllplus.NewLazyFromProto(source.Proto())
+		Expect(subject.Merge(other)).To(Succeed())
+		Expect(source.Merge(other)).To(Succeed())
+		Expect(subject.Proto()).To(Equal(source.Proto()))
+	})
+
+	It("should validate eagerly", func() {
+		msg := source.Proto()
+		*msg.PrecisionOrNumBuckets = 30
+		_, err := hllplus.NewLazyFromProto(msg)
+		Expect(err).To(MatchError("invalid normal precision 30"))
+
+		_, err = hllplus.NewLazyFromProto(source.Proto(), hllplus.WithEstimator(9))
+		Expect(err).To(MatchError("invalid estimator 9"))
+	})
+})
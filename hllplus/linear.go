@@ -0,0 +1,138 @@
+package hllplus
+
+import (
+	"fmt"
+	"math"
+	"math/bits"
+)
+
+// LinearCounting is a small sketch counting distinct values with a bitmap of m bits, as
+// described in "A Linear-Time Probabilistic Counting Algorithm for Database
+// Applications" (Whang, Vander-Zanden, Taylor, 1990). Its memory is fixed at m/8 bytes
+// and it is very accurate for cardinalities well below m, but saturates once all bits
+// are set. Values are hashed like in HLL, using the same hasher and seed options.
+type LinearCounting struct {
+	tmpl      *HLL // empty sketch, carries the configuration
+	m         uint64
+	bitmap    []uint64
+	numValues int64
+}
+
+// NewLinearCounting inits a new sketch with a bitmap of m bits, m must be between 1 and
+// 2^32. Options configure hashing, see New. WithExemplars is not supported.
+func NewLinearCounting(m int, opts ...Option) (*LinearCounting, error) {
+	if m < 1 || m > 1<<32 {
+		return nil, fmt.Errorf("invalid number of bits %d", m)
+	}
+
+	tmpl, err := New(MinPrecision, MinPrecision, opts...)
+	if err != nil {
+		return nil, err
+	}
+	if tmpl.exemplars != nil {
+		return nil, fmt.Errorf("exemplars are not supported by LinearCounting")
+	}
+	return &LinearCounting{
+		tmpl:   tmpl,
+		m:      uint64(m),
+		bitmap: make([]uint64, (m+63)/64),
+	}, nil
+}
+
+// M returns the number of bits.
+func (s *LinearCounting) M() int {
+	return int(s.m)
+}
+
+// NumValues returns the number of values seen.
+func (s *LinearCounting) NumValues() int64 {
+	return s.numValues
+}
+
+// MemoryUsage returns the number of bytes held by the bitmap.
+func (s *LinearCounting) MemoryUsage() int {
+	return cap(s.bitmap) * 8
+}
+
+// Add adds the uniform hash value to the sketch.
+func (s *LinearCounting) Add(hash uint64) {
+	s.numValues++
+
+	// map the hash to [0, m) without modulo bias
+	i, _ := bits.Mul64(hash, s.m)
+	s.bitmap[i/64] |= 1 << (i % 64)
+}
+
+// AddString hashes and adds a string value, see HLL.AddString.
+func (s *LinearCounting) AddString(v string) {
+	s.Add(s.tmpl.hashString(v))
+}
+
+// AddBytes hashes and adds a byte value, see HLL.AddBytes.
+func (s *LinearCounting) AddBytes(v []byte) {
+	s.Add(s.tmpl.hashBytes(v))
+}
+
+// AddInt64 hashes and adds a signed number, see HLL.AddInt64.
+func (s *LinearCounting) AddInt64(v int64) {
+	s.Add(s.tmpl.hashUint64(uint64(v)))
+}
+
+// AddUint64 hashes and adds an unsigned number, see HLL.AddUint64.
+func (s *LinearCounting) AddUint64(v uint64) {
+	s.Add(s.tmpl.hashUint64(v))
+}
+
+// AddFloat64 hashes and adds a floating point number, see HLL.AddFloat64.
+func (s *LinearCounting) AddFloat64(v float64) {
+	s.Add(s.tmpl.hashUint64(float64Bits(v)))
+}
+
+// Merge merges other into the sketch. Both sketches must have the same number of bits
+// and hash values alike.
+func (s *LinearCounting) Merge(other *LinearCounting) error {
+	if err := s.tmpl.checkHasher(other.tmpl); err != nil {
+		return err
+	}
+	if s.m != other.m {
+		return fmt.Errorf("cannot merge sketches with different number of bits %d and %d", s.m, other.m)
+	}
+
+	for i, w := range other.bitmap {
+		s.bitmap[i] |= w
+	}
+	s.numValues += other.numValues
+	return nil
+}
+
+// Saturated returns true if all bits are set. The estimate of a saturated sketch is only
+// a lower bound.
+func (s *LinearCounting) Saturated() bool {
+	return s.zeros() == 0
+}
+
+// Estimate computes the cardinality estimate m * ln(m / zeros). For saturated sketches,
+// it returns the estimate for a single zero bit.
+func (s *LinearCounting) Estimate() int64 {
+	zeros := s.zeros()
+	if zeros == 0 {
+		zeros = 1
+	}
+	return int64(math.Round(float64(s.m) * math.Log(float64(s.m)/float64(zeros))))
+}
+
+// Reset clears the sketch.
+func (s *LinearCounting) Reset() {
+	for i := range s.bitmap {
+		s.bitmap[i] = 0
+	}
+	s.numValues = 0
+}
+
+func (s *LinearCounting) zeros() uint64 {
+	var ones int
+	for _, w := range s.bitmap {
+		ones += bits.OnesCount64(w)
+	}
+	return s.m - uint64(ones)
+}
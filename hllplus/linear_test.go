@@ -0,0 +1,90 @@
+package hllplus_test
+
+import (
+	"math"
+
+	"github.com/gowthamkommineni/zetasketch/fingerprint"
+	"github.com/gowthamkommineni/zetasketch/hllplus"
+
+	. "github.com/bsm/ginkgo"
+	. "github.com/bsm/gomega"
+)
+
+var _ = Describe("LinearCounting", func() {
+	var subject *hllplus.LinearCounting
+
+	BeforeEach(func() {
+		var err error
+		subject, err = hllplus.NewLinearCounting(1 << 16)
+		Expect(err).NotTo(HaveOccurred())
+	})
+
+	It("should init", func() {
+		Expect(subject.M()).To(Equal(65_536))
+		Expect(subject.MemoryUsage()).To(Equal(8_192))
+		Expect(subject.Estimate()).To(BeZero())
+
+		_, err := hllplus.NewLinearCounting(0)
+		Expect(err).To(MatchError("invalid number of bits 0"))
+		_, err = hllplus.NewLinearCounting(64, hllplus.WithHasher(nil))
+		Expect(err).To(MatchError("invalid hasher"))
+	})
+
+	It("should estimate", func() {
+		for _, n := range []int{10, 1_000, 10_000, 50_000} {
+			subject.Reset()
+			for i := 0; i < n; i++ {
+				subject.AddInt64(int64(i))
+				subject.AddInt64(int64(i))
+			}
+			Expect(subject.NumValues()).To(Equal(int64(2 * n)))
+			Expect(subject.Estimate()).To(BeNumerically("~", n, math.Max(1, float64(n)*0.01)), "n=%d", n)
+		}
+	})
+
+	It("should hash like HLL", func() {
+		subject.AddString("foo")
+		subject.AddBytes([]byte("foo"))
+		subject.AddUint64(1)
+		subject.AddInt64(1)
+		subject.AddFloat64(1)
+		Expect(subject.Estimate()).To(Equal(int64(3)))
+
+		other, err := hllplus.NewLinearCounting(1 << 16)
+		Expect(err).NotTo(HaveOccurred())
+		other.Add(fingerprint.String("foo"))
+		Expect(subject.Merge(other)).To(Succeed())
+		Expect(subject.Estimate()).To(Equal(int64(3)))
+	})
+
+	It("should merge", func() {
+		other, err := hllplus.NewLinearCounting(1 << 16)
+		Expect(err).NotTo(HaveOccurred())
+		for i := 0; i < 10_000; i++ {
+			subject.AddInt64(int64(i))
+			other.AddInt64(int64(i + 5_000))
+		}
+
+		Expect(subject.Merge(other)).To(Succeed())
+		Expect(subject.NumValues()).To(Equal(int64(20_000)))
+		Expect(subject.Estimate()).To(BeNumerically("~", 15_000, 150))
+
+		small, err := hllplus.NewLinearCounting(1 << 10)
+		Expect(err).NotTo(HaveOccurred())
+		Expect(subject.Merge(small)).To(MatchError("cannot merge sketches with different number of bits 65536 and 1024"))
+
+		seeded, err := hllplus.NewLinearCounting(1<<16, hllplus.WithSeed(1))
+		Expect(err).NotTo(HaveOccurred())
+		Expect(subject.Merge(seeded)).To(MatchError("cannot merge sketches with different seeds 0 and 1"))
+	})
+
+	It("should saturate", func() {
+		s, err := hllplus.NewLinearCounting(100)
+		Expect(err).NotTo(HaveOccurred())
+		for i := 0; i < 10_000; i++ {
+			s.AddInt64(int64(i))
+		}
+		Expect(s.Saturated()).To(BeTrue())
+		Expect(s.Estimate()).To(Equal(int64(461)))
+	})
+})
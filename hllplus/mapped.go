@@ -0,0 +1,160 @@
+package hllplus
+
+import (
+	"encoding/binary"
+	"fmt"
+	"os"
+)
+
+// mappedMagic identifies files of MappedHLL.
+const mappedMagic = "ZHLL"
+
+// mappedVersion is the version of the file layout.
+const mappedVersion = 1
+
+// mappedHeaderLen is the size of the file header, which precedes the registers:
+//
+//	0: magic
+//	4: version
+//	5: precision
+//	6: sparse precision
+//	7: value type
+//	8: number of values, little endian
+const mappedHeaderLen = 16
+
+// MappedHLL is a sketch in normal representation, whose registers are stored in a
+// memory-mapped file rather than on the Go heap. Registers are written to the file as
+// values are added and survive restarts, which makes it suited for long-lived
+// counters with very high precisions. The number of values and the value type are
+// only persisted by Sync and Close.
+//
+// Memory-mapped files are supported on Linux, macOS and most BSDs.
+type MappedHLL struct {
+	h    *HLL
+	file *os.File
+	data []byte // the whole mapping
+}
+
+// OpenMapped opens or creates a sketch backed by the file at path. New files are
+// initialized with the given precisions, existing files must match them. See New for
+// all other parameters. Packed registers are not supported.
+func OpenMapped(path string, precision, sparsePrecision uint8, opts ...Option) (*MappedHLL, error) {
+	h, err := New(precision, sparsePrecision, opts...)
+	if err != nil {
+		return nil, err
+	}
+	if h.packed {
+		return nil, fmt.Errorf("cannot map packed registers")
+	}
+
+	file, err := os.OpenFile(path, os.O_RDWR|os.O_CREATE, 0o644)
+	if err != nil {
+		return nil, err
+	}
+
+	m := &MappedHLL{h: h, file: file}
+	if err := m.init(); err != nil {
+		_ = m.close()
+		return nil, err
+	}
+	return m, nil
+}
+
+func (m *MappedHLL) init() error {
+	size := mappedHeaderLen + 1<<m.h.precision
+
+	info, err := m.file.Stat()
+	if err != nil {
+		return err
+	}
+
+	created := info.Size() == 0
+	if created {
+		if err := m.file.Truncate(int64(size)); err != nil {
+			return err
+		}
+	} else if info.Size() != int64(size) {
+		return fmt.Errorf("invalid mapped sketch: %d bytes, expected %d", info.Size(), size)
+	}
+
+	if m.data, err = mmapFile(m.file, size); err != nil {
+		return err
+	}
+
+	header := m.data[:mappedHeaderLen]
+	if created {
+		copy(header, mappedMagic)
+		header[4] = mappedVersion
+		header[5] = m.h.precision
+		header[6] = m.h.sparsePrecision
+		header[7] = byte(m.h.valueType)
+	} else if err := m.restore(header); err != nil {
+		return err
+	}
+
+	m.h.replaceSparse(nil)
+	m.h.replaceNormal(m.data[mappedHeaderLen:], false)
+	m.h.cached = false
+	return nil
+}
+
+func (m *MappedHLL) restore(header []byte) error {
+	if string(header[:4]) != mappedMagic {
+		return fmt.Errorf("invalid mapped sketch: bad magic")
+	}
+	if header[4] != mappedVersion {
+		return fmt.Errorf("invalid mapped sketch: unsupported version %d", header[4])
+	}
+	if header[5] != m.h.precision || header[6] != m.h.sparsePrecision {
+		return fmt.Errorf("invalid mapped sketch: precisions %d/%d do not match %d/%d",
+			header[5], header[6], m.h.precision, m.h.sparsePrecision)
+	}
+
+	m.h.valueType = ValueType(header[7])
+	m.h.numValues = int64(binary.LittleEndian.Uint64(header[8:]))
+	return nil
+}
+
+// HLL returns the underlying sketch. It must not be used after Close, neither must its
+// snapshots, which share the mapped registers. Downgrading moves the registers onto the
+// heap, they are no longer persisted afterwards.
+func (m *MappedHLL) HLL() *HLL {
+	return m.h
+}
+
+// Sync persists the number of values and the value type and flushes the registers to
+// disk.
+func (m *MappedHLL) Sync() error {
+	if m.data == nil {
+		return os.ErrClosed
+	}
+
+	header := m.data[:mappedHeaderLen]
+	header[7] = byte(m.h.valueType)
+	binary.LittleEndian.PutUint64(header[8:], uint64(m.h.numValues))
+	return msyncFile(m.data)
+}
+
+// Close syncs and unmaps the file.
+func (m *MappedHLL) Close() error {
+	err := m.Sync()
+	if cerr := m.close(); err == nil {
+		err = cerr
+	}
+	return err
+}
+
+func (m *MappedHLL) close() error {
+	var err error
+	if m.data != nil {
+		if len(m.h.normal) != 0 && &m.h.normal[0] == &m.data[mappedHeaderLen] {
+			m.h.replaceNormal(nil, false)
+		}
+		err = munmapFile(m.data)
+		m.data = nil
+	}
+	if cerr := m.file.Close(); err == nil {
+		err = cerr
+	}
+	return err
+}
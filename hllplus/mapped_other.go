@@ -0,0 +1,14 @@
+//go:build !(darwin || dragonfly || freebsd || linux || openbsd)
+
+package hllplus
+
+import (
+	"fmt"
+	"os"
+)
+
+var errMappedUnsupported = fmt.Errorf("memory-mapped sketches are not supported on this platform")
+
+func mmapFile(*os.File, int) ([]byte, error) { return nil, errMappedUnsupported }
+func munmapFile([]byte) error                { return errMappedUnsupported }
+func msyncFile([]byte) error                 { return errMappedUnsupported }
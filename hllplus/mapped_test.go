@@ -0,0 +1,91 @@
+//go:build darwin || dragonfly || freebsd || linux || openbsd
+
+package hllplus_test
+
+import (
+	"math/rand"
+	"os"
+	"path/filepath"
+
+	"github.com/gowthamkommineni/zetasketch/hllplus"
+
+	. "github.com/bsm/ginkgo"
+	. "github.com/bsm/gomega"
+)
+
+var _ = Describe("MappedHLL", func() {
+	var dir, path string
+	var rnd *rand.Rand
+
+	BeforeEach(func() {
+		var err error
+		dir, err = os.MkdirTemp("", "zetasketch")
+		Expect(err).NotTo(HaveOccurred())
+
+		path = filepath.Join(dir, "sketch.hll")
+		rnd = rand.New(rand.NewSource(17))
+	})
+
+	AfterEach(func() {
+		Expect(os.RemoveAll(dir)).To(Succeed())
+	})
+
+	It("should persist registers", func() {
+		subject, err := hllplus.OpenMapped(path, 14, 19)
+		Expect(err).NotTo(HaveOccurred())
+
+		expected, _ := hllplus.New(14, 19)
+		for i := 0; i < 50_000; i++ {
+			v := rnd.Int63()
+			subject.HLL().AddInt64(v)
+			expected.AddInt64(v)
+		}
+		Expect(subject.HLL().Estimate()).To(Equal(expected.Estimate()))
+		Expect(subject.HLL().Proto()).To(Equal(expected.Proto()))
+		Expect(subject.Close()).To(Succeed())
+
+		info, err := os.Stat(path)
+		Expect(err).NotTo(HaveOccurred())
+		Expect(info.Size()).To(Equal(int64(16 + 1<<14)))
+
+		restored, err := hllplus.OpenMapped(path, 14, 19)
+		Expect(err).NotTo(HaveOccurred())
+		defer restored.Close()
+
+		Expect(restored.HLL().NumValues()).To(Equal(int64(50_000)))
+		Expect(restored.HLL().ValueType()).To(Equal(hllplus.ValueTypeInt64))
+		Expect(restored.HLL().Estimate()).To(Equal(expected.Estimate()))
+		Expect(restored.HLL().Proto()).To(Equal(expected.Proto()))
+	})
+
+	It("should start in normal representation", func() {
+		subject, err := hllplus.OpenMapped(path, 12, 17)
+		Expect(err).NotTo(HaveOccurred())
+		defer subject.Close()
+
+		Expect(subject.HLL().IsSparse()).To(BeFalse())
+		Expect(subject.HLL().Estimate()).To(Equal(int64(0)))
+		subject.HLL().Add(rnd.Uint64())
+		Expect(subject.HLL().Estimate()).To(Equal(int64(1)))
+		Expect(subject.Sync()).To(Succeed())
+	})
+
+	It("should reject mismatching files", func() {
+		subject, err := hllplus.OpenMapped(path, 12, 17)
+		Expect(err).NotTo(HaveOccurred())
+		Expect(subject.Close()).To(Succeed())
+		Expect(subject.Sync()).To(MatchError(os.ErrClosed))
+
+		_, err = hllplus.OpenMapped(path, 13, 18)
+		Expect(err).To(MatchError("invalid mapped sketch: 4112 bytes, expected 8208"))
+		_, err = hllplus.OpenMapped(path, 12, 16)
+		Expect(err).To(MatchError("invalid mapped sketch: precisions 12/17 do not match 12/16"))
+
+		Expect(os.WriteFile(path, make([]byte, 16+1<<12), 0o644)).To(Succeed())
+		_, err = hllplus.OpenMapped(path, 12, 17)
+		Expect(err).To(MatchError("invalid mapped sketch: bad magic"))
+
+		_, err = hllplus.OpenMapped(path, 12, 17, hllplus.WithPackedRegisters())
+		Expect(err).To(MatchError("cannot map packed registers"))
+	})
+})
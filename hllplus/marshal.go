@@ -0,0 +1,298 @@
+package hllplus
+
+import (
+	"database/sql/driver"
+	"encoding/base64"
+	"encoding/gob"
+	"encoding/json"
+	"fmt"
+	"strings"
+
+	pb "github.com/gowthamkommineni/zetasketch/internal/zetasketch"
+	"google.golang.org/protobuf/encoding/protowire"
+	"google.golang.org/protobuf/proto"
+)
+
+func init() {
+	gob.Register(new(HLL))
+}
+
+// gobVersion is the version of the gob payload.
+const gobVersion = 1
+
+// encodingVersion is the AggregatorStateProto encoding version
+// used by the zetasketch Java library and BigQuery.
+const encodingVersion = 2
+
+// Marshal serializes the sketch into an AggregatorStateProto message, which is
+// the format used by the zetasketch Java library and BigQuery's HLL_COUNT functions.
+// The output is deterministic, sketches with the same state and number of values
+// always produce identical bytes.
+func (s *HLL) Marshal() ([]byte, error) {
+	msg, state := s.aggregatorProto(), s.Proto()
+	data := make([]byte, 0, sizeAggregatorProto(msg, state))
+	return appendAggregatorProto(data, msg, state), nil
+}
+
+// ProtoSize returns the exact size of the serialized sketch, as returned by Marshal,
+// without building the proto message.
+func (s *HLL) ProtoSize() int {
+	n := 1 + protowire.SizeVarint(uint64(pb.AggregatorType_HYPERLOGLOG_PLUS_UNIQUE))
+	n += 1 + protowire.SizeVarint(uint64(s.numValues))
+	n += 1 + protowire.SizeVarint(encodingVersion)
+	if s.valueType != ValueTypeUnknown {
+		n += 1 + protowire.SizeVarint(uint64(s.valueType))
+	}
+
+	m := 1 + protowire.SizeVarint(uint64(s.precision))
+	m += 1 + protowire.SizeVarint(uint64(s.sparsePrecision))
+	if s.sparse != nil {
+		s.sparse.Flush()
+		m += 1 + protowire.SizeVarint(uint64(s.sparse.data.Count()))
+		m += 1 + protowire.SizeBytes(s.sparse.data.Len())
+	} else if s.normal != nil {
+		size := len(s.normal)
+		if s.packed && size != 0 {
+			size = 1 << s.precision
+		}
+		m += 1 + protowire.SizeBytes(size)
+	}
+	return n + protowire.SizeTag(protowire.Number(pb.E_HyperloglogplusUniqueState.Field)) + protowire.SizeBytes(m)
+}
+
+// Unmarshal restores the sketch from a serialized AggregatorStateProto message,
+// replacing its current state, including the value type. Other options the sketch
+// was created with are retained.
+func (s *HLL) Unmarshal(data []byte) error {
+	msg := new(pb.AggregatorStateProto)
+	if err := proto.Unmarshal(data, msg); err != nil {
+		return err
+	}
+	return s.fromAggregatorProto(msg, false)
+}
+
+// UnmarshalNoCopy is like Unmarshal, but the registers and sparse data of the
+// sketch alias data instead of being copied. This avoids allocations for
+// read-mostly workloads. The caller must not modify data while the sketch is in
+// use and must be aware that adding values to the sketch may modify data.
+func (s *HLL) UnmarshalNoCopy(data []byte) error {
+	msg, err := decodeAggregatorProto(data)
+	if err != nil {
+		return err
+	}
+	return s.fromAggregatorProto(msg, true)
+}
+
+// MergeProtoBytes merges a sketch serialized by Marshal directly into s, without
+// restoring it first. Registers and sparse data are read from data in place, which
+// saves most of the allocations of Unmarshal followed by Merge, e.g. in rollup jobs.
+// Since the hasher is not serialized, data is assumed to be hashed like s.
+func (s *HLL) MergeProtoBytes(data []byte) error {
+	var w wireSketch
+	if err := decodeWireSketch(data, &w); err != nil {
+		return err
+	}
+
+	if w.aggType != pb.AggregatorType_HYPERLOGLOG_PLUS_UNIQUE {
+		return fmt.Errorf("incompatible binary message: unexpected type %s", w.aggType.String())
+	}
+	if w.encodingVersion != encodingVersion {
+		return fmt.Errorf("incompatible binary message: unsupported encoding version %#v", w.encodingVersion)
+	}
+	if !w.hasNumValues {
+		return fmt.Errorf("incompatible binary message: no num values")
+	}
+	if !w.hasState {
+		return fmt.Errorf("incompatible binary message: invalid HyperLogLog++ state")
+	}
+
+	precision, sparsePrecision := uint8(w.precision), uint8(w.sparsePrecision)
+	if err := validate(precision, sparsePrecision); err != nil {
+		return err
+	}
+
+	other := &HLL{
+		precision:       precision,
+		sparsePrecision: sparsePrecision,
+		numValues:       w.numValues,
+		valueType:       ValueType(w.valueType),
+		hasher:          s.hasher,
+		seed:            s.seed,
+	}
+	if len(w.sparseData) != 0 {
+		if err := validateSparseData(precision, sparsePrecision, w.sparseData); err != nil {
+			return fmt.Errorf("incompatible binary message: %w", err)
+		}
+		other.sparse = newSparseView(precision, sparsePrecision, w.sparseData)
+	} else if len(w.data) != 0 {
+		if len(w.data) != 1<<precision {
+			return fmt.Errorf("incompatible binary message: %d registers, expected %d", len(w.data), 1<<precision)
+		}
+		other.normal = w.data
+	}
+	return s.Merge(other)
+}
+
+// aggregatorProto returns the AggregatorStateProto header, without the state extension.
+func (s *HLL) aggregatorProto() *pb.AggregatorStateProto {
+	var (
+		version   int32 = encodingVersion
+		aggType         = pb.AggregatorType_HYPERLOGLOG_PLUS_UNIQUE
+		numValues       = s.numValues
+	)
+	msg := &pb.AggregatorStateProto{
+		Type:            &aggType,
+		EncodingVersion: &version,
+		NumValues:       &numValues,
+	}
+	if s.valueType != ValueTypeUnknown {
+		valueType := int32(s.valueType)
+		msg.ValueType = &valueType
+	}
+	return msg
+}
+
+func (s *HLL) fromAggregatorProto(msg *pb.AggregatorStateProto, noCopy bool) error {
+	if msg.GetType() != pb.AggregatorType_HYPERLOGLOG_PLUS_UNIQUE {
+		return fmt.Errorf("incompatible binary message: unexpected type %s", msg.GetType().String())
+	}
+	if msg.GetEncodingVersion() != encodingVersion {
+		return fmt.Errorf("incompatible binary message: unsupported encoding version %#v", msg.GetEncodingVersion())
+	}
+	if msg.NumValues == nil {
+		return fmt.Errorf("incompatible binary message: no num values")
+	}
+
+	ext := proto.GetExtension(msg, pb.E_HyperloglogplusUniqueState)
+	state, ok := ext.(*pb.HyperLogLogPlusUniqueStateProto)
+	if !ok {
+		return fmt.Errorf("incompatible binary message: invalid HyperLogLog++ state")
+	}
+
+	restored, err := newFromProto(state, noCopy)
+	if err != nil {
+		return err
+	}
+
+	s.precision = restored.precision
+	s.sparsePrecision = restored.sparsePrecision
+	s.setUnpackedNormal(restored.normal)
+	s.replaceSparse(restored.sparse)
+	s.numValues = msg.GetNumValues()
+	s.valueType = ValueType(msg.GetValueType())
+	s.cached = false
+	s.resetMartingale()
+	s.resetTally()
+	return nil
+}
+
+// MarshalBinary implements encoding.BinaryMarshaler using the same format as Marshal.
+func (s *HLL) MarshalBinary() ([]byte, error) {
+	return s.Marshal()
+}
+
+// UnmarshalBinary implements encoding.BinaryUnmarshaler using the same format as Unmarshal.
+func (s *HLL) UnmarshalBinary(data []byte) error {
+	return s.Unmarshal(data)
+}
+
+type jsonHLL struct {
+	Precision       uint8  `json:"precision"`
+	SparsePrecision uint8  `json:"sparse_precision"`
+	State           []byte `json:"state"`
+}
+
+// MarshalJSON implements json.Marshaler. Sketches are encoded as compact objects containing
+// the precisions and the base64-encoded state in the same format as Marshal.
+func (s *HLL) MarshalJSON() ([]byte, error) {
+	state, err := s.Marshal()
+	if err != nil {
+		return nil, err
+	}
+	return json.Marshal(jsonHLL{
+		Precision:       s.precision,
+		SparsePrecision: s.sparsePrecision,
+		State:           state,
+	})
+}
+
+// UnmarshalJSON implements json.Unmarshaler.
+func (s *HLL) UnmarshalJSON(data []byte) error {
+	var v jsonHLL
+	if err := json.Unmarshal(data, &v); err != nil {
+		return err
+	}
+
+	msg := new(pb.AggregatorStateProto)
+	if err := proto.Unmarshal(v.State, msg); err != nil {
+		return err
+	}
+
+	state, _ := proto.GetExtension(msg, pb.E_HyperloglogplusUniqueState).(*pb.HyperLogLogPlusUniqueStateProto)
+	p, sp := state.GetPrecisionOrNumBuckets(), state.GetSparsePrecisionOrNumBuckets()
+	if state != nil && (p != int32(v.Precision) || sp != int32(v.SparsePrecision)) {
+		return fmt.Errorf("inconsistent JSON message: precisions %d/%d do not match state %d/%d",
+			v.Precision, v.SparsePrecision, p, sp)
+	}
+	return s.fromAggregatorProto(msg, false)
+}
+
+// GobEncode implements gob.GobEncoder. The payload consists of a version byte,
+// followed by the state in the same format as Marshal.
+func (s *HLL) GobEncode() ([]byte, error) {
+	msg, state := s.aggregatorProto(), s.Proto()
+	data := make([]byte, 1, 1+sizeAggregatorProto(msg, state))
+	data[0] = gobVersion
+	return appendAggregatorProto(data, msg, state), nil
+}
+
+// GobDecode implements gob.GobDecoder.
+func (s *HLL) GobDecode(data []byte) error {
+	if len(data) == 0 {
+		return fmt.Errorf("incompatible gob payload: no data")
+	}
+	if data[0] != gobVersion {
+		return fmt.Errorf("incompatible gob payload: unsupported version %d", data[0])
+	}
+	return s.Unmarshal(data[1:])
+}
+
+// ToBase64 returns the base64-encoded state in the same format as Marshal. This
+// is the representation BigQuery uses for sketches in query results and
+// accepts via FROM_BASE64.
+func (s *HLL) ToBase64() string {
+	data, _ := s.Marshal() // all required fields are always set
+	return base64.StdEncoding.EncodeToString(data)
+}
+
+// FromBase64 restores the sketch from a base64-encoded state, as returned by ToBase64
+// or BigQuery's TO_BASE64, replacing its current state. Surrounding whitespace is ignored.
+func (s *HLL) FromBase64(str string) error {
+	data, err := base64.StdEncoding.DecodeString(strings.TrimSpace(str))
+	if err != nil {
+		return err
+	}
+	return s.Unmarshal(data)
+}
+
+// Value implements driver.Valuer, sketches are stored in BYTES or BLOB columns in the
+// same format as Marshal.
+func (s *HLL) Value() (driver.Value, error) {
+	return s.Marshal()
+}
+
+// Scan implements sql.Scanner, it restores the sketch from a BYTES or BLOB column
+// like Unmarshal. NULL values cannot be scanned, use a nullable wrapper or COALESCE
+// instead.
+func (s *HLL) Scan(src interface{}) error {
+	switch v := src.(type) {
+	case []byte:
+		return s.Unmarshal(v)
+	case string:
+		return s.Unmarshal([]byte(v))
+	case nil:
+		return fmt.Errorf("cannot scan NULL into sketch")
+	default:
+		return fmt.Errorf("cannot scan %T into sketch", src)
+	}
+}
@@ -0,0 +1,557 @@
+package hllplus_test
+
+import (
+	"bytes"
+	"database/sql"
+	"database/sql/driver"
+	"encoding"
+	"encoding/gob"
+	"encoding/json"
+	"math/rand"
+	"strings"
+	"testing"
+
+	"github.com/gowthamkommineni/zetasketch/hllplus"
+	pb "github.com/gowthamkommineni/zetasketch/internal/zetasketch"
+	"google.golang.org/protobuf/proto"
+
+	. "github.com/bsm/ginkgo"
+	. "github.com/bsm/gomega"
+)
+
+var _ = Describe("HLL marshaling", func() {
+	var subject *hllplus.HLL
+	var rnd *rand.Rand
+
+	var _ encoding.BinaryMarshaler = subject
+	var _ encoding.BinaryUnmarshaler = subject
+	var _ sql.Scanner = subject
+	var _ driver.Valuer = subject
+
+	BeforeEach(func() {
+		rnd = rand.New(rand.NewSource(7))
+		subject, _ = hllplus.New(12, 17)
+		for i := 0; i < 500; i++ {
+			subject.Add(rnd.Uint64())
+		}
+		subject.AddString("foo")
+		subject.AddString("foo")
+	})
+
+	It("should count values", func() {
+		Expect(subject.NumValues()).To(Equal(int64(502)))
+
+		other, _ := hllplus.New(12, 17)
+		other.AddHashes([]uint64{1, 2, 3})
+		Expect(subject.Merge(other)).To(Succeed())
+		Expect(subject.NumValues()).To(Equal(int64(505)))
+		Expect(subject.Clone().NumValues()).To(Equal(int64(505)))
+	})
+
+	It("should marshal/unmarshal sparse sketches", func() {
+		data, err := subject.Marshal()
+		Expect(err).NotTo(HaveOccurred())
+		Expect(data).To(HaveLen(856))
+
+		restored := new(hllplus.HLL)
+		Expect(restored.Unmarshal(data)).To(Succeed())
+		Expect(restored.IsSparse()).To(BeTrue())
+		Expect(restored.NumValues()).To(Equal(int64(502)))
+		Expect(restored.Estimate()).To(Equal(subject.Estimate()))
+		Expect(restored.Proto()).To(Equal(subject.Proto()))
+	})
+
+	It("should marshal/unmarshal empty sketches", func() {
+		empty, err := hllplus.New(12, 17)
+		Expect(err).NotTo(HaveOccurred())
+		data, err := empty.Marshal()
+		Expect(err).NotTo(HaveOccurred())
+
+		restored := new(hllplus.HLL)
+		Expect(restored.Unmarshal(data)).To(Succeed())
+		Expect(restored.IsSparse()).To(BeTrue())
+		Expect(restored.Marshal()).To(Equal(data))
+
+		noCopy := new(hllplus.HLL)
+		Expect(noCopy.UnmarshalNoCopy(data)).To(Succeed())
+		Expect(noCopy.IsSparse()).To(BeTrue())
+		Expect(noCopy.Marshal()).To(Equal(data))
+
+		// restored sketches stay sparse on first add
+		restored.AddHashes([]uint64{1})
+		Expect(restored.IsSparse()).To(BeTrue())
+		Expect(restored.Estimate()).To(Equal(int64(1)))
+	})
+
+	It("should marshal/unmarshal normal sketches", func() {
+		for i := 0; i < 10_000; i++ {
+			subject.Add(rnd.Uint64())
+		}
+		Expect(subject.IsSparse()).To(BeFalse())
+
+		data, err := subject.Marshal()
+		Expect(err).NotTo(HaveOccurred())
+
+		restored, _ := hllplus.New(10, 15, hllplus.WithSeed(3))
+		restored.AddString("bar")
+		Expect(restored.Unmarshal(data)).To(Succeed())
+		Expect(restored.Precision()).To(Equal(uint8(12)))
+		Expect(restored.SparsePrecision()).To(Equal(uint8(17)))
+		Expect(restored.NumValues()).To(Equal(int64(10_502)))
+		Expect(restored.Estimate()).To(Equal(subject.Estimate()))
+		Expect(restored.Seed()).To(Equal(uint64(3)))
+	})
+
+	It("should unmarshal without copying", func() {
+		data, err := subject.Marshal()
+		Expect(err).NotTo(HaveOccurred())
+
+		restored := new(hllplus.HLL)
+		Expect(restored.UnmarshalNoCopy(data)).To(Succeed())
+		Expect(restored.IsSparse()).To(BeTrue())
+		Expect(restored.NumValues()).To(Equal(int64(502)))
+		Expect(restored.Proto()).To(Equal(subject.Proto()))
+
+		// adding values does not modify data
+		orig := append([]byte(nil), data...)
+		for i := 0; i < 100; i++ {
+			h := rnd.Uint64()
+			restored.Add(h)
+			subject.Add(h)
+		}
+		Expect(restored.Proto()).To(Equal(subject.Proto()))
+		Expect(data).To(Equal(orig))
+
+		for i := 0; i < 10_000; i++ {
+			subject.Add(rnd.Uint64())
+		}
+		data, err = subject.Marshal()
+		Expect(err).NotTo(HaveOccurred())
+		Expect(restored.UnmarshalNoCopy(data)).To(Succeed())
+		Expect(restored.IsSparse()).To(BeFalse())
+		Expect(restored.Estimate()).To(Equal(subject.Estimate()))
+
+		// registers alias data
+		for i := range data {
+			data[i] = 0
+		}
+		Expect(restored.Proto().Data).To(Equal(make([]byte, 1<<12)))
+	})
+
+	It("should reject invalid messages without copying", func() {
+		data, _ := subject.Marshal()
+		Expect(subject.UnmarshalNoCopy(data[:len(data)-3])).To(MatchError("unexpected EOF"))
+		Expect(subject.UnmarshalNoCopy(nil)).To(MatchError("incompatible binary message: unexpected type SUM"))
+	})
+
+	It("should predict the serialized size", func() {
+		empty, _ := hllplus.New(12, 17)
+		normal, _ := hllplus.NewNormal(12)
+		for i := 0; i < 10_000; i++ {
+			normal.Add(rnd.Uint64())
+		}
+		emptyNormal, _ := hllplus.NewNormal(12)
+
+		for _, s := range []*hllplus.HLL{subject, empty, normal, emptyNormal} {
+			data, err := s.Marshal()
+			Expect(err).NotTo(HaveOccurred())
+			Expect(s.ProtoSize()).To(Equal(len(data)))
+		}
+		Expect(subject.ProtoSize()).To(Equal(856))
+		Expect(normal.ProtoSize()).To(Equal(4_114))
+		Expect(testing.AllocsPerRun(10, func() { normal.ProtoSize() })).To(BeZero())
+	})
+
+	It("should convert to/from base64", func() {
+		subject, _ = hllplus.New(10, 15)
+		subject.AddString("foo")
+		subject.AddString("bar")
+		Expect(subject.ToBase64()).To(Equal("CHAQAhgCIAuCBw0QAhgKIA8yBfEe7bEB"))
+
+		restored := new(hllplus.HLL)
+		Expect(restored.FromBase64(" CHAQAhgCIAuCBw0QAhgKIA8yBfEe7bEB\n")).To(Succeed())
+		Expect(restored.NumValues()).To(Equal(int64(2)))
+		Expect(restored.Estimate()).To(Equal(int64(2)))
+		Expect(restored.Proto()).To(Equal(subject.Proto()))
+
+		Expect(restored.FromBase64("!")).To(MatchError("illegal base64 data at input byte 0"))
+	})
+
+	It("should serialize deterministically", func() {
+		hashes := make([]uint64, 2_000)
+		for i := range hashes {
+			hashes[i] = rnd.Uint64()
+		}
+
+		// same sparse index, different rhoW'
+		a := uint64(0x400)<<47 | 1<<20
+		b := uint64(0x400)<<47 | 1<<40
+
+		s1, _ := hllplus.New(12, 17)
+		s1.AddHashes(hashes)
+		s1.Add(b)
+		s1.Add(a)
+
+		s2, _ := hllplus.New(12, 17)
+		s2.Add(a)
+		for i := len(hashes) - 1; i >= 0; i-- {
+			s2.Add(hashes[i])
+		}
+		s2.Add(a)
+
+		Expect(s1.IsSparse()).To(BeTrue())
+		Expect(s1.Proto()).To(Equal(s2.Proto()))
+
+		d1, err := s1.Marshal()
+		Expect(err).NotTo(HaveOccurred())
+		d2, err := s2.Marshal()
+		Expect(err).NotTo(HaveOccurred())
+		Expect(d1).To(Equal(d2))
+		Expect(s1.Clone().Marshal()).To(Equal(d1))
+
+		restored := new(hllplus.HLL)
+		Expect(restored.Unmarshal(d1)).To(Succeed())
+		Expect(restored.Marshal()).To(Equal(d1))
+
+		for i := 0; i < 10_000; i++ {
+			h := rnd.Uint64()
+			s1.Add(h)
+			s2.Add(h)
+		}
+		Expect(s1.IsSparse()).To(BeFalse())
+		d1, _ = s1.Marshal()
+		d2, _ = s2.Marshal()
+		Expect(d1).To(Equal(d2))
+	})
+
+	It("should marshal/unmarshal binary", func() {
+		data, err := subject.MarshalBinary()
+		Expect(err).NotTo(HaveOccurred())
+		Expect(subject.Marshal()).To(Equal(data))
+
+		restored := new(hllplus.HLL)
+		Expect(restored.UnmarshalBinary(data)).To(Succeed())
+		Expect(restored.NumValues()).To(Equal(int64(502)))
+		Expect(restored.Estimate()).To(Equal(subject.Estimate()))
+	})
+
+	It("should implement database/sql interfaces", func() {
+		value, err := subject.Value()
+		Expect(err).NotTo(HaveOccurred())
+		Expect(driver.IsValue(value)).To(BeTrue())
+		Expect(subject.Marshal()).To(Equal(value))
+
+		restored := new(hllplus.HLL)
+		Expect(restored.Scan(value)).To(Succeed())
+		Expect(restored.NumValues()).To(Equal(int64(502)))
+		Expect(restored.Proto()).To(Equal(subject.Proto()))
+
+		// drivers may reuse the scanned bytes
+		buf := value.([]byte)
+		restored = new(hllplus.HLL)
+		Expect(restored.Scan(buf)).To(Succeed())
+		for i := range buf {
+			buf[i] = 0
+		}
+		Expect(restored.Estimate()).To(Equal(subject.Estimate()))
+
+		data, _ := subject.Marshal()
+		restored = new(hllplus.HLL)
+		Expect(restored.Scan(string(data))).To(Succeed())
+		Expect(restored.Proto()).To(Equal(subject.Proto()))
+
+		Expect(restored.Scan(nil)).To(MatchError("cannot scan NULL into sketch"))
+		Expect(restored.Scan(int64(1))).To(MatchError("cannot scan int64 into sketch"))
+	})
+
+	It("should marshal/unmarshal JSON", func() {
+		data, err := json.Marshal(map[string]*hllplus.HLL{"visitors": subject})
+		Expect(err).NotTo(HaveOccurred())
+		Expect(string(data)).To(HavePrefix(`{"visitors":{"precision":12,"sparse_precision":17,"state":"`))
+
+		var restored map[string]*hllplus.HLL
+		Expect(json.Unmarshal(data, &restored)).To(Succeed())
+		Expect(restored).To(HaveKey("visitors"))
+		Expect(restored["visitors"].NumValues()).To(Equal(int64(502)))
+		Expect(restored["visitors"].Estimate()).To(Equal(subject.Estimate()))
+		Expect(restored["visitors"].Proto()).To(Equal(subject.Proto()))
+	})
+
+	It("should reject inconsistent JSON", func() {
+		data, _ := subject.MarshalJSON()
+		data = []byte(strings.Replace(string(data), `"precision":12`, `"precision":14`, 1))
+
+		restored := new(hllplus.HLL)
+		Expect(restored.UnmarshalJSON(data)).To(MatchError("inconsistent JSON message: precisions 14/17 do not match state 12/17"))
+		Expect(restored.UnmarshalJSON([]byte(`{"state":"!"}`))).To(HaveOccurred())
+	})
+
+	It("should encode/decode gob", func() {
+		type snapshot struct {
+			Name   string
+			Sketch *hllplus.HLL
+			Any    interface{}
+		}
+
+		buf := new(bytes.Buffer)
+		Expect(gob.NewEncoder(buf).Encode(snapshot{Name: "visitors", Sketch: subject, Any: subject})).To(Succeed())
+
+		var restored snapshot
+		Expect(gob.NewDecoder(buf).Decode(&restored)).To(Succeed())
+		Expect(restored.Name).To(Equal("visitors"))
+		Expect(restored.Sketch.NumValues()).To(Equal(int64(502)))
+		Expect(restored.Sketch.Proto()).To(Equal(subject.Proto()))
+		Expect(restored.Any).To(BeAssignableToTypeOf(subject))
+		Expect(restored.Any.(*hllplus.HLL).Estimate()).To(Equal(subject.Estimate()))
+	})
+
+	It("should reject incompatible gob payloads", func() {
+		data, err := subject.GobEncode()
+		Expect(err).NotTo(HaveOccurred())
+		Expect(data[0]).To(Equal(byte(1)))
+
+		restored := new(hllplus.HLL)
+		Expect(restored.GobDecode(data)).To(Succeed())
+		Expect(restored.GobDecode(nil)).To(MatchError("incompatible gob payload: no data"))
+		Expect(restored.GobDecode([]byte{9})).To(MatchError("incompatible gob payload: unsupported version 9"))
+	})
+
+	It("should wrap the state into an aggregator envelope", func() {
+		data, err := subject.Marshal()
+		Expect(err).NotTo(HaveOccurred())
+
+		msg := new(pb.AggregatorStateProto)
+		Expect(proto.Unmarshal(data, msg)).To(Succeed())
+		Expect(msg.GetType()).To(Equal(pb.AggregatorType_HYPERLOGLOG_PLUS_UNIQUE))
+		Expect(msg.GetEncodingVersion()).To(Equal(int32(2)))
+		Expect(msg.GetNumValues()).To(Equal(int64(502)))
+		state := proto.GetExtension(msg, pb.E_HyperloglogplusUniqueState).(*pb.HyperLogLogPlusUniqueStateProto)
+		Expect(proto.Equal(state, subject.Proto())).To(BeTrue())
+	})
+
+	It("should emit and restore value types", func() {
+		data, err := subject.Marshal()
+		Expect(err).NotTo(HaveOccurred())
+
+		msg := new(pb.AggregatorStateProto)
+		Expect(proto.Unmarshal(data, msg)).To(Succeed())
+		Expect(msg.GetValueType()).To(Equal(int32(pb.DefaultOpsType_BYTES_OR_UTF8_STRING)))
+
+		restored, _ := hllplus.New(12, 17, hllplus.WithValueType(hllplus.ValueTypeInt64))
+		Expect(restored.Unmarshal(data)).To(Succeed())
+		Expect(restored.ValueType()).To(Equal(hllplus.ValueTypeBytes))
+
+		// unknown value types are preserved
+		msg.ValueType = proto.Int32(42)
+		data, _ = proto.Marshal(msg)
+		Expect(restored.Unmarshal(data)).To(Succeed())
+		Expect(restored.ValueType()).To(Equal(hllplus.ValueType(42)))
+		data, _ = restored.Marshal()
+		Expect(proto.Unmarshal(data, msg)).To(Succeed())
+		Expect(msg.GetValueType()).To(Equal(int32(42)))
+
+		untyped, _ := hllplus.New(12, 17)
+		untyped.Add(1)
+		data, _ = untyped.Marshal()
+		Expect(restored.Unmarshal(data)).To(Succeed())
+		Expect(restored.ValueType()).To(Equal(hllplus.ValueTypeUnknown))
+	})
+
+	It("should merge serialized sketches", func() {
+		normal, _ := hllplus.New(13, 18)
+		for i := 0; i < 20_000; i++ {
+			normal.Add(rnd.Uint64())
+		}
+		sparse, _ := hllplus.New(14, 19)
+		for i := 0; i < 300; i++ {
+			sparse.Add(rnd.Uint64())
+		}
+
+		acc := subject.Clone()
+		expected := subject.Clone()
+		for _, other := range []*hllplus.HLL{sparse, normal, sparse, subject} {
+			data, err := other.Marshal()
+			Expect(err).NotTo(HaveOccurred())
+			Expect(acc.MergeProtoBytes(data)).To(Succeed())
+
+			restored := new(hllplus.HLL)
+			Expect(restored.Unmarshal(data)).To(Succeed())
+			Expect(expected.Merge(restored)).To(Succeed())
+
+			Expect(acc.Proto()).To(Equal(expected.Proto()))
+			Expect(acc.NumValues()).To(Equal(expected.NumValues()))
+		}
+		Expect(acc.Precision()).To(Equal(uint8(12)))
+		Expect(acc.IsSparse()).To(BeFalse())
+		Expect(acc.Estimate()).To(Equal(expected.Estimate()))
+	})
+
+	It("should merge serialized sketches with fewer allocations", func() {
+		data, _ := subject.Marshal()
+		acc, _ := hllplus.New(12, 17)
+
+		direct := testing.AllocsPerRun(10, func() { _ = acc.MergeProtoBytes(data) })
+		restored := testing.AllocsPerRun(10, func() {
+			other := new(hllplus.HLL)
+			_ = other.Unmarshal(data)
+			_ = acc.Merge(other)
+		})
+		Expect(direct).To(BeNumerically("<=", restored/2))
+	})
+
+	It("should reject invalid messages when merging", func() {
+		acc := subject.Clone()
+
+		numValues := int64(1)
+		aggType := pb.AggregatorType_SUM
+		data, _ := proto.Marshal(&pb.AggregatorStateProto{Type: &aggType, NumValues: &numValues})
+		Expect(acc.MergeProtoBytes(data)).To(MatchError("incompatible binary message: unexpected type SUM"))
+
+		aggType = pb.AggregatorType_HYPERLOGLOG_PLUS_UNIQUE
+		data, _ = proto.Marshal(&pb.AggregatorStateProto{Type: &aggType, NumValues: &numValues})
+		Expect(acc.MergeProtoBytes(data)).To(MatchError("incompatible binary message: unsupported encoding version 1"))
+
+		other, _ := hllplus.NewNormal(12)
+		other.AddInt64(1)
+		data, _ = other.Marshal()
+		Expect(acc.MergeProtoBytes(data)).To(MatchError("cannot merge sketches with different value types BYTES_OR_UTF8_STRING and INT64"))
+		Expect(acc.MergeProtoBytes(data[:len(data)-1])).NotTo(Succeed())
+		Expect(acc.MergeProtoBytes([]byte("bad"))).NotTo(Succeed())
+
+		Expect(acc.Proto()).To(Equal(subject.Proto()))
+		Expect(acc.NumValues()).To(Equal(subject.NumValues()))
+	})
+
+	It("should reject invalid messages", func() {
+		numValues := int64(1)
+		aggType := pb.AggregatorType_SUM
+		data, _ := proto.Marshal(&pb.AggregatorStateProto{Type: &aggType, NumValues: &numValues})
+		Expect(subject.Unmarshal(data)).To(MatchError("incompatible binary message: unexpected type SUM"))
+
+		aggType = pb.AggregatorType_HYPERLOGLOG_PLUS_UNIQUE
+		version := int32(1)
+		data, _ = proto.Marshal(&pb.AggregatorStateProto{Type: &aggType, EncodingVersion: &version, NumValues: &numValues})
+		Expect(subject.Unmarshal(data)).To(MatchError("incompatible binary message: unsupported encoding version 1"))
+
+		data, _ = proto.Marshal(&pb.AggregatorStateProto{Type: &aggType, NumValues: &numValues})
+		Expect(subject.Unmarshal(data)).To(MatchError("incompatible binary message: unsupported encoding version 1"))
+
+		Expect(subject.Unmarshal([]byte("bad"))).NotTo(Succeed())
+		Expect(subject.NumValues()).To(Equal(int64(502)))
+	})
+
+	It("should reject malformed states", func() {
+		precision, sparsePrecision := int32(12), int32(17)
+		marshal := func(state *pb.HyperLogLogPlusUniqueStateProto) []byte {
+			data, _ := subject.Marshal()
+			msg := new(pb.AggregatorStateProto)
+			Expect(proto.Unmarshal(data, msg)).To(Succeed())
+			proto.SetExtension(msg, pb.E_HyperloglogplusUniqueState, state)
+			data, _ = proto.Marshal(msg)
+			return data
+		}
+
+		// too few registers
+		short := &pb.HyperLogLogPlusUniqueStateProto{
+			PrecisionOrNumBuckets:       &precision,
+			SparsePrecisionOrNumBuckets: &sparsePrecision,
+			Data:                        make([]byte, 8),
+		}
+		data := marshal(short)
+		Expect(subject.Unmarshal(data)).To(MatchError("invalid normal data: 8 registers, expected 4096"))
+		Expect(subject.UnmarshalNoCopy(data)).To(MatchError("invalid normal data: 8 registers, expected 4096"))
+		_, err := hllplus.NewFromProto(short)
+		Expect(err).To(MatchError("invalid normal data: 8 registers, expected 4096"))
+		_, err = hllplus.NewLazyFromProto(short)
+		Expect(err).To(MatchError("invalid normal data: 8 registers, expected 4096"))
+		Expect(subject.MergeProtoBytes(data)).To(MatchError("incompatible binary message: 8 registers, expected 4096"))
+
+		// too many registers
+		short.Data = make([]byte, 4097)
+		Expect(subject.Unmarshal(marshal(short))).To(MatchError("invalid normal data: 4097 registers, expected 4096"))
+
+		// a sparse index of 1<<17
+		outOfRange := &pb.HyperLogLogPlusUniqueStateProto{
+			PrecisionOrNumBuckets:       &precision,
+			SparsePrecisionOrNumBuckets: &sparsePrecision,
+			SparseData:                  []byte{0x80, 0x80, 0x08},
+		}
+		data = marshal(outOfRange)
+		Expect(subject.Unmarshal(data)).To(MatchError("invalid sparse data: index 131072, expected less than 131072"))
+		Expect(subject.MergeProtoBytes(data)).To(MatchError("incompatible binary message: invalid sparse data: index 131072, expected less than 131072"))
+		_, err = hllplus.NewLazyFromProto(outOfRange)
+		Expect(err).To(MatchError("invalid sparse data: index 131072, expected less than 131072"))
+
+		outOfRange.SparseData = []byte{0x80, 0x80, 0x30} // encoded flag 1<<18 | normal index 1<<13
+		Expect(subject.Unmarshal(marshal(outOfRange))).To(MatchError("invalid sparse data: index 262144, expected less than 131072"))
+
+		// valid indexes are accepted
+		outOfRange.SparseData = []byte{0xff, 0xff, 0x07}
+		restored, err := hllplus.NewFromProto(outOfRange)
+		Expect(err).NotTo(HaveOccurred())
+		Expect(restored.Estimate()).To(Equal(int64(1)))
+
+		Expect(subject.NumValues()).To(Equal(int64(502)))
+		restored.Add(rnd.Uint64())
+	})
+})
+
+func BenchmarkHLL_Unmarshal(b *testing.B) {
+	rnd := rand.New(rand.NewSource(33))
+	subject, _ := hllplus.New(15, 20)
+	for i := 0; i < 100_000; i++ {
+		subject.Add(rnd.Uint64())
+	}
+	data, _ := subject.Marshal()
+
+	b.Run("copy", func(b *testing.B) {
+		b.ReportAllocs()
+		for i := 0; i < b.N; i++ {
+			if err := new(hllplus.HLL).Unmarshal(data); err != nil {
+				b.Fatal(err)
+			}
+		}
+	})
+
+	b.Run("no copy", func(b *testing.B) {
+		b.ReportAllocs()
+		for i := 0; i < b.N; i++ {
+			if err := new(hllplus.HLL).UnmarshalNoCopy(data); err != nil {
+				b.Fatal(err)
+			}
+		}
+	})
+}
+
+func BenchmarkHLL_MergeProtoBytes(b *testing.B) {
+	rnd := rand.New(rand.NewSource(33))
+	subject, _ := hllplus.New(15, 20)
+	for i := 0; i < 100_000; i++ {
+		subject.Add(rnd.Uint64())
+	}
+	data, _ := subject.Marshal()
+
+	b.Run("direct", func(b *testing.B) {
+		acc, _ := hllplus.New(15, 20)
+		b.ReportAllocs()
+		for i := 0; i < b.N; i++ {
+			if err := acc.MergeProtoBytes(data); err != nil {
+				b.Fatal(err)
+			}
+		}
+	})
+
+	b.Run("unmarshal", func(b *testing.B) {
+		acc, _ := hllplus.New(15, 20)
+		b.ReportAllocs()
+		for i := 0; i < b.N; i++ {
+			other := new(hllplus.HLL)
+			if err := other.Unmarshal(data); err != nil {
+				b.Fatal(err)
+			}
+			if err := acc.Merge(other); err != nil {
+				b.Fatal(err)
+			}
+		}
+	})
+}
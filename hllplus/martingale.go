@@ -0,0 +1,50 @@
+package hllplus
+
+// martingale maintains a running historic inverse probability (HIP) estimate, as described in
+// "All-Distances Sketches, Revisited: HIP Estimators for Massive Graphs Analysis" (Cohen, 2014)
+// and "Streamed Approximate Counting of Distinct Elements" (Ting, 2014).
+//
+// Every time a register is increased, the estimate is incremented by the inverse of the
+// probability that a new value modifies the sketch. This makes Estimate O(1) in normal
+// representation. The estimate is not mergeable, so it is re-seeded from the regular
+// estimator after a merge or a downgrade. Sparse sketches use the regular estimator.
+type martingale struct {
+	estimate float64 // the running estimate
+	sum      float64 // the sum of 2^-rhoW over all registers
+	seeded   bool
+}
+
+func (m *martingale) Clone() *martingale {
+	if m == nil {
+		return nil
+	}
+
+	clone := *m
+	return &clone
+}
+
+// updateMartingale must be called before register is increased from old to rhoW.
+func (s *HLL) updateMartingale(old, rhoW uint8) {
+	if !s.martingale.seeded {
+		s.seedMartingale()
+	}
+
+	s.martingale.estimate += float64(uint64(1)<<s.precision) / s.martingale.sum
+	s.martingale.sum += inversePow2(rhoW) - inversePow2(old)
+}
+
+func (s *HLL) seedMartingale() {
+	n := s.estimateNormal(s.estimator).Estimate
+
+	_, sum := s.normalStats()
+
+	s.martingale.estimate = float64(n)
+	s.martingale.sum = sum
+	s.martingale.seeded = true
+}
+
+func (s *HLL) resetMartingale() {
+	if s.martingale != nil {
+		s.martingale.seeded = false
+	}
+}
@@ -0,0 +1,246 @@
+package hllplus
+
+import (
+	"context"
+	"fmt"
+	"runtime"
+	"sort"
+	"sync"
+)
+
+// mergeBatchSize is the number of sketches a MergeAll worker claims at once.
+const mergeBatchSize = 64
+
+// MergeAll merges sketches into a new sketch, using up to parallelism goroutines. If
+// parallelism is <= 0, runtime.GOMAXPROCS(0) is used. Workers merge batches of sketches
+// into partial results, which are then combined by pairwise tree reduction.
+//
+// Input sketches are not modified and must not be modified concurrently. Nil sketches
+// are ignored; MergeAll returns nil if there is nothing to merge. Like with Merge,
+// sketches of different precisions are downgraded to the lowest precision. The first
+// error, including cancellation of ctx, stops all workers and is returned.
+func MergeAll(ctx context.Context, sketches []*HLL, parallelism int) (*HLL, error) {
+	if parallelism <= 0 {
+		parallelism = runtime.GOMAXPROCS(0)
+	}
+	if n := (len(sketches) + mergeBatchSize - 1) / mergeBatchSize; parallelism > n {
+		parallelism = n
+	}
+	if parallelism <= 1 {
+		return mergeBatch(ctx, nil, sketches)
+	}
+
+	ctx, cancel := context.WithCancel(ctx)
+	defer cancel()
+
+	batches := make(chan []*HLL)
+	go func() {
+		defer close(batches)
+
+		for i := 0; i < len(sketches); i += mergeBatchSize {
+			j := i + mergeBatchSize
+			if j > len(sketches) {
+				j = len(sketches)
+			}
+			select {
+			case batches <- sketches[i:j]:
+			case <-ctx.Done():
+				return
+			}
+		}
+	}()
+
+	partials := make([]*HLL, parallelism)
+	errs := make([]error, parallelism)
+	var wg sync.WaitGroup
+	for w := 0; w < parallelism; w++ {
+		wg.Add(1)
+		go func(w int) {
+			defer wg.Done()
+
+			var acc *HLL
+			for batch := range batches {
+				var err error
+				if acc, err = mergeBatch(ctx, acc, batch); err != nil {
+					errs[w] = err
+					cancel()
+					return
+				}
+			}
+			partials[w] = acc
+		}(w)
+	}
+	wg.Wait()
+
+	if err := firstError(ctx, errs); err != nil {
+		return nil, err
+	}
+	return reducePartials(ctx, partials)
+}
+
+// reducePartials merges partial results pairwise, halving their number in each round.
+// Partials are owned by the caller and merged in place.
+func reducePartials(ctx context.Context, partials []*HLL) (*HLL, error) {
+	for len(partials) > 1 {
+		half := (len(partials) + 1) / 2
+		errs := make([]error, len(partials)/2)
+
+		var wg sync.WaitGroup
+		for i := range errs {
+			wg.Add(1)
+			go func(i int) {
+				defer wg.Done()
+
+				dst, src := partials[i], partials[half+i]
+				switch {
+				case src == nil:
+				case dst == nil:
+					partials[i] = src
+				default:
+					errs[i] = dst.Merge(src)
+				}
+			}(i)
+		}
+		wg.Wait()
+
+		if err := firstError(ctx, errs); err != nil {
+			return nil, err
+		}
+		partials = partials[:half]
+	}
+	return partials[0], nil
+}
+
+// mergeBatch merges sketches into acc, which is allocated on demand.
+func mergeBatch(ctx context.Context, acc *HLL, sketches []*HLL) (*HLL, error) {
+	for _, s := range sketches {
+		if err := ctx.Err(); err != nil {
+			return nil, err
+		}
+
+		if s == nil {
+			continue
+		}
+		if acc == nil {
+			acc = s.Clone()
+		} else if err := acc.Merge(s); err != nil {
+			return nil, err
+		}
+	}
+	return acc, nil
+}
+
+// firstError returns the first non-cancellation error, falling back to ctx.Err().
+func firstError(ctx context.Context, errs []error) error {
+	for _, err := range errs {
+		if err != nil && err != context.Canceled {
+			return err
+		}
+	}
+	return ctx.Err()
+}
+
+// MergeMany merges sketches of mixed precisions into a new sketch. Instead of merging
+// sketches one by one, which repeatedly downgrades and re-encodes the accumulator, it
+// plans the merge: sketches are grouped by precision and representation, each group is
+// merged at its own precision and then downgraded exactly once to the lowest precision.
+// This also keeps sparse sketches sparse for as long as possible.
+//
+// Input sketches are not modified. Nil sketches are ignored; MergeMany returns nil if
+// there is nothing to merge. All sketches are checked for compatibility up front, so an
+// error is returned before any merging work is done.
+func MergeMany(sketches ...*HLL) (*HLL, error) {
+	return MergeManyContext(context.Background(), sketches...)
+}
+
+// MergeManyContext is like MergeMany, but checks ctx between merges and while
+// downgrading, and returns ctx.Err() on cancellation.
+func MergeManyContext(ctx context.Context, sketches ...*HLL) (*HLL, error) {
+	plan, err := planMerge(sketches)
+	if err != nil || len(plan.groups) == 0 {
+		return nil, err
+	}
+
+	var acc *HLL
+	for _, group := range plan.groups {
+		res, err := mergeBatch(ctx, nil, group)
+		if err != nil {
+			return nil, err
+		}
+		if err := res.DowngradeContext(ctx, plan.precision, plan.sparsePrecision); err != nil {
+			return nil, err
+		}
+
+		if acc == nil {
+			acc = res
+		} else if err := acc.Merge(res); err != nil {
+			return nil, err
+		}
+	}
+	return acc, nil
+}
+
+// mergePlan groups sketches of equal precisions and representation.
+type mergePlan struct {
+	groups          [][]*HLL
+	precision       uint8 // the target precision
+	sparsePrecision uint8 // the target sparse precision
+}
+
+type mergeGroupKey struct {
+	precision, sparsePrecision uint8
+	sparse                     bool
+}
+
+func planMerge(sketches []*HLL) (*mergePlan, error) {
+	plan := new(mergePlan)
+	index := make(map[mergeGroupKey]int)
+
+	var first *HLL
+	valueType := ValueTypeUnknown
+	for _, s := range sketches {
+		if s == nil {
+			continue
+		}
+
+		if first == nil {
+			first = s
+			plan.precision, plan.sparsePrecision = s.precision, s.sparsePrecision
+		} else if err := first.checkHasher(s); err != nil {
+			return nil, err
+		}
+
+		if valueType == ValueTypeUnknown {
+			valueType = s.valueType
+		} else if s.valueType != valueType && s.valueType != ValueTypeUnknown {
+			return nil, fmt.Errorf("cannot merge sketches with different value types %s and %s", valueType, s.valueType)
+		}
+
+		if s.precision < plan.precision {
+			plan.precision = s.precision
+		}
+		if s.sparsePrecision < plan.sparsePrecision {
+			plan.sparsePrecision = s.sparsePrecision
+		}
+
+		key := mergeGroupKey{precision: s.precision, sparsePrecision: s.sparsePrecision, sparse: s.sparse != nil}
+		i, ok := index[key]
+		if !ok {
+			i = len(plan.groups)
+			index[key] = i
+			plan.groups = append(plan.groups, nil)
+		}
+		plan.groups[i] = append(plan.groups[i], s)
+	}
+
+	// Start with the largest normal group, so that the accumulator is normalized right
+	// away and all remaining groups can be merged into it.
+	sort.SliceStable(plan.groups, func(i, j int) bool {
+		a, b := plan.groups[i][0], plan.groups[j][0]
+		if an, bn := a.sparse == nil, b.sparse == nil; an != bn {
+			return an
+		}
+		return len(plan.groups[i]) > len(plan.groups[j])
+	})
+	return plan, nil
+}
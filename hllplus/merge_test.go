@@ -0,0 +1,169 @@
+package hllplus_test
+
+import (
+	"context"
+	"fmt"
+	"math/rand"
+	"testing"
+
+	"github.com/gowthamkommineni/zetasketch/hllplus"
+
+	. "github.com/bsm/ginkgo"
+	. "github.com/bsm/gomega"
+)
+
+var _ = Describe("MergeAll", func() {
+	var sketches []*hllplus.HLL
+	var expected *hllplus.HLL
+
+	BeforeEach(func() {
+		rnd := rand.New(rand.NewSource(3))
+		sketches = make([]*hllplus.HLL, 1000)
+		expected, _ = hllplus.New(12, 17)
+		for i := range sketches {
+			precision := uint8(12 + i%3)
+			sketches[i], _ = hllplus.New(precision, precision+5)
+			for j := 0; j < i%50; j++ {
+				hash := rnd.Uint64()
+				sketches[i].Add(hash)
+				expected.Add(hash)
+			}
+		}
+	})
+
+	It("should merge", func() {
+		for _, parallelism := range []int{0, 1, 3, 8, 100} {
+			res, err := hllplus.MergeAll(context.Background(), sketches, parallelism)
+			Expect(err).NotTo(HaveOccurred())
+			Expect(res.Precision()).To(Equal(uint8(12)))
+			Expect(res.NumValues()).To(Equal(expected.NumValues()))
+			Expect(res.Registers()).To(Equal(expected.Registers()), "parallelism %d", parallelism)
+			Expect(res.Estimate()).To(Equal(expected.Estimate()))
+		}
+	})
+
+	It("should not modify inputs", func() {
+		before := sketches[1].Clone()
+		_, err := hllplus.MergeAll(context.Background(), sketches, 4)
+		Expect(err).NotTo(HaveOccurred())
+		Expect(sketches[1].NumValues()).To(Equal(before.NumValues()))
+		Expect(sketches[1].Registers()).To(Equal(before.Registers()))
+	})
+
+	It("should skip nil sketches", func() {
+		Expect(hllplus.MergeAll(context.Background(), nil, 4)).To(BeNil())
+		Expect(hllplus.MergeAll(context.Background(), make([]*hllplus.HLL, 200), 4)).To(BeNil())
+
+		sketches[0] = nil // empty anyway
+		sketches[500] = nil
+		res, err := hllplus.MergeAll(context.Background(), sketches, 4)
+		Expect(err).NotTo(HaveOccurred())
+		Expect(res.Registers()).To(Equal(expected.Registers()))
+	})
+
+	It("should fail on incompatible sketches", func() {
+		sketches[700].AddInt64(1)
+		sketches[900].AddString("x")
+		_, err := hllplus.MergeAll(context.Background(), sketches, 4)
+		Expect(err).To(MatchError(ContainSubstring("cannot merge sketches with different value types")))
+	})
+
+	It("should respect cancellation", func() {
+		ctx, cancel := context.WithCancel(context.Background())
+		cancel()
+
+		_, err := hllplus.MergeAll(ctx, sketches, 4)
+		Expect(err).To(MatchError(context.Canceled))
+		_, err = hllplus.MergeAll(ctx, sketches, 1)
+		Expect(err).To(MatchError(context.Canceled))
+	})
+})
+
+var _ = Describe("MergeMany", func() {
+	var sketches []*hllplus.HLL
+
+	BeforeEach(func() {
+		rnd := rand.New(rand.NewSource(5))
+		sketches = make([]*hllplus.HLL, 60)
+		for i := range sketches {
+			precision := uint8(12 + i%4)
+			sketches[i], _ = hllplus.New(precision, precision+5)
+			for j := 0; j < i*i; j++ {
+				sketches[i].Add(rnd.Uint64())
+			}
+		}
+	})
+
+	It("should merge", func() {
+		expected := sketches[0].Clone()
+		for _, s := range sketches[1:] {
+			Expect(expected.Merge(s)).To(Succeed())
+		}
+
+		res, err := hllplus.MergeMany(sketches...)
+		Expect(err).NotTo(HaveOccurred())
+		Expect(res.Precision()).To(Equal(uint8(12)))
+		Expect(res.SparsePrecision()).To(Equal(uint8(17)))
+		Expect(res.NumValues()).To(Equal(expected.NumValues()))
+		Expect(res.Registers()).To(Equal(expected.Registers()))
+		Expect(res.Estimate()).To(Equal(expected.Estimate()))
+	})
+
+	It("should keep sparse sketches sparse", func() {
+		a, _ := hllplus.New(14, 20)
+		a.AddString("foo")
+		b, _ := hllplus.New(12, 17)
+		b.AddString("bar")
+
+		res, err := hllplus.MergeMany(a, nil, b)
+		Expect(err).NotTo(HaveOccurred())
+		Expect(res.Precision()).To(Equal(uint8(12)))
+		Expect(res.SparsePrecision()).To(Equal(uint8(17)))
+		Expect(res.Estimate()).To(Equal(int64(2)))
+		Expect(a.Precision()).To(Equal(uint8(14)))
+	})
+
+	It("should respect cancellation", func() {
+		ctx, cancel := context.WithCancel(context.Background())
+		cancel()
+
+		_, err := hllplus.MergeManyContext(ctx, sketches...)
+		Expect(err).To(MatchError(context.Canceled))
+	})
+
+	It("should skip nil sketches", func() {
+		Expect(hllplus.MergeMany()).To(BeNil())
+		Expect(hllplus.MergeMany(nil, nil)).To(BeNil())
+	})
+
+	It("should fail on incompatible sketches", func() {
+		sketches[30].AddInt64(1)
+		sketches[50].AddString("x")
+		_, err := hllplus.MergeMany(sketches...)
+		Expect(err).To(MatchError("cannot merge sketches with different value types INT64 and BYTES_OR_UTF8_STRING"))
+
+		seeded, _ := hllplus.New(12, 17, hllplus.WithSeed(1))
+		_, err = hllplus.MergeMany(sketches[0], seeded)
+		Expect(err).To(MatchError("cannot merge sketches with different seeds 0 and 1"))
+	})
+})
+
+func BenchmarkMergeAll(b *testing.B) {
+	sketches := make([]*hllplus.HLL, 10_000)
+	for i := range sketches {
+		sketches[i], _ = hllplus.New(14, 19)
+		for j := 0; j < 1000; j++ {
+			sketches[i].Add(rand.Uint64())
+		}
+	}
+
+	for _, parallelism := range []int{1, 4} {
+		b.Run(fmt.Sprintf("parallelism=%d", parallelism), func(b *testing.B) {
+			for i := 0; i < b.N; i++ {
+				if _, err := hllplus.MergeAll(context.Background(), sketches, parallelism); err != nil {
+					b.Fatal(err)
+				}
+			}
+		})
+	}
+}
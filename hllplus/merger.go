@@ -0,0 +1,117 @@
+package hllplus
+
+import (
+	"context"
+	"runtime"
+	"sync"
+
+	pb "github.com/gowthamkommineni/zetasketch/internal/zetasketch"
+)
+
+// Merger merges streams of serialized sketches, e.g. fanned in from message queue
+// consumers. Messages are decoded and merged concurrently by a fixed number of workers,
+// each holding a single accumulator, so memory is bounded regardless of the length of
+// the stream. A Merger is stateless and may be reused.
+type Merger struct {
+	parallelism int
+	opts        []Option
+}
+
+// NewMerger inits a new Merger with up to parallelism workers. If parallelism is <= 0,
+// runtime.GOMAXPROCS(0) is used. Options are applied to the resulting sketch.
+func NewMerger(parallelism int, opts ...Option) *Merger {
+	if parallelism <= 0 {
+		parallelism = runtime.GOMAXPROCS(0)
+	}
+	return &Merger{parallelism: parallelism, opts: opts}
+}
+
+// MergeProtos consumes proto messages from ch until it is closed and returns the merged
+// sketch, or nil if no messages were received. Messages are not modified. Nil messages
+// are ignored.
+//
+// On error or cancellation of ctx, the Merger stops consuming and returns immediately,
+// producers must therefore not block on sends indefinitely.
+func (m *Merger) MergeProtos(ctx context.Context, ch <-chan *pb.HyperLogLogPlusUniqueStateProto) (*HLL, error) {
+	return runMerger(ctx, m.parallelism, ch, func(msg *pb.HyperLogLogPlusUniqueStateProto, acc *HLL) (*HLL, error) {
+		if msg == nil {
+			return acc, nil
+		}
+
+		s, err := NewFromProto(msg, m.opts...)
+		if err != nil {
+			return nil, err
+		}
+		if acc == nil {
+			return s.Clone(), nil // do not alias msg
+		}
+		return acc, acc.Merge(s)
+	})
+}
+
+// MergeBytes consumes sketches serialized by Marshal from ch until it is closed and
+// returns the merged sketch, or nil if no sketches were received. Nil sketches are
+// ignored. See MergeProtos for details on error handling.
+func (m *Merger) MergeBytes(ctx context.Context, ch <-chan []byte) (*HLL, error) {
+	return runMerger(ctx, m.parallelism, ch, func(data []byte, acc *HLL) (*HLL, error) {
+		if data == nil {
+			return acc, nil
+		}
+
+		s := new(HLL)
+		if err := s.apply(m.opts); err != nil {
+			return nil, err
+		}
+		if err := s.Unmarshal(data); err != nil {
+			return nil, err
+		}
+		if acc == nil {
+			return s, nil
+		}
+		return acc, acc.Merge(s)
+	})
+}
+
+// runMerger runs parallelism workers which merge values received from ch into their
+// accumulators and reduces the results.
+func runMerger[T any](ctx context.Context, parallelism int, ch <-chan T, merge func(T, *HLL) (*HLL, error)) (*HLL, error) {
+	ctx, cancel := context.WithCancel(ctx)
+	defer cancel()
+
+	partials := make([]*HLL, parallelism)
+	errs := make([]error, parallelism)
+	var wg sync.WaitGroup
+	for w := 0; w < parallelism; w++ {
+		wg.Add(1)
+		go func(w int) {
+			defer wg.Done()
+
+			var acc *HLL
+			for {
+				select {
+				case v, ok := <-ch:
+					if !ok {
+						partials[w] = acc
+						return
+					}
+
+					var err error
+					if acc, err = merge(v, acc); err != nil {
+						errs[w] = err
+						cancel()
+						return
+					}
+				case <-ctx.Done():
+					errs[w] = ctx.Err()
+					return
+				}
+			}
+		}(w)
+	}
+	wg.Wait()
+
+	if err := firstError(ctx, errs); err != nil {
+		return nil, err
+	}
+	return reducePartials(ctx, partials)
+}
@@ -0,0 +1,104 @@
+package hllplus_test
+
+import (
+	"context"
+	"math/rand"
+
+	"github.com/gowthamkommineni/zetasketch/hllplus"
+	pb "github.com/gowthamkommineni/zetasketch/internal/zetasketch"
+
+	. "github.com/bsm/ginkgo"
+	. "github.com/bsm/gomega"
+)
+
+var _ = Describe("Merger", func() {
+	var sketches []*hllplus.HLL
+	var expected *hllplus.HLL
+
+	BeforeEach(func() {
+		rnd := rand.New(rand.NewSource(7))
+		sketches = make([]*hllplus.HLL, 300)
+		expected, _ = hllplus.New(12, 17)
+		for i := range sketches {
+			precision := uint8(12 + i%2)
+			sketches[i], _ = hllplus.New(precision, precision+5)
+			for j := 0; j < i*10; j++ {
+				hash := rnd.Uint64()
+				sketches[i].Add(hash)
+				expected.Add(hash)
+			}
+		}
+	})
+
+	It("should merge protos", func() {
+		ch := make(chan *pb.HyperLogLogPlusUniqueStateProto)
+		go func() {
+			defer close(ch)
+			for _, s := range sketches {
+				ch <- s.Proto()
+			}
+			ch <- nil
+		}()
+
+		res, err := hllplus.NewMerger(4).MergeProtos(context.Background(), ch)
+		Expect(err).NotTo(HaveOccurred())
+		Expect(res.Precision()).To(Equal(uint8(12)))
+		Expect(res.Registers()).To(Equal(expected.Registers()))
+		Expect(res.Estimate()).To(Equal(expected.Estimate()))
+	})
+
+	It("should not modify protos", func() {
+		s, _ := hllplus.NewNormal(12)
+		s.Add(1 << 63)
+		msg := s.Proto()
+		data := append([]byte(nil), msg.Data...)
+
+		ch := make(chan *pb.HyperLogLogPlusUniqueStateProto, 2)
+		ch <- msg
+		ch <- sketches[299].Proto()
+		close(ch)
+
+		_, err := hllplus.NewMerger(1).MergeProtos(context.Background(), ch)
+		Expect(err).NotTo(HaveOccurred())
+		Expect(msg.Data).To(Equal(data))
+	})
+
+	It("should merge bytes", func() {
+		ch := make(chan []byte)
+		go func() {
+			defer close(ch)
+			for _, s := range sketches {
+				data, _ := s.Marshal()
+				ch <- data
+			}
+		}()
+
+		res, err := hllplus.NewMerger(0).MergeBytes(context.Background(), ch)
+		Expect(err).NotTo(HaveOccurred())
+		Expect(res.NumValues()).To(Equal(expected.NumValues()))
+		Expect(res.Registers()).To(Equal(expected.Registers()))
+	})
+
+	It("should return nil for empty streams", func() {
+		ch := make(chan []byte)
+		close(ch)
+		Expect(hllplus.NewMerger(4).MergeBytes(context.Background(), ch)).To(BeNil())
+	})
+
+	It("should fail on invalid input", func() {
+		ch := make(chan []byte, 2)
+		ch <- []byte("invalid")
+		ch <- []byte("invalid")
+		_, err := hllplus.NewMerger(2).MergeBytes(context.Background(), ch)
+		Expect(err).To(HaveOccurred())
+		Expect(err).NotTo(MatchError(context.Canceled))
+	})
+
+	It("should respect cancellation", func() {
+		ctx, cancel := context.WithCancel(context.Background())
+		cancel()
+
+		_, err := hllplus.NewMerger(2).MergeBytes(ctx, make(chan []byte))
+		Expect(err).To(MatchError(context.Canceled))
+	})
+})
@@ -0,0 +1,187 @@
+package hllplus
+
+import (
+	"fmt"
+	"sort"
+
+	pb "github.com/gowthamkommineni/zetasketch/internal/zetasketch"
+)
+
+// Option configures optional sketch behaviour.
+type Option func(*HLL) error
+
+// WithMartingale enables the streaming martingale estimator. See martingale.go.
+func WithMartingale() Option {
+	return func(s *HLL) error {
+		s.martingale = new(martingale)
+		return nil
+	}
+}
+
+// WithIncrementalEstimate maintains the number of zero registers and the harmonic sum
+// of the normal registers while values are added, so that estimating does not have to
+// scan all registers. This benefits workloads which interleave adds and estimates, at a
+// small cost per register update. Estimates are equal to the regular estimator's, apart
+// from floating point rounding. See tally.go.
+func WithIncrementalEstimate() Option {
+	return func(s *HLL) error {
+		s.tally = new(tally)
+		return nil
+	}
+}
+
+// WithExemplars keeps a sample of up to n raw values added via AddString, AddBytes and
+// similar, e.g. to inspect example members when an estimate looks wrong. The sample is
+// uniform over the distinct values and carried through Merge, if the receiver keeps
+// exemplars too. Values are kept in memory only, they are not serialized. ShardedHLL,
+// GroupedHLL and HybridHLL sample values too, AtomicHLL, TTLHLL and LinearCounting
+// reject the option. See exemplars.go.
+func WithExemplars(n int) Option {
+	return func(s *HLL) error {
+		if n <= 0 {
+			return fmt.Errorf("invalid number of exemplars %d", n)
+		}
+		s.exemplars = &exemplars{max: n}
+		return nil
+	}
+}
+
+// WithThresholds calls fn once the estimate reaches each of the given thresholds, e.g.
+// to alert when the number of unique IPs exceeds a million without polling Estimate. The
+// estimate is checked while values are added or sketches are merged, fn is called
+// synchronously with the threshold and the estimate and must not modify the sketch.
+// Each threshold is reported at most once. It enables the incremental estimate, see
+// WithIncrementalEstimate. Callbacks are not copied by Clone or Snapshot. A ShardedHLL
+// only checks thresholds when it is read, see ShardedHLL. See thresholds.go.
+func WithThresholds(fn func(threshold, estimate int64), thresholds ...int64) Option {
+	return func(s *HLL) error {
+		if fn == nil {
+			return fmt.Errorf("invalid threshold callback")
+		}
+		if len(thresholds) == 0 {
+			return fmt.Errorf("invalid thresholds: at least one is required")
+		}
+
+		values := append([]int64(nil), thresholds...)
+		sort.Slice(values, func(i, j int) bool { return values[i] < values[j] })
+		n := 0
+		for _, v := range values {
+			if v <= 0 {
+				return fmt.Errorf("invalid threshold %d", v)
+			}
+			if n == 0 || v != values[n-1] {
+				values[n] = v
+				n++
+			}
+		}
+
+		s.thresholds = &thresholdState{fn: fn, values: values[:n]}
+		if s.tally == nil {
+			s.tally = new(tally)
+		}
+		return nil
+	}
+}
+
+// WithMonotonicEstimate never reports an estimate lower than previously reported for
+// the same sketch, e.g. for user-facing counters which must not decrease. Estimates may
+// otherwise fluctuate slightly downward after merges or downgrades, when switching
+// between estimators. It applies to Estimate, EstimateWithBounds and EstimateReadOnly
+// and is carried over to clones and snapshots, but not serialized. Merged sketches keep
+// the larger of both reported estimates.
+func WithMonotonicEstimate() Option {
+	return func(s *HLL) error {
+		s.monotonic = true
+		return nil
+	}
+}
+
+// WithNormalizeHook calls fn when the sketch switches from the sparse to the normal
+// representation, e.g. to monitor memory growth. It is called synchronously and must not
+// modify the sketch. Hooks are not copied by Clone or Snapshot.
+func WithNormalizeHook(fn func()) Option {
+	return func(s *HLL) error {
+		if fn == nil {
+			return fmt.Errorf("invalid normalize hook")
+		}
+		s.normalized = fn
+		return nil
+	}
+}
+
+// WithEstimator selects the estimator to use for normal sketches.
+func WithEstimator(e Estimator) Option {
+	return func(s *HLL) error {
+		if e > EstimatorLogLogBeta {
+			return fmt.Errorf("invalid estimator %d", e)
+		}
+		s.estimator = e
+		return nil
+	}
+}
+
+// WithBiasData overrides the built-in bias correction data for the given precision, e.g.
+// with data determined empirically for a non-standard hash function. The bias for a raw
+// estimate is interpolated from the nearest rawEstimates, which must be sorted in
+// ascending order and map to the biases at the same index. Raw estimates outside of the
+// given range are not corrected. May be applied multiple times for different precisions.
+func WithBiasData(precision uint8, rawEstimates, biases []float64) Option {
+	return func(s *HLL) error {
+		if precision < MinPrecision || precision > MaxPrecision {
+			return fmt.Errorf("invalid bias data precision %d", precision)
+		}
+		if len(rawEstimates) != len(biases) {
+			return fmt.Errorf("invalid bias data: %d raw estimates but %d biases", len(rawEstimates), len(biases))
+		}
+		if len(rawEstimates) < knnNumNeighbors {
+			return fmt.Errorf("invalid bias data: at least %d values are required", knnNumNeighbors)
+		}
+		if !sort.Float64sAreSorted(rawEstimates) {
+			return fmt.Errorf("invalid bias data: raw estimates must be sorted")
+		}
+
+		if s.biasTables == nil {
+			s.biasTables = make(map[uint8]*biasTable)
+		}
+		s.biasTables[precision] = &biasTable{
+			means:  append([]float64(nil), rawEstimates...),
+			biases: append([]float64(nil), biases...),
+		}
+		return nil
+	}
+}
+
+// WithHasher sets the hasher used for adding values, such as strings or numbers.
+// The hasher identity is not serialized, sketches restored from proto messages
+// must be configured with the same hasher again.
+func WithHasher(h Hasher) Option {
+	return func(s *HLL) error {
+		if h == nil {
+			return fmt.Errorf("invalid hasher")
+		}
+		s.hasher = h
+		return nil
+	}
+}
+
+// WithSeed sets a seed which is mixed into the hashes of all values added via
+// AddString, AddBytes and similar. Sketches using different seeds produce
+// uncorrelated estimates for the same values but cannot be merged. Hashes added
+// directly via Add are not affected. A zero seed disables seeding.
+func WithSeed(seed uint64) Option {
+	return func(s *HLL) error {
+		s.seed = seed
+		return nil
+	}
+}
+
+// WithValueType sets the type of values added to the sketch.
+func WithValueType(t ValueType) Option {
+	return func(s *HLL) error {
+		if _, ok := pb.DefaultOpsType_Id_name[int32(t)]; !ok {
+			return fmt.Errorf("invalid value type %d", t)
+		}
+		s.valueType = t
+		return nil
+	}
+}
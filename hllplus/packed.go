@@ -0,0 +1,98 @@
+package hllplus
+
+// WithPackedRegisters stores normal registers with 6 bits each instead of a full byte,
+// which cuts their memory by 25%. Register values never exceed 64-precision+1, so no
+// information is lost. Adding and estimating is somewhat slower and registers are
+// unpacked when the sketch is serialized.
+func WithPackedRegisters() Option {
+	return func(s *HLL) error {
+		if !s.packed && len(s.normal) != 0 {
+			packed := make([]byte, packedSize(s.precision))
+			packRegisters(packed, s.normal)
+			s.replaceNormal(packed, false)
+		}
+		s.packed = true
+		return nil
+	}
+}
+
+// packedSize returns the number of bytes of 1<<precision packed registers.
+func packedSize(precision uint8) int {
+	return 3 << (precision - 2)
+}
+
+// loadRegister returns the register at pos. Packed registers are stored in groups of four
+// per three bytes, little-endian.
+func loadRegister(normal []byte, packed bool, pos uint32) uint8 {
+	if !packed {
+		return normal[pos]
+	}
+
+	b := normal[pos>>2*3 : pos>>2*3+3]
+	w := uint32(b[0]) | uint32(b[1])<<8 | uint32(b[2])<<16
+	return uint8(w>>(pos&3*6)) & 0x3f
+}
+
+// storeRegister sets the register at pos to rhoW.
+func storeRegister(normal []byte, packed bool, pos uint32, rhoW uint8) {
+	if !packed {
+		normal[pos] = rhoW
+		return
+	}
+
+	b := normal[pos>>2*3 : pos>>2*3+3]
+	shift := pos & 3 * 6
+	w := uint32(b[0]) | uint32(b[1])<<8 | uint32(b[2])<<16
+	w = w&^(0x3f<<shift) | uint32(rhoW)<<shift
+	b[0], b[1], b[2] = byte(w), byte(w>>8), byte(w>>16)
+}
+
+// packRegisters packs the registers of src into dst.
+func packRegisters(dst, src []byte) {
+	for ; len(src) >= 4; src, dst = src[4:], dst[3:] {
+		w := uint32(src[0]) | uint32(src[1])<<6 | uint32(src[2])<<12 | uint32(src[3])<<18
+		dst[0], dst[1], dst[2] = byte(w), byte(w>>8), byte(w>>16)
+	}
+}
+
+// unpackRegisters unpacks the packed registers of src into dst.
+func unpackRegisters(dst, src []byte) {
+	for ; len(src) >= 3; src, dst = src[3:], dst[4:] {
+		w := uint32(src[0]) | uint32(src[1])<<8 | uint32(src[2])<<16
+		dst[0], dst[1], dst[2], dst[3] = byte(w&0x3f), byte(w>>6&0x3f), byte(w>>12&0x3f), byte(w>>18)
+	}
+}
+
+// unpackedNormal returns the normal registers, one byte each. Unless packed, the
+// registers are not copied.
+func (s *HLL) unpackedNormal() []byte {
+	if !s.packed || len(s.normal) == 0 {
+		return s.normal
+	}
+
+	normal := make([]byte, 1<<s.precision)
+	unpackRegisters(normal, s.normal)
+	return normal
+}
+
+// setUnpackedNormal replaces the normal registers with registers of one byte each, which
+// are packed if necessary.
+func (s *HLL) setUnpackedNormal(normal []byte) {
+	if !s.packed || len(normal) == 0 {
+		s.replaceNormal(normal, false)
+		return
+	}
+
+	packed := s.allocNormal(s.precision)
+	packRegisters(packed, normal)
+	s.replaceNormal(packed, s.alloc != nil)
+}
+
+// registerStats returns the number of zero registers and the sum of 2^-rhoW over all
+// registers.
+func (s *HLL) registerStats() (numZeros int, sum float64) {
+	if !s.packed {
+		return registerStats(s.normal)
+	}
+	return registerStatsPacked(s.normal)
+}
@@ -0,0 +1,132 @@
+package hllplus_test
+
+import (
+	"math/rand"
+	"testing"
+
+	"github.com/gowthamkommineni/zetasketch/hllplus"
+
+	. "github.com/bsm/ginkgo"
+	. "github.com/bsm/gomega"
+)
+
+var _ = Describe("WithPackedRegisters", func() {
+	var subject, plain *hllplus.HLL
+	var rnd *rand.Rand
+
+	BeforeEach(func() {
+		rnd = rand.New(rand.NewSource(17))
+		subject, _ = hllplus.New(14, 19, hllplus.WithPackedRegisters())
+		plain, _ = hllplus.New(14, 19)
+	})
+
+	add := func(n int) {
+		for i := 0; i < n; i++ {
+			hash := rnd.Uint64()
+			subject.Add(hash)
+			plain.Add(hash)
+		}
+	}
+
+	It("should behave like unpacked sketches", func() {
+		add(100)
+		Expect(subject.IsSparse()).To(BeTrue())
+		Expect(subject.Estimate()).To(Equal(plain.Estimate()))
+
+		add(50_000)
+		Expect(subject.IsSparse()).To(BeFalse())
+		Expect(subject.NormalSize()).To(Equal(3 << 12))
+		Expect(plain.NormalSize()).To(Equal(1 << 14))
+
+		Expect(subject.Registers()).To(Equal(plain.Registers()))
+		Expect(subject.Estimate()).To(Equal(plain.Estimate()))
+		Expect(subject.EstimateWith(hllplus.EstimatorLogLogBeta)).To(Equal(plain.EstimateWith(hllplus.EstimatorLogLogBeta)))
+
+		hashes := make([]uint64, 1000)
+		for i := range hashes {
+			hashes[i] = rnd.Uint64()
+		}
+		subject.AddHashes(hashes)
+		plain.AddHashes(hashes)
+		Expect(subject.Registers()).To(Equal(plain.Registers()))
+	})
+
+	It("should store max values", func() {
+		subject, _ = hllplus.NewNormal(10, hllplus.WithPackedRegisters())
+		for pos := uint64(0); pos < 1<<10; pos++ {
+			subject.Add(pos << 54) // all-zero suffix
+		}
+		registers := subject.Registers()
+		for _, rhoW := range registers {
+			Expect(rhoW).To(Equal(uint8(55)))
+		}
+	})
+
+	It("should serialize unpacked", func() {
+		add(50_000)
+		data, err := subject.Marshal()
+		Expect(err).NotTo(HaveOccurred())
+		Expect(plain.Marshal()).To(Equal(data))
+		Expect(subject.ProtoSize()).To(Equal(len(data)))
+
+		restored, _ := hllplus.New(10, 15, hllplus.WithPackedRegisters())
+		Expect(restored.Unmarshal(data)).To(Succeed())
+		Expect(restored.NormalSize()).To(Equal(3 << 12))
+		Expect(restored.Registers()).To(Equal(plain.Registers()))
+
+		restored, err = hllplus.NewFromProto(plain.Proto(), hllplus.WithPackedRegisters())
+		Expect(err).NotTo(HaveOccurred())
+		Expect(restored.NormalSize()).To(Equal(3 << 12))
+		Expect(restored.Registers()).To(Equal(plain.Registers()))
+	})
+
+	It("should merge and downgrade", func() {
+		add(50_000)
+
+		other, _ := hllplus.NewNormal(14)
+		other.Add(rnd.Uint64())
+		Expect(subject.Merge(other)).To(Succeed())
+		Expect(plain.Merge(other)).To(Succeed())
+		Expect(subject.Registers()).To(Equal(plain.Registers()))
+
+		merged, _ := hllplus.NewNormal(12)
+		Expect(merged.Merge(subject)).To(Succeed())
+		Expect(subject.Downgrade(12, 17)).To(Succeed())
+		Expect(plain.Downgrade(12, 17)).To(Succeed())
+		Expect(subject.NormalSize()).To(Equal(3 << 10))
+		Expect(subject.Registers()).To(Equal(plain.Registers()))
+		Expect(merged.Registers()).To(Equal(plain.Registers()))
+
+		Expect(subject.Clone().Registers()).To(Equal(plain.Registers()))
+		Expect(subject.Snapshot().Registers()).To(Equal(plain.Registers()))
+	})
+
+	It("should pack existing registers", func() {
+		add(50_000)
+		restored, err := hllplus.NewFromRegisters(14, 19, plain.Registers(), hllplus.WithPackedRegisters())
+		Expect(err).NotTo(HaveOccurred())
+		Expect(restored.NormalSize()).To(Equal(3 << 12))
+		Expect(restored.Estimate()).To(Equal(plain.Estimate()))
+	})
+
+	It("should support register pools", func() {
+		pool := new(hllplus.RegisterPool)
+		for i := 0; i < 3; i++ {
+			s, _ := hllplus.NewNormal(12, hllplus.WithPackedRegisters(), hllplus.WithRegisterPool(pool))
+			Expect(s.Estimate()).To(Equal(int64(0)))
+			for j := 0; j < 1000; j++ {
+				s.Add(rnd.Uint64())
+			}
+			Expect(s.NormalSize()).To(Equal(3 << 10))
+			s.Release()
+		}
+	})
+})
+
+func BenchmarkHLL_Add_packed(b *testing.B) {
+	s, _ := hllplus.NewNormal(14, hllplus.WithPackedRegisters())
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		s.Add(uint64(i) * 0x9e3779b97f4a7c15)
+	}
+}
@@ -0,0 +1,72 @@
+package hllplus
+
+import (
+	"fmt"
+	"math/bits"
+	"sync"
+)
+
+// RegisterPool recycles the normal registers of released sketches, for services which
+// create and discard large numbers of short-lived sketches. Sketches opt in via
+// WithRegisterPool and return their registers via HLL.Release. A RegisterPool may be
+// shared by sketches of different precisions and is safe for concurrent use. The zero
+// value is ready to use.
+//
+// RegisterPool implements Allocator. Only slices with the size of normal registers are
+// recycled, all others are left to the garbage collector.
+type RegisterPool struct {
+	pools [2][MaxPrecision + 1]sync.Pool // by packed, precision
+}
+
+// Alloc returns n zeroed bytes.
+func (p *RegisterPool) Alloc(n int) []byte {
+	if pool := p.pool(n); pool != nil {
+		if b, ok := pool.Get().([]byte); ok {
+			for i := range b {
+				b[i] = 0
+			}
+			return b
+		}
+	}
+	return make([]byte, n)
+}
+
+// Free returns b to the pool.
+func (p *RegisterPool) Free(b []byte) {
+	if pool := p.pool(len(b)); pool != nil {
+		pool.Put(b) //nolint:staticcheck // slices are large
+	}
+}
+
+// pool returns the pool for registers of n bytes, either 1<<precision or, if packed,
+// 3<<(precision-2). It returns nil for all other sizes.
+func (p *RegisterPool) pool(n int) *sync.Pool {
+	if n <= 0 {
+		return nil
+	}
+
+	tz := bits.TrailingZeros(uint(n))
+	switch n >> tz {
+	case 1:
+		if tz <= MaxPrecision {
+			return &p.pools[0][tz]
+		}
+	case 3:
+		if tz+2 <= MaxPrecision {
+			return &p.pools[1][tz+2]
+		}
+	}
+	return nil
+}
+
+// WithRegisterPool allocates normal registers from pool. Registers are returned to the
+// pool when the sketch is released or downgraded. Clones inherit the pool.
+func WithRegisterPool(pool *RegisterPool) Option {
+	return func(s *HLL) error {
+		if pool == nil {
+			return fmt.Errorf("invalid register pool")
+		}
+		s.alloc = pool
+		return nil
+	}
+}
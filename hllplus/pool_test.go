@@ -0,0 +1,89 @@
+package hllplus_test
+
+import (
+	"math/rand"
+	"testing"
+
+	"github.com/gowthamkommineni/zetasketch/hllplus"
+
+	. "github.com/bsm/ginkgo"
+	. "github.com/bsm/gomega"
+)
+
+var _ = Describe("RegisterPool", func() {
+	var pool *hllplus.RegisterPool
+	var rnd *rand.Rand
+
+	BeforeEach(func() {
+		pool = new(hllplus.RegisterPool)
+		rnd = rand.New(rand.NewSource(11))
+	})
+
+	It("should recycle registers", func() {
+		for i := 0; i < 5; i++ {
+			subject, err := hllplus.NewNormal(12, hllplus.WithRegisterPool(pool))
+			Expect(err).NotTo(HaveOccurred())
+			Expect(subject.Estimate()).To(Equal(int64(0)))
+
+			for j := 0; j < 1000; j++ {
+				subject.Add(rnd.Uint64())
+			}
+			Expect(subject.Estimate()).To(BeNumerically("~", 1000, 30))
+			subject.Release()
+		}
+	})
+
+	It("should recycle on downgrade", func() {
+		subject, _ := hllplus.NewNormal(14, hllplus.WithRegisterPool(pool))
+		for j := 0; j < 1000; j++ {
+			subject.Add(rnd.Uint64())
+		}
+		expected := subject.Clone()
+		Expect(subject.Downgrade(12, 17)).To(Succeed())
+		Expect(expected.Downgrade(12, 17)).To(Succeed())
+		Expect(subject.Registers()).To(Equal(expected.Registers()))
+
+		other, _ := hllplus.NewNormal(14, hllplus.WithRegisterPool(pool))
+		Expect(other.Estimate()).To(Equal(int64(0)))
+		other.Add(1 << 63)
+		Expect(other.Estimate()).To(Equal(int64(1)))
+	})
+
+	It("should not recycle shared registers", func() {
+		subject, _ := hllplus.NewNormal(12, hllplus.WithRegisterPool(pool))
+		for j := 0; j < 1000; j++ {
+			subject.Add(rnd.Uint64())
+		}
+		snap := subject.Snapshot()
+		regs := snap.Registers()
+		subject.Release()
+
+		other, _ := hllplus.NewNormal(12, hllplus.WithRegisterPool(pool))
+		other.Add(1 << 63)
+		Expect(snap.Registers()).To(Equal(regs))
+	})
+
+	It("should release sparse sketches", func() {
+		subject, _ := hllplus.New(12, 17, hllplus.WithRegisterPool(pool))
+		subject.AddString("foo")
+		subject.Release()
+
+		other, _ := hllplus.New(12, 17)
+		Expect(other.Estimate()).To(Equal(int64(0)))
+	})
+
+	It("should reject nil pools", func() {
+		_, err := hllplus.New(12, 17, hllplus.WithRegisterPool(nil))
+		Expect(err).To(MatchError("invalid register pool"))
+	})
+})
+
+func BenchmarkRegisterPool(b *testing.B) {
+	pool := new(hllplus.RegisterPool)
+	b.ReportAllocs()
+	for i := 0; i < b.N; i++ {
+		s, _ := hllplus.NewNormal(14, hllplus.WithRegisterPool(pool))
+		s.Add(uint64(i))
+		s.Release()
+	}
+}
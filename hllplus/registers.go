@@ -0,0 +1,56 @@
+package hllplus
+
+import "fmt"
+
+// NewFromRegisters creates a sketch in normal representation from an array of 1<<precision
+// registers, as used by many other HyperLogLog implementations. The registers are copied.
+//
+// Register values must have been derived using the same layout as Add: the register index is
+// given by the highest precision bits of a hash, the value by the number of leading zeros of
+// the remaining bits plus one. Registers derived from other hash functions are only compatible
+// with sketches which use a matching Hasher.
+func NewFromRegisters(precision, sparsePrecision uint8, registers []byte, opts ...Option) (*HLL, error) {
+	if err := validate(precision, sparsePrecision); err != nil {
+		return nil, err
+	}
+	if n := 1 << precision; len(registers) != n {
+		return nil, fmt.Errorf("invalid number of registers %d: expected %d", len(registers), n)
+	}
+
+	maxRhoW := 64 - precision + 1
+	for i, rhoW := range registers {
+		if rhoW > maxRhoW {
+			return nil, fmt.Errorf("invalid value %d of register %d: must be <= %d", rhoW, i, maxRhoW)
+		}
+	}
+
+	h := &HLL{
+		precision:       precision,
+		sparsePrecision: sparsePrecision,
+		normal:          make([]byte, len(registers)),
+	}
+	copy(h.normal, registers)
+
+	if err := h.apply(opts); err != nil {
+		return nil, err
+	}
+	return h, nil
+}
+
+// Registers returns a copy of the 1<<precision registers of the sketch. Sparse sketches
+// are converted, but not modified.
+func (s *HLL) Registers() []byte {
+	registers := make([]byte, 1<<s.precision)
+	if s.sparse != nil {
+		s.sparse.Iterate(func(pos uint32, rhoW uint8) {
+			if rhoW > registers[pos] {
+				registers[pos] = rhoW
+			}
+		})
+	} else if s.packed {
+		unpackRegisters(registers, s.normal)
+	} else {
+		copy(registers, s.normal)
+	}
+	return registers
+}
@@ -0,0 +1,66 @@
+package hllplus_test
+
+import (
+	"math/rand"
+
+	"github.com/gowthamkommineni/zetasketch/hllplus"
+
+	. "github.com/bsm/ginkgo"
+	. "github.com/bsm/gomega"
+)
+
+var _ = Describe("HLL registers", func() {
+	var rnd *rand.Rand
+
+	BeforeEach(func() {
+		rnd = rand.New(rand.NewSource(3))
+	})
+
+	It("should export registers", func() {
+		sparse, _ := hllplus.New(10, 15)
+		normal, _ := hllplus.NewNormal(10)
+		for i := 0; i < 300; i++ {
+			h := rnd.Uint64()
+			sparse.Add(h)
+			normal.Add(h)
+		}
+		Expect(sparse.IsSparse()).To(BeTrue())
+		Expect(sparse.Registers()).To(HaveLen(1024))
+		Expect(sparse.Registers()).To(Equal(normal.Registers()))
+		Expect(sparse.IsSparse()).To(BeTrue())
+
+		empty, _ := hllplus.NewNormal(10)
+		Expect(empty.Registers()).To(Equal(make([]byte, 1024)))
+	})
+
+	It("should import registers", func() {
+		normal, _ := hllplus.NewNormal(12)
+		for i := 0; i < 5_000; i++ {
+			normal.Add(rnd.Uint64())
+		}
+
+		registers := normal.Registers()
+		subject, err := hllplus.NewFromRegisters(12, 17, registers, hllplus.WithSeed(1))
+		Expect(err).NotTo(HaveOccurred())
+		Expect(subject.IsSparse()).To(BeFalse())
+		Expect(subject.Seed()).To(Equal(uint64(1)))
+		Expect(subject.Estimate()).To(Equal(normal.Estimate()))
+
+		// registers are copied
+		registers[0] = 9
+		Expect(subject.Registers()[0]).NotTo(Equal(byte(9)))
+	})
+
+	It("should reject invalid registers", func() {
+		_, err := hllplus.NewFromRegisters(12, 17, make([]byte, 1000))
+		Expect(err).To(MatchError("invalid number of registers 1000: expected 4096"))
+
+		registers := make([]byte, 4096)
+		registers[7] = 54
+		_, err = hllplus.NewFromRegisters(12, 17, registers)
+		Expect(err).To(MatchError("invalid value 54 of register 7: must be <= 53"))
+
+		_, err = hllplus.NewFromRegisters(12, 11, make([]byte, 4096))
+		Expect(err).To(MatchError("invalid sparse precision 11: must be >= normal precision 12"))
+	})
+})
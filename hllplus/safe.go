@@ -0,0 +1,141 @@
+package hllplus
+
+import "sync"
+
+// SafeHLL wraps a sketch for concurrent use by multiple goroutines. All methods are
+// serialized by a mutex, even read-only ones, since estimating flushes sparse
+// buffers and caches the result.
+type SafeHLL struct {
+	mu sync.Mutex
+	s  *HLL
+}
+
+// NewSafe inits a new, concurrency-safe sketch. See New for details.
+func NewSafe(precision, sparsePrecision uint8, opts ...Option) (*SafeHLL, error) {
+	s, err := New(precision, sparsePrecision, opts...)
+	if err != nil {
+		return nil, err
+	}
+	return NewSafeFrom(s), nil
+}
+
+// NewSafeFrom wraps an existing sketch. The sketch must not be accessed directly
+// afterwards.
+func NewSafeFrom(s *HLL) *SafeHLL {
+	return &SafeHLL{s: s}
+}
+
+// Precision returns the normal precision.
+func (s *SafeHLL) Precision() uint8 {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	return s.s.Precision()
+}
+
+// SparsePrecision returns the sparse precision.
+func (s *SafeHLL) SparsePrecision() uint8 {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	return s.s.SparsePrecision()
+}
+
+// NumValues returns the number of values seen.
+func (s *SafeHLL) NumValues() int64 {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	return s.s.NumValues()
+}
+
+// MemoryUsage returns the number of bytes held by the sketch, see HLL.MemoryUsage.
+func (s *SafeHLL) MemoryUsage() int {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	return s.s.MemoryUsage()
+}
+
+// Add adds the uniform hash value to the sketch.
+func (s *SafeHLL) Add(hash uint64) {
+	s.mu.Lock()
+	s.s.Add(hash)
+	s.mu.Unlock()
+}
+
+// AddHashes adds a batch of uniform hash values, holding the lock only once.
+func (s *SafeHLL) AddHashes(hashes []uint64) {
+	s.mu.Lock()
+	s.s.AddHashes(hashes)
+	s.mu.Unlock()
+}
+
+// AddString hashes and adds a string value, see HLL.AddString.
+func (s *SafeHLL) AddString(v string) {
+	s.mu.Lock()
+	s.s.AddString(v)
+	s.mu.Unlock()
+}
+
+// AddBytes hashes and adds a byte value, see HLL.AddBytes.
+func (s *SafeHLL) AddBytes(v []byte) {
+	s.mu.Lock()
+	s.s.AddBytes(v)
+	s.mu.Unlock()
+}
+
+// AddInt64 hashes and adds a signed number, see HLL.AddInt64.
+func (s *SafeHLL) AddInt64(v int64) {
+	s.mu.Lock()
+	s.s.AddInt64(v)
+	s.mu.Unlock()
+}
+
+// AddUint64 hashes and adds an unsigned number, see HLL.AddUint64.
+func (s *SafeHLL) AddUint64(v uint64) {
+	s.mu.Lock()
+	s.s.AddUint64(v)
+	s.mu.Unlock()
+}
+
+// AddFloat64 hashes and adds a float, see HLL.AddFloat64.
+func (s *SafeHLL) AddFloat64(v float64) {
+	s.mu.Lock()
+	s.s.AddFloat64(v)
+	s.mu.Unlock()
+}
+
+// Merge merges other into the sketch. To merge two SafeHLLs, pass a Snapshot of
+// the other one.
+func (s *SafeHLL) Merge(other *HLL) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	return s.s.Merge(other)
+}
+
+// Estimate computes the cardinality estimate, see HLL.Estimate.
+func (s *SafeHLL) Estimate() int64 {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	return s.s.Estimate()
+}
+
+// Marshal serializes the sketch, see HLL.Marshal.
+func (s *SafeHLL) Marshal() ([]byte, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	return s.s.Marshal()
+}
+
+// Snapshot returns a copy of the current state, which can be used without locking.
+// See HLL.Snapshot.
+func (s *SafeHLL) Snapshot() *HLL {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	return s.s.Snapshot()
+}
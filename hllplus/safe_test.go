@@ -0,0 +1,65 @@
+package hllplus_test
+
+import (
+	"strconv"
+	"sync"
+
+	"github.com/gowthamkommineni/zetasketch/hllplus"
+
+	. "github.com/bsm/ginkgo"
+	. "github.com/bsm/gomega"
+)
+
+var _ = Describe("SafeHLL", func() {
+	It("should support concurrent use", func() {
+		subject, err := hllplus.NewSafe(14, 19)
+		Expect(err).NotTo(HaveOccurred())
+
+		var wg sync.WaitGroup
+		for w := 0; w < 8; w++ {
+			wg.Add(1)
+			go func(w int) {
+				defer wg.Done()
+				for i := 0; i < 10_000; i++ {
+					subject.AddString(strconv.Itoa(w*10_000 + i))
+					if i%1_000 == 0 {
+						_ = subject.Estimate()
+					}
+				}
+			}(w)
+		}
+		wg.Wait()
+
+		expected, _ := hllplus.New(14, 19)
+		for i := 0; i < 80_000; i++ {
+			expected.AddString(strconv.Itoa(i))
+		}
+		Expect(subject.NumValues()).To(Equal(int64(80_000)))
+		Expect(subject.Estimate()).To(Equal(expected.Estimate()))
+
+		data, err := expected.Marshal()
+		Expect(err).NotTo(HaveOccurred())
+		Expect(subject.Marshal()).To(Equal(data))
+	})
+
+	It("should merge and snapshot", func() {
+		subject, _ := hllplus.NewSafe(12, 17)
+		subject.AddInt64(1)
+		subject.AddHashes([]uint64{1 << 63, 1 << 62, 1 << 61})
+
+		snapshot := subject.Snapshot()
+		subject.AddInt64(2)
+		Expect(snapshot.NumValues()).To(Equal(int64(4)))
+		Expect(subject.NumValues()).To(Equal(int64(5)))
+
+		other, _ := hllplus.NewSafe(11, 16)
+		other.AddInt64(3)
+		Expect(subject.Merge(other.Snapshot())).To(Succeed())
+		Expect(subject.Precision()).To(Equal(uint8(11)))
+		Expect(subject.SparsePrecision()).To(Equal(uint8(16)))
+		Expect(subject.Estimate()).To(Equal(int64(6)))
+
+		s, _ := hllplus.New(12, 17, hllplus.WithHasher(hllplus.XXHash64))
+		Expect(hllplus.NewSafeFrom(s).Merge(snapshot)).To(MatchError(`cannot merge sketches with different hashers "xxhash64" and "fingerprint2011"`))
+	})
+})
@@ -0,0 +1,216 @@
+package hllplus
+
+import (
+	"fmt"
+	"time"
+)
+
+// SeriesTier configures the buckets of one resolution of a Series.
+type SeriesTier struct {
+	// Interval is the length of the buckets.
+	Interval time.Duration
+	// Retention is the time for which buckets are kept at this resolution. Older buckets
+	// are compacted into the next tier, or dropped from the last one. A retention of 0
+	// keeps buckets forever and is only valid for the last tier.
+	Retention time.Duration
+}
+
+// Series maintains sketches per time interval, e.g. hourly buckets for the last two days
+// and daily buckets for the last year, and estimates distinct values over arbitrary time
+// ranges by merging buckets. As time advances, buckets which exceed the retention of
+// their tier are merged into the coarser buckets of the next tier.
+//
+// Series is not safe for concurrent use.
+type Series struct {
+	tmpl    *HLL // empty sketch, carries the configuration
+	tiers   []SeriesTier
+	buckets []map[int64]*HLL // by tier and bucket number
+	head    int64            // the latest time seen, in nanoseconds
+	started bool
+}
+
+// NewSeries inits a new series. Tiers must be ordered from the finest to the coarsest
+// resolution, each interval must be a multiple of the previous one and retentions must
+// increase. See New for all other parameters.
+func NewSeries(tiers []SeriesTier, precision, sparsePrecision uint8, opts ...Option) (*Series, error) {
+	if len(tiers) == 0 {
+		return nil, fmt.Errorf("invalid series: no tiers")
+	}
+	for i, tier := range tiers {
+		last := i == len(tiers)-1
+		switch {
+		case tier.Interval <= 0:
+			return nil, fmt.Errorf("invalid series tier %d: interval %s must be positive", i, tier.Interval)
+		case tier.Retention < 0 || (tier.Retention == 0 && !last):
+			return nil, fmt.Errorf("invalid series tier %d: retention %s must be positive", i, tier.Retention)
+		case i > 0 && tier.Interval%tiers[i-1].Interval != 0:
+			return nil, fmt.Errorf("invalid series tier %d: interval %s must be a multiple of %s", i, tier.Interval, tiers[i-1].Interval)
+		case i > 0 && tier.Retention != 0 && tier.Retention <= tiers[i-1].Retention:
+			return nil, fmt.Errorf("invalid series tier %d: retention %s must exceed %s", i, tier.Retention, tiers[i-1].Retention)
+		}
+	}
+
+	tmpl, err := New(precision, sparsePrecision, opts...)
+	if err != nil {
+		return nil, err
+	}
+
+	s := &Series{
+		tmpl:    tmpl,
+		tiers:   append([]SeriesTier(nil), tiers...),
+		buckets: make([]map[int64]*HLL, len(tiers)),
+	}
+	for i := range s.buckets {
+		s.buckets[i] = make(map[int64]*HLL)
+	}
+	return s, nil
+}
+
+// At returns the sketch of the finest bucket which contains t and is still retained,
+// so values can be added using any of the sketch's methods. If t is later than all
+// times seen before, buckets which exceed their retention are compacted first. At
+// returns nil if t is older than all retained tiers. The sketch must not be retained,
+// it may be merged into a coarser bucket and released.
+func (s *Series) At(t time.Time) *HLL {
+	ns := t.UnixNano()
+	if !s.started || ns > s.head {
+		s.Compact(t)
+	}
+
+	for i, tier := range s.tiers {
+		num := floorDiv(ns, int64(tier.Interval))
+		if tier.Retention != 0 && (num+1)*int64(tier.Interval) <= s.head-int64(tier.Retention) {
+			continue
+		}
+
+		b, ok := s.buckets[i][num]
+		if !ok {
+			b = s.tmpl.Clone()
+			s.buckets[i][num] = b
+		}
+		return b
+	}
+	return nil
+}
+
+// Add adds the uniform hash value to the current bucket.
+func (s *Series) Add(hash uint64) {
+	s.At(time.Now()).Add(hash)
+}
+
+// AddString hashes and adds a string value to the current bucket, see HLL.AddString.
+func (s *Series) AddString(v string) {
+	s.At(time.Now()).AddString(v)
+}
+
+// AddBytes hashes and adds a byte value to the current bucket, see HLL.AddBytes.
+func (s *Series) AddBytes(v []byte) {
+	s.At(time.Now()).AddBytes(v)
+}
+
+// AddInt64 hashes and adds a signed number to the current bucket, see HLL.AddInt64.
+func (s *Series) AddInt64(v int64) {
+	s.At(time.Now()).AddInt64(v)
+}
+
+// AddUint64 hashes and adds an unsigned number to the current bucket, see
+// HLL.AddUint64.
+func (s *Series) AddUint64(v uint64) {
+	s.At(time.Now()).AddUint64(v)
+}
+
+// AddFloat64 hashes and adds a floating point number to the current bucket, see
+// HLL.AddFloat64.
+func (s *Series) AddFloat64(v float64) {
+	s.At(time.Now()).AddFloat64(v)
+}
+
+// Compact advances the series to now, if it is later than all times seen before, and
+// merges all buckets which exceed the retention of their tier into the next tier.
+// Buckets of the last tier are dropped. Compact is called by At automatically, but may
+// be called explicitly to free memory while no values are added.
+func (s *Series) Compact(now time.Time) {
+	if ns := now.UnixNano(); !s.started || ns > s.head {
+		s.head, s.started = ns, true
+	}
+
+	for i, tier := range s.tiers {
+		if tier.Retention == 0 {
+			continue
+		}
+
+		cutoff := s.head - int64(tier.Retention)
+		for num, b := range s.buckets[i] {
+			if (num+1)*int64(tier.Interval) > cutoff {
+				continue
+			}
+
+			delete(s.buckets[i], num)
+			if i == len(s.tiers)-1 {
+				b.Release()
+				continue
+			}
+
+			// intervals nest, so the bucket is fully contained in the coarser one
+			next := s.tiers[i+1]
+			parent := floorDiv(num*int64(tier.Interval), int64(next.Interval))
+			if acc, ok := s.buckets[i+1][parent]; ok {
+				_ = acc.Merge(b) // buckets are always compatible
+				b.Release()
+			} else {
+				s.buckets[i+1][parent] = b
+			}
+		}
+	}
+}
+
+// SnapshotRange merges all buckets which overlap the time range [from, to) into a new
+// sketch. The range is effectively widened to the boundaries of these buckets, so it
+// is only exact for ranges which are aligned to the intervals of the covering tiers.
+func (s *Series) SnapshotRange(from, to time.Time) *HLL {
+	start, end := from.UnixNano(), to.UnixNano()
+	res := s.tmpl.Clone()
+	for i, tier := range s.tiers {
+		for num, b := range s.buckets[i] {
+			if num*int64(tier.Interval) < end && (num+1)*int64(tier.Interval) > start {
+				_ = res.Merge(b) // buckets are always compatible
+			}
+		}
+	}
+	return res
+}
+
+// EstimateRange returns the number of distinct values in the time range [from, to),
+// see SnapshotRange.
+func (s *Series) EstimateRange(from, to time.Time) int64 {
+	return s.SnapshotRange(from, to).Estimate()
+}
+
+// NumBuckets returns the number of buckets per tier.
+func (s *Series) NumBuckets() []int {
+	res := make([]int, len(s.buckets))
+	for i, m := range s.buckets {
+		res[i] = len(m)
+	}
+	return res
+}
+
+// MemoryUsage returns the number of bytes held by all buckets, see HLL.MemoryUsage.
+func (s *Series) MemoryUsage() int {
+	var n int
+	for _, m := range s.buckets {
+		for _, b := range m {
+			n += b.MemoryUsage()
+		}
+	}
+	return n
+}
+
+// floorDiv returns a/b rounded towards negative infinity.
+func floorDiv(a, b int64) int64 {
+	q := a / b
+	if a%b < 0 {
+		q--
+	}
+	return q
+}
@@ -0,0 +1,90 @@
+package hllplus_test
+
+import (
+	"time"
+
+	"github.com/gowthamkommineni/zetasketch/hllplus"
+
+	. "github.com/bsm/ginkgo"
+	. "github.com/bsm/gomega"
+)
+
+var _ = Describe("Series", func() {
+	var subject *hllplus.Series
+	t0 := time.Date(2024, 5, 1, 0, 0, 0, 0, time.UTC)
+	day := 24 * time.Hour
+
+	BeforeEach(func() {
+		var err error
+		subject, err = hllplus.NewSeries([]hllplus.SeriesTier{
+			{Interval: time.Hour, Retention: 2 * day},
+			{Interval: day, Retention: 30 * day},
+		}, 12, 17)
+		Expect(err).NotTo(HaveOccurred())
+	})
+
+	It("should validate", func() {
+		_, err := hllplus.NewSeries(nil, 12, 17)
+		Expect(err).To(MatchError("invalid series: no tiers"))
+		_, err = hllplus.NewSeries([]hllplus.SeriesTier{{Interval: 0}}, 12, 17)
+		Expect(err).To(MatchError("invalid series tier 0: interval 0s must be positive"))
+		_, err = hllplus.NewSeries([]hllplus.SeriesTier{{Interval: time.Hour}, {Interval: day}}, 12, 17)
+		Expect(err).To(MatchError("invalid series tier 0: retention 0s must be positive"))
+		_, err = hllplus.NewSeries([]hllplus.SeriesTier{{Interval: time.Hour, Retention: day}, {Interval: 90 * time.Minute}}, 12, 17)
+		Expect(err).To(MatchError("invalid series tier 1: interval 1h30m0s must be a multiple of 1h0m0s"))
+		_, err = hllplus.NewSeries([]hllplus.SeriesTier{{Interval: time.Hour, Retention: day}, {Interval: day, Retention: day}}, 12, 17)
+		Expect(err).To(MatchError("invalid series tier 1: retention 24h0m0s must exceed 24h0m0s"))
+		_, err = hllplus.NewSeries([]hllplus.SeriesTier{{Interval: time.Hour}}, 30, 17)
+		Expect(err).To(MatchError("invalid normal precision 30"))
+	})
+
+	It("should estimate ranges", func() {
+		// user i is active in hour i/100, for the first 48 hours
+		for i := 0; i < 4_800; i++ {
+			subject.At(t0.Add(time.Duration(i/100) * time.Hour)).AddInt64(int64(i))
+		}
+		Expect(subject.NumBuckets()).To(Equal([]int{48, 0}))
+
+		Expect(subject.EstimateRange(t0, t0.Add(time.Hour))).To(BeNumerically("~", 100, 3))
+		Expect(subject.EstimateRange(t0.Add(10*time.Hour), t0.Add(20*time.Hour))).To(BeNumerically("~", 1_000, 30))
+		Expect(subject.EstimateRange(t0, t0.Add(2*day))).To(BeNumerically("~", 4_800, 150))
+		Expect(subject.EstimateRange(t0.Add(-day), t0)).To(BeZero())
+
+		// unaligned ranges are widened to bucket boundaries
+		Expect(subject.EstimateRange(t0.Add(90*time.Minute), t0.Add(150*time.Minute))).To(BeNumerically("~", 200, 6))
+	})
+
+	It("should compact old buckets", func() {
+		for i := 0; i < 4_800; i++ {
+			subject.At(t0.Add(time.Duration(i/100) * time.Hour)).AddInt64(int64(i))
+		}
+		before := subject.EstimateRange(t0, t0.Add(day))
+
+		// the first day exceeds the retention of hourly buckets two days later
+		subject.Compact(t0.Add(3 * day))
+		Expect(subject.NumBuckets()).To(Equal([]int{24, 1}))
+		Expect(subject.EstimateRange(t0, t0.Add(day))).To(Equal(before))
+
+		// hourly resolution is lost
+		Expect(subject.EstimateRange(t0, t0.Add(time.Hour))).To(Equal(before))
+		Expect(subject.EstimateRange(t0.Add(day), t0.Add(day+time.Hour))).To(BeNumerically("~", 100, 3))
+
+		// late values are added to the daily bucket
+		subject.At(t0.Add(time.Hour)).AddInt64(-1)
+		Expect(subject.NumBuckets()).To(Equal([]int{24, 1}))
+		Expect(subject.EstimateRange(t0, t0.Add(day))).To(BeNumerically("~", before+1, 2))
+
+		// everything expires eventually
+		subject.Compact(t0.Add(40 * day))
+		Expect(subject.NumBuckets()).To(Equal([]int{0, 0}))
+		Expect(subject.MemoryUsage()).To(BeZero())
+		Expect(subject.At(t0)).To(BeNil())
+	})
+
+	It("should add values now", func() {
+		subject.AddString("a")
+		subject.AddString("b")
+		Expect(subject.EstimateRange(time.Now().Add(-time.Hour), time.Now().Add(time.Hour))).To(Equal(int64(2)))
+		Expect(subject.MemoryUsage()).To(BeNumerically(">", 0))
+	})
+})
@@ -0,0 +1,335 @@
+package hllplus
+
+import (
+	"math"
+	"sort"
+)
+
+// Intersect estimates the cardinality of the intersection of s and other using the
+// inclusion–exclusion principle |A ∩ B| = |A| + |B| - |A ∪ B|. Neither sketch is modified.
+//
+// Besides the estimate, it returns the absolute standard error of the estimate. Since
+// the errors of all three terms add up, the error can be large relative to the
+// estimate, especially when the intersection is small compared to the union.
+// Sketches built using different hashers cannot be compared, their error is infinite.
+func (s *HLL) Intersect(other *HLL) (estimate int64, err float64) {
+	inter, _, ok := s.intersect(other)
+	if !ok {
+		return 0, math.Inf(1)
+	}
+	return inter.n, inter.err
+}
+
+// Jaccard estimates the Jaccard similarity |A ∩ B| / |A ∪ B| of two sketches as the ratio
+// of the intersection estimate of HLL.Intersect and the union estimate. Neither sketch is
+// modified.
+//
+// Besides the estimate, it returns its absolute standard error, propagated from the errors
+// of the intersection and the union. It inherits the weakness of the intersection estimate:
+// for small similarities the error often exceeds the estimate itself, so results below a
+// few error bounds should not be told apart from 0. Sketches which cannot be merged have
+// an infinite error. The similarity of two empty sketches is 0.
+func Jaccard(a, b *HLL) (float64, float64) {
+	inter, union, ok := a.intersect(b)
+	if !ok {
+		return 0, math.Inf(1)
+	}
+	if union.n == 0 {
+		return 0, 0
+	}
+
+	i, u := float64(inter.n), float64(union.n)
+	j := i / u
+	if j > 1 {
+		j = 1
+	}
+	return j, math.Sqrt(inter.err*inter.err/(u*u) + i*i*union.err*union.err/(u*u*u*u))
+}
+
+// EstimateUnion estimates the cardinality of the union of two sketches without modifying
+// either of them. Unlike merging into a Clone, normal registers are not copied: values
+// of a sparse sketch are applied on the fly to the register statistics of a normal one,
+// and normal sketches of the same precision are combined while their registers are
+// counted. Only two sparse sketches, or a normal sketch with a higher precision than the
+// other one, are merged into a copy. It returns an error if the sketches cannot be merged.
+func EstimateUnion(a, b *HLL) (int64, error) {
+	return estimateUnion(a, b, new(unionScratch))
+}
+
+// unionScratch holds buffers which are reused across union estimates.
+type unionScratch struct {
+	updates []uint64
+}
+
+func estimateUnion(a, b *HLL, sc *unionScratch) (int64, error) {
+	if err := a.checkMergeable(b); err != nil {
+		return 0, err
+	}
+
+	if a.sparse != nil && b.sparse == nil {
+		a, b = b, a
+	}
+	switch {
+	case len(a.normal) == 0 || a.precision > b.precision:
+	case b.sparse != nil:
+		return a.estimateUnionSparse(b, sc).Estimate, nil
+	case len(b.normal) != 0 && a.precision == b.precision:
+		return a.estimateUnionNormal(b).Estimate, nil
+	}
+
+	if b.precision < a.precision {
+		a, b = b, a
+	}
+	merged := a.Clone()
+	if err := merged.Merge(b); err != nil {
+		return 0, err
+	}
+	return merged.Estimate(), nil
+}
+
+// EstimateUnionAll is like EstimateUnion for any number of sketches, none of which are
+// modified. Nil sketches are ignored. Sparse and normal sketches are merged separately
+// using MergeMany, unless there is only one of them, and the two results are combined
+// by EstimateUnion. It returns 0 if there are no sketches.
+func EstimateUnionAll(sketches ...*HLL) (int64, error) {
+	var normal, sparse []*HLL
+	for _, s := range sketches {
+		switch {
+		case s == nil:
+		case s.sparse != nil:
+			sparse = append(sparse, s)
+		default:
+			normal = append(normal, s)
+		}
+	}
+
+	a, err := unionOf(normal)
+	if err != nil {
+		return 0, err
+	}
+	b, err := unionOf(sparse)
+	if err != nil {
+		return 0, err
+	}
+
+	switch {
+	case a == nil && b == nil:
+		return 0, nil
+	case a == nil:
+		return b.EstimateReadOnly(), nil
+	case b == nil:
+		return a.EstimateReadOnly(), nil
+	}
+	return EstimateUnion(a, b)
+}
+
+// unionOf merges sketches using MergeMany. It returns the only sketch itself or nil if
+// there are none.
+func unionOf(sketches []*HLL) (*HLL, error) {
+	switch len(sketches) {
+	case 0:
+		return nil, nil
+	case 1:
+		return sketches[0], nil
+	}
+	return MergeMany(sketches...)
+}
+
+// estimateUnionSparse estimates the union of the normal registers of s and the sparse
+// sketch other, which must not have a lower precision.
+func (s *HLL) estimateUnionSparse(other *HLL, sc *unionScratch) EstimateDetails {
+	numZeros, sum := s.normalStats()
+
+	// sort updates by position, so the sum is deterministic and only the largest value
+	// of each register is applied
+	updates := sc.updates[:0]
+	other.downgradeEach(s.precision, func(pos uint32, rhoW uint8) {
+		updates = append(updates, uint64(pos)<<8|uint64(rhoW))
+	})
+	sc.updates = updates
+	sort.Slice(updates, func(i, j int) bool { return updates[i] < updates[j] })
+
+	for i, u := range updates {
+		pos, rhoW := uint32(u>>8), uint8(u)
+		if i+1 < len(updates) && uint32(updates[i+1]>>8) == pos {
+			continue
+		}
+
+		old := loadRegister(s.normal, s.packed, pos)
+		if rhoW <= old {
+			continue
+		}
+		if old == 0 {
+			numZeros--
+		}
+		sum += inversePow2(rhoW) - inversePow2(old)
+	}
+	return s.estimateStats(s.estimator, numZeros, sum)
+}
+
+// estimateUnionNormal estimates the union of the normal registers of s and other, which
+// must have the same precision.
+func (s *HLL) estimateUnionNormal(other *HLL) EstimateDetails {
+	var h registerHistogram
+	if !s.packed && !other.packed {
+		for i, c := range s.normal {
+			if o := other.normal[i]; o > c {
+				c = o
+			}
+			h[i&3][c]++
+		}
+	} else {
+		for pos := uint32(0); pos < 1<<s.precision; pos++ {
+			c := loadRegister(s.normal, s.packed, pos)
+			if o := loadRegister(other.normal, other.packed, pos); o > c {
+				c = o
+			}
+			h[pos&3][c]++
+		}
+	}
+
+	numZeros, sum := h.stats()
+	return s.estimateStats(s.estimator, numZeros, sum)
+}
+
+// EstimateDifference estimates the cardinality of the set difference |A \ B| = |A ∪ B| - |B|,
+// e.g. the number of users present in yesterday's sketch a, but not in today's sketch b.
+// Neither sketch is modified.
+//
+// Besides the estimate, it returns the absolute standard error, combined from the errors
+// of the union and of b. Like with HLL.Intersect, small differences cannot be told apart
+// from 0. Sketches which cannot be merged have an infinite error.
+func EstimateDifference(a, b *HLL) (estimate int64, err float64) {
+	merged := a.Clone()
+	if merged.Merge(b) != nil {
+		return 0, math.Inf(1)
+	}
+
+	da, db, u := a.estimateReadOnly(), b.estimateReadOnly(), merged.estimate()
+	if estimate = u.Estimate - db.Estimate; estimate < 0 {
+		estimate = 0
+	} else if estimate > da.Estimate {
+		estimate = da.Estimate
+	}
+
+	eb := b.stdError(db.Estimate, db.LinearCounting)
+	eu := merged.stdError(u.Estimate, u.LinearCounting)
+	return estimate, math.Sqrt(eb*eb + eu*eu)
+}
+
+// MaxIntersectMany is the maximum number of sketches IntersectMany accepts.
+const MaxIntersectMany = 16
+
+// IntersectMany estimates the cardinality of the intersection of all sketches using the
+// inclusion–exclusion principle over the unions of all 2^n-1 non-empty subsets. None of
+// the sketches are modified.
+//
+// Besides the estimate, it returns the absolute standard error, which combines the
+// errors of all union estimates. It grows quickly with the number of sketches, so
+// intersections of more than a handful of sketches are only meaningful if they are
+// large compared to the unions. Sketches which cannot be merged, or more than
+// MaxIntersectMany sketches, have an infinite error.
+func IntersectMany(sketches ...*HLL) (estimate int64, err float64) {
+	return IntersectManyWithin(math.Inf(1), sketches...)
+}
+
+// IntersectManyWithin is like IntersectMany, but stops early as soon as the accumulated
+// error exceeds maxErr. In this case, it returns an estimate of 0 and the error
+// accumulated so far, which is larger than maxErr.
+func IntersectManyWithin(maxErr float64, sketches ...*HLL) (estimate int64, err float64) {
+	if len(sketches) == 0 {
+		return 0, 0
+	}
+	if len(sketches) > MaxIntersectMany {
+		return 0, math.Inf(1)
+	}
+
+	minEstimate := int64(math.MaxInt64)
+	var sum int64
+	var variance float64
+
+	// visit the unions of all subsets in depth-first order, so only one merged
+	// sketch per level is alive at a time
+	var visit func(start, depth int, union *HLL) bool
+	visit = func(start, depth int, union *HLL) bool {
+		for i := start; i < len(sketches); i++ {
+			next := sketches[i]
+			if union != nil {
+				next = union.Clone()
+				if next.Merge(sketches[i]) != nil {
+					err = math.Inf(1)
+					return false
+				}
+			}
+
+			// next is one of the inputs at depth 0, which must not be modified
+			d := next.estimateReadOnly()
+			if depth == 0 && d.Estimate < minEstimate {
+				minEstimate = d.Estimate
+			}
+			if depth%2 == 0 {
+				sum += d.Estimate
+			} else {
+				sum -= d.Estimate
+			}
+
+			e := next.stdError(d.Estimate, d.LinearCounting)
+			variance += e * e
+			if err = math.Sqrt(variance); err > maxErr {
+				return false
+			}
+
+			if !visit(i+1, depth+1, next) {
+				return false
+			}
+		}
+		return true
+	}
+	if !visit(0, 0, nil) {
+		return 0, err
+	}
+
+	if estimate = sum; estimate < 0 {
+		estimate = 0
+	} else if estimate > minEstimate {
+		estimate = minEstimate
+	}
+	return estimate, err
+}
+
+// errEstimate is a cardinality estimate with its absolute standard error.
+type errEstimate struct {
+	n   int64
+	err float64
+}
+
+// intersect estimates the intersection and the union of s and other. It returns false if
+// the sketches cannot be merged.
+func (s *HLL) intersect(other *HLL) (inter, union errEstimate, ok bool) {
+	merged := s.Clone()
+	if merged.Merge(other) != nil {
+		return inter, union, false
+	}
+
+	a, b, u := s.estimateReadOnly(), other.estimateReadOnly(), merged.estimate()
+	inter.n = a.Estimate + b.Estimate - u.Estimate
+	if inter.n < 0 {
+		inter.n = 0
+	} else if inter.n > a.Estimate || inter.n > b.Estimate {
+		inter.n = minInt64(a.Estimate, b.Estimate)
+	}
+
+	ea := s.stdError(a.Estimate, a.LinearCounting)
+	eb := other.stdError(b.Estimate, b.LinearCounting)
+	eu := merged.stdError(u.Estimate, u.LinearCounting)
+	inter.err = math.Sqrt(ea*ea + eb*eb + eu*eu)
+	union = errEstimate{n: u.Estimate, err: eu}
+	return inter, union, true
+}
+
+func minInt64(a, b int64) int64 {
+	if a < b {
+		return a
+	}
+	return b
+}
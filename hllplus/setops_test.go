@@ -0,0 +1,214 @@
+package hllplus_test
+
+import (
+	"math"
+	"math/rand"
+	"testing"
+
+	"github.com/gowthamkommineni/zetasketch/hllplus"
+
+	. "github.com/bsm/ginkgo"
+	. "github.com/bsm/gomega"
+)
+
+var _ = Describe("Set operations", func() {
+	var s1, s2, s3 *hllplus.HLL
+
+	BeforeEach(func() {
+		rnd := rand.New(rand.NewSource(33))
+
+		s1, _ = hllplus.NewNormal(14)
+		s2, _ = hllplus.NewNormal(14)
+		s3, _ = hllplus.New(12, 17)
+
+		for i := 0; i < 50_000; i++ {
+			n := rnd.Uint64()
+			s1.Add(n)
+			s2.Add(n)
+		}
+		for i := 0; i < 50_000; i++ {
+			s1.Add(rnd.Uint64())
+			s2.Add(rnd.Uint64())
+		}
+		for i := 0; i < 500; i++ {
+			s3.Add(rnd.Uint64())
+		}
+	})
+
+	It("should intersect", func() {
+		e1, e2 := s1.Estimate(), s2.Estimate()
+
+		est, err := s1.Intersect(s2)
+		Expect(est).To(BeNumerically("~", 50_000, 3*err))
+		Expect(err).To(BeNumerically("~", 1_681, 1))
+
+		// sketches are not modified:
+		Expect(s1.Estimate()).To(Equal(e1))
+		Expect(s2.Estimate()).To(Equal(e2))
+	})
+
+	It("should intersect disjoint sketches", func() {
+		mem := s3.MemoryUsage()
+		est, err := s1.Intersect(s3)
+		Expect(est).To(BeNumerically("<", 3*err))
+		Expect(s3.IsSparse()).To(BeTrue())
+
+		// buffered values are not flushed
+		_, _ = s3.Intersect(s1)
+		_, _ = hllplus.Jaccard(s3, s1)
+		Expect(s3.MemoryUsage()).To(Equal(mem))
+	})
+
+	It("should estimate Jaccard similarities", func() {
+		j, err := hllplus.Jaccard(s1, s2)
+		Expect(j).To(BeNumerically("~", 1.0/3, 3*err))
+		Expect(err).To(BeNumerically("~", 0.011, 0.001))
+
+		j, err = hllplus.Jaccard(s1, s1)
+		Expect(j).To(Equal(1.0))
+		Expect(err).To(BeNumerically("<", 0.02))
+
+		j, err = hllplus.Jaccard(s1, s3)
+		Expect(j).To(BeNumerically("<", 3*err))
+
+		empty, _ := hllplus.New(12, 17)
+		Expect(hllplus.Jaccard(empty, empty)).To(BeZero())
+
+		xx, _ := hllplus.New(14, 19, hllplus.WithHasher(hllplus.XXHash64))
+		_, err = hllplus.Jaccard(s1, xx)
+		Expect(math.IsInf(err, 1)).To(BeTrue())
+	})
+	It("should estimate unions", func() {
+		merged := func(a, b *hllplus.HLL) int64 {
+			m := a.Clone()
+			Expect(m.Merge(b)).To(Succeed())
+			return m.Estimate()
+		}
+
+		s4, _ := hllplus.New(14, 19)
+		s5, _ := hllplus.NewNormal(12)
+		packed, _ := hllplus.NewNormal(14, hllplus.WithPackedRegisters())
+		for i := 0; i < 1_000; i++ {
+			s4.AddInt64(int64(i))
+			s5.AddInt64(int64(i))
+			packed.AddInt64(int64(i))
+		}
+
+		for _, pair := range [][2]*hllplus.HLL{
+			{s1, s2}, {s1, s3}, {s3, s1}, {s1, s4}, {s3, s4}, {s4, s5}, {s1, s5}, {s5, s1}, {s1, packed}, {packed, s4},
+		} {
+			Expect(hllplus.EstimateUnion(pair[0], pair[1])).To(Equal(merged(pair[0], pair[1])))
+		}
+		Expect(hllplus.EstimateUnion(s1, s2)).To(BeNumerically("~", 150_000, 3_000))
+
+		// sketches are not modified:
+		Expect(s3.IsSparse()).To(BeTrue())
+		Expect(s4.IsSparse()).To(BeTrue())
+
+		xx, _ := hllplus.New(14, 19, hllplus.WithHasher(hllplus.XXHash64))
+		_, err := hllplus.EstimateUnion(s1, xx)
+		Expect(err).To(MatchError(`cannot merge sketches with different hashers "fingerprint2011" and "xxhash64"`))
+	})
+
+	It("should estimate unions of many sketches", func() {
+		all, _ := hllplus.MergeMany(s1, s2, s3)
+		Expect(hllplus.EstimateUnionAll(s1, nil, s2, s3)).To(Equal(all.Estimate()))
+		u13, err := hllplus.EstimateUnion(s1, s3)
+		Expect(err).NotTo(HaveOccurred())
+		Expect(hllplus.EstimateUnionAll(s3, s1)).To(Equal(u13))
+		Expect(hllplus.EstimateUnionAll(s3)).To(Equal(s3.Estimate()))
+		Expect(hllplus.EstimateUnionAll()).To(BeZero())
+		Expect(hllplus.EstimateUnionAll(nil)).To(BeZero())
+
+		xx, _ := hllplus.New(14, 19, hllplus.WithHasher(hllplus.XXHash64))
+		_, err = hllplus.EstimateUnionAll(s1, s2, xx)
+		Expect(err).To(HaveOccurred())
+	})
+
+	It("should estimate differences", func() {
+		e1, e2 := s1.Estimate(), s2.Estimate()
+
+		est, err := hllplus.EstimateDifference(s1, s2)
+		Expect(est).To(BeNumerically("~", 50_000, 3*err))
+		Expect(err).To(BeNumerically(">", 0))
+		Expect(s1.Estimate()).To(Equal(e1))
+		Expect(s2.Estimate()).To(Equal(e2))
+
+		est, err = hllplus.EstimateDifference(s1, s1)
+		Expect(est).To(BeNumerically("<", 3*err))
+
+		mem := s3.MemoryUsage()
+		est, err = hllplus.EstimateDifference(s3, s1)
+		Expect(s3.MemoryUsage()).To(Equal(mem)) // buffered values are not flushed
+		Expect(est).To(BeNumerically("<=", s3.Estimate()))
+		Expect(est).To(BeNumerically("~", 500, 3*err))
+
+		xx, _ := hllplus.New(14, 19, hllplus.WithHasher(hllplus.XXHash64))
+		_, err = hllplus.EstimateDifference(s1, xx)
+		Expect(math.IsInf(err, 1)).To(BeTrue())
+	})
+
+	It("should intersect many sketches", func() {
+		e1, e2 := s1.Estimate(), s2.Estimate()
+
+		est, err := hllplus.IntersectMany(s1, s2)
+		Expect(est).To(BeNumerically("~", 50_000, 3*err))
+		Expect(err).To(BeNumerically("~", 1_681, 1))
+		Expect(s1.Estimate()).To(Equal(e1))
+		Expect(s2.Estimate()).To(Equal(e2))
+
+		est, err = hllplus.IntersectMany(s1, s2, s1)
+		Expect(est).To(BeNumerically("~", 50_000, 3*err))
+		Expect(err).To(BeNumerically(">", 1_681))
+
+		mem := s3.MemoryUsage()
+		est, err = hllplus.IntersectMany(s3, s1, s2)
+		Expect(est).To(BeNumerically("<", 3*err))
+		Expect(s3.MemoryUsage()).To(Equal(mem)) // buffered values are not flushed
+
+		Expect(hllplus.IntersectMany()).To(BeZero())
+		est, err = hllplus.IntersectMany(s1)
+		Expect(est).To(Equal(e1))
+		Expect(err).To(BeNumerically(">", 0))
+
+		xx, _ := hllplus.New(14, 19, hllplus.WithHasher(hllplus.XXHash64))
+		_, err = hllplus.IntersectMany(s1, s2, xx)
+		Expect(math.IsInf(err, 1)).To(BeTrue())
+
+		many := make([]*hllplus.HLL, hllplus.MaxIntersectMany+1)
+		for i := range many {
+			many[i] = s3
+		}
+		_, err = hllplus.IntersectMany(many...)
+		Expect(math.IsInf(err, 1)).To(BeTrue())
+	})
+
+	It("should bail out when the error exceeds the limit", func() {
+		est, err := hllplus.IntersectManyWithin(1_000, s1, s2)
+		Expect(est).To(BeZero())
+		Expect(err).To(BeNumerically(">", 1_000))
+		Expect(err).To(BeNumerically("<", 1_681))
+
+		est, err = hllplus.IntersectManyWithin(2_000, s1, s2)
+		Expect(est).To(BeNumerically("~", 50_000, 3*err))
+		Expect(err).To(BeNumerically("<=", 2_000))
+	})
+})
+
+func BenchmarkEstimateUnion(b *testing.B) {
+	normal, _ := hllplus.NewNormal(16)
+	sparse, _ := hllplus.New(16, 21)
+	for i := 0; i < 1_000_000; i++ {
+		normal.Add(rand.Uint64())
+	}
+	for i := 0; i < 1_000; i++ {
+		sparse.Add(rand.Uint64())
+	}
+
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		if _, err := hllplus.EstimateUnion(normal, sparse); err != nil {
+			b.Fatal(err)
+		}
+	}
+}
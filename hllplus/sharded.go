@@ -0,0 +1,236 @@
+package hllplus
+
+import (
+	"fmt"
+	"math"
+	"runtime"
+	"sync"
+	"sync/atomic"
+
+	pb "github.com/gowthamkommineni/zetasketch/internal/zetasketch"
+)
+
+// ShardedHLL is a concurrency-safe sketch for high write throughput. Values are
+// distributed across independently locked shards by their hash, so concurrent writers
+// rarely contend. Shards are merged when the sketch is read, which makes reads more
+// expensive than with SafeHLL.
+//
+// Shards are picked by hash rather than per goroutine, since Go has no goroutine-local
+// storage to key them by. Each shard is guarded by a mutex instead of being updated
+// lock-free: an uncontended lock and unlock take two atomic operations, little more than
+// the compare-and-swap of a lock-free register update, and unlike AtomicHLL, shards can
+// stay sparse, sample exemplars and track value types. With at least as many shards as
+// cores, writers mostly take uncontended locks.
+//
+// Since registers keep the maximum of all values added, the merged result is exactly
+// the same as if all values had been added to a single sketch. The memory usage is not:
+// every shard holds its own registers, so n normal shards take n×2^precision bytes,
+// plus another 2^precision bytes for the merged sketch of each read.
+//
+// State which spans all shards, the estimate reported with WithMonotonicEstimate and the
+// thresholds reached with WithThresholds, is guarded by a separate mutex and updated when
+// the shards are merged by Snapshot, Estimate, Proto or Marshal. This serializes reads of
+// such sketches, but never blocks writers. Thresholds are therefore only checked on reads,
+// since merging the shards on every add would defeat sharding.
+type ShardedHLL struct {
+	tmpl      *HLL // empty sketch, carries the configuration
+	shards    []hllShard
+	mask      uint64
+	valueType int32
+
+	mu         sync.Mutex // guards reported and thresholds
+	reported   int64
+	thresholds *thresholdState
+}
+
+type hllShard struct {
+	mu sync.Mutex
+	s  *HLL
+	_  [48]byte // avoid false sharing
+}
+
+// NewSharded inits a new sharded sketch with n shards, rounded up to the next power of
+// two. If n is <= 0, runtime.GOMAXPROCS(0) is used. See New for all other parameters.
+func NewSharded(n int, precision, sparsePrecision uint8, opts ...Option) (*ShardedHLL, error) {
+	tmpl, err := New(precision, sparsePrecision, opts...)
+	if err != nil {
+		return nil, err
+	}
+
+	if n <= 0 {
+		n = runtime.GOMAXPROCS(0)
+	}
+	n = 1 << uint(math.Ceil(math.Log2(float64(n))))
+
+	s := &ShardedHLL{
+		tmpl:       tmpl,
+		shards:     make([]hllShard, n),
+		mask:       uint64(n - 1),
+		valueType:  int32(tmpl.valueType),
+		thresholds: tmpl.thresholds,
+	}
+	tmpl.thresholds = nil
+	for i := range s.shards {
+		s.shards[i].s = tmpl.Clone()
+	}
+	return s, nil
+}
+
+// NumShards returns the number of shards.
+func (s *ShardedHLL) NumShards() int {
+	return len(s.shards)
+}
+
+// Add adds the uniform hash value to the sketch.
+func (s *ShardedHLL) Add(hash uint64) {
+	// the lowest bits are least relevant to the registers
+	sh := &s.shards[hash&s.mask]
+	sh.mu.Lock()
+	defer sh.mu.Unlock()
+
+	sh.s.Add(hash)
+}
+
+// addSharded adds the hash of v to its shard, which samples v, see WithExemplars.
+func addSharded[T exemplar](s *ShardedHLL, hash uint64, v T) {
+	sh := &s.shards[hash&s.mask]
+	sh.mu.Lock()
+	defer sh.mu.Unlock()
+
+	sample(sh.s, hash, v)
+	sh.s.Add(hash)
+}
+
+// AddString hashes and adds a string value, see HLL.AddString.
+func (s *ShardedHLL) AddString(v string) {
+	s.setValueType(ValueTypeBytes)
+	addSharded(s, s.tmpl.hashString(v), v)
+}
+
+// AddBytes hashes and adds a byte value, see HLL.AddBytes.
+func (s *ShardedHLL) AddBytes(v []byte) {
+	s.setValueType(ValueTypeBytes)
+	addSharded(s, s.tmpl.hashBytes(v), v)
+}
+
+// AddInt64 hashes and adds a signed number, see HLL.AddInt64.
+func (s *ShardedHLL) AddInt64(v int64) {
+	s.setValueType(ValueTypeInt64)
+	addSharded(s, s.tmpl.hashUint64(uint64(v)), v)
+}
+
+// AddUint64 hashes and adds an unsigned number, see HLL.AddUint64.
+func (s *ShardedHLL) AddUint64(v uint64) {
+	s.setValueType(ValueTypeUint64)
+	addSharded(s, s.tmpl.hashUint64(v), v)
+}
+
+// AddFloat64 hashes and adds a floating point number, see HLL.AddFloat64.
+func (s *ShardedHLL) AddFloat64(v float64) {
+	s.setValueType(ValueTypeDouble)
+	addSharded(s, s.tmpl.hashUint64(float64Bits(v)), v)
+}
+
+// Merge merges other into the sketch.
+func (s *ShardedHLL) Merge(other *HLL) error {
+	known := ValueType(atomic.LoadInt32(&s.valueType))
+	if known != other.valueType && known != ValueTypeUnknown && other.valueType != ValueTypeUnknown {
+		return fmt.Errorf("cannot merge sketches with different value types %s and %s", known, other.valueType)
+	}
+
+	sh := &s.shards[0]
+	sh.mu.Lock()
+	err := sh.s.Merge(other)
+	sh.mu.Unlock()
+
+	if err == nil && other.valueType != ValueTypeUnknown {
+		s.setValueType(other.valueType)
+	}
+	return err
+}
+
+// Snapshot merges all shards into a new sketch. In monotonic mode, the snapshot carries
+// the largest estimate reported so far. Thresholds the estimate of the snapshot reached
+// are reported before Snapshot returns, but the snapshot itself has no thresholds.
+func (s *ShardedHLL) Snapshot() *HLL {
+	res := s.tmpl.Clone()
+	for i := range s.shards {
+		sh := &s.shards[i]
+		sh.mu.Lock()
+		_ = res.Merge(sh.s) // shards are always compatible
+		sh.mu.Unlock()
+	}
+	res.valueType = ValueType(atomic.LoadInt32(&s.valueType))
+
+	if !res.monotonic && s.thresholds == nil {
+		return res
+	}
+
+	s.mu.Lock()
+	if s.reported > res.reported {
+		res.reported = s.reported
+	}
+	est := res.Estimate()
+	if res.monotonic {
+		s.reported = res.reported
+	}
+
+	var reached []int64
+	if t := s.thresholds; t != nil {
+		for ; t.next < len(t.values) && est >= t.values[t.next]; t.next++ {
+			reached = append(reached, t.values[t.next])
+		}
+	}
+	s.mu.Unlock()
+
+	// callbacks are invoked without holding the lock, so they may read the sketch
+	for _, threshold := range reached {
+		s.thresholds.fn(threshold, est)
+	}
+	return res
+}
+
+// NumValues returns the number of values seen.
+func (s *ShardedHLL) NumValues() int64 {
+	var n int64
+	for i := range s.shards {
+		sh := &s.shards[i]
+		sh.mu.Lock()
+		n += sh.s.NumValues()
+		sh.mu.Unlock()
+	}
+	return n
+}
+
+// MemoryUsage returns the number of bytes held by all shards, see HLL.MemoryUsage.
+func (s *ShardedHLL) MemoryUsage() int {
+	var n int
+	for i := range s.shards {
+		sh := &s.shards[i]
+		sh.mu.Lock()
+		n += sh.s.MemoryUsage()
+		sh.mu.Unlock()
+	}
+	return n
+}
+
+// Estimate merges all shards and computes the cardinality estimate.
+func (s *ShardedHLL) Estimate() int64 {
+	return s.Snapshot().Estimate()
+}
+
+// Proto merges all shards and builds a proto message of the result, see HLL.Proto.
+func (s *ShardedHLL) Proto() *pb.HyperLogLogPlusUniqueStateProto {
+	return s.Snapshot().Proto()
+}
+
+// Marshal merges all shards and serializes the result, see HLL.Marshal.
+func (s *ShardedHLL) Marshal() ([]byte, error) {
+	return s.Snapshot().Marshal()
+}
+
+func (s *ShardedHLL) setValueType(t ValueType) {
+	if atomic.LoadInt32(&s.valueType) == int32(ValueTypeUnknown) {
+		atomic.CompareAndSwapInt32(&s.valueType, int32(ValueTypeUnknown), int32(t))
+	}
+}
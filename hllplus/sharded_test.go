@@ -0,0 +1,153 @@
+package hllplus_test
+
+import (
+	"math/rand"
+	"sync"
+	"testing"
+
+	"github.com/gowthamkommineni/zetasketch/hllplus"
+
+	. "github.com/bsm/ginkgo"
+	. "github.com/bsm/gomega"
+)
+
+var _ = Describe("ShardedHLL", func() {
+	It("should round shards", func() {
+		subject, err := hllplus.NewSharded(5, 12, 17)
+		Expect(err).NotTo(HaveOccurred())
+		Expect(subject.NumShards()).To(Equal(8))
+
+		subject, err = hllplus.NewSharded(0, 12, 17)
+		Expect(err).NotTo(HaveOccurred())
+		Expect(subject.NumShards()).To(BeNumerically(">=", 1))
+
+		_, err = hllplus.NewSharded(4, 30, 17)
+		Expect(err).To(MatchError("invalid normal precision 30"))
+	})
+
+	It("should match a single sketch", func() {
+		subject, _ := hllplus.NewSharded(4, 14, 19)
+		expected, _ := hllplus.New(14, 19)
+
+		hashes := make([]uint64, 200_000)
+		rnd := rand.New(rand.NewSource(7))
+		for i := range hashes {
+			hashes[i] = rnd.Uint64()
+			expected.Add(hashes[i])
+		}
+
+		var wg sync.WaitGroup
+		for w := 0; w < 8; w++ {
+			wg.Add(1)
+			go func(w int) {
+				defer wg.Done()
+				for i := w; i < len(hashes); i += 8 {
+					subject.Add(hashes[i])
+				}
+			}(w)
+		}
+		wg.Wait()
+
+		Expect(subject.NumValues()).To(Equal(int64(200_000)))
+		Expect(subject.Estimate()).To(Equal(expected.Estimate()))
+		Expect(subject.Snapshot().Registers()).To(Equal(expected.Registers()))
+		Expect(subject.Proto()).To(Equal(expected.Proto()))
+	})
+
+	It("should track value types and merge", func() {
+		subject, _ := hllplus.NewSharded(4, 12, 17)
+		subject.AddString("foo")
+		subject.AddString("bar")
+		subject.AddInt64(1) // first type wins
+		Expect(subject.Snapshot().ValueType()).To(Equal(hllplus.ValueTypeBytes))
+
+		other, _ := hllplus.New(11, 16)
+		other.AddString("baz")
+		Expect(subject.Merge(other)).To(Succeed())
+		Expect(subject.Estimate()).To(Equal(int64(4)))
+		Expect(subject.Snapshot().Precision()).To(Equal(uint8(11)))
+
+		ints, _ := hllplus.New(12, 17)
+		ints.AddInt64(1)
+		Expect(subject.Merge(ints)).To(MatchError("cannot merge sketches with different value types BYTES_OR_UTF8_STRING and INT64"))
+
+		data, err := subject.Marshal()
+		Expect(err).NotTo(HaveOccurred())
+		restored := new(hllplus.HLL)
+		Expect(restored.Unmarshal(data)).To(Succeed())
+		Expect(restored.Estimate()).To(Equal(int64(4)))
+		Expect(restored.NumValues()).To(Equal(int64(4)))
+	})
+
+	It("should keep monotonic estimates across snapshots", func() {
+		subject, _ := hllplus.NewSharded(4, 10, 12, hllplus.WithMonotonicEstimate())
+		plain, _ := hllplus.NewSharded(4, 10, 12)
+
+		var max int64
+		var decreased bool
+		for i := 0; i < 10_000; i++ {
+			subject.AddInt64(int64(i))
+			plain.AddInt64(int64(i))
+
+			if est := plain.Estimate(); est < max {
+				decreased = true
+			} else {
+				max = est
+			}
+			Expect(subject.Estimate()).To(Equal(max), "after %d values", i+1)
+		}
+		Expect(decreased).To(BeTrue())
+		Expect(subject.Snapshot().EstimateReadOnly()).To(Equal(max))
+	})
+
+	It("should report thresholds on reads", func() {
+		var crossings [][2]int64
+		subject, _ := hllplus.NewSharded(4, 12, 17, hllplus.WithThresholds(func(threshold, estimate int64) {
+			crossings = append(crossings, [2]int64{threshold, estimate})
+		}, 100, 1_000))
+
+		for i := 0; i < 500; i++ {
+			subject.AddInt64(int64(i))
+		}
+		Expect(crossings).To(BeEmpty())
+		est := subject.Estimate()
+		Expect(crossings).To(Equal([][2]int64{{100, est}}))
+
+		// snapshots have no thresholds
+		snap := subject.Snapshot()
+		for i := 500; i < 2_000; i++ {
+			snap.AddInt64(int64(i))
+		}
+		Expect(snap.Estimate()).To(BeNumerically(">=", 1_000))
+		Expect(crossings).To(HaveLen(1))
+
+		Expect(subject.Merge(snap)).To(Succeed())
+		Expect(crossings).To(HaveLen(1))
+		Expect(subject.Estimate()).To(BeNumerically(">=", 1_000))
+		Expect(crossings).To(HaveLen(2))
+		Expect(crossings[1][0]).To(Equal(int64(1_000)))
+
+		subject.Snapshot()
+		Expect(crossings).To(HaveLen(2))
+	})
+})
+
+func BenchmarkShardedHLL_Add(b *testing.B) {
+	subject, _ := hllplus.NewSharded(0, 15, 20)
+	b.RunParallel(func(pb *testing.PB) {
+		rnd := rand.New(rand.NewSource(rand.Int63()))
+		for pb.Next() {
+			subject.Add(rnd.Uint64())
+		}
+	})
+}
+
+func BenchmarkSafeHLL_Add(b *testing.B) {
+	subject, _ := hllplus.NewSafe(15, 20)
+	b.RunParallel(func(pb *testing.PB) {
+		rnd := rand.New(rand.NewSource(rand.Int63()))
+		for pb.Next() {
+			subject.Add(rnd.Uint64())
+		}
+	})
+}
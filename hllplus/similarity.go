@@ -0,0 +1,203 @@
+package hllplus
+
+import (
+	"context"
+	"fmt"
+	"runtime"
+	"sync"
+)
+
+// NamedHLL is a sketch with a name, e.g. of an audience segment.
+type NamedHLL struct {
+	Name   string
+	Sketch *HLL
+}
+
+// Overlap contains the estimated overlap of two sketches.
+type Overlap struct {
+	// Union is the estimated cardinality of the union.
+	Union int64
+	// Intersection is the estimated cardinality of the intersection, computed by
+	// inclusion–exclusion like HLL.Intersect.
+	Intersection int64
+	// Jaccard is the estimated Jaccard similarity, the ratio of Intersection and Union.
+	Jaccard float64
+}
+
+// SimilarityMatrix contains the pairwise overlaps of named sketches.
+type SimilarityMatrix struct {
+	names []string
+	index map[string]int
+	cells []Overlap // n*n, row-major
+}
+
+// Len returns the number of sketches.
+func (m *SimilarityMatrix) Len() int {
+	return len(m.names)
+}
+
+// Names returns the names of the sketches, in the order they were passed.
+func (m *SimilarityMatrix) Names() []string {
+	return m.names
+}
+
+// At returns the overlap of the i-th and the j-th sketch.
+func (m *SimilarityMatrix) At(i, j int) Overlap {
+	return m.cells[i*len(m.names)+j]
+}
+
+// Lookup returns the overlap of the sketches named a and b. It returns false if either
+// name is unknown.
+func (m *SimilarityMatrix) Lookup(a, b string) (Overlap, bool) {
+	i, ok := m.index[a]
+	if !ok {
+		return Overlap{}, false
+	}
+	j, ok := m.index[b]
+	if !ok {
+		return Overlap{}, false
+	}
+	return m.At(i, j), true
+}
+
+// Similarities computes the overlaps of all pairs of sketches, using up to parallelism
+// goroutines. If parallelism is <= 0, runtime.GOMAXPROCS(0) is used.
+//
+// Each sketch is estimated once up front. Unions are estimated by EstimateUnion, which
+// does not copy the registers of normal sketches of the same precision, and each worker
+// reuses its buffers across pairs. Sketches must have unique names and must not be
+// modified concurrently. All sketches are checked for compatibility up front; the first
+// error, including cancellation of ctx, stops all workers and is returned.
+func Similarities(ctx context.Context, sketches []NamedHLL, parallelism int) (*SimilarityMatrix, error) {
+	n := len(sketches)
+	m := &SimilarityMatrix{
+		names: make([]string, n),
+		index: make(map[string]int, n),
+		cells: make([]Overlap, n*n),
+	}
+	if err := checkSimilarities(sketches); err != nil {
+		return nil, err
+	}
+
+	// estimate up front, this flushes buffers and caches results, so workers only read
+	estimates := make([]int64, n)
+	for i, s := range sketches {
+		if _, ok := m.index[s.Name]; ok {
+			return nil, fmt.Errorf("duplicate sketch name %q", s.Name)
+		}
+		m.names[i] = s.Name
+		m.index[s.Name] = i
+
+		estimates[i] = s.Sketch.Estimate()
+		m.cells[i*n+i] = Overlap{Union: estimates[i], Intersection: estimates[i]}
+		if estimates[i] != 0 {
+			m.cells[i*n+i].Jaccard = 1
+		}
+	}
+
+	if parallelism <= 0 {
+		parallelism = runtime.GOMAXPROCS(0)
+	}
+	if parallelism > n {
+		parallelism = n
+	}
+
+	ctx, cancel := context.WithCancel(ctx)
+	defer cancel()
+
+	rows := make(chan int)
+	go func() {
+		defer close(rows)
+
+		for i := 0; i < n; i++ {
+			select {
+			case rows <- i:
+			case <-ctx.Done():
+				return
+			}
+		}
+	}()
+
+	errs := make([]error, parallelism)
+	var wg sync.WaitGroup
+	for w := 0; w < parallelism; w++ {
+		wg.Add(1)
+		go func(w int) {
+			defer wg.Done()
+
+			sc := new(unionScratch)
+			for i := range rows {
+				if err := m.fillRow(ctx, i, sketches, estimates, sc); err != nil {
+					errs[w] = err
+					cancel()
+					return
+				}
+			}
+		}(w)
+	}
+	wg.Wait()
+
+	for _, err := range errs {
+		if err != nil {
+			return nil, err
+		}
+	}
+	if err := ctx.Err(); err != nil {
+		return nil, err
+	}
+	return m, nil
+}
+
+// fillRow computes the overlaps of the i-th sketch with all following ones and mirrors
+// them below the diagonal.
+func (m *SimilarityMatrix) fillRow(ctx context.Context, i int, sketches []NamedHLL, estimates []int64, sc *unionScratch) error {
+	n := len(sketches)
+	for j := i + 1; j < n; j++ {
+		if err := ctx.Err(); err != nil {
+			return err
+		}
+
+		u, err := estimateUnion(sketches[i].Sketch, sketches[j].Sketch, sc)
+		if err != nil {
+			return err
+		}
+
+		o := Overlap{Union: u, Intersection: estimates[i] + estimates[j] - u}
+		if o.Intersection < 0 {
+			o.Intersection = 0
+		} else if o.Intersection > estimates[i] || o.Intersection > estimates[j] {
+			o.Intersection = minInt64(estimates[i], estimates[j])
+		}
+		if u != 0 {
+			if o.Jaccard = float64(o.Intersection) / float64(u); o.Jaccard > 1 {
+				o.Jaccard = 1
+			}
+		}
+
+		m.cells[i*n+j] = o
+		m.cells[j*n+i] = o
+	}
+	return nil
+}
+
+// checkSimilarities ensures that all sketches can be merged with each other.
+func checkSimilarities(sketches []NamedHLL) error {
+	var typed *HLL
+	for _, s := range sketches {
+		if s.Sketch == nil {
+			return fmt.Errorf("missing sketch %q", s.Name)
+		}
+		if err := sketches[0].Sketch.checkHasher(s.Sketch); err != nil {
+			return err
+		}
+		if s.Sketch.valueType == ValueTypeUnknown {
+			continue
+		}
+		if typed == nil {
+			typed = s.Sketch
+		} else if err := typed.checkMergeable(s.Sketch); err != nil {
+			return err
+		}
+	}
+	return nil
+}
@@ -0,0 +1,120 @@
+package hllplus_test
+
+import (
+	"context"
+	"fmt"
+	"math/rand"
+	"testing"
+
+	"github.com/gowthamkommineni/zetasketch/hllplus"
+
+	. "github.com/bsm/ginkgo"
+	. "github.com/bsm/gomega"
+)
+
+var _ = Describe("Similarities", func() {
+	var sketches []hllplus.NamedHLL
+
+	BeforeEach(func() {
+		sketches = sketches[:0]
+		for i := 0; i < 8; i++ {
+			s, _ := hllplus.New(14, 19)
+			if i%2 == 0 {
+				s, _ = hllplus.NewNormal(14)
+			}
+			// segment i contains the values [i*1000, i*1000+4000)
+			for v := i * 1_000; v < i*1_000+4_000; v++ {
+				s.AddInt64(int64(v))
+			}
+			sketches = append(sketches, hllplus.NamedHLL{Name: fmt.Sprintf("s%d", i), Sketch: s})
+		}
+	})
+
+	It("should compute pairwise overlaps", func() {
+		m, err := hllplus.Similarities(context.Background(), sketches, 3)
+		Expect(err).NotTo(HaveOccurred())
+		Expect(m.Len()).To(Equal(8))
+		Expect(m.Names()).To(HaveLen(8))
+		Expect(m.Names()[2]).To(Equal("s2"))
+
+		for i := range sketches {
+			for j := range sketches {
+				o := m.At(i, j)
+				Expect(o).To(Equal(m.At(j, i)))
+
+				inter, _ := sketches[i].Sketch.Intersect(sketches[j].Sketch)
+				union, err := hllplus.EstimateUnion(sketches[i].Sketch, sketches[j].Sketch)
+				Expect(err).NotTo(HaveOccurred())
+				Expect(o.Union).To(Equal(union), "%d/%d", i, j)
+				Expect(o.Intersection).To(Equal(inter), "%d/%d", i, j)
+			}
+		}
+
+		o, ok := m.Lookup("s1", "s2")
+		Expect(ok).To(BeTrue())
+		Expect(o.Union).To(BeNumerically("~", 5_000, 100))
+		Expect(o.Intersection).To(BeNumerically("~", 3_000, 200))
+		Expect(o.Jaccard).To(BeNumerically("~", 0.6, 0.05))
+
+		o, _ = m.Lookup("s0", "s7")
+		Expect(o.Jaccard).To(BeNumerically("<", 0.05))
+
+		o, _ = m.Lookup("s3", "s3")
+		Expect(o.Jaccard).To(Equal(1.0))
+		Expect(o.Union).To(Equal(o.Intersection))
+
+		_, ok = m.Lookup("s1", "unknown")
+		Expect(ok).To(BeFalse())
+	})
+
+	It("should handle edge cases", func() {
+		m, err := hllplus.Similarities(context.Background(), nil, 0)
+		Expect(err).NotTo(HaveOccurred())
+		Expect(m.Len()).To(BeZero())
+
+		empty, _ := hllplus.New(12, 17)
+		m, err = hllplus.Similarities(context.Background(), []hllplus.NamedHLL{{Name: "a", Sketch: empty}}, 0)
+		Expect(err).NotTo(HaveOccurred())
+		Expect(m.At(0, 0)).To(Equal(hllplus.Overlap{}))
+	})
+
+	It("should reject invalid inputs", func() {
+		_, err := hllplus.Similarities(context.Background(), append(sketches, sketches[0]), 2)
+		Expect(err).To(MatchError(`duplicate sketch name "s0"`))
+
+		_, err = hllplus.Similarities(context.Background(), append(sketches, hllplus.NamedHLL{Name: "x"}), 2)
+		Expect(err).To(MatchError(`missing sketch "x"`))
+
+		str, _ := hllplus.New(14, 19)
+		str.AddString("x")
+		_, err = hllplus.Similarities(context.Background(), append(sketches, hllplus.NamedHLL{Name: "x", Sketch: str}), 2)
+		Expect(err).To(MatchError("cannot merge sketches with different value types INT64 and BYTES_OR_UTF8_STRING"))
+
+		xx, _ := hllplus.New(14, 19, hllplus.WithHasher(hllplus.XXHash64))
+		_, err = hllplus.Similarities(context.Background(), append(sketches, hllplus.NamedHLL{Name: "x", Sketch: xx}), 2)
+		Expect(err).To(MatchError(`cannot merge sketches with different hashers "fingerprint2011" and "xxhash64"`))
+
+		ctx, cancel := context.WithCancel(context.Background())
+		cancel()
+		_, err = hllplus.Similarities(ctx, sketches, 2)
+		Expect(err).To(MatchError(context.Canceled))
+	})
+})
+
+func BenchmarkSimilarities(b *testing.B) {
+	sketches := make([]hllplus.NamedHLL, 100)
+	for i := range sketches {
+		s, _ := hllplus.NewNormal(14)
+		for j := 0; j < 100_000; j++ {
+			s.Add(rand.Uint64())
+		}
+		sketches[i] = hllplus.NamedHLL{Name: fmt.Sprint(i), Sketch: s}
+	}
+
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		if _, err := hllplus.Similarities(context.Background(), sketches, 0); err != nil {
+			b.Fatal(err)
+		}
+	}
+}
@@ -2,7 +2,9 @@ package hllplus
 
 import (
 	"encoding/binary"
+	"fmt"
 	"math"
+	"math/bits"
 	"sort"
 	"sync"
 )
@@ -17,136 +19,293 @@ type sparseState struct {
 	sparsePrecision uint8
 
 	data   *deltaSlice
-	buffer uint32Set
+	spare  *deltaSlice // recycled by Flush
+	buffer uint32Slice // unsorted, may contain duplicates
 
 	encodedFlag  uint32
 	maxDataLen   int
 	maxBufferLen int
+
+	alloc Allocator // optional, allocates data
 }
 
-func newSparseState(normalPrecision, sparsePrecision uint8, state []byte) *sparseState {
-	m := 1 << normalPrecision
-	maxDataLen := m * 3 / 4
-	maxBufferLen := m / 4
+func newSparseState(normalPrecision, sparsePrecision uint8, state []byte, alloc Allocator) *sparseState {
+	s := newEmptySparseState(normalPrecision, sparsePrecision)
+	s.alloc = alloc
+
+	// restore state from passed data (optional):
+	s.data = newDeltaSlice(s.maxDataLen, alloc)
+	s.data.SetData(state)
+	return s
+}
 
+// newSparseView returns a state which aliases data. It must not be modified.
+func newSparseView(normalPrecision, sparsePrecision uint8, data []byte) *sparseState {
+	s := newEmptySparseState(normalPrecision, sparsePrecision)
+	s.data = new(deltaSlice)
+	s.data.SetDataNoCopy(data)
+	return s
+}
+
+// validateSparseData returns an error if data contains sparse indexes outside of the
+// sparse precision.
+func validateSparseData(normalPrecision, sparsePrecision uint8, data []byte) error {
+	s := newEmptySparseState(normalPrecision, sparsePrecision)
+	it := deltaIter{nums: data}
+	for {
+		x, ok := it.Next()
+		if !ok {
+			return nil
+		}
+		if !s.inRange(x) {
+			sparsePos, _ := s.decodeSparse(x)
+			return fmt.Errorf("invalid sparse data: index %d, expected less than %d", sparsePos, 1<<sparsePrecision)
+		}
+	}
+}
+
+// newEmptySparseState returns a state without data.
+func newEmptySparseState(normalPrecision, sparsePrecision uint8) *sparseState {
+	m := 1 << normalPrecision
 	encodedFlag := uint32(1 << sparsePrecision)
 	if n := normalPrecision + sparseRhoWBits; n > sparsePrecision {
 		encodedFlag = 1 << n
 	}
 
-	// restore state from passed data (optional):
-	data := recycleDeltaSlice(maxDataLen)
-	data.SetData(state)
-
 	return &sparseState{
 		normalPrecision: normalPrecision,
 		sparsePrecision: sparsePrecision,
 
-		data:   data,
-		buffer: make(uint32Set, 0),
-
 		encodedFlag:  encodedFlag,
-		maxDataLen:   maxDataLen,
-		maxBufferLen: maxBufferLen,
+		maxDataLen:   m * 3 / 4,
+		maxBufferLen: m / 4,
 	}
 }
 
+// setAllocator moves data into memory from alloc.
+func (s *sparseState) setAllocator(alloc Allocator) {
+	data := newDeltaSlice(s.maxDataLen, alloc)
+	data.SetData(s.data.Bytes())
+	s.data.Release()
+	if s.spare != nil {
+		s.spare.Release()
+	}
+	s.data, s.spare, s.alloc = data, nil, alloc
+}
+
 func (s *sparseState) Add(hash uint64) {
-	val := s.encode(hash)
-	if s.buffer.Add(val); s.buffer.Len() >= s.maxBufferLen {
-		s.Flush()
+	s.addEncoded(s.encode(hash))
+}
+
+// Merge merges other into s, re-encoding values if other has different
+// precisions. The precisions of s must not exceed the precisions of other.
+func (s *sparseState) Merge(other *sparseState) {
+	// Adding values may flush s, which would rewrite the data being iterated.
+	if other == s {
+		return
+	}
+
+	add := func(v uint32) {
+		s.addEncoded(s.convert(other, v))
+	}
+
+	other.data.Iterate(add)
+	for _, x := range other.buffer {
+		add(x)
 	}
 }
 
+// Convert returns a copy of the state, re-encoded to the given precisions.
+// The sparse precision must not exceed the current sparse precision.
+func (s *sparseState) Convert(normalPrecision, sparsePrecision uint8) *sparseState {
+	t := newSparseState(normalPrecision, sparsePrecision, nil, s.alloc)
+	t.Merge(s)
+	t.Flush()
+	return t
+}
+
 // Linear counting over the number of empty sparse buckets.
 func (s *sparseState) Estimate() int64 {
 	s.Flush()
+	return s.linearCount(s.data.Count())
+}
 
+// Count returns the number of non-empty sparse buckets. Unlike Estimate, it merges
+// buffered values on the fly and does not modify the state.
+func (s *sparseState) Count() int {
+	if len(s.buffer) == 0 {
+		return s.data.Count()
+	}
+
+	pending := make(map[uint32]struct{}, len(s.buffer))
+	for _, x := range s.buffer {
+		sparsePos, _ := s.decodeSparse(x)
+		pending[sparsePos] = struct{}{}
+	}
+	s.data.Iterate(func(x uint32) {
+		sparsePos, _ := s.decodeSparse(x)
+		delete(pending, sparsePos)
+	})
+	return s.data.Count() + len(pending)
+}
+
+// linearCount estimates the cardinality from n non-empty sparse buckets.
+func (s *sparseState) linearCount(n int) int64 {
 	mm := 1 << s.sparsePrecision
 	numBuckets := float64(mm)
-	numZeros := numBuckets - float64(s.data.Count())
+	numZeros := numBuckets - float64(n)
 	return int64(numBuckets*math.Log(numBuckets/numZeros) + 0.5)
 }
 
 func (s *sparseState) Clone() *sparseState {
+	if s == nil {
+		return nil
+	}
+
 	return &sparseState{
 		normalPrecision: s.normalPrecision,
 		sparsePrecision: s.sparsePrecision,
 
 		data:   s.data.Clone(),
-		buffer: s.buffer.Clone(),
+		buffer: append(uint32Slice(nil), s.buffer...),
 
 		encodedFlag:  s.encodedFlag,
 		maxDataLen:   s.maxDataLen,
 		maxBufferLen: s.maxBufferLen,
+
+		alloc: s.alloc,
 	}
 }
 
+// Flush merges buffered values into data. It does not allocate once the buffers of the
+// state have grown to their working size: the buffer is sorted in place and merged into
+// the spare slice, which then swaps places with data.
 func (s *sparseState) Flush() {
-	if s.buffer.Len() == 0 {
+	if len(s.buffer) == 0 {
 		return
 	}
 
-	result := recycleDeltaSlice(s.data.Len())
-	buffered := s.buffer.Flush()
+	result := s.spare
+	if result == nil {
+		result = newDeltaSlice(s.data.Len(), s.alloc)
+	}
+	sort.Sort(&s.buffer)
 
 	// merge existing data and buffered
-	s.data.Iterate(func(x uint32) {
-		if len(buffered) == 0 {
-			result.Append(x)
-			return
+	w := sparseWriter{s: s, out: result}
+	buffered := s.buffer
+	for it := s.data.iter(); ; {
+		x, ok := it.Next()
+		if !ok {
+			break
 		}
 
-		for len(buffered) > 0 {
-			b := buffered[0]
-
-			if b <= x {
-				// shift buffered element so it is not used on next iteration
-				buffered = buffered[1:]
-			}
-
-			if b < x {
-				// append all buffered elements, smaller than stored one
-				result.Append(b)
-			} else {
-				// append last element (stored, largest one)
-				result.Append(x)
-				break
-			}
+		// append all buffered elements, smaller than stored one
+		for len(buffered) > 0 && buffered[0] < x {
+			w.emit(buffered[0])
+			buffered = buffered[1:]
 		}
-	})
+		w.emit(x)
+	}
 
 	// append remaining
 	for _, x := range buffered {
-		result.Append(x)
+		w.emit(x)
 	}
+	w.close()
 
-	// replace data
-	s.data.Release()
+	// replace data, recycle the previous one
+	s.spare = nil
+	if !s.data.shared {
+		s.data.Reset()
+		s.spare = s.data
+	}
 	s.data = result
+	s.buffer = s.buffer[:0]
+}
+
+// Release returns the buffers of the state for reuse. The state must not be used
+// afterwards.
+func (s *sparseState) Release() {
+	s.data.Release()
+	if s.spare != nil {
+		s.spare.Release()
+	}
+	s.data, s.spare, s.buffer = nil, nil, nil
+}
+
+// sparseWriter appends sorted values to a deltaSlice. Explicitly encoded values of the
+// same index only differ in their rhoW', so only the last (largest) one is retained.
+type sparseWriter struct {
+	s       *sparseState
+	out     *deltaSlice
+	last    uint32
+	pending bool
+}
+
+func (w *sparseWriter) emit(x uint32) {
+	if w.pending && !w.s.sameIndex(w.last, x) {
+		w.out.Append(w.last)
+	}
+	w.last, w.pending = x, true
+}
+
+func (w *sparseWriter) close() {
+	if w.pending {
+		w.out.Append(w.last)
+	}
+}
+
+// sameIndex returns true if both sparse values refer to the same sparse index.
+func (s *sparseState) sameIndex(a, b uint32) bool {
+	if a == b {
+		return true
+	}
+	return a&b&s.encodedFlag != 0 && a>>sparseRhoWBits == b>>sparseRhoWBits
 }
 
 func (s *sparseState) OverMax() bool {
 	return s.data.Len() > s.maxDataLen
 }
 
+// MemoryUsage returns the number of bytes held by data and buffers.
+func (s *sparseState) MemoryUsage() int {
+	n := cap(s.data.nums) + cap(s.buffer)*4
+	if s.spare != nil {
+		n += cap(s.spare.nums)
+	}
+	return n
+}
+
 func (s *sparseState) Iterate(cb func(pos uint32, rhoW uint8)) {
 	handle := func(n uint32) {
 		cb(s.decode(n))
 	}
 
 	s.data.Iterate(handle)
-	s.buffer.Iterate(handle)
+	for _, x := range s.buffer {
+		handle(x)
+	}
 }
 
 func (s *sparseState) GetData() ([]byte, int) {
 	s.Flush()
-	d := s.data.Clone()
-	return d.Bytes(), d.Count()
+	data := make([]byte, s.data.Len())
+	copy(data, s.data.Bytes())
+	return data, s.data.Count()
+}
+
+func (s *sparseState) addEncoded(val uint32) {
+	if s.buffer = append(s.buffer, val); len(s.buffer) >= s.maxBufferLen {
+		s.Flush()
+	}
 }
 
 func (s *sparseState) encode(hash uint64) uint32 {
-	sparsePos, rho := computePosRhoW(hash, s.sparsePrecision)
+	return s.encodeSparse(computePosRhoW(hash, s.sparsePrecision))
+}
+
+func (s *sparseState) encodeSparse(sparsePos uint32, rho uint8) uint32 {
 	delta := s.sparsePrecision - s.normalPrecision
 
 	// Check if the normal rhoW can be re-constructed from the lowest sp-p bits of the sparse
@@ -181,46 +340,56 @@ func (s *sparseState) decode(sparseValue uint32) (pos uint32, rhoW uint8) {
 	return pos, rhoW
 }
 
-// --------------------------------------------------------------------
-
-type uint32Set map[uint32]struct{}
-
-func (s uint32Set) Add(n uint32) {
-	s[n] = struct{}{}
-}
-
-func (s uint32Set) Len() int {
-	return len(s)
+// inRange returns true if the sparse index of an encoded value is within the sparse
+// precision.
+func (s *sparseState) inRange(sparseValue uint32) bool {
+	if sparseValue&s.encodedFlag == 0 {
+		return sparseValue < 1<<s.sparsePrecision
+	}
+	return (sparseValue^s.encodedFlag)>>sparseRhoWBits < 1<<s.normalPrecision
 }
 
-func (s uint32Set) Clone() uint32Set {
-	if s == nil {
-		return nil
+// decodeSparse returns the sparse index and the sparse rhoW' of an encoded value.
+// The rhoW' is only returned for values where it was explicitly encoded, it is 0 otherwise.
+func (s *sparseState) decodeSparse(sparseValue uint32) (sparsePos uint32, rhoW uint8) {
+	if sparseValue&s.encodedFlag == 0 {
+		return sparseValue, 0
 	}
 
-	t := make(uint32Set, len(s))
-	for n := range s {
-		t.Add(n)
-	}
-	return t
+	pos := (sparseValue ^ s.encodedFlag) >> sparseRhoWBits
+	return pos << (s.sparsePrecision - s.normalPrecision), uint8(sparseValue & sparseRhowMask)
 }
 
-func (s uint32Set) Flush() []uint32 {
-	nums := make(uint32Slice, 0, len(s))
-	for n := range s {
-		nums = append(nums, n)
-		delete(s, n)
+// convert re-encodes a sparseValue from the src encoding into the encoding of s.
+// The sparse precision of s must not exceed the sparse precision of src. If the normal
+// precision of s is higher than the one of src, rhoW' values which were not encoded in
+// src but are required by s are assumed to be minimal.
+func (s *sparseState) convert(src *sparseState, sparseValue uint32) uint32 {
+	if s.normalPrecision == src.normalPrecision && s.sparsePrecision == src.sparsePrecision {
+		return sparseValue
 	}
-	sort.Sort(nums)
-	return nums
-}
 
-func (s uint32Set) Iterate(cb func(n uint32)) {
-	for n := range s {
-		cb(n)
+	sparsePos, rhoW := src.decodeSparse(sparseValue)
+	if shift := src.sparsePrecision - s.sparsePrecision; shift != 0 {
+		// If the stripped bits of the sparse index are not all zeros, the new rhoW' is the number
+		// of their leading zeros + 1. Otherwise, the old rhoW' needs to account for the additional
+		// zeros. The rhoW' is only unknown if the lowest sp-p bits of the source index were
+		// non-zero, in which case the target does not need to encode it either.
+		if w := sparsePos & (uint32(1)<<shift - 1); w != 0 {
+			rhoW = shift - uint8(bits.Len32(w)) + 1
+		} else {
+			rhoW += shift
+		}
+		sparsePos >>= shift
 	}
+	if rhoW == 0 {
+		rhoW = 1
+	}
+	return s.encodeSparse(sparsePos, rhoW)
 }
 
+// --------------------------------------------------------------------
+
 type uint32Slice []uint32
 
 func (p uint32Slice) Len() int           { return len(p) }
@@ -258,9 +427,23 @@ var deltaSlicePool sync.Pool
 
 // Delta encoded slice of uint32s.
 type deltaSlice struct {
-	nums uvarintSlice
-	last uint32
-	size int
+	nums   uvarintSlice
+	last   uint32
+	size   int
+	shared bool      // nums aliases external memory
+	alloc  Allocator // optional, allocates nums unless shared
+}
+
+// newDeltaSlice returns an empty slice with capacity for size bytes. Without an
+// allocator, slices are recycled.
+func newDeltaSlice(size int, alloc Allocator) *deltaSlice {
+	if alloc == nil {
+		return recycleDeltaSlice(size)
+	}
+
+	s := &deltaSlice{alloc: alloc}
+	s.reserve(size)
+	return s
 }
 
 func recycleDeltaSlice(size int) *deltaSlice {
@@ -285,6 +468,14 @@ func (s *deltaSlice) Reset() {
 }
 
 func (s *deltaSlice) Release() {
+	if s.alloc != nil {
+		s.free()
+		s.nums = nil
+		return
+	}
+	if s.shared {
+		return
+	}
 	s.Reset()
 	deltaSlicePool.Put(s)
 }
@@ -295,15 +486,22 @@ func (s *deltaSlice) Clone() *deltaSlice {
 	}
 
 	t := &deltaSlice{
-		nums: make(uvarintSlice, len(s.nums)),
-		last: s.last,
-		size: s.size,
+		last:  s.last,
+		size:  s.size,
+		alloc: s.alloc,
+	}
+	if s.alloc != nil {
+		t.reserve(len(s.nums))
+		t.nums = append(t.nums, s.nums...)
+	} else {
+		t.nums = make(uvarintSlice, len(s.nums))
+		copy(t.nums, s.nums)
 	}
-	copy(t.nums, s.nums)
 	return t
 }
 
 func (s *deltaSlice) Append(x uint32) {
+	s.reserve(binary.MaxVarintLen32)
 	s.nums = s.nums.Append(x - s.last)
 	s.last = x
 	s.size++
@@ -318,12 +516,58 @@ func (s *deltaSlice) Iterate(fn func(uint32)) {
 	})
 }
 
+// iter returns an iterator over the values of the slice.
+func (s *deltaSlice) iter() deltaIter {
+	return deltaIter{nums: s.nums}
+}
+
 func (s *deltaSlice) Bytes() []byte {
 	return s.nums
 }
 
 func (s *deltaSlice) SetData(p []byte) {
-	s.nums = append(s.nums[:0], p...)
+	s.nums = s.nums[:0]
+	s.reserve(len(p))
+	s.nums = append(s.nums, p...)
+	s.count()
+}
+
+// SetDataNoCopy is like SetData, but aliases p. The capacity of p is
+// truncated, so that appending never writes to p.
+func (s *deltaSlice) SetDataNoCopy(p []byte) {
+	s.free()
+	s.nums = uvarintSlice(p[:len(p):len(p)])
+	s.shared = true
+	s.count()
+}
+
+// reserve ensures that n bytes can be appended without growing nums by the runtime.
+// Without an allocator, the runtime is left to grow nums.
+func (s *deltaSlice) reserve(n int) {
+	if s.alloc == nil || cap(s.nums)-len(s.nums) >= n {
+		return
+	}
+
+	size := 2 * cap(s.nums)
+	if size < len(s.nums)+n {
+		size = len(s.nums) + n
+	}
+	b := s.alloc.Alloc(size)
+	nums := uvarintSlice(b[:len(s.nums):len(b)])
+	copy(nums, s.nums)
+	s.free()
+	s.nums, s.shared = nums, false
+}
+
+// free returns nums to the allocator.
+func (s *deltaSlice) free() {
+	if s.alloc != nil && !s.shared && cap(s.nums) != 0 {
+		s.alloc.Free(s.nums[:cap(s.nums)])
+	}
+}
+
+func (s *deltaSlice) count() {
+	s.last = 0
 	s.size = 0
 
 	s.Iterate(func(n uint32) {
@@ -331,3 +575,20 @@ func (s *deltaSlice) SetData(p []byte) {
 		s.size++
 	})
 }
+
+// deltaIter iterates over the values of a deltaSlice, without the need for a callback.
+type deltaIter struct {
+	nums uvarintSlice
+	last uint32
+}
+
+// Next returns the next value or false when all values have been returned.
+func (it *deltaIter) Next() (uint32, bool) {
+	u, m := binary.Uvarint(it.nums)
+	if m < 1 {
+		return 0, false
+	}
+	it.nums = it.nums[m:]
+	it.last += uint32(u)
+	return it.last, true
+}
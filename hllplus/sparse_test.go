@@ -0,0 +1,36 @@
+package hllplus_test
+
+import (
+	"math/rand"
+
+	"github.com/gowthamkommineni/zetasketch/hllplus"
+
+	. "github.com/bsm/ginkgo"
+	. "github.com/bsm/gomega"
+)
+
+var _ = Describe("sparse", func() {
+	It("should retain stored values when flushing", func() {
+		rnd := rand.New(rand.NewSource(5))
+		hashes := make([]uint64, 500)
+		for i := range hashes {
+			hashes[i] = rnd.Uint64()
+		}
+
+		// flush after every value, so buffered values are merged with stored ones
+		subject, _ := hllplus.New(14, 25)
+		for i, h := range hashes {
+			subject.Add(h)
+			Expect(subject.Estimate()).To(Equal(int64(i+1)), "after %d values", i+1)
+		}
+		Expect(subject.IsSparse()).To(BeTrue())
+
+		// flush once
+		expected, _ := hllplus.New(14, 25)
+		for _, h := range hashes {
+			expected.Add(h)
+		}
+		Expect(expected.Estimate()).To(Equal(int64(len(hashes))))
+		Expect(subject.Proto().SparseData).To(Equal(expected.Proto().SparseData))
+	})
+})
@@ -0,0 +1,75 @@
+package hllplus
+
+// inversePow2Table maps register values c to 2^-c.
+var inversePow2Table = func() (t [256]float64) {
+	for c := range t {
+		t[c] = 1.0 / float64(uint64(1)<<c)
+	}
+	return t
+}()
+
+// inversePow2 returns 2^-c, without actually performing a floating point exponent
+// computation (which is expensive).
+func inversePow2(c uint8) float64 {
+	return inversePow2Table[c]
+}
+
+// registerHistogram counts registers by value. Registers are counted into four
+// interleaved histograms, which avoids stalls on runs of equal values.
+type registerHistogram [4][256]uint32
+
+func (h *registerHistogram) add(registers []byte) {
+	for ; len(registers) >= 4; registers = registers[4:] {
+		r := registers[:4:4]
+		h[0][r[0]]++
+		h[1][r[1]]++
+		h[2][r[2]]++
+		h[3][r[3]]++
+	}
+	for _, c := range registers {
+		h[0][c]++
+	}
+}
+
+// addPacked is like add for packed registers, which are unpacked in chunks.
+func (h *registerHistogram) addPacked(packed []byte) {
+	var chunk [4096]byte
+
+	for len(packed) != 0 {
+		n := len(chunk) / 4 * 3
+		if n > len(packed) {
+			n = len(packed)
+		}
+
+		registers := chunk[:n/3*4]
+		unpackRegisters(registers, packed[:n])
+		h.add(registers)
+		packed = packed[n:]
+	}
+}
+
+// stats returns the number of zero registers and the sum of 2^-rhoW over all registers.
+func (h *registerHistogram) stats() (numZeros int, sum float64) {
+	for c := range h[0] {
+		if n := h[0][c] + h[1][c] + h[2][c] + h[3][c]; n != 0 {
+			sum += float64(n) * inversePow2Table[c]
+		}
+	}
+	return int(h[0][0] + h[1][0] + h[2][0] + h[3][0]), sum
+}
+
+// registerStatsGeneric is the pure Go implementation of registerStats. It builds a
+// histogram of register values, so only one floating point operation per distinct
+// value is required.
+func registerStatsGeneric(registers []byte) (numZeros int, sum float64) {
+	var h registerHistogram
+	h.add(registers)
+	return h.stats()
+}
+
+// registerStatsPacked is like registerStatsGeneric for packed registers.
+func registerStatsPacked(packed []byte) (numZeros int, sum float64) {
+	var h registerHistogram
+	h.addPacked(packed)
+	return h.stats()
+}
@@ -0,0 +1,61 @@
+//go:build amd64 && !purego
+
+package hllplus
+
+var hasAVX2 = detectAVX2()
+
+// registerStats returns the number of zero registers and the sum of 2^-rhoW over all
+// registers. With AVX2, registers are summed in 16 lanes. Partial sums are exact unless
+// registers hold very large values, so results only differ from registerStatsGeneric
+// in the last bits for extreme sketches.
+func registerStats(registers []byte) (numZeros int, sum float64) {
+	if !hasAVX2 {
+		return registerStatsGeneric(registers)
+	}
+
+	var lanes [16]float64
+	var zeros [4]uint64
+
+	n := len(registers) &^ 15
+	if n != 0 {
+		registerStatsAVX2(registers[:n], &lanes, &zeros)
+	}
+	numZeros = int(zeros[0] + zeros[1] + zeros[2] + zeros[3])
+	for i, c := range registers[n:] {
+		if c == 0 {
+			numZeros++
+		}
+		lanes[i] += inversePow2(c)
+	}
+
+	for _, v := range lanes {
+		sum += v
+	}
+	return numZeros, sum
+}
+
+// detectAVX2 reports whether the CPU and OS support AVX2.
+func detectAVX2() bool {
+	maxID, _, _, _ := cpuid(0, 0)
+	if maxID < 7 {
+		return false
+	}
+
+	_, _, ecx1, _ := cpuid(1, 0)
+	if ecx1&(1<<27) == 0 || ecx1&(1<<28) == 0 { // OSXSAVE, AVX
+		return false
+	}
+	if eax, _ := xgetbv(); eax&6 != 6 { // XMM and YMM state enabled by the OS
+		return false
+	}
+
+	_, ebx7, _, _ := cpuid(7, 0)
+	return ebx7&(1<<5) != 0
+}
+
+//go:noescape
+func registerStatsAVX2(registers []byte, lanes *[16]float64, zeros *[4]uint64)
+
+func cpuid(eaxArg, ecxArg uint32) (eax, ebx, ecx, edx uint32)
+
+func xgetbv() (eax, edx uint32)
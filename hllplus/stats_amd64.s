@@ -0,0 +1,89 @@
+//go:build amd64 && !purego
+
+#include "textflag.h"
+
+// func registerStatsAVX2(registers []byte, lanes *[16]float64, zeros *[4]uint64)
+//
+// Each iteration widens 16 registers c to 64 bits and adds 2^-c to the lanes, computed
+// by constructing the float64 bits (1023-c)<<52 directly. Zero registers are counted
+// by subtracting the all-ones comparison mask.
+TEXT ·registerStatsAVX2(SB), NOSPLIT, $0-40
+	MOVQ registers_base+0(FP), SI
+	MOVQ registers_len+8(FP), CX
+	MOVQ lanes+24(FP), DI
+	MOVQ zeros+32(FP), R8
+
+	VMOVUPD 0(DI), Y0
+	VMOVUPD 32(DI), Y1
+	VMOVUPD 64(DI), Y2
+	VMOVUPD 96(DI), Y3
+
+	MOVQ         $1023, AX
+	MOVQ         AX, X14
+	VPBROADCASTQ X14, Y14
+	VPXOR        Y15, Y15, Y15
+	VPXOR        Y13, Y13, Y13
+
+	SHRQ $4, CX
+	JZ   done
+
+loop:
+	VPMOVZXBQ 0(SI), Y4
+	VPMOVZXBQ 4(SI), Y5
+	VPMOVZXBQ 8(SI), Y6
+	VPMOVZXBQ 12(SI), Y7
+
+	VPCMPEQQ Y15, Y4, Y8
+	VPCMPEQQ Y15, Y5, Y9
+	VPCMPEQQ Y15, Y6, Y10
+	VPCMPEQQ Y15, Y7, Y11
+	VPADDQ   Y9, Y8, Y8
+	VPADDQ   Y11, Y10, Y10
+	VPADDQ   Y10, Y8, Y8
+	VPSUBQ   Y8, Y13, Y13
+
+	VPSUBQ Y4, Y14, Y4
+	VPSUBQ Y5, Y14, Y5
+	VPSUBQ Y6, Y14, Y6
+	VPSUBQ Y7, Y14, Y7
+	VPSLLQ $52, Y4, Y4
+	VPSLLQ $52, Y5, Y5
+	VPSLLQ $52, Y6, Y6
+	VPSLLQ $52, Y7, Y7
+
+	VADDPD Y4, Y0, Y0
+	VADDPD Y5, Y1, Y1
+	VADDPD Y6, Y2, Y2
+	VADDPD Y7, Y3, Y3
+
+	ADDQ $16, SI
+	DECQ CX
+	JNZ  loop
+
+done:
+	VMOVUPD Y0, 0(DI)
+	VMOVUPD Y1, 32(DI)
+	VMOVUPD Y2, 64(DI)
+	VMOVUPD Y3, 96(DI)
+	VMOVDQU Y13, 0(R8)
+	VZEROUPPER
+	RET
+
+// func cpuid(eaxArg, ecxArg uint32) (eax, ebx, ecx, edx uint32)
+TEXT ·cpuid(SB), NOSPLIT, $0-24
+	MOVL eaxArg+0(FP), AX
+	MOVL ecxArg+4(FP), CX
+	CPUID
+	MOVL AX, eax+8(FP)
+	MOVL BX, ebx+12(FP)
+	MOVL CX, ecx+16(FP)
+	MOVL DX, edx+20(FP)
+	RET
+
+// func xgetbv() (eax, edx uint32)
+TEXT ·xgetbv(SB), NOSPLIT, $0-8
+	MOVL $0, CX
+	XGETBV
+	MOVL AX, eax+0(FP)
+	MOVL DX, edx+4(FP)
+	RET
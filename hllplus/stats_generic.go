@@ -0,0 +1,9 @@
+//go:build !amd64 || purego
+
+package hllplus
+
+// registerStats returns the number of zero registers and the sum of 2^-rhoW over all
+// registers.
+func registerStats(registers []byte) (numZeros int, sum float64) {
+	return registerStatsGeneric(registers)
+}
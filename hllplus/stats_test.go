@@ -0,0 +1,80 @@
+package hllplus_test
+
+import (
+	"fmt"
+	"math"
+	"math/rand"
+	"testing"
+
+	"github.com/gowthamkommineni/zetasketch/hllplus"
+
+	. "github.com/bsm/ginkgo"
+	. "github.com/bsm/gomega"
+)
+
+var _ = Describe("RegisterStats", func() {
+	It("should count zeros and sum", func() {
+		rnd := rand.New(rand.NewSource(13))
+		for _, n := range []int{0, 1, 15, 16, 17, 1024, 1<<18 + 7} {
+			registers := make([]byte, n)
+			for i := range registers {
+				if rnd.Intn(4) != 0 {
+					registers[i] = byte(rnd.Intn(56))
+				}
+			}
+
+			var expZeros int
+			var expSum float64
+			for _, c := range registers {
+				if c == 0 {
+					expZeros++
+				}
+				expSum += math.Pow(2, -float64(c))
+			}
+
+			numZeros, sum := hllplus.RegisterStats(registers)
+			Expect(numZeros).To(Equal(expZeros), "n=%d", n)
+			Expect(sum).To(BeNumerically("~", expSum, 1e-12*expSum), "n=%d", n)
+
+			genZeros, genSum := hllplus.RegisterStatsGeneric(registers)
+			Expect(genZeros).To(Equal(expZeros), "n=%d", n)
+			Expect(genSum).To(BeNumerically("~", expSum, 1e-12*expSum), "n=%d", n)
+		}
+	})
+
+	It("should produce identical results for real sketches", func() {
+		rnd := rand.New(rand.NewSource(13))
+		s, _ := hllplus.NewNormal(18)
+		for i := 0; i < 1_000_000; i++ {
+			s.Add(rnd.Uint64())
+		}
+
+		registers := s.Registers()
+		numZeros, sum := hllplus.RegisterStats(registers)
+		genZeros, genSum := hllplus.RegisterStatsGeneric(registers)
+		Expect(numZeros).To(Equal(genZeros))
+		Expect(math.Float64bits(sum)).To(Equal(math.Float64bits(genSum)))
+	})
+})
+
+func BenchmarkRegisterStats(b *testing.B) {
+	for _, p := range []uint8{14, 18, 22} {
+		registers := make([]byte, 1<<p)
+		for i := range registers {
+			registers[i] = byte(rand.Intn(20))
+		}
+
+		b.Run(fmt.Sprintf("p=%d/native", p), func(b *testing.B) {
+			b.SetBytes(int64(len(registers)))
+			for i := 0; i < b.N; i++ {
+				hllplus.RegisterStats(registers)
+			}
+		})
+		b.Run(fmt.Sprintf("p=%d/generic", p), func(b *testing.B) {
+			b.SetBytes(int64(len(registers)))
+			for i := 0; i < b.N; i++ {
+				hllplus.RegisterStatsGeneric(registers)
+			}
+		})
+	}
+}
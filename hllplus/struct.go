@@ -0,0 +1,156 @@
+package hllplus
+
+import (
+	"encoding/binary"
+	"fmt"
+	"math"
+	"reflect"
+	"sort"
+	"sync"
+)
+
+// AddStruct canonically encodes a struct (or a pointer to a struct) and adds its hash
+// to the sketch. This allows counting distinct composite keys, such as (user, country, device),
+// with an encoding which is stable across applications.
+//
+// All exported fields are encoded in the order of their names, so reordering struct fields
+// does not change the hash. Names can be set explicitly with `hll:"name"` tags, fields
+// tagged with `hll:"-"` are skipped. Supported field types are integers, floats, bools,
+// strings, byte slices, nested structs and pointers to these.
+func (s *HLL) AddStruct(v interface{}) error {
+	rv := reflect.ValueOf(v)
+	for rv.Kind() == reflect.Ptr && !rv.IsNil() {
+		rv = rv.Elem()
+	}
+	if rv.Kind() != reflect.Struct {
+		return fmt.Errorf("cannot add %T: not a struct", v)
+	}
+
+	buf, err := appendStruct(nil, rv)
+	if err != nil {
+		return err
+	}
+	s.Add(s.hashBytes(buf))
+	return nil
+}
+
+// Type markers of the canonical encoding.
+const (
+	structNil    = 'n'
+	structBool   = 'b'
+	structInt    = 'i'
+	structUint   = 'u'
+	structFloat  = 'f'
+	structString = 's'
+	structBytes  = 'y'
+	structStruct = 'S'
+)
+
+type structField struct {
+	name  string
+	index int
+}
+
+var structFieldCache sync.Map // map[reflect.Type][]structField
+
+func structFields(t reflect.Type) []structField {
+	if v, ok := structFieldCache.Load(t); ok {
+		return v.([]structField)
+	}
+
+	fields := make([]structField, 0, t.NumField())
+	for i := 0; i < t.NumField(); i++ {
+		f := t.Field(i)
+		if f.PkgPath != "" {
+			continue
+		}
+
+		name := f.Name
+		if tag, ok := f.Tag.Lookup("hll"); ok {
+			if tag == "-" {
+				continue
+			} else if tag != "" {
+				name = tag
+			}
+		}
+		fields = append(fields, structField{name: name, index: i})
+	}
+	sort.Slice(fields, func(i, j int) bool { return fields[i].name < fields[j].name })
+
+	structFieldCache.Store(t, fields)
+	return fields
+}
+
+func appendStruct(buf []byte, v reflect.Value) ([]byte, error) {
+	fields := structFields(v.Type())
+
+	buf = append(buf, structStruct)
+	buf = appendUvarint(buf, uint64(len(fields)))
+	for _, f := range fields {
+		buf = appendString(buf, f.name)
+
+		var err error
+		if buf, err = appendValue(buf, v.Field(f.index)); err != nil {
+			return nil, fmt.Errorf("cannot encode field %s: %w", f.name, err)
+		}
+	}
+	return buf, nil
+}
+
+func appendValue(buf []byte, v reflect.Value) ([]byte, error) {
+	switch v.Kind() {
+	case reflect.Ptr:
+		if v.IsNil() {
+			return append(buf, structNil), nil
+		}
+		return appendValue(buf, v.Elem())
+	case reflect.Bool:
+		if v.Bool() {
+			return append(buf, structBool, 1), nil
+		}
+		return append(buf, structBool, 0), nil
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
+		return appendUint64(buf, structInt, uint64(v.Int())), nil
+	case reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64, reflect.Uintptr:
+		return appendUint64(buf, structUint, v.Uint()), nil
+	case reflect.Float32, reflect.Float64:
+		f := v.Float()
+		switch {
+		case f == 0:
+			f = 0
+		case f != f:
+			f = math.NaN()
+		}
+		return appendUint64(buf, structFloat, math.Float64bits(f)), nil
+	case reflect.String:
+		return appendString(append(buf, structString), v.String()), nil
+	case reflect.Slice:
+		if v.Type().Elem().Kind() != reflect.Uint8 {
+			break
+		}
+		b := v.Bytes()
+		buf = appendUvarint(append(buf, structBytes), uint64(len(b)))
+		return append(buf, b...), nil
+	case reflect.Struct:
+		return appendStruct(buf, v)
+	}
+	return nil, fmt.Errorf("unsupported type %s", v.Type())
+}
+
+func appendUint64(buf []byte, marker byte, v uint64) []byte {
+	var tmp [9]byte
+	tmp[0] = marker
+	binary.LittleEndian.PutUint64(tmp[1:], v)
+	return append(buf, tmp[:]...)
+}
+
+func appendUvarint(buf []byte, v uint64) []byte {
+	var tmp [binary.MaxVarintLen64]byte
+	n := binary.PutUvarint(tmp[:], v)
+	return append(buf, tmp[:n]...)
+}
+
+func appendString(buf []byte, s string) []byte {
+	buf = appendUvarint(buf, uint64(len(s)))
+	return append(buf, s...)
+}
@@ -0,0 +1,77 @@
+package hllplus_test
+
+import (
+	"github.com/gowthamkommineni/zetasketch/hllplus"
+
+	. "github.com/bsm/ginkgo"
+	. "github.com/bsm/gomega"
+)
+
+var _ = Describe("HLL structs", func() {
+	type device struct {
+		OS    string
+		Model *string
+	}
+
+	type visit struct {
+		UserID  int64  `hll:"user_id"`
+		Country string `hll:"country"`
+		Device  device `hll:"device"`
+		Note    string `hll:"-"`
+		private string
+	}
+
+	type reordered struct {
+		Device  device
+		Country string `hll:"country"`
+		UserID  int32  `hll:"user_id"`
+	}
+
+	var subject, expected *hllplus.HLL
+
+	BeforeEach(func() {
+		subject, _ = hllplus.New(12, 17)
+		expected, _ = hllplus.New(12, 17)
+	})
+
+	It("should add structs", func() {
+		Expect(subject.AddStruct(visit{UserID: 1, Country: "DE", Device: device{OS: "ios"}})).To(Succeed())
+		Expect(subject.AddStruct(&visit{UserID: 1, Country: "DE", Device: device{OS: "ios"}, Note: "x", private: "y"})).To(Succeed())
+		Expect(subject.AddStruct(visit{UserID: 2, Country: "DE", Device: device{OS: "ios"}})).To(Succeed())
+		Expect(subject.Estimate()).To(Equal(int64(2)))
+
+		expected.Add(0x4343019eb773ba7c)
+		expected.Add(0xdc4a97611bd9776d)
+		Expect(subject.Proto()).To(Equal(expected.Proto()))
+	})
+
+	It("should encode canonically", func() {
+		model := "pixel"
+		Expect(subject.AddStruct(visit{UserID: 1, Country: "DE", Device: device{OS: "android", Model: &model}})).To(Succeed())
+		Expect(expected.AddStruct(reordered{UserID: 1, Country: "DE", Device: device{OS: "android", Model: &model}})).To(Succeed())
+		Expect(subject.Proto()).NotTo(Equal(expected.Proto()))
+
+		type renamed struct {
+			D device `hll:"device"`
+			C string `hll:"country"`
+			U uint8  `hll:"user_id"`
+		}
+		expected, _ = hllplus.New(12, 17)
+		Expect(expected.AddStruct(renamed{U: 1, C: "DE", D: device{OS: "android", Model: &model}})).To(Succeed())
+		Expect(subject.Proto()).NotTo(Equal(expected.Proto()))
+
+		type same struct {
+			D device `hll:"device"`
+			C string `hll:"country"`
+			U int16  `hll:"user_id"`
+		}
+		expected, _ = hllplus.New(12, 17)
+		Expect(expected.AddStruct(same{U: 1, C: "DE", D: device{OS: "android", Model: &model}})).To(Succeed())
+		Expect(subject.Proto()).To(Equal(expected.Proto()))
+	})
+
+	It("should reject unsupported values", func() {
+		Expect(subject.AddStruct("foo")).To(MatchError("cannot add string: not a struct"))
+		Expect(subject.AddStruct(struct{ Tags []string }{})).To(MatchError("cannot encode field Tags: unsupported type []string"))
+	})
+})
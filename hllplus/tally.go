@@ -0,0 +1,100 @@
+package hllplus
+
+import "math/bits"
+
+// tally maintains the number of zero registers and the sum of 2^-rhoW over all registers
+// while registers are increased, which makes estimating O(1) in normal representation.
+// The sum is kept as an exact 64.64 fixed point number, so it never drifts, no matter how
+// many registers are updated. Like the martingale estimator, it is re-seeded from the
+// registers after a merge or a downgrade.
+type tally struct {
+	numZeros int
+	sumInt   uint64 // integer part of the sum
+	sumFrac  uint64 // fractional part of the sum, in units of 2^-64
+	seeded   bool
+}
+
+func (t *tally) Clone() *tally {
+	if t == nil {
+		return nil
+	}
+
+	clone := *t
+	return &clone
+}
+
+// add adds n * 2^-c to the sum. Terms smaller than 2^-64 are ignored, which is well
+// beyond the largest possible register value.
+func (t *tally) add(c uint8, n uint64) {
+	switch {
+	case c == 0:
+		t.sumInt += n
+	case c <= 64:
+		hi, lo := bits.Mul64(n, 1<<(64-c))
+		var carry uint64
+		t.sumFrac, carry = bits.Add64(t.sumFrac, lo, 0)
+		t.sumInt += hi + carry
+	}
+}
+
+// sub subtracts 2^-c from the sum.
+func (t *tally) sub(c uint8) {
+	switch {
+	case c == 0:
+		t.sumInt--
+	case c <= 64:
+		var borrow uint64
+		t.sumFrac, borrow = bits.Sub64(t.sumFrac, 1<<(64-c), 0)
+		t.sumInt -= borrow
+	}
+}
+
+// sum returns the sum of 2^-rhoW over all registers.
+func (t *tally) sum() float64 {
+	return float64(t.sumInt) + float64(t.sumFrac)*0x1p-64
+}
+
+// updateTally must be called before register is increased from old to rhoW.
+func (s *HLL) updateTally(old, rhoW uint8) {
+	if !s.tally.seeded {
+		s.seedTally()
+	}
+
+	if old == 0 {
+		s.tally.numZeros--
+	}
+	s.tally.sub(old)
+	s.tally.add(rhoW, 1)
+}
+
+func (s *HLL) seedTally() {
+	var h registerHistogram
+	if s.packed {
+		h.addPacked(s.normal)
+	} else {
+		h.add(s.normal)
+	}
+
+	*s.tally = tally{seeded: true}
+	for c := range h[0] {
+		if n := h[0][c] + h[1][c] + h[2][c] + h[3][c]; n != 0 {
+			s.tally.add(uint8(c), uint64(n))
+		}
+	}
+	s.tally.numZeros = int(h[0][0] + h[1][0] + h[2][0] + h[3][0])
+}
+
+func (s *HLL) resetTally() {
+	if s.tally != nil {
+		s.tally.seeded = false
+	}
+}
+
+// normalStats returns the number of zero registers and the sum of 2^-rhoW over all
+// normal registers, from the tally if available.
+func (s *HLL) normalStats() (numZeros int, sum float64) {
+	if s.tally != nil && s.tally.seeded {
+		return s.tally.numZeros, s.tally.sum()
+	}
+	return s.registerStats()
+}
@@ -0,0 +1,31 @@
+package hllplus
+
+// thresholdState invokes a callback when the estimate of a sketch reaches the next of a
+// number of ascending thresholds. It is checked whenever registers are updated, which
+// is cheap with the incremental estimate in normal representation. In sparse
+// representation, the estimate is only computed once an upper bound, assuming that
+// all buffered values are distinct, reaches the next threshold.
+type thresholdState struct {
+	fn     func(threshold, estimate int64)
+	values []int64 // sorted, unique
+	next   int     // index of the next threshold to reach
+}
+
+// checkThresholds invokes the callback for all thresholds the estimate has reached.
+func (s *HLL) checkThresholds() {
+	t := s.thresholds
+	if t.next == len(t.values) {
+		return
+	}
+	if s.sparse != nil {
+		n := s.sparse.data.Count() + len(s.sparse.buffer)
+		if n < 1<<s.sparsePrecision && s.sparse.linearCount(n) < t.values[t.next] {
+			return
+		}
+	}
+
+	est := s.Estimate()
+	for ; t.next < len(t.values) && est >= t.values[t.next]; t.next++ {
+		t.fn(t.values[t.next], est)
+	}
+}
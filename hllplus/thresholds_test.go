@@ -0,0 +1,87 @@
+package hllplus_test
+
+import (
+	"github.com/gowthamkommineni/zetasketch/hllplus"
+
+	. "github.com/bsm/ginkgo"
+	. "github.com/bsm/gomega"
+)
+
+var _ = Describe("Thresholds", func() {
+	type crossing struct{ threshold, estimate int64 }
+	var crossings []crossing
+	var record func(threshold, estimate int64)
+
+	BeforeEach(func() {
+		crossings = crossings[:0]
+		record = func(threshold, estimate int64) {
+			crossings = append(crossings, crossing{threshold, estimate})
+		}
+	})
+
+	It("should validate", func() {
+		_, err := hllplus.New(12, 17, hllplus.WithThresholds(nil, 10))
+		Expect(err).To(MatchError("invalid threshold callback"))
+
+		_, err = hllplus.New(12, 17, hllplus.WithThresholds(record))
+		Expect(err).To(MatchError("invalid thresholds: at least one is required"))
+
+		_, err = hllplus.New(12, 17, hllplus.WithThresholds(record, 10, 0))
+		Expect(err).To(MatchError("invalid threshold 0"))
+	})
+
+	It("should report crossings once", func() {
+		s, err := hllplus.New(12, 17, hllplus.WithThresholds(record, 10_000, 100, 1_000, 100))
+		Expect(err).NotTo(HaveOccurred())
+
+		for i := 0; i < 20_000; i++ {
+			s.AddInt64(int64(i))
+			for _, c := range crossings {
+				Expect(c.estimate).To(BeNumerically(">=", c.threshold))
+			}
+
+			// in sparse representation, estimates increase by at most one at a time,
+			// so crossings are reported right away
+			if n := len(crossings); n != 0 && crossings[n-1].threshold <= 1_000 {
+				Expect(crossings[n-1].estimate).To(Equal(crossings[n-1].threshold))
+			}
+			if i == 99 {
+				Expect(crossings).To(HaveLen(1))
+			}
+		}
+		Expect(crossings).To(HaveLen(3))
+		Expect(crossings[2].threshold).To(Equal(int64(10_000)))
+		Expect(crossings[2].estimate).To(BeNumerically("<", 10_010))
+
+		// adding the same values again does not report anything
+		for i := 0; i < 20_000; i++ {
+			s.AddInt64(int64(i))
+		}
+		Expect(crossings).To(HaveLen(3))
+	})
+
+	It("should report crossings by merges and batches", func() {
+		s, _ := hllplus.NewNormal(12, hllplus.WithThresholds(record, 500, 1_500))
+
+		other, _ := hllplus.New(12, 17)
+		for i := 0; i < 1_000; i++ {
+			other.AddInt64(int64(i))
+		}
+		Expect(s.Merge(other)).To(Succeed())
+		Expect(crossings).To(HaveLen(1))
+		Expect(crossings[0].threshold).To(Equal(int64(500)))
+		Expect(crossings[0].estimate).To(BeNumerically("~", 1_000, 30))
+
+		hashes := make([]uint64, 1_000)
+		for i := range hashes {
+			hashes[i] = uint64(i) * 0x9E3779B97F4A7C15
+		}
+		s.AddHashes(hashes)
+		Expect(crossings).To(HaveLen(2))
+
+		// clones do not report
+		clone := s.Clone()
+		clone.AddInt64(-1)
+		Expect(crossings).To(HaveLen(2))
+	})
+})
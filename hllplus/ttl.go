@@ -0,0 +1,188 @@
+package hllplus
+
+import (
+	"fmt"
+	"time"
+)
+
+// TTLHLL is a sketch in normal representation which expires stale registers, for
+// approximate distinct counts over a rolling horizon without keeping a sketch per time
+// bucket like WindowHLL. Each register stores a coarse timestamp of its last update
+// besides its value and is treated as empty once it is older than the TTL.
+//
+// Expiry is approximate: a register only remembers its largest value, so smaller values
+// added to it in the meantime are lost when it expires. Estimates therefore tend to be
+// low shortly after registers expire, until the values which are still active update
+// them again. Each register takes 5 bytes, so it always allocates 5*2^precision bytes.
+//
+// TTLHLL is not safe for concurrent use.
+type TTLHLL struct {
+	tmpl       *HLL // empty sketch, carries the configuration
+	resolution int64
+	ttl        int32 // in ticks of the resolution
+	registers  []byte
+	ticks      []uint32 // time of the last update, in ticks, wrapping around
+	numValues  int64
+	valueType  ValueType
+}
+
+// NewTTL inits a new sketch whose registers expire after ttl. Timestamps are stored in
+// ticks of the given resolution, which must be positive and at most the TTL. See New
+// for all other parameters. WithExemplars is not supported, since exemplars would not
+// expire with the registers.
+func NewTTL(ttl, resolution time.Duration, precision, sparsePrecision uint8, opts ...Option) (*TTLHLL, error) {
+	if resolution <= 0 || ttl < resolution || ttl/resolution >= 1<<30 {
+		return nil, fmt.Errorf("invalid TTL %s: must be between 1 and 2^30 times the resolution %s", ttl, resolution)
+	}
+
+	tmpl, err := New(precision, sparsePrecision, opts...)
+	if err != nil {
+		return nil, err
+	}
+	if tmpl.exemplars != nil {
+		return nil, fmt.Errorf("exemplars are not supported by TTLHLL")
+	}
+
+	return &TTLHLL{
+		tmpl:       tmpl,
+		resolution: int64(resolution),
+		ttl:        int32((ttl + resolution - 1) / resolution),
+		registers:  make([]byte, 1<<precision),
+		ticks:      make([]uint32, 1<<precision),
+		valueType:  tmpl.valueType,
+	}, nil
+}
+
+// Precision returns the normal precision.
+func (s *TTLHLL) Precision() uint8 {
+	return s.tmpl.precision
+}
+
+// NumValues returns the number of values seen, including expired ones.
+func (s *TTLHLL) NumValues() int64 {
+	return s.numValues
+}
+
+// MemoryUsage returns the number of bytes held by the registers and timestamps.
+func (s *TTLHLL) MemoryUsage() int {
+	return cap(s.registers) + cap(s.ticks)*4
+}
+
+// Add adds the uniform hash value to the sketch now.
+func (s *TTLHLL) Add(hash uint64) {
+	s.AddAt(time.Now(), hash)
+}
+
+// AddAt adds the uniform hash value to the sketch at time t. Values with event times
+// can be hashed using the sketch's Hasher. A register updated with a time older than
+// its timestamp keeps the later time, unless the value raises it.
+func (s *TTLHLL) AddAt(t time.Time, hash uint64) {
+	s.numValues++
+
+	pos, rhoW := computePosRhoW(hash, s.tmpl.precision)
+	tick := s.tick(t)
+	switch old := s.registers[pos]; {
+	case rhoW > old || s.expired(pos, tick):
+		s.registers[pos], s.ticks[pos] = rhoW, tick
+	case rhoW == old && int32(tick-s.ticks[pos]) > 0:
+		s.ticks[pos] = tick
+	}
+}
+
+// AddString hashes and adds a string value now, see HLL.AddString.
+func (s *TTLHLL) AddString(v string) {
+	s.setValueType(ValueTypeBytes)
+	s.Add(s.tmpl.hashString(v))
+}
+
+// AddBytes hashes and adds a byte value now, see HLL.AddBytes.
+func (s *TTLHLL) AddBytes(v []byte) {
+	s.setValueType(ValueTypeBytes)
+	s.Add(s.tmpl.hashBytes(v))
+}
+
+// AddInt64 hashes and adds a signed number now, see HLL.AddInt64.
+func (s *TTLHLL) AddInt64(v int64) {
+	s.setValueType(ValueTypeInt64)
+	s.Add(s.tmpl.hashUint64(uint64(v)))
+}
+
+// AddUint64 hashes and adds an unsigned number now, see HLL.AddUint64.
+func (s *TTLHLL) AddUint64(v uint64) {
+	s.setValueType(ValueTypeUint64)
+	s.Add(s.tmpl.hashUint64(v))
+}
+
+// AddFloat64 hashes and adds a floating point number now, see HLL.AddFloat64.
+func (s *TTLHLL) AddFloat64(v float64) {
+	s.setValueType(ValueTypeDouble)
+	s.Add(s.tmpl.hashUint64(float64Bits(v)))
+}
+
+// Hasher returns the hasher of the sketch.
+func (s *TTLHLL) Hasher() Hasher {
+	return s.tmpl.Hasher()
+}
+
+// Expire clears all registers which have expired at time now. Expired registers are
+// ignored by snapshots anyway, but clearing them avoids wrap-around of their
+// timestamps in sketches which are rarely updated.
+func (s *TTLHLL) Expire(now time.Time) {
+	tick := s.tick(now)
+	for pos, rhoW := range s.registers {
+		if rhoW != 0 && s.expired(uint32(pos), tick) {
+			s.registers[pos], s.ticks[pos] = 0, 0
+		}
+	}
+}
+
+// Snapshot copies the registers which have not expired now into a new sketch.
+func (s *TTLHLL) Snapshot() *HLL {
+	return s.SnapshotAt(time.Now())
+}
+
+// SnapshotAt copies the registers which have not expired at time t into a new sketch.
+func (s *TTLHLL) SnapshotAt(t time.Time) *HLL {
+	tick := s.tick(t)
+	normal := make([]byte, len(s.registers))
+	for pos, rhoW := range s.registers {
+		if rhoW != 0 && !s.expired(uint32(pos), tick) {
+			normal[pos] = rhoW
+		}
+	}
+
+	res := s.tmpl.Clone()
+	res.sparse = nil
+	res.setUnpackedNormal(normal)
+	res.numValues = s.numValues
+	res.valueType = s.valueType
+	return res
+}
+
+// Estimate computes the cardinality estimate of the registers which have not expired.
+func (s *TTLHLL) Estimate() int64 {
+	return s.Snapshot().Estimate()
+}
+
+// EstimateAt computes the cardinality estimate of the registers which have not expired
+// at time t.
+func (s *TTLHLL) EstimateAt(t time.Time) int64 {
+	return s.SnapshotAt(t).Estimate()
+}
+
+// expired reports whether the register at pos has expired at tick. Registers updated
+// after tick have not.
+func (s *TTLHLL) expired(pos uint32, tick uint32) bool {
+	return int32(tick-s.ticks[pos]) >= s.ttl
+}
+
+// tick returns the timestamp of t in ticks of the resolution.
+func (s *TTLHLL) tick(t time.Time) uint32 {
+	return uint32(floorDiv(t.UnixNano(), s.resolution))
+}
+
+func (s *TTLHLL) setValueType(t ValueType) {
+	if s.valueType == ValueTypeUnknown {
+		s.valueType = t
+	}
+}
@@ -0,0 +1,86 @@
+package hllplus_test
+
+import (
+	"math/rand"
+	"time"
+
+	"github.com/gowthamkommineni/zetasketch/hllplus"
+
+	. "github.com/bsm/ginkgo"
+	. "github.com/bsm/gomega"
+)
+
+var _ = Describe("TTLHLL", func() {
+	var subject *hllplus.TTLHLL
+	t0 := time.Date(2024, 5, 1, 12, 0, 0, 0, time.UTC)
+
+	hashes := make([]uint64, 1_100)
+	rnd := rand.New(rand.NewSource(5))
+	for i := range hashes {
+		hashes[i] = rnd.Uint64()
+	}
+
+	BeforeEach(func() {
+		var err error
+		subject, err = hllplus.NewTTL(time.Hour, time.Minute, 12, 17)
+		Expect(err).NotTo(HaveOccurred())
+	})
+
+	It("should validate", func() {
+		_, err := hllplus.NewTTL(time.Second, time.Minute, 12, 17)
+		Expect(err).To(MatchError("invalid TTL 1s: must be between 1 and 2^30 times the resolution 1m0s"))
+		_, err = hllplus.NewTTL(time.Hour, 0, 12, 17)
+		Expect(err).To(MatchError("invalid TTL 1h0m0s: must be between 1 and 2^30 times the resolution 0s"))
+		_, err = hllplus.NewTTL(time.Hour, time.Minute, 30, 17)
+		Expect(err).To(MatchError("invalid normal precision 30"))
+
+		Expect(subject.Precision()).To(Equal(uint8(12)))
+		Expect(subject.MemoryUsage()).To(Equal(5 << 12))
+	})
+
+	It("should expire stale registers", func() {
+		for i := 0; i < 1_000; i++ {
+			subject.AddAt(t0, hashes[i])
+		}
+		for i := 1_000; i < 1_100; i++ {
+			subject.AddAt(t0.Add(30*time.Minute), hashes[i])
+		}
+		Expect(subject.NumValues()).To(Equal(int64(1_100)))
+
+		Expect(subject.EstimateAt(t0.Add(59 * time.Minute))).To(BeNumerically("~", 1_100, 40))
+		Expect(subject.EstimateAt(t0.Add(60 * time.Minute))).To(BeNumerically("~", 100, 10))
+		Expect(subject.EstimateAt(t0.Add(90 * time.Minute))).To(BeZero())
+
+		// snapshots do not expire registers:
+		Expect(subject.EstimateAt(t0.Add(time.Minute))).To(BeNumerically("~", 1_100, 40))
+
+		subject.Expire(t0.Add(60 * time.Minute))
+		Expect(subject.EstimateAt(t0.Add(time.Minute))).To(BeNumerically("~", 100, 10))
+	})
+
+	It("should refresh registers of active values", func() {
+		for i := 0; i < 1_000; i++ {
+			subject.AddAt(t0, hashes[i])
+		}
+		for i := 0; i < 1_000; i++ {
+			subject.AddAt(t0.Add(45*time.Minute), hashes[i])
+		}
+		Expect(subject.EstimateAt(t0.Add(90 * time.Minute))).To(BeNumerically("~", 1_000, 40))
+
+		// late values do not roll back timestamps
+		for i := 0; i < 1_000; i++ {
+			subject.AddAt(t0, hashes[i])
+		}
+		Expect(subject.EstimateAt(t0.Add(90 * time.Minute))).To(BeNumerically("~", 1_000, 40))
+		Expect(subject.EstimateAt(t0.Add(105 * time.Minute))).To(BeZero())
+	})
+
+	It("should add values now", func() {
+		subject.AddString("a")
+		subject.AddString("b")
+		subject.AddInt64(1)
+		Expect(subject.Estimate()).To(Equal(int64(3)))
+		Expect(subject.Snapshot().ValueType()).To(Equal(hllplus.ValueTypeBytes))
+		Expect(subject.Hasher()).To(Equal(hllplus.Fingerprint2011))
+	})
+})
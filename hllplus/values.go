@@ -0,0 +1,96 @@
+package hllplus
+
+import "math"
+
+// AddString hashes and adds a string value. Strings are hashed as UTF-8 bytes, with the
+// default hasher exactly like the zetasketch Java library and BigQuery's HLL_COUNT.INIT.
+func (s *HLL) AddString(v string) {
+	s.setValueType(ValueTypeBytes)
+	hash := s.hashString(v)
+	sample(s, hash, v)
+	s.Add(hash)
+}
+
+// AddStrings hashes and adds multiple string values. It is equivalent to
+// calling AddString for each value, but faster.
+func (s *HLL) AddStrings(vs []string) {
+	s.setValueType(ValueTypeBytes)
+	var hashes [64]uint64
+	for len(vs) != 0 {
+		n := len(vs)
+		if n > len(hashes) {
+			n = len(hashes)
+		}
+		for i, v := range vs[:n] {
+			hashes[i] = s.hashString(v)
+			sample(s, hashes[i], v)
+		}
+		s.AddHashes(hashes[:n])
+		vs = vs[n:]
+	}
+}
+
+// AddBytes hashes and adds a byte value, compatible with BigQuery BYTES.
+func (s *HLL) AddBytes(v []byte) {
+	s.setValueType(ValueTypeBytes)
+	hash := s.hashBytes(v)
+	sample(s, hash, v)
+	s.Add(hash)
+}
+
+// AddInt64 hashes and adds a signed number, compatible with BigQuery INT64.
+func (s *HLL) AddInt64(v int64) {
+	s.setValueType(ValueTypeInt64)
+	hash := s.hashUint64(uint64(v))
+	sample(s, hash, v)
+	s.Add(hash)
+}
+
+// AddUint64 hashes and adds an unsigned number. Values are hashed with the same
+// 8-byte little-endian representation as int64 values.
+func (s *HLL) AddUint64(v uint64) {
+	s.setValueType(ValueTypeUint64)
+	hash := s.hashUint64(v)
+	sample(s, hash, v)
+	s.Add(hash)
+}
+
+// AddFloat64 hashes and adds a floating point number. Numbers are hashed using their
+// IEEE 754 representation, negative zero and NaNs are canonicalized first.
+func (s *HLL) AddFloat64(v float64) {
+	s.setValueType(ValueTypeDouble)
+	hash := s.hashUint64(float64Bits(v))
+	sample(s, hash, v)
+	s.Add(hash)
+}
+
+// exemplar is the set of value types kept by WithExemplars.
+type exemplar interface {
+	string | []byte | int64 | uint64 | float64
+}
+
+// sample keeps v as exemplar of hash, if the sketch keeps exemplars and accepts it. Values
+// are only boxed, and byte slices copied, once accepted. Wrappers which add hashes to a
+// sketch directly must call it for their typed values, see WithExemplars.
+func sample[T exemplar](s *HLL, hash uint64, v T) {
+	if !s.exemplars.accepts(hash) {
+		return
+	}
+
+	x := interface{}(v)
+	if b, ok := x.([]byte); ok {
+		x = append([]byte(nil), b...)
+	}
+	s.exemplars.insert(hash, x)
+}
+
+// float64Bits returns the IEEE 754 representation of a canonicalized float.
+func float64Bits(v float64) uint64 {
+	switch {
+	case v == 0:
+		v = 0
+	case v != v:
+		v = math.NaN()
+	}
+	return math.Float64bits(v)
+}
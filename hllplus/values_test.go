@@ -0,0 +1,225 @@
+package hllplus_test
+
+import (
+	"fmt"
+	"hash/fnv"
+	"math"
+	"testing"
+
+	"github.com/gowthamkommineni/zetasketch/hllplus"
+	"github.com/gowthamkommineni/zetasketch/internal/hash"
+
+	. "github.com/bsm/ginkgo"
+	. "github.com/bsm/gomega"
+)
+
+var _ = Describe("HLL values", func() {
+	var subject, expected *hllplus.HLL
+
+	BeforeEach(func() {
+		subject, _ = hllplus.New(12, 17)
+		expected, _ = hllplus.New(12, 17)
+	})
+
+	It("should add strings", func() {
+		subject.AddString("foo")
+		subject.AddString("Zürich")
+		subject.AddString("foo")
+
+		expected.Add(0xd0bcbfe261b36504)
+		expected.Add(0x27efc00f7d2ce548)
+		Expect(subject.Proto()).To(Equal(expected.Proto()))
+		Expect(subject.Estimate()).To(Equal(int64(2)))
+	})
+
+	It("should add strings in batches", func() {
+		values := make([]string, 5_000)
+		for i := range values {
+			values[i] = fmt.Sprintf("user-%d", i%4_000)
+			expected.AddString(values[i])
+		}
+
+		subject.AddStrings(values)
+		Expect(subject.Proto()).To(Equal(expected.Proto()))
+		Expect(subject.IsSparse()).To(BeFalse())
+	})
+
+	It("should add bytes", func() {
+		subject.AddBytes([]byte("foo"))
+		subject.AddBytes(nil)
+
+		expected.Add(0xd0bcbfe261b36504)
+		expected.Add(0x23ad7c904aa665e3)
+		Expect(subject.Proto()).To(Equal(expected.Proto()))
+	})
+
+	It("should add numbers", func() {
+		subject.AddInt64(1)
+		subject.AddInt64(-1)
+		subject.AddUint64(1)
+		subject.AddUint64(2)
+
+		expected.Add(0xb91968b83211c978)
+		expected.Add(hash.Uint64(1<<64 - 1))
+		expected.Add(0x83e2c1afe085d87a)
+		Expect(subject.Proto()).To(Equal(expected.Proto()))
+		Expect(subject.Estimate()).To(Equal(int64(3)))
+	})
+	It("should add 128-bit hashes", func() {
+		subject.Add128(0xcbd8a7b341bd9b02, 0x5b1e906a48ae1d19)
+		subject.Add128(0, 0x27efc00f7d2ce548)
+
+		expected.Add(0x90c637d90913861b)
+		expected.Add(0x27efc00f7d2ce548)
+		Expect(subject.Proto()).To(Equal(expected.Proto()))
+	})
+
+	It("should add floats", func() {
+		subject.AddFloat64(1.5)
+		subject.AddFloat64(math.Copysign(0, -1))
+		subject.AddFloat64(math.NaN())
+
+		expected.AddUint64(math.Float64bits(1.5))
+		expected.AddUint64(0)
+		expected.AddUint64(0x7ff8000000000001)
+		Expect(subject.Proto()).To(Equal(expected.Proto()))
+	})
+
+	It("should add generic values", func() {
+		type userID int32
+		type name string
+
+		hllplus.AddValue(subject, "foo")
+		hllplus.AddValue(subject, []byte("foo"))
+		hllplus.AddValue(subject, name("bar"))
+		hllplus.AddValue(subject, 7)
+		hllplus.AddValue(subject, userID(-1))
+		hllplus.AddValue(subject, uint8(9))
+		hllplus.AddValue(subject, float32(1.5))
+
+		expected.AddString("foo")
+		expected.AddString("bar")
+		expected.AddInt64(7)
+		expected.AddInt64(-1)
+		expected.AddUint64(9)
+		expected.AddFloat64(1.5)
+		Expect(subject.Proto()).To(Equal(expected.Proto()))
+		Expect(subject.Estimate()).To(Equal(int64(6)))
+	})
+
+	It("should add values using custom hashers", func() {
+		subject, err := hllplus.New(12, 17, hllplus.WithHasher(fnvHasher{}))
+		Expect(err).NotTo(HaveOccurred())
+		Expect(subject.Hasher().ID()).To(Equal("fnv64a"))
+		Expect(expected.Hasher()).To(Equal(hllplus.Fingerprint2011))
+
+		subject.AddString("foo")
+		subject.AddUint64(1)
+
+		expected.Add(0xdcb27518fed9d577)
+		expected.Add(0x89cd31291d2aefa4)
+		Expect(subject.Proto()).To(Equal(expected.Proto()))
+	})
+
+	It("should provide built-in hashers", func() {
+		Expect(hllplus.XXHash64.ID()).To(Equal("xxhash64"))
+		Expect(hllplus.XXHash64.Hash64([]byte("abc"))).To(Equal(uint64(0x44bc2cf5ad770999)))
+		Expect(hllplus.Murmur3.ID()).To(Equal("murmur3-128"))
+		Expect(hllplus.Murmur3.Hash64([]byte("hello"))).To(Equal(uint64(0xcbd8a7b341bd9b02 ^ 0x5b1e906a48ae1d19)))
+	})
+
+	It("should reject nil hashers", func() {
+		_, err := hllplus.New(12, 17, hllplus.WithHasher(nil))
+		Expect(err).To(MatchError("invalid hasher"))
+	})
+
+	It("should add values using seeds", func() {
+		subject, err := hllplus.New(12, 17, hllplus.WithSeed(42))
+		Expect(err).NotTo(HaveOccurred())
+		Expect(subject.Seed()).To(Equal(uint64(42)))
+
+		subject.AddString("foo")
+		subject.AddString("foo")
+		subject.Add(0x27efc00f7d2ce548)
+
+		expected.Add(0x99d6ef7b19523c45)
+		expected.Add(0x27efc00f7d2ce548)
+		Expect(subject.Proto()).To(Equal(expected.Proto()))
+		Expect(subject.Clone().Seed()).To(Equal(uint64(42)))
+	})
+
+	It("should refuse to merge sketches with different seeds", func() {
+		other, _ := hllplus.New(12, 17, hllplus.WithSeed(1))
+		other.AddString("foo")
+
+		Expect(subject.Merge(other)).To(MatchError("cannot merge sketches with different seeds 0 and 1"))
+		Expect(other.Merge(other.Clone())).To(Succeed())
+	})
+
+	It("should track value types", func() {
+		Expect(subject.ValueType()).To(Equal(hllplus.ValueTypeUnknown))
+		subject.Add(1)
+		Expect(subject.ValueType()).To(Equal(hllplus.ValueTypeUnknown))
+		subject.AddString("foo")
+		Expect(subject.ValueType()).To(Equal(hllplus.ValueTypeBytes))
+		Expect(subject.ValueType().String()).To(Equal("BYTES_OR_UTF8_STRING"))
+
+		typed, err := hllplus.New(12, 17, hllplus.WithValueType(hllplus.ValueTypeInt64))
+		Expect(err).NotTo(HaveOccurred())
+		Expect(typed.ValueType()).To(Equal(hllplus.ValueTypeInt64))
+		typed.AddInt64(7)
+		Expect(typed.ValueType()).To(Equal(hllplus.ValueTypeInt64))
+
+		_, err = hllplus.New(12, 17, hllplus.WithValueType(99))
+		Expect(err).To(MatchError("invalid value type 99"))
+	})
+
+	It("should refuse to merge sketches with different value types", func() {
+		subject.AddInt64(1)
+		other, _ := hllplus.New(12, 17)
+		other.AddString("foo")
+		Expect(subject.Merge(other)).To(MatchError("cannot merge sketches with different value types INT64 and BYTES_OR_UTF8_STRING"))
+
+		untyped, _ := hllplus.New(12, 17)
+		untyped.Add(1)
+		Expect(untyped.Merge(other)).To(Succeed())
+		Expect(untyped.ValueType()).To(Equal(hllplus.ValueTypeBytes))
+		Expect(other.Merge(expected)).To(Succeed())
+		Expect(other.ValueType()).To(Equal(hllplus.ValueTypeBytes))
+	})
+
+	It("should refuse to merge sketches with different hashers", func() {
+		other, _ := hllplus.New(12, 17, hllplus.WithHasher(fnvHasher{}))
+		other.AddString("foo")
+
+		Expect(subject.Merge(other)).To(MatchError(`cannot merge sketches with different hashers "fingerprint2011" and "fnv64a"`))
+		Expect(subject.Estimate()).To(Equal(int64(0)))
+		Expect(other.Merge(other.Clone())).To(Succeed())
+	})
+})
+
+type fnvHasher struct{}
+
+func (fnvHasher) ID() string { return "fnv64a" }
+func (fnvHasher) Hash64(p []byte) uint64 {
+	h := fnv.New64a()
+	_, _ = h.Write(p)
+	return h.Sum64()
+}
+
+func BenchmarkHLL_AddString(b *testing.B) {
+	values := make([]string, 1024)
+	for i := range values {
+		values[i] = fmt.Sprintf("user-%08d@example.com", i)
+	}
+
+	for _, hasher := range []hllplus.Hasher{hllplus.Fingerprint2011, hllplus.XXHash64, hllplus.Murmur3} {
+		b.Run(hasher.ID(), func(b *testing.B) {
+			subject, _ := hllplus.New(15, 20, hllplus.WithHasher(hasher))
+			b.ResetTimer()
+			for i := 0; i < b.N; i++ {
+				subject.AddString(values[i%len(values)])
+			}
+		})
+	}
+}
@@ -0,0 +1,38 @@
+package hllplus
+
+import pb "github.com/gowthamkommineni/zetasketch/internal/zetasketch"
+
+// ValueType identifies the type of values added to a sketch. It is stored in the value_type
+// field of serialized sketches, like the zetasketch Java library does. Sketches with different
+// known value types cannot be merged.
+type ValueType int32
+
+// Supported value types.
+const (
+	ValueTypeUnknown = ValueType(pb.DefaultOpsType_UNKNOWN)
+	ValueTypeInt32   = ValueType(pb.DefaultOpsType_INT32)
+	ValueTypeInt64   = ValueType(pb.DefaultOpsType_INT64)
+	ValueTypeUint32  = ValueType(pb.DefaultOpsType_UINT32)
+	ValueTypeUint64  = ValueType(pb.DefaultOpsType_UINT64)
+	ValueTypeDouble  = ValueType(pb.DefaultOpsType_DOUBLE)
+	ValueTypeBytes   = ValueType(pb.DefaultOpsType_BYTES_OR_UTF8_STRING)
+)
+
+// String returns the name of the value type.
+func (t ValueType) String() string {
+	return pb.DefaultOpsType_Id(t).String()
+}
+
+// ValueType returns the type of values added to the sketch. It is either set explicitly
+// via WithValueType, restored from a serialized sketch or inferred from the first value
+// added via AddString, AddInt64 or similar.
+func (s *HLL) ValueType() ValueType {
+	return s.valueType
+}
+
+// setValueType records the value type, unless already known.
+func (s *HLL) setValueType(t ValueType) {
+	if s.valueType == ValueTypeUnknown {
+		s.valueType = t
+	}
+}
@@ -0,0 +1,183 @@
+package hllplus
+
+import (
+	"fmt"
+	"time"
+)
+
+// WindowHLL counts distinct values over a sliding time window, e.g. distinct users in
+// the last 15 minutes. The window is divided into buckets of a fixed granularity, each
+// with its own sketch, which are merged when the window is estimated. Buckets expire
+// automatically as time advances, so the window slides in steps of the granularity.
+//
+// WindowHLL is not safe for concurrent use.
+type WindowHLL struct {
+	tmpl        *HLL // empty sketch, carries the configuration
+	granularity int64
+	buckets     []windowBucket // ring, indexed by bucket number
+	head        int64          // the latest bucket number
+	started     bool
+}
+
+type windowBucket struct {
+	num int64
+	s   *HLL // nil if unused
+}
+
+// NewWindow inits a new sliding window, which must be a positive multiple of the
+// granularity. See New for all other parameters.
+func NewWindow(window, granularity time.Duration, precision, sparsePrecision uint8, opts ...Option) (*WindowHLL, error) {
+	if granularity <= 0 || window <= 0 || window%granularity != 0 {
+		return nil, fmt.Errorf("invalid window %s: must be a positive multiple of the granularity %s", window, granularity)
+	}
+
+	tmpl, err := New(precision, sparsePrecision, opts...)
+	if err != nil {
+		return nil, err
+	}
+
+	return &WindowHLL{
+		tmpl:        tmpl,
+		granularity: int64(granularity),
+		buckets:     make([]windowBucket, window/granularity),
+	}, nil
+}
+
+// Window returns the length of the window.
+func (w *WindowHLL) Window() time.Duration {
+	return time.Duration(w.granularity * int64(len(w.buckets)))
+}
+
+// Granularity returns the length of a bucket.
+func (w *WindowHLL) Granularity() time.Duration {
+	return time.Duration(w.granularity)
+}
+
+// At returns the sketch of the bucket which contains t, so values with event times can
+// be added using any of the sketch's methods. Advancing to a later bucket expires all
+// buckets which are no longer part of the window. At returns nil if t is older than the
+// window of the latest bucket seen. The sketch must not be retained, it is released
+// once the bucket expires.
+func (w *WindowHLL) At(t time.Time) *HLL {
+	num := w.bucketNum(t)
+	if !w.started || num > w.head {
+		w.advance(num)
+	} else if num <= w.head-int64(len(w.buckets)) {
+		return nil
+	}
+
+	b := &w.buckets[w.slot(num)]
+	if b.s == nil || b.num != num {
+		if b.s != nil {
+			b.s.Release()
+		}
+		b.num, b.s = num, w.tmpl.Clone()
+	}
+	return b.s
+}
+
+// Add adds the uniform hash value to the current bucket.
+func (w *WindowHLL) Add(hash uint64) {
+	w.At(time.Now()).Add(hash)
+}
+
+// AddString hashes and adds a string value to the current bucket, see HLL.AddString.
+func (w *WindowHLL) AddString(v string) {
+	w.At(time.Now()).AddString(v)
+}
+
+// AddBytes hashes and adds a byte value to the current bucket, see HLL.AddBytes.
+func (w *WindowHLL) AddBytes(v []byte) {
+	w.At(time.Now()).AddBytes(v)
+}
+
+// AddInt64 hashes and adds a signed number to the current bucket, see HLL.AddInt64.
+func (w *WindowHLL) AddInt64(v int64) {
+	w.At(time.Now()).AddInt64(v)
+}
+
+// AddUint64 hashes and adds an unsigned number to the current bucket, see
+// HLL.AddUint64.
+func (w *WindowHLL) AddUint64(v uint64) {
+	w.At(time.Now()).AddUint64(v)
+}
+
+// AddFloat64 hashes and adds a floating point number to the current bucket, see
+// HLL.AddFloat64.
+func (w *WindowHLL) AddFloat64(v float64) {
+	w.At(time.Now()).AddFloat64(v)
+}
+
+// Snapshot merges all buckets of the window which ends now into a new sketch.
+func (w *WindowHLL) Snapshot() *HLL {
+	return w.SnapshotAt(time.Now())
+}
+
+// SnapshotAt merges all buckets of the window which ends with the bucket containing t
+// into a new sketch. Buckets are not expired, so SnapshotAt can also look at past
+// windows, as long as their buckets have not been reused.
+func (w *WindowHLL) SnapshotAt(t time.Time) *HLL {
+	num := w.bucketNum(t)
+	res := w.tmpl.Clone()
+	for _, b := range w.buckets {
+		if b.s != nil && b.num <= num && b.num > num-int64(len(w.buckets)) {
+			_ = res.Merge(b.s) // buckets are always compatible
+		}
+	}
+	return res
+}
+
+// Estimate returns the number of distinct values in the window which ends now.
+func (w *WindowHLL) Estimate() int64 {
+	return w.Snapshot().Estimate()
+}
+
+// EstimateAt returns the number of distinct values in the window which ends with the
+// bucket containing t, see SnapshotAt.
+func (w *WindowHLL) EstimateAt(t time.Time) int64 {
+	return w.SnapshotAt(t).Estimate()
+}
+
+// MemoryUsage returns the number of bytes held by all buckets, see HLL.MemoryUsage.
+func (w *WindowHLL) MemoryUsage() int {
+	var n int
+	for _, b := range w.buckets {
+		if b.s != nil {
+			n += b.s.MemoryUsage()
+		}
+	}
+	return n
+}
+
+// Reset removes all buckets.
+func (w *WindowHLL) Reset() {
+	for i := range w.buckets {
+		if b := &w.buckets[i]; b.s != nil {
+			b.s.Release()
+			*b = windowBucket{}
+		}
+	}
+	w.head, w.started = 0, false
+}
+
+// advance moves the head to bucket num and releases all buckets which fall out of the
+// window.
+func (w *WindowHLL) advance(num int64) {
+	for i := range w.buckets {
+		if b := &w.buckets[i]; b.s != nil && b.num <= num-int64(len(w.buckets)) {
+			b.s.Release()
+			*b = windowBucket{}
+		}
+	}
+	w.head, w.started = num, true
+}
+
+// bucketNum returns the number of the bucket containing t.
+func (w *WindowHLL) bucketNum(t time.Time) int64 {
+	return floorDiv(t.UnixNano(), w.granularity)
+}
+
+func (w *WindowHLL) slot(num int64) int {
+	n := int64(len(w.buckets))
+	return int((num%n + n) % n)
+}
@@ -0,0 +1,81 @@
+package hllplus_test
+
+import (
+	"time"
+
+	"github.com/gowthamkommineni/zetasketch/hllplus"
+
+	. "github.com/bsm/ginkgo"
+	. "github.com/bsm/gomega"
+)
+
+var _ = Describe("WindowHLL", func() {
+	var subject *hllplus.WindowHLL
+	t0 := time.Date(2024, 5, 1, 12, 0, 0, 0, time.UTC)
+
+	BeforeEach(func() {
+		var err error
+		subject, err = hllplus.NewWindow(10*time.Minute, time.Minute, 12, 17)
+		Expect(err).NotTo(HaveOccurred())
+	})
+
+	It("should validate", func() {
+		_, err := hllplus.NewWindow(10*time.Minute, 3*time.Minute, 12, 17)
+		Expect(err).To(MatchError("invalid window 10m0s: must be a positive multiple of the granularity 3m0s"))
+		_, err = hllplus.NewWindow(10*time.Minute, 0, 12, 17)
+		Expect(err).To(MatchError("invalid window 10m0s: must be a positive multiple of the granularity 0s"))
+		_, err = hllplus.NewWindow(10*time.Minute, time.Minute, 30, 17)
+		Expect(err).To(MatchError("invalid normal precision 30"))
+
+		Expect(subject.Window()).To(Equal(10 * time.Minute))
+		Expect(subject.Granularity()).To(Equal(time.Minute))
+	})
+
+	It("should count distinct values in the window", func() {
+		// user i is active in minute i/100
+		for i := 0; i < 2_000; i++ {
+			subject.At(t0.Add(time.Duration(i/100) * time.Minute)).AddInt64(int64(i))
+		}
+
+		Expect(subject.EstimateAt(t0.Add(19*time.Minute + 30*time.Second))).To(BeNumerically("~", 1_000, 30))
+		Expect(subject.EstimateAt(t0.Add(14 * time.Minute))).To(BeNumerically("~", 500, 15))
+		Expect(subject.EstimateAt(t0.Add(25 * time.Minute))).To(BeNumerically("~", 400, 15))
+		Expect(subject.EstimateAt(t0.Add(40 * time.Minute))).To(BeZero())
+
+		// older buckets have expired:
+		Expect(subject.EstimateAt(t0.Add(5 * time.Minute))).To(BeZero())
+		Expect(subject.At(t0.Add(5 * time.Minute))).To(BeNil())
+
+		// late values within the window are accepted:
+		subject.At(t0.Add(10 * time.Minute)).AddInt64(-1)
+		Expect(subject.EstimateAt(t0.Add(19 * time.Minute))).To(BeNumerically("~", 1_001, 30))
+	})
+
+	It("should expire buckets when advancing", func() {
+		for i := 0; i < 1_000; i++ {
+			subject.At(t0).AddInt64(int64(i))
+		}
+		Expect(subject.MemoryUsage()).To(BeNumerically(">", 0))
+		Expect(subject.EstimateAt(t0.Add(9 * time.Minute))).To(BeNumerically("~", 1_000, 30))
+
+		Expect(subject.At(t0.Add(10 * time.Minute))).NotTo(BeNil())
+		Expect(subject.EstimateAt(t0.Add(10 * time.Minute))).To(BeZero())
+		Expect(subject.EstimateAt(t0)).To(BeZero())
+
+		// reusing the slot of an expired bucket starts empty:
+		subject.At(t0.Add(20 * time.Minute)).AddInt64(1)
+		Expect(subject.EstimateAt(t0.Add(20 * time.Minute))).To(Equal(int64(1)))
+	})
+
+	It("should add values now", func() {
+		subject.AddString("a")
+		subject.AddString("b")
+		subject.AddInt64(1)
+		Expect(subject.Estimate()).To(Equal(int64(3)))
+		Expect(subject.Snapshot().ValueType()).To(Equal(hllplus.ValueTypeBytes))
+
+		subject.Reset()
+		Expect(subject.Estimate()).To(BeZero())
+		Expect(subject.MemoryUsage()).To(BeZero())
+	})
+})
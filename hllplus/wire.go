@@ -0,0 +1,271 @@
+package hllplus
+
+import (
+	pb "github.com/gowthamkommineni/zetasketch/internal/zetasketch"
+	"google.golang.org/protobuf/encoding/protowire"
+	"google.golang.org/protobuf/proto"
+)
+
+// decodeAggregatorProto decodes an AggregatorStateProto message with a
+// HyperLogLog++ state extension. Unlike proto.Unmarshal, the bytes fields of the
+// resulting state alias b instead of being copied.
+func decodeAggregatorProto(b []byte) (*pb.AggregatorStateProto, error) {
+	msg := new(pb.AggregatorStateProto)
+	var state *pb.HyperLogLogPlusUniqueStateProto
+
+	err := decodeFields(b, func(num protowire.Number, typ protowire.Type, b []byte) (int, error) {
+		switch {
+		case num == 1 && typ == protowire.VarintType:
+			v, n := protowire.ConsumeVarint(b)
+			msg.Type = pb.AggregatorType(int32(v)).Enum()
+			return n, nil
+		case num == 2 && typ == protowire.VarintType:
+			v, n := protowire.ConsumeVarint(b)
+			msg.NumValues = proto.Int64(int64(v))
+			return n, nil
+		case num == 3 && typ == protowire.VarintType:
+			v, n := protowire.ConsumeVarint(b)
+			msg.EncodingVersion = proto.Int32(int32(v))
+			return n, nil
+		case num == 4 && typ == protowire.VarintType:
+			v, n := protowire.ConsumeVarint(b)
+			msg.ValueType = proto.Int32(int32(v))
+			return n, nil
+		case num == protowire.Number(pb.E_HyperloglogplusUniqueState.Field) && typ == protowire.BytesType:
+			v, n := protowire.ConsumeBytes(b)
+			if n < 0 {
+				return n, nil
+			}
+			if state == nil {
+				state = new(pb.HyperLogLogPlusUniqueStateProto)
+			}
+			return n, decodeStateProto(v, state)
+		}
+		return protowire.ConsumeFieldValue(num, typ, b), nil
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	if state != nil {
+		proto.SetExtension(msg, pb.E_HyperloglogplusUniqueState, state)
+	}
+	return msg, nil
+}
+
+func decodeStateProto(b []byte, state *pb.HyperLogLogPlusUniqueStateProto) error {
+	return decodeFields(b, func(num protowire.Number, typ protowire.Type, b []byte) (int, error) {
+		switch {
+		case num == 2 && typ == protowire.VarintType:
+			v, n := protowire.ConsumeVarint(b)
+			state.SparseSize = proto.Int32(int32(v))
+			return n, nil
+		case num == 3 && typ == protowire.VarintType:
+			v, n := protowire.ConsumeVarint(b)
+			state.PrecisionOrNumBuckets = proto.Int32(int32(v))
+			return n, nil
+		case num == 4 && typ == protowire.VarintType:
+			v, n := protowire.ConsumeVarint(b)
+			state.SparsePrecisionOrNumBuckets = proto.Int32(int32(v))
+			return n, nil
+		case num == 5 && typ == protowire.BytesType:
+			v, n := protowire.ConsumeBytes(b)
+			state.Data = v
+			return n, nil
+		case num == 6 && typ == protowire.BytesType:
+			v, n := protowire.ConsumeBytes(b)
+			state.SparseData = v
+			return n, nil
+		}
+		return protowire.ConsumeFieldValue(num, typ, b), nil
+	})
+}
+
+// wireSketch holds the fields of an AggregatorStateProto message with a HyperLogLog++
+// state extension. Bytes fields alias the encoded message.
+type wireSketch struct {
+	aggType         pb.AggregatorType
+	numValues       int64
+	encodingVersion int32
+	valueType       int32
+	hasNumValues    bool
+	hasState        bool
+
+	precision       int32
+	sparsePrecision int32
+	data            []byte
+	sparseData      []byte
+}
+
+// decodeWireSketch decodes the fields of a serialized sketch without building proto
+// messages. Unset fields default like the getters of the generated messages.
+func decodeWireSketch(b []byte, w *wireSketch) error {
+	*w = wireSketch{encodingVersion: 1}
+
+	return decodeFields(b, func(num protowire.Number, typ protowire.Type, b []byte) (int, error) {
+		switch {
+		case num == 1 && typ == protowire.VarintType:
+			v, n := protowire.ConsumeVarint(b)
+			w.aggType = pb.AggregatorType(int32(v))
+			return n, nil
+		case num == 2 && typ == protowire.VarintType:
+			v, n := protowire.ConsumeVarint(b)
+			w.numValues, w.hasNumValues = int64(v), true
+			return n, nil
+		case num == 3 && typ == protowire.VarintType:
+			v, n := protowire.ConsumeVarint(b)
+			w.encodingVersion = int32(v)
+			return n, nil
+		case num == 4 && typ == protowire.VarintType:
+			v, n := protowire.ConsumeVarint(b)
+			w.valueType = int32(v)
+			return n, nil
+		case num == protowire.Number(pb.E_HyperloglogplusUniqueState.Field) && typ == protowire.BytesType:
+			v, n := protowire.ConsumeBytes(b)
+			if n < 0 {
+				return n, nil
+			}
+			w.hasState = true
+			return n, decodeWireState(v, w)
+		}
+		return protowire.ConsumeFieldValue(num, typ, b), nil
+	})
+}
+
+func decodeWireState(b []byte, w *wireSketch) error {
+	return decodeFields(b, func(num protowire.Number, typ protowire.Type, b []byte) (int, error) {
+		switch {
+		case num == 3 && typ == protowire.VarintType:
+			v, n := protowire.ConsumeVarint(b)
+			w.precision = int32(v)
+			return n, nil
+		case num == 4 && typ == protowire.VarintType:
+			v, n := protowire.ConsumeVarint(b)
+			w.sparsePrecision = int32(v)
+			return n, nil
+		case num == 5 && typ == protowire.BytesType:
+			v, n := protowire.ConsumeBytes(b)
+			w.data = v
+			return n, nil
+		case num == 6 && typ == protowire.BytesType:
+			v, n := protowire.ConsumeBytes(b)
+			w.sparseData = v
+			return n, nil
+		}
+		return protowire.ConsumeFieldValue(num, typ, b), nil
+	})
+}
+
+// decodeFields iterates over the fields of a message. The callback must
+// consume the field value and return the number of bytes consumed.
+func decodeFields(b []byte, fn func(protowire.Number, protowire.Type, []byte) (int, error)) error {
+	for len(b) > 0 {
+		num, typ, n := protowire.ConsumeTag(b)
+		if n < 0 {
+			return protowire.ParseError(n)
+		}
+		b = b[n:]
+
+		n, err := fn(num, typ, b)
+		if err != nil {
+			return err
+		} else if n < 0 {
+			return protowire.ParseError(n)
+		}
+		b = b[n:]
+	}
+	return nil
+}
+
+// appendAggregatorProto encodes an AggregatorStateProto message with an optional
+// HyperLogLog++ state extension. Unlike proto.Marshal, fields are always written in
+// field number order, exactly like the zetasketch Java library.
+func appendAggregatorProto(b []byte, msg *pb.AggregatorStateProto, state *pb.HyperLogLogPlusUniqueStateProto) []byte {
+	if msg.Type != nil {
+		b = protowire.AppendTag(b, 1, protowire.VarintType)
+		b = protowire.AppendVarint(b, uint64(*msg.Type))
+	}
+	if msg.NumValues != nil {
+		b = protowire.AppendTag(b, 2, protowire.VarintType)
+		b = protowire.AppendVarint(b, uint64(*msg.NumValues))
+	}
+	if msg.EncodingVersion != nil {
+		b = protowire.AppendTag(b, 3, protowire.VarintType)
+		b = protowire.AppendVarint(b, uint64(*msg.EncodingVersion))
+	}
+	if msg.ValueType != nil {
+		b = protowire.AppendTag(b, 4, protowire.VarintType)
+		b = protowire.AppendVarint(b, uint64(*msg.ValueType))
+	}
+	if state != nil {
+		b = protowire.AppendTag(b, protowire.Number(pb.E_HyperloglogplusUniqueState.Field), protowire.BytesType)
+		b = protowire.AppendVarint(b, uint64(sizeStateProto(state)))
+		b = appendStateProto(b, state)
+	}
+	return b
+}
+
+// sizeAggregatorProto returns the encoded size of an AggregatorStateProto message.
+func sizeAggregatorProto(msg *pb.AggregatorStateProto, state *pb.HyperLogLogPlusUniqueStateProto) int {
+	n := 0
+	if msg.Type != nil {
+		n += 1 + protowire.SizeVarint(uint64(*msg.Type))
+	}
+	if msg.NumValues != nil {
+		n += 1 + protowire.SizeVarint(uint64(*msg.NumValues))
+	}
+	if msg.EncodingVersion != nil {
+		n += 1 + protowire.SizeVarint(uint64(*msg.EncodingVersion))
+	}
+	if msg.ValueType != nil {
+		n += 1 + protowire.SizeVarint(uint64(*msg.ValueType))
+	}
+	if state != nil {
+		n += protowire.SizeTag(protowire.Number(pb.E_HyperloglogplusUniqueState.Field)) + protowire.SizeBytes(sizeStateProto(state))
+	}
+	return n
+}
+
+func appendStateProto(b []byte, msg *pb.HyperLogLogPlusUniqueStateProto) []byte {
+	if msg.SparseSize != nil {
+		b = protowire.AppendTag(b, 2, protowire.VarintType)
+		b = protowire.AppendVarint(b, uint64(*msg.SparseSize))
+	}
+	if msg.PrecisionOrNumBuckets != nil {
+		b = protowire.AppendTag(b, 3, protowire.VarintType)
+		b = protowire.AppendVarint(b, uint64(*msg.PrecisionOrNumBuckets))
+	}
+	if msg.SparsePrecisionOrNumBuckets != nil {
+		b = protowire.AppendTag(b, 4, protowire.VarintType)
+		b = protowire.AppendVarint(b, uint64(*msg.SparsePrecisionOrNumBuckets))
+	}
+	if msg.Data != nil {
+		b = protowire.AppendTag(b, 5, protowire.BytesType)
+		b = protowire.AppendBytes(b, msg.Data)
+	}
+	if msg.SparseData != nil {
+		b = protowire.AppendTag(b, 6, protowire.BytesType)
+		b = protowire.AppendBytes(b, msg.SparseData)
+	}
+	return b
+}
+
+func sizeStateProto(msg *pb.HyperLogLogPlusUniqueStateProto) int {
+	n := 0
+	if msg.SparseSize != nil {
+		n += 1 + protowire.SizeVarint(uint64(*msg.SparseSize))
+	}
+	if msg.PrecisionOrNumBuckets != nil {
+		n += 1 + protowire.SizeVarint(uint64(*msg.PrecisionOrNumBuckets))
+	}
+	if msg.SparsePrecisionOrNumBuckets != nil {
+		n += 1 + protowire.SizeVarint(uint64(*msg.SparsePrecisionOrNumBuckets))
+	}
+	if msg.Data != nil {
+		n += 1 + protowire.SizeBytes(len(msg.Data))
+	}
+	if msg.SparseData != nil {
+		n += 1 + protowire.SizeBytes(len(msg.SparseData))
+	}
+	return n
+}
@@ -0,0 +1,70 @@
+package hllplus
+
+import "bytes"
+
+// Writer adapts a sketch to io.Writer. By default, each call to Write adds the
+// written bytes as a single value, which makes it suitable for fmt.Fprintf-style
+// pipelines. Delimited writers split the written stream into values instead.
+type Writer struct {
+	s *HLL
+
+	delim     byte
+	delimited bool
+	buf       []byte
+}
+
+// NewWriter returns a writer which adds each written chunk as a value to s.
+func NewWriter(s *HLL) *Writer {
+	return &Writer{s: s}
+}
+
+// NewDelimitedWriter returns a writer which splits the written stream by delim
+// and adds each non-empty frame as a value to s. A trailing frame without
+// delimiter is buffered until Flush is called.
+func NewDelimitedWriter(s *HLL, delim byte) *Writer {
+	return &Writer{s: s, delim: delim, delimited: true}
+}
+
+// Write implements io.Writer.
+func (w *Writer) Write(p []byte) (int, error) {
+	if !w.delimited {
+		w.s.AddBytes(p)
+		return len(p), nil
+	}
+
+	n := len(p)
+	for {
+		pos := bytes.IndexByte(p, w.delim)
+		if pos < 0 {
+			w.buf = append(w.buf, p...)
+			return n, nil
+		}
+
+		if len(w.buf) != 0 {
+			w.buf = append(w.buf, p[:pos]...)
+			w.s.AddBytes(w.buf)
+			w.buf = w.buf[:0]
+		} else if pos != 0 {
+			w.s.AddBytes(p[:pos])
+		}
+		p = p[pos+1:]
+	}
+}
+
+// WriteString implements io.StringWriter.
+func (w *Writer) WriteString(s string) (int, error) {
+	if !w.delimited {
+		w.s.AddString(s)
+		return len(s), nil
+	}
+	return w.Write([]byte(s))
+}
+
+// Flush adds a buffered trailing frame of a delimited writer to the sketch.
+func (w *Writer) Flush() error {
+	if len(w.buf) != 0 {
+		w.s.AddBytes(w.buf)
+		w.buf = w.buf[:0]
+	}
+	return nil
+}
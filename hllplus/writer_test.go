@@ -0,0 +1,51 @@
+package hllplus_test
+
+import (
+	"fmt"
+	"io"
+	"strings"
+
+	"github.com/gowthamkommineni/zetasketch/hllplus"
+
+	. "github.com/bsm/ginkgo"
+	. "github.com/bsm/gomega"
+)
+
+var _ = Describe("Writer", func() {
+	var subject, expected *hllplus.HLL
+
+	BeforeEach(func() {
+		subject, _ = hllplus.New(12, 17)
+		expected, _ = hllplus.New(12, 17)
+	})
+
+	It("should add each write as a value", func() {
+		w := hllplus.NewWriter(subject)
+		fmt.Fprintf(w, "user:%d", 1)
+		fmt.Fprintf(w, "user:%d", 2)
+		_, _ = io.WriteString(w, "user:1")
+		Expect(w.Flush()).To(Succeed())
+
+		expected.AddString("user:1")
+		expected.AddString("user:2")
+		Expect(subject.Proto()).To(Equal(expected.Proto()))
+		Expect(subject.Estimate()).To(Equal(int64(2)))
+	})
+
+	It("should split delimited streams", func() {
+		w := hllplus.NewDelimitedWriter(subject, '\n')
+		n, err := io.Copy(w, strings.NewReader("foo\nbar\n\nba"))
+		Expect(err).NotTo(HaveOccurred())
+		Expect(n).To(Equal(int64(11)))
+		_, _ = w.WriteString("z\nqux")
+
+		expected.AddString("foo")
+		expected.AddString("bar")
+		expected.AddString("baz")
+		Expect(subject.Proto()).To(Equal(expected.Proto()))
+
+		Expect(w.Flush()).To(Succeed())
+		expected.AddString("qux")
+		Expect(subject.Proto()).To(Equal(expected.Proto()))
+	})
+})
@@ -0,0 +1,116 @@
+package hllserver
+
+import (
+	"context"
+	"fmt"
+	"sync"
+	"time"
+)
+
+// ExtractFunc extracts the counter and the values to add from a message.
+type ExtractFunc func(msg []byte) (counter string, v Values, err error)
+
+// CheckpointFunc persists serialized sketches by counter, e.g. to a database or to an
+// output topic.
+type CheckpointFunc func(ctx context.Context, sketches map[string][]byte) error
+
+// Consumer aggregates messages from a consumer loop, e.g. of Kafka or Pub/Sub, into the
+// counters of a Service and periodically checkpoints the sketches of all counters which
+// have been updated since the last checkpoint. Checkpoints contain the full state of
+// each counter, so persisting the latest one per counter is sufficient. It is safe for
+// concurrent use.
+type Consumer struct {
+	s          *Service
+	extract    ExtractFunc
+	checkpoint CheckpointFunc
+
+	mu    sync.Mutex
+	dirty map[string]struct{}
+}
+
+// NewConsumer inits a new consumer.
+func NewConsumer(s *Service, extract ExtractFunc, checkpoint CheckpointFunc) *Consumer {
+	return &Consumer{
+		s:          s,
+		extract:    extract,
+		checkpoint: checkpoint,
+		dirty:      make(map[string]struct{}),
+	}
+}
+
+// Handle extracts and adds the values of a message. It can be passed as a message
+// handler to consumer loops directly. Messages which cannot be extracted are rejected
+// with an error.
+func (c *Consumer) Handle(msg []byte) error {
+	counter, v, err := c.extract(msg)
+	if err != nil {
+		return fmt.Errorf("invalid message: %w", err)
+	}
+	if err := c.s.AddValues(counter, v); err != nil {
+		return err
+	}
+
+	c.mu.Lock()
+	c.dirty[counter] = struct{}{}
+	c.mu.Unlock()
+	return nil
+}
+
+// Checkpoint passes the sketches of all counters updated since the last checkpoint to
+// the CheckpointFunc. If it fails, the counters are included in the next checkpoint.
+func (c *Consumer) Checkpoint(ctx context.Context) error {
+	c.mu.Lock()
+	dirty := c.dirty
+	c.dirty = make(map[string]struct{})
+	c.mu.Unlock()
+
+	if len(dirty) == 0 {
+		return nil
+	}
+
+	sketches := make(map[string][]byte, len(dirty))
+	for counter := range dirty {
+		data, err := c.s.ExportProto(counter)
+		if err != nil {
+			c.restore(dirty)
+			return err
+		}
+		if data != nil {
+			sketches[counter] = data
+		}
+	}
+
+	if err := c.checkpoint(ctx, sketches); err != nil {
+		c.restore(dirty)
+		return err
+	}
+	return nil
+}
+
+// Run checkpoints every interval until ctx is cancelled, then checkpoints a final time
+// with a context without cancellation. It returns the first checkpoint error.
+func (c *Consumer) Run(ctx context.Context, interval time.Duration) error {
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return c.Checkpoint(context.Background())
+		case <-ticker.C:
+			if err := c.Checkpoint(ctx); err != nil {
+				return err
+			}
+		}
+	}
+}
+
+// restore marks counters of a failed checkpoint as updated again.
+func (c *Consumer) restore(counters map[string]struct{}) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	for counter := range counters {
+		c.dirty[counter] = struct{}{}
+	}
+}
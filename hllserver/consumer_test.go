@@ -0,0 +1,114 @@
+package hllserver_test
+
+import (
+	"context"
+	"fmt"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/gowthamkommineni/zetasketch/hllplus"
+	"github.com/gowthamkommineni/zetasketch/hllserver"
+
+	. "github.com/bsm/ginkgo"
+	. "github.com/bsm/gomega"
+)
+
+var _ = Describe("Consumer", func() {
+	var subject *hllserver.Consumer
+	var mu sync.Mutex
+	var checkpoints []map[string][]byte
+	var failCheckpoint bool
+
+	// messages are "page,user"
+	extract := func(msg []byte) (string, hllserver.Values, error) {
+		parts := strings.SplitN(string(msg), ",", 2)
+		if len(parts) != 2 {
+			return "", hllserver.Values{}, fmt.Errorf("missing user")
+		}
+		return parts[0], hllserver.Values{Strings: parts[1:]}, nil
+	}
+
+	estimate := func(data []byte) int64 {
+		s := new(hllplus.HLL)
+		Expect(s.Unmarshal(data)).To(Succeed())
+		return s.Estimate()
+	}
+
+	BeforeEach(func() {
+		checkpoints, failCheckpoint = nil, false
+		service, _ := hllserver.New(14, 19)
+		subject = hllserver.NewConsumer(service, extract, func(_ context.Context, sketches map[string][]byte) error {
+			mu.Lock()
+			defer mu.Unlock()
+
+			if failCheckpoint {
+				return fmt.Errorf("unavailable")
+			}
+			checkpoints = append(checkpoints, sketches)
+			return nil
+		})
+	})
+
+	It("should checkpoint updated counters", func() {
+		Expect(subject.Handle([]byte("home,alice"))).To(Succeed())
+		Expect(subject.Handle([]byte("home,bob"))).To(Succeed())
+		Expect(subject.Handle([]byte("about,alice"))).To(Succeed())
+		Expect(subject.Handle([]byte("bad"))).To(MatchError("invalid message: missing user"))
+		Expect(subject.Handle([]byte(",alice"))).To(MatchError("invalid counter: name is required"))
+
+		Expect(subject.Checkpoint(context.Background())).To(Succeed())
+		Expect(checkpoints).To(HaveLen(1))
+		Expect(checkpoints[0]).To(HaveLen(2))
+		Expect(estimate(checkpoints[0]["home"])).To(Equal(int64(2)))
+		Expect(estimate(checkpoints[0]["about"])).To(Equal(int64(1)))
+
+		// nothing changed
+		Expect(subject.Checkpoint(context.Background())).To(Succeed())
+		Expect(checkpoints).To(HaveLen(1))
+
+		// checkpoints contain the full state
+		Expect(subject.Handle([]byte("home,carol"))).To(Succeed())
+		Expect(subject.Checkpoint(context.Background())).To(Succeed())
+		Expect(checkpoints).To(HaveLen(2))
+		Expect(checkpoints[1]).To(HaveLen(1))
+		Expect(estimate(checkpoints[1]["home"])).To(Equal(int64(3)))
+	})
+
+	It("should retry failed checkpoints", func() {
+		Expect(subject.Handle([]byte("home,alice"))).To(Succeed())
+
+		failCheckpoint = true
+		Expect(subject.Checkpoint(context.Background())).To(MatchError("unavailable"))
+		Expect(subject.Handle([]byte("about,alice"))).To(Succeed())
+
+		failCheckpoint = false
+		Expect(subject.Checkpoint(context.Background())).To(Succeed())
+		Expect(checkpoints).To(HaveLen(1))
+		Expect(checkpoints[0]).To(HaveKey("home"))
+		Expect(checkpoints[0]).To(HaveKey("about"))
+	})
+
+	It("should checkpoint periodically", func() {
+		ctx, cancel := context.WithCancel(context.Background())
+		done := make(chan error, 1)
+		go func() { done <- subject.Run(ctx, 10*time.Millisecond) }()
+
+		Expect(subject.Handle([]byte("home,alice"))).To(Succeed())
+		Eventually(func() int {
+			mu.Lock()
+			defer mu.Unlock()
+			return len(checkpoints)
+		}).Should(Equal(1))
+
+		// a final checkpoint is made on cancellation
+		Expect(subject.Handle([]byte("home,bob"))).To(Succeed())
+		cancel()
+		Eventually(done).Should(Receive(BeNil()))
+
+		mu.Lock()
+		defer mu.Unlock()
+		Expect(checkpoints).To(HaveLen(2))
+		Expect(estimate(checkpoints[1]["home"])).To(Equal(int64(2)))
+	})
+})
@@ -0,0 +1,22 @@
+module github.com/gowthamkommineni/zetasketch/hllserver/hllgrpc
+
+go 1.24.0
+
+require (
+	github.com/bsm/ginkgo v1.16.4
+	github.com/bsm/gomega v1.16.0
+	github.com/gowthamkommineni/zetasketch v0.0.0
+	google.golang.org/grpc v1.79.0
+	google.golang.org/protobuf v1.36.11
+)
+
+require (
+	github.com/cespare/xxhash/v2 v2.3.0 // indirect
+	github.com/spaolacci/murmur3 v1.1.0 // indirect
+	golang.org/x/net v0.48.0 // indirect
+	golang.org/x/sys v0.39.0 // indirect
+	golang.org/x/text v0.32.0 // indirect
+	google.golang.org/genproto/googleapis/rpc v0.0.0-20251202230838-ff82c1b0f217 // indirect
+)
+
+replace github.com/gowthamkommineni/zetasketch => ../..
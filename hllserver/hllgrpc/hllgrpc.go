@@ -0,0 +1,106 @@
+// Package hllgrpc serves a hllserver.Service over gRPC. The service is defined in
+// hllserver.proto, the Go code in hllserver.pb.go and hllserver_grpc.pb.go is generated
+// from it, see the hllgrpc target of the Makefile. It is a separate module, so that the
+// zetasketch module does not depend on google.golang.org/grpc.
+//
+//	s, err := hllserver.New(14, 25)
+//	...
+//	srv := grpc.NewServer()
+//	hllgrpc.RegisterHLLServiceServer(srv, hllgrpc.NewServer(s))
+//	err = srv.Serve(lis)
+package hllgrpc
+
+import (
+	"context"
+
+	"github.com/gowthamkommineni/zetasketch/hllserver"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+)
+
+// Server adapts a hllserver.Service to HLLServiceServer. Invalid requests, e.g. missing
+// counter names, invalid or incompatible sketches, fail with INVALID_ARGUMENT.
+type Server struct {
+	UnimplementedHLLServiceServer
+
+	s *hllserver.Service
+}
+
+var _ HLLServiceServer = (*Server)(nil)
+
+// NewServer inits a new server for s.
+func NewServer(s *hllserver.Service) *Server {
+	return &Server{s: s}
+}
+
+// AddValues implements HLLServiceServer.
+func (srv *Server) AddValues(_ context.Context, req *AddValuesRequest) (*EstimateResponse, error) {
+	v := req.GetValues()
+	err := srv.s.AddValues(req.GetCounter(), hllserver.Values{
+		Strings:  v.GetStrings(),
+		Bytes:    v.GetBytes(),
+		Int64s:   v.GetInt64S(),
+		Uint64s:  v.GetUint64S(),
+		Float64s: v.GetFloat64S(),
+	})
+	if err != nil {
+		return nil, status.Error(codes.InvalidArgument, err.Error())
+	}
+	return srv.estimate(req.GetCounter()), nil
+}
+
+// MergeSketch implements HLLServiceServer.
+func (srv *Server) MergeSketch(_ context.Context, req *MergeSketchRequest) (*EstimateResponse, error) {
+	if err := srv.s.MergeSketch(req.GetCounter(), req.GetSketch()); err != nil {
+		return nil, status.Error(codes.InvalidArgument, err.Error())
+	}
+	return srv.estimate(req.GetCounter()), nil
+}
+
+// GetEstimate implements HLLServiceServer.
+func (srv *Server) GetEstimate(_ context.Context, req *GetEstimateRequest) (*EstimateResponse, error) {
+	return srv.estimate(req.GetCounter()), nil
+}
+
+// ExportProto implements HLLServiceServer.
+func (srv *Server) ExportProto(_ context.Context, req *ExportProtoRequest) (*ExportProtoResponse, error) {
+	data, err := srv.s.ExportProto(req.GetCounter())
+	if err != nil {
+		return nil, status.Error(codes.Internal, err.Error())
+	}
+	if data == nil {
+		return nil, status.Errorf(codes.NotFound, "unknown counter %q", req.GetCounter())
+	}
+	return &ExportProtoResponse{Counter: req.GetCounter(), Sketch: data}, nil
+}
+
+// EstimateUnion implements HLLServiceServer.
+func (srv *Server) EstimateUnion(_ context.Context, req *EstimateUnionRequest) (*EstimateUnionResponse, error) {
+	n, err := srv.s.EstimateUnion(req.GetCounters()...)
+	if err != nil {
+		return nil, status.Error(codes.InvalidArgument, err.Error())
+	}
+	return &EstimateUnionResponse{Estimate: n}, nil
+}
+
+// MergeCounters implements HLLServiceServer.
+func (srv *Server) MergeCounters(_ context.Context, req *MergeCountersRequest) (*EstimateResponse, error) {
+	if err := srv.s.MergeCounters(req.GetDest(), req.GetSources()...); err != nil {
+		return nil, status.Error(codes.InvalidArgument, err.Error())
+	}
+	return srv.estimate(req.GetDest()), nil
+}
+
+// ListCounters implements HLLServiceServer.
+func (srv *Server) ListCounters(_ context.Context, _ *ListCountersRequest) (*ListCountersResponse, error) {
+	return &ListCountersResponse{Counters: srv.s.Counters()}, nil
+}
+
+// DeleteCounter implements HLLServiceServer.
+func (srv *Server) DeleteCounter(_ context.Context, req *DeleteCounterRequest) (*DeleteCounterResponse, error) {
+	return &DeleteCounterResponse{Deleted: srv.s.Delete(req.GetCounter())}, nil
+}
+
+func (srv *Server) estimate(counter string) *EstimateResponse {
+	return &EstimateResponse{Counter: counter, Estimate: srv.s.GetEstimate(counter)}
+}
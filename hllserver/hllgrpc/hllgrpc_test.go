@@ -0,0 +1,156 @@
+package hllgrpc_test
+
+import (
+	"context"
+	"net"
+	"testing"
+
+	"github.com/gowthamkommineni/zetasketch/hllplus"
+	"github.com/gowthamkommineni/zetasketch/hllserver"
+	"github.com/gowthamkommineni/zetasketch/hllserver/hllgrpc"
+	pb "github.com/gowthamkommineni/zetasketch/internal/zetasketch"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/credentials/insecure"
+	"google.golang.org/grpc/status"
+	"google.golang.org/grpc/test/bufconn"
+	"google.golang.org/protobuf/proto"
+
+	. "github.com/bsm/ginkgo"
+	. "github.com/bsm/gomega"
+)
+
+var _ = Describe("Server", func() {
+	var client hllgrpc.HLLServiceClient
+	var srv *grpc.Server
+	var conn *grpc.ClientConn
+	ctx := context.Background()
+
+	BeforeEach(func() {
+		s, err := hllserver.New(14, 19)
+		Expect(err).NotTo(HaveOccurred())
+
+		lis := bufconn.Listen(1 << 20)
+		srv = grpc.NewServer()
+		hllgrpc.RegisterHLLServiceServer(srv, hllgrpc.NewServer(s))
+		go func() { _ = srv.Serve(lis) }()
+
+		conn, err = grpc.NewClient("passthrough:///bufnet",
+			grpc.WithContextDialer(func(ctx context.Context, _ string) (net.Conn, error) { return lis.DialContext(ctx) }),
+			grpc.WithTransportCredentials(insecure.NewCredentials()),
+		)
+		Expect(err).NotTo(HaveOccurred())
+		client = hllgrpc.NewHLLServiceClient(conn)
+	})
+
+	AfterEach(func() {
+		Expect(conn.Close()).To(Succeed())
+		srv.Stop()
+	})
+
+	It("should add values", func() {
+		res, err := client.AddValues(ctx, &hllgrpc.AddValuesRequest{
+			Counter: "letters",
+			Values:  &hllgrpc.Values{Strings: []string{"a", "b", "a"}, Bytes: [][]byte{[]byte("c")}},
+		})
+		Expect(err).NotTo(HaveOccurred())
+		Expect(res.Counter).To(Equal("letters"))
+		Expect(res.Estimate).To(Equal(int64(3)))
+
+		res, err = client.AddValues(ctx, &hllgrpc.AddValuesRequest{
+			Counter: "numbers",
+			Values:  &hllgrpc.Values{Int64S: []int64{1, 2, 3, 3}},
+		})
+		Expect(err).NotTo(HaveOccurred())
+		Expect(res.Estimate).To(Equal(int64(3)))
+
+		res, err = client.GetEstimate(ctx, &hllgrpc.GetEstimateRequest{Counter: "unknown"})
+		Expect(err).NotTo(HaveOccurred())
+		Expect(res.Estimate).To(BeZero())
+	})
+
+	It("should merge and export sketches", func() {
+		sketch, err := hllplus.New(14, 19)
+		Expect(err).NotTo(HaveOccurred())
+		sketch.AddString("a")
+		sketch.AddString("b")
+		data, err := sketch.Marshal()
+		Expect(err).NotTo(HaveOccurred())
+
+		res, err := client.MergeSketch(ctx, &hllgrpc.MergeSketchRequest{Counter: "letters", Sketch: data})
+		Expect(err).NotTo(HaveOccurred())
+		Expect(res.Estimate).To(Equal(int64(2)))
+
+		exported, err := client.ExportProto(ctx, &hllgrpc.ExportProtoRequest{Counter: "letters"})
+		Expect(err).NotTo(HaveOccurred())
+		Expect(exported.Sketch).To(Equal(data))
+
+		_, err = client.ExportProto(ctx, &hllgrpc.ExportProtoRequest{Counter: "unknown"})
+		Expect(status.Code(err)).To(Equal(codes.NotFound))
+	})
+
+	It("should merge, list and delete counters", func() {
+		_, err := client.AddValues(ctx, &hllgrpc.AddValuesRequest{Counter: "a", Values: &hllgrpc.Values{Strings: []string{"x", "y"}}})
+		Expect(err).NotTo(HaveOccurred())
+		_, err = client.AddValues(ctx, &hllgrpc.AddValuesRequest{Counter: "b", Values: &hllgrpc.Values{Strings: []string{"y", "z"}}})
+		Expect(err).NotTo(HaveOccurred())
+
+		union, err := client.EstimateUnion(ctx, &hllgrpc.EstimateUnionRequest{Counters: []string{"a", "b"}})
+		Expect(err).NotTo(HaveOccurred())
+		Expect(union.Estimate).To(Equal(int64(3)))
+
+		merged, err := client.MergeCounters(ctx, &hllgrpc.MergeCountersRequest{Dest: "c", Sources: []string{"a", "b"}})
+		Expect(err).NotTo(HaveOccurred())
+		Expect(merged.Estimate).To(Equal(int64(3)))
+
+		list, err := client.ListCounters(ctx, &hllgrpc.ListCountersRequest{})
+		Expect(err).NotTo(HaveOccurred())
+		Expect(list.Counters).To(Equal([]string{"a", "b", "c"}))
+
+		deleted, err := client.DeleteCounter(ctx, &hllgrpc.DeleteCounterRequest{Counter: "a"})
+		Expect(err).NotTo(HaveOccurred())
+		Expect(deleted.Deleted).To(BeTrue())
+		deleted, err = client.DeleteCounter(ctx, &hllgrpc.DeleteCounterRequest{Counter: "a"})
+		Expect(err).NotTo(HaveOccurred())
+		Expect(deleted.Deleted).To(BeFalse())
+	})
+
+	It("should reject invalid requests", func() {
+		_, err := client.AddValues(ctx, &hllgrpc.AddValuesRequest{Values: &hllgrpc.Values{Strings: []string{"a"}}})
+		Expect(status.Code(err)).To(Equal(codes.InvalidArgument))
+		Expect(status.Convert(err).Message()).To(Equal("invalid counter: name is required"))
+
+		_, err = client.MergeSketch(ctx, &hllgrpc.MergeSketchRequest{Counter: "a", Sketch: []byte("bad")})
+		Expect(status.Code(err)).To(Equal(codes.InvalidArgument))
+		Expect(status.Convert(err).Message()).To(HavePrefix("invalid sketch: "))
+
+		_, err = client.MergeCounters(ctx, &hllgrpc.MergeCountersRequest{Sources: []string{"a"}})
+		Expect(status.Code(err)).To(Equal(codes.InvalidArgument))
+	})
+
+	It("should reject malformed sketches", func() {
+		sketch, _ := hllplus.New(14, 19)
+		data, _ := sketch.Marshal()
+		msg := new(pb.AggregatorStateProto)
+		Expect(proto.Unmarshal(data, msg)).To(Succeed())
+		state := proto.GetExtension(msg, pb.E_HyperloglogplusUniqueState).(*pb.HyperLogLogPlusUniqueStateProto)
+		state.Data, state.SparseData = make([]byte, 1<<14+1), nil
+		proto.SetExtension(msg, pb.E_HyperloglogplusUniqueState, state)
+		data, err := proto.Marshal(msg)
+		Expect(err).NotTo(HaveOccurred())
+
+		_, err = client.MergeSketch(ctx, &hllgrpc.MergeSketchRequest{Counter: "a", Sketch: data})
+		Expect(status.Code(err)).To(Equal(codes.InvalidArgument))
+		Expect(status.Convert(err).Message()).To(Equal("invalid sketch: invalid normal data: 16385 registers, expected 16384"))
+
+		// the server is still serving
+		res, err := client.AddValues(ctx, &hllgrpc.AddValuesRequest{Counter: "a", Values: &hllgrpc.Values{Strings: []string{"x"}}})
+		Expect(err).NotTo(HaveOccurred())
+		Expect(res.Estimate).To(Equal(int64(1)))
+	})
+})
+
+func TestSuite(t *testing.T) {
+	RegisterFailHandler(Fail)
+	RunSpecs(t, "zetasketch/hllserver/hllgrpc")
+}
@@ -0,0 +1,833 @@
+// Code generated by protoc-gen-go. DO NOT EDIT.
+// versions:
+// 	protoc-gen-go v1.36.11
+// 	protoc        (unknown)
+// source: hllserver.proto
+
+package hllgrpc
+
+import (
+	protoreflect "google.golang.org/protobuf/reflect/protoreflect"
+	protoimpl "google.golang.org/protobuf/runtime/protoimpl"
+	reflect "reflect"
+	sync "sync"
+	unsafe "unsafe"
+)
+
+const (
+	// Verify that this generated code is sufficiently up-to-date.
+	_ = protoimpl.EnforceVersion(20 - protoimpl.MinVersion)
+	// Verify that runtime/protoimpl is sufficiently up-to-date.
+	_ = protoimpl.EnforceVersion(protoimpl.MaxVersion - 20)
+)
+
+// Values contains values of different types, see hllserver.Values.
+type Values struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	Strings       []string               `protobuf:"bytes,1,rep,name=strings,proto3" json:"strings,omitempty"`
+	Bytes         [][]byte               `protobuf:"bytes,2,rep,name=bytes,proto3" json:"bytes,omitempty"`
+	Int64S        []int64                `protobuf:"varint,3,rep,packed,name=int64s,proto3" json:"int64s,omitempty"`
+	Uint64S       []uint64               `protobuf:"varint,4,rep,packed,name=uint64s,proto3" json:"uint64s,omitempty"`
+	Float64S      []float64              `protobuf:"fixed64,5,rep,packed,name=float64s,proto3" json:"float64s,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *Values) Reset() {
+	*x = Values{}
+	mi := &file_hllserver_proto_msgTypes[0]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *Values) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*Values) ProtoMessage() {}
+
+func (x *Values) ProtoReflect() protoreflect.Message {
+	mi := &file_hllserver_proto_msgTypes[0]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use Values.ProtoReflect.Descriptor instead.
+func (*Values) Descriptor() ([]byte, []int) {
+	return file_hllserver_proto_rawDescGZIP(), []int{0}
+}
+
+func (x *Values) GetStrings() []string {
+	if x != nil {
+		return x.Strings
+	}
+	return nil
+}
+
+func (x *Values) GetBytes() [][]byte {
+	if x != nil {
+		return x.Bytes
+	}
+	return nil
+}
+
+func (x *Values) GetInt64S() []int64 {
+	if x != nil {
+		return x.Int64S
+	}
+	return nil
+}
+
+func (x *Values) GetUint64S() []uint64 {
+	if x != nil {
+		return x.Uint64S
+	}
+	return nil
+}
+
+func (x *Values) GetFloat64S() []float64 {
+	if x != nil {
+		return x.Float64S
+	}
+	return nil
+}
+
+type AddValuesRequest struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	Counter       string                 `protobuf:"bytes,1,opt,name=counter,proto3" json:"counter,omitempty"`
+	Values        *Values                `protobuf:"bytes,2,opt,name=values,proto3" json:"values,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *AddValuesRequest) Reset() {
+	*x = AddValuesRequest{}
+	mi := &file_hllserver_proto_msgTypes[1]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *AddValuesRequest) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*AddValuesRequest) ProtoMessage() {}
+
+func (x *AddValuesRequest) ProtoReflect() protoreflect.Message {
+	mi := &file_hllserver_proto_msgTypes[1]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use AddValuesRequest.ProtoReflect.Descriptor instead.
+func (*AddValuesRequest) Descriptor() ([]byte, []int) {
+	return file_hllserver_proto_rawDescGZIP(), []int{1}
+}
+
+func (x *AddValuesRequest) GetCounter() string {
+	if x != nil {
+		return x.Counter
+	}
+	return ""
+}
+
+func (x *AddValuesRequest) GetValues() *Values {
+	if x != nil {
+		return x.Values
+	}
+	return nil
+}
+
+type MergeSketchRequest struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	Counter       string                 `protobuf:"bytes,1,opt,name=counter,proto3" json:"counter,omitempty"`
+	Sketch        []byte                 `protobuf:"bytes,2,opt,name=sketch,proto3" json:"sketch,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *MergeSketchRequest) Reset() {
+	*x = MergeSketchRequest{}
+	mi := &file_hllserver_proto_msgTypes[2]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *MergeSketchRequest) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*MergeSketchRequest) ProtoMessage() {}
+
+func (x *MergeSketchRequest) ProtoReflect() protoreflect.Message {
+	mi := &file_hllserver_proto_msgTypes[2]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use MergeSketchRequest.ProtoReflect.Descriptor instead.
+func (*MergeSketchRequest) Descriptor() ([]byte, []int) {
+	return file_hllserver_proto_rawDescGZIP(), []int{2}
+}
+
+func (x *MergeSketchRequest) GetCounter() string {
+	if x != nil {
+		return x.Counter
+	}
+	return ""
+}
+
+func (x *MergeSketchRequest) GetSketch() []byte {
+	if x != nil {
+		return x.Sketch
+	}
+	return nil
+}
+
+type GetEstimateRequest struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	Counter       string                 `protobuf:"bytes,1,opt,name=counter,proto3" json:"counter,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *GetEstimateRequest) Reset() {
+	*x = GetEstimateRequest{}
+	mi := &file_hllserver_proto_msgTypes[3]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *GetEstimateRequest) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*GetEstimateRequest) ProtoMessage() {}
+
+func (x *GetEstimateRequest) ProtoReflect() protoreflect.Message {
+	mi := &file_hllserver_proto_msgTypes[3]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use GetEstimateRequest.ProtoReflect.Descriptor instead.
+func (*GetEstimateRequest) Descriptor() ([]byte, []int) {
+	return file_hllserver_proto_rawDescGZIP(), []int{3}
+}
+
+func (x *GetEstimateRequest) GetCounter() string {
+	if x != nil {
+		return x.Counter
+	}
+	return ""
+}
+
+type EstimateResponse struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	Counter       string                 `protobuf:"bytes,1,opt,name=counter,proto3" json:"counter,omitempty"`
+	Estimate      int64                  `protobuf:"varint,2,opt,name=estimate,proto3" json:"estimate,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *EstimateResponse) Reset() {
+	*x = EstimateResponse{}
+	mi := &file_hllserver_proto_msgTypes[4]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *EstimateResponse) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*EstimateResponse) ProtoMessage() {}
+
+func (x *EstimateResponse) ProtoReflect() protoreflect.Message {
+	mi := &file_hllserver_proto_msgTypes[4]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use EstimateResponse.ProtoReflect.Descriptor instead.
+func (*EstimateResponse) Descriptor() ([]byte, []int) {
+	return file_hllserver_proto_rawDescGZIP(), []int{4}
+}
+
+func (x *EstimateResponse) GetCounter() string {
+	if x != nil {
+		return x.Counter
+	}
+	return ""
+}
+
+func (x *EstimateResponse) GetEstimate() int64 {
+	if x != nil {
+		return x.Estimate
+	}
+	return 0
+}
+
+type ExportProtoRequest struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	Counter       string                 `protobuf:"bytes,1,opt,name=counter,proto3" json:"counter,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *ExportProtoRequest) Reset() {
+	*x = ExportProtoRequest{}
+	mi := &file_hllserver_proto_msgTypes[5]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *ExportProtoRequest) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*ExportProtoRequest) ProtoMessage() {}
+
+func (x *ExportProtoRequest) ProtoReflect() protoreflect.Message {
+	mi := &file_hllserver_proto_msgTypes[5]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use ExportProtoRequest.ProtoReflect.Descriptor instead.
+func (*ExportProtoRequest) Descriptor() ([]byte, []int) {
+	return file_hllserver_proto_rawDescGZIP(), []int{5}
+}
+
+func (x *ExportProtoRequest) GetCounter() string {
+	if x != nil {
+		return x.Counter
+	}
+	return ""
+}
+
+type ExportProtoResponse struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	Counter       string                 `protobuf:"bytes,1,opt,name=counter,proto3" json:"counter,omitempty"`
+	Sketch        []byte                 `protobuf:"bytes,2,opt,name=sketch,proto3" json:"sketch,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *ExportProtoResponse) Reset() {
+	*x = ExportProtoResponse{}
+	mi := &file_hllserver_proto_msgTypes[6]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *ExportProtoResponse) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*ExportProtoResponse) ProtoMessage() {}
+
+func (x *ExportProtoResponse) ProtoReflect() protoreflect.Message {
+	mi := &file_hllserver_proto_msgTypes[6]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use ExportProtoResponse.ProtoReflect.Descriptor instead.
+func (*ExportProtoResponse) Descriptor() ([]byte, []int) {
+	return file_hllserver_proto_rawDescGZIP(), []int{6}
+}
+
+func (x *ExportProtoResponse) GetCounter() string {
+	if x != nil {
+		return x.Counter
+	}
+	return ""
+}
+
+func (x *ExportProtoResponse) GetSketch() []byte {
+	if x != nil {
+		return x.Sketch
+	}
+	return nil
+}
+
+type EstimateUnionRequest struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	Counters      []string               `protobuf:"bytes,1,rep,name=counters,proto3" json:"counters,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *EstimateUnionRequest) Reset() {
+	*x = EstimateUnionRequest{}
+	mi := &file_hllserver_proto_msgTypes[7]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *EstimateUnionRequest) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*EstimateUnionRequest) ProtoMessage() {}
+
+func (x *EstimateUnionRequest) ProtoReflect() protoreflect.Message {
+	mi := &file_hllserver_proto_msgTypes[7]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use EstimateUnionRequest.ProtoReflect.Descriptor instead.
+func (*EstimateUnionRequest) Descriptor() ([]byte, []int) {
+	return file_hllserver_proto_rawDescGZIP(), []int{7}
+}
+
+func (x *EstimateUnionRequest) GetCounters() []string {
+	if x != nil {
+		return x.Counters
+	}
+	return nil
+}
+
+type EstimateUnionResponse struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	Estimate      int64                  `protobuf:"varint,1,opt,name=estimate,proto3" json:"estimate,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *EstimateUnionResponse) Reset() {
+	*x = EstimateUnionResponse{}
+	mi := &file_hllserver_proto_msgTypes[8]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *EstimateUnionResponse) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*EstimateUnionResponse) ProtoMessage() {}
+
+func (x *EstimateUnionResponse) ProtoReflect() protoreflect.Message {
+	mi := &file_hllserver_proto_msgTypes[8]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use EstimateUnionResponse.ProtoReflect.Descriptor instead.
+func (*EstimateUnionResponse) Descriptor() ([]byte, []int) {
+	return file_hllserver_proto_rawDescGZIP(), []int{8}
+}
+
+func (x *EstimateUnionResponse) GetEstimate() int64 {
+	if x != nil {
+		return x.Estimate
+	}
+	return 0
+}
+
+type MergeCountersRequest struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	Dest          string                 `protobuf:"bytes,1,opt,name=dest,proto3" json:"dest,omitempty"`
+	Sources       []string               `protobuf:"bytes,2,rep,name=sources,proto3" json:"sources,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *MergeCountersRequest) Reset() {
+	*x = MergeCountersRequest{}
+	mi := &file_hllserver_proto_msgTypes[9]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *MergeCountersRequest) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*MergeCountersRequest) ProtoMessage() {}
+
+func (x *MergeCountersRequest) ProtoReflect() protoreflect.Message {
+	mi := &file_hllserver_proto_msgTypes[9]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use MergeCountersRequest.ProtoReflect.Descriptor instead.
+func (*MergeCountersRequest) Descriptor() ([]byte, []int) {
+	return file_hllserver_proto_rawDescGZIP(), []int{9}
+}
+
+func (x *MergeCountersRequest) GetDest() string {
+	if x != nil {
+		return x.Dest
+	}
+	return ""
+}
+
+func (x *MergeCountersRequest) GetSources() []string {
+	if x != nil {
+		return x.Sources
+	}
+	return nil
+}
+
+type ListCountersRequest struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *ListCountersRequest) Reset() {
+	*x = ListCountersRequest{}
+	mi := &file_hllserver_proto_msgTypes[10]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *ListCountersRequest) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*ListCountersRequest) ProtoMessage() {}
+
+func (x *ListCountersRequest) ProtoReflect() protoreflect.Message {
+	mi := &file_hllserver_proto_msgTypes[10]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use ListCountersRequest.ProtoReflect.Descriptor instead.
+func (*ListCountersRequest) Descriptor() ([]byte, []int) {
+	return file_hllserver_proto_rawDescGZIP(), []int{10}
+}
+
+type ListCountersResponse struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	Counters      []string               `protobuf:"bytes,1,rep,name=counters,proto3" json:"counters,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *ListCountersResponse) Reset() {
+	*x = ListCountersResponse{}
+	mi := &file_hllserver_proto_msgTypes[11]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *ListCountersResponse) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*ListCountersResponse) ProtoMessage() {}
+
+func (x *ListCountersResponse) ProtoReflect() protoreflect.Message {
+	mi := &file_hllserver_proto_msgTypes[11]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use ListCountersResponse.ProtoReflect.Descriptor instead.
+func (*ListCountersResponse) Descriptor() ([]byte, []int) {
+	return file_hllserver_proto_rawDescGZIP(), []int{11}
+}
+
+func (x *ListCountersResponse) GetCounters() []string {
+	if x != nil {
+		return x.Counters
+	}
+	return nil
+}
+
+type DeleteCounterRequest struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	Counter       string                 `protobuf:"bytes,1,opt,name=counter,proto3" json:"counter,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *DeleteCounterRequest) Reset() {
+	*x = DeleteCounterRequest{}
+	mi := &file_hllserver_proto_msgTypes[12]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *DeleteCounterRequest) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*DeleteCounterRequest) ProtoMessage() {}
+
+func (x *DeleteCounterRequest) ProtoReflect() protoreflect.Message {
+	mi := &file_hllserver_proto_msgTypes[12]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use DeleteCounterRequest.ProtoReflect.Descriptor instead.
+func (*DeleteCounterRequest) Descriptor() ([]byte, []int) {
+	return file_hllserver_proto_rawDescGZIP(), []int{12}
+}
+
+func (x *DeleteCounterRequest) GetCounter() string {
+	if x != nil {
+		return x.Counter
+	}
+	return ""
+}
+
+type DeleteCounterResponse struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	Deleted       bool                   `protobuf:"varint,1,opt,name=deleted,proto3" json:"deleted,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *DeleteCounterResponse) Reset() {
+	*x = DeleteCounterResponse{}
+	mi := &file_hllserver_proto_msgTypes[13]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *DeleteCounterResponse) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*DeleteCounterResponse) ProtoMessage() {}
+
+func (x *DeleteCounterResponse) ProtoReflect() protoreflect.Message {
+	mi := &file_hllserver_proto_msgTypes[13]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use DeleteCounterResponse.ProtoReflect.Descriptor instead.
+func (*DeleteCounterResponse) Descriptor() ([]byte, []int) {
+	return file_hllserver_proto_rawDescGZIP(), []int{13}
+}
+
+func (x *DeleteCounterResponse) GetDeleted() bool {
+	if x != nil {
+		return x.Deleted
+	}
+	return false
+}
+
+var File_hllserver_proto protoreflect.FileDescriptor
+
+const file_hllserver_proto_rawDesc = "" +
+	"\n" +
+	"\x0fhllserver.proto\x12\x14zetasketch.hllserver\"\x86\x01\n" +
+	"\x06Values\x12\x18\n" +
+	"\astrings\x18\x01 \x03(\tR\astrings\x12\x14\n" +
+	"\x05bytes\x18\x02 \x03(\fR\x05bytes\x12\x16\n" +
+	"\x06int64s\x18\x03 \x03(\x03R\x06int64s\x12\x18\n" +
+	"\auint64s\x18\x04 \x03(\x04R\auint64s\x12\x1a\n" +
+	"\bfloat64s\x18\x05 \x03(\x01R\bfloat64s\"b\n" +
+	"\x10AddValuesRequest\x12\x18\n" +
+	"\acounter\x18\x01 \x01(\tR\acounter\x124\n" +
+	"\x06values\x18\x02 \x01(\v2\x1c.zetasketch.hllserver.ValuesR\x06values\"F\n" +
+	"\x12MergeSketchRequest\x12\x18\n" +
+	"\acounter\x18\x01 \x01(\tR\acounter\x12\x16\n" +
+	"\x06sketch\x18\x02 \x01(\fR\x06sketch\".\n" +
+	"\x12GetEstimateRequest\x12\x18\n" +
+	"\acounter\x18\x01 \x01(\tR\acounter\"H\n" +
+	"\x10EstimateResponse\x12\x18\n" +
+	"\acounter\x18\x01 \x01(\tR\acounter\x12\x1a\n" +
+	"\bestimate\x18\x02 \x01(\x03R\bestimate\".\n" +
+	"\x12ExportProtoRequest\x12\x18\n" +
+	"\acounter\x18\x01 \x01(\tR\acounter\"G\n" +
+	"\x13ExportProtoResponse\x12\x18\n" +
+	"\acounter\x18\x01 \x01(\tR\acounter\x12\x16\n" +
+	"\x06sketch\x18\x02 \x01(\fR\x06sketch\"2\n" +
+	"\x14EstimateUnionRequest\x12\x1a\n" +
+	"\bcounters\x18\x01 \x03(\tR\bcounters\"3\n" +
+	"\x15EstimateUnionResponse\x12\x1a\n" +
+	"\bestimate\x18\x01 \x01(\x03R\bestimate\"D\n" +
+	"\x14MergeCountersRequest\x12\x12\n" +
+	"\x04dest\x18\x01 \x01(\tR\x04dest\x12\x18\n" +
+	"\asources\x18\x02 \x03(\tR\asources\"\x15\n" +
+	"\x13ListCountersRequest\"2\n" +
+	"\x14ListCountersResponse\x12\x1a\n" +
+	"\bcounters\x18\x01 \x03(\tR\bcounters\"0\n" +
+	"\x14DeleteCounterRequest\x12\x18\n" +
+	"\acounter\x18\x01 \x01(\tR\acounter\"1\n" +
+	"\x15DeleteCounterResponse\x12\x18\n" +
+	"\adeleted\x18\x01 \x01(\bR\adeleted2\xaf\x06\n" +
+	"\n" +
+	"HLLService\x12[\n" +
+	"\tAddValues\x12&.zetasketch.hllserver.AddValuesRequest\x1a&.zetasketch.hllserver.EstimateResponse\x12_\n" +
+	"\vMergeSketch\x12(.zetasketch.hllserver.MergeSketchRequest\x1a&.zetasketch.hllserver.EstimateResponse\x12_\n" +
+	"\vGetEstimate\x12(.zetasketch.hllserver.GetEstimateRequest\x1a&.zetasketch.hllserver.EstimateResponse\x12b\n" +
+	"\vExportProto\x12(.zetasketch.hllserver.ExportProtoRequest\x1a).zetasketch.hllserver.ExportProtoResponse\x12h\n" +
+	"\rEstimateUnion\x12*.zetasketch.hllserver.EstimateUnionRequest\x1a+.zetasketch.hllserver.EstimateUnionResponse\x12c\n" +
+	"\rMergeCounters\x12*.zetasketch.hllserver.MergeCountersRequest\x1a&.zetasketch.hllserver.EstimateResponse\x12e\n" +
+	"\fListCounters\x12).zetasketch.hllserver.ListCountersRequest\x1a*.zetasketch.hllserver.ListCountersResponse\x12h\n" +
+	"\rDeleteCounter\x12*.zetasketch.hllserver.DeleteCounterRequest\x1a+.zetasketch.hllserver.DeleteCounterResponseB:Z8github.com/gowthamkommineni/zetasketch/hllserver/hllgrpcb\x06proto3"
+
+var (
+	file_hllserver_proto_rawDescOnce sync.Once
+	file_hllserver_proto_rawDescData []byte
+)
+
+func file_hllserver_proto_rawDescGZIP() []byte {
+	file_hllserver_proto_rawDescOnce.Do(func() {
+		file_hllserver_proto_rawDescData = protoimpl.X.CompressGZIP(unsafe.Slice(unsafe.StringData(file_hllserver_proto_rawDesc), len(file_hllserver_proto_rawDesc)))
+	})
+	return file_hllserver_proto_rawDescData
+}
+
+var file_hllserver_proto_msgTypes = make([]protoimpl.MessageInfo, 14)
+var file_hllserver_proto_goTypes = []any{
+	(*Values)(nil),                // 0: zetasketch.hllserver.Values
+	(*AddValuesRequest)(nil),      // 1: zetasketch.hllserver.AddValuesRequest
+	(*MergeSketchRequest)(nil),    // 2: zetasketch.hllserver.MergeSketchRequest
+	(*GetEstimateRequest)(nil),    // 3: zetasketch.hllserver.GetEstimateRequest
+	(*EstimateResponse)(nil),      // 4: zetasketch.hllserver.EstimateResponse
+	(*ExportProtoRequest)(nil),    // 5: zetasketch.hllserver.ExportProtoRequest
+	(*ExportProtoResponse)(nil),   // 6: zetasketch.hllserver.ExportProtoResponse
+	(*EstimateUnionRequest)(nil),  // 7: zetasketch.hllserver.EstimateUnionRequest
+	(*EstimateUnionResponse)(nil), // 8: zetasketch.hllserver.EstimateUnionResponse
+	(*MergeCountersRequest)(nil),  // 9: zetasketch.hllserver.MergeCountersRequest
+	(*ListCountersRequest)(nil),   // 10: zetasketch.hllserver.ListCountersRequest
+	(*ListCountersResponse)(nil),  // 11: zetasketch.hllserver.ListCountersResponse
+	(*DeleteCounterRequest)(nil),  // 12: zetasketch.hllserver.DeleteCounterRequest
+	(*DeleteCounterResponse)(nil), // 13: zetasketch.hllserver.DeleteCounterResponse
+}
+var file_hllserver_proto_depIdxs = []int32{
+	0,  // 0: zetasketch.hllserver.AddValuesRequest.values:type_name -> zetasketch.hllserver.Values
+	1,  // 1: zetasketch.hllserver.HLLService.AddValues:input_type -> zetasketch.hllserver.AddValuesRequest
+	2,  // 2: zetasketch.hllserver.HLLService.MergeSketch:input_type -> zetasketch.hllserver.MergeSketchRequest
+	3,  // 3: zetasketch.hllserver.HLLService.GetEstimate:input_type -> zetasketch.hllserver.GetEstimateRequest
+	5,  // 4: zetasketch.hllserver.HLLService.ExportProto:input_type -> zetasketch.hllserver.ExportProtoRequest
+	7,  // 5: zetasketch.hllserver.HLLService.EstimateUnion:input_type -> zetasketch.hllserver.EstimateUnionRequest
+	9,  // 6: zetasketch.hllserver.HLLService.MergeCounters:input_type -> zetasketch.hllserver.MergeCountersRequest
+	10, // 7: zetasketch.hllserver.HLLService.ListCounters:input_type -> zetasketch.hllserver.ListCountersRequest
+	12, // 8: zetasketch.hllserver.HLLService.DeleteCounter:input_type -> zetasketch.hllserver.DeleteCounterRequest
+	4,  // 9: zetasketch.hllserver.HLLService.AddValues:output_type -> zetasketch.hllserver.EstimateResponse
+	4,  // 10: zetasketch.hllserver.HLLService.MergeSketch:output_type -> zetasketch.hllserver.EstimateResponse
+	4,  // 11: zetasketch.hllserver.HLLService.GetEstimate:output_type -> zetasketch.hllserver.EstimateResponse
+	6,  // 12: zetasketch.hllserver.HLLService.ExportProto:output_type -> zetasketch.hllserver.ExportProtoResponse
+	8,  // 13: zetasketch.hllserver.HLLService.EstimateUnion:output_type -> zetasketch.hllserver.EstimateUnionResponse
+	4,  // 14: zetasketch.hllserver.HLLService.MergeCounters:output_type -> zetasketch.hllserver.EstimateResponse
+	11, // 15: zetasketch.hllserver.HLLService.ListCounters:output_type -> zetasketch.hllserver.ListCountersResponse
+	13, // 16: zetasketch.hllserver.HLLService.DeleteCounter:output_type -> zetasketch.hllserver.DeleteCounterResponse
+	9,  // [9:17] is the sub-list for method output_type
+	1,  // [1:9] is the sub-list for method input_type
+	1,  // [1:1] is the sub-list for extension type_name
+	1,  // [1:1] is the sub-list for extension extendee
+	0,  // [0:1] is the sub-list for field type_name
+}
+
+func init() { file_hllserver_proto_init() }
+func file_hllserver_proto_init() {
+	if File_hllserver_proto != nil {
+		return
+	}
+	type x struct{}
+	out := protoimpl.TypeBuilder{
+		File: protoimpl.DescBuilder{
+			GoPackagePath: reflect.TypeOf(x{}).PkgPath(),
+			RawDescriptor: unsafe.Slice(unsafe.StringData(file_hllserver_proto_rawDesc), len(file_hllserver_proto_rawDesc)),
+			NumEnums:      0,
+			NumMessages:   14,
+			NumExtensions: 0,
+			NumServices:   1,
+		},
+		GoTypes:           file_hllserver_proto_goTypes,
+		DependencyIndexes: file_hllserver_proto_depIdxs,
+		MessageInfos:      file_hllserver_proto_msgTypes,
+	}.Build()
+	File_hllserver_proto = out.File
+	file_hllserver_proto_goTypes = nil
+	file_hllserver_proto_depIdxs = nil
+}
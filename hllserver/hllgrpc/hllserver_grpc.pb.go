@@ -0,0 +1,411 @@
+// Code generated by protoc-gen-go-grpc. DO NOT EDIT.
+// versions:
+// - protoc-gen-go-grpc v1.6.0
+// - protoc             (unknown)
+// source: hllserver.proto
+
+package hllgrpc
+
+import (
+	context "context"
+	grpc "google.golang.org/grpc"
+	codes "google.golang.org/grpc/codes"
+	status "google.golang.org/grpc/status"
+)
+
+// This is a compile-time assertion to ensure that this generated file
+// is compatible with the grpc package it is being compiled against.
+// Requires gRPC-Go v1.64.0 or later.
+const _ = grpc.SupportPackageIsVersion9
+
+const (
+	HLLService_AddValues_FullMethodName     = "/zetasketch.hllserver.HLLService/AddValues"
+	HLLService_MergeSketch_FullMethodName   = "/zetasketch.hllserver.HLLService/MergeSketch"
+	HLLService_GetEstimate_FullMethodName   = "/zetasketch.hllserver.HLLService/GetEstimate"
+	HLLService_ExportProto_FullMethodName   = "/zetasketch.hllserver.HLLService/ExportProto"
+	HLLService_EstimateUnion_FullMethodName = "/zetasketch.hllserver.HLLService/EstimateUnion"
+	HLLService_MergeCounters_FullMethodName = "/zetasketch.hllserver.HLLService/MergeCounters"
+	HLLService_ListCounters_FullMethodName  = "/zetasketch.hllserver.HLLService/ListCounters"
+	HLLService_DeleteCounter_FullMethodName = "/zetasketch.hllserver.HLLService/DeleteCounter"
+)
+
+// HLLServiceClient is the client API for HLLService service.
+//
+// For semantics around ctx use and closing/ending streaming RPCs, please refer to https://pkg.go.dev/google.golang.org/grpc/?tab=doc#ClientConn.NewStream.
+//
+// HLLService maintains named unique counters, see hllserver.Service. Sketches are
+// exchanged in the format of hllplus.HLL.Marshal.
+type HLLServiceClient interface {
+	// AddValues adds values to a counter, which is created on first use.
+	AddValues(ctx context.Context, in *AddValuesRequest, opts ...grpc.CallOption) (*EstimateResponse, error)
+	// MergeSketch merges a serialized sketch into a counter.
+	MergeSketch(ctx context.Context, in *MergeSketchRequest, opts ...grpc.CallOption) (*EstimateResponse, error)
+	// GetEstimate returns the estimate of a counter, 0 for unknown counters.
+	GetEstimate(ctx context.Context, in *GetEstimateRequest, opts ...grpc.CallOption) (*EstimateResponse, error)
+	// ExportProto returns the serialized sketch of a counter. It fails with NOT_FOUND
+	// for unknown counters.
+	ExportProto(ctx context.Context, in *ExportProtoRequest, opts ...grpc.CallOption) (*ExportProtoResponse, error)
+	// EstimateUnion returns the estimate of the union of counters.
+	EstimateUnion(ctx context.Context, in *EstimateUnionRequest, opts ...grpc.CallOption) (*EstimateUnionResponse, error)
+	// MergeCounters merges source counters into a destination counter.
+	MergeCounters(ctx context.Context, in *MergeCountersRequest, opts ...grpc.CallOption) (*EstimateResponse, error)
+	// ListCounters returns the names of all counters in sorted order.
+	ListCounters(ctx context.Context, in *ListCountersRequest, opts ...grpc.CallOption) (*ListCountersResponse, error)
+	// DeleteCounter removes a counter.
+	DeleteCounter(ctx context.Context, in *DeleteCounterRequest, opts ...grpc.CallOption) (*DeleteCounterResponse, error)
+}
+
+type hLLServiceClient struct {
+	cc grpc.ClientConnInterface
+}
+
+func NewHLLServiceClient(cc grpc.ClientConnInterface) HLLServiceClient {
+	return &hLLServiceClient{cc}
+}
+
+func (c *hLLServiceClient) AddValues(ctx context.Context, in *AddValuesRequest, opts ...grpc.CallOption) (*EstimateResponse, error) {
+	cOpts := append([]grpc.CallOption{grpc.StaticMethod()}, opts...)
+	out := new(EstimateResponse)
+	err := c.cc.Invoke(ctx, HLLService_AddValues_FullMethodName, in, out, cOpts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *hLLServiceClient) MergeSketch(ctx context.Context, in *MergeSketchRequest, opts ...grpc.CallOption) (*EstimateResponse, error) {
+	cOpts := append([]grpc.CallOption{grpc.StaticMethod()}, opts...)
+	out := new(EstimateResponse)
+	err := c.cc.Invoke(ctx, HLLService_MergeSketch_FullMethodName, in, out, cOpts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *hLLServiceClient) GetEstimate(ctx context.Context, in *GetEstimateRequest, opts ...grpc.CallOption) (*EstimateResponse, error) {
+	cOpts := append([]grpc.CallOption{grpc.StaticMethod()}, opts...)
+	out := new(EstimateResponse)
+	err := c.cc.Invoke(ctx, HLLService_GetEstimate_FullMethodName, in, out, cOpts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *hLLServiceClient) ExportProto(ctx context.Context, in *ExportProtoRequest, opts ...grpc.CallOption) (*ExportProtoResponse, error) {
+	cOpts := append([]grpc.CallOption{grpc.StaticMethod()}, opts...)
+	out := new(ExportProtoResponse)
+	err := c.cc.Invoke(ctx, HLLService_ExportProto_FullMethodName, in, out, cOpts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *hLLServiceClient) EstimateUnion(ctx context.Context, in *EstimateUnionRequest, opts ...grpc.CallOption) (*EstimateUnionResponse, error) {
+	cOpts := append([]grpc.CallOption{grpc.StaticMethod()}, opts...)
+	out := new(EstimateUnionResponse)
+	err := c.cc.Invoke(ctx, HLLService_EstimateUnion_FullMethodName, in, out, cOpts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *hLLServiceClient) MergeCounters(ctx context.Context, in *MergeCountersRequest, opts ...grpc.CallOption) (*EstimateResponse, error) {
+	cOpts := append([]grpc.CallOption{grpc.StaticMethod()}, opts...)
+	out := new(EstimateResponse)
+	err := c.cc.Invoke(ctx, HLLService_MergeCounters_FullMethodName, in, out, cOpts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *hLLServiceClient) ListCounters(ctx context.Context, in *ListCountersRequest, opts ...grpc.CallOption) (*ListCountersResponse, error) {
+	cOpts := append([]grpc.CallOption{grpc.StaticMethod()}, opts...)
+	out := new(ListCountersResponse)
+	err := c.cc.Invoke(ctx, HLLService_ListCounters_FullMethodName, in, out, cOpts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *hLLServiceClient) DeleteCounter(ctx context.Context, in *DeleteCounterRequest, opts ...grpc.CallOption) (*DeleteCounterResponse, error) {
+	cOpts := append([]grpc.CallOption{grpc.StaticMethod()}, opts...)
+	out := new(DeleteCounterResponse)
+	err := c.cc.Invoke(ctx, HLLService_DeleteCounter_FullMethodName, in, out, cOpts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+// HLLServiceServer is the server API for HLLService service.
+// All implementations must embed UnimplementedHLLServiceServer
+// for forward compatibility.
+//
+// HLLService maintains named unique counters, see hllserver.Service. Sketches are
+// exchanged in the format of hllplus.HLL.Marshal.
+type HLLServiceServer interface {
+	// AddValues adds values to a counter, which is created on first use.
+	AddValues(context.Context, *AddValuesRequest) (*EstimateResponse, error)
+	// MergeSketch merges a serialized sketch into a counter.
+	MergeSketch(context.Context, *MergeSketchRequest) (*EstimateResponse, error)
+	// GetEstimate returns the estimate of a counter, 0 for unknown counters.
+	GetEstimate(context.Context, *GetEstimateRequest) (*EstimateResponse, error)
+	// ExportProto returns the serialized sketch of a counter. It fails with NOT_FOUND
+	// for unknown counters.
+	ExportProto(context.Context, *ExportProtoRequest) (*ExportProtoResponse, error)
+	// EstimateUnion returns the estimate of the union of counters.
+	EstimateUnion(context.Context, *EstimateUnionRequest) (*EstimateUnionResponse, error)
+	// MergeCounters merges source counters into a destination counter.
+	MergeCounters(context.Context, *MergeCountersRequest) (*EstimateResponse, error)
+	// ListCounters returns the names of all counters in sorted order.
+	ListCounters(context.Context, *ListCountersRequest) (*ListCountersResponse, error)
+	// DeleteCounter removes a counter.
+	DeleteCounter(context.Context, *DeleteCounterRequest) (*DeleteCounterResponse, error)
+	mustEmbedUnimplementedHLLServiceServer()
+}
+
+// UnimplementedHLLServiceServer must be embedded to have
+// forward compatible implementations.
+//
+// NOTE: this should be embedded by value instead of pointer to avoid a nil
+// pointer dereference when methods are called.
+type UnimplementedHLLServiceServer struct{}
+
+func (UnimplementedHLLServiceServer) AddValues(context.Context, *AddValuesRequest) (*EstimateResponse, error) {
+	return nil, status.Error(codes.Unimplemented, "method AddValues not implemented")
+}
+func (UnimplementedHLLServiceServer) MergeSketch(context.Context, *MergeSketchRequest) (*EstimateResponse, error) {
+	return nil, status.Error(codes.Unimplemented, "method MergeSketch not implemented")
+}
+func (UnimplementedHLLServiceServer) GetEstimate(context.Context, *GetEstimateRequest) (*EstimateResponse, error) {
+	return nil, status.Error(codes.Unimplemented, "method GetEstimate not implemented")
+}
+func (UnimplementedHLLServiceServer) ExportProto(context.Context, *ExportProtoRequest) (*ExportProtoResponse, error) {
+	return nil, status.Error(codes.Unimplemented, "method ExportProto not implemented")
+}
+func (UnimplementedHLLServiceServer) EstimateUnion(context.Context, *EstimateUnionRequest) (*EstimateUnionResponse, error) {
+	return nil, status.Error(codes.Unimplemented, "method EstimateUnion not implemented")
+}
+func (UnimplementedHLLServiceServer) MergeCounters(context.Context, *MergeCountersRequest) (*EstimateResponse, error) {
+	return nil, status.Error(codes.Unimplemented, "method MergeCounters not implemented")
+}
+func (UnimplementedHLLServiceServer) ListCounters(context.Context, *ListCountersRequest) (*ListCountersResponse, error) {
+	return nil, status.Error(codes.Unimplemented, "method ListCounters not implemented")
+}
+func (UnimplementedHLLServiceServer) DeleteCounter(context.Context, *DeleteCounterRequest) (*DeleteCounterResponse, error) {
+	return nil, status.Error(codes.Unimplemented, "method DeleteCounter not implemented")
+}
+func (UnimplementedHLLServiceServer) mustEmbedUnimplementedHLLServiceServer() {}
+func (UnimplementedHLLServiceServer) testEmbeddedByValue()                    {}
+
+// UnsafeHLLServiceServer may be embedded to opt out of forward compatibility for this service.
+// Use of this interface is not recommended, as added methods to HLLServiceServer will
+// result in compilation errors.
+type UnsafeHLLServiceServer interface {
+	mustEmbedUnimplementedHLLServiceServer()
+}
+
+func RegisterHLLServiceServer(s grpc.ServiceRegistrar, srv HLLServiceServer) {
+	// If the following call panics, it indicates UnimplementedHLLServiceServer was
+	// embedded by pointer and is nil.  This will cause panics if an
+	// unimplemented method is ever invoked, so we test this at initialization
+	// time to prevent it from happening at runtime later due to I/O.
+	if t, ok := srv.(interface{ testEmbeddedByValue() }); ok {
+		t.testEmbeddedByValue()
+	}
+	s.RegisterService(&HLLService_ServiceDesc, srv)
+}
+
+func _HLLService_AddValues_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(AddValuesRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(HLLServiceServer).AddValues(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: HLLService_AddValues_FullMethodName,
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(HLLServiceServer).AddValues(ctx, req.(*AddValuesRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _HLLService_MergeSketch_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(MergeSketchRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(HLLServiceServer).MergeSketch(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: HLLService_MergeSketch_FullMethodName,
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(HLLServiceServer).MergeSketch(ctx, req.(*MergeSketchRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _HLLService_GetEstimate_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(GetEstimateRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(HLLServiceServer).GetEstimate(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: HLLService_GetEstimate_FullMethodName,
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(HLLServiceServer).GetEstimate(ctx, req.(*GetEstimateRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _HLLService_ExportProto_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(ExportProtoRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(HLLServiceServer).ExportProto(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: HLLService_ExportProto_FullMethodName,
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(HLLServiceServer).ExportProto(ctx, req.(*ExportProtoRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _HLLService_EstimateUnion_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(EstimateUnionRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(HLLServiceServer).EstimateUnion(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: HLLService_EstimateUnion_FullMethodName,
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(HLLServiceServer).EstimateUnion(ctx, req.(*EstimateUnionRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _HLLService_MergeCounters_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(MergeCountersRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(HLLServiceServer).MergeCounters(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: HLLService_MergeCounters_FullMethodName,
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(HLLServiceServer).MergeCounters(ctx, req.(*MergeCountersRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _HLLService_ListCounters_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(ListCountersRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(HLLServiceServer).ListCounters(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: HLLService_ListCounters_FullMethodName,
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(HLLServiceServer).ListCounters(ctx, req.(*ListCountersRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _HLLService_DeleteCounter_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(DeleteCounterRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(HLLServiceServer).DeleteCounter(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: HLLService_DeleteCounter_FullMethodName,
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(HLLServiceServer).DeleteCounter(ctx, req.(*DeleteCounterRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+// HLLService_ServiceDesc is the grpc.ServiceDesc for HLLService service.
+// It's only intended for direct use with grpc.RegisterService,
+// and not to be introspected or modified (even as a copy)
+var HLLService_ServiceDesc = grpc.ServiceDesc{
+	ServiceName: "zetasketch.hllserver.HLLService",
+	HandlerType: (*HLLServiceServer)(nil),
+	Methods: []grpc.MethodDesc{
+		{
+			MethodName: "AddValues",
+			Handler:    _HLLService_AddValues_Handler,
+		},
+		{
+			MethodName: "MergeSketch",
+			Handler:    _HLLService_MergeSketch_Handler,
+		},
+		{
+			MethodName: "GetEstimate",
+			Handler:    _HLLService_GetEstimate_Handler,
+		},
+		{
+			MethodName: "ExportProto",
+			Handler:    _HLLService_ExportProto_Handler,
+		},
+		{
+			MethodName: "EstimateUnion",
+			Handler:    _HLLService_EstimateUnion_Handler,
+		},
+		{
+			MethodName: "MergeCounters",
+			Handler:    _HLLService_MergeCounters_Handler,
+		},
+		{
+			MethodName: "ListCounters",
+			Handler:    _HLLService_ListCounters_Handler,
+		},
+		{
+			MethodName: "DeleteCounter",
+			Handler:    _HLLService_DeleteCounter_Handler,
+		},
+	},
+	Streams:  []grpc.StreamDesc{},
+	Metadata: "hllserver.proto",
+}
@@ -0,0 +1,181 @@
+// Package hllserver implements a unique-counting service: values and serialized sketches
+// are added to named counters, whose estimates and sketches can be read back at any
+// time. Counters are backed by a hllplus.GroupedHLL and created on first use.
+//
+// The Service is independent of the transport. Handler serves it over HTTP and
+// RESPServer over the Redis protocol. The hllserver/hllgrpc module serves it over gRPC;
+// it is a separate module, so that this one does not depend on google.golang.org/grpc.
+package hllserver
+
+import (
+	"fmt"
+
+	"github.com/gowthamkommineni/zetasketch/hllplus"
+)
+
+// Values contains values of different types to add to a counter. Strings and bytes hash
+// alike, see hllplus.HLL.AddString. A counter must only be fed values of a single type,
+// apart from strings and bytes.
+type Values struct {
+	Strings  []string  `json:"strings,omitempty"`
+	Bytes    [][]byte  `json:"bytes,omitempty"`
+	Int64s   []int64   `json:"int64s,omitempty"`
+	Uint64s  []uint64  `json:"uint64s,omitempty"`
+	Float64s []float64 `json:"float64s,omitempty"`
+}
+
+// Len returns the number of values.
+func (v *Values) Len() int {
+	return len(v.Strings) + len(v.Bytes) + len(v.Int64s) + len(v.Uint64s) + len(v.Float64s)
+}
+
+// Service maintains named counters and is safe for concurrent use.
+type Service struct {
+	g               *hllplus.GroupedHLL
+	precision       uint8
+	sparsePrecision uint8
+	opts            []hllplus.Option
+}
+
+// New inits a new service. Counters are created with the given precisions and options,
+// see hllplus.New. The options also apply to sketches passed to MergeSketch, which must
+// therefore be hashed alike.
+func New(precision, sparsePrecision uint8, opts ...hllplus.Option) (*Service, error) {
+	g, err := hllplus.NewGrouped(0, precision, sparsePrecision, opts...)
+	if err != nil {
+		return nil, err
+	}
+	return &Service{
+		g:               g,
+		precision:       precision,
+		sparsePrecision: sparsePrecision,
+		opts:            opts,
+	}, nil
+}
+
+// AddValues adds values to counter.
+func (s *Service) AddValues(counter string, v Values) error {
+	_, err := s.UpdateValues(counter, v)
+	return err
+}
+
+// UpdateValues adds values to counter like AddValues, and reports whether any register
+// of counter changed. Values are added at once, while the counter is locked, so that
+// concurrent updates of the same counter are reported consistently, see
+// hllplus.GroupedHLL.Update.
+func (s *Service) UpdateValues(counter string, v Values) (bool, error) {
+	if err := validateCounter(counter); err != nil {
+		return false, err
+	}
+	if v.Len() == 0 {
+		return false, nil
+	}
+
+	return s.g.Update(counter, func(h *hllplus.HLL) {
+		for _, x := range v.Strings {
+			h.AddString(x)
+		}
+		for _, x := range v.Bytes {
+			h.AddBytes(x)
+		}
+		for _, x := range v.Int64s {
+			h.AddInt64(x)
+		}
+		for _, x := range v.Uint64s {
+			h.AddUint64(x)
+		}
+		for _, x := range v.Float64s {
+			h.AddFloat64(x)
+		}
+	}), nil
+}
+
+// MergeSketch merges a sketch serialized by hllplus.HLL.Marshal, e.g. a pre-aggregated
+// sketch from BigQuery, into counter.
+func (s *Service) MergeSketch(counter string, sketch []byte) error {
+	if err := validateCounter(counter); err != nil {
+		return err
+	}
+
+	other := s.empty()
+	if err := other.Unmarshal(sketch); err != nil {
+		return fmt.Errorf("invalid sketch: %w", err)
+	}
+	return s.g.Merge(counter, other)
+}
+
+// GetEstimate returns the cardinality estimate of counter, or 0 if it does not exist.
+func (s *Service) GetEstimate(counter string) int64 {
+	return s.g.Estimate(counter)
+}
+
+// ExportProto returns the sketch of counter, serialized by hllplus.HLL.Marshal, or nil
+// if it does not exist.
+func (s *Service) ExportProto(counter string) ([]byte, error) {
+	sketch := s.g.Get(counter)
+	if sketch == nil {
+		return nil, nil
+	}
+	return sketch.Marshal()
+}
+
+// EstimateUnion returns the cardinality estimate of the union of counters. Unknown
+// counters are ignored.
+func (s *Service) EstimateUnion(counters ...string) (int64, error) {
+	if len(counters) == 1 {
+		return s.g.Estimate(counters[0]), nil
+	}
+
+	sketches := make([]*hllplus.HLL, len(counters))
+	for i, counter := range counters {
+		sketches[i] = s.g.Get(counter)
+	}
+	return hllplus.EstimateUnionAll(sketches...)
+}
+
+// MergeCounters merges the sketches of the source counters into dest, which is created
+// if it does not exist. Unknown sources are ignored.
+func (s *Service) MergeCounters(dest string, sources ...string) error {
+	if err := validateCounter(dest); err != nil {
+		return err
+	}
+
+	for _, src := range sources {
+		if sketch := s.g.Get(src); sketch != nil {
+			if err := s.g.Merge(dest, sketch); err != nil {
+				return err
+			}
+		}
+	}
+	if s.g.Get(dest) == nil {
+		return s.g.Merge(dest, s.empty())
+	}
+	return nil
+}
+
+// Counters returns the names of all counters in sorted order.
+func (s *Service) Counters() []string {
+	return s.g.Keys()
+}
+
+// Delete removes counter. It returns false if counter did not exist.
+func (s *Service) Delete(counter string) bool {
+	if s.g.Get(counter) == nil {
+		return false
+	}
+	s.g.Delete(counter)
+	return true
+}
+
+// empty returns an empty sketch with the configuration of the counters.
+func (s *Service) empty() *hllplus.HLL {
+	sketch, _ := hllplus.New(s.precision, s.sparsePrecision, s.opts...) // validated by New
+	return sketch
+}
+
+func validateCounter(counter string) error {
+	if counter == "" {
+		return fmt.Errorf("invalid counter: name is required")
+	}
+	return nil
+}
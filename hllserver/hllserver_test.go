@@ -0,0 +1,143 @@
+package hllserver_test
+
+import (
+	"fmt"
+	"sync"
+	"sync/atomic"
+	"testing"
+
+	"github.com/gowthamkommineni/zetasketch/hllplus"
+	"github.com/gowthamkommineni/zetasketch/hllserver"
+	pb "github.com/gowthamkommineni/zetasketch/internal/zetasketch"
+	"google.golang.org/protobuf/proto"
+
+	. "github.com/bsm/ginkgo"
+	. "github.com/bsm/gomega"
+)
+
+var _ = Describe("Service", func() {
+	var subject *hllserver.Service
+
+	BeforeEach(func() {
+		var err error
+		subject, err = hllserver.New(14, 19)
+		Expect(err).NotTo(HaveOccurred())
+	})
+
+	It("should validate", func() {
+		_, err := hllserver.New(30, 30)
+		Expect(err).To(MatchError("invalid normal precision 30"))
+
+		Expect(subject.AddValues("", hllserver.Values{Strings: []string{"a"}})).To(MatchError("invalid counter: name is required"))
+		Expect(subject.MergeSketch("", nil)).To(MatchError("invalid counter: name is required"))
+		Expect(subject.MergeSketch("a", []byte("bad"))).To(MatchError(HavePrefix("invalid sketch: ")))
+	})
+
+	It("should reject malformed sketches", func() {
+		Expect(subject.AddValues("a", hllserver.Values{Strings: []string{"x"}})).To(Succeed())
+		Expect(subject.MergeSketch("a", malformedSketch())).To(MatchError("invalid sketch: invalid normal data: 16385 registers, expected 16384"))
+
+		// the counter is still usable
+		Expect(subject.AddValues("a", hllserver.Values{Strings: []string{"y"}})).To(Succeed())
+		Expect(subject.GetEstimate("a")).To(Equal(int64(2)))
+	})
+
+	It("should report changes atomically", func() {
+		var wg sync.WaitGroup
+		var changed int32
+		for i := 0; i < 8; i++ {
+			wg.Add(1)
+			go func() {
+				defer wg.Done()
+				ok, err := subject.UpdateValues("a", hllserver.Values{Strings: []string{"x"}})
+				Expect(err).NotTo(HaveOccurred())
+				if ok {
+					atomic.AddInt32(&changed, 1)
+				}
+			}()
+		}
+		wg.Wait()
+		Expect(changed).To(Equal(int32(1)))
+
+		Expect(subject.UpdateValues("a", hllserver.Values{})).To(BeFalse())
+		Expect(subject.UpdateValues("a", hllserver.Values{Strings: []string{"x", "y"}})).To(BeTrue())
+		_, err := subject.UpdateValues("", hllserver.Values{})
+		Expect(err).To(MatchError("invalid counter: name is required"))
+	})
+
+	It("should add values", func() {
+		v := hllserver.Values{Strings: []string{"a", "b", "a"}, Bytes: [][]byte{[]byte("b"), []byte("c")}}
+		Expect(v.Len()).To(Equal(5))
+		Expect(subject.AddValues("letters", v)).To(Succeed())
+		Expect(subject.AddValues("numbers", hllserver.Values{Int64s: []int64{1, 2, 3, 3}})).To(Succeed())
+
+		Expect(subject.GetEstimate("letters")).To(Equal(int64(3)))
+		Expect(subject.GetEstimate("numbers")).To(Equal(int64(3)))
+		Expect(subject.GetEstimate("unknown")).To(BeZero())
+		Expect(subject.Counters()).To(Equal([]string{"letters", "numbers"}))
+
+		subject.Delete("letters")
+		Expect(subject.Counters()).To(Equal([]string{"numbers"}))
+	})
+
+	It("should merge and export sketches", func() {
+		other, _ := hllplus.New(12, 17)
+		for i := 0; i < 1_000; i++ {
+			other.AddString(fmt.Sprintf("u%d", i))
+		}
+		data, _ := other.Marshal()
+
+		Expect(subject.AddValues("users", hllserver.Values{Strings: []string{"u1", "x"}})).To(Succeed())
+		Expect(subject.MergeSketch("users", data)).To(Succeed())
+		Expect(subject.GetEstimate("users")).To(BeNumerically("~", 1_001, 10))
+
+		exported, err := subject.ExportProto("users")
+		Expect(err).NotTo(HaveOccurred())
+
+		restored := new(hllplus.HLL)
+		Expect(restored.Unmarshal(exported)).To(Succeed())
+		Expect(restored.Precision()).To(Equal(uint8(12)))
+		Expect(restored.NumValues()).To(Equal(int64(1_002)))
+		Expect(restored.Estimate()).To(Equal(subject.GetEstimate("users")))
+
+		Expect(subject.ExportProto("unknown")).To(BeNil())
+
+		ints, _ := hllplus.New(12, 17)
+		ints.AddInt64(1)
+		data, _ = ints.Marshal()
+		Expect(subject.MergeSketch("users", data)).To(MatchError("cannot merge sketches with different value types BYTES_OR_UTF8_STRING and INT64"))
+	})
+
+	It("should apply options to merged sketches", func() {
+		seeded, _ := hllserver.New(12, 17, hllplus.WithSeed(7))
+
+		other, _ := hllplus.New(12, 17, hllplus.WithSeed(7))
+		other.AddString("a")
+		data, _ := other.Marshal()
+		Expect(seeded.MergeSketch("a", data)).To(Succeed())
+		Expect(seeded.AddValues("a", hllserver.Values{Strings: []string{"a"}})).To(Succeed())
+		Expect(seeded.GetEstimate("a")).To(Equal(int64(1)))
+	})
+})
+
+// malformedSketch returns a serialized sketch with one register more than its precision
+// allows.
+func malformedSketch() []byte {
+	sketch, _ := hllplus.New(14, 19)
+	data, _ := sketch.Marshal()
+
+	msg := new(pb.AggregatorStateProto)
+	Expect(proto.Unmarshal(data, msg)).To(Succeed())
+	state := proto.GetExtension(msg, pb.E_HyperloglogplusUniqueState).(*pb.HyperLogLogPlusUniqueStateProto)
+	state.Data, state.SparseData = make([]byte, 1<<14+1), nil
+	proto.SetExtension(msg, pb.E_HyperloglogplusUniqueState, state)
+
+	data, err := proto.Marshal(msg)
+	Expect(err).NotTo(HaveOccurred())
+	return data
+}
+
+func TestSuite(t *testing.T) {
+	RegisterFailHandler(Fail)
+	RunSpecs(t, "zetasketch/hllserver")
+}
@@ -0,0 +1,177 @@
+package hllserver
+
+import (
+	"bufio"
+	"encoding/json"
+	"fmt"
+	"io"
+	"mime"
+	"net/http"
+	"strings"
+)
+
+// maxBodySize limits the size of request bodies.
+const maxBodySize = 32 << 20
+
+// Content types of serialized sketches.
+const (
+	contentTypeProto  = "application/x-protobuf"
+	contentTypeBinary = "application/octet-stream"
+)
+
+// Handler serves a Service over HTTP with JSON responses:
+//
+//	GET    /counters                list all counters
+//	GET    /counters/{name}         get the estimate of a counter
+//	POST   /counters/{name}/values  add values, as JSON Values or as text, one per line
+//	POST   /counters/{name}/sketch  merge a serialized sketch
+//	DELETE /counters/{name}         delete a counter
+//
+// Sketches are exchanged as raw bytes, in the format of hllplus.HLL.Marshal, with a
+// content type of application/x-protobuf or application/octet-stream. Getting a counter
+// with either type in the Accept header exports its sketch instead of the estimate.
+// Unknown counters have an estimate of 0, but no sketch. Use http.StripPrefix to mount
+// the handler under a different path.
+type Handler struct {
+	s *Service
+}
+
+// NewHandler inits a new handler for s.
+func NewHandler(s *Service) *Handler {
+	return &Handler{s: s}
+}
+
+// ServeHTTP implements http.Handler.
+func (h *Handler) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	path := strings.TrimSuffix(r.URL.Path, "/")
+	if path == "/counters" {
+		if r.Method != http.MethodGet {
+			writeError(w, http.StatusMethodNotAllowed, fmt.Errorf("method %s not allowed", r.Method))
+			return
+		}
+		writeJSON(w, http.StatusOK, map[string][]string{"counters": h.s.Counters()})
+		return
+	}
+
+	name := strings.TrimPrefix(path, "/counters/")
+	if name == path || name == "" {
+		writeError(w, http.StatusNotFound, fmt.Errorf("not found"))
+		return
+	}
+
+	switch r.Method {
+	case http.MethodGet:
+		h.get(w, r, name)
+	case http.MethodDelete:
+		h.s.Delete(name)
+		w.WriteHeader(http.StatusNoContent)
+	case http.MethodPost:
+		r.Body = http.MaxBytesReader(w, r.Body, maxBodySize)
+		switch {
+		case strings.HasSuffix(name, "/values"):
+			h.addValues(w, r, strings.TrimSuffix(name, "/values"))
+		case strings.HasSuffix(name, "/sketch"):
+			h.mergeSketch(w, r, strings.TrimSuffix(name, "/sketch"))
+		default:
+			writeError(w, http.StatusNotFound, fmt.Errorf("not found"))
+		}
+	default:
+		writeError(w, http.StatusMethodNotAllowed, fmt.Errorf("method %s not allowed", r.Method))
+	}
+}
+
+func (h *Handler) get(w http.ResponseWriter, r *http.Request, name string) {
+	if accept := r.Header.Get("Accept"); strings.Contains(accept, contentTypeProto) || strings.Contains(accept, contentTypeBinary) {
+		data, err := h.s.ExportProto(name)
+		if err != nil {
+			writeError(w, http.StatusInternalServerError, err)
+			return
+		}
+		if data == nil {
+			writeError(w, http.StatusNotFound, fmt.Errorf("unknown counter %q", name))
+			return
+		}
+
+		contentType := contentTypeProto
+		if !strings.Contains(accept, contentTypeProto) {
+			contentType = contentTypeBinary
+		}
+		w.Header().Set("Content-Type", contentType)
+		_, _ = w.Write(data)
+		return
+	}
+
+	writeJSON(w, http.StatusOK, estimateResponse{Counter: name, Estimate: h.s.GetEstimate(name)})
+}
+
+func (h *Handler) addValues(w http.ResponseWriter, r *http.Request, name string) {
+	var v Values
+	switch mediaType(r) {
+	case "application/json":
+		if err := json.NewDecoder(r.Body).Decode(&v); err != nil {
+			writeError(w, http.StatusBadRequest, fmt.Errorf("invalid values: %w", err))
+			return
+		}
+	case "text/plain":
+		scanner := bufio.NewScanner(r.Body)
+		for scanner.Scan() {
+			v.Strings = append(v.Strings, scanner.Text())
+		}
+		if err := scanner.Err(); err != nil {
+			writeError(w, http.StatusBadRequest, fmt.Errorf("invalid values: %w", err))
+			return
+		}
+	default:
+		writeError(w, http.StatusUnsupportedMediaType, fmt.Errorf("unsupported content type %q", r.Header.Get("Content-Type")))
+		return
+	}
+
+	if err := h.s.AddValues(name, v); err != nil {
+		writeError(w, http.StatusBadRequest, err)
+		return
+	}
+	writeJSON(w, http.StatusOK, estimateResponse{Counter: name, Estimate: h.s.GetEstimate(name)})
+}
+
+func (h *Handler) mergeSketch(w http.ResponseWriter, r *http.Request, name string) {
+	if t := mediaType(r); t != contentTypeProto && t != contentTypeBinary {
+		writeError(w, http.StatusUnsupportedMediaType, fmt.Errorf("unsupported content type %q", r.Header.Get("Content-Type")))
+		return
+	}
+
+	data, err := io.ReadAll(r.Body)
+	if err != nil {
+		writeError(w, http.StatusBadRequest, err)
+		return
+	}
+	if err := h.s.MergeSketch(name, data); err != nil {
+		writeError(w, http.StatusBadRequest, err)
+		return
+	}
+	writeJSON(w, http.StatusOK, estimateResponse{Counter: name, Estimate: h.s.GetEstimate(name)})
+}
+
+type estimateResponse struct {
+	Counter  string `json:"counter"`
+	Estimate int64  `json:"estimate"`
+}
+
+type errorResponse struct {
+	Error string `json:"error"`
+}
+
+// mediaType returns the media type of the request body, without parameters.
+func mediaType(r *http.Request) string {
+	t, _, _ := mime.ParseMediaType(r.Header.Get("Content-Type"))
+	return t
+}
+
+func writeJSON(w http.ResponseWriter, status int, v interface{}) {
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(status)
+	_ = json.NewEncoder(w).Encode(v)
+}
+
+func writeError(w http.ResponseWriter, status int, err error) {
+	writeJSON(w, status, errorResponse{Error: err.Error()})
+}
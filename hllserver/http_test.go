@@ -0,0 +1,137 @@
+package hllserver_test
+
+import (
+	"bytes"
+	"encoding/json"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+
+	"github.com/gowthamkommineni/zetasketch/hllplus"
+	"github.com/gowthamkommineni/zetasketch/hllserver"
+
+	. "github.com/bsm/ginkgo"
+	. "github.com/bsm/gomega"
+)
+
+var _ = Describe("Handler", func() {
+	var service *hllserver.Service
+	var subject *hllserver.Handler
+
+	do := func(method, path, contentType, accept string, body []byte) *httptest.ResponseRecorder {
+		r := httptest.NewRequest(method, path, bytes.NewReader(body))
+		if contentType != "" {
+			r.Header.Set("Content-Type", contentType)
+		}
+		if accept != "" {
+			r.Header.Set("Accept", accept)
+		}
+		w := httptest.NewRecorder()
+		subject.ServeHTTP(w, r)
+		return w
+	}
+
+	decode := func(w *httptest.ResponseRecorder) map[string]interface{} {
+		var v map[string]interface{}
+		Expect(json.Unmarshal(w.Body.Bytes(), &v)).To(Succeed())
+		return v
+	}
+
+	BeforeEach(func() {
+		service, _ = hllserver.New(14, 19)
+		subject = hllserver.NewHandler(service)
+	})
+
+	It("should add values", func() {
+		w := do("POST", "/counters/ips/values", "application/json", "", []byte(`{"strings":["a","b","a"]}`))
+		Expect(w.Code).To(Equal(http.StatusOK))
+		Expect(w.Header().Get("Content-Type")).To(Equal("application/json"))
+		Expect(decode(w)).To(Equal(map[string]interface{}{"counter": "ips", "estimate": 2.0}))
+
+		w = do("POST", "/counters/ips/values", "text/plain; charset=utf-8", "", []byte("c\nd\na\n"))
+		Expect(w.Code).To(Equal(http.StatusOK))
+		Expect(decode(w)).To(HaveKeyWithValue("estimate", 4.0))
+
+		w = do("GET", "/counters/ips", "", "", nil)
+		Expect(w.Code).To(Equal(http.StatusOK))
+		Expect(decode(w)).To(Equal(map[string]interface{}{"counter": "ips", "estimate": 4.0}))
+
+		w = do("GET", "/counters/", "", "", nil)
+		Expect(w.Code).To(Equal(http.StatusOK))
+		Expect(decode(w)).To(Equal(map[string]interface{}{"counters": []interface{}{"ips"}}))
+
+		w = do("DELETE", "/counters/ips", "", "", nil)
+		Expect(w.Code).To(Equal(http.StatusNoContent))
+		Expect(service.Counters()).To(BeEmpty())
+	})
+
+	It("should merge and export sketches", func() {
+		other, _ := hllplus.New(12, 17)
+		other.AddString("a")
+		other.AddString("b")
+		data, _ := other.Marshal()
+
+		w := do("POST", "/counters/ips/sketch", "application/x-protobuf", "", data)
+		Expect(w.Code).To(Equal(http.StatusOK))
+		Expect(decode(w)).To(HaveKeyWithValue("estimate", 2.0))
+
+		w = do("GET", "/counters/ips", "", "application/x-protobuf", nil)
+		Expect(w.Code).To(Equal(http.StatusOK))
+		Expect(w.Header().Get("Content-Type")).To(Equal("application/x-protobuf"))
+		Expect(w.Body.Bytes()).To(Equal(data))
+
+		w = do("GET", "/counters/ips", "", "application/octet-stream", nil)
+		Expect(w.Header().Get("Content-Type")).To(Equal("application/octet-stream"))
+		Expect(w.Body.Bytes()).To(Equal(data))
+
+		w = do("GET", "/counters/unknown", "", "application/x-protobuf", nil)
+		Expect(w.Code).To(Equal(http.StatusNotFound))
+		Expect(decode(w)).To(Equal(map[string]interface{}{"error": `unknown counter "unknown"`}))
+
+		w = do("GET", "/counters/unknown", "", "", nil)
+		Expect(w.Code).To(Equal(http.StatusOK))
+		Expect(decode(w)).To(HaveKeyWithValue("estimate", 0.0))
+	})
+
+	It("should reject invalid requests", func() {
+		w := do("POST", "/counters/ips/values", "application/json", "", []byte(`{`))
+		Expect(w.Code).To(Equal(http.StatusBadRequest))
+		Expect(decode(w)["error"]).To(HavePrefix("invalid values: "))
+
+		w = do("POST", "/counters/ips/values", "application/xml", "", nil)
+		Expect(w.Code).To(Equal(http.StatusUnsupportedMediaType))
+
+		w = do("POST", "/counters/ips/sketch", "application/json", "", nil)
+		Expect(w.Code).To(Equal(http.StatusUnsupportedMediaType))
+
+		w = do("POST", "/counters/ips/sketch", "application/octet-stream", "", []byte("bad"))
+		Expect(w.Code).To(Equal(http.StatusBadRequest))
+		Expect(decode(w)["error"]).To(HavePrefix("invalid sketch: "))
+
+		w = do("POST", "/counters/ips", "application/json", "", nil)
+		Expect(w.Code).To(Equal(http.StatusNotFound))
+
+		w = do("PUT", "/counters/ips", "", "", nil)
+		Expect(w.Code).To(Equal(http.StatusMethodNotAllowed))
+
+		w = do("POST", "/counters", "", "", nil)
+		Expect(w.Code).To(Equal(http.StatusMethodNotAllowed))
+
+		w = do("GET", "/other", "", "", nil)
+		Expect(w.Code).To(Equal(http.StatusNotFound))
+	})
+
+	It("should serve under a prefix", func() {
+		srv := httptest.NewServer(http.StripPrefix("/api", subject))
+		defer srv.Close()
+
+		resp, err := http.Post(srv.URL+"/api/counters/ips/values", "text/plain", strings.NewReader("a\nb"))
+		Expect(err).NotTo(HaveOccurred())
+		defer resp.Body.Close()
+
+		body, _ := io.ReadAll(resp.Body)
+		Expect(resp.StatusCode).To(Equal(http.StatusOK))
+		Expect(string(body)).To(Equal(`{"counter":"ips","estimate":2}` + "\n"))
+	})
+})
@@ -0,0 +1,240 @@
+package hllserver
+
+import (
+	"bufio"
+	"fmt"
+	"io"
+	"net"
+	"strconv"
+	"strings"
+)
+
+// Limits of commands, which are enforced before memory is allocated for them. Like
+// request bodies of the Handler, all bulk strings of a command take at most maxBodySize
+// bytes.
+const (
+	maxRESPArgs = 1 << 16  // arguments per command
+	maxRESPLine = 64 << 10 // bytes of an inline command or header line
+)
+
+// RESPServer serves a Service over the Redis protocol (RESP), so existing Redis clients
+// can use it as a unique-counting backend. It supports the commands:
+//
+//	PFADD key [element ...]             returns 1 if a register changed, else 0
+//	PFCOUNT key [key ...]               returns the estimate of the union of keys
+//	PFMERGE destkey [sourcekey ...]     merges sources into destkey
+//	DEL key [key ...]                   deletes keys, returns the number of deleted keys
+//	PING [message]
+//	QUIT
+//
+// Elements are added as strings. Unlike in Redis, the state of each key is a sketch in
+// the BigQuery-compatible format, which can be exported by Service.ExportProto.
+type RESPServer struct {
+	s *Service
+}
+
+// NewRESPServer inits a new server for s.
+func NewRESPServer(s *Service) *RESPServer {
+	return &RESPServer{s: s}
+}
+
+// Serve accepts connections on l and serves each in a new goroutine. It returns the
+// error of l.Accept, e.g. once l is closed.
+func (srv *RESPServer) Serve(l net.Listener) error {
+	for {
+		conn, err := l.Accept()
+		if err != nil {
+			return err
+		}
+		go srv.ServeConn(conn)
+	}
+}
+
+// ServeConn serves commands on conn until the client quits or disconnects, or sends an
+// invalid request. It closes conn.
+func (srv *RESPServer) ServeConn(conn io.ReadWriteCloser) {
+	defer conn.Close()
+
+	r := bufio.NewReader(conn)
+	w := bufio.NewWriter(conn)
+	for {
+		args, err := readRESPCommand(r)
+		if err != nil {
+			if err != io.EOF {
+				writeRESPError(w, err)
+				_ = w.Flush()
+			}
+			return
+		}
+
+		quit := srv.handle(w, args)
+		if r.Buffered() == 0 || quit {
+			if err := w.Flush(); err != nil || quit {
+				return
+			}
+		}
+	}
+}
+
+// handle executes a command and writes the reply. It returns true if the client quits.
+func (srv *RESPServer) handle(w *bufio.Writer, args []string) bool {
+	if len(args) == 0 {
+		return false
+	}
+
+	cmd, args := strings.ToUpper(args[0]), args[1:]
+	switch cmd {
+	case "PING":
+		if len(args) == 0 {
+			w.WriteString("+PONG\r\n")
+		} else {
+			writeRESPBulk(w, args[0])
+		}
+	case "QUIT":
+		w.WriteString("+OK\r\n")
+		return true
+	case "PFADD":
+		if len(args) == 0 {
+			writeRESPArity(w, cmd)
+			break
+		}
+		changed, err := srv.s.UpdateValues(args[0], Values{Strings: args[1:]})
+		if err != nil {
+			writeRESPError(w, err)
+			break
+		}
+		if changed {
+			writeRESPInt(w, 1)
+		} else {
+			writeRESPInt(w, 0)
+		}
+	case "PFCOUNT":
+		if len(args) == 0 {
+			writeRESPArity(w, cmd)
+			break
+		}
+		n, err := srv.s.EstimateUnion(args...)
+		if err != nil {
+			writeRESPError(w, err)
+			break
+		}
+		writeRESPInt(w, n)
+	case "PFMERGE":
+		if len(args) == 0 {
+			writeRESPArity(w, cmd)
+			break
+		}
+		if err := srv.s.MergeCounters(args[0], args[1:]...); err != nil {
+			writeRESPError(w, err)
+			break
+		}
+		w.WriteString("+OK\r\n")
+	case "DEL":
+		if len(args) == 0 {
+			writeRESPArity(w, cmd)
+			break
+		}
+		var n int64
+		for _, key := range args {
+			if srv.s.Delete(key) {
+				n++
+			}
+		}
+		writeRESPInt(w, n)
+	default:
+		writeRESPError(w, fmt.Errorf("unknown command '%s'", strings.ToLower(cmd)))
+	}
+	return false
+}
+
+// readRESPCommand reads a command, either as an array of bulk strings or inline.
+func readRESPCommand(r *bufio.Reader) ([]string, error) {
+	line, err := readRESPLine(r)
+	if err != nil {
+		return nil, err
+	}
+	if !strings.HasPrefix(line, "*") {
+		return strings.Fields(line), nil
+	}
+
+	n, err := strconv.Atoi(line[1:])
+	if err != nil || n < 0 || n > maxRESPArgs {
+		return nil, fmt.Errorf("Protocol error: invalid multibulk length")
+	}
+
+	// grow args as they arrive, rather than trusting n
+	var args []string
+	remaining := maxBodySize
+	for len(args) < n {
+		line, err := readRESPLine(r)
+		if err != nil {
+			return nil, err
+		}
+		if !strings.HasPrefix(line, "$") {
+			return nil, fmt.Errorf("Protocol error: expected '$', got '%.1s'", line)
+		}
+		size, err := strconv.Atoi(line[1:])
+		if err != nil || size < 0 || size > remaining {
+			return nil, fmt.Errorf("Protocol error: invalid bulk length")
+		}
+		remaining -= size
+
+		buf := make([]byte, size+2)
+		if _, err := io.ReadFull(r, buf); err != nil {
+			return nil, err
+		}
+		args = append(args, string(buf[:size]))
+	}
+	return args, nil
+}
+
+// readRESPLine reads a line of at most maxRESPLine bytes, terminated by CRLF, or LF for
+// inline commands.
+func readRESPLine(r *bufio.Reader) (string, error) {
+	var line []byte
+	for {
+		chunk, err := r.ReadSlice('\n')
+		if len(line)+len(chunk) > maxRESPLine {
+			return "", fmt.Errorf("Protocol error: too big inline request")
+		}
+		line = append(line, chunk...)
+
+		switch err {
+		case nil:
+			return strings.TrimRight(string(line), "\r\n"), nil
+		case bufio.ErrBufferFull:
+			continue
+		case io.EOF:
+			if len(line) != 0 {
+				err = io.ErrUnexpectedEOF
+			}
+		}
+		return "", err
+	}
+}
+
+func writeRESPInt(w *bufio.Writer, n int64) {
+	w.WriteByte(':')
+	w.WriteString(strconv.FormatInt(n, 10))
+	w.WriteString("\r\n")
+}
+
+func writeRESPBulk(w *bufio.Writer, s string) {
+	w.WriteByte('$')
+	w.WriteString(strconv.Itoa(len(s)))
+	w.WriteString("\r\n")
+	w.WriteString(s)
+	w.WriteString("\r\n")
+}
+
+func writeRESPError(w *bufio.Writer, err error) {
+	msg := strings.NewReplacer("\r", " ", "\n", " ").Replace(err.Error())
+	if !strings.HasPrefix(msg, "Protocol error") {
+		msg = "ERR " + msg
+	}
+	w.WriteString("-" + msg + "\r\n")
+}
+
+func writeRESPArity(w *bufio.Writer, cmd string) {
+	writeRESPError(w, fmt.Errorf("wrong number of arguments for '%s' command", strings.ToLower(cmd)))
+}
@@ -0,0 +1,137 @@
+package hllserver_test
+
+import (
+	"bufio"
+	"fmt"
+	"net"
+	"strings"
+
+	"github.com/gowthamkommineni/zetasketch/hllplus"
+	"github.com/gowthamkommineni/zetasketch/hllserver"
+
+	. "github.com/bsm/ginkgo"
+	. "github.com/bsm/gomega"
+)
+
+var _ = Describe("RESPServer", func() {
+	var service *hllserver.Service
+	var conn net.Conn
+	var r *bufio.Reader
+
+	// send writes a command as an array of bulk strings and returns the reply line
+	send := func(args ...string) string {
+		var b strings.Builder
+		fmt.Fprintf(&b, "*%d\r\n", len(args))
+		for _, arg := range args {
+			fmt.Fprintf(&b, "$%d\r\n%s\r\n", len(arg), arg)
+		}
+		_, err := conn.Write([]byte(b.String()))
+		Expect(err).NotTo(HaveOccurred())
+
+		line, err := r.ReadString('\n')
+		Expect(err).NotTo(HaveOccurred())
+		return strings.TrimSuffix(line, "\r\n")
+	}
+
+	BeforeEach(func() {
+		service, _ = hllserver.New(14, 19)
+
+		var server net.Conn
+		conn, server = net.Pipe()
+		go hllserver.NewRESPServer(service).ServeConn(server)
+		r = bufio.NewReader(conn)
+	})
+
+	AfterEach(func() {
+		conn.Close()
+	})
+
+	It("should count", func() {
+		Expect(send("PING")).To(Equal("+PONG"))
+		Expect(send("PFADD", "a", "x", "y", "z")).To(Equal(":1"))
+		Expect(send("PFADD", "a", "x")).To(Equal(":0"))
+		Expect(send("pfadd", "b", "z", "w")).To(Equal(":1"))
+		Expect(send("PFCOUNT", "a")).To(Equal(":3"))
+		Expect(send("PFCOUNT", "a", "b", "unknown")).To(Equal(":4"))
+		Expect(send("PFCOUNT", "unknown")).To(Equal(":0"))
+
+		Expect(send("PFMERGE", "c", "a", "b")).To(Equal("+OK"))
+		Expect(send("PFCOUNT", "c")).To(Equal(":4"))
+		Expect(send("PFMERGE", "empty")).To(Equal("+OK"))
+		Expect(service.Counters()).To(Equal([]string{"a", "b", "c", "empty"}))
+
+		Expect(send("DEL", "a", "unknown", "empty")).To(Equal(":2"))
+		Expect(service.Counters()).To(Equal([]string{"b", "c"}))
+
+		// state is exported in the BigQuery format
+		data, err := service.ExportProto("c")
+		Expect(err).NotTo(HaveOccurred())
+		s := new(hllplus.HLL)
+		Expect(s.Unmarshal(data)).To(Succeed())
+		Expect(s.Estimate()).To(Equal(int64(4)))
+	})
+
+	It("should reply with errors", func() {
+		Expect(send("PFADD")).To(Equal("-ERR wrong number of arguments for 'pfadd' command"))
+		Expect(send("PFCOUNT")).To(Equal("-ERR wrong number of arguments for 'pfcount' command"))
+		Expect(send("GET", "a")).To(Equal("-ERR unknown command 'get'"))
+		Expect(send("PFADD", "", "x")).To(Equal("-ERR invalid counter: name is required"))
+		Expect(send("PING", "hello")).To(Equal("$5"))
+
+		_, err := conn.Write([]byte("*1\r\n+PING\r\n"))
+		Expect(err).NotTo(HaveOccurred())
+		Expect(r.ReadString('\n')).To(Equal("hello\r\n"))
+		Expect(r.ReadString('\n')).To(Equal("-Protocol error: expected '$', got '+'\r\n"))
+	})
+
+	It("should limit the number of arguments", func() {
+		// reject before reading any arguments
+		_, err := conn.Write([]byte("*100000\r\n"))
+		Expect(err).NotTo(HaveOccurred())
+		Expect(r.ReadString('\n')).To(Equal("-Protocol error: invalid multibulk length\r\n"))
+	})
+
+	It("should limit the total size of arguments", func() {
+		big := strings.Repeat("x", 20<<20)
+		go func() {
+			_, _ = fmt.Fprintf(conn, "*3\r\n$5\r\nPFADD\r\n$%d\r\n%s\r\n$%d\r\n", len(big), big, len(big))
+		}()
+		Expect(r.ReadString('\n')).To(Equal("-Protocol error: invalid bulk length\r\n"))
+	})
+
+	It("should limit the size of inline commands", func() {
+		go func() {
+			_, _ = conn.Write([]byte("PFADD a " + strings.Repeat("x ", 64<<10)))
+		}()
+		Expect(r.ReadString('\n')).To(Equal("-Protocol error: too big inline request\r\n"))
+	})
+
+	It("should support inline commands and pipelining", func() {
+		_, err := conn.Write([]byte("PFADD a x y\r\nPFCOUNT a\r\nQUIT\r\n"))
+		Expect(err).NotTo(HaveOccurred())
+		Expect(r.ReadString('\n')).To(Equal(":1\r\n"))
+		Expect(r.ReadString('\n')).To(Equal(":2\r\n"))
+		Expect(r.ReadString('\n')).To(Equal("+OK\r\n"))
+
+		_, err = r.ReadString('\n')
+		Expect(err).To(HaveOccurred())
+	})
+
+	It("should serve listeners", func() {
+		l, err := net.Listen("tcp", "127.0.0.1:0")
+		Expect(err).NotTo(HaveOccurred())
+		done := make(chan error, 1)
+		go func() { done <- hllserver.NewRESPServer(service).Serve(l) }()
+
+		client, err := net.Dial("tcp", l.Addr().String())
+		Expect(err).NotTo(HaveOccurred())
+		defer client.Close()
+
+		_, err = client.Write([]byte("PFADD a x\r\n"))
+		Expect(err).NotTo(HaveOccurred())
+		Expect(bufio.NewReader(client).ReadString('\n')).To(Equal(":1\r\n"))
+
+		Expect(l.Close()).To(Succeed())
+		Eventually(done).Should(Receive(HaveOccurred()))
+	})
+})
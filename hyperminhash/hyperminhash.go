@@ -0,0 +1,256 @@
+// Package hyperminhash implements HyperMinHash, as described in "HyperMinHash: MinHash in
+// LogLog space" (Yu, Weber, 2017). Each register holds the leading zero count of a
+// HyperLogLog register plus a few bits of the minimum hash in its bucket, which allows
+// estimating the Jaccard index and intersections of sketches with a bounded error, in
+// addition to cardinalities.
+//
+// The leading zero counts are derived exactly like hllplus registers, so cardinalities
+// are estimated by hllplus and a sketch can be converted into an hllplus sketch built
+// with the same hasher.
+package hyperminhash
+
+import (
+	"encoding/binary"
+	"fmt"
+	"math"
+	"math/bits"
+
+	"github.com/gowthamkommineni/zetasketch/hllplus"
+)
+
+const (
+	// q is the number of bits of the leading zero count.
+	q = 6
+	// r is the number of mantissa bits, following the leading one.
+	r = 10
+)
+
+var defaultHasher = hllplus.Fingerprint2011
+
+// Option configures optional sketch behaviour.
+type Option func(*Sketch) error
+
+// WithHasher sets the hasher used for adding values, such as strings or numbers.
+// Sketches can only be compared with sketches using the same hasher.
+func WithHasher(h hllplus.Hasher) Option {
+	return func(s *Sketch) error {
+		if h == nil {
+			return fmt.Errorf("invalid hasher")
+		}
+		s.hasher = h
+		return nil
+	}
+}
+
+// Sketch is a HyperMinHash sketch with 2^precision 16-bit registers.
+type Sketch struct {
+	precision uint8
+	hasher    hllplus.Hasher
+	registers []uint16 // leading zeros << r | inverted mantissa, 0 marks empty registers
+}
+
+// New inits a new sketch with the given precision, which must be between 10 and 24,
+// like hllplus normal precisions.
+func New(precision uint8, opts ...Option) (*Sketch, error) {
+	if precision < hllplus.MinPrecision || precision > hllplus.MaxPrecision {
+		return nil, fmt.Errorf("invalid precision %d", precision)
+	}
+
+	s := &Sketch{precision: precision, hasher: defaultHasher}
+	for _, opt := range opts {
+		if err := opt(s); err != nil {
+			return nil, err
+		}
+	}
+	s.registers = make([]uint16, 1<<precision)
+	return s, nil
+}
+
+// Precision returns the precision.
+func (s *Sketch) Precision() uint8 {
+	return s.precision
+}
+
+// Hasher returns the hasher used by the sketch.
+func (s *Sketch) Hasher() hllplus.Hasher {
+	if s.hasher == nil {
+		return defaultHasher
+	}
+	return s.hasher
+}
+
+// Add adds the uniform hash value to the sketch.
+func (s *Sketch) Add(hash uint64) {
+	pos := hash >> (64 - s.precision)
+	w := hash << s.precision
+
+	var lz, mantissa uint64
+	if w == 0 {
+		lz = 64 - uint64(s.precision) + 1
+	} else {
+		lz = uint64(bits.LeadingZeros64(w)) + 1
+		mantissa = w << lz >> (64 - r)
+	}
+
+	// smaller hashes have more leading zeros or a smaller mantissa
+	if v := uint16(lz<<r | (1<<r - 1 - mantissa)); v > s.registers[pos] {
+		s.registers[pos] = v
+	}
+}
+
+// AddString hashes and adds a string value.
+func (s *Sketch) AddString(v string) {
+	s.Add(s.hasher.Hash64([]byte(v)))
+}
+
+// AddBytes hashes and adds a byte value.
+func (s *Sketch) AddBytes(v []byte) {
+	s.Add(s.hasher.Hash64(v))
+}
+
+// AddInt64 hashes and adds a signed number.
+func (s *Sketch) AddInt64(v int64) {
+	s.Add(s.hashUint64(uint64(v)))
+}
+
+// AddUint64 hashes and adds an unsigned number.
+func (s *Sketch) AddUint64(v uint64) {
+	s.Add(s.hashUint64(v))
+}
+
+// Merge merges other into the sketch. Both sketches must have the same precision and
+// hasher.
+func (s *Sketch) Merge(other *Sketch) error {
+	if err := s.checkCompatible(other); err != nil {
+		return err
+	}
+	for i, v := range other.registers {
+		if v > s.registers[i] {
+			s.registers[i] = v
+		}
+	}
+	return nil
+}
+
+// Clone creates a copy of the sketch.
+func (s *Sketch) Clone() *Sketch {
+	registers := make([]uint16, len(s.registers))
+	copy(registers, s.registers)
+	return &Sketch{precision: s.precision, hasher: s.hasher, registers: registers}
+}
+
+// HLL returns an hllplus sketch with the same registers, as if all values had been added
+// to it, using the same hasher.
+func (s *Sketch) HLL() (*hllplus.HLL, error) {
+	registers := make([]byte, len(s.registers))
+	for i, v := range s.registers {
+		registers[i] = byte(v >> r)
+	}
+	return hllplus.NewFromRegisters(s.precision, s.precision, registers, hllplus.WithHasher(s.Hasher()))
+}
+
+// Estimate returns the estimated number of distinct values, as estimated by hllplus.
+func (s *Sketch) Estimate() int64 {
+	h, err := s.HLL()
+	if err != nil {
+		return 0 // precisions are validated by New
+	}
+	return h.Estimate()
+}
+
+// Jaccard estimates the Jaccard index |A ∩ B| / |A ∪ B| of the sketch and other. The
+// number of colliding registers which would be expected by chance is subtracted.
+func (s *Sketch) Jaccard(other *Sketch) (float64, error) {
+	if err := s.checkCompatible(other); err != nil {
+		return 0, err
+	}
+
+	var matches, nonEmpty int
+	for i, v := range s.registers {
+		w := other.registers[i]
+		if v != 0 && v == w {
+			matches++
+		}
+		if v != 0 || w != 0 {
+			nonEmpty++
+		}
+	}
+	if matches == 0 {
+		return 0, nil
+	}
+
+	ec := s.expectedCollisions(float64(s.Estimate()), float64(other.Estimate()))
+	res := (float64(matches) - ec) / float64(nonEmpty)
+	if res < 0 {
+		return 0, nil
+	}
+	return res, nil
+}
+
+// Intersect estimates the number of distinct values in both, the sketch and other, as
+// the Jaccard index times the cardinality of the union.
+func (s *Sketch) Intersect(other *Sketch) (int64, error) {
+	j, err := s.Jaccard(other)
+	if err != nil {
+		return 0, err
+	}
+
+	union := s.Clone()
+	if err := union.Merge(other); err != nil {
+		return 0, err
+	}
+	return int64(math.Round(j * float64(union.Estimate()))), nil
+}
+
+// expectedCollisions returns the number of registers expected to match by chance for
+// two disjoint sets of cardinalities n and m.
+func (s *Sketch) expectedCollisions(n, m float64) float64 {
+	if n < m {
+		n, m = m, n
+	}
+	if n == 0 || m == 0 {
+		return 0
+	}
+
+	p := float64(s.precision)
+	if n > math.Pow(2, p+5) {
+		// approximation for large cardinalities, see section 4.3 of the paper
+		d := (4 * n / m) / math.Pow(1+n/m, 2)
+		return 0.169919487159739093975315012348 * math.Pow(2, p-r) * d
+	}
+
+	// sum the probabilities of both minima falling into the same interval of a register
+	var x float64
+	for i := 1; i <= 1<<q; i++ {
+		for j := 1; j <= 1<<r; j++ {
+			var b1, b2 float64
+			if i != 1<<q {
+				den := math.Pow(2, p+r+float64(i))
+				b1, b2 = float64(1<<r+j)/den, float64(1<<r+j+1)/den
+			} else {
+				den := math.Pow(2, p+r+float64(i)-1)
+				b1, b2 = float64(j)/den, float64(j+1)/den
+			}
+			px := math.Pow(1-b2, n) - math.Pow(1-b1, n)
+			py := math.Pow(1-b2, m) - math.Pow(1-b1, m)
+			x += px * py
+		}
+	}
+	return x * math.Pow(2, p)
+}
+
+func (s *Sketch) checkCompatible(other *Sketch) error {
+	if s.precision != other.precision {
+		return fmt.Errorf("cannot combine sketches with different precisions %d and %d", s.precision, other.precision)
+	}
+	if id, otherID := s.Hasher().ID(), other.Hasher().ID(); id != otherID {
+		return fmt.Errorf("cannot combine sketches with different hashers %q and %q", id, otherID)
+	}
+	return nil
+}
+
+func (s *Sketch) hashUint64(v uint64) uint64 {
+	var buf [8]byte
+	binary.LittleEndian.PutUint64(buf[:], v)
+	return s.hasher.Hash64(buf[:])
+}
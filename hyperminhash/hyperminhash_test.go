@@ -0,0 +1,111 @@
+package hyperminhash_test
+
+import (
+	"testing"
+
+	"github.com/gowthamkommineni/zetasketch/hllplus"
+	"github.com/gowthamkommineni/zetasketch/hyperminhash"
+
+	. "github.com/bsm/ginkgo"
+	. "github.com/bsm/gomega"
+)
+
+var _ = Describe("Sketch", func() {
+	newSketch := func(lo, hi int, opts ...hyperminhash.Option) *hyperminhash.Sketch {
+		s, err := hyperminhash.New(14, opts...)
+		Expect(err).NotTo(HaveOccurred())
+		for i := lo; i < hi; i++ {
+			s.AddInt64(int64(i))
+		}
+		return s
+	}
+
+	It("should validate", func() {
+		_, err := hyperminhash.New(9)
+		Expect(err).To(MatchError("invalid precision 9"))
+		_, err = hyperminhash.New(14, hyperminhash.WithHasher(nil))
+		Expect(err).To(MatchError("invalid hasher"))
+	})
+
+	It("should estimate cardinality like hllplus", func() {
+		s := newSketch(0, 100_000)
+		s.AddString("foo")
+		s.AddBytes([]byte("foo"))
+		s.AddUint64(1)
+		Expect(s.Precision()).To(Equal(uint8(14)))
+		Expect(s.Hasher()).To(Equal(hllplus.Fingerprint2011))
+
+		h, err := hllplus.New(14, 14)
+		Expect(err).NotTo(HaveOccurred())
+		for i := 0; i < 100_000; i++ {
+			h.AddInt64(int64(i))
+		}
+		h.AddString("foo")
+		Expect(s.Estimate()).To(Equal(h.Estimate()))
+
+		conv, err := s.HLL()
+		Expect(err).NotTo(HaveOccurred())
+		Expect(conv.Estimate()).To(Equal(h.Estimate()))
+		Expect(conv.Merge(h)).To(Succeed())
+		Expect(conv.Estimate()).To(Equal(h.Estimate()))
+	})
+
+	It("should estimate Jaccard indexes and intersections", func() {
+		for _, tc := range []struct {
+			aLo, aHi, bLo, bHi int
+			jaccard            float64
+		}{
+			{0, 10_000, 5_000, 15_000, 1.0 / 3},
+			{0, 100_000, 90_000, 190_000, 10.0 / 190},
+			{0, 1_000_000, 0, 1_000_000, 1},
+			{0, 1_000_000, 500_000, 1_500_000, 1.0 / 3},
+		} {
+			a, b := newSketch(tc.aLo, tc.aHi), newSketch(tc.bLo, tc.bHi)
+			Expect(a.Jaccard(b)).To(BeNumerically("~", tc.jaccard, 0.02), "%+v", tc)
+
+			exact := float64(tc.aHi - tc.bLo)
+			Expect(a.Intersect(b)).To(BeNumerically("~", exact, exact*0.1), "%+v", tc)
+		}
+
+		// disjoint sets
+		a, b := newSketch(0, 100_000), newSketch(100_000, 200_000)
+		Expect(a.Jaccard(b)).To(BeNumerically("~", 0, 0.005))
+		Expect(a.Intersect(b)).To(BeNumerically("<", 1_000))
+	})
+
+	It("should merge", func() {
+		a, b := newSketch(0, 50_000), newSketch(25_000, 75_000)
+		Expect(a.Merge(b)).To(Succeed())
+		Expect(a.Estimate()).To(Equal(newSketch(0, 75_000).Estimate()))
+		Expect(a.Jaccard(newSketch(0, 75_000))).To(BeNumerically("~", 1, 0.001))
+	})
+
+	It("should reject incompatible sketches", func() {
+		a := newSketch(0, 10)
+		b, err := hyperminhash.New(12)
+		Expect(err).NotTo(HaveOccurred())
+		Expect(a.Merge(b)).To(MatchError("cannot combine sketches with different precisions 14 and 12"))
+
+		c := newSketch(0, 10, hyperminhash.WithHasher(hllplus.XXHash64))
+		_, err = a.Jaccard(c)
+		Expect(err).To(MatchError(`cannot combine sketches with different hashers "fingerprint2011" and "xxhash64"`))
+	})
+})
+
+func TestSuite(t *testing.T) {
+	RegisterFailHandler(Fail)
+	RunSpecs(t, "zetasketch/hyperminhash")
+}
+
+func BenchmarkSketch_Add(b *testing.B) {
+	s, err := hyperminhash.New(14)
+	if err != nil {
+		b.Fatal(err)
+	}
+
+	b.ReportAllocs()
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		s.Add(uint64(i) * 0x9e3779b97f4a7c15)
+	}
+}
@@ -0,0 +1,77 @@
+package hyperminhash
+
+import (
+	"encoding/binary"
+	"fmt"
+
+	"github.com/gowthamkommineni/zetasketch/hllplus"
+)
+
+// encodingVersion is the version of the binary format.
+const encodingVersion = 1
+
+// Marshal serializes the sketch into a stable binary format: a version byte, the
+// precision, the length and ID of the hasher, followed by the registers as little-endian
+// uint16 values.
+func (s *Sketch) Marshal() ([]byte, error) {
+	id := s.Hasher().ID()
+	if len(id) > 255 {
+		return nil, fmt.Errorf("cannot marshal sketch with hasher %q: ID too long", id)
+	}
+
+	data := make([]byte, 0, 3+len(id)+len(s.registers)*2)
+	data = append(data, encodingVersion, s.precision, byte(len(id)))
+	data = append(data, id...)
+	for _, v := range s.registers {
+		data = append(data, byte(v), byte(v>>8))
+	}
+	return data, nil
+}
+
+// Unmarshal restores the sketch from data serialized by Marshal, replacing its current
+// state, including the precision. The sketch must use the same hasher as the serialized
+// one.
+func (s *Sketch) Unmarshal(data []byte) error {
+	if len(data) < 3 {
+		return fmt.Errorf("invalid HyperMinHash sketch: too short")
+	}
+	if data[0] != encodingVersion {
+		return fmt.Errorf("invalid HyperMinHash sketch: unsupported version %d", data[0])
+	}
+
+	precision, idLen := data[1], int(data[2])
+	if precision < hllplus.MinPrecision || precision > hllplus.MaxPrecision {
+		return fmt.Errorf("invalid HyperMinHash sketch: invalid precision %d", precision)
+	}
+	data = data[3:]
+	if len(data) < idLen {
+		return fmt.Errorf("invalid HyperMinHash sketch: too short")
+	}
+	if id := string(data[:idLen]); id != s.Hasher().ID() {
+		return fmt.Errorf("cannot restore sketch with hasher %q into %q", id, s.Hasher().ID())
+	}
+	data = data[idLen:]
+
+	if len(data) != 2<<precision {
+		return fmt.Errorf("invalid HyperMinHash sketch: %d bytes of registers, expected %d", len(data), 2<<precision)
+	}
+	registers := make([]uint16, 1<<precision)
+	for i := range registers {
+		registers[i] = binary.LittleEndian.Uint16(data[i*2:])
+	}
+
+	s.precision = precision
+	s.hasher = s.Hasher()
+	s.registers = registers
+	return nil
+}
+
+// MarshalBinary implements encoding.BinaryMarshaler using the same format as Marshal.
+func (s *Sketch) MarshalBinary() ([]byte, error) {
+	return s.Marshal()
+}
+
+// UnmarshalBinary implements encoding.BinaryUnmarshaler using the same format as Unmarshal.
+func (s *Sketch) UnmarshalBinary(data []byte) error {
+	return s.Unmarshal(data)
+}
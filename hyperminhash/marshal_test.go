@@ -0,0 +1,69 @@
+package hyperminhash_test
+
+import (
+	"github.com/gowthamkommineni/zetasketch/hllplus"
+	"github.com/gowthamkommineni/zetasketch/hyperminhash"
+
+	. "github.com/bsm/ginkgo"
+	. "github.com/bsm/gomega"
+)
+
+var _ = Describe("Sketch (marshal)", func() {
+	It("should marshal", func() {
+		s, err := hyperminhash.New(10)
+		Expect(err).NotTo(HaveOccurred())
+		s.Add(0x0000_3000_0000_0000) // register 0, 9 leading zeros, mantissa 1<<9
+
+		data, err := s.Marshal()
+		Expect(err).NotTo(HaveOccurred())
+		Expect(data).To(HaveLen(3 + 15 + 2048))
+		Expect(data[:20]).To(Equal([]byte{
+			1, 10, 15, // version, precision, hasher ID length
+			'f', 'i', 'n', 'g', 'e', 'r', 'p', 'r', 'i', 'n', 't', '2', '0', '1', '1',
+			0xff, 0x25, // 9<<10 | 1023-512
+		}))
+
+		res := new(hyperminhash.Sketch)
+		Expect(res.Unmarshal(data)).To(Succeed())
+		Expect(res.Precision()).To(Equal(uint8(10)))
+		Expect(res.Marshal()).To(Equal(data))
+		Expect(res.Jaccard(s)).To(BeNumerically("~", 1, 0.001))
+	})
+
+	It("should round trip", func() {
+		s, err := hyperminhash.New(12)
+		Expect(err).NotTo(HaveOccurred())
+		for i := 0; i < 10_000; i++ {
+			s.AddInt64(int64(i))
+		}
+
+		data, err := s.MarshalBinary()
+		Expect(err).NotTo(HaveOccurred())
+
+		res, err := hyperminhash.New(14)
+		Expect(err).NotTo(HaveOccurred())
+		Expect(res.UnmarshalBinary(data)).To(Succeed())
+		Expect(res.Precision()).To(Equal(uint8(12)))
+		Expect(res.Estimate()).To(Equal(s.Estimate()))
+		res.AddInt64(10_000)
+		Expect(res.Merge(s)).To(Succeed())
+	})
+
+	It("should reject invalid data", func() {
+		s, err := hyperminhash.New(10)
+		Expect(err).NotTo(HaveOccurred())
+		data, err := s.Marshal()
+		Expect(err).NotTo(HaveOccurred())
+
+		res := new(hyperminhash.Sketch)
+		Expect(res.Unmarshal(nil)).To(MatchError("invalid HyperMinHash sketch: too short"))
+		Expect(res.Unmarshal([]byte{2, 10, 0})).To(MatchError("invalid HyperMinHash sketch: unsupported version 2"))
+		Expect(res.Unmarshal([]byte{1, 9, 0})).To(MatchError("invalid HyperMinHash sketch: invalid precision 9"))
+		Expect(res.Unmarshal(data[:10])).To(MatchError("invalid HyperMinHash sketch: too short"))
+		Expect(res.Unmarshal(data[:100])).To(MatchError("invalid HyperMinHash sketch: 82 bytes of registers, expected 2048"))
+
+		xx, err := hyperminhash.New(10, hyperminhash.WithHasher(hllplus.XXHash64))
+		Expect(err).NotTo(HaveOccurred())
+		Expect(xx.Unmarshal(data)).To(MatchError(`cannot restore sketch with hasher "fingerprint2011" into "xxhash64"`))
+	})
+})
@@ -24,3 +24,8 @@ func Uint64(v uint64) uint64 {
 func String(v string) uint64 {
 	return Bytes([]byte(v))
 }
+
+// Seeded mixes a seed into hash h.
+func Seeded(h, seed uint64) uint64 {
+	return hash128to64(seed, h)
+}
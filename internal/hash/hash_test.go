@@ -29,6 +29,11 @@ var _ = Describe("Hash", func() {
 		Expect(hash.String("Z\u00fcrich")).To(Equal(uint64(0x27efc00f7d2ce548)))
 		Expect(hash.String("Zu\u0308rich")).To(Equal(uint64(0x7dfa3067e55c7e8a)))
 	})
+
+	It("should seed hashes", func() {
+		Expect(hash.Seeded(0xd0bcbfe261b36504, 1)).To(Equal(uint64(0xd8c43c64eb058b3e)))
+		Expect(hash.Seeded(0xd0bcbfe261b36504, 42)).To(Equal(uint64(0x99d6ef7b19523c45)))
+	})
 })
 
 func TestSuite(t *testing.T) {
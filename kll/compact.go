@@ -0,0 +1,223 @@
+package kll
+
+import "sort"
+
+// powersOfThree contains 3^i for i < 31.
+var powersOfThree = func() (t [31]uint64) {
+	t[0] = 1
+	for i := 1; i < len(t); i++ {
+		t[i] = t[i-1] * 3
+	}
+	return t
+}()
+
+// levelCapacity returns the capacity of level h of a sketch with numLevels levels. Each
+// level below the top has 2/3 of the capacity of the level above, but at least m items.
+func levelCapacity(k uint16, m uint8, numLevels, h int) uint32 {
+	c := intCapAux(uint64(k), numLevels-h-1)
+	if c < uint64(m) {
+		c = uint64(m)
+	}
+	return uint32(c)
+}
+
+// totalCapacity returns the number of items a sketch with numLevels levels can hold.
+func totalCapacity(k uint16, m uint8, numLevels int) uint32 {
+	var total uint32
+	for h := 0; h < numLevels; h++ {
+		total += levelCapacity(k, m, numLevels, h)
+	}
+	return total
+}
+
+// intCapAux returns k * (2/3)^depth, rounded, without overflowing.
+func intCapAux(k uint64, depth int) uint64 {
+	if depth <= 30 {
+		return intCapAuxAux(k, depth)
+	}
+	half := depth / 2
+	return intCapAuxAux(intCapAuxAux(k, half), depth-half)
+}
+
+func intCapAuxAux(k uint64, depth int) uint64 {
+	twoK := k << 1
+	tmp := (twoK << depth) / powersOfThree[depth]
+	return (tmp + 1) >> 1
+}
+
+// compressWhileUpdating compacts the lowest level over capacity to make room in level 0.
+func (s *Sketch) compressWhileUpdating() {
+	h := s.findLevelToCompact()
+
+	// add a new top level to make room if necessary, this shifts all levels
+	if h == s.numLevels()-1 {
+		s.addEmptyTopLevel()
+	}
+
+	rawBeg, rawLim := s.levels[h], s.levels[h+1]
+	popAbove := s.levels[h+2] - rawLim
+	rawPop := rawLim - rawBeg
+	oddPop := rawPop%2 == 1
+	adjBeg, adjPop := rawBeg, rawPop
+	if oddPop {
+		adjBeg, adjPop = adjBeg+1, adjPop-1
+	}
+	halfAdjPop := adjPop / 2
+
+	// level 0 might not be sorted
+	if h == 0 && !s.sorted {
+		sort.Float64s(s.items[adjBeg : adjBeg+adjPop])
+	}
+	if popAbove == 0 {
+		randomlyHalveUp(s.items, adjBeg, adjPop, s.randomBit())
+	} else {
+		randomlyHalveDown(s.items, adjBeg, adjPop, s.randomBit())
+		merged := make([]float64, halfAdjPop+popAbove)
+		mergeSorted(merged, s.items[adjBeg:adjBeg+halfAdjPop], s.items[rawLim:rawLim+popAbove])
+		copy(s.items[adjBeg+halfAdjPop:], merged)
+	}
+
+	s.levels[h+1] -= halfAdjPop
+	if oddPop {
+		// the current level retains one item
+		s.levels[h] = s.levels[h+1] - 1
+		s.items[s.levels[h]] = s.items[rawBeg]
+	} else {
+		s.levels[h] = s.levels[h+1]
+	}
+
+	// shift the levels below up, so that the freed space can be used by level 0
+	if h > 0 {
+		amount := rawBeg - s.levels[0]
+		copy(s.items[s.levels[0]+halfAdjPop:], s.items[s.levels[0]:s.levels[0]+amount])
+		for lvl := 0; lvl < h; lvl++ {
+			s.levels[lvl] += halfAdjPop
+		}
+	}
+}
+
+func (s *Sketch) findLevelToCompact() int {
+	numLevels := s.numLevels()
+	for h := 0; ; h++ {
+		pop := s.levels[h+1] - s.levels[h]
+		if pop >= levelCapacity(s.k, s.m, numLevels, h) {
+			return h
+		}
+	}
+}
+
+// addEmptyTopLevel adds a level to a completely full sketch. The items array grows by
+// the capacity of the new level 0.
+func (s *Sketch) addEmptyTopLevel() {
+	numLevels := s.numLevels()
+	curCap := s.levels[numLevels]
+	delta := levelCapacity(s.k, s.m, numLevels+1, 0)
+
+	items := make([]float64, curCap+delta)
+	copy(items[delta:], s.items)
+	s.items = items
+
+	for h := range s.levels {
+		s.levels[h] += delta
+	}
+	s.levels = append(s.levels, curCap+delta)
+}
+
+// generalCompress compacts levels which are over capacity, as long as the sketch is
+// over its total capacity. Items are compacted in place: buf[inLevels[h]:inLevels[h+1]]
+// are the items of level h before, buf[outLevels[h]:outLevels[h+1]] after compaction.
+// It returns the new number of levels, the total capacity and the number of items.
+func generalCompress(k uint16, m uint8, numLevels int, buf []float64, inLevels, outLevels []uint32, sorted bool, randomBit func() uint32) (int, int, int) {
+	size := inLevels[numLevels] - inLevels[0]
+	capacity := totalCapacity(k, m, numLevels)
+	outLevels[0] = 0
+
+	for h := 0; h < numLevels; h++ {
+		// add an empty level above the top for convenience
+		if h == numLevels-1 {
+			inLevels[h+2] = inLevels[h+1]
+		}
+
+		rawBeg, rawLim := inLevels[h], inLevels[h+1]
+		rawPop := rawLim - rawBeg
+
+		if size < capacity || rawPop < levelCapacity(k, m, numLevels, h) {
+			// copy level as is, data is never moved upwards
+			copy(buf[outLevels[h]:], buf[rawBeg:rawLim])
+			outLevels[h+1] = outLevels[h] + rawPop
+			continue
+		}
+
+		// the sketch and this level are over capacity, compact it
+		popAbove := inLevels[h+2] - rawLim
+		oddPop := rawPop%2 == 1
+		adjBeg, adjPop := rawBeg, rawPop
+		if oddPop {
+			adjBeg, adjPop = adjBeg+1, adjPop-1
+		}
+		halfAdjPop := adjPop / 2
+
+		if oddPop {
+			buf[outLevels[h]] = buf[rawBeg]
+			outLevels[h+1] = outLevels[h] + 1
+		} else {
+			outLevels[h+1] = outLevels[h]
+		}
+
+		if h == 0 && !sorted {
+			sort.Float64s(buf[adjBeg : adjBeg+adjPop])
+		}
+		if popAbove == 0 {
+			randomlyHalveUp(buf, adjBeg, adjPop, randomBit())
+		} else {
+			randomlyHalveDown(buf, adjBeg, adjPop, randomBit())
+			merged := make([]float64, halfAdjPop+popAbove)
+			mergeSorted(merged, buf[adjBeg:adjBeg+halfAdjPop], buf[rawLim:rawLim+popAbove])
+			copy(buf[adjBeg+halfAdjPop:], merged)
+		}
+
+		size -= halfAdjPop
+		inLevels[h+1] -= halfAdjPop
+
+		// compacting the top level adds a level, and capacity
+		if h == numLevels-1 {
+			numLevels++
+			capacity += levelCapacity(k, m, numLevels, 0)
+		}
+	}
+	return numLevels, int(capacity), int(size)
+}
+
+// randomlyHalveDown keeps every other item of buf[start:start+n] in its lower half.
+func randomlyHalveDown(buf []float64, start, n, offset uint32) {
+	half := n / 2
+	j := start + offset
+	for i := start; i < start+half; i++ {
+		buf[i] = buf[j]
+		j += 2
+	}
+}
+
+// randomlyHalveUp keeps every other item of buf[start:start+n] in its upper half.
+func randomlyHalveUp(buf []float64, start, n, offset uint32) {
+	half := n / 2
+	j := start + n - 1 - offset
+	for i := start + n - 1; i >= start+half; i-- {
+		buf[i] = buf[j]
+		j -= 2
+	}
+}
+
+// mergeSorted merges the sorted slices a and b into dst.
+func mergeSorted(dst, a, b []float64) {
+	i, j := 0, 0
+	for k := range dst[:len(a)+len(b)] {
+		if j == len(b) || (i < len(a) && a[i] < b[j]) {
+			dst[k] = a[i]
+			i++
+		} else {
+			dst[k] = b[j]
+			j++
+		}
+	}
+}
@@ -0,0 +1,311 @@
+// Package kll implements the KLL streaming quantile sketch, as described in "Optimal
+// Quantile Approximation in Streams" (Karnin, Lang, Liberty, 2016). The implementation
+// follows the Apache DataSketches KLL sketch for doubles and uses the same binary format,
+// so sketches can be exchanged with the DataSketches Java and C++ libraries.
+package kll
+
+import (
+	"fmt"
+	"math"
+	"math/bits"
+	"math/rand"
+	"sort"
+)
+
+// Parameter limits and defaults.
+const (
+	// DefaultK is the default accuracy parameter, which results in a normalized rank
+	// error of about 1.65%.
+	DefaultK = 200
+	// MinK is the minimum accuracy parameter.
+	MinK = 8
+	// MaxK is the maximum accuracy parameter.
+	MaxK = 1<<16 - 1
+
+	// defaultM is the minimum width of a level.
+	defaultM = 8
+)
+
+// Option configures optional sketch behaviour.
+type Option func(*Sketch) error
+
+// WithRand sets the source of randomness for compactions, e.g. to obtain reproducible
+// results. By default, the global source of the math/rand package is used.
+func WithRand(rnd *rand.Rand) Option {
+	return func(s *Sketch) error {
+		if rnd == nil {
+			return fmt.Errorf("invalid random source")
+		}
+		s.rnd = rnd
+		return nil
+	}
+}
+
+// Sketch is a KLL quantile sketch for float64 values.
+//
+// Items are stored in a single array, which is divided into levels. Items on level h
+// have a weight of 2^h. Level 0 is filled from the top down; once the sketch is full,
+// the lowest level over capacity is compacted by sorting it and promoting every other
+// item to the next level.
+type Sketch struct {
+	k      uint16
+	m      uint8
+	minK   uint16
+	n      uint64
+	levels []uint32  // start of each level in items, followed by the total capacity
+	items  []float64 // items of level h are items[levels[h]:levels[h+1]]
+	sorted bool      // level 0 is sorted
+	min    float64
+	max    float64
+	rnd    *rand.Rand
+}
+
+// New inits a new sketch with accuracy parameter k, which must be between 8 and 65535.
+func New(k int, opts ...Option) (*Sketch, error) {
+	if k < MinK || k > MaxK {
+		return nil, fmt.Errorf("invalid k %d", k)
+	}
+
+	s := &Sketch{
+		k:      uint16(k),
+		m:      defaultM,
+		minK:   uint16(k),
+		levels: []uint32{uint32(k), uint32(k)},
+		items:  make([]float64, k),
+		min:    math.NaN(),
+		max:    math.NaN(),
+	}
+	for _, opt := range opts {
+		if err := opt(s); err != nil {
+			return nil, err
+		}
+	}
+	return s, nil
+}
+
+// K returns the accuracy parameter.
+func (s *Sketch) K() int {
+	return int(s.k)
+}
+
+// N returns the number of values added to the sketch, including values of merged
+// sketches.
+func (s *Sketch) N() uint64 {
+	return s.n
+}
+
+// IsEmpty returns true if no values have been added.
+func (s *Sketch) IsEmpty() bool {
+	return s.n == 0
+}
+
+// NumRetained returns the number of values retained by the sketch.
+func (s *Sketch) NumRetained() int {
+	return int(s.levels[s.numLevels()] - s.levels[0])
+}
+
+// Min returns the smallest value added, or NaN if the sketch is empty.
+func (s *Sketch) Min() float64 {
+	return s.min
+}
+
+// Max returns the largest value added, or NaN if the sketch is empty.
+func (s *Sketch) Max() float64 {
+	return s.max
+}
+
+// NormalizedRankError returns the approximate rank error of the sketch with a
+// confidence of 99%, for single ranks or, if pmf is true, for probability mass functions.
+func (s *Sketch) NormalizedRankError(pmf bool) float64 {
+	return NormalizedRankError(int(s.minK), pmf)
+}
+
+// NormalizedRankError returns the approximate rank error of sketches with accuracy
+// parameter k, see Sketch.NormalizedRankError.
+func NormalizedRankError(k int, pmf bool) float64 {
+	if pmf {
+		return 2.446 / math.Pow(float64(k), 0.9433)
+	}
+	return 2.296 / math.Pow(float64(k), 0.9723)
+}
+
+// Add adds a value to the sketch. NaN values are ignored.
+func (s *Sketch) Add(v float64) {
+	if math.IsNaN(v) {
+		return
+	}
+
+	s.updateMinMax(v, v)
+	s.update(v)
+}
+
+func (s *Sketch) update(v float64) {
+	if s.levels[0] == 0 {
+		s.compressWhileUpdating()
+	}
+
+	s.n++
+	s.sorted = false
+	s.levels[0]--
+	s.items[s.levels[0]] = v
+}
+
+func (s *Sketch) updateMinMax(min, max float64) {
+	if s.n == 0 || min < s.min {
+		s.min = min
+	}
+	if s.n == 0 || max > s.max {
+		s.max = max
+	}
+}
+
+// Merge merges other into the sketch. Sketches with different accuracy parameters
+// can be merged, the accuracy of the result is limited by the smaller one.
+func (s *Sketch) Merge(other *Sketch) {
+	if other == nil || other.n == 0 {
+		return
+	}
+
+	finalN := s.n + other.n
+	s.updateMinMax(other.min, other.max)
+
+	// add level 0 of other like new values
+	for _, v := range other.items[other.levels[0]:other.levels[1]] {
+		s.update(v)
+	}
+	if other.numLevels() > 1 {
+		s.mergeHigherLevels(other, finalN)
+	}
+
+	s.n = finalN
+	if other.numLevels() > 1 && other.minK < s.minK {
+		s.minK = other.minK
+	}
+}
+
+func (s *Sketch) mergeHigherLevels(other *Sketch, finalN uint64) {
+	numLevels := s.numLevels()
+	if n := other.numLevels(); n > numLevels {
+		numLevels = n
+	}
+	ub := bits.Len64(finalN)
+	if ub < numLevels {
+		ub = numLevels
+	}
+
+	// populate the work buffer with the levels of both sketches, merging level by level
+	buf := make([]float64, s.NumRetained()+int(other.levels[other.numLevels()]-other.levels[1]))
+	inLevels := make([]uint32, ub+2)
+	outLevels := make([]uint32, ub+2)
+
+	inLevels[1] = uint32(copy(buf, s.levelItems(0)))
+	for h := 1; h < numLevels; h++ {
+		a, b := s.levelItems(h), other.levelItems(h)
+		mergeSorted(buf[inLevels[h]:], a, b)
+		inLevels[h+1] = inLevels[h] + uint32(len(a)+len(b))
+	}
+
+	// compact in place
+	numLevels, capacity, size := generalCompress(s.k, s.m, numLevels, buf, inLevels, outLevels, s.sorted, s.randomBit)
+
+	s.items = make([]float64, capacity)
+	s.levels = make([]uint32, numLevels+1)
+	free := uint32(capacity - size)
+	copy(s.items[free:], buf[outLevels[0]:outLevels[0]+uint32(size)])
+	for h := range s.levels {
+		s.levels[h] = outLevels[h] - outLevels[0] + free
+	}
+}
+
+// Quantile returns the approximate value at normalized rank q, which must be between 0
+// and 1, or NaN if the sketch is empty. Ranks are inclusive: the result is the smallest
+// retained value whose rank is at least q. Quantiles 0 and 1 return the exact minimum and
+// maximum.
+func (s *Sketch) Quantile(q float64) float64 {
+	if s.n == 0 || !(q >= 0 && q <= 1) {
+		return math.NaN()
+	}
+	if q == 0 {
+		return s.min
+	}
+	if q == 1 {
+		return s.max
+	}
+
+	v := s.sortedView()
+	target := uint64(math.Ceil(q * float64(s.n)))
+	i := sort.Search(len(v.weights), func(i int) bool { return v.weights[i] >= target })
+	if i == len(v.items) {
+		i--
+	}
+	return v.items[i]
+}
+
+// Rank returns the approximate normalized rank of v, which is the fraction of values
+// less than or equal to v, or NaN if the sketch is empty.
+func (s *Sketch) Rank(v float64) float64 {
+	if s.n == 0 || math.IsNaN(v) {
+		return math.NaN()
+	}
+
+	var weight uint64
+	for h := 0; h < s.numLevels(); h++ {
+		for _, x := range s.levelItems(h) {
+			if x <= v {
+				weight += 1 << h
+			}
+		}
+	}
+	return float64(weight) / float64(s.n)
+}
+
+// sortedView contains the retained items in ascending order, with cumulative weights.
+type sortedView struct {
+	items   []float64
+	weights []uint64
+}
+
+func (v *sortedView) Len() int           { return len(v.items) }
+func (v *sortedView) Less(i, j int) bool { return v.items[i] < v.items[j] }
+func (v *sortedView) Swap(i, j int) {
+	v.items[i], v.items[j] = v.items[j], v.items[i]
+	v.weights[i], v.weights[j] = v.weights[j], v.weights[i]
+}
+
+func (s *Sketch) sortedView() *sortedView {
+	v := &sortedView{
+		items:   make([]float64, 0, s.NumRetained()),
+		weights: make([]uint64, 0, s.NumRetained()),
+	}
+	for h := 0; h < s.numLevels(); h++ {
+		for _, x := range s.levelItems(h) {
+			v.items = append(v.items, x)
+			v.weights = append(v.weights, 1<<h)
+		}
+	}
+	sort.Stable(v)
+
+	for i := 1; i < len(v.weights); i++ {
+		v.weights[i] += v.weights[i-1]
+	}
+	return v
+}
+
+func (s *Sketch) numLevels() int {
+	return len(s.levels) - 1
+}
+
+// levelItems returns the items of level h, or nil if the level does not exist.
+func (s *Sketch) levelItems(h int) []float64 {
+	if h >= s.numLevels() {
+		return nil
+	}
+	return s.items[s.levels[h]:s.levels[h+1]]
+}
+
+func (s *Sketch) randomBit() uint32 {
+	if s.rnd != nil {
+		return uint32(s.rnd.Intn(2))
+	}
+	return uint32(rand.Intn(2))
+}
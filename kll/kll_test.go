@@ -0,0 +1,172 @@
+package kll_test
+
+import (
+	"math"
+	"math/rand"
+	"testing"
+
+	"github.com/gowthamkommineni/zetasketch/kll"
+
+	. "github.com/bsm/ginkgo"
+	. "github.com/bsm/gomega"
+)
+
+var _ = Describe("Sketch", func() {
+	var subject *kll.Sketch
+
+	newSketch := func(k int, seed int64) *kll.Sketch {
+		s, err := kll.New(k, kll.WithRand(rand.New(rand.NewSource(seed))))
+		Expect(err).NotTo(HaveOccurred())
+		return s
+	}
+
+	BeforeEach(func() {
+		subject = newSketch(kll.DefaultK, 1)
+	})
+
+	It("should validate k", func() {
+		_, err := kll.New(7)
+		Expect(err).To(MatchError("invalid k 7"))
+		_, err = kll.New(1 << 16)
+		Expect(err).To(MatchError("invalid k 65536"))
+		_, err = kll.New(kll.DefaultK, kll.WithRand(nil))
+		Expect(err).To(MatchError("invalid random source"))
+	})
+
+	It("should handle empty sketches", func() {
+		Expect(subject.IsEmpty()).To(BeTrue())
+		Expect(subject.N()).To(Equal(uint64(0)))
+		Expect(subject.NumRetained()).To(Equal(0))
+		Expect(math.IsNaN(subject.Min())).To(BeTrue())
+		Expect(math.IsNaN(subject.Max())).To(BeTrue())
+		Expect(math.IsNaN(subject.Quantile(0.5))).To(BeTrue())
+		Expect(math.IsNaN(subject.Rank(1))).To(BeTrue())
+	})
+
+	It("should ignore NaN", func() {
+		subject.Add(math.NaN())
+		Expect(subject.IsEmpty()).To(BeTrue())
+
+		subject.Add(1)
+		Expect(subject.N()).To(Equal(uint64(1)))
+		Expect(math.IsNaN(subject.Quantile(math.NaN()))).To(BeTrue())
+		Expect(math.IsNaN(subject.Quantile(1.1))).To(BeTrue())
+		Expect(math.IsNaN(subject.Rank(math.NaN()))).To(BeTrue())
+	})
+
+	It("should be exact in exact mode", func() {
+		for i := 1; i <= 100; i++ {
+			subject.Add(float64(i))
+		}
+		Expect(subject.N()).To(Equal(uint64(100)))
+		Expect(subject.NumRetained()).To(Equal(100))
+		Expect(subject.Min()).To(Equal(1.0))
+		Expect(subject.Max()).To(Equal(100.0))
+
+		Expect(subject.Quantile(0)).To(Equal(1.0))
+		Expect(subject.Quantile(0.01)).To(Equal(1.0))
+		Expect(subject.Quantile(0.5)).To(Equal(50.0))
+		Expect(subject.Quantile(0.505)).To(Equal(51.0))
+		Expect(subject.Quantile(1)).To(Equal(100.0))
+
+		Expect(subject.Rank(0)).To(Equal(0.0))
+		Expect(subject.Rank(1)).To(Equal(0.01))
+		Expect(subject.Rank(50)).To(Equal(0.5))
+		Expect(subject.Rank(100)).To(Equal(1.0))
+	})
+
+	It("should estimate quantiles and ranks", func() {
+		const n = 1_000_000
+		for _, i := range rand.New(rand.NewSource(33)).Perm(n) {
+			subject.Add(float64(i))
+		}
+		Expect(subject.N()).To(Equal(uint64(n)))
+		Expect(subject.NumRetained()).To(BeNumerically("<", 4*kll.DefaultK))
+		Expect(subject.Min()).To(Equal(0.0))
+		Expect(subject.Max()).To(Equal(float64(n - 1)))
+
+		eps := subject.NormalizedRankError(false)
+		Expect(eps).To(BeNumerically("~", 0.0133, 0.0001))
+		for _, q := range []float64{0.01, 0.1, 0.25, 0.5, 0.75, 0.9, 0.99} {
+			Expect(subject.Quantile(q)/n).To(BeNumerically("~", q, eps), "quantile %v", q)
+			Expect(subject.Rank(q*n)).To(BeNumerically("~", q, eps), "rank %v", q)
+		}
+	})
+
+	It("should be deterministic with a random source", func() {
+		other := newSketch(kll.DefaultK, 1)
+		for i := 0; i < 100_000; i++ {
+			subject.Add(float64(i))
+			other.Add(float64(i))
+		}
+		data, err := other.Marshal()
+		Expect(err).NotTo(HaveOccurred())
+		Expect(subject.Marshal()).To(Equal(data))
+	})
+
+	It("should merge", func() {
+		other := newSketch(kll.DefaultK, 2)
+		for i := 0; i < 100_000; i++ {
+			subject.Add(float64(i))
+			other.Add(float64(100_000 + i))
+		}
+
+		subject.Merge(other)
+		subject.Merge(nil)
+		Expect(subject.N()).To(Equal(uint64(200_000)))
+		Expect(subject.Min()).To(Equal(0.0))
+		Expect(subject.Max()).To(Equal(199_999.0))
+		Expect(subject.NumRetained()).To(BeNumerically("<", 4*kll.DefaultK))
+
+		eps := subject.NormalizedRankError(false)
+		for _, q := range []float64{0.1, 0.25, 0.5, 0.75, 0.9} {
+			Expect(subject.Quantile(q)/200_000).To(BeNumerically("~", q, eps), "quantile %v", q)
+		}
+	})
+
+	It("should merge into empty sketches", func() {
+		for i := 0; i < 10_000; i++ {
+			subject.Add(float64(i))
+		}
+
+		empty := newSketch(kll.DefaultK, 2)
+		empty.Merge(subject)
+		Expect(empty.N()).To(Equal(subject.N()))
+		Expect(empty.Min()).To(Equal(0.0))
+		Expect(empty.Max()).To(Equal(9_999.0))
+		Expect(empty.Quantile(0.5)).To(BeNumerically("~", 5_000, 10_000*empty.NormalizedRankError(false)))
+	})
+
+	It("should merge sketches with different k", func() {
+		other := newSketch(50, 2)
+		for i := 0; i < 100_000; i++ {
+			subject.Add(float64(i))
+			other.Add(float64(i))
+		}
+		Expect(other.NormalizedRankError(false)).To(BeNumerically(">", subject.NormalizedRankError(false)))
+
+		subject.Merge(other)
+		Expect(subject.K()).To(Equal(kll.DefaultK))
+		Expect(subject.N()).To(Equal(uint64(200_000)))
+		Expect(subject.NormalizedRankError(false)).To(Equal(other.NormalizedRankError(false)))
+		Expect(subject.Quantile(0.5)).To(BeNumerically("~", 50_000, 100_000*subject.NormalizedRankError(false)))
+	})
+})
+
+func TestSuite(t *testing.T) {
+	RegisterFailHandler(Fail)
+	RunSpecs(t, "zetasketch/kll")
+}
+
+func BenchmarkSketch_Add(b *testing.B) {
+	s, err := kll.New(kll.DefaultK)
+	if err != nil {
+		b.Fatal(err)
+	}
+
+	b.ReportAllocs()
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		s.Add(float64(i))
+	}
+}
@@ -0,0 +1,203 @@
+package kll
+
+import (
+	"encoding/binary"
+	"fmt"
+	"math"
+)
+
+// Apache DataSketches serialization constants, see
+// https://datasketches.apache.org/docs/KLL/KLLSketch.html.
+const (
+	preIntsShort = 2 // empty and single item sketches
+	preIntsFull  = 5
+
+	serVerEmptyFull = 1
+	serVerSingle    = 2
+
+	familyKLL = 15
+
+	maxNumLevels = 61
+
+	flagEmpty           = 1 << 0
+	flagLevelZeroSorted = 1 << 1
+	flagSingleItem      = 1 << 2
+
+	headerSizeShort = preIntsShort * 4
+	headerSizeFull  = preIntsFull * 4
+)
+
+// Marshal serializes the sketch in the compact binary format of the Apache DataSketches
+// KLL sketch for doubles.
+func (s *Sketch) Marshal() ([]byte, error) {
+	var flags byte
+	if s.sorted {
+		flags |= flagLevelZeroSorted
+	}
+
+	switch s.n {
+	case 0:
+		data := s.appendHeader(make([]byte, 0, headerSizeShort), preIntsShort, serVerEmptyFull, flags|flagEmpty)
+		return data, nil
+	case 1:
+		data := s.appendHeader(make([]byte, 0, headerSizeShort+8), preIntsShort, serVerSingle, flags|flagSingleItem)
+		return appendFloat64(data, s.items[s.levels[0]]), nil
+	}
+
+	numLevels := s.numLevels()
+	data := make([]byte, 0, headerSizeFull+numLevels*4+16+s.NumRetained()*8)
+	data = s.appendHeader(data, preIntsFull, serVerEmptyFull, flags)
+	data = appendUint64(data, s.n)
+	data = appendUint16(data, s.minK)
+	data = append(data, byte(numLevels), 0)
+
+	// the last level boundary is the total capacity, which is derived on restore
+	for _, v := range s.levels[:numLevels] {
+		data = appendUint32(data, v)
+	}
+	data = appendFloat64(data, s.min)
+	data = appendFloat64(data, s.max)
+	for _, v := range s.items[s.levels[0]:] {
+		data = appendFloat64(data, v)
+	}
+	return data, nil
+}
+
+// Unmarshal restores the sketch from the binary format of the Apache DataSketches KLL
+// sketch for doubles, replacing its current state. Options the sketch was created with
+// are retained.
+func (s *Sketch) Unmarshal(data []byte) error {
+	if len(data) < headerSizeShort {
+		return fmt.Errorf("invalid KLL sketch: too short")
+	}
+
+	preInts, serVer, family, flags := data[0], data[1], data[2], data[3]
+	k, m := binary.LittleEndian.Uint16(data[4:]), data[6]
+
+	if family != familyKLL {
+		return fmt.Errorf("invalid KLL sketch: unsupported family %d", family)
+	}
+	if k < MinK {
+		return fmt.Errorf("invalid KLL sketch: invalid k %d", k)
+	}
+	if m < 2 || m > defaultM {
+		return fmt.Errorf("invalid KLL sketch: invalid m %d", m)
+	}
+
+	res := Sketch{k: k, m: m, minK: k, sorted: flags&flagLevelZeroSorted != 0, rnd: s.rnd}
+	switch {
+	case serVer == serVerEmptyFull && flags&flagEmpty != 0:
+		if preInts != preIntsShort {
+			return fmt.Errorf("invalid KLL sketch: %d preamble ints, expected %d", preInts, preIntsShort)
+		}
+		res.levels = []uint32{uint32(k), uint32(k)}
+		res.items = make([]float64, k)
+		res.min, res.max = math.NaN(), math.NaN()
+	case serVer == serVerSingle && flags&flagSingleItem != 0:
+		if preInts != preIntsShort {
+			return fmt.Errorf("invalid KLL sketch: %d preamble ints, expected %d", preInts, preIntsShort)
+		}
+		if len(data) != headerSizeShort+8 {
+			return fmt.Errorf("invalid KLL sketch: %d bytes, expected %d", len(data), headerSizeShort+8)
+		}
+		v := readFloat64(data[headerSizeShort:])
+		res.n = 1
+		res.levels = []uint32{uint32(k) - 1, uint32(k)}
+		res.items = make([]float64, k)
+		res.items[k-1] = v
+		res.min, res.max = v, v
+	case serVer == serVerEmptyFull:
+		if preInts != preIntsFull {
+			return fmt.Errorf("invalid KLL sketch: %d preamble ints, expected %d", preInts, preIntsFull)
+		}
+		if err := res.unmarshalFull(data); err != nil {
+			return err
+		}
+	default:
+		return fmt.Errorf("invalid KLL sketch: unsupported serial version %d", serVer)
+	}
+
+	*s = res
+	return nil
+}
+
+func (s *Sketch) unmarshalFull(data []byte) error {
+	if len(data) < headerSizeFull {
+		return fmt.Errorf("invalid KLL sketch: too short")
+	}
+
+	s.n = binary.LittleEndian.Uint64(data[8:])
+	s.minK = binary.LittleEndian.Uint16(data[16:])
+	numLevels := int(data[18])
+	if numLevels == 0 || numLevels > maxNumLevels || s.minK < MinK || s.minK > s.k {
+		return fmt.Errorf("invalid KLL sketch: invalid preamble")
+	}
+
+	data = data[headerSizeFull:]
+	if len(data) < numLevels*4+16 {
+		return fmt.Errorf("invalid KLL sketch: too short")
+	}
+
+	capacity := totalCapacity(s.k, s.m, numLevels)
+	s.levels = make([]uint32, numLevels+1)
+	for h := range s.levels[:numLevels] {
+		s.levels[h] = binary.LittleEndian.Uint32(data[h*4:])
+	}
+	s.levels[numLevels] = capacity
+	for h := 0; h < numLevels; h++ {
+		if s.levels[h] > s.levels[h+1] {
+			return fmt.Errorf("invalid KLL sketch: invalid levels")
+		}
+	}
+	data = data[numLevels*4:]
+
+	s.min = readFloat64(data)
+	s.max = readFloat64(data[8:])
+	data = data[16:]
+
+	retained := int(capacity - s.levels[0])
+	if len(data) != retained*8 {
+		return fmt.Errorf("invalid KLL sketch: %d bytes of items, expected %d", len(data), retained*8)
+	}
+	s.items = make([]float64, capacity)
+	for i := range s.items[s.levels[0]:] {
+		s.items[int(s.levels[0])+i] = readFloat64(data[i*8:])
+	}
+	return nil
+}
+
+// MarshalBinary implements encoding.BinaryMarshaler using the same format as Marshal.
+func (s *Sketch) MarshalBinary() ([]byte, error) {
+	return s.Marshal()
+}
+
+// UnmarshalBinary implements encoding.BinaryUnmarshaler using the same format as Unmarshal.
+func (s *Sketch) UnmarshalBinary(data []byte) error {
+	return s.Unmarshal(data)
+}
+
+func (s *Sketch) appendHeader(data []byte, preInts, serVer, flags byte) []byte {
+	data = append(data, preInts, serVer, familyKLL, flags)
+	data = appendUint16(data, s.k)
+	return append(data, s.m, 0)
+}
+
+func appendFloat64(data []byte, v float64) []byte {
+	return appendUint64(data, math.Float64bits(v))
+}
+
+func readFloat64(data []byte) float64 {
+	return math.Float64frombits(binary.LittleEndian.Uint64(data))
+}
+
+func appendUint16(data []byte, v uint16) []byte {
+	return append(data, byte(v), byte(v>>8))
+}
+
+func appendUint32(data []byte, v uint32) []byte {
+	return append(data, byte(v), byte(v>>8), byte(v>>16), byte(v>>24))
+}
+
+func appendUint64(data []byte, v uint64) []byte {
+	return appendUint32(appendUint32(data, uint32(v)), uint32(v>>32))
+}
@@ -0,0 +1,141 @@
+package kll_test
+
+import (
+	"encoding/binary"
+	"math"
+	"math/rand"
+
+	"github.com/gowthamkommineni/zetasketch/kll"
+
+	. "github.com/bsm/ginkgo"
+	. "github.com/bsm/gomega"
+)
+
+var _ = Describe("Sketch (marshal)", func() {
+	var subject *kll.Sketch
+
+	BeforeEach(func() {
+		var err error
+		subject, err = kll.New(kll.DefaultK, kll.WithRand(rand.New(rand.NewSource(1))))
+		Expect(err).NotTo(HaveOccurred())
+	})
+
+	roundTrip := func(s *kll.Sketch) *kll.Sketch {
+		data, err := s.Marshal()
+		Expect(err).NotTo(HaveOccurred())
+
+		res, err := kll.New(kll.MinK)
+		Expect(err).NotTo(HaveOccurred())
+		Expect(res.Unmarshal(data)).To(Succeed())
+		Expect(res.Marshal()).To(Equal(data))
+		return res
+	}
+
+	It("should marshal empty sketches", func() {
+		Expect(subject.Marshal()).To(Equal([]byte{
+			2, 1, 15, 1, // preamble ints, serial version, family, flags: empty
+			200, 0, 8, 0, // k, m, unused
+		}))
+
+		res := roundTrip(subject)
+		Expect(res.IsEmpty()).To(BeTrue())
+		Expect(res.K()).To(Equal(kll.DefaultK))
+		Expect(math.IsNaN(res.Min())).To(BeTrue())
+	})
+
+	It("should marshal single item sketches", func() {
+		subject.Add(1)
+		Expect(subject.Marshal()).To(Equal([]byte{
+			2, 2, 15, 4, // preamble ints, serial version, family, flags: single item
+			200, 0, 8, 0, // k, m, unused
+			0, 0, 0, 0, 0, 0, 0xf0, 0x3f, // 1.0
+		}))
+
+		res := roundTrip(subject)
+		Expect(res.N()).To(Equal(uint64(1)))
+		Expect(res.Min()).To(Equal(1.0))
+		Expect(res.Max()).To(Equal(1.0))
+		Expect(res.Quantile(0.5)).To(Equal(1.0))
+
+		// adding continues where the single item left off
+		res.Add(2)
+		Expect(res.Quantile(1)).To(Equal(2.0))
+		Expect(res.NumRetained()).To(Equal(2))
+	})
+
+	It("should marshal full sketches", func() {
+		subject.Add(3)
+		subject.Add(2)
+		data, err := subject.Marshal()
+		Expect(err).NotTo(HaveOccurred())
+		Expect(data).To(Equal([]byte{
+			5, 1, 15, 0, // preamble ints, serial version, family, flags
+			200, 0, 8, 0, // k, m, unused
+			2, 0, 0, 0, 0, 0, 0, 0, // n
+			200, 0, 1, 0, // min k, number of levels, unused
+			198, 0, 0, 0, // levels
+			0, 0, 0, 0, 0, 0, 0, 0x40, // min
+			0, 0, 0, 0, 0, 0, 0x08, 0x40, // max
+			0, 0, 0, 0, 0, 0, 0, 0x40, // items
+			0, 0, 0, 0, 0, 0, 0x08, 0x40,
+		}))
+
+		for i := 0; i < 100_000; i++ {
+			subject.Add(float64(i))
+		}
+		data, err = subject.Marshal()
+		Expect(err).NotTo(HaveOccurred())
+		Expect(data[:8]).To(Equal([]byte{5, 1, 15, 0, 200, 0, 8, 0}))
+		Expect(binary.LittleEndian.Uint64(data[8:])).To(Equal(uint64(100_002)))
+
+		numLevels := int(data[18])
+		Expect(numLevels).To(BeNumerically(">", 1))
+		Expect(data).To(HaveLen(20 + numLevels*4 + 16 + subject.NumRetained()*8))
+
+		res := roundTrip(subject)
+		Expect(res.N()).To(Equal(subject.N()))
+		Expect(res.NumRetained()).To(Equal(subject.NumRetained()))
+		Expect(res.Min()).To(Equal(0.0))
+		Expect(res.Max()).To(Equal(99_999.0))
+		for _, q := range []float64{0.1, 0.5, 0.9} {
+			Expect(res.Quantile(q)).To(Equal(subject.Quantile(q)))
+		}
+
+		// restored sketches can be updated and merged
+		res.Add(100_000)
+		res.Merge(subject)
+		Expect(res.N()).To(Equal(uint64(200_005)))
+	})
+
+	It("should reject invalid data", func() {
+		Expect(subject.Unmarshal(nil)).To(MatchError("invalid KLL sketch: too short"))
+		Expect(subject.Unmarshal([]byte{2, 1, 7, 1, 200, 0, 8, 0})).To(MatchError("invalid KLL sketch: unsupported family 7"))
+		Expect(subject.Unmarshal([]byte{2, 1, 15, 1, 4, 0, 8, 0})).To(MatchError("invalid KLL sketch: invalid k 4"))
+		Expect(subject.Unmarshal([]byte{2, 3, 15, 0, 200, 0, 8, 0})).To(MatchError("invalid KLL sketch: unsupported serial version 3"))
+		Expect(subject.Unmarshal([]byte{5, 1, 15, 1, 200, 0, 8, 0})).To(MatchError("invalid KLL sketch: 5 preamble ints, expected 2"))
+		Expect(subject.Unmarshal([]byte{2, 2, 15, 4, 200, 0, 8, 0})).To(MatchError("invalid KLL sketch: 8 bytes, expected 16"))
+		Expect(subject.Unmarshal([]byte{5, 1, 15, 0, 200, 0, 8, 0})).To(MatchError("invalid KLL sketch: too short"))
+
+		subject.Add(1)
+		subject.Add(2)
+		data, err := subject.Marshal()
+		Expect(err).NotTo(HaveOccurred())
+		Expect(subject.Unmarshal(data[:len(data)-1])).To(MatchError("invalid KLL sketch: 15 bytes of items, expected 16"))
+
+		large, err := kll.New(kll.DefaultK)
+		Expect(err).NotTo(HaveOccurred())
+		for i := 0; i < 1000; i++ {
+			large.Add(float64(i))
+		}
+		data, err = large.Marshal()
+		Expect(err).NotTo(HaveOccurred())
+		for _, numLevels := range []byte{62, 100, 255} {
+			corrupt := append([]byte(nil), data...)
+			corrupt[18] = numLevels
+			Expect(subject.Unmarshal(corrupt)).To(MatchError("invalid KLL sketch: invalid preamble"))
+		}
+
+		// the sketch is not modified on errors
+		Expect(subject.N()).To(Equal(uint64(2)))
+	})
+})
@@ -0,0 +1,161 @@
+// Package reservoir implements fixed-size random samples of streams, e.g. to keep
+// exemplar values next to a sketch. Sampling follows Algorithm A-Res of "Weighted Random
+// Sampling with a Reservoir" (Efraimidis, Spirakis, 2006): each value is assigned a
+// random key u^(1/weight) and the values with the largest keys are retained. With equal
+// weights, this is a uniform sample like Algorithm R, and samples of disjoint streams
+// can be merged exactly.
+package reservoir
+
+import (
+	"container/heap"
+	"fmt"
+	"math"
+	"math/rand"
+	"sort"
+)
+
+// Option configures optional sampler behaviour.
+type Option func(*config) error
+
+type config struct {
+	rnd *rand.Rand
+}
+
+// WithRand sets the source of randomness, e.g. to obtain reproducible samples. By
+// default, the global source of the math/rand package is used.
+func WithRand(rnd *rand.Rand) Option {
+	return func(c *config) error {
+		if rnd == nil {
+			return fmt.Errorf("invalid random source")
+		}
+		c.rnd = rnd
+		return nil
+	}
+}
+
+// Sampler retains a random sample of up to k values.
+type Sampler[T any] struct {
+	k      int
+	n      uint64
+	weight float64
+	heap   entryHeap[T] // min-heap by key
+	rnd    *rand.Rand
+}
+
+type entry[T any] struct {
+	value T
+	key   float64 // log(u) / weight, which orders like u^(1/weight)
+}
+
+// New inits a new sampler retaining up to k values.
+func New[T any](k int, opts ...Option) (*Sampler[T], error) {
+	if k < 1 {
+		return nil, fmt.Errorf("invalid sample size %d", k)
+	}
+
+	var c config
+	for _, opt := range opts {
+		if err := opt(&c); err != nil {
+			return nil, err
+		}
+	}
+	return &Sampler[T]{k: k, heap: make(entryHeap[T], 0, k), rnd: c.rnd}, nil
+}
+
+// K returns the maximum sample size.
+func (s *Sampler[T]) K() int {
+	return s.k
+}
+
+// N returns the number of values seen, including values of merged samplers.
+func (s *Sampler[T]) N() uint64 {
+	return s.n
+}
+
+// Weight returns the total weight of values seen.
+func (s *Sampler[T]) Weight() float64 {
+	return s.weight
+}
+
+// Add adds a value with weight 1.
+func (s *Sampler[T]) Add(v T) {
+	s.AddWeighted(v, 1)
+}
+
+// AddWeighted adds a value with a weight. Values are retained with a probability
+// proportional to their weight. Values with non-positive weights are ignored.
+func (s *Sampler[T]) AddWeighted(v T, weight float64) {
+	if !(weight > 0) || math.IsInf(weight, 1) {
+		return
+	}
+
+	s.n++
+	s.weight += weight
+	s.offer(entry[T]{value: v, key: math.Log(s.random()) / weight})
+}
+
+// Sample returns the retained values, in no particular order.
+func (s *Sampler[T]) Sample() []T {
+	res := make([]T, len(s.heap))
+	for i, e := range s.heap {
+		res[i] = e.value
+	}
+	return res
+}
+
+// Merge merges other into the sampler. Both samplers must have seen disjoint streams.
+// The result is a sample of the combined stream, as if all values had been added to a
+// single sampler.
+func (s *Sampler[T]) Merge(other *Sampler[T]) {
+	if other == nil {
+		return
+	}
+
+	s.n += other.n
+	s.weight += other.weight
+
+	// offer in descending order of keys, so that fewer entries are replaced
+	entries := append(entryHeap[T](nil), other.heap...)
+	sort.Slice(entries, func(i, j int) bool { return entries[i].key > entries[j].key })
+	for _, e := range entries {
+		s.offer(e)
+	}
+}
+
+// Reset removes all values.
+func (s *Sampler[T]) Reset() {
+	s.n = 0
+	s.weight = 0
+	s.heap = s.heap[:0]
+}
+
+func (s *Sampler[T]) offer(e entry[T]) {
+	if len(s.heap) < s.k {
+		heap.Push(&s.heap, e)
+	} else if e.key > s.heap[0].key {
+		s.heap[0] = e
+		heap.Fix(&s.heap, 0)
+	}
+}
+
+// random returns a number in (0, 1].
+func (s *Sampler[T]) random() float64 {
+	if s.rnd != nil {
+		return 1 - s.rnd.Float64()
+	}
+	return 1 - rand.Float64()
+}
+
+type entryHeap[T any] []entry[T]
+
+func (h entryHeap[T]) Len() int           { return len(h) }
+func (h entryHeap[T]) Less(i, j int) bool { return h[i].key < h[j].key }
+func (h entryHeap[T]) Swap(i, j int)      { h[i], h[j] = h[j], h[i] }
+
+func (h *entryHeap[T]) Push(x interface{}) { *h = append(*h, x.(entry[T])) }
+func (h *entryHeap[T]) Pop() interface{} {
+	old := *h
+	e := old[len(old)-1]
+	*h = old[:len(old)-1]
+	return e
+}
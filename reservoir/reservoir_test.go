@@ -0,0 +1,142 @@
+package reservoir_test
+
+import (
+	"math/rand"
+	"sort"
+	"testing"
+
+	"github.com/gowthamkommineni/zetasketch/reservoir"
+
+	. "github.com/bsm/ginkgo"
+	. "github.com/bsm/gomega"
+)
+
+var _ = Describe("Sampler", func() {
+	var rnd *rand.Rand
+
+	newSampler := func(k int) *reservoir.Sampler[int] {
+		s, err := reservoir.New[int](k, reservoir.WithRand(rnd))
+		Expect(err).NotTo(HaveOccurred())
+		return s
+	}
+
+	BeforeEach(func() {
+		rnd = rand.New(rand.NewSource(1))
+	})
+
+	It("should validate", func() {
+		_, err := reservoir.New[int](0)
+		Expect(err).To(MatchError("invalid sample size 0"))
+		_, err = reservoir.New[int](1, reservoir.WithRand(nil))
+		Expect(err).To(MatchError("invalid random source"))
+	})
+
+	It("should retain all values until full", func() {
+		s := newSampler(5)
+		s.Add(1)
+		s.Add(2)
+		s.AddWeighted(3, 0.5)
+		s.AddWeighted(4, 0)
+
+		Expect(s.K()).To(Equal(5))
+		Expect(s.N()).To(Equal(uint64(3)))
+		Expect(s.Weight()).To(Equal(2.5))
+		Expect(s.Sample()).To(ConsistOf(1, 2, 3))
+
+		s.Reset()
+		Expect(s.N()).To(BeZero())
+		Expect(s.Sample()).To(BeEmpty())
+	})
+
+	It("should sample uniformly", func() {
+		counts := make([]int, 100)
+		for i := 0; i < 10_000; i++ {
+			s := newSampler(10)
+			for v := 0; v < 100; v++ {
+				s.Add(v)
+			}
+			Expect(s.Sample()).To(HaveLen(10))
+			for _, v := range s.Sample() {
+				counts[v]++
+			}
+		}
+		for v, n := range counts {
+			Expect(n).To(BeNumerically("~", 1_000, 150), "value %d", v)
+		}
+	})
+
+	It("should sample proportionally to weights", func() {
+		var heavy int
+		for i := 0; i < 10_000; i++ {
+			s := newSampler(1)
+			s.AddWeighted(1, 3)
+			s.AddWeighted(2, 1)
+			if s.Sample()[0] == 1 {
+				heavy++
+			}
+		}
+		Expect(heavy).To(BeNumerically("~", 7_500, 200))
+	})
+
+	It("should merge", func() {
+		counts := make([]int, 2)
+		for i := 0; i < 10_000; i++ {
+			a, b := newSampler(10), newSampler(10)
+			for v := 0; v < 100; v++ {
+				a.Add(0)
+			}
+			for v := 0; v < 300; v++ {
+				b.Add(1)
+			}
+
+			a.Merge(b)
+			a.Merge(nil)
+			Expect(a.N()).To(Equal(uint64(400)))
+			Expect(a.Sample()).To(HaveLen(10))
+			for _, v := range a.Sample() {
+				counts[v]++
+			}
+		}
+		Expect(counts[0]).To(BeNumerically("~", 25_000, 1_000))
+		Expect(counts[1]).To(BeNumerically("~", 75_000, 1_000))
+	})
+
+	It("should be reproducible", func() {
+		sample := func() []int {
+			rnd = rand.New(rand.NewSource(33))
+			s := newSampler(5)
+			for v := 0; v < 1_000; v++ {
+				s.Add(v)
+			}
+			res := s.Sample()
+			sort.Ints(res)
+			return res
+		}
+		Expect(sample()).To(Equal(sample()))
+	})
+
+	It("should sample any type", func() {
+		s, err := reservoir.New[string](2)
+		Expect(err).NotTo(HaveOccurred())
+		s.Add("foo")
+		Expect(s.Sample()).To(Equal([]string{"foo"}))
+	})
+})
+
+func TestSuite(t *testing.T) {
+	RegisterFailHandler(Fail)
+	RunSpecs(t, "zetasketch/reservoir")
+}
+
+func BenchmarkSampler_Add(b *testing.B) {
+	s, err := reservoir.New[int](100)
+	if err != nil {
+		b.Fatal(err)
+	}
+
+	b.ReportAllocs()
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		s.Add(i)
+	}
+}
@@ -0,0 +1,152 @@
+package tdigest
+
+import (
+	"encoding/binary"
+	"fmt"
+	"math"
+)
+
+// Encodings of the reference MergingDigest, all numbers are big-endian.
+const (
+	verboseEncoding = 1
+	smallEncoding   = 2
+
+	verboseHeaderSize = 4 + 8 + 8 + 8 + 4
+	smallHeaderSize   = 4 + 8 + 8 + 4 + 2 + 2 + 2
+)
+
+// Marshal serializes the digest in the verbose encoding of the reference MergingDigest:
+// the encoding as int32, min, max and compression as float64, the number of centroids as
+// int32, followed by the weight and mean of each centroid as float64.
+func (d *Digest) Marshal() ([]byte, error) {
+	d.flush()
+
+	data := make([]byte, verboseHeaderSize+len(d.centroids)*16)
+	binary.BigEndian.PutUint32(data, verboseEncoding)
+	binary.BigEndian.PutUint64(data[4:], math.Float64bits(d.min))
+	binary.BigEndian.PutUint64(data[12:], math.Float64bits(d.max))
+	binary.BigEndian.PutUint64(data[20:], math.Float64bits(d.compression))
+	binary.BigEndian.PutUint32(data[28:], uint32(len(d.centroids)))
+
+	buf := data[verboseHeaderSize:]
+	for i, c := range d.centroids {
+		binary.BigEndian.PutUint64(buf[i*16:], math.Float64bits(c.weight))
+		binary.BigEndian.PutUint64(buf[i*16+8:], math.Float64bits(c.mean))
+	}
+	return data, nil
+}
+
+// MarshalSmall serializes the digest in the small encoding of the reference
+// MergingDigest, which stores weights and means as float32 and loses precision.
+func (d *Digest) MarshalSmall() ([]byte, error) {
+	d.flush()
+	if len(d.centroids) > math.MaxInt16 {
+		return nil, fmt.Errorf("cannot marshal %d centroids in small encoding", len(d.centroids))
+	}
+
+	data := make([]byte, smallHeaderSize+len(d.centroids)*8)
+	binary.BigEndian.PutUint32(data, smallEncoding)
+	binary.BigEndian.PutUint64(data[4:], math.Float64bits(d.min))
+	binary.BigEndian.PutUint64(data[12:], math.Float64bits(d.max))
+	binary.BigEndian.PutUint32(data[20:], math.Float32bits(float32(d.compression)))
+	binary.BigEndian.PutUint16(data[24:], uint16(2*d.compression))           // size of the centroid arrays
+	binary.BigEndian.PutUint16(data[26:], uint16(bufferSize(d.compression))) // size of the buffer
+	binary.BigEndian.PutUint16(data[28:], uint16(len(d.centroids)))
+
+	buf := data[smallHeaderSize:]
+	for i, c := range d.centroids {
+		binary.BigEndian.PutUint32(buf[i*8:], math.Float32bits(float32(c.weight)))
+		binary.BigEndian.PutUint32(buf[i*8+4:], math.Float32bits(float32(c.mean)))
+	}
+	return data, nil
+}
+
+// Unmarshal restores the digest from the verbose or small encoding of the reference
+// MergingDigest, replacing its current state, including the compression.
+func (d *Digest) Unmarshal(data []byte) error {
+	if len(data) < 4 {
+		return fmt.Errorf("invalid t-digest: too short")
+	}
+
+	var (
+		res       *Digest
+		centroids []centroid
+		err       error
+	)
+	switch enc := binary.BigEndian.Uint32(data); enc {
+	case verboseEncoding:
+		if len(data) < verboseHeaderSize {
+			return fmt.Errorf("invalid t-digest: too short")
+		}
+		if res, err = newFromHeader(data, math.Float64frombits(binary.BigEndian.Uint64(data[20:]))); err != nil {
+			return err
+		}
+		n := int(binary.BigEndian.Uint32(data[28:]))
+		if buf := data[verboseHeaderSize:]; len(buf) != n*16 {
+			return fmt.Errorf("invalid t-digest: %d bytes of centroids, expected %d", len(buf), n*16)
+		}
+		centroids = make([]centroid, n)
+		for i, buf := 0, data[verboseHeaderSize:]; i < n; i, buf = i+1, buf[16:] {
+			centroids[i] = centroid{
+				weight: math.Float64frombits(binary.BigEndian.Uint64(buf)),
+				mean:   math.Float64frombits(binary.BigEndian.Uint64(buf[8:])),
+			}
+		}
+	case smallEncoding:
+		if len(data) < smallHeaderSize {
+			return fmt.Errorf("invalid t-digest: too short")
+		}
+		if res, err = newFromHeader(data, float64(math.Float32frombits(binary.BigEndian.Uint32(data[20:])))); err != nil {
+			return err
+		}
+		n := int(binary.BigEndian.Uint16(data[28:]))
+		if buf := data[smallHeaderSize:]; len(buf) != n*8 {
+			return fmt.Errorf("invalid t-digest: %d bytes of centroids, expected %d", len(buf), n*8)
+		}
+		centroids = make([]centroid, n)
+		for i, buf := 0, data[smallHeaderSize:]; i < n; i, buf = i+1, buf[8:] {
+			centroids[i] = centroid{
+				weight: float64(math.Float32frombits(binary.BigEndian.Uint32(buf))),
+				mean:   float64(math.Float32frombits(binary.BigEndian.Uint32(buf[4:]))),
+			}
+		}
+	default:
+		return fmt.Errorf("invalid t-digest: unsupported encoding %d", enc)
+	}
+
+	for i, c := range centroids {
+		if !(c.weight > 0) || math.IsNaN(c.mean) || (i > 0 && c.mean < centroids[i-1].mean) {
+			return fmt.Errorf("invalid t-digest: invalid centroid %d", i)
+		}
+		res.weight += c.weight
+	}
+	if len(centroids) != 0 {
+		res.centroids = centroids
+	} else {
+		res.min, res.max = math.Inf(1), math.Inf(-1)
+	}
+
+	*d = *res
+	return nil
+}
+
+// newFromHeader inits a digest with the compression and extremes of a serialized one.
+func newFromHeader(data []byte, compression float64) (*Digest, error) {
+	res, err := New(compression)
+	if err != nil {
+		return nil, fmt.Errorf("invalid t-digest: %w", err)
+	}
+	res.min = math.Float64frombits(binary.BigEndian.Uint64(data[4:]))
+	res.max = math.Float64frombits(binary.BigEndian.Uint64(data[12:]))
+	return res, nil
+}
+
+// MarshalBinary implements encoding.BinaryMarshaler using the same format as Marshal.
+func (d *Digest) MarshalBinary() ([]byte, error) {
+	return d.Marshal()
+}
+
+// UnmarshalBinary implements encoding.BinaryUnmarshaler using the same format as Unmarshal.
+func (d *Digest) UnmarshalBinary(data []byte) error {
+	return d.Unmarshal(data)
+}
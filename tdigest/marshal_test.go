@@ -0,0 +1,119 @@
+package tdigest_test
+
+import (
+	"math"
+
+	"github.com/gowthamkommineni/zetasketch/tdigest"
+
+	. "github.com/bsm/ginkgo"
+	. "github.com/bsm/gomega"
+)
+
+var _ = Describe("Digest (marshal)", func() {
+	var subject *tdigest.Digest
+
+	BeforeEach(func() {
+		var err error
+		subject, err = tdigest.New(tdigest.DefaultCompression)
+		Expect(err).NotTo(HaveOccurred())
+	})
+
+	It("should marshal empty digests", func() {
+		Expect(subject.Marshal()).To(Equal([]byte{
+			0, 0, 0, 1, // verbose encoding
+			0x7f, 0xf0, 0, 0, 0, 0, 0, 0, // min: +Inf
+			0xff, 0xf0, 0, 0, 0, 0, 0, 0, // max: -Inf
+			0x40, 0x59, 0, 0, 0, 0, 0, 0, // compression: 100
+			0, 0, 0, 0, // centroids
+		}))
+
+		res := new(tdigest.Digest)
+		data, err := subject.Marshal()
+		Expect(err).NotTo(HaveOccurred())
+		Expect(res.Unmarshal(data)).To(Succeed())
+		Expect(res.IsEmpty()).To(BeTrue())
+		Expect(res.Compression()).To(Equal(100.0))
+	})
+
+	It("should marshal", func() {
+		subject.Add(2)
+		subject.AddWeighted(1, 3)
+
+		Expect(subject.Marshal()).To(Equal([]byte{
+			0, 0, 0, 1, // verbose encoding
+			0x3f, 0xf0, 0, 0, 0, 0, 0, 0, // min: 1
+			0x40, 0, 0, 0, 0, 0, 0, 0, // max: 2
+			0x40, 0x59, 0, 0, 0, 0, 0, 0, // compression: 100
+			0, 0, 0, 2, // centroids
+			0x40, 0x08, 0, 0, 0, 0, 0, 0, // weight: 3
+			0x3f, 0xf0, 0, 0, 0, 0, 0, 0, // mean: 1
+			0x3f, 0xf0, 0, 0, 0, 0, 0, 0, // weight: 1
+			0x40, 0, 0, 0, 0, 0, 0, 0, // mean: 2
+		}))
+
+		Expect(subject.MarshalSmall()).To(Equal([]byte{
+			0, 0, 0, 2, // small encoding
+			0x3f, 0xf0, 0, 0, 0, 0, 0, 0, // min: 1
+			0x40, 0, 0, 0, 0, 0, 0, 0, // max: 2
+			0x42, 0xc8, 0, 0, // compression: 100
+			0, 200, 0x01, 0xf4, 0, 2, // centroid array size, buffer size, centroids
+			0x40, 0x40, 0, 0, 0x3f, 0x80, 0, 0, // weight: 3, mean: 1
+			0x3f, 0x80, 0, 0, 0x40, 0, 0, 0, // weight: 1, mean: 2
+		}))
+	})
+
+	It("should round trip", func() {
+		for i := 0; i < 100_000; i++ {
+			subject.Add(math.Sqrt(float64(i)))
+		}
+
+		data, err := subject.MarshalBinary()
+		Expect(err).NotTo(HaveOccurred())
+
+		res, err := tdigest.New(50)
+		Expect(err).NotTo(HaveOccurred())
+		Expect(res.UnmarshalBinary(data)).To(Succeed())
+		Expect(res.Compression()).To(Equal(100.0))
+		Expect(res.Count()).To(Equal(subject.Count()))
+		Expect(res.NumCentroids()).To(Equal(subject.NumCentroids()))
+		Expect(res.Min()).To(Equal(subject.Min()))
+		Expect(res.Max()).To(Equal(subject.Max()))
+		for _, q := range []float64{0.01, 0.5, 0.99} {
+			Expect(res.Quantile(q)).To(Equal(subject.Quantile(q)))
+		}
+		Expect(res.Marshal()).To(Equal(data))
+
+		small, err := subject.MarshalSmall()
+		Expect(err).NotTo(HaveOccurred())
+		Expect(res.Unmarshal(small)).To(Succeed())
+		Expect(res.Count()).To(Equal(subject.Count()))
+		Expect(res.Quantile(0.5)).To(BeNumerically("~", subject.Quantile(0.5), 0.01))
+
+		// restored digests can be updated and merged
+		res.Add(0)
+		res.Merge(subject)
+		Expect(res.Count()).To(Equal(200_001.0))
+	})
+
+	It("should reject invalid data", func() {
+		data, err := subject.Marshal()
+		Expect(err).NotTo(HaveOccurred())
+
+		Expect(subject.Unmarshal(nil)).To(MatchError("invalid t-digest: too short"))
+		Expect(subject.Unmarshal([]byte{0, 0, 0, 3})).To(MatchError("invalid t-digest: unsupported encoding 3"))
+		Expect(subject.Unmarshal(data[:20])).To(MatchError("invalid t-digest: too short"))
+		Expect(subject.Unmarshal(append(data, 0))).To(MatchError("invalid t-digest: 1 bytes of centroids, expected 0"))
+
+		bad := append([]byte{}, data...)
+		bad[21] = 0x14 // compression: 5
+		Expect(subject.Unmarshal(bad)).To(MatchError("invalid t-digest: invalid compression 5"))
+
+		subject.Add(1)
+		subject.Add(2)
+		data, err = subject.Marshal()
+		Expect(err).NotTo(HaveOccurred())
+		copy(data[32:], data[48:64]) // duplicate the second centroid
+		copy(data[48:], make([]byte, 8))
+		Expect(subject.Unmarshal(data)).To(MatchError("invalid t-digest: invalid centroid 1"))
+	})
+})
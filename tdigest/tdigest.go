@@ -0,0 +1,238 @@
+// Package tdigest implements the merging t-digest, as described in "Computing Extremely
+// Accurate Quantiles Using t-Digests" (Dunning, Ertl, 2019). Digests are accurate at the
+// tails of a distribution, which makes them well suited for latency percentiles.
+//
+// Digests are serialized in the format of MergingDigest of the reference Java library
+// https://github.com/tdunning/t-digest.
+package tdigest
+
+import (
+	"fmt"
+	"math"
+	"sort"
+)
+
+// DefaultCompression is the default compression, which bounds the number of centroids.
+const DefaultCompression = 100
+
+// MinCompression is the minimum compression.
+const MinCompression = 10
+
+// Digest is a merging t-digest. Values are buffered and merged into centroids once the
+// buffer is full, or when the digest is queried.
+type Digest struct {
+	compression float64
+	centroids   []centroid // sorted by mean
+	weight      float64    // total weight of centroids
+	buffer      []centroid
+	min, max    float64
+}
+
+type centroid struct {
+	mean, weight float64
+}
+
+// New inits a new digest. Higher compressions increase accuracy and size, a digest
+// retains at most about 2*compression centroids.
+func New(compression float64) (*Digest, error) {
+	if !(compression >= MinCompression && compression <= math.MaxInt16) {
+		return nil, fmt.Errorf("invalid compression %v", compression)
+	}
+	return &Digest{
+		compression: compression,
+		buffer:      make([]centroid, 0, bufferSize(compression)),
+		min:         math.Inf(1),
+		max:         math.Inf(-1),
+	}, nil
+}
+
+func bufferSize(compression float64) int {
+	return int(5 * compression)
+}
+
+// Compression returns the compression.
+func (d *Digest) Compression() float64 {
+	return d.compression
+}
+
+// Count returns the total weight of all values added.
+func (d *Digest) Count() float64 {
+	var n float64
+	for _, c := range d.buffer {
+		n += c.weight
+	}
+	return d.weight + n
+}
+
+// IsEmpty returns true if no values have been added.
+func (d *Digest) IsEmpty() bool {
+	return len(d.centroids) == 0 && len(d.buffer) == 0
+}
+
+// Min returns the smallest value added, or NaN if the digest is empty.
+func (d *Digest) Min() float64 {
+	if d.IsEmpty() {
+		return math.NaN()
+	}
+	return d.min
+}
+
+// Max returns the largest value added, or NaN if the digest is empty.
+func (d *Digest) Max() float64 {
+	if d.IsEmpty() {
+		return math.NaN()
+	}
+	return d.max
+}
+
+// NumCentroids returns the number of centroids after merging buffered values.
+func (d *Digest) NumCentroids() int {
+	d.flush()
+	return len(d.centroids)
+}
+
+// Add adds a value with weight 1. NaN values are ignored.
+func (d *Digest) Add(v float64) {
+	d.AddWeighted(v, 1)
+}
+
+// AddWeighted adds a value with a weight. NaN values and non-positive weights are
+// ignored.
+func (d *Digest) AddWeighted(v, weight float64) {
+	if math.IsNaN(v) || !(weight > 0) {
+		return
+	}
+
+	if v < d.min {
+		d.min = v
+	}
+	if v > d.max {
+		d.max = v
+	}
+	d.buffer = append(d.buffer, centroid{mean: v, weight: weight})
+	if len(d.buffer) >= bufferSize(d.compression) {
+		d.flush()
+	}
+}
+
+// Merge merges other into the digest.
+func (d *Digest) Merge(other *Digest) {
+	if other == nil || other.IsEmpty() {
+		return
+	}
+
+	if other.min < d.min {
+		d.min = other.min
+	}
+	if other.max > d.max {
+		d.max = other.max
+	}
+	d.buffer = append(d.buffer, other.centroids...)
+	d.buffer = append(d.buffer, other.buffer...)
+	d.flush()
+}
+
+// Quantile returns the approximate value at quantile q, which must be between 0 and 1,
+// or NaN if the digest is empty.
+func (d *Digest) Quantile(q float64) float64 {
+	d.flush()
+	if len(d.centroids) == 0 || !(q >= 0 && q <= 1) {
+		return math.NaN()
+	}
+
+	// interpolate linearly between the centers of centroids, and the extremes
+	target := q * d.weight
+	lo, loPos := d.min, 0.0
+	pos := 0.0
+	for _, c := range d.centroids {
+		mid := pos + c.weight/2
+		if target < mid {
+			return interpolate(target, loPos, mid, lo, c.mean)
+		}
+		lo, loPos = c.mean, mid
+		pos += c.weight
+	}
+	return interpolate(target, loPos, d.weight, lo, d.max)
+}
+
+// CDF returns the approximate fraction of values less than or equal to x, or NaN if the
+// digest is empty.
+func (d *Digest) CDF(x float64) float64 {
+	d.flush()
+	if len(d.centroids) == 0 || math.IsNaN(x) {
+		return math.NaN()
+	}
+	if x < d.min {
+		return 0
+	}
+	if x >= d.max {
+		return 1
+	}
+
+	lo, loPos := d.min, 0.0
+	pos := 0.0
+	for _, c := range d.centroids {
+		mid := pos + c.weight/2
+		if x < c.mean {
+			return interpolate(x, lo, c.mean, loPos, mid) / d.weight
+		}
+		lo, loPos = c.mean, mid
+		pos += c.weight
+	}
+	return interpolate(x, lo, d.max, loPos, d.weight) / d.weight
+}
+
+// flush merges buffered values into the centroids.
+func (d *Digest) flush() {
+	if len(d.buffer) == 0 {
+		return
+	}
+
+	all := append(d.buffer, d.centroids...)
+	sort.Slice(all, func(i, j int) bool { return all[i].mean < all[j].mean })
+
+	var total float64
+	for _, c := range all {
+		total += c.weight
+	}
+
+	// merge neighbours as long as the k1 scale function grows by at most 1
+	merged := make([]centroid, 0, int(2*d.compression)+1)
+	cur := all[0]
+	var weightSoFar float64
+	limit := total * d.qLimit(0)
+	for _, c := range all[1:] {
+		if weightSoFar+cur.weight+c.weight <= limit {
+			cur.weight += c.weight
+			cur.mean += (c.mean - cur.mean) * c.weight / cur.weight
+			continue
+		}
+		weightSoFar += cur.weight
+		merged = append(merged, cur)
+		cur = c
+		limit = total * d.qLimit(weightSoFar/total)
+	}
+	merged = append(merged, cur)
+
+	d.centroids = merged
+	d.weight = total
+	d.buffer = d.buffer[:0]
+}
+
+// qLimit returns the largest quantile which can be merged into a centroid starting at
+// quantile q, using the scale function k1(q) = compression/(2π) * asin(2q-1).
+func (d *Digest) qLimit(q float64) float64 {
+	k := d.compression/(2*math.Pi)*math.Asin(2*q-1) + 1
+	if k >= d.compression/4 {
+		return 1
+	}
+	return (math.Sin(k*2*math.Pi/d.compression) + 1) / 2
+}
+
+// interpolate maps x from [x0, x1] to [y0, y1].
+func interpolate(x, x0, x1, y0, y1 float64) float64 {
+	if x1 <= x0 {
+		return y1
+	}
+	return y0 + (x-x0)/(x1-x0)*(y1-y0)
+}
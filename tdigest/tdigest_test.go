@@ -0,0 +1,122 @@
+package tdigest_test
+
+import (
+	"math"
+	"math/rand"
+	"sort"
+	"testing"
+
+	"github.com/gowthamkommineni/zetasketch/tdigest"
+
+	. "github.com/bsm/ginkgo"
+	. "github.com/bsm/gomega"
+)
+
+var _ = Describe("Digest", func() {
+	var subject *tdigest.Digest
+
+	BeforeEach(func() {
+		var err error
+		subject, err = tdigest.New(tdigest.DefaultCompression)
+		Expect(err).NotTo(HaveOccurred())
+	})
+
+	It("should validate compression", func() {
+		_, err := tdigest.New(5)
+		Expect(err).To(MatchError("invalid compression 5"))
+		_, err = tdigest.New(math.NaN())
+		Expect(err).To(MatchError("invalid compression NaN"))
+	})
+
+	It("should handle empty digests", func() {
+		Expect(subject.IsEmpty()).To(BeTrue())
+		Expect(subject.Count()).To(BeZero())
+		Expect(math.IsNaN(subject.Min())).To(BeTrue())
+		Expect(math.IsNaN(subject.Quantile(0.5))).To(BeTrue())
+		Expect(math.IsNaN(subject.CDF(1))).To(BeTrue())
+	})
+
+	It("should ignore NaN and non-positive weights", func() {
+		subject.Add(math.NaN())
+		subject.AddWeighted(1, 0)
+		subject.AddWeighted(1, -1)
+		Expect(subject.IsEmpty()).To(BeTrue())
+	})
+
+	It("should be exact for few values", func() {
+		subject.Add(3)
+		subject.Add(1)
+		subject.AddWeighted(2, 2)
+
+		Expect(subject.Count()).To(Equal(4.0))
+		Expect(subject.NumCentroids()).To(Equal(3))
+		Expect(subject.Min()).To(Equal(1.0))
+		Expect(subject.Max()).To(Equal(3.0))
+		Expect(subject.Quantile(0)).To(Equal(1.0))
+		Expect(subject.Quantile(0.5)).To(Equal(2.0))
+		Expect(subject.Quantile(1)).To(Equal(3.0))
+		Expect(math.IsNaN(subject.Quantile(1.5))).To(BeTrue())
+		Expect(subject.CDF(0)).To(Equal(0.0))
+		Expect(subject.CDF(2)).To(Equal(0.5))
+		Expect(subject.CDF(3)).To(Equal(1.0))
+	})
+
+	It("should estimate quantiles of heavy-tailed distributions", func() {
+		rnd := rand.New(rand.NewSource(1))
+		values := make([]float64, 1_000_000)
+		for i := range values {
+			values[i] = math.Exp(rnd.NormFloat64() * 2) // log-normal
+			subject.Add(values[i])
+		}
+		sort.Float64s(values)
+
+		Expect(subject.Count()).To(Equal(1_000_000.0))
+		Expect(subject.NumCentroids()).To(BeNumerically("<=", 2*tdigest.DefaultCompression))
+		Expect(subject.Min()).To(Equal(values[0]))
+		Expect(subject.Max()).To(Equal(values[len(values)-1]))
+
+		for _, q := range []float64{0.001, 0.01, 0.1, 0.5, 0.9, 0.99, 0.999} {
+			// compare ranks, the tails are accurate to a fraction of their distance to 0 or 1
+			est := subject.Quantile(q)
+			rank := float64(sort.SearchFloat64s(values, est)) / float64(len(values))
+			Expect(rank).To(BeNumerically("~", q, 0.01*math.Min(q, 1-q)+0.0005), "quantile %v", q)
+			Expect(subject.CDF(values[int(q*float64(len(values)))])).To(BeNumerically("~", q, 0.01*math.Min(q, 1-q)+0.0005), "CDF %v", q)
+		}
+	})
+
+	It("should merge", func() {
+		other, err := tdigest.New(tdigest.DefaultCompression)
+		Expect(err).NotTo(HaveOccurred())
+		for i := 0; i < 50_000; i++ {
+			subject.Add(float64(i))
+			other.Add(float64(50_000 + i))
+		}
+
+		subject.Merge(other)
+		subject.Merge(nil)
+		Expect(subject.Count()).To(Equal(100_000.0))
+		Expect(subject.Min()).To(Equal(0.0))
+		Expect(subject.Max()).To(Equal(99_999.0))
+		Expect(subject.Quantile(0.5)).To(BeNumerically("~", 50_000, 500))
+		Expect(subject.Quantile(0.99)).To(BeNumerically("~", 99_000, 100))
+		Expect(subject.CDF(25_000)).To(BeNumerically("~", 0.25, 0.005))
+	})
+})
+
+func TestSuite(t *testing.T) {
+	RegisterFailHandler(Fail)
+	RunSpecs(t, "zetasketch/tdigest")
+}
+
+func BenchmarkDigest_Add(b *testing.B) {
+	d, err := tdigest.New(tdigest.DefaultCompression)
+	if err != nil {
+		b.Fatal(err)
+	}
+
+	b.ReportAllocs()
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		d.Add(float64(i))
+	}
+}
@@ -0,0 +1,63 @@
+package theta
+
+import "sort"
+
+// CompactSketch is an immutable Theta sketch, which retains its hashes in ascending
+// order. It is the result of set operations and the most efficient input to them.
+type CompactSketch struct {
+	empty    bool
+	hashSeed uint32
+	theta    uint64
+	hashes   []uint64
+}
+
+// newCompact sorts hashes and returns a compact sketch. It takes ownership of hashes.
+func newCompact(empty bool, seed uint32, theta uint64, hashes []uint64) *CompactSketch {
+	sort.Slice(hashes, func(i, j int) bool { return hashes[i] < hashes[j] })
+
+	// sketches without any retained hashes and theta are treated as empty, like in DataSketches
+	if len(hashes) == 0 && theta == maxTheta {
+		empty = true
+	}
+	return &CompactSketch{empty: empty, hashSeed: seed, theta: theta, hashes: hashes}
+}
+
+// IsEmpty implements Sketch.
+func (s *CompactSketch) IsEmpty() bool {
+	return s.empty
+}
+
+// Estimate implements Sketch.
+func (s *CompactSketch) Estimate() float64 {
+	return estimate(len(s.hashes), s.theta)
+}
+
+// Theta implements Sketch.
+func (s *CompactSketch) Theta() float64 {
+	return float64(s.theta) / maxTheta
+}
+
+// NumRetained implements Sketch.
+func (s *CompactSketch) NumRetained() int {
+	return len(s.hashes)
+}
+
+// Compact implements Sketch. It returns the sketch itself.
+func (s *CompactSketch) Compact() *CompactSketch {
+	return s
+}
+
+func (s *CompactSketch) seed() uint32      { return s.hashSeed }
+func (s *CompactSketch) thetaLong() uint64 { return s.theta }
+
+func (s *CompactSketch) forEach(fn func(uint64)) {
+	for _, h := range s.hashes {
+		fn(h)
+	}
+}
+
+// contains returns true if h is retained.
+func (s *CompactSketch) contains(h uint64) bool {
+	i := sort.Search(len(s.hashes), func(i int) bool { return s.hashes[i] >= h })
+	return i < len(s.hashes) && s.hashes[i] == h
+}
@@ -0,0 +1,153 @@
+package theta
+
+import "fmt"
+
+// Union computes the union of sketches. It retains up to k hashes, where k is the
+// nominal number of entries it was created with.
+type Union struct {
+	gadget *UpdateSketch
+	theta  uint64
+}
+
+// NewUnion inits a new union with a nominal number of 2^lgK entries, see NewUpdate.
+func NewUnion(lgK uint8, opts ...Option) (*Union, error) {
+	gadget, err := NewUpdate(lgK, opts...)
+	if err != nil {
+		return nil, err
+	}
+	return &Union{gadget: gadget, theta: maxTheta}, nil
+}
+
+// Update adds a sketch to the union.
+func (u *Union) Update(s Sketch) error {
+	if err := checkSeeds(u.gadget, s); err != nil {
+		return err
+	}
+	if s.IsEmpty() {
+		return nil
+	}
+
+	if t := s.thetaLong(); t < u.theta {
+		u.theta = t
+	}
+	s.forEach(func(h uint64) {
+		if h < u.theta {
+			u.gadget.insert(h)
+		}
+	})
+	u.gadget.empty = false
+	return nil
+}
+
+// Result returns the union of all sketches added so far.
+func (u *Union) Result() *CompactSketch {
+	theta := u.theta
+	if u.gadget.theta < theta {
+		theta = u.gadget.theta
+	}
+
+	hashes := make([]uint64, 0, u.gadget.count)
+	u.gadget.forEach(func(h uint64) {
+		if h < theta {
+			hashes = append(hashes, h)
+		}
+	})
+
+	// retain at most k hashes
+	if k := 1 << u.gadget.lgK; len(hashes) > k {
+		theta = selectKth(hashes, k)
+		hashes = hashes[:k]
+	}
+	return newCompact(u.gadget.empty, u.gadget.hashSeed, theta, hashes)
+}
+
+// Reset resets the union to its initial state.
+func (u *Union) Reset() {
+	u.gadget.Reset()
+	u.theta = maxTheta
+}
+
+// Intersection computes the intersection of sketches.
+type Intersection struct {
+	hashSeed uint32
+	valid    bool // at least one sketch was added
+	result   *CompactSketch
+}
+
+// NewIntersection inits a new intersection.
+func NewIntersection(opts ...Option) (*Intersection, error) {
+	tmpl, err := NewUpdate(MinLgK, opts...)
+	if err != nil {
+		return nil, err
+	}
+	return &Intersection{hashSeed: tmpl.hashSeed}, nil
+}
+
+// Update intersects the current result with a sketch.
+func (x *Intersection) Update(s Sketch) error {
+	if s.seed() != x.hashSeed {
+		return fmt.Errorf("cannot combine sketches with different seeds %d and %d", x.hashSeed, s.seed())
+	}
+
+	if !x.valid {
+		x.valid = true
+		x.result = copyCompact(s.Compact())
+		return nil
+	}
+
+	theta := x.result.theta
+	if t := s.thetaLong(); t < theta {
+		theta = t
+	}
+
+	other := s.Compact()
+	hashes := x.result.hashes[:0]
+	for _, h := range x.result.hashes {
+		if h < theta && other.contains(h) {
+			hashes = append(hashes, h)
+		}
+	}
+	x.result = newCompact(x.result.empty || other.empty, x.hashSeed, theta, hashes)
+	return nil
+}
+
+// HasResult returns true if at least one sketch was added.
+func (x *Intersection) HasResult() bool {
+	return x.valid
+}
+
+// Result returns the intersection of all sketches added so far. The intersection of
+// no sketches is undefined, which is reported as an error.
+func (x *Intersection) Result() (*CompactSketch, error) {
+	if !x.valid {
+		return nil, fmt.Errorf("intersection has no input")
+	}
+	return copyCompact(x.result), nil
+}
+
+// AnotB returns the sketch of all values in a which are not in b.
+func AnotB(a, b Sketch) (*CompactSketch, error) {
+	if err := checkSeeds(a, b); err != nil {
+		return nil, err
+	}
+
+	theta := a.thetaLong()
+	if t := b.thetaLong(); t < theta {
+		theta = t
+	}
+
+	other := b.Compact()
+	hashes := make([]uint64, 0, a.NumRetained())
+	a.forEach(func(h uint64) {
+		if h < theta && !other.contains(h) {
+			hashes = append(hashes, h)
+		}
+	})
+	return newCompact(a.IsEmpty(), a.seed(), theta, hashes), nil
+}
+
+func copyCompact(s *CompactSketch) *CompactSketch {
+	hashes := make([]uint64, len(s.hashes))
+	copy(hashes, s.hashes)
+	return &CompactSketch{empty: s.empty, hashSeed: s.hashSeed, theta: s.theta, hashes: hashes}
+}
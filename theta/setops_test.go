@@ -0,0 +1,111 @@
+package theta_test
+
+import (
+	"github.com/gowthamkommineni/zetasketch/theta"
+
+	. "github.com/bsm/ginkgo"
+	. "github.com/bsm/gomega"
+)
+
+var _ = Describe("set operations", func() {
+	var a, b, empty *theta.UpdateSketch
+
+	newSketch := func(lo, hi int) *theta.UpdateSketch {
+		s, err := theta.NewUpdate(theta.DefaultLgK)
+		Expect(err).NotTo(HaveOccurred())
+		for i := lo; i < hi; i++ {
+			s.AddInt64(int64(i))
+		}
+		return s
+	}
+
+	BeforeEach(func() {
+		a = newSketch(0, 100_000)      // 100k
+		b = newSketch(50_000, 200_000) // 150k, 50k overlap
+		empty = newSketch(0, 0)
+	})
+
+	It("should union", func() {
+		u, err := theta.NewUnion(theta.DefaultLgK)
+		Expect(err).NotTo(HaveOccurred())
+		Expect(u.Result().IsEmpty()).To(BeTrue())
+
+		Expect(u.Update(a)).To(Succeed())
+		Expect(u.Update(b.Compact())).To(Succeed())
+		Expect(u.Update(empty)).To(Succeed())
+
+		res := u.Result()
+		Expect(res.IsEmpty()).To(BeFalse())
+		Expect(res.NumRetained()).To(Equal(1 << theta.DefaultLgK))
+		Expect(res.Estimate()).To(BeNumerically("~", 200_000, 10_000))
+
+		u.Reset()
+		Expect(u.Result().IsEmpty()).To(BeTrue())
+	})
+
+	It("should union exactly in exact mode", func() {
+		u, err := theta.NewUnion(theta.DefaultLgK)
+		Expect(err).NotTo(HaveOccurred())
+		Expect(u.Update(newSketch(0, 100))).To(Succeed())
+		Expect(u.Update(newSketch(50, 150))).To(Succeed())
+		Expect(u.Result().Estimate()).To(Equal(150.0))
+	})
+
+	It("should intersect", func() {
+		x, err := theta.NewIntersection()
+		Expect(err).NotTo(HaveOccurred())
+		Expect(x.HasResult()).To(BeFalse())
+		_, err = x.Result()
+		Expect(err).To(MatchError("intersection has no input"))
+
+		Expect(x.Update(a)).To(Succeed())
+		Expect(x.Update(b)).To(Succeed())
+		Expect(x.HasResult()).To(BeTrue())
+
+		res, err := x.Result()
+		Expect(err).NotTo(HaveOccurred())
+		Expect(res.IsEmpty()).To(BeFalse())
+		Expect(res.Estimate()).To(BeNumerically("~", 50_000, 5_000))
+
+		// intersecting with an empty sketch yields an empty result
+		Expect(x.Update(empty)).To(Succeed())
+		res, err = x.Result()
+		Expect(err).NotTo(HaveOccurred())
+		Expect(res.IsEmpty()).To(BeTrue())
+		Expect(res.Estimate()).To(Equal(0.0))
+	})
+
+	It("should compute differences", func() {
+		res, err := theta.AnotB(a, b)
+		Expect(err).NotTo(HaveOccurred())
+		Expect(res.Estimate()).To(BeNumerically("~", 50_000, 5_000))
+
+		res, err = theta.AnotB(b.Compact(), a.Compact())
+		Expect(err).NotTo(HaveOccurred())
+		Expect(res.Estimate()).To(BeNumerically("~", 100_000, 10_000))
+
+		res, err = theta.AnotB(a, empty)
+		Expect(err).NotTo(HaveOccurred())
+		Expect(res.Estimate()).To(Equal(a.Estimate()))
+
+		res, err = theta.AnotB(empty, a)
+		Expect(err).NotTo(HaveOccurred())
+		Expect(res.IsEmpty()).To(BeTrue())
+	})
+
+	It("should reject sketches with different seeds", func() {
+		seeded, err := theta.NewUpdate(theta.DefaultLgK, theta.WithSeed(1))
+		Expect(err).NotTo(HaveOccurred())
+
+		u, err := theta.NewUnion(theta.DefaultLgK)
+		Expect(err).NotTo(HaveOccurred())
+		Expect(u.Update(seeded)).To(MatchError("cannot combine sketches with different seeds 9001 and 1"))
+
+		x, err := theta.NewIntersection()
+		Expect(err).NotTo(HaveOccurred())
+		Expect(x.Update(seeded)).To(MatchError("cannot combine sketches with different seeds 9001 and 1"))
+
+		_, err = theta.AnotB(a, seeded)
+		Expect(err).To(MatchError("cannot combine sketches with different seeds 9001 and 1"))
+	})
+})
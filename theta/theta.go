@@ -0,0 +1,88 @@
+// Package theta implements Theta sketches for distinct counting with set operations,
+// following the design of the Apache DataSketches Theta sketch. Unlike HyperLogLog,
+// Theta sketches support unions, intersections and differences with errors bounded
+// by the sizes of the inputs rather than the result.
+//
+// Values are hashed like in DataSketches, using the 128-bit MurmurHash3 with seed 9001,
+// so the same values sample the same hashes as sketches built by the Java and C++
+// libraries.
+package theta
+
+import (
+	"encoding/binary"
+	"fmt"
+	"math"
+
+	"github.com/spaolacci/murmur3"
+)
+
+// Parameter limits and defaults.
+const (
+	// DefaultLgK is the default log2 of the nominal number of entries, which results in
+	// a relative standard error of about 1.6%.
+	DefaultLgK = 12
+	// MinLgK is the minimum log2 of the nominal number of entries.
+	MinLgK = 4
+	// MaxLgK is the maximum log2 of the nominal number of entries.
+	MaxLgK = 26
+
+	// DefaultSeed is the default hash seed of DataSketches.
+	DefaultSeed = 9001
+)
+
+// maxTheta is the initial threshold, hashes are limited to 63 bits.
+const maxTheta = math.MaxInt64
+
+// Sketch is implemented by UpdateSketch and CompactSketch.
+type Sketch interface {
+	// IsEmpty returns true if no values have been added.
+	IsEmpty() bool
+	// Estimate returns the estimated number of distinct values.
+	Estimate() float64
+	// Theta returns the sampling threshold, as a fraction between 0 and 1.
+	Theta() float64
+	// NumRetained returns the number of hashes retained by the sketch.
+	NumRetained() int
+	// Compact returns an immutable, compact copy of the sketch.
+	Compact() *CompactSketch
+
+	seed() uint32
+	thetaLong() uint64
+	forEach(fn func(uint64)) // calls fn for each retained hash below theta
+}
+
+func estimate(count int, theta uint64) float64 {
+	if theta == maxTheta {
+		return float64(count)
+	}
+	return float64(count) / (float64(theta) / maxTheta)
+}
+
+func checkSeeds(a, b Sketch) error {
+	if a.seed() != b.seed() {
+		return fmt.Errorf("cannot combine sketches with different seeds %d and %d", a.seed(), b.seed())
+	}
+	return nil
+}
+
+func hashBytes(p []byte, seed uint32) uint64 {
+	h1, _ := murmur3.Sum128WithSeed(p, seed)
+	return h1 >> 1
+}
+
+func hashUint64(v uint64, seed uint32) uint64 {
+	var buf [8]byte
+	binary.LittleEndian.PutUint64(buf[:], v)
+	return hashBytes(buf[:], seed)
+}
+
+// float64Bits canonicalizes -0 and NaNs like DataSketches.
+func float64Bits(v float64) uint64 {
+	switch {
+	case v == 0:
+		v = 0
+	case v != v:
+		v = math.NaN()
+	}
+	return math.Float64bits(v)
+}
@@ -0,0 +1,114 @@
+package theta_test
+
+import (
+	"math"
+	"testing"
+
+	"github.com/gowthamkommineni/zetasketch/theta"
+
+	. "github.com/bsm/ginkgo"
+	. "github.com/bsm/gomega"
+)
+
+var _ = Describe("UpdateSketch", func() {
+	var subject *theta.UpdateSketch
+
+	BeforeEach(func() {
+		var err error
+		subject, err = theta.NewUpdate(theta.DefaultLgK)
+		Expect(err).NotTo(HaveOccurred())
+	})
+
+	It("should validate lgK", func() {
+		_, err := theta.NewUpdate(3)
+		Expect(err).To(MatchError("invalid lgK 3"))
+		_, err = theta.NewUpdate(27)
+		Expect(err).To(MatchError("invalid lgK 27"))
+	})
+
+	It("should init empty", func() {
+		Expect(subject.IsEmpty()).To(BeTrue())
+		Expect(subject.LgK()).To(Equal(uint8(theta.DefaultLgK)))
+		Expect(subject.Estimate()).To(Equal(0.0))
+		Expect(subject.Theta()).To(Equal(1.0))
+		Expect(subject.NumRetained()).To(Equal(0))
+	})
+
+	It("should count exactly in exact mode", func() {
+		for i := 0; i < 3; i++ {
+			for j := 0; j < 1000; j++ {
+				subject.AddInt64(int64(j))
+			}
+		}
+		subject.AddString("foo")
+		subject.AddBytes([]byte("foo"))
+		subject.AddBytes(nil)
+		subject.AddUint64(1)  // same as int64
+		subject.AddFloat64(0) // same bits as int64 0
+		subject.AddFloat64(math.Copysign(0, -1))
+		subject.AddFloat64(math.NaN())
+
+		Expect(subject.IsEmpty()).To(BeFalse())
+		Expect(subject.Theta()).To(Equal(1.0))
+		Expect(subject.NumRetained()).To(Equal(1002))
+		Expect(subject.Estimate()).To(Equal(1002.0))
+	})
+
+	It("should estimate", func() {
+		for _, n := range []int{10_000, 100_000, 1_000_000} {
+			subject.Reset()
+			for i := 0; i < n; i++ {
+				subject.AddInt64(int64(i))
+			}
+			Expect(subject.Theta()).To(BeNumerically("<", 1))
+			Expect(subject.NumRetained()).To(BeNumerically(">=", 1<<theta.DefaultLgK))
+			Expect(subject.NumRetained()).To(BeNumerically("<=", 3<<theta.DefaultLgK/2))
+			Expect(subject.Estimate()).To(BeNumerically("~", n, float64(n)*0.05), "n=%d", n)
+		}
+	})
+
+	It("should use seeds", func() {
+		seeded, err := theta.NewUpdate(theta.DefaultLgK, theta.WithSeed(1))
+		Expect(err).NotTo(HaveOccurred())
+
+		subject.AddString("foo")
+		seeded.AddString("foo")
+		Expect(subject.Compact()).NotTo(Equal(seeded.Compact()))
+	})
+
+	It("should compact", func() {
+		for i := 0; i < 100_000; i++ {
+			subject.AddInt64(int64(i))
+		}
+
+		c := subject.Compact()
+		Expect(c.IsEmpty()).To(BeFalse())
+		Expect(c.Theta()).To(Equal(subject.Theta()))
+		Expect(c.NumRetained()).To(Equal(subject.NumRetained()))
+		Expect(c.Estimate()).To(Equal(subject.Estimate()))
+		Expect(c.Compact()).To(BeIdenticalTo(c))
+
+		// compact sketches are unaffected by updates
+		subject.AddInt64(-1)
+		subject.Reset()
+		Expect(c.Estimate()).To(BeNumerically("~", 100_000, 5_000))
+	})
+})
+
+func TestSuite(t *testing.T) {
+	RegisterFailHandler(Fail)
+	RunSpecs(t, "zetasketch/theta")
+}
+
+func BenchmarkUpdateSketch_AddInt64(b *testing.B) {
+	s, err := theta.NewUpdate(theta.DefaultLgK)
+	if err != nil {
+		b.Fatal(err)
+	}
+
+	b.ReportAllocs()
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		s.AddInt64(int64(i))
+	}
+}
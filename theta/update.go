@@ -0,0 +1,243 @@
+package theta
+
+import "fmt"
+
+// Option configures optional sketch behaviour.
+type Option func(*UpdateSketch) error
+
+// WithSeed sets the hash seed. Sketches can only be combined with sketches using the same
+// seed.
+func WithSeed(seed uint32) Option {
+	return func(s *UpdateSketch) error {
+		s.hashSeed = seed
+		return nil
+	}
+}
+
+// minLgArr is the log2 of the initial hash table size.
+const minLgArr = 5
+
+// UpdateSketch is a Theta sketch which values can be added to. It retains the hashes of
+// up to 2k distinct values in a hash table. Once full, only the k smallest hashes are
+// kept and theta is lowered to the next one.
+type UpdateSketch struct {
+	lgK      uint8
+	hashSeed uint32
+	empty    bool
+	theta    uint64
+	count    int
+	table    []uint64 // open addressing with linear probing, 0 marks free slots
+}
+
+// NewUpdate inits a new sketch with a nominal number of 2^lgK entries, lgK must be
+// between 4 and 26.
+func NewUpdate(lgK uint8, opts ...Option) (*UpdateSketch, error) {
+	if lgK < MinLgK || lgK > MaxLgK {
+		return nil, fmt.Errorf("invalid lgK %d", lgK)
+	}
+
+	s := &UpdateSketch{
+		lgK:      lgK,
+		hashSeed: DefaultSeed,
+		empty:    true,
+		theta:    maxTheta,
+	}
+	for _, opt := range opts {
+		if err := opt(s); err != nil {
+			return nil, err
+		}
+	}
+
+	lgArr := uint8(minLgArr)
+	if lgK+1 < lgArr {
+		lgArr = lgK + 1
+	}
+	s.table = make([]uint64, 1<<lgArr)
+	return s, nil
+}
+
+// LgK returns the log2 of the nominal number of entries.
+func (s *UpdateSketch) LgK() uint8 {
+	return s.lgK
+}
+
+// IsEmpty implements Sketch.
+func (s *UpdateSketch) IsEmpty() bool {
+	return s.empty
+}
+
+// Estimate implements Sketch.
+func (s *UpdateSketch) Estimate() float64 {
+	return estimate(s.count, s.theta)
+}
+
+// Theta implements Sketch.
+func (s *UpdateSketch) Theta() float64 {
+	return float64(s.theta) / maxTheta
+}
+
+// NumRetained implements Sketch.
+func (s *UpdateSketch) NumRetained() int {
+	return s.count
+}
+
+// Compact implements Sketch.
+func (s *UpdateSketch) Compact() *CompactSketch {
+	hashes := make([]uint64, 0, s.count)
+	s.forEach(func(h uint64) { hashes = append(hashes, h) })
+	return newCompact(s.empty, s.hashSeed, s.theta, hashes)
+}
+
+// Reset resets the sketch to its initial state.
+func (s *UpdateSketch) Reset() {
+	s.empty = true
+	s.theta = maxTheta
+	s.count = 0
+	for i := range s.table {
+		s.table[i] = 0
+	}
+}
+
+// Add adds a pre-computed, uniform 64-bit hash value to the sketch. Like in DataSketches,
+// only the lower 63 bits of the hash are used.
+func (s *UpdateSketch) Add(hash uint64) {
+	s.insert(hash >> 1)
+}
+
+// AddString hashes and adds a string value.
+func (s *UpdateSketch) AddString(v string) {
+	s.AddBytes([]byte(v))
+}
+
+// AddBytes hashes and adds a byte value. Empty values are ignored.
+func (s *UpdateSketch) AddBytes(v []byte) {
+	if len(v) == 0 {
+		return
+	}
+	s.insert(hashBytes(v, s.hashSeed))
+}
+
+// AddInt64 hashes and adds a signed number.
+func (s *UpdateSketch) AddInt64(v int64) {
+	s.insert(hashUint64(uint64(v), s.hashSeed))
+}
+
+// AddUint64 hashes and adds an unsigned number.
+func (s *UpdateSketch) AddUint64(v uint64) {
+	s.insert(hashUint64(v, s.hashSeed))
+}
+
+// AddFloat64 hashes and adds a floating point number. All NaNs are treated as the same
+// value, as are positive and negative zero.
+func (s *UpdateSketch) AddFloat64(v float64) {
+	s.insert(hashUint64(float64Bits(v), s.hashSeed))
+}
+
+func (s *UpdateSketch) seed() uint32      { return s.hashSeed }
+func (s *UpdateSketch) thetaLong() uint64 { return s.theta }
+
+func (s *UpdateSketch) forEach(fn func(uint64)) {
+	for _, h := range s.table {
+		if h != 0 && h < s.theta {
+			fn(h)
+		}
+	}
+}
+
+// insert adds a 63-bit hash.
+func (s *UpdateSketch) insert(h uint64) {
+	s.empty = false
+	if h == 0 || h >= s.theta {
+		return
+	}
+
+	mask := uint64(len(s.table) - 1)
+	i := h & mask
+	for s.table[i] != 0 {
+		if s.table[i] == h {
+			return
+		}
+		i = (i + 1) & mask
+	}
+	s.table[i] = h
+	s.count++
+
+	if s.count > len(s.table)*3/4 {
+		if len(s.table) < 2<<s.lgK {
+			s.resize(len(s.table) * 2)
+		} else {
+			s.rebuild()
+		}
+	}
+}
+
+// resize moves all hashes into a table of the given size.
+func (s *UpdateSketch) resize(size int) {
+	old := s.table
+	s.table = make([]uint64, size)
+	s.count = 0
+	for _, h := range old {
+		if h != 0 && h < s.theta {
+			s.reinsert(h)
+		}
+	}
+}
+
+// rebuild lowers theta to the (k+1)-th smallest hash, retaining the k smallest ones.
+func (s *UpdateSketch) rebuild() {
+	k := 1 << s.lgK
+	hashes := make([]uint64, 0, s.count)
+	s.forEach(func(h uint64) { hashes = append(hashes, h) })
+	s.theta = selectKth(hashes, k)
+
+	for i := range s.table {
+		s.table[i] = 0
+	}
+	s.count = 0
+	for _, h := range hashes[:k] {
+		s.reinsert(h)
+	}
+}
+
+// reinsert adds a hash known to be absent, without checking capacity.
+func (s *UpdateSketch) reinsert(h uint64) {
+	mask := uint64(len(s.table) - 1)
+	i := h & mask
+	for s.table[i] != 0 {
+		i = (i + 1) & mask
+	}
+	s.table[i] = h
+	s.count++
+}
+
+// selectKth partially sorts vs, so that the k smallest values come first, and returns
+// the (k+1)-th smallest.
+func selectKth(vs []uint64, k int) uint64 {
+	lo, hi := 0, len(vs)-1
+	for lo < hi {
+		pivot := vs[lo+(hi-lo)/2]
+		i, j := lo, hi
+		for i <= j {
+			for vs[i] < pivot {
+				i++
+			}
+			for vs[j] > pivot {
+				j--
+			}
+			if i <= j {
+				vs[i], vs[j] = vs[j], vs[i]
+				i++
+				j--
+			}
+		}
+		switch {
+		case k <= j:
+			hi = j
+		case k >= i:
+			lo = i
+		default:
+			return vs[k]
+		}
+	}
+	return vs[k]
+}
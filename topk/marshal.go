@@ -0,0 +1,109 @@
+package topk
+
+import (
+	"fmt"
+
+	"google.golang.org/protobuf/encoding/protowire"
+)
+
+// encodingVersion is the version of the binary format.
+const encodingVersion = 1
+
+// Marshal serializes the sketch into a compact binary format: a version byte, followed
+// by varints k, N and the number of items, then the value, count and error of each item
+// in order of descending count.
+func (s *Sketch) Marshal() ([]byte, error) {
+	items := s.TopK()
+
+	size := 1 + protowire.SizeVarint(uint64(s.k)) + protowire.SizeVarint(s.n) + protowire.SizeVarint(uint64(len(items)))
+	for _, it := range items {
+		size += protowire.SizeBytes(len(it.Value)) + protowire.SizeVarint(it.Count) + protowire.SizeVarint(it.Error)
+	}
+
+	data := make([]byte, 0, size)
+	data = append(data, encodingVersion)
+	data = protowire.AppendVarint(data, uint64(s.k))
+	data = protowire.AppendVarint(data, s.n)
+	data = protowire.AppendVarint(data, uint64(len(items)))
+	for _, it := range items {
+		data = protowire.AppendString(data, it.Value)
+		data = protowire.AppendVarint(data, it.Count)
+		data = protowire.AppendVarint(data, it.Error)
+	}
+	return data, nil
+}
+
+// Unmarshal restores the sketch from data serialized by Marshal, replacing its current
+// state, including k.
+func (s *Sketch) Unmarshal(data []byte) error {
+	if len(data) == 0 {
+		return fmt.Errorf("invalid top-k sketch: too short")
+	}
+	if data[0] != encodingVersion {
+		return fmt.Errorf("invalid top-k sketch: unsupported version %d", data[0])
+	}
+	data = data[1:]
+
+	var fields [3]uint64 // k, n, number of items
+	for i := range fields {
+		v, n := protowire.ConsumeVarint(data)
+		if n < 0 {
+			return fmt.Errorf("invalid top-k sketch: %w", protowire.ParseError(n))
+		}
+		fields[i], data = v, data[n:]
+	}
+	k, total, numItems := fields[0], fields[1], fields[2]
+	// each item takes at least 3 bytes
+	if k < 1 || k > maxK || numItems > k || numItems > uint64(len(data)/3) {
+		return fmt.Errorf("invalid top-k sketch: %d items with k %d", numItems, k)
+	}
+
+	items := make([]Item, 0, numItems)
+	seen := make(map[string]struct{}, numItems)
+	for i := uint64(0); i < numItems; i++ {
+		v, n := protowire.ConsumeString(data)
+		if n < 0 {
+			return fmt.Errorf("invalid top-k sketch: %w", protowire.ParseError(n))
+		}
+		data = data[n:]
+
+		count, n := protowire.ConsumeVarint(data)
+		if n < 0 {
+			return fmt.Errorf("invalid top-k sketch: %w", protowire.ParseError(n))
+		}
+		data = data[n:]
+
+		errCount, n := protowire.ConsumeVarint(data)
+		if n < 0 {
+			return fmt.Errorf("invalid top-k sketch: %w", protowire.ParseError(n))
+		}
+		data = data[n:]
+
+		if errCount > count {
+			return fmt.Errorf("invalid top-k sketch: error %d exceeds count %d", errCount, count)
+		}
+		if _, ok := seen[v]; ok {
+			return fmt.Errorf("invalid top-k sketch: duplicate item %q", v)
+		}
+		seen[v] = struct{}{}
+		items = append(items, Item{Value: v, Count: count, Error: errCount})
+	}
+	if len(data) != 0 {
+		return fmt.Errorf("invalid top-k sketch: %d trailing bytes", len(data))
+	}
+
+	s.k = int(k)
+	s.n = total
+	s.reset(items)
+	return nil
+}
+
+// MarshalBinary implements encoding.BinaryMarshaler using the same format as Marshal.
+func (s *Sketch) MarshalBinary() ([]byte, error) {
+	return s.Marshal()
+}
+
+// UnmarshalBinary implements encoding.BinaryUnmarshaler using the same format as Unmarshal.
+func (s *Sketch) UnmarshalBinary(data []byte) error {
+	return s.Unmarshal(data)
+}
@@ -0,0 +1,58 @@
+package topk_test
+
+import (
+	"github.com/gowthamkommineni/zetasketch/topk"
+
+	. "github.com/bsm/ginkgo"
+	. "github.com/bsm/gomega"
+)
+
+var _ = Describe("Sketch (marshal)", func() {
+	var subject *topk.Sketch
+
+	BeforeEach(func() {
+		var err error
+		subject, err = topk.New(3)
+		Expect(err).NotTo(HaveOccurred())
+	})
+
+	It("should marshal", func() {
+		Expect(subject.Marshal()).To(Equal([]byte{1, 3, 0, 0}))
+
+		subject.AddN("a", 5)
+		subject.AddN("b", 3)
+		subject.AddN("c", 2)
+		subject.Add("d")
+		data, err := subject.Marshal()
+		Expect(err).NotTo(HaveOccurred())
+		Expect(data).To(Equal([]byte{
+			1,        // version
+			3, 11, 3, // k, n, number of items
+			1, 'a', 5, 0,
+			1, 'b', 3, 0,
+			1, 'd', 3, 2,
+		}))
+
+		res, err := topk.New(1)
+		Expect(err).NotTo(HaveOccurred())
+		Expect(res.Unmarshal(data)).To(Succeed())
+		Expect(res.K()).To(Equal(3))
+		Expect(res.N()).To(Equal(uint64(11)))
+		Expect(res.TopK()).To(Equal(subject.TopK()))
+		Expect(res.Marshal()).To(Equal(data))
+
+		// restored sketches continue to replace the minimum counter
+		res.AddN("e", 2)
+		Expect(res.Estimate("e")).To(Equal(topk.Item{Value: "e", Count: 5, Error: 3}))
+	})
+
+	It("should reject invalid data", func() {
+		Expect(subject.Unmarshal(nil)).To(MatchError("invalid top-k sketch: too short"))
+		Expect(subject.Unmarshal([]byte{2})).To(MatchError("invalid top-k sketch: unsupported version 2"))
+		Expect(subject.Unmarshal([]byte{1, 3})).To(MatchError("invalid top-k sketch: unexpected EOF"))
+		Expect(subject.Unmarshal([]byte{1, 1, 5, 2, 1, 'a', 3, 0})).To(MatchError("invalid top-k sketch: 2 items with k 1"))
+		Expect(subject.Unmarshal([]byte{1, 2, 5, 1, 1, 'a', 3, 4})).To(MatchError("invalid top-k sketch: error 4 exceeds count 3"))
+		Expect(subject.Unmarshal([]byte{1, 2, 5, 2, 1, 'a', 3, 0, 1, 'a', 2, 0})).To(MatchError(`invalid top-k sketch: duplicate item "a"`))
+		Expect(subject.Unmarshal([]byte{1, 2, 5, 1, 1, 'a', 3, 0, 0})).To(MatchError("invalid top-k sketch: 1 trailing bytes"))
+	})
+})
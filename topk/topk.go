@@ -0,0 +1,216 @@
+// Package topk implements the SpaceSaving heavy hitters sketch, as described in
+// "Efficient Computation of Frequent and Top-k Elements in Data Streams" (Metwally,
+// Agrawal, El Abbadi, 2005). It tracks the most frequent items of a stream with a fixed
+// number of counters, and supports merging as described in "Mergeable Summaries"
+// (Agarwal et al., 2012).
+package topk
+
+import (
+	"container/heap"
+	"fmt"
+	"sort"
+)
+
+// Item is a tracked item with its estimated count. The true count is between
+// Count-Error and Count.
+type Item struct {
+	Value string
+	Count uint64
+	Error uint64
+}
+
+// LowerBound returns the guaranteed minimum count of the item.
+func (i Item) LowerBound() uint64 {
+	return i.Count - i.Error
+}
+
+// Sketch is a SpaceSaving sketch with a fixed number of counters. Once all counters
+// are in use, a new item replaces the item with the lowest count and inherits its count
+// as error. Any item with a true count above N/k is guaranteed to be tracked.
+type Sketch struct {
+	k     int
+	n     uint64
+	index map[string]*counter
+	heap  counterHeap // min-heap by count
+}
+
+type counter struct {
+	Item
+	pos int // position in the heap
+}
+
+// maxK is the maximum number of counters.
+const maxK = 1 << 24
+
+// New inits a new sketch with k counters, k must be between 1 and 2^24.
+func New(k int) (*Sketch, error) {
+	if k < 1 || k > maxK {
+		return nil, fmt.Errorf("invalid k %d", k)
+	}
+	return &Sketch{
+		k:     k,
+		index: make(map[string]*counter, k),
+		heap:  make(counterHeap, 0, k),
+	}, nil
+}
+
+// K returns the number of counters.
+func (s *Sketch) K() int {
+	return s.k
+}
+
+// N returns the total count of all items added, including items of merged sketches.
+func (s *Sketch) N() uint64 {
+	return s.n
+}
+
+// MaxError returns the maximum error of any count estimate, which is the lowest count
+// tracked once all counters are in use, or 0 otherwise.
+func (s *Sketch) MaxError() uint64 {
+	if len(s.heap) < s.k {
+		return 0
+	}
+	return s.heap[0].Count
+}
+
+// Add adds an item.
+func (s *Sketch) Add(v string) {
+	s.AddN(v, 1)
+}
+
+// AddN adds an item with count n.
+func (s *Sketch) AddN(v string, n uint64) {
+	if n == 0 {
+		return
+	}
+	s.n += n
+	s.update(v, n)
+}
+
+// Estimate returns the estimated count of an item. Untracked items are reported with a
+// count and error of MaxError.
+func (s *Sketch) Estimate(v string) Item {
+	if c, ok := s.index[v]; ok {
+		return c.Item
+	}
+	e := s.MaxError()
+	return Item{Value: v, Count: e, Error: e}
+}
+
+// TopK returns the tracked items, sorted by count in descending order. Ties are broken by
+// the lower bound, then by value.
+func (s *Sketch) TopK() []Item {
+	items := make([]Item, len(s.heap))
+	for i, c := range s.heap {
+		items[i] = c.Item
+	}
+	sort.Slice(items, func(i, j int) bool {
+		a, b := items[i], items[j]
+		if a.Count != b.Count {
+			return a.Count > b.Count
+		}
+		if a.LowerBound() != b.LowerBound() {
+			return a.LowerBound() > b.LowerBound()
+		}
+		return a.Value < b.Value
+	})
+	return items
+}
+
+// Merge merges other into the sketch. Items which are only tracked by one of the
+// sketches are assumed to have the maximum error count in the other one.
+func (s *Sketch) Merge(other *Sketch) {
+	if other == nil || other.n == 0 {
+		return
+	}
+
+	ownMin, otherMin := s.MaxError(), other.MaxError()
+	merged := make([]Item, 0, len(s.heap)+len(other.heap))
+	for _, c := range s.heap {
+		it := c.Item
+		if o, ok := other.index[it.Value]; ok {
+			it.Count += o.Count
+			it.Error += o.Error
+		} else {
+			it.Count += otherMin
+			it.Error += otherMin
+		}
+		merged = append(merged, it)
+	}
+	for _, o := range other.heap {
+		if _, ok := s.index[o.Value]; ok {
+			continue
+		}
+		it := o.Item
+		it.Count += ownMin
+		it.Error += ownMin
+		merged = append(merged, it)
+	}
+
+	// retain the k largest counts
+	sort.Slice(merged, func(i, j int) bool { return merged[i].Count > merged[j].Count })
+	if len(merged) > s.k {
+		merged = merged[:s.k]
+	}
+
+	s.n += other.n
+	s.reset(merged)
+}
+
+// update increments the count of v, replacing the minimum counter if v is not tracked.
+func (s *Sketch) update(v string, n uint64) {
+	if c, ok := s.index[v]; ok {
+		c.Count += n
+		heap.Fix(&s.heap, c.pos)
+		return
+	}
+
+	if len(s.heap) < s.k {
+		c := &counter{Item: Item{Value: v, Count: n}}
+		s.index[v] = c
+		heap.Push(&s.heap, c)
+		return
+	}
+
+	// replace the item with the lowest count
+	c := s.heap[0]
+	delete(s.index, c.Value)
+	c.Item = Item{Value: v, Count: c.Count + n, Error: c.Count}
+	s.index[v] = c
+	heap.Fix(&s.heap, 0)
+}
+
+// reset replaces all counters with items.
+func (s *Sketch) reset(items []Item) {
+	s.index = make(map[string]*counter, len(items))
+	s.heap = s.heap[:0]
+	for _, it := range items {
+		c := &counter{Item: it, pos: len(s.heap)}
+		s.index[it.Value] = c
+		s.heap = append(s.heap, c)
+	}
+	heap.Init(&s.heap)
+}
+
+type counterHeap []*counter
+
+func (h counterHeap) Len() int           { return len(h) }
+func (h counterHeap) Less(i, j int) bool { return h[i].Count < h[j].Count }
+func (h counterHeap) Swap(i, j int) {
+	h[i], h[j] = h[j], h[i]
+	h[i].pos = i
+	h[j].pos = j
+}
+
+func (h *counterHeap) Push(x interface{}) {
+	c := x.(*counter)
+	c.pos = len(*h)
+	*h = append(*h, c)
+}
+
+func (h *counterHeap) Pop() interface{} {
+	old := *h
+	c := old[len(old)-1]
+	*h = old[:len(old)-1]
+	return c
+}
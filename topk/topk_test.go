@@ -0,0 +1,127 @@
+package topk_test
+
+import (
+	"fmt"
+	"math/rand"
+	"testing"
+
+	"github.com/gowthamkommineni/zetasketch/topk"
+
+	. "github.com/bsm/ginkgo"
+	. "github.com/bsm/gomega"
+)
+
+var _ = Describe("Sketch", func() {
+	var subject *topk.Sketch
+
+	BeforeEach(func() {
+		var err error
+		subject, err = topk.New(3)
+		Expect(err).NotTo(HaveOccurred())
+	})
+
+	It("should validate k", func() {
+		_, err := topk.New(0)
+		Expect(err).To(MatchError("invalid k 0"))
+		_, err = topk.New(1<<24 + 1)
+		Expect(err).To(MatchError("invalid k 16777217"))
+	})
+
+	It("should count exactly while counters are available", func() {
+		subject.Add("a")
+		subject.AddN("b", 3)
+		subject.Add("a")
+		subject.AddN("c", 0)
+
+		Expect(subject.K()).To(Equal(3))
+		Expect(subject.N()).To(Equal(uint64(5)))
+		Expect(subject.MaxError()).To(Equal(uint64(0)))
+		Expect(subject.TopK()).To(Equal([]topk.Item{
+			{Value: "b", Count: 3},
+			{Value: "a", Count: 2},
+		}))
+		Expect(subject.Estimate("a")).To(Equal(topk.Item{Value: "a", Count: 2}))
+		Expect(subject.Estimate("x")).To(Equal(topk.Item{Value: "x"}))
+	})
+
+	It("should replace the minimum counter", func() {
+		subject.AddN("a", 5)
+		subject.AddN("b", 3)
+		subject.AddN("c", 2)
+		subject.Add("d")
+
+		Expect(subject.N()).To(Equal(uint64(11)))
+		Expect(subject.MaxError()).To(Equal(uint64(3)))
+		Expect(subject.TopK()).To(Equal([]topk.Item{
+			{Value: "a", Count: 5},
+			{Value: "b", Count: 3},
+			{Value: "d", Count: 3, Error: 2},
+		}))
+		Expect(subject.Estimate("d").LowerBound()).To(Equal(uint64(1)))
+		Expect(subject.Estimate("c")).To(Equal(topk.Item{Value: "c", Count: 3, Error: 3}))
+	})
+
+	It("should find heavy hitters", func() {
+		s, err := topk.New(50)
+		Expect(err).NotTo(HaveOccurred())
+
+		// item i occurs with a frequency proportional to 1/(i+1)
+		rnd := rand.New(rand.NewSource(1))
+		zipf := rand.NewZipf(rnd, 1.2, 1, 9_999)
+		for i := 0; i < 100_000; i++ {
+			s.Add(fmt.Sprint(zipf.Uint64()))
+		}
+
+		top := s.TopK()
+		Expect(top).To(HaveLen(50))
+		for i, want := range []string{"0", "1", "2", "3", "4"} {
+			Expect(top[i].Value).To(Equal(want))
+			Expect(top[i].Error).To(BeNumerically("<=", s.MaxError()))
+		}
+		Expect(s.MaxError()).To(BeNumerically("<=", s.N()/50))
+	})
+
+	It("should merge", func() {
+		other, err := topk.New(3)
+		Expect(err).NotTo(HaveOccurred())
+
+		subject.AddN("a", 10)
+		subject.AddN("b", 5)
+		subject.AddN("c", 2)
+		other.AddN("a", 4)
+		other.AddN("d", 6)
+		other.AddN("e", 1)
+
+		subject.Merge(other)
+		subject.Merge(nil)
+		Expect(subject.N()).To(Equal(uint64(28)))
+		Expect(subject.TopK()).To(Equal([]topk.Item{
+			{Value: "a", Count: 14},
+			{Value: "d", Count: 8, Error: 2},
+			{Value: "b", Count: 6, Error: 1},
+		}))
+	})
+})
+
+func TestSuite(t *testing.T) {
+	RegisterFailHandler(Fail)
+	RunSpecs(t, "zetasketch/topk")
+}
+
+func BenchmarkSketch_Add(b *testing.B) {
+	s, err := topk.New(100)
+	if err != nil {
+		b.Fatal(err)
+	}
+
+	values := make([]string, 1000)
+	for i := range values {
+		values[i] = fmt.Sprint(i)
+	}
+
+	b.ReportAllocs()
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		s.Add(values[i%len(values)])
+	}
+}
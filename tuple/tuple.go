@@ -0,0 +1,231 @@
+// Package tuple implements Tuple sketches, which extend Theta sketches with a summary per
+// retained key, following the design of the Apache DataSketches Tuple sketch. They count
+// distinct keys while aggregating values per key, e.g. to estimate the number of distinct
+// users together with their total revenue, or the number of users with more than a given
+// number of purchases.
+//
+// Keys are hashed like in the theta package, so sketches sample the same keys as Theta
+// sketches built with the same seed.
+package tuple
+
+import (
+	"encoding/binary"
+	"fmt"
+	"math"
+	"sort"
+
+	"github.com/spaolacci/murmur3"
+)
+
+// Parameter limits and defaults, matching the theta package.
+const (
+	DefaultLgK  = 12
+	MinLgK      = 4
+	MaxLgK      = 26
+	DefaultSeed = 9001
+)
+
+// maxTheta is the initial threshold, hashes are limited to 63 bits.
+const maxTheta = math.MaxInt64
+
+// Option configures optional sketch behaviour.
+type Option func(*config) error
+
+type config struct {
+	seed uint32
+}
+
+// WithSeed sets the hash seed. Sketches can only be merged with sketches using the same
+// seed.
+func WithSeed(seed uint32) Option {
+	return func(c *config) error {
+		c.seed = seed
+		return nil
+	}
+}
+
+// Sketch is a Tuple sketch with summaries of type S. It retains the hashes of up to 2k
+// distinct keys with their summaries; once full, only the k smallest hashes are kept and
+// theta is lowered to the next one.
+type Sketch[S any] struct {
+	lgK     uint8
+	seed    uint32
+	combine func(S, S) S
+	theta   uint64
+	entries map[uint64]S
+}
+
+// Entry is a retained key hash with its summary.
+type Entry[S any] struct {
+	Hash    uint64
+	Summary S
+}
+
+// New inits a new sketch with a nominal number of 2^lgK entries, lgK must be between 4
+// and 26. Combine aggregates the summaries of the same key, it is called with the
+// existing summary first.
+func New[S any](lgK uint8, combine func(S, S) S, opts ...Option) (*Sketch[S], error) {
+	if lgK < MinLgK || lgK > MaxLgK {
+		return nil, fmt.Errorf("invalid lgK %d", lgK)
+	}
+	if combine == nil {
+		return nil, fmt.Errorf("invalid combine function")
+	}
+
+	c := config{seed: DefaultSeed}
+	for _, opt := range opts {
+		if err := opt(&c); err != nil {
+			return nil, err
+		}
+	}
+	return &Sketch[S]{
+		lgK:     lgK,
+		seed:    c.seed,
+		combine: combine,
+		theta:   maxTheta,
+		entries: make(map[uint64]S),
+	}, nil
+}
+
+// LgK returns the log2 of the nominal number of entries.
+func (s *Sketch[S]) LgK() uint8 {
+	return s.lgK
+}
+
+// Theta returns the sampling threshold, as a fraction between 0 and 1.
+func (s *Sketch[S]) Theta() float64 {
+	return float64(s.theta) / maxTheta
+}
+
+// NumRetained returns the number of keys retained by the sketch.
+func (s *Sketch[S]) NumRetained() int {
+	return len(s.entries)
+}
+
+// Estimate returns the estimated number of distinct keys.
+func (s *Sketch[S]) Estimate() float64 {
+	return float64(len(s.entries)) / s.Theta()
+}
+
+// EstimateWhere returns the estimated number of distinct keys whose summary satisfies
+// the predicate.
+func (s *Sketch[S]) EstimateWhere(pred func(S) bool) float64 {
+	var n int
+	for _, v := range s.entries {
+		if pred(v) {
+			n++
+		}
+	}
+	return float64(n) / s.Theta()
+}
+
+// EstimateSum returns the estimated sum of fn over the summaries of all distinct keys,
+// e.g. the total revenue of all users.
+func (s *Sketch[S]) EstimateSum(fn func(S) float64) float64 {
+	var sum float64
+	for _, v := range s.entries {
+		sum += fn(v)
+	}
+	return sum / s.Theta()
+}
+
+// Entries returns the retained entries, in ascending order of hashes.
+func (s *Sketch[S]) Entries() []Entry[S] {
+	res := make([]Entry[S], 0, len(s.entries))
+	for h, v := range s.entries {
+		res = append(res, Entry[S]{Hash: h, Summary: v})
+	}
+	sort.Slice(res, func(i, j int) bool { return res[i].Hash < res[j].Hash })
+	return res
+}
+
+// Add adds a pre-computed, uniform 64-bit key hash with a value. Like in the theta
+// package, only the lower 63 bits of the hash are used.
+func (s *Sketch[S]) Add(hash uint64, v S) {
+	s.insert(hash>>1, v)
+}
+
+// AddString hashes a string key and adds it with a value.
+func (s *Sketch[S]) AddString(key string, v S) {
+	s.AddBytes([]byte(key), v)
+}
+
+// AddBytes hashes a byte key and adds it with a value. Empty keys are ignored.
+func (s *Sketch[S]) AddBytes(key []byte, v S) {
+	if len(key) == 0 {
+		return
+	}
+	s.insert(hashBytes(key, s.seed), v)
+}
+
+// AddInt64 hashes a signed number key and adds it with a value.
+func (s *Sketch[S]) AddInt64(key int64, v S) {
+	s.AddUint64(uint64(key), v)
+}
+
+// AddUint64 hashes an unsigned number key and adds it with a value.
+func (s *Sketch[S]) AddUint64(key uint64, v S) {
+	var buf [8]byte
+	binary.LittleEndian.PutUint64(buf[:], key)
+	s.insert(hashBytes(buf[:], s.seed), v)
+}
+
+// Merge merges other into the sketch, combining the summaries of keys retained by both.
+// Both sketches must use the same seed.
+func (s *Sketch[S]) Merge(other *Sketch[S]) error {
+	if s.seed != other.seed {
+		return fmt.Errorf("cannot merge sketches with different seeds %d and %d", s.seed, other.seed)
+	}
+
+	if other.theta < s.theta {
+		s.theta = other.theta
+		for h := range s.entries {
+			if h >= s.theta {
+				delete(s.entries, h)
+			}
+		}
+	}
+	for h, v := range other.entries {
+		s.insert(h, v)
+	}
+	s.trim(1 << s.lgK)
+	return nil
+}
+
+// insert adds a 63-bit hash with a value.
+func (s *Sketch[S]) insert(h uint64, v S) {
+	if h == 0 || h >= s.theta {
+		return
+	}
+
+	if old, ok := s.entries[h]; ok {
+		s.entries[h] = s.combine(old, v)
+		return
+	}
+	s.entries[h] = v
+	s.trim(2 << s.lgK)
+}
+
+// trim lowers theta to retain the k smallest hashes, once more than limit are retained.
+func (s *Sketch[S]) trim(limit int) {
+	k := 1 << s.lgK
+	if len(s.entries) <= limit || len(s.entries) <= k {
+		return
+	}
+
+	hashes := make([]uint64, 0, len(s.entries))
+	for h := range s.entries {
+		hashes = append(hashes, h)
+	}
+	sort.Slice(hashes, func(i, j int) bool { return hashes[i] < hashes[j] })
+
+	s.theta = hashes[k]
+	for _, h := range hashes[k:] {
+		delete(s.entries, h)
+	}
+}
+
+func hashBytes(p []byte, seed uint32) uint64 {
+	h1, _ := murmur3.Sum128WithSeed(p, seed)
+	return h1 >> 1
+}
@@ -0,0 +1,112 @@
+package tuple_test
+
+import (
+	"testing"
+
+	"github.com/gowthamkommineni/zetasketch/theta"
+	"github.com/gowthamkommineni/zetasketch/tuple"
+
+	. "github.com/bsm/ginkgo"
+	. "github.com/bsm/gomega"
+)
+
+var _ = Describe("Sketch", func() {
+	sum := func(a, b float64) float64 { return a + b }
+	identity := func(v float64) float64 { return v }
+
+	newSketch := func(opts ...tuple.Option) *tuple.Sketch[float64] {
+		s, err := tuple.New(tuple.DefaultLgK, sum, opts...)
+		Expect(err).NotTo(HaveOccurred())
+		return s
+	}
+
+	It("should validate", func() {
+		_, err := tuple.New(3, sum)
+		Expect(err).To(MatchError("invalid lgK 3"))
+		_, err = tuple.New[float64](tuple.DefaultLgK, nil)
+		Expect(err).To(MatchError("invalid combine function"))
+	})
+
+	It("should aggregate exactly in exact mode", func() {
+		s := newSketch()
+		s.AddString("alice", 10)
+		s.AddString("bob", 5)
+		s.AddBytes([]byte("alice"), 2.5)
+		s.AddBytes(nil, 100)
+		s.AddInt64(1, 1)
+		s.AddUint64(1, 1)
+
+		Expect(s.LgK()).To(Equal(uint8(tuple.DefaultLgK)))
+		Expect(s.Theta()).To(Equal(1.0))
+		Expect(s.NumRetained()).To(Equal(3))
+		Expect(s.Estimate()).To(Equal(3.0))
+		Expect(s.EstimateSum(identity)).To(Equal(19.5))
+		Expect(s.EstimateWhere(func(v float64) bool { return v > 3 })).To(Equal(2.0))
+
+		entries := s.Entries()
+		Expect(entries).To(HaveLen(3))
+		Expect(entries[0].Hash).To(BeNumerically("<", entries[1].Hash))
+	})
+
+	It("should estimate", func() {
+		s := newSketch()
+		for i := 0; i < 1_000_000; i++ {
+			user := int64(i % 100_000)
+			s.AddInt64(user, float64(user%10)) // users buy ten times
+		}
+
+		Expect(s.Theta()).To(BeNumerically("<", 1))
+		Expect(s.NumRetained()).To(BeNumerically("<=", 2<<tuple.DefaultLgK))
+		Expect(s.Estimate()).To(BeNumerically("~", 100_000, 5_000))
+		Expect(s.EstimateSum(identity)).To(BeNumerically("~", 4_500_000, 250_000))
+		Expect(s.EstimateWhere(func(v float64) bool { return v >= 50 })).To(BeNumerically("~", 50_000, 3_000))
+	})
+
+	It("should estimate like theta sketches", func() {
+		s := newSketch()
+		th, err := theta.NewUpdate(tuple.DefaultLgK)
+		Expect(err).NotTo(HaveOccurred())
+		for i := 0; i < 100_000; i++ {
+			s.AddInt64(int64(i), 1)
+			th.AddInt64(int64(i))
+		}
+		Expect(s.Estimate()).To(BeNumerically("~", th.Estimate(), th.Estimate()*0.05))
+	})
+
+	It("should merge", func() {
+		a, b := newSketch(), newSketch()
+		for i := 0; i < 100_000; i++ {
+			a.AddInt64(int64(i), 1)
+			b.AddInt64(int64(i+50_000), 2)
+		}
+
+		Expect(a.Merge(b)).To(Succeed())
+		Expect(a.NumRetained()).To(Equal(1 << tuple.DefaultLgK))
+		Expect(a.Estimate()).To(BeNumerically("~", 150_000, 7_500))
+		Expect(a.EstimateSum(identity)).To(BeNumerically("~", 300_000, 15_000))
+		Expect(a.EstimateWhere(func(v float64) bool { return v == 3 })).To(BeNumerically("~", 50_000, 5_000))
+
+		seeded := newSketch(tuple.WithSeed(1))
+		Expect(a.Merge(seeded)).To(MatchError("cannot merge sketches with different seeds 9001 and 1"))
+	})
+
+	It("should support any summary", func() {
+		type stats struct{ count, max int }
+		s, err := tuple.New(tuple.DefaultLgK, func(a, b stats) stats {
+			if b.max > a.max {
+				a.max = b.max
+			}
+			return stats{count: a.count + b.count, max: a.max}
+		})
+		Expect(err).NotTo(HaveOccurred())
+
+		s.AddString("alice", stats{count: 1, max: 3})
+		s.AddString("alice", stats{count: 1, max: 7})
+		Expect(s.Entries()[0].Summary).To(Equal(stats{count: 2, max: 7}))
+	})
+})
+
+func TestSuite(t *testing.T) {
+	RegisterFailHandler(Fail)
+	RunSpecs(t, "zetasketch/tuple")
+}